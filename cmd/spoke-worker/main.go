@@ -0,0 +1,99 @@
+// Command spoke-worker runs queued compile jobs. It shares the storage and
+// compile queue of the Spoke servers, configured with the same environment
+// variables, so compilation scales apart from serving requests.
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled"
+	"github.com/platinummonkey/spoke/pkg/codegen/docker"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"github.com/platinummonkey/spoke/pkg/codegen/queue"
+	"github.com/platinummonkey/spoke/pkg/config"
+	"github.com/platinummonkey/spoke/pkg/observability"
+	"github.com/platinummonkey/spoke/pkg/storage"
+	"github.com/platinummonkey/spoke/pkg/storage/postgres"
+)
+
+func main() {
+	// Started as a host process of the host compile runner, this becomes
+	// protoc under its limits and does not return
+	docker.RunHostProcess()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	logger := observability.NewLogger(cfg.Observability.LogLevel, os.Stdout)
+
+	var store api.Storage
+	var compileQueue queue.Store
+	switch cfg.Storage.Type {
+	case "filesystem":
+		store, err = storage.NewFileSystemStorage(cfg.Storage.FilesystemRoot)
+		if err != nil {
+			log.Fatalf("Failed to initialize filesystem storage: %v", err)
+		}
+		compileQueue, err = queue.NewSQLiteStore(filepath.Join(cfg.Storage.FilesystemRoot, "compile_jobs.db"))
+		if err != nil {
+			log.Fatalf("Failed to initialize compile queue: %v", err)
+		}
+
+	case "postgres", "hybrid":
+		pgStore, err := postgres.NewPostgresStorage(cfg.Storage)
+		if err != nil {
+			log.Fatalf("Failed to initialize PostgreSQL storage: %v", err)
+		}
+		store = pgStore
+		compileQueue = queue.NewPostgresStore(pgStore.GetDB())
+
+	default:
+		log.Fatalf("Unknown storage type: %s", cfg.Storage.Type)
+	}
+	defer compileQueue.Close()
+
+	// Jobs finishing together for different languages of a version must not
+	// overwrite each other's compiled code
+	if _, ok := store.(api.CompilationUpdater); !ok {
+		log.Fatalf("Storage type %s cannot store compiled code, compile workers are not supported with it", cfg.Storage.Type)
+	}
+
+	orchConfig := orchestrator.DefaultConfig()
+	orchConfig.Runner = orchestrator.RunnerType(cfg.Codegen.Runner)
+	orchConfig.RedisAddr = cfg.Codegen.CacheRedisAddr
+	orchConfig.RedisPassword = cfg.Codegen.CacheRedisPassword
+	orchConfig.RedisDB = cfg.Codegen.CacheRedisDB
+	if cfg.LocalArtifacts() {
+		orchConfig.StorageDir = cfg.Codegen.ArtifactsDir
+	}
+	orchConfig.ArtifactsBaseURL = cfg.Codegen.ArtifactsBaseURL
+	orchConfig.ArtifactsSigningKey = cfg.Codegen.ArtifactsSigningKey
+	compiler, err := orchestrator.NewOrchestrator(orchConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize code generation: %v", err)
+	}
+	defer compiler.Close()
+
+	// Workers default to the server's setting; a dedicated worker with none
+	// would do nothing
+	poolConfig := queue.DefaultPoolConfig()
+	if cfg.Codegen.Workers > 0 {
+		poolConfig.Workers = cfg.Codegen.Workers
+	}
+	pool := queue.NewPool(compileQueue, compiled.NewJobRunner(store, compiler), poolConfig)
+	pool.Start()
+	logger.Infof("Spoke worker started with %d workers", poolConfig.Workers)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	<-sigChan
+
+	logger.Info("Shutting down, returning running jobs to the queue")
+	pool.Stop()
+}
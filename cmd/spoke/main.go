@@ -6,16 +6,27 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
 	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen/artifacts"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled"
+	"github.com/platinummonkey/spoke/pkg/codegen/docker"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"github.com/platinummonkey/spoke/pkg/codegen/queue"
 	"github.com/platinummonkey/spoke/pkg/config"
+	"github.com/platinummonkey/spoke/pkg/contracts"
 	"github.com/platinummonkey/spoke/pkg/dependencies"
 	"github.com/platinummonkey/spoke/pkg/docs"
+	"github.com/platinummonkey/spoke/pkg/goproxy"
+	"github.com/platinummonkey/spoke/pkg/mavenrepo"
+	"github.com/platinummonkey/spoke/pkg/npmregistry"
 	"github.com/platinummonkey/spoke/pkg/observability"
+	"github.com/platinummonkey/spoke/pkg/pypiindex"
 	"github.com/platinummonkey/spoke/pkg/search"
 	"github.com/platinummonkey/spoke/pkg/storage"
 	"github.com/platinummonkey/spoke/pkg/storage/postgres"
@@ -23,6 +34,10 @@ import (
 )
 
 func main() {
+	// Started as a host process of the host compile runner, this becomes
+	// protoc under its limits and does not return
+	docker.RunHostProcess()
+
 	// Load configuration from environment
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -107,7 +122,22 @@ func main() {
 	server.RegisterRoutes(searchHandlers)
 	logger.Info("Search routes registered")
 
+	// Consumer usage contracts live next to the module data
+	var contractStore contracts.Store
+	if pgStore, ok := store.(*postgres.PostgresStorage); ok {
+		contractStore = contracts.NewPostgresStore(pgStore.GetDB())
+	} else {
+		contractStore, err = contracts.NewFileStore(cfg.Storage.FilesystemRoot)
+		if err != nil {
+			log.Fatalf("Failed to initialize contract storage: %v", err)
+		}
+	}
+	server.SetContractStore(contractStore)
+	server.RegisterRoutes(contracts.NewHandlers(contractStore))
+	logger.Info("Consumer contract routes registered")
+
 	depHandlers := dependencies.NewDependencyHandlers(store)
+	depHandlers.SetContractStore(contractStore)
 	server.RegisterRoutes(depHandlers)
 	logger.Info("Dependency routes registered")
 
@@ -116,6 +146,116 @@ func main() {
 	server.RegisterRoutes(swaggerHandlers)
 	logger.Info("OpenAPI/Swagger documentation routes registered")
 
+	// Queue compile requests next to the module data, for workers in this
+	// server and in spoke-worker processes
+	var compileQueue queue.Store
+	if pgStore, ok := store.(*postgres.PostgresStorage); ok {
+		compileQueue = queue.NewPostgresStore(pgStore.GetDB())
+	} else {
+		compileQueue, err = queue.NewSQLiteStore(filepath.Join(cfg.Storage.FilesystemRoot, "compile_jobs.db"))
+		if err != nil {
+			log.Fatalf("Failed to initialize compile queue: %v", err)
+		}
+	}
+	server.SetCompileQueue(compileQueue)
+	logger.Info("Compile queue initialized")
+
+	// Serve generated code to package managers and run queued compile jobs.
+	// Without an orchestrator only versions already compiled can be
+	// downloaded.
+	var codegenOrchestrator *orchestrator.DefaultOrchestrator
+	var compiler orchestrator.Orchestrator
+	if cfg.Codegen.Workers > 0 || cfg.Codegen.GoProxyModulePrefix != "" || cfg.Codegen.NPMScope != "" || cfg.Codegen.PyPIEnabled || cfg.Codegen.MavenGroupID != "" {
+		orchConfig := orchestrator.DefaultConfig()
+		orchConfig.Runner = orchestrator.RunnerType(cfg.Codegen.Runner)
+		orchConfig.RedisAddr = cfg.Codegen.CacheRedisAddr
+		orchConfig.RedisPassword = cfg.Codegen.CacheRedisPassword
+		orchConfig.RedisDB = cfg.Codegen.CacheRedisDB
+		if cfg.LocalArtifacts() {
+			orchConfig.StorageDir = cfg.Codegen.ArtifactsDir
+		}
+		orchConfig.ArtifactsBaseURL = cfg.Codegen.ArtifactsBaseURL
+		orchConfig.ArtifactsSigningKey = cfg.Codegen.ArtifactsSigningKey
+		codegenOrchestrator, err = orchestrator.NewOrchestrator(orchConfig)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to initialize code generation, serving compiled versions only and leaving compile jobs to spoke-worker")
+		} else {
+			compiler = codegenOrchestrator
+		}
+	}
+
+	var compilePool *queue.Pool
+	if compiler != nil && cfg.Codegen.Workers > 0 {
+		if _, ok := store.(api.CompilationUpdater); !ok {
+			log.Fatalf("Storage type %s cannot store compiled code, set SPOKE_CODEGEN_WORKERS=0", cfg.Storage.Type)
+		}
+		poolConfig := queue.DefaultPoolConfig()
+		poolConfig.Workers = cfg.Codegen.Workers
+		compilePool = queue.NewPool(compileQueue, compiled.NewJobRunner(store, compiler), poolConfig)
+		compilePool.Start()
+	}
+
+	// Serve downloads of compiled artifacts stored locally
+	if cfg.LocalArtifacts() {
+		artifactsConfig := artifacts.DefaultConfig()
+		artifactsConfig.LocalDir = cfg.Codegen.ArtifactsDir
+		artifactsConfig.BaseURL = cfg.Codegen.ArtifactsBaseURL
+		artifactsConfig.URLSigningKey = cfg.Codegen.ArtifactsSigningKey
+		artifactsManager, err := artifacts.NewFileManager(artifactsConfig)
+		if err != nil {
+			log.Fatalf("Invalid artifacts configuration: %v", err)
+		}
+		server.RegisterRoutes(artifactsManager)
+		logger.Infof("Artifact download routes registered for %s", artifactsConfig.LocalDir)
+	}
+
+	if cfg.Codegen.GoProxyModulePrefix != "" {
+		proxyConfig := goproxy.DefaultConfig(cfg.Codegen.GoProxyModulePrefix)
+		proxyConfig.IncludeGRPC = cfg.Codegen.GoProxyIncludeGRPC
+		if err := proxyConfig.Validate(); err != nil {
+			log.Fatalf("Invalid Go module proxy configuration: %v", err)
+		}
+		server.RegisterRoutes(goproxy.NewHandlers(store, compiler, proxyConfig))
+		logger.Infof("Go module proxy routes registered for %s", proxyConfig.ModulePrefix)
+	}
+
+	if cfg.Codegen.NPMScope != "" {
+		npmConfig := npmregistry.DefaultConfig(cfg.Codegen.NPMScope)
+		npmConfig.NameSuffix = cfg.Codegen.NPMNameSuffix
+		npmConfig.Language = api.Language(cfg.Codegen.NPMLanguage)
+		npmConfig.IncludeGRPC = cfg.Codegen.NPMIncludeGRPC
+		npmConfig.BaseURL = cfg.Codegen.NPMBaseURL
+		if err := npmConfig.Validate(); err != nil {
+			log.Fatalf("Invalid npm registry configuration: %v", err)
+		}
+		server.RegisterRoutes(npmregistry.NewHandlers(store, compiler, npmConfig))
+		logger.Infof("npm registry routes registered for @%s", npmConfig.Scope)
+	}
+
+	if cfg.Codegen.PyPIEnabled {
+		pypiConfig := pypiindex.DefaultConfig()
+		pypiConfig.NamePrefix = cfg.Codegen.PyPINamePrefix
+		pypiConfig.NameSuffix = cfg.Codegen.PyPINameSuffix
+		pypiConfig.IncludeGRPC = cfg.Codegen.PyPIIncludeGRPC
+		if err := pypiConfig.Validate(); err != nil {
+			log.Fatalf("Invalid Python package index configuration: %v", err)
+		}
+		server.RegisterRoutes(pypiindex.NewHandlers(store, compiler, pypiConfig))
+		logger.Info("Python package index routes registered")
+	}
+
+	if cfg.Codegen.MavenGroupID != "" {
+		mavenConfig := mavenrepo.DefaultConfig(cfg.Codegen.MavenGroupID)
+		mavenConfig.ArtifactSuffix = cfg.Codegen.MavenArtifactSuffix
+		mavenConfig.Language = api.Language(cfg.Codegen.MavenLanguage)
+		mavenConfig.IncludeGRPC = cfg.Codegen.MavenIncludeGRPC
+		if err := mavenConfig.Validate(); err != nil {
+			log.Fatalf("Invalid Maven repository configuration: %v", err)
+		}
+		server.RegisterRoutes(mavenrepo.NewHandlers(store, compiler, mavenConfig))
+		logger.Infof("Maven repository routes registered for %s", mavenConfig.GroupID)
+	}
+
 	// Wrap with OpenTelemetry HTTP instrumentation
 	var handler http.Handler = server
 	if cfg.Observability.OTelEnabled {
@@ -175,6 +315,20 @@ func main() {
 		return healthServer.Shutdown(ctx)
 	})
 
+	shutdownManager.RegisterShutdownFunc(func(ctx context.Context) error {
+		logger.Info("Shutting down code generation")
+		// Running jobs return to the queue before their compiler goes away
+		if compilePool != nil {
+			compilePool.Stop()
+		}
+		if codegenOrchestrator != nil {
+			if err := codegenOrchestrator.Close(); err != nil {
+				return err
+			}
+		}
+		return compileQueue.Close()
+	})
+
 	if otelProviders != nil {
 		shutdownManager.RegisterShutdownFunc(func(ctx context.Context) error {
 			logger.Info("Shutting down OpenTelemetry")
@@ -0,0 +1,4 @@
+-- Migration 012 Rollback: Drop Consumer Usage Contracts
+
+DROP INDEX IF EXISTS idx_consumer_contracts_module;
+DROP TABLE IF EXISTS consumer_contracts;
@@ -0,0 +1,15 @@
+-- Migration 012: Consumer Usage Contracts
+-- Records which schema elements of a module version each consumer uses
+
+CREATE TABLE IF NOT EXISTS consumer_contracts (
+    id BIGSERIAL PRIMARY KEY,
+    module_name VARCHAR(255) NOT NULL REFERENCES modules(name) ON DELETE CASCADE,
+    consumer VARCHAR(255) NOT NULL,
+    version VARCHAR(100) NOT NULL,
+    source VARCHAR(50), -- scan, declared
+    uses JSONB NOT NULL DEFAULT '[]',
+    registered_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_consumer_contract UNIQUE (module_name, consumer)
+);
+
+CREATE INDEX IF NOT EXISTS idx_consumer_contracts_module ON consumer_contracts(module_name);
@@ -0,0 +1,4 @@
+-- Migration 013 Rollback: Drop Version Quality Scores
+
+DROP INDEX IF EXISTS idx_versions_quality_score;
+ALTER TABLE versions DROP COLUMN IF EXISTS quality;
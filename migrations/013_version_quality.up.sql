@@ -0,0 +1,6 @@
+-- Migration 013: Version Quality Scores
+-- Stores the weighted lint quality of each module version
+
+ALTER TABLE versions ADD COLUMN IF NOT EXISTS quality JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_versions_quality_score ON versions (((quality->>'score')::NUMERIC));
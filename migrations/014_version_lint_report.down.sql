@@ -0,0 +1,4 @@
+-- Migration 014 Rollback: Drop Version Lint Reports
+
+DROP INDEX IF EXISTS idx_versions_lint_passed;
+ALTER TABLE versions DROP COLUMN IF EXISTS lint_report;
@@ -0,0 +1,7 @@
+-- Migration 014: Version Lint Reports
+-- Stores the governance lint and validation report of each module version.
+-- Module governance policies are kept in modules.metadata.
+
+ALTER TABLE versions ADD COLUMN IF NOT EXISTS lint_report JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_versions_lint_passed ON versions (((lint_report->>'passed')::BOOLEAN));
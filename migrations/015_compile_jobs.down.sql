@@ -0,0 +1,6 @@
+-- Migration 015 Rollback: Drop Compile Jobs
+
+DROP INDEX IF EXISTS idx_compile_jobs_version;
+DROP INDEX IF EXISTS idx_compile_jobs_dedup;
+DROP INDEX IF EXISTS idx_compile_jobs_ready;
+DROP TABLE IF EXISTS compile_jobs;
@@ -0,0 +1,34 @@
+-- Migration 015: Compile Jobs
+-- Durable queue of compile jobs, kept as the compile history of each version
+
+CREATE TABLE IF NOT EXISTS compile_jobs (
+    id VARCHAR(36) PRIMARY KEY,
+    module_name VARCHAR(255) NOT NULL REFERENCES modules(name) ON DELETE CASCADE,
+    version VARCHAR(100) NOT NULL,
+    language VARCHAR(50) NOT NULL,
+    include_grpc BOOLEAN NOT NULL DEFAULT FALSE,
+    options JSONB NOT NULL DEFAULT '{}',
+    priority INTEGER NOT NULL DEFAULT 0,
+    dedup_key TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL, -- pending, running, completed, failed, cancelled
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    error_message TEXT NOT NULL DEFAULT '',
+    cache_hit BOOLEAN NOT NULL DEFAULT FALSE,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    worker_id VARCHAR(255) NOT NULL DEFAULT '',
+    cancel_requested BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    lease_expires_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_compile_jobs_ready ON compile_jobs(status, priority DESC, created_at);
+-- Jobs waiting for their first run are unique by dedup key, so identical
+-- requests to several replicas queue one job. Retried jobs have started and
+-- do not count.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_compile_jobs_dedup ON compile_jobs(dedup_key)
+    WHERE status = 'pending' AND started_at IS NULL AND dedup_key <> '';
+CREATE INDEX IF NOT EXISTS idx_compile_jobs_version ON compile_jobs(module_name, version, created_at DESC);
@@ -0,0 +1,4 @@
+-- Migration 016 Rollback: Drop Compile Job Logs
+
+DROP INDEX IF EXISTS idx_compile_job_logs_job;
+DROP TABLE IF EXISTS compile_job_logs;
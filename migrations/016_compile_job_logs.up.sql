@@ -0,0 +1,11 @@
+-- Migration 016: Compile Job Logs
+-- Output of protoc and its plugins for each compile job, kept after it finishes
+
+CREATE TABLE IF NOT EXISTS compile_job_logs (
+    seq BIGSERIAL PRIMARY KEY,
+    job_id VARCHAR(36) NOT NULL REFERENCES compile_jobs(id) ON DELETE CASCADE,
+    data TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_compile_job_logs_job ON compile_job_logs(job_id, seq);
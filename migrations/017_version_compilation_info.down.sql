@@ -0,0 +1,3 @@
+-- Migration 017 Rollback: Drop Version Compilation Info
+
+ALTER TABLE versions DROP COLUMN IF EXISTS compilation_info;
@@ -0,0 +1,5 @@
+-- Migration 017: Version Compilation Info
+-- Stores the compiled packages of each module version, one entry per
+-- language and package name, updated in place as compile jobs finish.
+
+ALTER TABLE versions ADD COLUMN IF NOT EXISTS compilation_info JSONB NOT NULL DEFAULT '[]'::jsonb;
@@ -27,6 +27,7 @@ type ModuleHealth struct {
 	DeprecatedFieldCount int      `json:"deprecated_field_count"`
 	BreakingChanges30d   int      `json:"breaking_changes_30d"`
 	DependentsCount      int      `json:"dependents_count"`
+	QualityScore         *float64 `json:"quality_score,omitempty"` // Lint quality 0-100 (higher is better), nil when not scored
 	Recommendations      []string `json:"recommendations"`
 }
 
@@ -73,6 +74,12 @@ func (h *HealthScorer) CalculateHealth(ctx context.Context, moduleName, version
 		return nil, err
 	}
 
+	// Get the lint quality score stored with the version
+	health.QualityScore, err = h.getQualityScore(ctx, versionID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Calculate maintainability index
 	health.MaintainabilityIndex = h.calculateMaintainability(health)
 
@@ -252,6 +259,25 @@ func (h *HealthScorer) countDependents(ctx context.Context, moduleName, version
 	return count, err
 }
 
+// getQualityScore retrieves the lint quality score of a version, nil when
+// the version was not scored
+func (h *HealthScorer) getQualityScore(ctx context.Context, versionID int64) (*float64, error) {
+	query := `
+		SELECT (quality->>'score')::FLOAT8
+		FROM versions
+		WHERE id = $1
+	`
+
+	var score sql.NullFloat64
+	if err := h.db.QueryRowContext(ctx, query, versionID).Scan(&score); err != nil {
+		return nil, err
+	}
+	if !score.Valid {
+		return nil, nil
+	}
+	return &score.Float64, nil
+}
+
 // calculateMaintainability computes maintainability index (0-100, higher is better)
 func (h *HealthScorer) calculateMaintainability(health *ModuleHealth) float64 {
 	score := 100.0
@@ -304,6 +330,11 @@ func (h *HealthScorer) calculateOverallHealth(health *ModuleHealth) float64 {
 		weights["deprecated"]*deprecatedScore +
 		weights["breaking"]*breakingScore
 
+	// Lint quality, when known, accounts for a fifth of the score
+	if health.QualityScore != nil {
+		score = 0.8*score + 0.2**health.QualityScore
+	}
+
 	return math.Round(score*10) / 10
 }
 
@@ -336,6 +367,11 @@ func (h *HealthScorer) generateRecommendations(health *ModuleHealth) []string {
 			"This module has many dependents. Breaking changes require careful coordination.")
 	}
 
+	if health.QualityScore != nil && *health.QualityScore < 60 {
+		recommendations = append(recommendations,
+			"Lint quality is low. Run spoke lint to document declarations, simplify large messages and fix violations.")
+	}
+
 	if health.HealthScore > 80 {
 		recommendations = append(recommendations,
 			"Schema health is excellent! Keep following protobuf best practices.")
@@ -67,6 +67,11 @@ func TestCalculateHealth(t *testing.T) {
 		WithArgs("test-module@v1.0.0").
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
 
+	// Mock getQualityScore
+	mock.ExpectQuery("SELECT (.+)quality(.+)FROM versions WHERE id").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"score"}).AddRow(72.5))
+
 	// Execute
 	health, err := scorer.CalculateHealth(context.Background(), "test-module", "v1.0.0")
 	if err != nil {
@@ -95,6 +100,9 @@ func TestCalculateHealth(t *testing.T) {
 	if health.DependentsCount != 5 {
 		t.Errorf("Expected 5 dependents, got %d", health.DependentsCount)
 	}
+	if health.QualityScore == nil || *health.QualityScore != 72.5 {
+		t.Errorf("Expected quality score 72.5, got %v", health.QualityScore)
+	}
 	if health.MaintainabilityIndex == 0 {
 		t.Error("Expected non-zero maintainability index")
 	}
@@ -512,6 +520,17 @@ func TestCalculateOverallHealth(t *testing.T) {
 			expectedMin: 0,
 			expectedMax: 50,
 		},
+		{
+			name: "excellent health with poor lint quality",
+			health: &ModuleHealth{
+				ComplexityScore:      10.0,
+				MaintainabilityIndex: 95.0,
+				UnusedFields:         []string{},
+				QualityScore:         floatPtr(0),
+			},
+			expectedMin: 70,
+			expectedMax: 80,
+		},
 	}
 
 	for _, tt := range tests {
@@ -632,6 +651,16 @@ func TestGenerateRecommendations(t *testing.T) {
 			expectedContains: []string{"needs attention"},
 			expectedMinCount: 1,
 		},
+		{
+			name: "low lint quality",
+			health: &ModuleHealth{
+				UnusedFields: []string{},
+				QualityScore: floatPtr(40.0),
+				HealthScore:  70.0,
+			},
+			expectedContains: []string{"Lint quality is low"},
+			expectedMinCount: 1,
+		},
 	}
 
 	for _, tt := range tests {
@@ -785,6 +814,35 @@ func TestCalculateHealth_ErrorPaths(t *testing.T) {
 			},
 			expectedError: true,
 		},
+		{
+			name: "getQualityScore error",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT v.id(.+)FROM versions v(.+)").
+					WithArgs("test-module", "v1.0.0").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery("SELECT(.+)FROM proto_search_index(.+)WHERE version_id").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{
+						"message_count", "enum_count", "service_count", "field_count", "method_count",
+					}).AddRow(10, 5, 2, 50, 8))
+				mock.ExpectQuery("SELECT DISTINCT psi.entity_name(.+)FROM proto_search_index psi(.+)").
+					WithArgs("test-module", "v1.0.0").
+					WillReturnRows(sqlmock.NewRows([]string{"entity_name"}))
+				mock.ExpectQuery("SELECT COUNT(.+)FROM proto_search_index(.+)WHERE version_id").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+				mock.ExpectQuery("SELECT COUNT(.+)FROM versions v(.+)WHERE m.name").
+					WithArgs("test-module").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectQuery("SELECT COUNT(.+)FROM versions v(.+)WHERE v.dependencies").
+					WithArgs("test-module@v1.0.0").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+				mock.ExpectQuery("SELECT (.+)quality(.+)FROM versions WHERE id").
+					WithArgs(1).
+					WillReturnError(sql.ErrConnDone)
+			},
+			expectedError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -882,3 +940,7 @@ func containsSubstring(s, substr string) bool {
 			return false
 		}())
 }
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
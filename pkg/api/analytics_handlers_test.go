@@ -429,6 +429,7 @@ func TestGetModuleHealth_Success(t *testing.T) {
 	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"score"}).AddRow(85.0))
 
 	service := analytics.NewService(db)
 	handlers := NewAnalyticsHandlers(service)
@@ -442,6 +443,7 @@ func TestGetModuleHealth_Success(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"quality_score":85`)
 
 	mock.ExpectClose()
 }
@@ -459,6 +461,7 @@ func TestGetModuleHealth_DefaultVersion(t *testing.T) {
 	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"score"}).AddRow(nil))
 
 	service := analytics.NewService(db)
 	handlers := NewAnalyticsHandlers(service)
@@ -4,14 +4,15 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
-	"github.com/platinummonkey/spoke/pkg/api/protobuf"
 	"github.com/platinummonkey/spoke/pkg/compatibility"
+	"github.com/platinummonkey/spoke/pkg/contracts"
 	"github.com/platinummonkey/spoke/pkg/httputil"
 )
 
 // CompatibilityHandlers handles compatibility checking HTTP requests
 type CompatibilityHandlers struct {
-	storage Storage
+	storage   Storage
+	contracts contracts.Store
 }
 
 // NewCompatibilityHandlers creates a new compatibility handlers instance
@@ -21,6 +22,24 @@ func NewCompatibilityHandlers(storage Storage) *CompatibilityHandlers {
 	}
 }
 
+// SetContractStore enables reporting of the registered consumers a change breaks
+func (h *CompatibilityHandlers) SetContractStore(store contracts.Store) {
+	h.contracts = store
+}
+
+// brokenConsumers returns the registered consumers broken by a check, or nil
+// when no contract store is configured
+func (h *CompatibilityHandlers) brokenConsumers(r *http.Request, moduleName string, result *compatibility.CheckResult) ([]contracts.Breakage, error) {
+	if h.contracts == nil {
+		return nil, nil
+	}
+	registered, err := h.contracts.List(r.Context(), moduleName)
+	if err != nil {
+		return nil, err
+	}
+	return contracts.Breakages(result, registered), nil
+}
+
 // RegisterRoutes registers compatibility routes
 func (h *CompatibilityHandlers) RegisterRoutes(router *mux.Router) {
 	// Check compatibility between two versions
@@ -91,35 +110,26 @@ func (h *CompatibilityHandlers) checkCompatibility(w http.ResponseWriter, r *htt
 		return
 	}
 
-	// Parse old schema
-	oldAST, err := protobuf.ParseString(oldVer.Files[0].Content)
+	// Build schema graphs from all files of each version
+	oldSchema, err := compatibility.ParseSchemaFiles(compatibility.SourceFiles(oldVer.Files))
 	if err != nil {
 		httputil.WriteInternalError(w, err)
 		return
 	}
-
-	// Parse new schema
-	newAST, err := protobuf.ParseString(newVer.Files[0].Content)
+	newSchema, err := compatibility.ParseSchemaFiles(compatibility.SourceFiles(newVer.Files))
 	if err != nil {
 		httputil.WriteInternalError(w, err)
 		return
 	}
 
-	// Build schema graphs
-	builder := compatibility.NewSchemaGraphBuilder()
-	oldSchema, err := builder.BuildFromAST(oldAST)
-	if err != nil {
-		httputil.WriteInternalError(w, err)
-		return
-	}
-	newSchema, err := builder.BuildFromAST(newAST)
+	// Compare schemas
+	result, err := compatibility.CheckCompatibility(oldSchema, newSchema, mode)
 	if err != nil {
 		httputil.WriteInternalError(w, err)
 		return
 	}
 
-	// Compare schemas
-	result, err := compatibility.CheckCompatibility(oldSchema, newSchema, mode)
+	broken, err := h.brokenConsumers(r, moduleName, result)
 	if err != nil {
 		httputil.WriteInternalError(w, err)
 		return
@@ -127,19 +137,21 @@ func (h *CompatibilityHandlers) checkCompatibility(w http.ResponseWriter, r *htt
 
 	// Return result
 	response := struct {
-		Compatible   bool                        `json:"compatible"`
-		Mode         string                      `json:"mode"`
-		Violations   []compatibility.Violation   `json:"violations"`
-		ErrorCount   int                         `json:"error_count"`
-		WarningCount int                         `json:"warning_count"`
-		InfoCount    int                         `json:"info_count"`
+		Compatible      bool                      `json:"compatible"`
+		Mode            string                    `json:"mode"`
+		Violations      []compatibility.Violation `json:"violations"`
+		ErrorCount      int                       `json:"error_count"`
+		WarningCount    int                       `json:"warning_count"`
+		InfoCount       int                       `json:"info_count"`
+		BrokenConsumers []contracts.Breakage      `json:"broken_consumers,omitempty"`
 	}{
-		Compatible:   result.Compatible,
-		Mode:         result.Mode,
-		Violations:   result.Violations,
-		ErrorCount:   result.Summary.Errors,
-		WarningCount: result.Summary.Warnings,
-		InfoCount:    result.Summary.Infos,
+		Compatible:      result.Compatible,
+		Mode:            result.Mode,
+		Violations:      result.Violations,
+		ErrorCount:      result.Summary.Errors,
+		WarningCount:    result.Summary.Warnings,
+		InfoCount:       result.Summary.Infos,
+		BrokenConsumers: broken,
 	}
 
 	// Set appropriate status code and return result
@@ -220,35 +232,26 @@ func (h *CompatibilityHandlers) checkVersionCompatibility(w http.ResponseWriter,
 		return
 	}
 
-	// Parse old schema
-	oldAST, err := protobuf.ParseString(oldVer.Files[0].Content)
+	// Build schema graphs from all files of each version
+	oldSchema, err := compatibility.ParseSchemaFiles(compatibility.SourceFiles(oldVer.Files))
 	if err != nil {
 		httputil.WriteInternalError(w, err)
 		return
 	}
-
-	// Parse new schema
-	newAST, err := protobuf.ParseString(newVer.Files[0].Content)
+	newSchema, err := compatibility.ParseSchemaFiles(compatibility.SourceFiles(newVer.Files))
 	if err != nil {
 		httputil.WriteInternalError(w, err)
 		return
 	}
 
-	// Build schema graphs
-	builder := compatibility.NewSchemaGraphBuilder()
-	oldSchema, err := builder.BuildFromAST(oldAST)
-	if err != nil {
-		httputil.WriteInternalError(w, err)
-		return
-	}
-	newSchema, err := builder.BuildFromAST(newAST)
+	// Compare schemas
+	result, err := compatibility.CheckCompatibility(oldSchema, newSchema, mode)
 	if err != nil {
 		httputil.WriteInternalError(w, err)
 		return
 	}
 
-	// Compare schemas
-	result, err := compatibility.CheckCompatibility(oldSchema, newSchema, mode)
+	broken, err := h.brokenConsumers(r, moduleName, result)
 	if err != nil {
 		httputil.WriteInternalError(w, err)
 		return
@@ -256,23 +259,25 @@ func (h *CompatibilityHandlers) checkVersionCompatibility(w http.ResponseWriter,
 
 	// Return result
 	response := struct {
-		Compatible     bool                      `json:"compatible"`
-		Mode           string                    `json:"mode"`
-		OldVersion     string                    `json:"old_version"`
-		NewVersion     string                    `json:"new_version"`
-		Violations     []compatibility.Violation `json:"violations"`
-		ErrorCount     int                       `json:"error_count"`
-		WarningCount   int                       `json:"warning_count"`
-		InfoCount      int                       `json:"info_count"`
+		Compatible      bool                      `json:"compatible"`
+		Mode            string                    `json:"mode"`
+		OldVersion      string                    `json:"old_version"`
+		NewVersion      string                    `json:"new_version"`
+		Violations      []compatibility.Violation `json:"violations"`
+		ErrorCount      int                       `json:"error_count"`
+		WarningCount    int                       `json:"warning_count"`
+		InfoCount       int                       `json:"info_count"`
+		BrokenConsumers []contracts.Breakage      `json:"broken_consumers,omitempty"`
 	}{
-		Compatible:   result.Compatible,
-		Mode:         result.Mode,
-		OldVersion:   oldVer.Version,
-		NewVersion:   version,
-		Violations:   result.Violations,
-		ErrorCount:   result.Summary.Errors,
-		WarningCount: result.Summary.Warnings,
-		InfoCount:    result.Summary.Infos,
+		Compatible:      result.Compatible,
+		Mode:            result.Mode,
+		OldVersion:      oldVer.Version,
+		NewVersion:      version,
+		Violations:      result.Violations,
+		ErrorCount:      result.Summary.Errors,
+		WarningCount:    result.Summary.Warnings,
+		InfoCount:       result.Summary.Infos,
+		BrokenConsumers: broken,
 	}
 
 	// Set appropriate status code and return result
@@ -2,14 +2,28 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/cache"
+	"github.com/platinummonkey/spoke/pkg/codegen/queue"
 	"github.com/platinummonkey/spoke/pkg/httputil"
 )
 
+const (
+	logPollInterval = 500 * time.Millisecond // How often log streams check for output
+	logKeepAlive    = 15 * time.Second       // Longest a log stream stays silent
+)
+
+// eventLineBreaks normalizes the line breaks of event data; each line is a
+// data field of its own
+var eventLineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
 
 // compileWithGenerator compiles a version using the simplified code generator
 func (s *Server) compileWithGenerator(version *Version, language Language) (CompilationInfo, error) {
@@ -123,6 +137,11 @@ func (s *Server) compileVersion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.compileQueue != nil {
+		s.enqueueCompilation(w, r, version, req)
+		return
+	}
+
 	// Create generation request
 	genReq := &codegen.GenerateRequest{
 		ModuleName:  moduleName,
@@ -161,14 +180,223 @@ func (s *Server) compileVersion(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteSuccess(w, response)
 }
 
+// enqueueCompilation queues a compile job per language for workers to run,
+// responding before they do
+func (s *Server) enqueueCompilation(w http.ResponseWriter, r *http.Request, version *Version, req CompileRequest) {
+	// Jobs compiling the same files with the same options are merged; the
+	// cache key identifies them as it identifies their results
+	protoFiles := s.convertFilesToProtoFiles(version.Files)
+	dependencies := make([]codegen.Dependency, 0, len(version.Dependencies))
+	for _, dep := range version.Dependencies {
+		name, v, ok := strings.Cut(dep, "@")
+		if !ok {
+			continue
+		}
+		dependencies = append(dependencies, codegen.Dependency{ModuleName: name, Version: v})
+	}
+	keyOptions := map[string]string{"include_grpc": strconv.FormatBool(req.IncludeGRPC)}
+	for k, v := range req.Options {
+		keyOptions[k] = v
+	}
+
+	jobInfos := make([]CompilationJobInfo, 0, len(req.Languages))
+	for _, language := range req.Languages {
+		key := cache.GenerateCacheKey(version.ModuleName, version.Version, language, "", protoFiles, dependencies, keyOptions)
+		job, _, err := s.compileQueue.Enqueue(r.Context(), &queue.Job{
+			ModuleName:  version.ModuleName,
+			Version:     version.Version,
+			Language:    language,
+			IncludeGRPC: req.IncludeGRPC,
+			Options:     req.Options,
+			Priority:    req.Priority,
+			DedupKey:    cache.FormatCacheKey(key),
+		})
+		if err != nil {
+			httputil.WriteInternalError(w, fmt.Errorf("failed to queue compilation: %w", err))
+			return
+		}
+		jobInfos = append(jobInfos, jobInfo(job))
+	}
+
+	httputil.WriteJSON(w, http.StatusAccepted, CompileResponse{
+		JobID:   fmt.Sprintf("%s-%s", version.ModuleName, version.Version),
+		Results: jobInfos,
+	})
+}
+
 // getCompilationJob returns the status of a compilation job
 func (s *Server) getCompilationJob(w http.ResponseWriter, r *http.Request) {
 	vars := httputil.GetPathVars(r)
 	jobID := vars["jobId"]
 
-	// Since we no longer track jobs asynchronously, return a simple completed status
-	// In the new simplified model, compilations happen synchronously
-	httputil.WriteNotFoundError(w, fmt.Sprintf("Job tracking not supported in simplified model. Job ID: %s", jobID))
+	if s.compileQueue == nil {
+		// Without a queue compilations happen synchronously and are not tracked
+		httputil.WriteNotFoundError(w, fmt.Sprintf("Job tracking not supported in simplified model. Job ID: %s", jobID))
+		return
+	}
+
+	job, ok := s.findCompilationJob(w, r)
+	if !ok {
+		return
+	}
+	httputil.WriteSuccess(w, jobInfo(job))
+}
+
+// listCompilationJobs returns the compilation history of a version, most
+// recent first
+func (s *Server) listCompilationJobs(w http.ResponseWriter, r *http.Request) {
+	vars := httputil.GetPathVars(r)
+
+	if s.compileQueue == nil {
+		httputil.WriteNotFoundError(w, "Job tracking not supported in simplified model")
+		return
+	}
+
+	limit, err := httputil.ParseQueryInt(r, "limit", 100)
+	if err != nil || limit <= 0 {
+		httputil.WriteBadRequest(w, "Invalid limit")
+		return
+	}
+	jobs, err := s.compileQueue.List(r.Context(), queue.Filter{
+		ModuleName: vars["name"],
+		Version:    vars["version"],
+		Status:     codegen.JobStatus(httputil.ParseQueryString(r, "status", "")),
+		Limit:      limit,
+	})
+	if err != nil {
+		httputil.WriteInternalError(w, err)
+		return
+	}
+
+	jobInfos := make([]CompilationJobInfo, len(jobs))
+	for i, job := range jobs {
+		jobInfos[i] = jobInfo(job)
+	}
+	httputil.WriteSuccess(w, jobInfos)
+}
+
+// cancelCompilationJob cancels a queued or running compilation job
+func (s *Server) cancelCompilationJob(w http.ResponseWriter, r *http.Request) {
+	if s.compileQueue == nil {
+		httputil.WriteNotFoundError(w, "Job tracking not supported in simplified model")
+		return
+	}
+
+	job, ok := s.findCompilationJob(w, r)
+	if !ok {
+		return
+	}
+	job, err := s.compileQueue.Cancel(r.Context(), job.ID)
+	if errors.Is(err, queue.ErrJobFinished) {
+		httputil.WriteConflict(w, fmt.Sprintf("Job %s already %s", job.ID, job.Status))
+		return
+	} else if err != nil {
+		httputil.WriteInternalError(w, err)
+		return
+	}
+	httputil.WriteSuccess(w, jobInfo(job))
+}
+
+// streamCompilationLogs streams the output of a job as server-sent events
+// until it finishes. Each chunk of output is a "log" event numbered by its
+// event ID, so clients reconnecting with Last-Event-ID resume after the
+// chunks they received. The stream ends with a "status" event holding the
+// finished job. Logs are kept, so finished jobs replay theirs.
+func (s *Server) streamCompilationLogs(w http.ResponseWriter, r *http.Request) {
+	if s.compileQueue == nil {
+		httputil.WriteNotFoundError(w, "Job tracking not supported in simplified model")
+		return
+	}
+
+	job, ok := s.findCompilationJob(w, r)
+	if !ok {
+		return
+	}
+	var after int64
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		var err error
+		after, err = strconv.ParseInt(lastID, 10, 64)
+		if err != nil || after < 0 {
+			httputil.WriteBadRequest(w, "Invalid Last-Event-ID")
+			return
+		}
+	}
+
+	// Compilations outlast the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	ctx := r.Context()
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+	lastWrite := time.Now()
+	for {
+		// Workers store the whole log before finishing a job, so reading
+		// the log after seeing it finished reads all of it
+		finished := job.Finished()
+		chunks, err := s.compileQueue.Logs(ctx, job.ID, after)
+		if err != nil {
+			if ctx.Err() == nil {
+				writeEvent(w, "error", "", err.Error())
+				_ = rc.Flush()
+			}
+			return
+		}
+		for _, chunk := range chunks {
+			writeEvent(w, "log", strconv.FormatInt(chunk.Seq, 10), chunk.Data)
+			after = chunk.Seq
+		}
+		if finished {
+			data, _ := json.Marshal(jobInfo(job))
+			writeEvent(w, "status", "", string(data))
+			_ = rc.Flush()
+			return
+		}
+
+		if len(chunks) > 0 {
+			lastWrite = time.Now()
+		} else if time.Since(lastWrite) >= logKeepAlive {
+			// Comments keep proxies from closing idle streams
+			fmt.Fprint(w, ": keep-alive\n\n")
+			lastWrite = time.Now()
+		}
+		_ = rc.Flush()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		job, err = s.compileQueue.Get(ctx, job.ID)
+		if err != nil {
+			if ctx.Err() == nil {
+				writeEvent(w, "error", "", err.Error())
+				_ = rc.Flush()
+			}
+			return
+		}
+	}
+}
+
+// findCompilationJob returns the queued job of a request, writing an error
+// when it is not a job of the version requested
+func (s *Server) findCompilationJob(w http.ResponseWriter, r *http.Request) (*queue.Job, bool) {
+	vars := httputil.GetPathVars(r)
+	job, err := s.compileQueue.Get(r.Context(), vars["jobId"])
+	if errors.Is(err, queue.ErrJobNotFound) || (err == nil && (job.ModuleName != vars["name"] || job.Version != vars["version"])) {
+		httputil.WriteNotFoundError(w, fmt.Sprintf("Job not found: %s", vars["jobId"]))
+		return nil, false
+	} else if err != nil {
+		httputil.WriteInternalError(w, err)
+		return nil, false
+	}
+	return job, true
 }
 
 // Helper functions
@@ -184,6 +412,39 @@ func (s *Server) convertFilesToProtoFiles(files []File) []codegen.ProtoFile {
 	return protoFiles
 }
 
+// jobInfo describes a queued compilation job
+func jobInfo(job *queue.Job) CompilationJobInfo {
+	createdAt := job.CreatedAt
+	return CompilationJobInfo{
+		ID:          job.ID,
+		Language:    job.Language,
+		Status:      string(job.Status),
+		Attempts:    job.Attempts,
+		Priority:    job.Priority,
+		CreatedAt:   &createdAt,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+		Duration:    job.Duration.Milliseconds(),
+		CacheHit:    job.CacheHit,
+		Error:       job.Error,
+	}
+}
+
+// writeEvent writes a server-sent event, one data field per line of data
+func writeEvent(w http.ResponseWriter, event, id, data string) {
+	var b strings.Builder
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	fmt.Fprintf(&b, "event: %s\n", event)
+	data = eventLineBreaks.Replace(strings.TrimSuffix(data, "\n"))
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	_, _ = w.Write([]byte(b.String()))
+}
+
 func getStatusFromResult(result *codegen.CompilationResult) string {
 	if result.Success {
 		return "completed"
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newQueueTestServer(t *testing.T) (*Server, *queue.SQLStore) {
+	t.Helper()
+	mockStore := newMockStorage()
+	require.NoError(t, mockStore.CreateModule(&Module{Name: "users"}))
+	require.NoError(t, mockStore.CreateVersion(&Version{
+		ModuleName: "users",
+		Version:    "v1.0.0",
+		Files:      []File{{Path: "users.proto", Content: "syntax = \"proto3\";"}},
+	}))
+
+	store, err := queue.NewSQLiteStore(filepath.Join(t.TempDir(), "jobs.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	server := NewServer(mockStore, nil)
+	server.SetCompileQueue(store)
+	return server, store
+}
+
+func serve(server *Server, method, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w
+}
+
+func TestCompileVersion_Queued(t *testing.T) {
+	server, store := newQueueTestServer(t)
+
+	w := serve(server, "POST", "/api/v1/modules/users/versions/v1.0.0/compile",
+		`{"languages": ["go", "python"], "include_grpc": true, "priority": 5}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var resp CompileResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "users-v1.0.0", resp.JobID)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, "pending", resp.Results[0].Status)
+	assert.Equal(t, 5, resp.Results[0].Priority)
+
+	job, err := store.Get(context.Background(), resp.Results[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "go", job.Language)
+	assert.True(t, job.IncludeGRPC)
+	assert.NotEmpty(t, job.DedupKey)
+
+	// Identical requests are merged with the pending jobs; others are not
+	w = serve(server, "POST", "/api/v1/modules/users/versions/v1.0.0/compile",
+		`{"languages": ["go"], "include_grpc": true}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var again CompileResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &again))
+	assert.Equal(t, resp.Results[0].ID, again.Results[0].ID)
+
+	w = serve(server, "POST", "/api/v1/modules/users/versions/v1.0.0/compile", `{"languages": ["go"]}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &again))
+	assert.NotEqual(t, resp.Results[0].ID, again.Results[0].ID)
+
+	w = serve(server, "POST", "/api/v1/modules/users/versions/v9.9.9/compile", `{"languages": ["go"]}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCompilationJobs(t *testing.T) {
+	server, store := newQueueTestServer(t)
+	ctx := context.Background()
+
+	pending, _, err := store.Enqueue(ctx, &queue.Job{ModuleName: "users", Version: "v1.0.0", Language: "go"})
+	require.NoError(t, err)
+	running, _, err := store.Enqueue(ctx, &queue.Job{ModuleName: "users", Version: "v1.0.0", Language: "python", Priority: 1})
+	require.NoError(t, err)
+	_, err = store.Claim(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+
+	w := serve(server, "GET", "/api/v1/modules/users/versions/v1.0.0/compile/"+running.ID, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var info CompilationJobInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, "running", info.Status)
+	assert.Equal(t, 1, info.Attempts)
+	assert.NotNil(t, info.StartedAt)
+
+	// Jobs belong to their version
+	w = serve(server, "GET", "/api/v1/modules/users/versions/v2.0.0/compile/"+running.ID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve(server, "GET", "/api/v1/modules/users/versions/v1.0.0/compile/missing", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serve(server, "DELETE", "/api/v1/modules/users/versions/v1.0.0/compile/"+pending.ID, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, "cancelled", info.Status)
+	w = serve(server, "DELETE", "/api/v1/modules/users/versions/v1.0.0/compile/"+pending.ID, "")
+	assert.Equal(t, http.StatusOK, w.Code, "cancelling again is harmless")
+
+	w = serve(server, "DELETE", "/api/v1/modules/users/versions/v1.0.0/compile/"+running.ID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	job, err := store.Get(ctx, running.ID)
+	require.NoError(t, err)
+	assert.True(t, job.CancelRequested)
+
+	var jobs []CompilationJobInfo
+	w = serve(server, "GET", "/api/v1/modules/users/versions/v1.0.0/compile", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &jobs))
+	assert.Len(t, jobs, 2)
+
+	w = serve(server, "GET", "/api/v1/modules/users/versions/v1.0.0/compile?status=cancelled", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &jobs))
+	require.Len(t, jobs, 1)
+	assert.Equal(t, pending.ID, jobs[0].ID)
+
+	w = serve(server, "GET", "/api/v1/modules/users/versions/v1.0.0/compile?limit=zero", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCompilationJobs_WithoutQueue(t *testing.T) {
+	server := NewServer(newMockStorage(), nil)
+
+	w := serve(server, "GET", "/api/v1/modules/users/versions/v1.0.0/compile/users-v1.0.0-go", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve(server, "GET", "/api/v1/modules/users/versions/v1.0.0/compile", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve(server, "DELETE", "/api/v1/modules/users/versions/v1.0.0/compile/users-v1.0.0-go", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve(server, "GET", "/api/v1/modules/users/versions/v1.0.0/compile/users-v1.0.0-go/logs", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestStreamCompilationLogs_Finished(t *testing.T) {
+	server, store := newQueueTestServer(t)
+	ctx := context.Background()
+
+	job, _, err := store.Enqueue(ctx, &queue.Job{ModuleName: "users", Version: "v1.0.0", Language: "go"})
+	require.NoError(t, err)
+	job, err = store.Claim(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, store.AppendLog(ctx, job.ID, "Attempt 1 of 3 on w1\n"))
+	require.NoError(t, store.AppendLog(ctx, job.ID, "users.proto:3:1: syntax error\r\nFailed: protoc failed\n"))
+	job.Status = codegen.JobStatusFailed
+	job.Error = "protoc failed"
+	require.NoError(t, store.Finish(ctx, job))
+
+	target := "/api/v1/modules/users/versions/v1.0.0/compile/" + job.ID + "/logs"
+	w := serve(server, "GET", target, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+	chunks, err := store.Logs(ctx, job.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	body := w.Body.String()
+	assert.Contains(t, body, fmt.Sprintf("id: %d\nevent: log\ndata: Attempt 1 of 3 on w1\n\n", chunks[0].Seq))
+	assert.Contains(t, body, fmt.Sprintf("id: %d\nevent: log\ndata: users.proto:3:1: syntax error\ndata: Failed: protoc failed\n\n", chunks[1].Seq))
+	require.Contains(t, body, "event: status\ndata: ")
+	status := strings.TrimSpace(body[strings.Index(body, "event: status\ndata: ")+len("event: status\ndata: "):])
+	var info CompilationJobInfo
+	require.NoError(t, json.Unmarshal([]byte(status), &info))
+	assert.Equal(t, "failed", info.Status)
+	assert.Equal(t, "protoc failed", info.Error)
+
+	// Reconnecting clients resume after the last event they received
+	r := httptest.NewRequest("GET", target, nil)
+	r.Header.Set("Last-Event-ID", strconv.FormatInt(chunks[0].Seq, 10))
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "Attempt 1")
+	assert.Contains(t, w.Body.String(), "syntax error")
+
+	r.Header.Set("Last-Event-ID", "latest")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(server, "GET", "/api/v1/modules/users/versions/v2.0.0/compile/"+job.ID+"/logs", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestStreamCompilationLogs_Live(t *testing.T) {
+	server, store := newQueueTestServer(t)
+	ctx := context.Background()
+
+	job, _, err := store.Enqueue(ctx, &queue.Job{ModuleName: "users", Version: "v1.0.0", Language: "go"})
+	require.NoError(t, err)
+	job, err = store.Claim(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, store.AppendLog(ctx, job.ID, "Attempt 1 of 3 on w1\n"))
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + "/api/v1/modules/users/versions/v1.0.0/compile/" + job.ID + "/logs")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	lines := bufio.NewScanner(resp.Body)
+	readUntil := func(want string) {
+		t.Helper()
+		for lines.Scan() {
+			if lines.Text() == want {
+				return
+			}
+		}
+		t.Fatalf("stream ended before %q: %v", want, lines.Err())
+	}
+
+	// Output written while the job runs streams as it is stored
+	readUntil("data: Attempt 1 of 3 on w1")
+	require.NoError(t, store.AppendLog(ctx, job.ID, "Completed in 1s\n"))
+	readUntil("data: Completed in 1s")
+
+	job.Status = codegen.JobStatusCompleted
+	require.NoError(t, store.Finish(ctx, job))
+	readUntil("event: status")
+	require.True(t, lines.Scan())
+	assert.Contains(t, lines.Text(), `"status":"completed"`)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/platinummonkey/spoke/pkg/compatibility"
 	"github.com/platinummonkey/spoke/pkg/httputil"
 )
 
@@ -11,6 +12,20 @@ import (
 type DiffRequest struct {
 	FromVersion string `json:"from_version"`
 	ToVersion   string `json:"to_version"`
+	Mode        string `json:"mode,omitempty"` // Compatibility mode used to grade changes (default BACKWARD)
+}
+
+// DiffResponse is the structural diff between two versions, graded by the
+// compatibility engine
+type DiffResponse struct {
+	FromVersion string                    `json:"from_version"`
+	ToVersion   string                    `json:"to_version"`
+	Mode        string                    `json:"mode"`
+	Compatible  bool                      `json:"compatible"`
+	Changes     []compatibility.DiffEntry `json:"changes"`
+	Breaking    int                       `json:"breaking_count"`
+	Warnings    int                       `json:"warning_count"`
+	NonBreaking int                       `json:"non_breaking_count"`
 }
 
 // compareDiff compares two versions and returns the differences
@@ -24,6 +39,16 @@ func (s *Server) compareDiff(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	mode := compatibility.CompatibilityModeBackward
+	if req.Mode != "" {
+		parsed, err := compatibility.ParseCompatibilityMode(req.Mode)
+		if err != nil {
+			httputil.WriteBadRequest(w, "invalid compatibility mode")
+			return
+		}
+		mode = parsed
+	}
+
 	// Get both versions
 	fromVer, err := s.storage.GetVersion(moduleName, req.FromVersion)
 	if err != nil {
@@ -37,22 +62,40 @@ func (s *Server) compareDiff(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// TODO: Implement actual diff analysis
-	// For now, return a placeholder
-	placeholder := map[string]interface{}{
-		"from_version": req.FromVersion,
-		"to_version":   req.ToVersion,
-		"changes": []map[string]interface{}{
-			{
-				"type":        "placeholder",
-				"severity":    "non_breaking",
-				"location":    "example.proto",
-				"description": "Schema diff analysis coming soon!",
-			},
-		},
-		"_note": fmt.Sprintf("Comparing %d files in v%s with %d files in v%s",
-			len(fromVer.Files), req.FromVersion, len(toVer.Files), req.ToVersion),
-	}
-
-	httputil.WriteSuccess(w, placeholder)
+	oldSchema, err := compatibility.ParseSchemaFiles(compatibility.SourceFiles(fromVer.Files))
+	if err != nil {
+		httputil.WriteErrorMessage(w, http.StatusUnprocessableEntity, fmt.Sprintf("failed to parse version %s: %v", req.FromVersion, err))
+		return
+	}
+	newSchema, err := compatibility.ParseSchemaFiles(compatibility.SourceFiles(toVer.Files))
+	if err != nil {
+		httputil.WriteErrorMessage(w, http.StatusUnprocessableEntity, fmt.Sprintf("failed to parse version %s: %v", req.ToVersion, err))
+		return
+	}
+
+	result, err := compatibility.CheckCompatibility(oldSchema, newSchema, mode)
+	if err != nil {
+		httputil.WriteInternalError(w, err)
+		return
+	}
+
+	response := DiffResponse{
+		FromVersion: req.FromVersion,
+		ToVersion:   req.ToVersion,
+		Mode:        result.Mode,
+		Compatible:  result.Compatible,
+		Changes:     result.Entries(),
+	}
+	for _, change := range response.Changes {
+		switch change.Severity {
+		case compatibility.SeverityBreaking:
+			response.Breaking++
+		case compatibility.SeverityWarning:
+			response.Warnings++
+		default:
+			response.NonBreaking++
+		}
+	}
+
+	httputil.WriteSuccess(w, response)
 }
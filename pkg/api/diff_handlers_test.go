@@ -123,6 +123,38 @@ func TestCompareDiff_ToVersionNotFound(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "To version not found")
 }
 
+// TestCompareDiff_UnparsableVersion tests that a stored version that does not parse is reported as bad input
+func TestCompareDiff_UnparsableVersion(t *testing.T) {
+	storage := newMockStorage()
+	server := NewServer(storage, nil)
+
+	storage.versions["test-module"] = map[string]*Version{
+		"v1.0.0": {
+			ModuleName: "test-module",
+			Version:    "v1.0.0",
+			Files:      []File{{Path: "test.proto", Content: "syntax = \"proto3\";"}},
+		},
+		"v1.1.0": {
+			ModuleName: "test-module",
+			Version:    "v1.1.0",
+			Files:      []File{{Path: "test.proto", Content: "message {"}},
+		},
+	}
+
+	reqBody, _ := json.Marshal(DiffRequest{
+		FromVersion: "v1.0.0",
+		ToVersion:   "v1.1.0",
+	})
+	req := httptest.NewRequest("POST", "/modules/test-module/diff", bytes.NewBuffer(reqBody))
+	req = mux.SetURLVars(req, map[string]string{"name": "test-module"})
+	w := httptest.NewRecorder()
+
+	server.compareDiff(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "failed to parse version v1.1.0")
+}
+
 // TestCompareDiff_MissingVersions tests with empty version strings
 func TestCompareDiff_MissingVersions(t *testing.T) {
 	storage := newMockStorage()
@@ -140,3 +172,70 @@ func TestCompareDiff_MissingVersions(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
+
+// TestCompareDiff_RealChanges tests that the diff reflects actual schema changes
+func TestCompareDiff_RealChanges(t *testing.T) {
+	storage := newMockStorage()
+	server := NewServer(storage, nil)
+
+	storage.versions["test-module"] = map[string]*Version{
+		"v1.0.0": {
+			ModuleName: "test-module",
+			Version:    "v1.0.0",
+			Files: []File{
+				{Path: "user.proto", Content: "syntax = \"proto3\";\npackage users;\nmessage User {\n  string id = 1;\n  string phone = 2;\n}\n"},
+			},
+		},
+		"v1.1.0": {
+			ModuleName: "test-module",
+			Version:    "v1.1.0",
+			Files: []File{
+				{Path: "user.proto", Content: "syntax = \"proto3\";\npackage users;\nmessage User {\n  string id = 1;\n  string email = 3;\n}\n"},
+			},
+		},
+	}
+
+	reqBody, _ := json.Marshal(DiffRequest{FromVersion: "v1.0.0", ToVersion: "v1.1.0"})
+	req := httptest.NewRequest("POST", "/modules/test-module/diff", bytes.NewBuffer(reqBody))
+	req = mux.SetURLVars(req, map[string]string{"name": "test-module"})
+	w := httptest.NewRecorder()
+
+	server.compareDiff(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response DiffResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "BACKWARD", response.Mode)
+	assert.False(t, response.Compatible)
+	assert.Equal(t, 1, response.Breaking)
+	assert.Equal(t, 1, response.NonBreaking)
+	require.Len(t, response.Changes, 2)
+
+	removed := response.Changes[0]
+	assert.Equal(t, "field_removed", string(removed.Type))
+	assert.Equal(t, "breaking", string(removed.Severity))
+	assert.Equal(t, "users.User.phone", removed.Location)
+	assert.Equal(t, "user.proto", removed.File)
+	assert.NotEmpty(t, removed.MigrationTip)
+
+	added := response.Changes[1]
+	assert.Equal(t, "field_added", string(added.Type))
+	assert.Equal(t, "non_breaking", string(added.Severity))
+	assert.Empty(t, added.MigrationTip)
+}
+
+// TestCompareDiff_InvalidMode tests with an unknown compatibility mode
+func TestCompareDiff_InvalidMode(t *testing.T) {
+	storage := newMockStorage()
+	server := NewServer(storage, nil)
+
+	reqBody, _ := json.Marshal(DiffRequest{FromVersion: "v1.0.0", ToVersion: "v1.1.0", Mode: "SIDEWAYS"})
+	req := httptest.NewRequest("POST", "/modules/test-module/diff", bytes.NewBuffer(reqBody))
+	req = mux.SetURLVars(req, map[string]string{"name": "test-module"})
+	w := httptest.NewRecorder()
+
+	server.compareDiff(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -75,7 +75,10 @@
 //	GET    /api/v1/languages                                  - List supported languages
 //	GET    /api/v1/languages/{id}                             - Get language details
 //	POST   /api/v1/modules/{name}/versions/{version}/compile  - Compile to languages
+//	GET    /api/v1/modules/{name}/versions/{version}/compile  - List compilation history
 //	GET    /api/v1/modules/{name}/versions/{version}/compile/{jobId} - Check compilation status
+//	DELETE /api/v1/modules/{name}/versions/{version}/compile/{jobId} - Cancel compilation
+//	GET    /api/v1/modules/{name}/versions/{version}/compile/{jobId}/logs - Stream compiler output
 //	GET    /api/v1/modules/{name}/versions/{version}/examples/{lang} - Generate usage examples
 //	POST   /api/v1/modules/{name}/diff                        - Compare versions for breaking changes
 //
@@ -217,8 +220,10 @@
 // The orchestrator (v2) includes sophisticated cache key generation based on proto content
 // and dependencies.
 //
-// Async Compilation: Large compilation jobs can be run asynchronously. The compile endpoint
-// returns a job ID that clients poll for status.
+// Async Compilation: With a compile queue set, the compile endpoint queues one job per
+// language, run by worker pools in any server or spoke-worker process, and returns the
+// job IDs that clients poll for status. The output of protoc and its plugins is kept
+// with each job and streamed as server-sent events while it runs.
 //
 // Search Indexing: The search indexer runs in the background, parsing proto files and
 // indexing messages, enums, services, and fields for fast full-text search.
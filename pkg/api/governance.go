@@ -0,0 +1,207 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+	"github.com/platinummonkey/spoke/pkg/linter/rules"
+	"github.com/platinummonkey/spoke/pkg/validation"
+)
+
+// Governance is the publishing policy of a module. Versions pushed to the
+// module are linted and validated against it before they are stored, and
+// rejected when they fail it.
+type Governance struct {
+	Lint       []string               `json:"lint,omitempty"`       // Lint presets, as lint.use in spoke-lint.yaml; empty for the default
+	Rules      map[string]string      `json:"rules,omitempty"`      // Rule settings, as lint.rules: "off" or a severity
+	FailOn     linter.Severity        `json:"fail_on,omitempty"`    // Lowest lint severity that rejects a version; empty never rejects
+	Validation []string               `json:"validation,omitempty"` // Validation rules, such as MESSAGE_NAME_CONVENTION, that must pass
+	Baseline   []linter.BaselineEntry `json:"baseline,omitempty"`   // Accepted violations, which are reported but never reject
+}
+
+// LintConfig returns the lint configuration of the policy
+func (g *Governance) LintConfig() *linter.Config {
+	config := linter.DefaultConfig()
+	if g == nil {
+		return config
+	}
+	if len(g.Lint) > 0 {
+		config.Lint.Use = g.Lint
+	}
+	for name, setting := range g.Rules {
+		config.Lint.Rules[name] = setting
+	}
+	return config
+}
+
+// Validate checks that the presets, rule settings, severity and validation
+// rules of the policy are known
+func (g *Governance) Validate() error {
+	if err := g.LintConfig().Validate(); err != nil {
+		return err
+	}
+	if _, ok := severityRank[g.FailOn]; !ok && g.FailOn != "" {
+		return fmt.Errorf("unknown fail_on severity %q, expected error, warning or info", g.FailOn)
+	}
+	for _, rule := range g.Validation {
+		if !validation.IsRule(rule) {
+			return fmt.Errorf("unknown validation rule %q", rule)
+		}
+	}
+	return nil
+}
+
+// severityRank orders lint severities from least to most severe
+var severityRank = map[linter.Severity]int{
+	linter.SeverityInfo:    1,
+	linter.SeverityWarning: 2,
+	linter.SeverityError:   3,
+}
+
+// LintReport is the outcome of checking a version against the governance
+// policy of its module. It is stored with accepted versions and returned
+// when a version is rejected.
+type LintReport struct {
+	Passed      bool                `json:"passed"`
+	FailOn      linter.Severity     `json:"fail_on,omitempty"`
+	Errors      int                 `json:"errors"`
+	Warnings    int                 `json:"warnings"`
+	Infos       int                 `json:"infos"`
+	Baselined   int                 `json:"baselined,omitempty"` // Violations accepted by the policy's baseline
+	Violations  []LintViolation     `json:"violations"`
+	Validation  []ValidationFinding `json:"validation,omitempty"`   // Findings of the required validation rules
+	ParseErrors []string            `json:"parse_errors,omitempty"` // Files that could not be checked
+	Reasons     []string            `json:"reasons,omitempty"`      // Why the version was rejected
+}
+
+// LintViolation is a lint violation in a file of a version
+type LintViolation struct {
+	File     string          `json:"file"`
+	Line     int             `json:"line"`
+	Column   int             `json:"column"`
+	Rule     string          `json:"rule"`
+	Severity linter.Severity `json:"severity"`
+	Message  string          `json:"message"`
+}
+
+// ValidationFinding is a finding of a required validation rule
+type ValidationFinding struct {
+	File     string `json:"file"`
+	Location string `json:"location"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+// checkVersion lints and validates the proto files of a version against a
+// governance policy. Without a policy the version is linted with the
+// default rules and always passes.
+func checkVersion(version *Version, governance *Governance) *LintReport {
+	engine := linter.NewLintEngine(governance.LintConfig())
+	for _, rule := range rules.DefaultRules() {
+		engine.Registry().Register(rule)
+	}
+	if governance != nil && len(governance.Baseline) > 0 {
+		engine.SetBaseline(&linter.Baseline{Entries: governance.Baseline})
+	}
+
+	sources := make(map[string]string)
+	for _, file := range version.Files {
+		if strings.HasSuffix(file.Path, ".proto") {
+			sources[file.Path] = file.Content
+		}
+	}
+	files, err := protobuf.ParseFiles(sources)
+	results := engine.LintModule("", files)
+
+	report := &LintReport{Violations: make([]LintViolation, 0)}
+	if err != nil {
+		report.ParseErrors = strings.Split(err.Error(), "\n")
+	}
+	for _, result := range results {
+		report.Baselined += len(result.Baselined)
+		for _, v := range result.Violations {
+			report.Violations = append(report.Violations, LintViolation{
+				File:     result.FilePath,
+				Line:     v.Position.Line,
+				Column:   v.Position.Column,
+				Rule:     v.Rule,
+				Severity: v.Severity,
+				Message:  v.Message,
+			})
+			switch v.Severity {
+			case linter.SeverityError:
+				report.Errors++
+			case linter.SeverityWarning:
+				report.Warnings++
+			case linter.SeverityInfo:
+				report.Infos++
+			}
+		}
+	}
+
+	if governance == nil {
+		report.Passed = true
+		return report
+	}
+	report.FailOn = governance.FailOn
+
+	if len(governance.Validation) > 0 {
+		report.Validation = validateVersion(files, governance.Validation)
+	}
+
+	if len(report.ParseErrors) > 0 {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("%d file(s) failed to parse", len(report.ParseErrors)))
+	}
+	if governance.FailOn != "" {
+		failing := 0
+		for _, v := range report.Violations {
+			if severityRank[v.Severity] >= severityRank[governance.FailOn] {
+				failing++
+			}
+		}
+		if failing > 0 {
+			report.Reasons = append(report.Reasons, fmt.Sprintf("%d lint violation(s) at or above %s", failing, governance.FailOn))
+		}
+	}
+	if len(report.Validation) > 0 {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("%d required validation finding(s)", len(report.Validation)))
+	}
+	report.Passed = len(report.Reasons) == 0
+	return report
+}
+
+// validateVersion returns the findings of the required validation rules in
+// the files that parsed
+func validateVersion(files map[string]*protobuf.RootNode, required []string) []ValidationFinding {
+	wanted := make(map[string]bool, len(required))
+	for _, rule := range required {
+		wanted[rule] = true
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	validator := validation.NewValidator(validation.DefaultValidationConfig())
+	findings := make([]ValidationFinding, 0)
+	for _, path := range paths {
+		result := validator.Validate(files[path])
+		for _, finding := range append(result.Errors, result.Warnings...) {
+			if !wanted[finding.Rule] {
+				continue
+			}
+			findings = append(findings, ValidationFinding{
+				File:     path,
+				Location: finding.Location,
+				Rule:     finding.Rule,
+				Message:  finding.Message,
+			})
+		}
+	}
+	return findings
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"net/http"
 	"sort"
 	"strings"
@@ -11,20 +12,23 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/platinummonkey/spoke/pkg/analytics"
 	"github.com/platinummonkey/spoke/pkg/async"
+	"github.com/platinummonkey/spoke/pkg/codegen/queue"
+	"github.com/platinummonkey/spoke/pkg/contracts"
 	"github.com/platinummonkey/spoke/pkg/httputil"
 	"github.com/platinummonkey/spoke/pkg/search"
 )
 
 // Server represents our API server
 type Server struct {
-	storage             Storage
-	router              *mux.Router
-	db                  *sql.DB
-	authHandlers        *AuthHandlers
-	compatHandlers      *CompatibilityHandlers
-	validationHandlers  *ValidationHandlers
-	searchIndexer       *search.Indexer            // Search indexer for proto entities
-	eventTracker        *analytics.EventTracker    // Analytics event tracker
+	storage            Storage
+	router             *mux.Router
+	db                 *sql.DB
+	authHandlers       *AuthHandlers
+	compatHandlers     *CompatibilityHandlers
+	validationHandlers *ValidationHandlers
+	searchIndexer      *search.Indexer         // Search indexer for proto entities
+	eventTracker       *analytics.EventTracker // Analytics event tracker
+	compileQueue       queue.Store             // Queue of compile jobs run by workers
 }
 
 // NewServer creates a new API server
@@ -58,12 +62,27 @@ func NewServer(storage Storage, db *sql.DB) *Server {
 	return s
 }
 
+// SetContractStore enables consumer breakage reporting in compatibility checks
+func (s *Server) SetContractStore(store contracts.Store) {
+	if s.compatHandlers != nil {
+		s.compatHandlers.SetContractStore(store)
+	}
+}
+
+// SetCompileQueue makes compile requests queue jobs run by workers instead
+// of compiling while the request waits, and enables job tracking
+func (s *Server) SetCompileQueue(store queue.Store) {
+	s.compileQueue = store
+}
+
 // setupRoutes configures all the API routes
 func (s *Server) setupRoutes() {
 	// Module routes
 	s.router.HandleFunc("/modules", s.createModule).Methods("POST")
 	s.router.HandleFunc("/modules", s.listModules).Methods("GET")
 	s.router.HandleFunc("/modules/{name}", s.getModule).Methods("GET")
+	s.router.HandleFunc("/modules/{name}/governance", s.getGovernance).Methods("GET")
+	s.router.HandleFunc("/modules/{name}/governance", s.updateGovernance).Methods("PUT")
 
 	// Version routes
 	s.router.HandleFunc("/modules/{name}/versions", s.createVersion).Methods("POST")
@@ -82,7 +101,10 @@ func (s *Server) setupRoutes() {
 
 	// Compilation routes (v2 API)
 	s.router.HandleFunc("/api/v1/modules/{name}/versions/{version}/compile", s.compileVersion).Methods("POST")
+	s.router.HandleFunc("/api/v1/modules/{name}/versions/{version}/compile", s.listCompilationJobs).Methods("GET")
 	s.router.HandleFunc("/api/v1/modules/{name}/versions/{version}/compile/{jobId}", s.getCompilationJob).Methods("GET")
+	s.router.HandleFunc("/api/v1/modules/{name}/versions/{version}/compile/{jobId}", s.cancelCompilationJob).Methods("DELETE")
+	s.router.HandleFunc("/api/v1/modules/{name}/versions/{version}/compile/{jobId}/logs", s.streamCompilationLogs).Methods("GET")
 
 	// Example generation routes
 	s.router.HandleFunc("/api/v1/modules/{name}/versions/{version}/examples/{language}", s.getExamples).Methods("GET")
@@ -143,6 +165,13 @@ func (s *Server) createModule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if module.Governance != nil {
+		if err := module.Governance.Validate(); err != nil {
+			httputil.WriteBadRequest(w, "invalid governance: "+err.Error())
+			return
+		}
+	}
+
 	module.CreatedAt = time.Now()
 	module.UpdatedAt = time.Now()
 
@@ -249,6 +278,54 @@ func (s *Server) getModule(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteSuccess(w, moduleWithVersions)
 }
 
+// getGovernance handles GET /modules/{name}/governance
+func (s *Server) getGovernance(w http.ResponseWriter, r *http.Request) {
+	vars := httputil.GetPathVars(r)
+	module, err := s.storage.GetModule(vars["name"])
+	if err != nil {
+		httputil.WriteNotFoundError(w, err.Error())
+		return
+	}
+	if module.Governance == nil {
+		httputil.WriteSuccess(w, &Governance{})
+		return
+	}
+	httputil.WriteSuccess(w, module.Governance)
+}
+
+// updateGovernance handles PUT /modules/{name}/governance
+func (s *Server) updateGovernance(w http.ResponseWriter, r *http.Request) {
+	vars := httputil.GetPathVars(r)
+	updater, ok := s.storage.(ModuleUpdater)
+	if !ok {
+		httputil.WriteErrorMessage(w, http.StatusNotImplemented, "storage does not support updating modules")
+		return
+	}
+
+	var governance Governance
+	if !httputil.ParseJSONOrError(w, r, &governance) {
+		return
+	}
+	if err := governance.Validate(); err != nil {
+		httputil.WriteBadRequest(w, "invalid governance: "+err.Error())
+		return
+	}
+
+	module, err := s.storage.GetModule(vars["name"])
+	if err != nil {
+		httputil.WriteNotFoundError(w, err.Error())
+		return
+	}
+	module.Governance = &governance
+	module.UpdatedAt = time.Now()
+	if err := updater.UpdateModule(module); err != nil {
+		httputil.WriteInternalError(w, err)
+		return
+	}
+
+	httputil.WriteSuccess(w, module.Governance)
+}
+
 // createVersion handles POST /modules/{name}/versions
 func (s *Server) createVersion(w http.ResponseWriter, r *http.Request) {
 	vars := httputil.GetPathVars(r)
@@ -260,6 +337,29 @@ func (s *Server) createVersion(w http.ResponseWriter, r *http.Request) {
 	version.ModuleName = vars["name"]
 	version.CreatedAt = time.Now()
 
+	// Check the version against the module's governance before storing it
+	// Only a module that does not exist yet has no policy to enforce
+	var governance *Governance
+	module, err := s.storage.GetModule(version.ModuleName)
+	if err == nil {
+		governance = module.Governance
+	} else if !errors.Is(err, ErrNotFound) {
+		httputil.WriteInternalError(w, err)
+		return
+	}
+	version.Lint = checkVersion(&version, governance)
+	if !version.Lint.Passed {
+		httputil.WriteJSON(w, http.StatusUnprocessableEntity, struct {
+			Error string      `json:"error"`
+			Lint  *LintReport `json:"lint"`
+		}{
+			Error: "version rejected by module governance: " + strings.Join(version.Lint.Reasons, "; "),
+			Lint:  version.Lint,
+		})
+		return
+	}
+	version.Quality = scoreVersion(&version)
+
 	if err := s.storage.CreateVersion(&version); err != nil {
 		httputil.WriteInternalError(w, err)
 		return
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/platinummonkey/spoke/pkg/linter"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -116,6 +117,14 @@ func (m *mockStorage) UpdateVersion(version *Version) error {
 	return nil
 }
 
+func (m *mockStorage) UpdateModule(module *Module) error {
+	if m.modules[module.Name] == nil {
+		return ErrNotFound
+	}
+	m.modules[module.Name] = module
+	return nil
+}
+
 func (m *mockStorage) GetFile(moduleName, version, path string) (*File, error) {
 	if m.getFileError != nil {
 		return nil, m.getFileError
@@ -406,6 +415,193 @@ func TestCreateVersion_Success(t *testing.T) {
 	assert.False(t, response.CreatedAt.IsZero())
 }
 
+// TestCreateVersion_QualityScore tests that created versions store their lint quality
+func TestCreateVersion_QualityScore(t *testing.T) {
+	storage := newMockStorage()
+	server := NewServer(storage, nil)
+
+	version := Version{
+		Version: "v1.0.0",
+		Files: []File{
+			{Path: "acme/v1/user.proto", Content: "syntax = \"proto3\";\npackage acme.v1;\n\n// A user.\nmessage User {\n  // The name.\n  string name = 1;\n}\n"},
+			{Path: "acme/v1/broken.proto", Content: "message {"},
+			{Path: "README.md", Content: "# Users"},
+		},
+	}
+	body, err := json.Marshal(version)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/modules/test-module/versions", bytes.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"name": "test-module"})
+	w := httptest.NewRecorder()
+
+	server.createVersion(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	stored := storage.versions["test-module"]["v1.0.0"]
+	require.NotNil(t, stored)
+	require.NotNil(t, stored.Quality)
+	assert.Equal(t, 1, stored.Quality.Files)
+	assert.Equal(t, 100.0, stored.Quality.DocumentationCoverage)
+	assert.Greater(t, stored.Quality.Score, 0.0)
+	assert.LessOrEqual(t, stored.Quality.Score, 100.0)
+
+	var response Version
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, stored.Quality, response.Quality)
+}
+
+// TestCreateVersion_GovernanceRejects tests that versions failing their module's governance are not stored
+func TestCreateVersion_GovernanceRejects(t *testing.T) {
+	storage := newMockStorage()
+	storage.modules["test-module"] = &Module{
+		Name: "test-module",
+		Governance: &Governance{
+			Lint:       []string{"minimal"},
+			Rules:      map[string]string{"message-naming": "error"},
+			FailOn:     linter.SeverityError,
+			Validation: []string{"MESSAGE_NAME_CONVENTION"},
+		},
+	}
+	server := NewServer(storage, nil)
+
+	version := Version{
+		Version: "v1.0.0",
+		Files: []File{
+			{Path: "acme/v1/user.proto", Content: "syntax = \"proto3\";\npackage acme.v1;\n\nmessage user {\n  string Name = 1;\n}\n"},
+		},
+	}
+	body, err := json.Marshal(version)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/modules/test-module/versions", bytes.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"name": "test-module"})
+	w := httptest.NewRecorder()
+
+	server.createVersion(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Nil(t, storage.versions["test-module"])
+
+	var response struct {
+		Error string      `json:"error"`
+		Lint  *LintReport `json:"lint"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Contains(t, response.Error, "version rejected by module governance")
+	require.NotNil(t, response.Lint)
+	assert.False(t, response.Lint.Passed)
+	assert.Greater(t, response.Lint.Errors, 0)
+	require.Len(t, response.Lint.Validation, 1)
+	assert.Equal(t, "MESSAGE_NAME_CONVENTION", response.Lint.Validation[0].Rule)
+	assert.Len(t, response.Lint.Reasons, 2)
+}
+
+// TestCreateVersion_GovernancePasses tests that accepted versions store their lint report
+func TestCreateVersion_GovernancePasses(t *testing.T) {
+	storage := newMockStorage()
+	storage.modules["test-module"] = &Module{
+		Name:       "test-module",
+		Governance: &Governance{Lint: []string{"minimal"}, FailOn: linter.SeverityError},
+	}
+	server := NewServer(storage, nil)
+
+	version := Version{
+		Version: "v1.0.0",
+		Files: []File{
+			{Path: "acme/v1/user.proto", Content: "syntax = \"proto3\";\npackage acme.v1;\n\nmessage User {\n  string name = 1;\n}\n"},
+		},
+	}
+	body, err := json.Marshal(version)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/modules/test-module/versions", bytes.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"name": "test-module"})
+	w := httptest.NewRecorder()
+
+	server.createVersion(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	stored := storage.versions["test-module"]["v1.0.0"]
+	require.NotNil(t, stored)
+	require.NotNil(t, stored.Lint)
+	assert.True(t, stored.Lint.Passed)
+	assert.Equal(t, linter.SeverityError, stored.Lint.FailOn)
+}
+
+// TestCreateVersion_GovernanceLookupError tests that versions are not stored unchecked when the module can't be read
+func TestCreateVersion_GovernanceLookupError(t *testing.T) {
+	storage := newMockStorage()
+	storage.getModuleError = errors.New("storage error")
+	server := NewServer(storage, nil)
+
+	version := Version{
+		Version: "v1.0.0",
+		Files: []File{
+			{Path: "test.proto", Content: "syntax = \"proto3\";"},
+		},
+	}
+	body, err := json.Marshal(version)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/modules/test-module/versions", bytes.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"name": "test-module"})
+	w := httptest.NewRecorder()
+
+	server.createVersion(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Nil(t, storage.versions["test-module"])
+}
+
+// TestUpdateGovernance tests replacing the governance of a module
+func TestUpdateGovernance(t *testing.T) {
+	storage := newMockStorage()
+	storage.modules["test-module"] = &Module{Name: "test-module"}
+	server := NewServer(storage, nil)
+
+	body := []byte(`{"lint": ["basic"], "fail_on": "warning", "validation": ["DUPLICATE_FIELD_NUMBER"]}`)
+	req := httptest.NewRequest("PUT", "/modules/test-module/governance", bytes.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"name": "test-module"})
+	w := httptest.NewRecorder()
+
+	server.updateGovernance(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, storage.modules["test-module"].Governance)
+	assert.Equal(t, linter.SeverityWarning, storage.modules["test-module"].Governance.FailOn)
+
+	req = httptest.NewRequest("GET", "/modules/test-module/governance", nil)
+	req = mux.SetURLVars(req, map[string]string{"name": "test-module"})
+	w = httptest.NewRecorder()
+
+	server.getGovernance(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var governance Governance
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &governance))
+	assert.Equal(t, []string{"basic"}, governance.Lint)
+}
+
+// TestUpdateGovernance_Invalid tests that unknown presets and severities are rejected
+func TestUpdateGovernance_Invalid(t *testing.T) {
+	storage := newMockStorage()
+	storage.modules["test-module"] = &Module{Name: "test-module"}
+	server := NewServer(storage, nil)
+
+	for _, body := range []string{`{"lint": ["nope"]}`, `{"fail_on": "fatal"}`, `{"rules": {"naming": "loud"}}`, `{"validation": ["MESSAGE_NAME_CONVENTON"]}`} {
+		req := httptest.NewRequest("PUT", "/modules/test-module/governance", bytes.NewReader([]byte(body)))
+		req = mux.SetURLVars(req, map[string]string{"name": "test-module"})
+		w := httptest.NewRecorder()
+
+		server.updateGovernance(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	assert.Nil(t, storage.modules["test-module"].Governance)
+}
+
 // TestCreateVersion_InvalidJSON tests version creation with invalid JSON
 func TestCreateVersion_InvalidJSON(t *testing.T) {
 	storage := newMockStorage()
@@ -1,5 +1,7 @@
 package protobuf
 
+import "fmt"
+
 // NodeType represents the type of AST node
 type NodeType int
 
@@ -41,9 +43,12 @@ type RootNode struct {
 	Package         *PackageNode
 	Imports         []*ImportNode
 	Options         []*OptionNode
+	Features        *Features // Resolved file-level features
 	Messages        []*MessageNode
 	Enums           []*EnumNode
 	Services        []*ServiceNode
+	Extensions      []*ExtendNode
+	References      []*Reference // Uses of named types and enum values, in source order
 	Comments        []*CommentNode
 	SpokeDirectives []*SpokeDirectiveNode
 	Pos             Position
@@ -67,7 +72,8 @@ func (n *RootNode) End() Position {
 
 // SyntaxNode represents a syntax statement in protobuf
 type SyntaxNode struct {
-	Value           string // proto2 or proto3
+	Value           string // proto2, proto3 or editions
+	Edition         string // Edition number (e.g. 2023) when Value is editions
 	Comments        []*CommentNode
 	SpokeDirectives []*SpokeDirectiveNode
 	Pos             Position
@@ -143,6 +149,8 @@ func (n *ImportNode) End() Position {
 type OptionNode struct {
 	Name            string
 	Value           string
+	Custom          bool        // Custom option defined by an extension; Name is "(full.name)"
+	TypedValue      interface{} // Value as string, int64, uint64, float64, bool, []byte, EnumName, []interface{} or map[string]interface{}
 	Comments        []*CommentNode
 	SpokeDirectives []*SpokeDirectiveNode
 	Pos             Position
@@ -172,11 +180,15 @@ type FieldNode struct {
 	Repeated        bool
 	Optional        bool
 	Required        bool
+	HasPresence     bool      // Whether the field tracks presence (has_* accessors)
+	Features        *Features // Resolved features, nil when unknown
 	Options         []*OptionNode
 	Comments        []*CommentNode
 	SpokeDirectives []*SpokeDirectiveNode
 	Pos             Position
 	EndPos          Position
+	NamePos         Position // Span of the declared name
+	NameEnd         Position
 }
 
 // NodeType returns the node type
@@ -201,11 +213,18 @@ type MessageNode struct {
 	Nested          []*MessageNode
 	Enums           []*EnumNode
 	OneOfs          []*OneOfNode
+	Extensions      []*ExtendNode // extend blocks nested in the message
+	ReservedRanges  []Range
+	ReservedNames   []string
+	ExtensionRanges []Range
+	Features        *Features // Resolved features, nil when unknown
 	Options         []*OptionNode
 	Comments        []*CommentNode
 	SpokeDirectives []*SpokeDirectiveNode
 	Pos             Position
 	EndPos          Position
+	NamePos         Position // Span of the declared name
+	NameEnd         Position
 }
 
 // NodeType returns the node type
@@ -232,6 +251,8 @@ type EnumValueNode struct {
 	SpokeDirectives []*SpokeDirectiveNode
 	Pos             Position
 	EndPos          Position
+	NamePos         Position // Span of the declared name
+	NameEnd         Position
 }
 
 // NodeType returns the node type
@@ -253,11 +274,16 @@ func (n *EnumValueNode) End() Position {
 type EnumNode struct {
 	Name            string
 	Values          []*EnumValueNode
+	ReservedRanges  []Range
+	ReservedNames   []string
+	Features        *Features // Resolved features, nil when unknown
 	Options         []*OptionNode
 	Comments        []*CommentNode
 	SpokeDirectives []*SpokeDirectiveNode
 	Pos             Position
 	EndPos          Position
+	NamePos         Position // Span of the declared name
+	NameEnd         Position
 }
 
 // NodeType returns the node type
@@ -287,6 +313,8 @@ type RPCNode struct {
 	SpokeDirectives []*SpokeDirectiveNode
 	Pos             Position
 	EndPos          Position
+	NamePos         Position // Span of the declared name
+	NameEnd         Position
 }
 
 // NodeType returns the node type
@@ -313,6 +341,8 @@ type ServiceNode struct {
 	SpokeDirectives []*SpokeDirectiveNode
 	Pos             Position
 	EndPos          Position
+	NamePos         Position // Span of the declared name
+	NameEnd         Position
 }
 
 // NodeType returns the node type
@@ -386,6 +416,8 @@ type OneOfNode struct {
 	SpokeDirectives []*SpokeDirectiveNode
 	Pos             Position
 	EndPos          Position
+	NamePos         Position // Span of the declared name
+	NameEnd         Position
 }
 
 // NodeType returns the node type
@@ -403,3 +435,58 @@ func (n *OneOfNode) End() Position {
 	return n.EndPos
 }
 
+// Range is an inclusive range of field or enum value numbers, as used by
+// reserved and extensions statements
+type Range struct {
+	Start int
+	End   int
+}
+
+// Contains reports whether n is within the range
+func (r Range) Contains(n int) bool {
+	return n >= r.Start && n <= r.End
+}
+
+// String renders the range as written in proto source
+func (r Range) String() string {
+	if r.Start == r.End {
+		return fmt.Sprintf("%d", r.Start)
+	}
+	return fmt.Sprintf("%d to %d", r.Start, r.End)
+}
+
+// ExtendNode represents an extend block. Extension fields declared for the
+// same message in one scope are grouped into one node.
+type ExtendNode struct {
+	Extendee        string // Fully qualified name of the extended message
+	Fields          []*FieldNode
+	Comments        []*CommentNode
+	SpokeDirectives []*SpokeDirectiveNode
+	Pos             Position
+	EndPos          Position
+}
+
+// NodeType returns the node type
+func (n *ExtendNode) NodeType() NodeType {
+	return NodeTypeExtend
+}
+
+// Position returns the start position
+func (n *ExtendNode) Position() Position {
+	return n.Pos
+}
+
+// End returns the end position
+func (n *ExtendNode) End() Position {
+	return n.EndPos
+}
+
+// Reference is a use of a declared name: a field type, an extendee, an RPC
+// input or output, or an enum default value. Symbol is the fully qualified
+// name it resolves to; Text is the name as written, which may be relative.
+type Reference struct {
+	Symbol string
+	Text   string
+	Pos    Position
+	EndPos Position
+}
@@ -2,7 +2,10 @@ package protobuf
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/bufbuild/protocompile"
@@ -19,8 +22,81 @@ import (
 // 2. Parse @spoke directives and comments from raw content
 // 3. Convert descriptor to AST and merge directives/comments
 func ParseWithDescriptor(filename, content string) (*RootNode, error) {
+	return parseWithSources(filename, content, nil)
+}
+
+// ParseFiles parses the files of a module together, keyed by import path, so
+// that imports between them resolve to the declarations of the imported file
+// rather than placeholders. A file that cannot be compiled with its imports,
+// such as one in an import cycle, is parsed on its own; files that do not
+// parse at all are left out of the result and their errors joined.
+func ParseFiles(sources map[string]string) (map[string]*RootNode, error) {
+	paths := make([]string, 0, len(sources))
+	for path := range sources {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	files := make(map[string]*RootNode, len(sources))
+	var errs []error
+	for _, path := range paths {
+		root, err := parseWithSources(path, sources[path], sources)
+		if err != nil {
+			// Break the import cycles it reaches, then try it on its own,
+			// keeping the first error
+			var retryErr error
+			if root, retryErr = parseWithSources(path, sources[path], withoutImportCycles(sources, path)); retryErr != nil {
+				root, retryErr = ParseWithDescriptor(path, sources[path])
+			}
+			if retryErr != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+				continue
+			}
+		}
+		files[path] = root
+	}
+	return files, errors.Join(errs...)
+}
+
+// withoutImportCycles blanks the imports that close a cycle among the
+// files path reaches, keeping line numbers, so that the files of a cycle can
+// still be compiled as long as they do not use what the blanked imports
+// declare
+func withoutImportCycles(sources map[string]string, path string) map[string]string {
+	result := make(map[string]string, len(sources))
+	for p, content := range sources {
+		result[p] = content
+	}
+
+	state := make(map[string]int) // 1 while being visited, 2 when done
+	var visit func(file string)
+	visit = func(file string) {
+		state[file] = 1
+		for _, imp := range extractImportPaths(result[file]) {
+			if _, ok := result[imp]; !ok {
+				continue
+			}
+			switch state[imp] {
+			case 0:
+				visit(imp)
+			case 1:
+				if file != path {
+					importLine := regexp.MustCompile(`(?m)^\s*import\s+(public\s+|weak\s+)?"` + regexp.QuoteMeta(imp) + `"\s*;`)
+					result[file] = importLine.ReplaceAllString(result[file], "")
+				}
+			}
+		}
+		state[file] = 2
+	}
+	visit(path)
+	return result
+}
+
+// parseWithSources parses a file, resolving imports found in sources to their
+// content and any others to placeholders
+func parseWithSources(filename, content string, sources map[string]string) (*RootNode, error) {
 	// Stage 1: Parse with protocompile
-	desc, sourceInfo, originalContent, err := parseToDescriptor(filename, content)
+	desc, sourceInfo, originalContent, err := parseToDescriptor(filename, content, sources)
 	if err != nil {
 		return nil, fmt.Errorf("protocompile parse failed: %w", err)
 	}
@@ -44,6 +120,18 @@ func ParseWithDescriptor(filename, content string) (*RootNode, error) {
 // For well-known Google protobuf types, returns proper definitions
 // For other imports, returns minimal valid proto
 func getDummyProtoContent(importPath string) string {
+	if content, ok := knownProtoContent(importPath); ok {
+		return content
+	}
+	// For unknown imports, try to create a reasonable proto file based on the import path
+	// Extract package name and potential message names from the path
+	return generateDummyProtoFromPath(importPath)
+}
+
+// knownProtoContent returns the definitions of the well-known Google
+// protobuf types and the google.api annotations, which stand in for the real
+// files when resolving imports
+func knownProtoContent(importPath string) (string, bool) {
 	// Handle well-known Google protobuf types
 	switch importPath {
 	case "google/protobuf/timestamp.proto":
@@ -54,7 +142,7 @@ message Timestamp {
   int64 seconds = 1;
   int32 nanos = 2;
 }
-`
+`, true
 	case "google/protobuf/duration.proto":
 		return `syntax = "proto3";
 package google.protobuf;
@@ -63,7 +151,7 @@ message Duration {
   int64 seconds = 1;
   int32 nanos = 2;
 }
-`
+`, true
 	case "google/protobuf/any.proto":
 		return `syntax = "proto3";
 package google.protobuf;
@@ -72,7 +160,7 @@ message Any {
   string type_url = 1;
   bytes value = 2;
 }
-`
+`, true
 	case "google/protobuf/struct.proto":
 		return `syntax = "proto3";
 package google.protobuf;
@@ -94,13 +182,13 @@ message Value {
 message ListValue {
   repeated Value values = 1;
 }
-`
+`, true
 	case "google/protobuf/empty.proto":
 		return `syntax = "proto3";
 package google.protobuf;
 
 message Empty {}
-`
+`, true
 	case "google/protobuf/wrappers.proto":
 		return `syntax = "proto3";
 package google.protobuf;
@@ -114,11 +202,10 @@ message UInt32Value { uint32 value = 1; }
 message BoolValue { bool value = 1; }
 message StringValue { string value = 1; }
 message BytesValue { bytes value = 1; }
-`
+`, true
 	default:
-		// For unknown imports, try to create a reasonable proto file based on the import path
-		// Extract package name and potential message names from the path
-		return generateDummyProtoFromPath(importPath)
+		content, ok := googleAPIProtos[importPath]
+		return content, ok
 	}
 }
 
@@ -320,7 +407,12 @@ func extractImportModifiers(content string) (publicImports []string, weakImports
 }
 
 // parseToDescriptor uses protocompile to parse proto content into a FileDescriptorProto
-func parseToDescriptor(filename, content string) (*descriptorpb.FileDescriptorProto, *descriptorpb.SourceCodeInfo, string, error) {
+// Imports found in sources are compiled from their content.
+func parseToDescriptor(filename, content string, sources map[string]string) (*descriptorpb.FileDescriptorProto, *descriptorpb.SourceCodeInfo, string, error) {
+	if err := checkEdition(content); err != nil {
+		return nil, nil, "", err
+	}
+
 	// Preprocess content to handle custom Spoke import syntax with @ symbols
 	// Replace @ with - in import statements for protocompile compatibility
 	// The original import paths will still be preserved for dependency extraction
@@ -334,20 +426,36 @@ func parseToDescriptor(filename, content string) (*descriptorpb.FileDescriptorPr
 		filename: preprocessedContent,
 	}
 
-	// Add dummy proto files for each import so protocompile doesn't fail on unresolvable imports
-	for _, imp := range imports {
-		if imp != "" && imp != filename {
+	// Add dummy proto files for each import so protocompile doesn't fail on
+	// unresolvable imports, including the imports of those dummy files
+	for len(imports) > 0 {
+		imp := imports[0]
+		imports = imports[1:]
+
+		// descriptor.proto is resolved from the standard imports so custom
+		// options can extend the real option messages
+		if imp == "google/protobuf/descriptor.proto" {
+			continue
+		}
+		if _, ok := fileMap[imp]; ok || imp == "" {
+			continue
+		}
+		if source, ok := sources[imp]; ok {
+			fileMap[imp] = preprocessImportPaths(source)
+		} else {
 			// Create a proto file for the import
 			// For well-known Google protobuf types, use proper definitions
 			fileMap[imp] = getDummyProtoContent(imp)
 		}
+		imports = append(imports, extractImportPaths(fileMap[imp])...)
 	}
 
 	// Create a protocompile parser
 	compiler := protocompile.Compiler{
-		Resolver: &protocompile.SourceResolver{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
 			Accessor: protocompile.SourceAccessorFromMap(fileMap),
-		},
+		}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
 	}
 
 	// Parse the file
@@ -369,6 +477,7 @@ func parseToDescriptor(filename, content string) (*descriptorpb.FileDescriptorPr
 
 	// Convert to FileDescriptorProto
 	fileDescProto := protodescriptorToProto(fileDesc)
+	fileDescProto.SourceCodeInfo = sourceCodeInfo(fileDesc)
 
 	// Extract public/weak import information from content
 	publicImports, weakImports := extractImportModifiers(content)
@@ -407,6 +516,11 @@ func protodescriptorToProto(fd protoreflect.FileDescriptor) *descriptorpb.FileDe
 		Package: &pkg,
 		Syntax:  &syntax,
 	}
+	if edition := fileEdition(fd); edition != "" {
+		if value, ok := descriptorpb.Edition_value["EDITION_"+edition]; ok {
+			fileProto.Edition = descriptorpb.Edition(value).Enum()
+		}
+	}
 
 	// Add dependencies
 	deps := make([]string, fd.Imports().Len())
@@ -458,11 +572,13 @@ func protodescriptorToProto(fd protoreflect.FileDescriptor) *descriptorpb.FileDe
 		fileProto.Service = services
 	}
 
-	// Add file options
-	if fd.Options() != nil {
-		// Convert protoreflect options to FileOptions proto
-		fileProto.Options = fd.Options().(*descriptorpb.FileOptions)
-	}
+	// Add top-level extensions
+	fileProto.Extension = extensionDescriptorsToProto(fd.Extensions())
+
+	// Add file options, replacing declared features with the resolved ones
+	fileOpts := cloneOptions(fd.Options(), &descriptorpb.FileOptions{})
+	fileOpts.Features = resolveFeatures(fd)
+	fileProto.Options = fileOpts
 
 	return fileProto
 }
@@ -470,8 +586,11 @@ func protodescriptorToProto(fd protoreflect.FileDescriptor) *descriptorpb.FileDe
 // messageDescriptorToProto converts a protoreflect.MessageDescriptor to descriptorpb.DescriptorProto
 func messageDescriptorToProto(md protoreflect.MessageDescriptor) *descriptorpb.DescriptorProto {
 	name := string(md.Name())
+	msgOpts := cloneOptions(md.Options(), &descriptorpb.MessageOptions{})
+	msgOpts.Features = resolveFeatures(md)
 	msgProto := &descriptorpb.DescriptorProto{
-		Name: &name,
+		Name:    &name,
+		Options: msgOpts,
 	}
 
 	// Add fields
@@ -501,30 +620,78 @@ func messageDescriptorToProto(md protoreflect.MessageDescriptor) *descriptorpb.D
 		msgProto.EnumType = nestedEnums
 	}
 
-	// Add oneofs
-	oneofs := make([]*descriptorpb.OneofDescriptorProto, md.Oneofs().Len())
+	// Add oneofs, skipping the synthetic oneofs of proto3 optional fields.
+	// Synthetic oneofs always follow the real ones, so indices are unchanged.
+	oneofs := make([]*descriptorpb.OneofDescriptorProto, 0, md.Oneofs().Len())
 	for i := 0; i < md.Oneofs().Len(); i++ {
 		od := md.Oneofs().Get(i)
+		if od.IsSynthetic() {
+			continue
+		}
 		name := string(od.Name())
-		oneofs[i] = &descriptorpb.OneofDescriptorProto{
+		oneofs = append(oneofs, &descriptorpb.OneofDescriptorProto{
 			Name: &name,
-		}
+		})
 	}
 	if len(oneofs) > 0 {
 		msgProto.OneofDecl = oneofs
 	}
 
+	// Add nested extensions
+	msgProto.Extension = extensionDescriptorsToProto(md.Extensions())
+
+	// Add reserved and extension ranges; both use exclusive ends like
+	// DescriptorProto
+	for i := 0; i < md.ReservedRanges().Len(); i++ {
+		r := md.ReservedRanges().Get(i)
+		start, end := int32(r[0]), int32(r[1])
+		msgProto.ReservedRange = append(msgProto.ReservedRange, &descriptorpb.DescriptorProto_ReservedRange{
+			Start: &start,
+			End:   &end,
+		})
+	}
+	for i := 0; i < md.ReservedNames().Len(); i++ {
+		msgProto.ReservedName = append(msgProto.ReservedName, string(md.ReservedNames().Get(i)))
+	}
+	for i := 0; i < md.ExtensionRanges().Len(); i++ {
+		r := md.ExtensionRanges().Get(i)
+		start, end := int32(r[0]), int32(r[1])
+		msgProto.ExtensionRange = append(msgProto.ExtensionRange, &descriptorpb.DescriptorProto_ExtensionRange{
+			Start: &start,
+			End:   &end,
+		})
+	}
+
 	return msgProto
 }
 
+// extensionDescriptorsToProto converts extension declarations, recording the
+// extended message in Extendee
+func extensionDescriptorsToProto(exts protoreflect.ExtensionDescriptors) []*descriptorpb.FieldDescriptorProto {
+	if exts.Len() == 0 {
+		return nil
+	}
+	protos := make([]*descriptorpb.FieldDescriptorProto, exts.Len())
+	for i := 0; i < exts.Len(); i++ {
+		ext := exts.Get(i)
+		protos[i] = fieldDescriptorToProto(ext)
+		extendee := "." + string(ext.ContainingMessage().FullName())
+		protos[i].Extendee = &extendee
+	}
+	return protos
+}
+
 // fieldDescriptorToProto converts a protoreflect.FieldDescriptor to descriptorpb.FieldDescriptorProto
 func fieldDescriptorToProto(fd protoreflect.FieldDescriptor) *descriptorpb.FieldDescriptorProto {
 	name := string(fd.Name())
 	number := int32(fd.Number())
 
+	fieldOpts := cloneOptions(fd.Options(), &descriptorpb.FieldOptions{})
+	fieldOpts.Features = resolveFieldFeatures(fd)
 	fieldProto := &descriptorpb.FieldDescriptorProto{
-		Name:   &name,
-		Number: &number,
+		Name:    &name,
+		Number:  &number,
+		Options: fieldOpts,
 	}
 
 	// Set type
@@ -533,7 +700,7 @@ func fieldDescriptorToProto(fd protoreflect.FieldDescriptor) *descriptorpb.Field
 	fieldProto.Type = &typ
 
 	// Set type name for message/enum types
-	if kind == protoreflect.MessageKind {
+	if kind == protoreflect.MessageKind || kind == protoreflect.GroupKind {
 		if msg := fd.Message(); msg != nil {
 			typeName := "." + string(msg.FullName())
 			fieldProto.TypeName = &typeName
@@ -558,7 +725,7 @@ func fieldDescriptorToProto(fd protoreflect.FieldDescriptor) *descriptorpb.Field
 	}
 
 	// Set oneof index if field belongs to a oneof
-	if od := fd.ContainingOneof(); od != nil {
+	if od := fd.ContainingOneof(); od != nil && !od.IsSynthetic() {
 		index := int32(od.Index())
 		fieldProto.OneofIndex = &index
 	}
@@ -569,8 +736,11 @@ func fieldDescriptorToProto(fd protoreflect.FieldDescriptor) *descriptorpb.Field
 // enumDescriptorToProto converts a protoreflect.EnumDescriptor to descriptorpb.EnumDescriptorProto
 func enumDescriptorToProto(ed protoreflect.EnumDescriptor) *descriptorpb.EnumDescriptorProto {
 	name := string(ed.Name())
+	enumOpts := cloneOptions(ed.Options(), &descriptorpb.EnumOptions{})
+	enumOpts.Features = resolveFeatures(ed)
 	enumProto := &descriptorpb.EnumDescriptorProto{
-		Name: &name,
+		Name:    &name,
+		Options: enumOpts,
 	}
 
 	// Add values
@@ -580,14 +750,28 @@ func enumDescriptorToProto(ed protoreflect.EnumDescriptor) *descriptorpb.EnumDes
 		valName := string(val.Name())
 		valNumber := int32(val.Number())
 		values[i] = &descriptorpb.EnumValueDescriptorProto{
-			Name:   &valName,
-			Number: &valNumber,
+			Name:    &valName,
+			Number:  &valNumber,
+			Options: cloneOptions(val.Options(), &descriptorpb.EnumValueOptions{}),
 		}
 	}
 	if len(values) > 0 {
 		enumProto.Value = values
 	}
 
+	// Add reserved ranges, which are inclusive for enums
+	for i := 0; i < ed.ReservedRanges().Len(); i++ {
+		r := ed.ReservedRanges().Get(i)
+		start, end := int32(r[0]), int32(r[1])
+		enumProto.ReservedRange = append(enumProto.ReservedRange, &descriptorpb.EnumDescriptorProto_EnumReservedRange{
+			Start: &start,
+			End:   &end,
+		})
+	}
+	for i := 0; i < ed.ReservedNames().Len(); i++ {
+		enumProto.ReservedName = append(enumProto.ReservedName, string(ed.ReservedNames().Get(i)))
+	}
+
 	return enumProto
 }
 
@@ -595,7 +779,8 @@ func enumDescriptorToProto(ed protoreflect.EnumDescriptor) *descriptorpb.EnumDes
 func serviceDescriptorToProto(sd protoreflect.ServiceDescriptor) *descriptorpb.ServiceDescriptorProto {
 	name := string(sd.Name())
 	svcProto := &descriptorpb.ServiceDescriptorProto{
-		Name: &name,
+		Name:    &name,
+		Options: cloneOptions(sd.Options(), &descriptorpb.ServiceOptions{}),
 	}
 
 	// Add methods
@@ -614,6 +799,7 @@ func serviceDescriptorToProto(sd protoreflect.ServiceDescriptor) *descriptorpb.S
 			OutputType:      &outputType,
 			ClientStreaming: &clientStreaming,
 			ServerStreaming: &serverStreaming,
+			Options:         cloneOptions(method.Options(), &descriptorpb.MethodOptions{}),
 		}
 	}
 	if len(methods) > 0 {
@@ -634,6 +820,8 @@ type positionMap struct {
 	fields      map[string]int // field name -> line (scoped by message: "Message.field")
 	oneofs      map[string]int // oneof name -> line
 	rpcs        map[string]int // rpc name -> line (scoped by service: "Service.Method")
+	extends     map[string]int // extendee as written -> line of the extend block
+	source      *sourceIndex   // Exact spans from source code info, when available
 }
 
 // extractPositionsFromContent scans the proto content to find line numbers for each element
@@ -646,6 +834,7 @@ func extractPositionsFromContent(content string) *positionMap {
 		fields:   make(map[string]int),
 		oneofs:   make(map[string]int),
 		rpcs:     make(map[string]int),
+		extends:  make(map[string]int),
 	}
 
 	lines := strings.Split(content, "\n")
@@ -666,8 +855,8 @@ func extractPositionsFromContent(content string) *positionMap {
 		braceDepth += strings.Count(trimmed, "{")
 		braceDepth -= strings.Count(trimmed, "}")
 
-		// Find syntax
-		if strings.HasPrefix(trimmed, "syntax") {
+		// Find syntax or edition
+		if strings.HasPrefix(trimmed, "syntax") || strings.HasPrefix(trimmed, "edition") {
 			pm.syntaxLine = lineNum
 		}
 
@@ -735,6 +924,14 @@ func extractPositionsFromContent(content string) *positionMap {
 			}
 		}
 
+		// Find extend blocks
+		if strings.HasPrefix(trimmed, "extend ") {
+			parts := strings.Fields(strings.TrimSuffix(trimmed, "{"))
+			if len(parts) >= 2 {
+				pm.extends[strings.TrimPrefix(parts[1], ".")] = lineNum
+			}
+		}
+
 		// Find oneofs
 		if strings.HasPrefix(trimmed, "oneof") {
 			parts := strings.Fields(trimmed)
@@ -791,9 +988,9 @@ func convertDescriptorToAST(desc *descriptorpb.FileDescriptorProto, sourceInfo *
 		SpokeDirectives: make([]*SpokeDirectiveNode, 0),
 	}
 
-	// Use sourceInfo to extract positions if available
-	// Note: sourceInfo is currently unused, but kept for future enhancement
-	_ = sourceInfo
+	// Source code info gives exact spans; the content scan above remains the
+	// fallback for line numbers
+	positions.source = newSourceIndex(sourceInfo, content)
 
 	// Convert syntax
 	if desc.Syntax != nil {
@@ -803,6 +1000,9 @@ func convertDescriptorToAST(desc *descriptorpb.FileDescriptorProto, sourceInfo *
 			SpokeDirectives: make([]*SpokeDirectiveNode, 0),
 			Pos:             Position{Line: positions.syntaxLine},
 		}
+		if desc.Edition != nil {
+			root.Syntax.Edition = strings.TrimPrefix(desc.GetEdition().String(), "EDITION_")
+		}
 	}
 
 	// Convert package
@@ -816,12 +1016,16 @@ func convertDescriptorToAST(desc *descriptorpb.FileDescriptorProto, sourceInfo *
 	}
 
 	// Convert imports
-	for _, dep := range desc.GetDependency() {
-		root.Imports = append(root.Imports, &ImportNode{
+	for i, dep := range desc.GetDependency() {
+		imp := &ImportNode{
 			Path:            dep,
 			Comments:        make([]*CommentNode, 0),
 			SpokeDirectives: make([]*SpokeDirectiveNode, 0),
-		})
+		}
+		if start, end, ok := positions.source.span([]int32{pathFileDependency, int32(i)}); ok {
+			imp.Pos, imp.EndPos = start, end
+		}
+		root.Imports = append(root.Imports, imp)
 	}
 	// Mark public imports
 	for _, publicDepIdx := range desc.GetPublicDependency() {
@@ -844,22 +1048,31 @@ func convertDescriptorToAST(desc *descriptorpb.FileDescriptorProto, sourceInfo *
 
 	// Convert file options
 	if desc.Options != nil {
-		root.Options = convertFileOptions(desc.Options)
+		root.Options = append(convertFileOptions(desc.Options), optionNodes(desc.Options, true)...)
+		root.Features = featuresFromProto(desc.Options.GetFeatures())
 	}
 
+	// Convert top-level extensions
+	root.Extensions = convertExtensions(desc.GetExtension(), "", []int32{pathFileExtension}, positions)
+
 	// Convert messages
-	for _, msgDesc := range desc.GetMessageType() {
-		root.Messages = append(root.Messages, convertMessage(msgDesc, positions))
+	for i, msgDesc := range desc.GetMessageType() {
+		root.Messages = append(root.Messages, convertMessage(msgDesc, []int32{pathFileMessage, int32(i)}, positions))
 	}
 
 	// Convert enums
-	for _, enumDesc := range desc.GetEnumType() {
-		root.Enums = append(root.Enums, convertEnum(enumDesc, positions))
+	for i, enumDesc := range desc.GetEnumType() {
+		root.Enums = append(root.Enums, convertEnum(enumDesc, []int32{pathFileEnum, int32(i)}, positions))
 	}
 
 	// Convert services
-	for _, svcDesc := range desc.GetService() {
-		root.Services = append(root.Services, convertService(svcDesc, positions))
+	for i, svcDesc := range desc.GetService() {
+		root.Services = append(root.Services, convertService(svcDesc, []int32{pathFileService, int32(i)}, positions))
+	}
+
+	root.References = positions.source.references
+	if root.References == nil {
+		root.References = make([]*Reference, 0)
 	}
 
 	return root
@@ -971,24 +1184,27 @@ func convertFileOptions(opts *descriptorpb.FileOptions) []*OptionNode {
 	return options
 }
 
-// convertMessage converts a DescriptorProto (message descriptor) to MessageNode
-func convertMessage(desc *descriptorpb.DescriptorProto, positions *positionMap) *MessageNode {
+// convertMessage converts a DescriptorProto (message descriptor) to MessageNode.
+// path is the source code info path of the message.
+func convertMessage(desc *descriptorpb.DescriptorProto, path []int32, positions *positionMap) *MessageNode {
 	msg := &MessageNode{
 		Name:            desc.GetName(),
 		Fields:          make([]*FieldNode, 0),
 		Nested:          make([]*MessageNode, 0),
 		Enums:           make([]*EnumNode, 0),
 		OneOfs:          make([]*OneOfNode, 0),
-		Options:         make([]*OptionNode, 0),
 		Comments:        make([]*CommentNode, 0),
 		SpokeDirectives: make([]*SpokeDirectiveNode, 0),
 		Pos:             Position{Line: positions.messages[desc.GetName()]},
+		Features:        featuresFromProto(desc.GetOptions().GetFeatures()),
+		Options:         optionNodes(desc.GetOptions(), false),
 	}
+	positions.source.declare(path, &msg.Pos, &msg.EndPos, &msg.NamePos, &msg.NameEnd)
 
 	// Convert fields first (we'll organize them into oneofs later)
 	allFields := make([]*FieldNode, 0)
-	for _, fieldDesc := range desc.GetField() {
-		allFields = append(allFields, convertField(fieldDesc, desc.GetName(), positions))
+	for i, fieldDesc := range desc.GetField() {
+		allFields = append(allFields, convertField(fieldDesc, desc.GetName(), childPath(path, pathMessageField, int32(i)), positions))
 	}
 
 	// Convert oneofs
@@ -1000,6 +1216,7 @@ func convertMessage(desc *descriptorpb.DescriptorProto, positions *positionMap)
 			SpokeDirectives: make([]*SpokeDirectiveNode, 0),
 			Pos:             Position{Line: positions.oneofs[oneofDesc.GetName()]},
 		}
+		positions.source.declare(childPath(path, pathMessageOneof, int32(oneofIndex)), &oneof.Pos, &oneof.EndPos, &oneof.NamePos, &oneof.NameEnd)
 
 		// Find all fields that belong to this oneof
 		for i, fieldDesc := range desc.GetField() {
@@ -1014,23 +1231,88 @@ func convertMessage(desc *descriptorpb.DescriptorProto, positions *positionMap)
 	// Add all fields to message (including oneof fields)
 	msg.Fields = allFields
 
+	// Map fields name their value type inside "map<K, V>"
+	for i, fieldDesc := range desc.GetField() {
+		for _, nestedDesc := range desc.GetNestedType() {
+			if !nestedDesc.GetOptions().GetMapEntry() || !strings.HasSuffix(fieldDesc.GetTypeName(), "."+nestedDesc.GetName()) {
+				continue
+			}
+			if entry := nestedDesc.GetField(); len(entry) == 2 && entry[1].TypeName != nil {
+				positions.source.mapValueReference(childPath(path, pathMessageField, int32(i), pathFieldTypeName), strings.TrimPrefix(entry[1].GetTypeName(), "."))
+			}
+		}
+	}
+
 	// Convert nested messages
-	for _, nestedDesc := range desc.GetNestedType() {
-		msg.Nested = append(msg.Nested, convertMessage(nestedDesc, positions))
+	for i, nestedDesc := range desc.GetNestedType() {
+		msg.Nested = append(msg.Nested, convertMessage(nestedDesc, childPath(path, pathMessageNested, int32(i)), positions))
 	}
 
 	// Convert nested enums
-	for _, enumDesc := range desc.GetEnumType() {
-		msg.Enums = append(msg.Enums, convertEnum(enumDesc, positions))
+	for i, enumDesc := range desc.GetEnumType() {
+		msg.Enums = append(msg.Enums, convertEnum(enumDesc, childPath(path, pathMessageEnum, int32(i)), positions))
 	}
 
-	// TODO: Convert extensions, reserved fields (low priority - rarely used)
+	// Convert nested extensions
+	msg.Extensions = convertExtensions(desc.GetExtension(), desc.GetName(), childPath(path, pathMessageExtension), positions)
+
+	// Convert reserved and extension ranges; descriptor ends are exclusive
+	for _, r := range desc.GetReservedRange() {
+		msg.ReservedRanges = append(msg.ReservedRanges, Range{Start: int(r.GetStart()), End: int(r.GetEnd()) - 1})
+	}
+	msg.ReservedNames = desc.GetReservedName()
+	for _, r := range desc.GetExtensionRange() {
+		msg.ExtensionRanges = append(msg.ExtensionRanges, Range{Start: int(r.GetStart()), End: int(r.GetEnd()) - 1})
+	}
 
 	return msg
 }
 
-// convertField converts a FieldDescriptorProto to FieldNode
-func convertField(desc *descriptorpb.FieldDescriptorProto, messageName string, positions *positionMap) *FieldNode {
+// convertExtensions groups extension fields into one ExtendNode per extended
+// message, in declaration order. scope is the name of the enclosing message,
+// if any, and is used to look up field positions; path is the source code
+// info path of the extension list.
+func convertExtensions(descs []*descriptorpb.FieldDescriptorProto, scope string, path []int32, positions *positionMap) []*ExtendNode {
+	extends := make([]*ExtendNode, 0)
+	byExtendee := make(map[string]*ExtendNode)
+	for i, desc := range descs {
+		extendee := strings.TrimPrefix(desc.GetExtendee(), ".")
+		ext, ok := byExtendee[extendee]
+		if !ok {
+			ext = &ExtendNode{
+				Extendee:        extendee,
+				Fields:          make([]*FieldNode, 0),
+				Comments:        make([]*CommentNode, 0),
+				SpokeDirectives: make([]*SpokeDirectiveNode, 0),
+				Pos:             Position{Line: extendLine(extendee, positions)},
+			}
+			byExtendee[extendee] = ext
+			extends = append(extends, ext)
+		}
+		ext.Fields = append(ext.Fields, convertField(desc, scope, childPath(path, int32(i)), positions))
+	}
+	return extends
+}
+
+// extendLine finds the extend block of a fully qualified extendee, which the
+// source may name relative to the current package or scope
+func extendLine(extendee string, positions *positionMap) int {
+	for name := extendee; name != ""; {
+		if line, ok := positions.extends[name]; ok {
+			return line
+		}
+		idx := strings.Index(name, ".")
+		if idx < 0 {
+			break
+		}
+		name = name[idx+1:]
+	}
+	return 0
+}
+
+// convertField converts a FieldDescriptorProto to FieldNode, recording the
+// names it references
+func convertField(desc *descriptorpb.FieldDescriptorProto, messageName string, path []int32, positions *positionMap) *FieldNode {
 	// Try message-scoped key first, fall back to just field name
 	fieldKey := messageName + "." + desc.GetName()
 	lineNum := positions.fields[fieldKey]
@@ -1043,10 +1325,29 @@ func convertField(desc *descriptorpb.FieldDescriptorProto, messageName string, p
 		Name:            desc.GetName(),
 		Type:            getFieldTypeName(desc),
 		Number:          int(desc.GetNumber()),
-		Options:         make([]*OptionNode, 0),
+		Options:         optionNodes(desc.GetOptions(), false),
 		Comments:        make([]*CommentNode, 0),
 		SpokeDirectives: make([]*SpokeDirectiveNode, 0),
 		Pos:             Position{Line: lineNum},
+		Features:        featuresFromProto(desc.GetOptions().GetFeatures()),
+	}
+	positions.source.declare(path, &field.Pos, &field.EndPos, &field.NamePos, &field.NameEnd)
+
+	if desc.Extendee != nil {
+		positions.source.reference(childPath(path, pathFieldExtendee), strings.TrimPrefix(desc.GetExtendee(), "."))
+	}
+	if desc.TypeName != nil {
+		typeName := strings.TrimPrefix(desc.GetTypeName(), ".")
+		positions.source.reference(childPath(path, pathFieldTypeName), typeName)
+
+		// Enum values are scoped as siblings of their enum
+		if desc.GetType() == descriptorpb.FieldDescriptorProto_TYPE_ENUM {
+			scope := ""
+			if idx := strings.LastIndex(typeName, "."); idx >= 0 {
+				scope = typeName[:idx+1]
+			}
+			positions.source.defaultReference(childPath(path, pathFieldDefault), scope)
+		}
 	}
 
 	// Set field modifiers
@@ -1060,6 +1361,16 @@ func convertField(desc *descriptorpb.FieldDescriptorProto, messageName string, p
 		field.Required = true
 	}
 
+	// Singular fields track presence unless they have implicit presence;
+	// message fields and oneof members always do
+	if !field.Repeated {
+		field.HasPresence = desc.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE ||
+			desc.GetType() == descriptorpb.FieldDescriptorProto_TYPE_GROUP ||
+			desc.OneofIndex != nil ||
+			field.Features == nil ||
+			field.Features.FieldPresence != PresenceImplicit
+	}
+
 	return field
 }
 
@@ -1108,43 +1419,56 @@ func getFieldTypeName(desc *descriptorpb.FieldDescriptorProto) string {
 }
 
 // convertEnum converts an EnumDescriptorProto to EnumNode
-func convertEnum(desc *descriptorpb.EnumDescriptorProto, positions *positionMap) *EnumNode {
+func convertEnum(desc *descriptorpb.EnumDescriptorProto, path []int32, positions *positionMap) *EnumNode {
 	enum := &EnumNode{
 		Name:            desc.GetName(),
 		Values:          make([]*EnumValueNode, 0),
-		Options:         make([]*OptionNode, 0),
+		Options:         optionNodes(desc.GetOptions(), false),
 		Comments:        make([]*CommentNode, 0),
 		SpokeDirectives: make([]*SpokeDirectiveNode, 0),
 		Pos:             Position{Line: positions.enums[desc.GetName()]},
+		Features:        featuresFromProto(desc.GetOptions().GetFeatures()),
 	}
+	positions.source.declare(path, &enum.Pos, &enum.EndPos, &enum.NamePos, &enum.NameEnd)
 
-	// Convert enum values
-	for _, valueDesc := range desc.GetValue() {
-		enum.Values = append(enum.Values, &EnumValueNode{
+	// Convert enum values; the content scan records "NAME = n;" lines inside
+	// enums under the bare value name
+	for i, valueDesc := range desc.GetValue() {
+		value := &EnumValueNode{
 			Name:            valueDesc.GetName(),
 			Number:          int(valueDesc.GetNumber()),
-			Options:         make([]*OptionNode, 0),
+			Options:         optionNodes(valueDesc.GetOptions(), false),
 			Comments:        make([]*CommentNode, 0),
 			SpokeDirectives: make([]*SpokeDirectiveNode, 0),
-		})
+			Pos:             Position{Line: positions.fields[valueDesc.GetName()]},
+		}
+		positions.source.declare(childPath(path, pathEnumValue, int32(i)), &value.Pos, &value.EndPos, &value.NamePos, &value.NameEnd)
+		enum.Values = append(enum.Values, value)
+	}
+
+	// Convert reserved ranges, which are inclusive for enums
+	for _, r := range desc.GetReservedRange() {
+		enum.ReservedRanges = append(enum.ReservedRanges, Range{Start: int(r.GetStart()), End: int(r.GetEnd())})
 	}
+	enum.ReservedNames = desc.GetReservedName()
 
 	return enum
 }
 
 // convertService converts a ServiceDescriptorProto to ServiceNode
-func convertService(desc *descriptorpb.ServiceDescriptorProto, positions *positionMap) *ServiceNode {
+func convertService(desc *descriptorpb.ServiceDescriptorProto, path []int32, positions *positionMap) *ServiceNode {
 	svc := &ServiceNode{
 		Name:            desc.GetName(),
 		RPCs:            make([]*RPCNode, 0),
-		Options:         make([]*OptionNode, 0),
+		Options:         optionNodes(desc.GetOptions(), false),
 		Comments:        make([]*CommentNode, 0),
 		SpokeDirectives: make([]*SpokeDirectiveNode, 0),
 		Pos:             Position{Line: positions.services[desc.GetName()]},
 	}
+	positions.source.declare(path, &svc.Pos, &svc.EndPos, &svc.NamePos, &svc.NameEnd)
 
 	// Convert RPC methods
-	for _, methodDesc := range desc.GetMethod() {
+	for i, methodDesc := range desc.GetMethod() {
 		// Try service-scoped key first, fall back to just method name
 		rpcKey := desc.GetName() + "." + methodDesc.GetName()
 		lineNum := positions.rpcs[rpcKey]
@@ -1153,17 +1477,22 @@ func convertService(desc *descriptorpb.ServiceDescriptorProto, positions *positi
 			lineNum = positions.rpcs[methodDesc.GetName()]
 		}
 
-		svc.RPCs = append(svc.RPCs, &RPCNode{
+		rpc := &RPCNode{
 			Name:            methodDesc.GetName(),
 			InputType:       strings.TrimPrefix(methodDesc.GetInputType(), "."),
 			OutputType:      strings.TrimPrefix(methodDesc.GetOutputType(), "."),
 			ClientStreaming: methodDesc.GetClientStreaming(),
 			ServerStreaming: methodDesc.GetServerStreaming(),
-			Options:         make([]*OptionNode, 0),
+			Options:         optionNodes(methodDesc.GetOptions(), false),
 			Comments:        make([]*CommentNode, 0),
 			SpokeDirectives: make([]*SpokeDirectiveNode, 0),
 			Pos:             Position{Line: lineNum},
-		})
+		}
+		methodPath := childPath(path, pathServiceMethod, int32(i))
+		positions.source.declare(methodPath, &rpc.Pos, &rpc.EndPos, &rpc.NamePos, &rpc.NameEnd)
+		positions.source.reference(childPath(methodPath, pathMethodInput), rpc.InputType)
+		positions.source.reference(childPath(methodPath, pathMethodOutput), rpc.OutputType)
+		svc.RPCs = append(svc.RPCs, rpc)
 	}
 
 	return svc
@@ -1205,13 +1534,20 @@ func mergeDirectivesAndComments(root *RootNode, directives map[int]*SpokeDirecti
 	associateAndMarkConsumed(root, directives, comments, consumed, 1)
 }
 
-// associateAndMarkConsumed associates directives with a node and marks them as consumed
+// associateAndMarkConsumed associates directives and comments with a node and
+// marks them as consumed, so each documents only the first node after it.
+// Comments on the node's own line are trailing comments of the node.
 func associateAndMarkConsumed(node interface{}, directives map[int]*SpokeDirectiveNode, comments map[int][]*CommentNode, consumed map[int]bool, startLine int) {
 	// Look for directives/comments in the 3 lines before the node
-	for line := startLine - 3; line < startLine; line++ {
+	for line := startLine - 3; line <= startLine; line++ {
 		if line < 1 || consumed[line] {
 			continue
 		}
+		if line == startLine {
+			if _, ok := directives[line]; ok {
+				continue
+			}
+		}
 
 		// Check if this line has a directive
 		if directive, ok := directives[line]; ok {
@@ -1255,6 +1591,7 @@ func associateAndMarkConsumed(node interface{}, directives map[int]*SpokeDirecti
 
 		// Also handle comments
 		if commentList, ok := comments[line]; ok {
+			consumed[line] = true
 			switch n := node.(type) {
 			case *RootNode:
 				n.Comments = append(n.Comments, commentList...)
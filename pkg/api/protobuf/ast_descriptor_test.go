@@ -465,3 +465,76 @@ message User {
 	require.Len(t, ast.Messages[0].Fields, 1)
 	assert.Equal(t, "name", ast.Messages[0].Fields[0].Name)
 }
+
+func TestParseWithDescriptor_CommentsAttachOnce(t *testing.T) {
+	content := `syntax = "proto3";
+package test;
+
+// A user.
+message User {
+  // The id.
+  string id = 1;
+  string name = 2;
+  string email = 3;
+}
+`
+	ast, err := ParseWithDescriptor("test.proto", content)
+	require.NoError(t, err)
+
+	msg := ast.Messages[0]
+	require.Len(t, msg.Comments, 1)
+	assert.Equal(t, "// A user.", strings.TrimSpace(msg.Comments[0].Text))
+	require.Len(t, msg.Fields[0].Comments, 1)
+	assert.Equal(t, "// The id.", strings.TrimSpace(msg.Fields[0].Comments[0].Text))
+	assert.Empty(t, msg.Fields[1].Comments)
+	assert.Empty(t, msg.Fields[2].Comments)
+}
+
+func TestParseWithDescriptor_GoogleAPIAnnotations(t *testing.T) {
+	content := `syntax = "proto3";
+package library.v1;
+
+import "google/api/annotations.proto";
+import "google/api/resource.proto";
+import "google/protobuf/field_mask.proto";
+
+message Book {
+  option (google.api.resource) = {
+    type: "library.googleapis.com/Book"
+    pattern: "shelves/{shelf}/books/{book}"
+  };
+  string name = 1;
+}
+
+message UpdateBookRequest {
+  Book book = 1;
+  google.protobuf.FieldMask update_mask = 2;
+}
+
+service Library {
+  rpc UpdateBook(UpdateBookRequest) returns (Book) {
+    option (google.api.http) = { patch: "/v1/{book.name=shelves/*/books/*}" body: "book" };
+  }
+}
+`
+	ast, err := ParseWithDescriptor("library/v1/library.proto", content)
+	require.NoError(t, err)
+
+	require.Len(t, ast.Messages, 2)
+	require.Len(t, ast.Messages[0].Options, 1)
+	resource := ast.Messages[0].Options[0]
+	assert.Equal(t, "(google.api.resource)", resource.Name)
+	assert.Equal(t, map[string]interface{}{
+		"type":    "library.googleapis.com/Book",
+		"pattern": []interface{}{"shelves/{shelf}/books/{book}"},
+	}, resource.TypedValue)
+	assert.Equal(t, "google.protobuf.FieldMask", ast.Messages[1].Fields[1].Type)
+
+	// The "/*" in the path is not the start of a block comment
+	rpc := ast.Services[0].RPCs[0]
+	require.Len(t, rpc.Options, 1)
+	assert.Equal(t, map[string]interface{}{
+		"patch": "/v1/{book.name=shelves/*/books/*}",
+		"body":  "book",
+	}, rpc.Options[0].TypedValue)
+}
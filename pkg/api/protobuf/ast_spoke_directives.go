@@ -26,11 +26,11 @@ func ParseSpokeDirectivesFromContent(content string) (map[int]*SpokeDirectiveNod
 		line = strings.TrimSpace(line)
 
 		// Handle block comments
-		if strings.Contains(line, "/*") {
+		if start := blockCommentStart(line); start >= 0 {
 			inBlockComment = true
 
 			// Check if block comment ends on same line
-			if strings.Contains(line, "*/") {
+			if strings.Contains(line[start+2:], "*/") {
 				inBlockComment = false
 				// Extract text between /* and */
 				blockText := extractBlockCommentText(line)
@@ -46,7 +46,7 @@ func ParseSpokeDirectivesFromContent(content string) (map[int]*SpokeDirectiveNod
 
 			// Start of multi-line block comment
 			// Extract any text after /*
-			afterStart := line[strings.Index(line, "/*")+2:]
+			afterStart := line[start+2:]
 			afterStart = strings.TrimSpace(afterStart)
 			if afterStart != "" && IsSpokeDirective(afterStart) {
 				directive, err := ExtractSpokeDirective(afterStart, lineNum+1, 0)
@@ -119,15 +119,43 @@ func ParseSpokeDirectivesFromContent(content string) (map[int]*SpokeDirectiveNod
 
 // extractBlockCommentText extracts text from a single-line block comment /* ... */
 func extractBlockCommentText(line string) string {
-	start := strings.Index(line, "/*")
-	end := strings.Index(line, "*/")
-	if start == -1 || end == -1 || end <= start {
+	start := blockCommentStart(line)
+	if start == -1 {
+		return ""
+	}
+	end := strings.Index(line[start+2:], "*/")
+	if end == -1 {
 		return ""
 	}
-	text := line[start+2 : end]
+	text := line[start+2 : start+2+end]
 	return strings.TrimSpace(text)
 }
 
+// blockCommentStart returns the index of the "/*" opening a block comment on
+// the line, ignoring "/*" inside string literals such as HTTP path templates
+// and after a line comment, or -1
+func blockCommentStart(line string) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return -1
+		case c == '/' && i+1 < len(line) && line[i+1] == '*':
+			return i
+		}
+	}
+	return -1
+}
+
 // IsSpokeDirective checks if a comment text contains a spoke directive.
 // A spoke directive starts with @spoke: followed by option:value
 func IsSpokeDirective(text string) bool {
@@ -304,3 +304,20 @@ func TestAssociateSpokeDirectivesWithNode(t *testing.T) {
 		assert.Len(t, msg.SpokeDirectives, 0)
 	})
 }
+
+func TestBlockCommentStart(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected int
+	}{
+		{line: "/* @spoke:domain:x */", expected: 0},
+		{line: "string id = 1; /* note */", expected: 15},
+		{line: `option (google.api.http) = { get: "/v1/{name=shelves/*}" };`, expected: -1},
+		{line: `string path = 1 [default = "a/*b"]; /* c */`, expected: 36},
+		{line: "// see /* here", expected: -1},
+		{line: "no comment", expected: -1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, blockCommentStart(tt.line), tt.line)
+	}
+}
@@ -0,0 +1,246 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Syntax values of SyntaxNode
+const (
+	SyntaxProto2   = "proto2"
+	SyntaxProto3   = "proto3"
+	SyntaxEditions = "editions"
+)
+
+// SupportedEditions lists the editions the parser can compile. Edition 2024
+// is not among them: protocompile knows it but cannot compile it yet, so
+// files declaring it are rejected with ErrUnsupportedEdition.
+var SupportedEditions = []string{"2023"}
+
+// ErrUnsupportedEdition is returned when parsing a file whose edition is not
+// one of SupportedEditions
+var ErrUnsupportedEdition = errors.New("unsupported edition")
+
+var editionPattern = regexp.MustCompile(`(?m)^\s*edition\s*=\s*["']([^"']*)["']\s*;`)
+
+// IsSupportedEdition reports whether edition is one of SupportedEditions
+func IsSupportedEdition(edition string) bool {
+	for _, supported := range SupportedEditions {
+		if edition == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// checkEdition rejects content declaring an edition outside
+// SupportedEditions, naming the supported ones instead of the compiler error
+func checkEdition(content string) error {
+	match := editionPattern.FindStringSubmatch(content)
+	if match == nil || IsSupportedEdition(match[1]) {
+		return nil
+	}
+	return fmt.Errorf("%w %q (supported: %s)", ErrUnsupportedEdition, match[1], strings.Join(SupportedEditions, ", "))
+}
+
+// Feature values, as written in features.* options
+const (
+	PresenceExplicit       = "EXPLICIT"
+	PresenceImplicit       = "IMPLICIT"
+	PresenceLegacyRequired = "LEGACY_REQUIRED"
+
+	EnumTypeOpen   = "OPEN"
+	EnumTypeClosed = "CLOSED"
+
+	EncodingPacked   = "PACKED"
+	EncodingExpanded = "EXPANDED"
+
+	UTF8Verify = "VERIFY"
+	UTF8None   = "NONE"
+
+	MessageEncodingLengthPrefixed = "LENGTH_PREFIXED"
+	MessageEncodingDelimited      = "DELIMITED"
+
+	JSONFormatAllow            = "ALLOW"
+	JSONFormatLegacyBestEffort = "LEGACY_BEST_EFFORT"
+)
+
+// Features holds the resolved edition features of a declaration. Files using
+// proto2 or proto3 syntax get the features their syntax implies, so tooling
+// can reason about presence, enum openness and encoding the same way for
+// every file instead of switching on the syntax.
+type Features struct {
+	FieldPresence         string `json:"field_presence,omitempty"`
+	EnumType              string `json:"enum_type,omitempty"`
+	RepeatedFieldEncoding string `json:"repeated_field_encoding,omitempty"`
+	UTF8Validation        string `json:"utf8_validation,omitempty"`
+	MessageEncoding       string `json:"message_encoding,omitempty"`
+	JSONFormat            string `json:"json_format,omitempty"`
+}
+
+// DefaultFeatures returns the features of a declaration that sets none in a
+// file of the given syntax. All supported editions share the same defaults.
+func DefaultFeatures(syntax string) *Features {
+	return featuresFromProto(featureDefaults(syntax))
+}
+
+// IsOpenEnum reports whether an enum keeps unknown values. Enums without
+// resolved features are treated as open, as in proto3.
+func (e *EnumNode) IsOpenEnum() bool {
+	return e.Features == nil || e.Features.EnumType != EnumTypeClosed
+}
+
+// IsEditions reports whether the file uses edition syntax
+func (n *SyntaxNode) IsEditions() bool {
+	return n != nil && n.Value == SyntaxEditions
+}
+
+func featureDefaults(syntax string) *descriptorpb.FeatureSet {
+	switch syntax {
+	case SyntaxProto2:
+		return &descriptorpb.FeatureSet{
+			FieldPresence:         descriptorpb.FeatureSet_EXPLICIT.Enum(),
+			EnumType:              descriptorpb.FeatureSet_CLOSED.Enum(),
+			RepeatedFieldEncoding: descriptorpb.FeatureSet_EXPANDED.Enum(),
+			Utf8Validation:        descriptorpb.FeatureSet_NONE.Enum(),
+			MessageEncoding:       descriptorpb.FeatureSet_LENGTH_PREFIXED.Enum(),
+			JsonFormat:            descriptorpb.FeatureSet_LEGACY_BEST_EFFORT.Enum(),
+		}
+	case SyntaxProto3:
+		return &descriptorpb.FeatureSet{
+			FieldPresence:         descriptorpb.FeatureSet_IMPLICIT.Enum(),
+			EnumType:              descriptorpb.FeatureSet_OPEN.Enum(),
+			RepeatedFieldEncoding: descriptorpb.FeatureSet_PACKED.Enum(),
+			Utf8Validation:        descriptorpb.FeatureSet_VERIFY.Enum(),
+			MessageEncoding:       descriptorpb.FeatureSet_LENGTH_PREFIXED.Enum(),
+			JsonFormat:            descriptorpb.FeatureSet_ALLOW.Enum(),
+		}
+	default: // edition 2023 and later
+		return &descriptorpb.FeatureSet{
+			FieldPresence:         descriptorpb.FeatureSet_EXPLICIT.Enum(),
+			EnumType:              descriptorpb.FeatureSet_OPEN.Enum(),
+			RepeatedFieldEncoding: descriptorpb.FeatureSet_PACKED.Enum(),
+			Utf8Validation:        descriptorpb.FeatureSet_VERIFY.Enum(),
+			MessageEncoding:       descriptorpb.FeatureSet_LENGTH_PREFIXED.Enum(),
+			JsonFormat:            descriptorpb.FeatureSet_ALLOW.Enum(),
+		}
+	}
+}
+
+// fileEdition returns the edition number of a file using edition syntax
+func fileEdition(fd protoreflect.FileDescriptor) string {
+	if fd.Syntax() != protoreflect.Editions {
+		return ""
+	}
+	if e, ok := fd.(interface{ Edition() int32 }); ok {
+		return strings.TrimPrefix(descriptorpb.Edition(e.Edition()).String(), "EDITION_")
+	}
+	return ""
+}
+
+// declaredFeatures returns the features set directly on a declaration
+func declaredFeatures(d protoreflect.Descriptor) *descriptorpb.FeatureSet {
+	switch opts := d.Options().(type) {
+	case *descriptorpb.FileOptions:
+		return opts.GetFeatures()
+	case *descriptorpb.MessageOptions:
+		return opts.GetFeatures()
+	case *descriptorpb.FieldOptions:
+		return opts.GetFeatures()
+	case *descriptorpb.OneofOptions:
+		return opts.GetFeatures()
+	case *descriptorpb.EnumOptions:
+		return opts.GetFeatures()
+	case *descriptorpb.EnumValueOptions:
+		return opts.GetFeatures()
+	case *descriptorpb.ServiceOptions:
+		return opts.GetFeatures()
+	case *descriptorpb.MethodOptions:
+		return opts.GetFeatures()
+	}
+	return nil
+}
+
+// resolveFeatures merges the syntax defaults with the features declared on
+// the file and every enclosing declaration down to d
+func resolveFeatures(d protoreflect.Descriptor) *descriptorpb.FeatureSet {
+	var chain []protoreflect.Descriptor
+	for cur := d; cur != nil; cur = cur.Parent() {
+		chain = append(chain, cur)
+	}
+
+	resolved := featureDefaults(d.ParentFile().Syntax().String())
+	for i := len(chain) - 1; i >= 0; i-- {
+		if declared := declaredFeatures(chain[i]); declared != nil {
+			proto.Merge(resolved, declared)
+		}
+	}
+	return resolved
+}
+
+// resolveFieldFeatures resolves the features of a field, inferring them from
+// labels and legacy options (required, proto3 optional, groups, packed) the
+// way protoc does for proto2 and proto3 files
+func resolveFieldFeatures(fd protoreflect.FieldDescriptor) *descriptorpb.FeatureSet {
+	resolved := resolveFeatures(fd)
+
+	switch {
+	case fd.Cardinality() == protoreflect.Required:
+		resolved.FieldPresence = descriptorpb.FeatureSet_LEGACY_REQUIRED.Enum()
+	case fd.HasOptionalKeyword():
+		resolved.FieldPresence = descriptorpb.FeatureSet_EXPLICIT.Enum()
+	}
+	if fd.Kind() == protoreflect.GroupKind {
+		resolved.MessageEncoding = descriptorpb.FeatureSet_DELIMITED.Enum()
+	}
+	if fd.IsList() && isPackable(fd.Kind()) {
+		if fd.IsPacked() {
+			resolved.RepeatedFieldEncoding = descriptorpb.FeatureSet_PACKED.Enum()
+		} else {
+			resolved.RepeatedFieldEncoding = descriptorpb.FeatureSet_EXPANDED.Enum()
+		}
+	}
+	return resolved
+}
+
+// isPackable reports whether repeated fields of a kind can use packed encoding
+func isPackable(kind protoreflect.Kind) bool {
+	switch kind {
+	case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.MessageKind, protoreflect.GroupKind:
+		return false
+	}
+	return true
+}
+
+// featuresFromProto converts a resolved FeatureSet to Features
+func featuresFromProto(fs *descriptorpb.FeatureSet) *Features {
+	if fs == nil {
+		return nil
+	}
+	features := &Features{}
+	if fs.FieldPresence != nil {
+		features.FieldPresence = fs.GetFieldPresence().String()
+	}
+	if fs.EnumType != nil {
+		features.EnumType = fs.GetEnumType().String()
+	}
+	if fs.RepeatedFieldEncoding != nil {
+		features.RepeatedFieldEncoding = fs.GetRepeatedFieldEncoding().String()
+	}
+	if fs.Utf8Validation != nil {
+		features.UTF8Validation = fs.GetUtf8Validation().String()
+	}
+	if fs.MessageEncoding != nil {
+		features.MessageEncoding = fs.GetMessageEncoding().String()
+	}
+	if fs.JsonFormat != nil {
+		features.JSONFormat = fs.GetJsonFormat().String()
+	}
+	return features
+}
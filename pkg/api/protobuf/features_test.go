@@ -0,0 +1,145 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEditions(t *testing.T) {
+	content := `edition = "2023";
+
+package example.v1;
+
+option features.field_presence = IMPLICIT;
+
+message User {
+  string name = 1;
+  int32 age = 2 [features.field_presence = EXPLICIT];
+  repeated int32 scores = 3 [features.repeated_field_encoding = EXPANDED];
+  Address address = 4;
+  Status status = 5 [features.field_presence = EXPLICIT];
+  Address home = 6 [features.message_encoding = DELIMITED];
+}
+
+message Address {
+  option features.json_format = LEGACY_BEST_EFFORT;
+  string street = 1 [features.utf8_validation = NONE];
+}
+
+enum Status {
+  option features.enum_type = CLOSED;
+  STATUS_UNSPECIFIED = 0;
+  STATUS_ACTIVE = 1;
+}
+`
+	root, err := ParseWithDescriptor("user.proto", content)
+	require.NoError(t, err)
+
+	require.NotNil(t, root.Syntax)
+	assert.Equal(t, SyntaxEditions, root.Syntax.Value)
+	assert.Equal(t, "2023", root.Syntax.Edition)
+	assert.True(t, root.Syntax.IsEditions())
+	assert.Equal(t, 1, root.Syntax.Pos.Line)
+
+	require.NotNil(t, root.Features)
+	assert.Equal(t, PresenceImplicit, root.Features.FieldPresence)
+	assert.Equal(t, EnumTypeOpen, root.Features.EnumType)
+
+	fields := make(map[string]*FieldNode)
+	for _, f := range root.Messages[0].Fields {
+		fields[f.Name] = f
+	}
+
+	assert.Equal(t, PresenceImplicit, fields["name"].Features.FieldPresence)
+	assert.False(t, fields["name"].HasPresence)
+	assert.Equal(t, PresenceExplicit, fields["age"].Features.FieldPresence)
+	assert.True(t, fields["age"].HasPresence)
+	assert.Equal(t, EncodingExpanded, fields["scores"].Features.RepeatedFieldEncoding)
+	assert.False(t, fields["scores"].HasPresence)
+	assert.True(t, fields["address"].HasPresence, "message fields always track presence")
+	assert.Equal(t, MessageEncodingDelimited, fields["home"].Features.MessageEncoding)
+	assert.Equal(t, "example.v1.Address", fields["home"].Type)
+
+	address := root.Messages[1]
+	assert.Equal(t, JSONFormatLegacyBestEffort, address.Features.JSONFormat)
+	assert.Equal(t, JSONFormatLegacyBestEffort, address.Fields[0].Features.JSONFormat)
+	assert.Equal(t, UTF8None, address.Fields[0].Features.UTF8Validation)
+	assert.Equal(t, UTF8Verify, fields["name"].Features.UTF8Validation)
+
+	require.Len(t, root.Enums, 1)
+	assert.Equal(t, EnumTypeClosed, root.Enums[0].Features.EnumType)
+	assert.False(t, root.Enums[0].IsOpenEnum())
+}
+
+func TestParseEditions_Unsupported(t *testing.T) {
+	_, err := ParseWithDescriptor("user.proto", "edition = \"2024\";\n\npackage example.v1;\n\nmessage User {\n  string name = 1;\n}\n")
+	require.ErrorIs(t, err, ErrUnsupportedEdition)
+	assert.Contains(t, err.Error(), `"2024" (supported: 2023)`)
+
+	_, err = ParseFiles(map[string]string{"user.proto": "edition = '2024';\n"})
+	assert.ErrorIs(t, err, ErrUnsupportedEdition)
+}
+
+func TestParseLegacySyntaxFeatures(t *testing.T) {
+	t.Run("proto3", func(t *testing.T) {
+		root, err := ParseString(`syntax = "proto3";
+package test;
+message M {
+  string a = 1;
+  optional string b = 2;
+  repeated int32 c = 3;
+  repeated int32 d = 4 [packed = false];
+}
+enum E { E_UNSPECIFIED = 0; }
+`)
+		require.NoError(t, err)
+		assert.Equal(t, SyntaxProto3, root.Syntax.Value)
+		assert.Empty(t, root.Syntax.Edition)
+		assert.False(t, root.Syntax.IsEditions())
+
+		fields := root.Messages[0].Fields
+		assert.Equal(t, PresenceImplicit, fields[0].Features.FieldPresence)
+		assert.False(t, fields[0].HasPresence)
+		assert.Equal(t, PresenceExplicit, fields[1].Features.FieldPresence)
+		assert.True(t, fields[1].HasPresence)
+		assert.Equal(t, EncodingPacked, fields[2].Features.RepeatedFieldEncoding)
+		assert.Equal(t, EncodingExpanded, fields[3].Features.RepeatedFieldEncoding)
+		assert.Equal(t, UTF8Verify, fields[0].Features.UTF8Validation)
+		assert.True(t, root.Enums[0].IsOpenEnum())
+		assert.Empty(t, root.Messages[0].OneOfs, "synthetic oneofs of optional fields are not reported")
+	})
+
+	t.Run("proto2", func(t *testing.T) {
+		root, err := ParseString(`syntax = "proto2";
+package test;
+message M {
+  optional string a = 1;
+  required int32 b = 2;
+  repeated int32 c = 3 [packed = true];
+}
+enum E { E_UNSPECIFIED = 0; }
+`)
+		require.NoError(t, err)
+		fields := root.Messages[0].Fields
+		assert.Equal(t, PresenceExplicit, fields[0].Features.FieldPresence)
+		assert.True(t, fields[0].HasPresence)
+		assert.Equal(t, PresenceLegacyRequired, fields[1].Features.FieldPresence)
+		assert.Equal(t, EncodingPacked, fields[2].Features.RepeatedFieldEncoding)
+		assert.Equal(t, UTF8None, fields[0].Features.UTF8Validation)
+		assert.Equal(t, EnumTypeClosed, root.Enums[0].Features.EnumType)
+		assert.False(t, root.Enums[0].IsOpenEnum())
+	})
+}
+
+func TestDefaultFeatures(t *testing.T) {
+	assert.Equal(t, PresenceExplicit, DefaultFeatures(SyntaxEditions).FieldPresence)
+	assert.Equal(t, PresenceImplicit, DefaultFeatures(SyntaxProto3).FieldPresence)
+	assert.Equal(t, EnumTypeClosed, DefaultFeatures(SyntaxProto2).EnumType)
+	assert.Equal(t, JSONFormatLegacyBestEffort, DefaultFeatures(SyntaxProto2).JSONFormat)
+}
+
+func TestEnumNode_IsOpenEnumWithoutFeatures(t *testing.T) {
+	assert.True(t, (&EnumNode{Name: "E"}).IsOpenEnum())
+}
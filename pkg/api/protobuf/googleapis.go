@@ -0,0 +1,129 @@
+package protobuf
+
+// googleAPIProtos holds definitions of the google.api annotations used by
+// AIP-style APIs and of well-known types that the parser cannot infer from
+// an import path. They declare the extensions with their real field numbers
+// so options such as (google.api.http) compile and keep their typed values.
+var googleAPIProtos = map[string]string{
+	"google/api/http.proto": `syntax = "proto3";
+package google.api;
+
+message Http {
+  repeated HttpRule rules = 1;
+  bool fully_decode_reserved_expansion = 2;
+}
+
+message HttpRule {
+  string selector = 1;
+  oneof pattern {
+    string get = 2;
+    string put = 3;
+    string post = 4;
+    string delete = 5;
+    string patch = 6;
+    CustomHttpPattern custom = 8;
+  }
+  string body = 7;
+  string response_body = 12;
+  repeated HttpRule additional_bindings = 11;
+}
+
+message CustomHttpPattern {
+  string kind = 1;
+  string path = 2;
+}
+`,
+	"google/api/annotations.proto": `syntax = "proto3";
+package google.api;
+
+import "google/api/http.proto";
+import "google/protobuf/descriptor.proto";
+
+extend google.protobuf.MethodOptions {
+  HttpRule http = 72295728;
+}
+`,
+	"google/api/resource.proto": `syntax = "proto3";
+package google.api;
+
+import "google/protobuf/descriptor.proto";
+
+extend google.protobuf.FieldOptions {
+  ResourceReference resource_reference = 1055;
+}
+
+extend google.protobuf.FileOptions {
+  repeated ResourceDescriptor resource_definition = 1053;
+}
+
+extend google.protobuf.MessageOptions {
+  ResourceDescriptor resource = 1053;
+}
+
+message ResourceDescriptor {
+  enum History {
+    HISTORY_UNSPECIFIED = 0;
+    ORIGINALLY_SINGLE_PATTERN = 1;
+    FUTURE_MULTI_PATTERN = 2;
+  }
+  enum Style {
+    STYLE_UNSPECIFIED = 0;
+    DECLARATIVE_FRIENDLY = 1;
+  }
+  string type = 1;
+  repeated string pattern = 2;
+  string name_field = 3;
+  History history = 4;
+  string plural = 5;
+  string singular = 6;
+  repeated Style style = 10;
+}
+
+message ResourceReference {
+  string type = 1;
+  string child_type = 2;
+}
+`,
+	"google/api/field_behavior.proto": `syntax = "proto3";
+package google.api;
+
+import "google/protobuf/descriptor.proto";
+
+extend google.protobuf.FieldOptions {
+  repeated FieldBehavior field_behavior = 1052 [packed = false];
+}
+
+enum FieldBehavior {
+  FIELD_BEHAVIOR_UNSPECIFIED = 0;
+  OPTIONAL = 1;
+  REQUIRED = 2;
+  OUTPUT_ONLY = 3;
+  INPUT_ONLY = 4;
+  IMMUTABLE = 5;
+  UNORDERED_LIST = 6;
+  NON_EMPTY_DEFAULT = 7;
+  IDENTIFIER = 8;
+}
+`,
+	"google/api/client.proto": `syntax = "proto3";
+package google.api;
+
+import "google/protobuf/descriptor.proto";
+
+extend google.protobuf.MethodOptions {
+  repeated string method_signature = 1051;
+}
+
+extend google.protobuf.ServiceOptions {
+  string default_host = 1049;
+  string oauth_scopes = 1050;
+}
+`,
+	"google/protobuf/field_mask.proto": `syntax = "proto3";
+package google.protobuf;
+
+message FieldMask {
+  repeated string paths = 1;
+}
+`,
+}
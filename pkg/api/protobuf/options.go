@@ -0,0 +1,167 @@
+package protobuf
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// EnumName is the TypedValue of enum-valued options, so they can be told
+// apart from string options when written back to proto source
+type EnumName string
+
+// FormatOptionValue renders a TypedValue in proto source syntax, quoting
+// strings. Repeated options are rendered as a list, which proto source only
+// accepts inside message values.
+func FormatOptionValue(value interface{}) string {
+	return optionText(value, true)
+}
+
+// cloneOptions copies the options of a descriptor so resolved features can
+// be stored on them, falling back to empty when the descriptor has none
+func cloneOptions[T proto.Message](opts proto.Message, empty T) T {
+	if opts == nil {
+		return empty
+	}
+	if clone, ok := proto.Clone(opts).(T); ok && clone.ProtoReflect().IsValid() {
+		return clone
+	}
+	return empty
+}
+
+// optionNodes converts the options set on a declaration to OptionNodes,
+// ordered by option field number. Features are exposed as Features instead.
+// Standard options are skipped when customOnly is set.
+func optionNodes(opts proto.Message, customOnly bool) []*OptionNode {
+	nodes := make([]*OptionNode, 0)
+	if opts == nil || !opts.ProtoReflect().IsValid() {
+		return nodes
+	}
+
+	type setOption struct {
+		fd    protoreflect.FieldDescriptor
+		value protoreflect.Value
+	}
+	var set []setOption
+	opts.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Name() == "features" || fd.Name() == "uninterpreted_option" {
+			return true
+		}
+		if customOnly && !fd.IsExtension() {
+			return true
+		}
+		set = append(set, setOption{fd: fd, value: v})
+		return true
+	})
+	sort.Slice(set, func(i, j int) bool { return set[i].fd.Number() < set[j].fd.Number() })
+
+	for _, opt := range set {
+		name := string(opt.fd.Name())
+		if opt.fd.IsExtension() {
+			name = "(" + string(opt.fd.FullName()) + ")"
+		}
+		typed := fieldValue(opt.fd, opt.value)
+		nodes = append(nodes, &OptionNode{
+			Name:            name,
+			Value:           optionText(typed, false),
+			Custom:          opt.fd.IsExtension(),
+			TypedValue:      typed,
+			Comments:        make([]*CommentNode, 0),
+			SpokeDirectives: make([]*SpokeDirectiveNode, 0),
+		})
+	}
+	return nodes
+}
+
+// fieldValue converts a protoreflect value to a plain Go value
+func fieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		values := make([]interface{}, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			values[i] = singularValue(fd, list.Get(i))
+		}
+		return values
+	case fd.IsMap():
+		values := make(map[string]interface{})
+		v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			values[k.String()] = singularValue(fd.MapValue(), mv)
+			return true
+		})
+		return values
+	default:
+		return singularValue(fd, v)
+	}
+}
+
+func singularValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return v.Bool()
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return v.Int()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return v.Uint()
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float()
+	case protoreflect.StringKind:
+		return v.String()
+	case protoreflect.BytesKind:
+		return v.Bytes()
+	case protoreflect.EnumKind:
+		if value := fd.Enum().Values().ByNumber(v.Enum()); value != nil {
+			return EnumName(value.Name())
+		}
+		return int64(v.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		values := make(map[string]interface{})
+		v.Message().Range(func(mfd protoreflect.FieldDescriptor, mv protoreflect.Value) bool {
+			values[string(mfd.Name())] = fieldValue(mfd, mv)
+			return true
+		})
+		return values
+	default:
+		return v.Interface()
+	}
+}
+
+// optionText renders a typed option value in proto text syntax. Top-level
+// strings are left unquoted, like the values of standard options.
+func optionText(value interface{}, quote bool) string {
+	switch v := value.(type) {
+	case string:
+		if quote {
+			return strconv.Quote(v)
+		}
+		return v
+	case EnumName:
+		return string(v)
+	case []byte:
+		return strconv.Quote(string(v))
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = optionText(item, true)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fields := make([]string, len(keys))
+		for i, k := range keys {
+			fields[i] = fmt.Sprintf("%s: %s", k, optionText(v[k], true))
+		}
+		return "{" + strings.Join(fields, " ") + "}"
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,142 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const extensionsProto = `syntax = "proto2";
+package shop.v1;
+
+import "google/protobuf/descriptor.proto";
+
+message Meta {
+  optional string owner = 1;
+  repeated string tags = 2;
+}
+
+extend google.protobuf.FieldOptions {
+  optional bool sensitive = 50001;
+  optional Meta meta = 50002;
+}
+
+extend google.protobuf.FileOptions {
+  optional string team = 50003;
+}
+
+option (team) = "payments";
+option go_package = "example.com/shop";
+
+message Order {
+  reserved 2, 10 to 20;
+  reserved "legacy";
+  extensions 100 to max;
+
+  optional string id = 1 [(sensitive) = true, (meta) = {owner: "a" tags: ["x", "y"]}, deprecated = true];
+
+  extend Order {
+    optional int32 rank = 100;
+  }
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  reserved 5 to 7;
+  reserved "STATUS_OLD";
+}
+`
+
+func findOption(options []*OptionNode, name string) *OptionNode {
+	for _, opt := range options {
+		if opt.Name == name {
+			return opt
+		}
+	}
+	return nil
+}
+
+func TestParseExtensions(t *testing.T) {
+	root, err := ParseWithDescriptor("shop/order.proto", extensionsProto)
+	require.NoError(t, err)
+
+	require.Len(t, root.Extensions, 2)
+	fieldOpts := root.Extensions[0]
+	assert.Equal(t, "google.protobuf.FieldOptions", fieldOpts.Extendee)
+	assert.Equal(t, 11, fieldOpts.Pos.Line)
+	require.Len(t, fieldOpts.Fields, 2)
+	assert.Equal(t, "sensitive", fieldOpts.Fields[0].Name)
+	assert.Equal(t, 50001, fieldOpts.Fields[0].Number)
+	assert.Equal(t, "shop.v1.Meta", fieldOpts.Fields[1].Type)
+	assert.Equal(t, "google.protobuf.FileOptions", root.Extensions[1].Extendee)
+
+	order := root.Messages[1]
+	require.Len(t, order.Extensions, 1)
+	assert.Equal(t, "shop.v1.Order", order.Extensions[0].Extendee)
+	assert.Equal(t, 30, order.Extensions[0].Pos.Line)
+	assert.Equal(t, "rank", order.Extensions[0].Fields[0].Name)
+	assert.Equal(t, NodeTypeExtend, order.Extensions[0].NodeType())
+}
+
+func TestParseReserved(t *testing.T) {
+	root, err := ParseWithDescriptor("shop/order.proto", extensionsProto)
+	require.NoError(t, err)
+
+	order := root.Messages[1]
+	assert.Equal(t, []Range{{Start: 2, End: 2}, {Start: 10, End: 20}}, order.ReservedRanges)
+	assert.Equal(t, []string{"legacy"}, order.ReservedNames)
+	require.Len(t, order.ExtensionRanges, 1)
+	assert.Equal(t, 100, order.ExtensionRanges[0].Start)
+	assert.Equal(t, 536870911, order.ExtensionRanges[0].End)
+
+	status := root.Enums[0]
+	assert.Equal(t, []Range{{Start: 5, End: 7}}, status.ReservedRanges)
+	assert.Equal(t, []string{"STATUS_OLD"}, status.ReservedNames)
+}
+
+func TestParseCustomOptions(t *testing.T) {
+	root, err := ParseWithDescriptor("shop/order.proto", extensionsProto)
+	require.NoError(t, err)
+
+	goPackage := findOption(root.Options, "go_package")
+	require.NotNil(t, goPackage)
+	assert.False(t, goPackage.Custom)
+
+	team := findOption(root.Options, "(shop.v1.team)")
+	require.NotNil(t, team)
+	assert.True(t, team.Custom)
+	assert.Equal(t, "payments", team.Value)
+	assert.Equal(t, "payments", team.TypedValue)
+
+	id := root.Messages[1].Fields[0]
+	require.Len(t, id.Options, 3)
+	assert.Equal(t, "deprecated", id.Options[0].Name, "options are ordered by field number")
+	assert.Equal(t, true, id.Options[0].TypedValue)
+
+	sensitive := findOption(id.Options, "(shop.v1.sensitive)")
+	require.NotNil(t, sensitive)
+	assert.Equal(t, true, sensitive.TypedValue)
+	assert.Equal(t, "true", sensitive.Value)
+
+	meta := findOption(id.Options, "(shop.v1.meta)")
+	require.NotNil(t, meta)
+	assert.Equal(t, map[string]interface{}{
+		"owner": "a",
+		"tags":  []interface{}{"x", "y"},
+	}, meta.TypedValue)
+	assert.Equal(t, `{owner: "a" tags: ["x", "y"]}`, meta.Value)
+}
+
+func TestRange(t *testing.T) {
+	single := Range{Start: 5, End: 5}
+	assert.True(t, single.Contains(5))
+	assert.False(t, single.Contains(6))
+	assert.Equal(t, "5", single.String())
+
+	span := Range{Start: 10, End: 20}
+	assert.True(t, span.Contains(10))
+	assert.True(t, span.Contains(20))
+	assert.False(t, span.Contains(21))
+	assert.Equal(t, "10 to 20", span.String())
+}
@@ -0,0 +1,239 @@
+package protobuf
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Descriptor field numbers used in source code info paths
+const (
+	pathFileDependency = 3
+	pathFileMessage    = 4
+	pathFileEnum       = 5
+	pathFileService    = 6
+	pathFileExtension  = 7
+
+	pathMessageField     = 2
+	pathMessageNested    = 3
+	pathMessageEnum      = 4
+	pathMessageExtension = 6
+	pathMessageOneof     = 8
+
+	pathFieldExtendee = 2
+	pathFieldTypeName = 6
+	pathFieldDefault  = 7
+
+	pathEnumValue     = 2
+	pathServiceMethod = 2
+
+	pathMethodInput  = 2
+	pathMethodOutput = 3
+
+	pathName = 1
+)
+
+// symbolText matches names that can be renamed in place, which excludes the
+// "map<...>" spelling of map entry types
+var symbolText = regexp.MustCompile(`^\.?[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// mapValueText finds the value type in "map<K, V>"
+var mapValueText = regexp.MustCompile(`^map\s*<\s*[A-Za-z0-9_.]+\s*,\s*([A-Za-z0-9_.]+)\s*>$`)
+
+// sourceCodeInfo copies the source locations of a compiled file
+func sourceCodeInfo(fd protoreflect.FileDescriptor) *descriptorpb.SourceCodeInfo {
+	locs := fd.SourceLocations()
+	if locs.Len() == 0 {
+		return nil
+	}
+	info := &descriptorpb.SourceCodeInfo{}
+	for i := 0; i < locs.Len(); i++ {
+		loc := locs.Get(i)
+		span := []int32{int32(loc.StartLine), int32(loc.StartColumn), int32(loc.EndLine), int32(loc.EndColumn)}
+		if loc.StartLine == loc.EndLine {
+			span = []int32{int32(loc.StartLine), int32(loc.StartColumn), int32(loc.EndColumn)}
+		}
+		info.Location = append(info.Location, &descriptorpb.SourceCodeInfo_Location{
+			Path: append([]int32(nil), loc.Path...),
+			Span: span,
+		})
+	}
+	return info
+}
+
+// sourceIndex resolves descriptor paths to positions in the source content
+type sourceIndex struct {
+	content    string
+	lineStarts []int
+	spans      map[string][]int32
+	references []*Reference
+}
+
+func newSourceIndex(info *descriptorpb.SourceCodeInfo, content string) *sourceIndex {
+	idx := &sourceIndex{
+		content:    content,
+		lineStarts: []int{0},
+		spans:      make(map[string][]int32),
+	}
+	for i, c := range content {
+		if c == '\n' {
+			idx.lineStarts = append(idx.lineStarts, i+1)
+		}
+	}
+	for _, loc := range info.GetLocation() {
+		key := pathKey(loc.GetPath())
+		// Repeated paths, such as the extendee of every field in an extend
+		// block, keep their first location
+		if _, ok := idx.spans[key]; !ok {
+			idx.spans[key] = loc.GetSpan()
+		}
+	}
+	return idx
+}
+
+func pathKey(path []int32) string {
+	var sb strings.Builder
+	for i, p := range path {
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(strconv.Itoa(int(p)))
+	}
+	return sb.String()
+}
+
+// childPath returns path extended by elems without aliasing path
+func childPath(path []int32, elems ...int32) []int32 {
+	child := make([]int32, 0, len(path)+len(elems))
+	child = append(child, path...)
+	return append(child, elems...)
+}
+
+// span returns the start and end positions recorded for a path
+func (s *sourceIndex) span(path []int32) (Position, Position, bool) {
+	if s == nil {
+		return Position{}, Position{}, false
+	}
+	span, ok := s.spans[pathKey(path)]
+	if !ok || len(span) < 3 {
+		return Position{}, Position{}, false
+	}
+	endLine, endCol := span[0], span[2]
+	if len(span) == 4 {
+		endLine, endCol = span[2], span[3]
+	}
+	return s.position(int(span[0]), int(span[1])), s.position(int(endLine), int(endCol)), true
+}
+
+// positionAt converts a byte offset to a Position
+func (s *sourceIndex) positionAt(offset int) Position {
+	line := sort.Search(len(s.lineStarts), func(i int) bool { return s.lineStarts[i] > offset }) - 1
+	return Position{
+		Line:   line + 1,
+		Column: utf8.RuneCountInString(s.content[s.lineStarts[line]:offset]) + 1,
+		Offset: offset,
+	}
+}
+
+// text returns the source text between two positions
+func (s *sourceIndex) text(start, end Position) string {
+	if start.Offset < 0 || end.Offset > len(s.content) || start.Offset > end.Offset {
+		return ""
+	}
+	return s.content[start.Offset:end.Offset]
+}
+
+// position converts a zero-based line and descriptor column, where tabs
+// advance to the next multiple of 8, to a Position with a byte offset and a
+// one-based column counted in characters
+func (s *sourceIndex) position(line, col int) Position {
+	if line < 0 || line >= len(s.lineStarts) {
+		return Position{}
+	}
+	offset := s.lineStarts[line]
+	column := 0
+	for column < col && offset < len(s.content) && s.content[offset] != '\n' {
+		if s.content[offset] == '\t' {
+			column += 8 - column%8
+			offset++
+		} else {
+			_, size := utf8.DecodeRuneInString(s.content[offset:])
+			column++
+			offset += size
+		}
+	}
+	return s.positionAt(offset)
+}
+
+// declare sets the declaration and name spans of a node from the source,
+// keeping the line found by the content scan when the path has no location
+func (s *sourceIndex) declare(path []int32, pos, end, namePos, nameEnd *Position) {
+	if start, stop, ok := s.span(path); ok {
+		*pos, *end = start, stop
+	}
+	if start, stop, ok := s.span(childPath(path, pathName)); ok {
+		*namePos, *nameEnd = start, stop
+	}
+}
+
+// reference records a use of symbol written at path
+func (s *sourceIndex) reference(path []int32, symbol string) {
+	if start, end, ok := s.span(path); ok {
+		s.addReference(start.Offset, end.Offset, symbol)
+	}
+}
+
+// mapValueReference records the value type of a map field, whose type is
+// written as "map<K, V>" at path
+func (s *sourceIndex) mapValueReference(path []int32, symbol string) {
+	start, end, ok := s.span(path)
+	if !ok {
+		return
+	}
+	if m := mapValueText.FindStringSubmatchIndex(s.text(start, end)); m != nil {
+		s.addReference(start.Offset+m[2], start.Offset+m[3], symbol)
+	}
+}
+
+// defaultReference records an enum default value. The location at path
+// covers the whole "default = VALUE" option.
+func (s *sourceIndex) defaultReference(path []int32, scope string) {
+	start, end, ok := s.span(path)
+	if !ok {
+		return
+	}
+	text := s.text(start, end)
+	eq := strings.Index(text, "=")
+	if eq < 0 {
+		return
+	}
+	value := strings.TrimSpace(text[eq+1:])
+	valueStart := start.Offset + eq + 1 + strings.Index(text[eq+1:], value)
+	s.addReference(valueStart, valueStart+len(value), scope+value)
+}
+
+func (s *sourceIndex) addReference(start, end int, symbol string) {
+	if start < 0 || end > len(s.content) || start >= end {
+		return
+	}
+	text := s.content[start:end]
+	if !symbolText.MatchString(text) {
+		return
+	}
+	for _, ref := range s.references {
+		if ref.Pos.Offset == start {
+			return
+		}
+	}
+	s.references = append(s.references, &Reference{
+		Symbol: symbol,
+		Text:   text,
+		Pos:    s.positionAt(start),
+		EndPos: s.positionAt(end),
+	})
+}
@@ -0,0 +1,71 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWithDescriptor_SourceSpans(t *testing.T) {
+	content := "syntax = \"proto2\";\npackage test;\n\n" +
+		"enum Kind {\n" +
+		"  KIND_UNSPECIFIED = 0;\n" +
+		"\tKIND_ALPHA = 1;\n" +
+		"}\n\n" +
+		"// é comment\n" +
+		"message Item {\n" +
+		"  optional Kind kind = 1 [default = KIND_ALPHA];\n" +
+		"  map<string, Item> children = 2;\n" +
+		"  optional .test.Item parent = 3;\n" +
+		"}\n\n" +
+		"service Items {\n" +
+		"  rpc Get(Item) returns (test.Item);\n" +
+		"}\n"
+
+	ast, err := ParseWithDescriptor("test.proto", content)
+	require.NoError(t, err)
+
+	span := func(start, end Position) string {
+		return content[start.Offset:end.Offset]
+	}
+
+	t.Run("declaration spans", func(t *testing.T) {
+		require.Len(t, ast.Messages, 1)
+		msg := ast.Messages[0]
+		assert.Equal(t, "Item", span(msg.NamePos, msg.NameEnd))
+		assert.Equal(t, Position{Line: 10, Column: 9, Offset: msg.NamePos.Offset}, msg.NamePos)
+		assert.Equal(t, '}', rune(content[msg.EndPos.Offset-1]))
+
+		require.Len(t, msg.Fields, 3)
+		assert.Equal(t, "kind", span(msg.Fields[0].NamePos, msg.Fields[0].NameEnd))
+		assert.Equal(t, "optional Kind kind = 1 [default = KIND_ALPHA];", span(msg.Fields[0].Pos, msg.Fields[0].EndPos))
+
+		require.Len(t, ast.Enums, 1)
+		value := ast.Enums[0].Values[1]
+		assert.Equal(t, "KIND_ALPHA", span(value.NamePos, value.NameEnd))
+		assert.Equal(t, 6, value.NamePos.Line)
+		assert.Equal(t, 2, value.NamePos.Column)
+
+		require.Len(t, ast.Services, 1)
+		rpc := ast.Services[0].RPCs[0]
+		assert.Equal(t, "Get", span(rpc.NamePos, rpc.NameEnd))
+		assert.Equal(t, "Items", span(ast.Services[0].NamePos, ast.Services[0].NameEnd))
+	})
+
+	t.Run("references", func(t *testing.T) {
+		var got []string
+		for _, ref := range ast.References {
+			assert.Equal(t, ref.Text, span(ref.Pos, ref.EndPos))
+			got = append(got, ref.Symbol+"="+ref.Text)
+		}
+		assert.ElementsMatch(t, []string{
+			"test.Kind=Kind",
+			"test.KIND_ALPHA=KIND_ALPHA",
+			"test.Item=Item",
+			"test.Item=.test.Item",
+			"test.Item=Item",
+			"test.Item=test.Item",
+		}, got)
+	})
+}
@@ -0,0 +1,373 @@
+package protobuf
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// SymbolKind is the kind of declaration a symbol names
+type SymbolKind string
+
+const (
+	SymbolMessage   SymbolKind = "message"
+	SymbolEnum      SymbolKind = "enum"
+	SymbolEnumValue SymbolKind = "enum_value"
+	SymbolService   SymbolKind = "service"
+	SymbolExtension SymbolKind = "extension"
+)
+
+// Symbol is a named declaration of a module file or of a well-known import
+type Symbol struct {
+	FullName string
+	Kind     SymbolKind
+	File     string // Import path of the declaring file
+	Node     Node   // Declaring node, nil for symbols of descriptor.proto
+}
+
+// SymbolTable indexes the declarations of the files of a module, and of the
+// well-known files they import, by fully qualified name
+type SymbolTable struct {
+	files    map[string]*RootNode
+	symbols  map[string][]*Symbol
+	declared map[string][]*Symbol
+}
+
+// knownFiles caches parsed well-known imports by path
+var knownFiles sync.Map
+
+// NewSymbolTable builds the symbol table of files keyed by import path.
+// Imports that are not among files are indexed when they are well-known
+// protobuf types or google.api annotations.
+func NewSymbolTable(files map[string]*RootNode) *SymbolTable {
+	t := &SymbolTable{
+		files:    files,
+		symbols:  make(map[string][]*Symbol),
+		declared: make(map[string][]*Symbol),
+	}
+	for _, path := range sortedPaths(files) {
+		t.addFile(path, files[path])
+	}
+	for _, path := range sortedPaths(files) {
+		for _, imp := range files[path].Imports {
+			t.addKnownImport(imp.Path)
+		}
+	}
+	return t
+}
+
+func sortedPaths(files map[string]*RootNode) []string {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// addKnownImport indexes a well-known import and the files it imports
+func (t *SymbolTable) addKnownImport(path string) {
+	if _, ok := t.declared[path]; ok {
+		return
+	}
+	if _, ok := t.files[path]; ok {
+		return
+	}
+	if path == "google/protobuf/descriptor.proto" {
+		t.declared[path] = []*Symbol{}
+		t.addDescriptor(path, descriptorpb.File_google_protobuf_descriptor_proto)
+		return
+	}
+	root := knownFile(path)
+	if root == nil {
+		return
+	}
+	t.addFile(path, root)
+	for _, imp := range root.Imports {
+		t.addKnownImport(imp.Path)
+	}
+}
+
+// knownFile parses a well-known import, or returns nil when path is not one
+func knownFile(path string) *RootNode {
+	if root, ok := knownFiles.Load(path); ok {
+		return root.(*RootNode)
+	}
+	content, ok := knownProtoContent(path)
+	if !ok {
+		return nil
+	}
+	root, err := ParseWithDescriptor(path, content)
+	if err != nil {
+		return nil
+	}
+	knownFiles.Store(path, root)
+	return root
+}
+
+func (t *SymbolTable) add(sym *Symbol) {
+	t.symbols[sym.FullName] = append(t.symbols[sym.FullName], sym)
+	t.declared[sym.File] = append(t.declared[sym.File], sym)
+}
+
+func (t *SymbolTable) addFile(path string, root *RootNode) {
+	if _, ok := t.declared[path]; !ok {
+		t.declared[path] = []*Symbol{}
+	}
+	scope := ""
+	if root.Package != nil {
+		scope = root.Package.Name
+	}
+	t.addMessages(path, scope, root.Messages)
+	t.addEnums(path, scope, root.Enums)
+	t.addExtensions(path, scope, root.Extensions)
+	for _, svc := range root.Services {
+		t.add(&Symbol{FullName: qualifiedName(scope, svc.Name), Kind: SymbolService, File: path, Node: svc})
+	}
+}
+
+func (t *SymbolTable) addMessages(path, scope string, msgs []*MessageNode) {
+	for _, msg := range msgs {
+		name := qualifiedName(scope, msg.Name)
+		t.add(&Symbol{FullName: name, Kind: SymbolMessage, File: path, Node: msg})
+		t.addMessages(path, name, msg.Nested)
+		t.addEnums(path, name, msg.Enums)
+		t.addExtensions(path, name, msg.Extensions)
+	}
+}
+
+func (t *SymbolTable) addEnums(path, scope string, enums []*EnumNode) {
+	for _, enum := range enums {
+		t.add(&Symbol{FullName: qualifiedName(scope, enum.Name), Kind: SymbolEnum, File: path, Node: enum})
+		// Enum values are scoped as siblings of their enum
+		for _, value := range enum.Values {
+			t.add(&Symbol{FullName: qualifiedName(scope, value.Name), Kind: SymbolEnumValue, File: path, Node: value})
+		}
+	}
+}
+
+func (t *SymbolTable) addExtensions(path, scope string, exts []*ExtendNode) {
+	for _, ext := range exts {
+		for _, field := range ext.Fields {
+			t.add(&Symbol{FullName: qualifiedName(scope, field.Name), Kind: SymbolExtension, File: path, Node: field})
+		}
+	}
+}
+
+// addDescriptor indexes a compiled file, used for descriptor.proto which the
+// parser takes from the standard imports
+func (t *SymbolTable) addDescriptor(path string, fd protoreflect.FileDescriptor) {
+	var addMessages func(msgs protoreflect.MessageDescriptors)
+	addEnums := func(enums protoreflect.EnumDescriptors) {
+		for i := 0; i < enums.Len(); i++ {
+			t.add(&Symbol{FullName: string(enums.Get(i).FullName()), Kind: SymbolEnum, File: path})
+		}
+	}
+	addMessages = func(msgs protoreflect.MessageDescriptors) {
+		for i := 0; i < msgs.Len(); i++ {
+			md := msgs.Get(i)
+			t.add(&Symbol{FullName: string(md.FullName()), Kind: SymbolMessage, File: path})
+			addMessages(md.Messages())
+			addEnums(md.Enums())
+		}
+	}
+	addMessages(fd.Messages())
+	addEnums(fd.Enums())
+}
+
+func qualifiedName(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// Lookup returns the declarations of a fully qualified name; more than one
+// means the name is declared twice
+func (t *SymbolTable) Lookup(fullName string) []*Symbol {
+	return t.symbols[strings.TrimPrefix(fullName, ".")]
+}
+
+// Symbols returns the symbols declared by a file, in declaration order
+func (t *SymbolTable) Symbols(path string) []*Symbol {
+	return t.declared[path]
+}
+
+// Resolve finds the symbol a name written in scope refers to, searching
+// from the innermost enclosing scope outwards as protoc does
+func (t *SymbolTable) Resolve(scope, name string) *Symbol {
+	if strings.HasPrefix(name, ".") {
+		return t.first(name[1:])
+	}
+	for {
+		if sym := t.first(qualifiedName(scope, name)); sym != nil {
+			return sym
+		}
+		if scope == "" {
+			return nil
+		}
+		i := strings.LastIndex(scope, ".")
+		if i < 0 {
+			scope = ""
+		} else {
+			scope = scope[:i]
+		}
+	}
+}
+
+func (t *SymbolTable) first(fullName string) *Symbol {
+	if syms := t.symbols[fullName]; len(syms) > 0 {
+		return syms[0]
+	}
+	return nil
+}
+
+// SymbolReference is a use of a name in a file and the symbol it resolves
+// to, nil when it is declared outside the table
+type SymbolReference struct {
+	Name   string // Fully qualified name referred to
+	Pos    Position
+	Symbol *Symbol
+}
+
+// References returns the uses of names in a file: field types, extendees,
+// RPC inputs and outputs, enum default values and custom options
+func (t *SymbolTable) References(path string) []SymbolReference {
+	root := t.files[path]
+	if root == nil {
+		return nil
+	}
+	var refs []SymbolReference
+	for _, ref := range root.References {
+		refs = append(refs, SymbolReference{Name: ref.Symbol, Pos: ref.Pos, Symbol: t.first(ref.Symbol)})
+	}
+	visitOptions(root, func(opt *OptionNode) {
+		if name := customOptionName(opt); name != "" {
+			refs = append(refs, SymbolReference{Name: name, Pos: opt.Pos, Symbol: t.first(name)})
+		}
+	})
+	return refs
+}
+
+// customOptionName is the extension a custom option such as
+// "(acme.v1.table).name" sets
+func customOptionName(opt *OptionNode) string {
+	if !opt.Custom || !strings.HasPrefix(opt.Name, "(") {
+		return ""
+	}
+	end := strings.Index(opt.Name, ")")
+	if end < 0 {
+		return ""
+	}
+	return strings.TrimPrefix(opt.Name[1:end], ".")
+}
+
+// visitOptions calls fn for the options of every declaration of a file
+func visitOptions(root *RootNode, fn func(opt *OptionNode)) {
+	each := func(opts []*OptionNode) {
+		for _, opt := range opts {
+			fn(opt)
+		}
+	}
+	enums := func(list []*EnumNode) {
+		for _, enum := range list {
+			each(enum.Options)
+			for _, value := range enum.Values {
+				each(value.Options)
+			}
+		}
+	}
+	var messages func(msgs []*MessageNode)
+	messages = func(msgs []*MessageNode) {
+		for _, msg := range msgs {
+			each(msg.Options)
+			for _, field := range msg.Fields {
+				each(field.Options)
+			}
+			messages(msg.Nested)
+			enums(msg.Enums)
+		}
+	}
+	each(root.Options)
+	messages(root.Messages)
+	enums(root.Enums)
+	for _, svc := range root.Services {
+		each(svc.Options)
+		for _, rpc := range svc.RPCs {
+			each(rpc.Options)
+		}
+	}
+}
+
+// UnusedImports returns the imports of a file that none of its references
+// resolve into. Public imports are re-exported and always used, and imports
+// whose declarations are unknown, neither in the table nor well-known, are
+// not judged.
+func (t *SymbolTable) UnusedImports(path string) []*ImportNode {
+	root := t.files[path]
+	if root == nil {
+		return nil
+	}
+	used := make(map[string]bool)
+	for _, ref := range t.References(path) {
+		if ref.Symbol != nil {
+			used[ref.Symbol.File] = true
+		}
+	}
+
+	var unused []*ImportNode
+	for _, imp := range root.Imports {
+		if imp.Public {
+			continue
+		}
+		provides, known := t.provides(imp.Path, make(map[string]bool))
+		if !known {
+			continue
+		}
+		isUsed := false
+		for _, file := range provides {
+			if used[file] {
+				isUsed = true
+				break
+			}
+		}
+		if !isUsed {
+			unused = append(unused, imp)
+		}
+	}
+	return unused
+}
+
+// provides lists the file an import makes visible along with the files it
+// publicly imports, and whether all of them are known
+func (t *SymbolTable) provides(path string, seen map[string]bool) ([]string, bool) {
+	if seen[path] {
+		return nil, true
+	}
+	seen[path] = true
+	if _, ok := t.declared[path]; !ok {
+		return nil, false
+	}
+	files := []string{path}
+	root := t.files[path]
+	if root == nil {
+		root = knownFile(path)
+	}
+	if root == nil {
+		return files, true
+	}
+	for _, imp := range root.Imports {
+		if !imp.Public {
+			continue
+		}
+		more, known := t.provides(imp.Path, seen)
+		if !known {
+			return nil, false
+		}
+		files = append(files, more...)
+	}
+	return files, true
+}
@@ -0,0 +1,148 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var moduleSources = map[string]string{
+	"acme/v1/common.proto": `syntax = "proto3";
+package acme.v1;
+
+message Money {
+  int64 units = 1;
+}
+
+enum Currency {
+  CURRENCY_UNSPECIFIED = 0;
+}
+`,
+	"acme/v1/order.proto": `syntax = "proto3";
+package acme.v1;
+
+import "acme/v1/common.proto";
+import "google/protobuf/timestamp.proto";
+import "google/protobuf/duration.proto";
+
+message Order {
+  Money total = 1;
+  google.protobuf.Timestamp created = 2;
+
+  message Line {
+    Currency currency = 1;
+  }
+}
+`,
+}
+
+func TestParseFiles(t *testing.T) {
+	files, err := ParseFiles(moduleSources)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	order := files["acme/v1/order.proto"]
+	require.NotNil(t, order)
+	require.Len(t, order.Messages, 1)
+	assert.Equal(t, "acme.v1.Money", order.Messages[0].Fields[0].Type)
+	require.Len(t, order.Imports, 3)
+	assert.Equal(t, 4, order.Imports[0].Pos.Line)
+	assert.Equal(t, 5, order.Imports[1].Pos.Line)
+}
+
+func TestParseFiles_Errors(t *testing.T) {
+	files, err := ParseFiles(map[string]string{
+		"ok.proto":     "syntax = \"proto3\";\npackage ok;\n",
+		"broken.proto": "syntax = \"proto3\";\nmessage {\n",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broken.proto")
+	assert.Len(t, files, 1)
+	assert.NotNil(t, files["ok.proto"])
+}
+
+func TestParseFiles_ImportCycle(t *testing.T) {
+	files, err := ParseFiles(map[string]string{
+		"a.proto": "syntax = \"proto3\";\npackage cycle;\nimport \"b.proto\";\n\nmessage A {\n  B b = 1;\n}\n",
+		"b.proto": "syntax = \"proto3\";\npackage cycle;\nimport \"a.proto\";\n\nmessage B {}\n",
+	})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "cycle.B", files["a.proto"].Messages[0].Fields[0].Type)
+	require.Len(t, files["b.proto"].Imports, 1)
+	assert.Equal(t, "a.proto", files["b.proto"].Imports[0].Path)
+}
+
+func TestSymbolTable(t *testing.T) {
+	files, err := ParseFiles(moduleSources)
+	require.NoError(t, err)
+	table := NewSymbolTable(files)
+
+	money := table.Lookup("acme.v1.Money")
+	require.Len(t, money, 1)
+	assert.Equal(t, SymbolMessage, money[0].Kind)
+	assert.Equal(t, "acme/v1/common.proto", money[0].File)
+
+	require.Len(t, table.Lookup(".acme.v1.CURRENCY_UNSPECIFIED"), 1)
+	assert.Equal(t, SymbolEnumValue, table.Lookup("acme.v1.CURRENCY_UNSPECIFIED")[0].Kind)
+	require.Len(t, table.Lookup("google.protobuf.Timestamp"), 1)
+	assert.Equal(t, "google/protobuf/timestamp.proto", table.Lookup("google.protobuf.Timestamp")[0].File)
+
+	var names []string
+	for _, sym := range table.Symbols("acme/v1/order.proto") {
+		names = append(names, sym.FullName)
+	}
+	assert.Equal(t, []string{"acme.v1.Order", "acme.v1.Order.Line"}, names)
+
+	assert.Equal(t, "acme.v1.Money", table.Resolve("acme.v1.Order.Line", "Money").FullName)
+	assert.Equal(t, "acme.v1.Order.Line", table.Resolve("acme.v1.Order", "Line").FullName)
+	assert.Nil(t, table.Resolve("acme.v1", "Line"))
+
+	var refs []string
+	for _, ref := range table.References("acme/v1/order.proto") {
+		require.NotNil(t, ref.Symbol, ref.Name)
+		refs = append(refs, ref.Symbol.File)
+	}
+	assert.Equal(t, []string{"acme/v1/common.proto", "google/protobuf/timestamp.proto", "acme/v1/common.proto"}, refs)
+}
+
+func TestSymbolTable_UnusedImports(t *testing.T) {
+	files, err := ParseFiles(moduleSources)
+	require.NoError(t, err)
+	table := NewSymbolTable(files)
+
+	unused := table.UnusedImports("acme/v1/order.proto")
+	require.Len(t, unused, 1)
+	assert.Equal(t, "google/protobuf/duration.proto", unused[0].Path)
+	assert.Empty(t, table.UnusedImports("acme/v1/common.proto"))
+}
+
+func TestSymbolTable_UnusedImportsCustomOptions(t *testing.T) {
+	root, err := ParseString(`syntax = "proto3";
+package acme.v1;
+
+import "google/api/annotations.proto";
+import "google/api/field_behavior.proto";
+import "other/unknown.proto";
+import public "google/protobuf/empty.proto";
+
+service Users {
+  rpc GetUser(GetUserRequest) returns (GetUserRequest) {
+    option (google.api.http) = {get: "/v1/users"};
+  }
+}
+
+message GetUserRequest {
+  string name = 1;
+}
+`)
+	require.NoError(t, err)
+	table := NewSymbolTable(map[string]*RootNode{"input.proto": root})
+
+	// annotations.proto is used through its option, the unknown import is
+	// not judged and the public import is re-exported
+	unused := table.UnusedImports("input.proto")
+	require.Len(t, unused, 1)
+	assert.Equal(t, "google/api/field_behavior.proto", unused[0].Path)
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/linter"
+	"github.com/platinummonkey/spoke/pkg/linter/rules"
+)
+
+// scoreVersion lints the proto files of a version together with the
+// default rules and returns their weighted quality, or nil when no file
+// parses
+func scoreVersion(version *Version) *QualityScore {
+	config := linter.DefaultConfig()
+	engine := linter.NewLintEngine(config)
+	for _, rule := range rules.DefaultRules() {
+		engine.Registry().Register(rule)
+	}
+
+	sources := make(map[string]string)
+	for _, file := range version.Files {
+		if strings.HasSuffix(file.Path, ".proto") {
+			sources[file.Path] = file.Content
+		}
+	}
+	// Files that fail to parse are not scored
+	results, _ := engine.LintModuleSources("", sources)
+	if len(results) == 0 {
+		return nil
+	}
+
+	violations := 0
+	for _, result := range results {
+		violations += len(result.Violations)
+	}
+	report := linter.AggregateMetrics(results, &config.Quality)
+	return &QualityScore{
+		Score:                 report.QualityScore,
+		DocumentationCoverage: report.DocumentationCoverage,
+		Complexity:            report.ComplexityScore,
+		Maintainability:       report.MaintainabilityScore,
+		Violations:            violations,
+		Files:                 report.Files,
+	}
+}
@@ -12,10 +12,11 @@ var (
 
 // Module represents a protobuf module with its metadata
 type Module struct {
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Governance  *Governance `json:"governance,omitempty"` // Checks versions must pass to be published
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
 }
 
 // SourceInfo represents information about the source code of a version
@@ -56,13 +57,51 @@ type CompilationInfo struct {
 
 // Version represents a specific version of a protobuf module
 type Version struct {
-	ModuleName       string           `json:"module_name"`
-	Version          string           `json:"version"` // Can be semantic version or commit hash
-	Files            []File           `json:"files"`
-	CreatedAt        time.Time        `json:"created_at"`
-	Dependencies     []string         `json:"dependencies,omitempty"`
-	SourceInfo       SourceInfo       `json:"source_info"`
-	CompilationInfo  []CompilationInfo `json:"compilation_info,omitempty"`
+	ModuleName      string            `json:"module_name"`
+	Version         string            `json:"version"` // Can be semantic version or commit hash
+	Files           []File            `json:"files"`
+	CreatedAt       time.Time         `json:"created_at"`
+	Dependencies    []string          `json:"dependencies,omitempty"`
+	SourceInfo      SourceInfo        `json:"source_info"`
+	CompilationInfo []CompilationInfo `json:"compilation_info,omitempty"`
+	Quality         *QualityScore     `json:"quality,omitempty"` // Lint quality, scored when the version is created
+	Lint            *LintReport       `json:"lint,omitempty"`    // Governance checks the version passed when it was created
+}
+
+// QualityScore is the weighted lint quality of a module version. Scores
+// range from 0 to 100.
+type QualityScore struct {
+	Score                 float64 `json:"score"`                  // Higher is better
+	DocumentationCoverage float64 `json:"documentation_coverage"` // Percent of documented declarations
+	Complexity            float64 `json:"complexity"`             // Lower is better
+	Maintainability       float64 `json:"maintainability"`        // Higher is better
+	Violations            int     `json:"violations"`
+	Files                 int     `json:"files"` // Files that parsed and were scored
+}
+
+// ModuleUpdater is implemented by storage that can change a module after it
+// is created, such as to set its governance
+type ModuleUpdater interface {
+	UpdateModule(module *Module) error
+}
+
+// CompilationUpdater is implemented by storage that can store the compiled
+// code of a version for one language atomically, so compilations for other
+// languages finishing at the same time are not overwritten
+type CompilationUpdater interface {
+	PutCompilation(moduleName, version string, info CompilationInfo) error
+}
+
+// SetCompilation replaces the compilation of a version with the same language
+// and package name as info, or adds info when there is none
+func (v *Version) SetCompilation(info CompilationInfo) {
+	for i, existing := range v.CompilationInfo {
+		if existing.Language == info.Language && existing.PackageName == info.PackageName {
+			v.CompilationInfo[i] = info
+			return
+		}
+	}
+	v.CompilationInfo = append(v.CompilationInfo, info)
 }
 
 // File represents a single protobuf file
@@ -73,16 +112,16 @@ type File struct {
 
 // LanguageInfo represents information about a supported language
 type LanguageInfo struct {
-	ID               string            `json:"id"`
-	Name             string            `json:"name"`
-	DisplayName      string            `json:"display_name"`
-	SupportsGRPC     bool              `json:"supports_grpc"`
-	FileExtensions   []string          `json:"file_extensions"`
-	Enabled          bool              `json:"enabled"`
-	Stable           bool              `json:"stable"`
-	Description      string            `json:"description"`
-	DocumentationURL string            `json:"documentation_url"`
-	PluginVersion    string            `json:"plugin_version"`
+	ID               string              `json:"id"`
+	Name             string              `json:"name"`
+	DisplayName      string              `json:"display_name"`
+	SupportsGRPC     bool                `json:"supports_grpc"`
+	FileExtensions   []string            `json:"file_extensions"`
+	Enabled          bool                `json:"enabled"`
+	Stable           bool                `json:"stable"`
+	Description      string              `json:"description"`
+	DocumentationURL string              `json:"documentation_url"`
+	PluginVersion    string              `json:"plugin_version"`
 	PackageManager   *PackageManagerInfo `json:"package_manager,omitempty"`
 }
 
@@ -94,29 +133,33 @@ type PackageManagerInfo struct {
 
 // CompileRequest represents a request to compile proto files
 type CompileRequest struct {
-	Languages   []string          `json:"languages"`   // List of language IDs to compile for
+	Languages   []string          `json:"languages"` // List of language IDs to compile for
 	IncludeGRPC bool              `json:"include_grpc"`
 	Options     map[string]string `json:"options,omitempty"`
+	Priority    int               `json:"priority,omitempty"` // Queued jobs with higher priorities run first
 }
 
 // CompileResponse represents the response from a compilation request
 type CompileResponse struct {
-	JobID   string                  `json:"job_id"`
-	Results []CompilationJobInfo    `json:"results"`
+	JobID   string               `json:"job_id"`
+	Results []CompilationJobInfo `json:"results"`
 }
 
 // CompilationJobInfo represents information about a compilation job
 type CompilationJobInfo struct {
-	ID          string    `json:"id"`
-	Language    string    `json:"language"`
-	Status      string    `json:"status"` // "pending", "running", "completed", "failed"
+	ID          string     `json:"id"`
+	Language    string     `json:"language"`
+	Status      string     `json:"status"` // "pending", "running", "completed", "failed", "cancelled"
+	Attempts    int        `json:"attempts,omitempty"`
+	Priority    int        `json:"priority,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
 	StartedAt   *time.Time `json:"started_at,omitempty"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
-	Duration    int64     `json:"duration_ms,omitempty"` // Duration in milliseconds
-	CacheHit    bool      `json:"cache_hit"`
-	Error       string    `json:"error,omitempty"`
-	S3Key       string    `json:"s3_key,omitempty"`
-	S3Bucket    string    `json:"s3_bucket,omitempty"`
+	Duration    int64      `json:"duration_ms,omitempty"` // Duration in milliseconds
+	CacheHit    bool       `json:"cache_hit"`
+	Error       string     `json:"error,omitempty"`
+	S3Key       string     `json:"s3_key,omitempty"`
+	S3Bucket    string     `json:"s3_bucket,omitempty"`
 }
 
 // Storage interface defines the methods required for storing and retrieving protobuf modules
@@ -129,9 +172,10 @@ type CompilationJobInfo struct {
 //   - Migration period: 12 months
 //
 // STATUS (as of 2026-01-30):
-//   ⚠️  15 days PAST removal deadline
-//   ⚠️  This interface should be removed imminently
-//   ⚠️  All production code MUST migrate to storage.Storage
+//
+//	⚠️  15 days PAST removal deadline
+//	⚠️  This interface should be removed imminently
+//	⚠️  All production code MUST migrate to storage.Storage
 //
 // After 2026-01-15, this interface will be completely removed.
 // Code using api.Storage will not compile. Plan migration before this date.
@@ -164,4 +208,4 @@ type Storage interface {
 
 	// File operations
 	GetFile(moduleName, version, path string) (*File, error)
-} 
\ No newline at end of file
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/formatter"
 	"github.com/platinummonkey/spoke/pkg/httputil"
 	"github.com/platinummonkey/spoke/pkg/validation"
 )
@@ -31,6 +32,9 @@ func (h *ValidationHandlers) RegisterRoutes(router *mux.Router) {
 
 	// Normalize a proto file
 	router.HandleFunc("/normalize", h.normalizeProto).Methods("POST")
+
+	// Format a proto file, preserving comments and directives
+	router.HandleFunc("/format", h.formatProto).Methods("POST")
 }
 
 // validateProto handles POST /validate
@@ -209,3 +213,49 @@ func (h *ValidationHandlers) normalizeProto(w http.ResponseWriter, r *http.Reque
 
 	httputil.WriteSuccess(w, response)
 }
+
+// formatProto handles POST /format
+func (h *ValidationHandlers) formatProto(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Content  string `json:"content"`
+		Filename string `json:"filename"`
+		Diff     bool   `json:"diff"`
+	}
+
+	if !httputil.ParseJSONOrError(w, r, &req) {
+		return
+	}
+
+	if !httputil.RequireNonEmpty(w, req.Content, "content") {
+		return
+	}
+
+	formatted, err := formatter.Format(req.Content)
+	if err != nil {
+		httputil.WriteBadRequest(w, "failed to format: "+err.Error())
+		return
+	}
+
+	response := struct {
+		Formatted string `json:"formatted"`
+		Changed   bool   `json:"changed"`
+		Diff      string `json:"diff,omitempty"`
+	}{
+		Formatted: formatted,
+		Changed:   formatted != req.Content,
+	}
+
+	if req.Diff {
+		filename := req.Filename
+		if filename == "" {
+			filename = "input.proto"
+		}
+		response.Diff, err = formatter.Diff(filename, req.Content, formatted)
+		if err != nil {
+			httputil.WriteInternalError(w, err)
+			return
+		}
+	}
+
+	httputil.WriteSuccess(w, response)
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestNewValidationHandlers verifies handler initialization
@@ -35,6 +36,7 @@ func TestValidationHandlers_RegisterRoutes(t *testing.T) {
 		{"POST", "/validate"},
 		{"GET", "/modules/test-module/versions/1.0.0/validate"},
 		{"POST", "/normalize"},
+		{"POST", "/format"},
 	}
 
 	for _, tt := range tests {
@@ -458,3 +460,57 @@ message TestMessage {
 		handlers.normalizeProto(w, req)
 	}
 }
+
+// TestFormatProto_MissingContent tests with missing content field
+func TestFormatProto_MissingContent(t *testing.T) {
+	handlers := NewValidationHandlers(&mockStorage{})
+
+	reqBody, _ := json.Marshal(map[string]string{"content": ""})
+	req := httptest.NewRequest("POST", "/format", bytes.NewBuffer(reqBody))
+	w := httptest.NewRecorder()
+
+	handlers.formatProto(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestFormatProto_InvalidProto tests with source the formatter cannot tokenize
+func TestFormatProto_InvalidProto(t *testing.T) {
+	handlers := NewValidationHandlers(&mockStorage{})
+
+	reqBody, _ := json.Marshal(map[string]string{"content": "message A {"})
+	req := httptest.NewRequest("POST", "/format", bytes.NewBuffer(reqBody))
+	w := httptest.NewRecorder()
+
+	handlers.formatProto(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "failed to format")
+}
+
+// TestFormatProto_Success tests that comments and directives survive formatting
+func TestFormatProto_Success(t *testing.T) {
+	handlers := NewValidationHandlers(&mockStorage{})
+
+	reqBody, _ := json.Marshal(map[string]interface{}{
+		"content":  "syntax=\"proto3\";\n// @spoke:domain:test\nmessage A{string name=1; // the name\n}\n",
+		"filename": "a.proto",
+		"diff":     true,
+	})
+	req := httptest.NewRequest("POST", "/format", bytes.NewBuffer(reqBody))
+	w := httptest.NewRecorder()
+
+	handlers.formatProto(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Formatted string `json:"formatted"`
+		Changed   bool   `json:"changed"`
+		Diff      string `json:"diff"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "syntax = \"proto3\";\n// @spoke:domain:test\nmessage A {\n  string name = 1; // the name\n}\n", response.Formatted)
+	assert.True(t, response.Changed)
+	assert.Contains(t, response.Diff, "+++ a.proto")
+}
@@ -6,8 +6,8 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/platinummonkey/spoke/pkg/api/protobuf"
 	"github.com/platinummonkey/spoke/pkg/compatibility"
+	"github.com/platinummonkey/spoke/pkg/reporting"
 )
 
 func newCheckCompatibilityCommand() *Command {
@@ -22,7 +22,7 @@ func newCheckCompatibilityCommand() *Command {
 	cmd.Flags.String("new", "", "Directory or file containing new proto schema (required)")
 	cmd.Flags.String("mode", "BACKWARD", "Compatibility mode: BACKWARD, FORWARD, FULL, BACKWARD_TRANSITIVE, FORWARD_TRANSITIVE, FULL_TRANSITIVE")
 	cmd.Flags.Bool("verbose", false, "Show all violations including info level")
-	cmd.Flags.String("format", "text", "Output format: text, json")
+	cmd.Flags.String("format", "text", "Output format: text, json, sarif, junit, checkstyle, github")
 
 	return cmd
 }
@@ -33,7 +33,7 @@ func runCheckCompatibility(args []string) error {
 	newPath := flags.String("new", "", "Directory or file containing new proto schema (required)")
 	mode := flags.String("mode", "BACKWARD", "Compatibility mode")
 	verbose := flags.Bool("verbose", false, "Show all violations including info level")
-	format := flags.String("format", "text", "Output format: text, json")
+	format := flags.String("format", "text", "Output format: text, json, sarif, junit, checkstyle, github")
 
 	if err := flags.Parse(args); err != nil {
 		return err
@@ -50,33 +50,58 @@ func runCheckCompatibility(args []string) error {
 		return fmt.Errorf("invalid compatibility mode: %v", err)
 	}
 
+	// Machine-readable formats own stdout, so progress is only printed for text
+	var reportFormat reporting.Format
+	if *format != "text" && *format != "json" {
+		reportFormat, err = reporting.ParseFormat(*format)
+		if err != nil {
+			return err
+		}
+	}
+	progress := func(msg string, args ...interface{}) {
+		if *format == "text" {
+			fmt.Printf(msg, args...)
+		}
+	}
+
 	// Parse old schema
-	fmt.Printf("Parsing old schema from %s...\n", *oldPath)
+	progress("Parsing old schema from %s...\n", *oldPath)
 	oldSchema, err := parseSchema(*oldPath)
 	if err != nil {
 		return fmt.Errorf("failed to parse old schema: %v", err)
 	}
 
 	// Parse new schema
-	fmt.Printf("Parsing new schema from %s...\n", *newPath)
+	progress("Parsing new schema from %s...\n", *newPath)
 	newSchema, err := parseSchema(*newPath)
 	if err != nil {
 		return fmt.Errorf("failed to parse new schema: %v", err)
 	}
 
 	// Run compatibility check
-	fmt.Printf("Checking compatibility (mode: %s)...\n\n", compatMode.String())
+	progress("Checking compatibility (mode: %s)...\n\n", compatMode.String())
 	result, err := compatibility.CheckCompatibility(oldSchema, newSchema, compatMode)
 	if err != nil {
 		return fmt.Errorf("compatibility check failed: %v", err)
 	}
 
 	// Output results
-	if *format == "json" {
+	switch *format {
+	case "text":
+		return outputText(result, *verbose)
+	case "json":
 		return outputJSON(result)
+	default:
+		return outputReport(result, schemaRoot(*newPath), reportFormat)
 	}
+}
 
-	return outputText(result, *verbose)
+// schemaRoot returns the directory schema file paths are relative to
+func schemaRoot(path string) string {
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return filepath.Dir(path)
+	}
+	return path
 }
 
 func parseSchema(path string) (*compatibility.SchemaGraph, error) {
@@ -109,24 +134,27 @@ func parseSchema(path string) (*compatibility.SchemaGraph, error) {
 		return nil, fmt.Errorf("no proto files found in %s", path)
 	}
 
-	// For now, parse the first file only
-	// TODO: Merge multiple files into a single schema
-	content, err := os.ReadFile(protoFiles[0])
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %v", err)
+	// Merge all files into a single schema, keyed by path relative to the root
+	root := path
+	if !info.IsDir() {
+		root = filepath.Dir(path)
 	}
-
-	// Parse protobuf
-	ast, err := protobuf.ParseString(string(content))
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse proto: %v", err)
+	files := make([]compatibility.SourceFile, 0, len(protoFiles))
+	for _, protoFile := range protoFiles {
+		content, err := os.ReadFile(protoFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %v", err)
+		}
+		rel, err := filepath.Rel(root, protoFile)
+		if err != nil {
+			rel = protoFile
+		}
+		files = append(files, compatibility.SourceFile{Path: filepath.ToSlash(rel), Content: string(content)})
 	}
 
-	// Build schema graph
-	builder := compatibility.NewSchemaGraphBuilder()
-	schema, err := builder.BuildFromAST(ast)
+	schema, err := compatibility.ParseSchemaFiles(files)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build schema graph: %v", err)
+		return nil, fmt.Errorf("failed to parse proto: %v", err)
 	}
 
 	return schema, nil
@@ -215,3 +243,17 @@ func outputJSON(result *compatibility.CheckResult) error {
 	// TODO: Implement JSON output
 	return fmt.Errorf("JSON output not yet implemented")
 }
+
+// outputReport writes the result in a CI reporting format. File paths are
+// made relative to the working directory through the new schema root.
+func outputReport(result *compatibility.CheckResult, root string, format reporting.Format) error {
+	report := reporting.FromCompatibility(result, filepath.ToSlash(root))
+	if err := reporting.Write(os.Stdout, format, report); err != nil {
+		return fmt.Errorf("failed to write %s report: %w", format, err)
+	}
+
+	if !result.Compatible {
+		return fmt.Errorf("compatibility check failed")
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -173,7 +174,7 @@ message User {
 	assert.NoError(t, err)
 	assert.NotNil(t, schema)
 	assert.Equal(t, "test", schema.Package)
-	assert.Contains(t, schema.Messages, "User")
+	assert.Contains(t, schema.Messages, "test.User")
 }
 
 func TestParseSchemaDirectory(t *testing.T) {
@@ -434,7 +435,7 @@ message Outer {
 	schema, err := parseSchema(protoFile)
 	assert.NoError(t, err)
 	assert.NotNil(t, schema)
-	assert.Contains(t, schema.Messages, "Outer")
+	assert.Contains(t, schema.Messages, "test.Outer")
 }
 
 func TestParseSchemaWithEnum(t *testing.T) {
@@ -462,8 +463,8 @@ message User {
 	schema, err := parseSchema(protoFile)
 	assert.NoError(t, err)
 	assert.NotNil(t, schema)
-	assert.Contains(t, schema.Messages, "User")
-	assert.Contains(t, schema.Enums, "Status")
+	assert.Contains(t, schema.Messages, "test.User")
+	assert.Contains(t, schema.Enums, "test.Status")
 }
 
 func TestParseSchemaWithService(t *testing.T) {
@@ -492,7 +493,7 @@ service TestService {
 	schema, err := parseSchema(protoFile)
 	assert.NoError(t, err)
 	assert.NotNil(t, schema)
-	assert.Contains(t, schema.Services, "TestService")
+	assert.Contains(t, schema.Services, "test.TestService")
 }
 
 func TestParseSchemaDirectoryWithSubdirectories(t *testing.T) {
@@ -644,3 +645,47 @@ message Test {
 	assert.NoError(t, err)
 	assert.NotNil(t, schema)
 }
+
+func TestRunCheckCompatibilityReportFormats(t *testing.T) {
+	oldDir := t.TempDir()
+	newDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(oldDir, "user.proto"), []byte(`syntax = "proto3";
+package users;
+
+message User {
+  string id = 1;
+  string email = 2;
+}
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(newDir, "user.proto"), []byte(`syntax = "proto3";
+package users;
+
+message User {
+  string id = 1;
+}
+`), 0644))
+
+	for _, format := range []string{"sarif", "junit", "checkstyle", "github"} {
+		t.Run(format, func(t *testing.T) {
+			old := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			err := runCheckCompatibility([]string{"-old", oldDir, "-new", newDir, "-format", format})
+
+			w.Close()
+			os.Stdout = old
+			out, _ := io.ReadAll(r)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "compatibility check failed")
+			assert.NotContains(t, string(out), "Parsing old schema", "progress must not corrupt the report")
+			assert.Contains(t, string(out), "FIELD_REMOVED")
+			assert.Contains(t, string(out), filepath.ToSlash(filepath.Join(newDir, "user.proto")))
+		})
+	}
+
+	err := runCheckCompatibility([]string{"-old", oldDir, "-new", newDir, "-format", "html"})
+	assert.ErrorContains(t, err, "unknown report format")
+}
@@ -70,6 +70,11 @@ func newCompileCommand() *Command {
 	cmd.Flags.Bool("grpc", false, "Include gRPC code generation")
 	cmd.Flags.Bool("parallel", false, "Compile multiple languages in parallel")
 	cmd.Flags.Bool("recursive", false, "Pull dependencies recursively")
+	cmd.Flags.Bool("remote", false, "Compile a registry version on the server instead of local files")
+	cmd.Flags.String("module", "", "Module name (with --remote)")
+	cmd.Flags.String("version", "", "Version (with --remote)")
+	cmd.Flags.String("registry", "http://localhost:8080", "Registry URL (with --remote)")
+	cmd.Flags.Bool("follow", false, "Print the compiler output of remote compilations until they finish")
 
 	return cmd
 }
@@ -101,6 +106,18 @@ func runCompile(args []string) error {
 		languages = []string{lang}
 	}
 
+	if cmd.Flags.Lookup("remote").Value.String() == "true" {
+		return runRemoteCompile(
+			cmd.Flags.Lookup("registry").Value.String(),
+			cmd.Flags.Lookup("module").Value.String(),
+			cmd.Flags.Lookup("version").Value.String(),
+			languages,
+			includeGRPC,
+			cmd.Flags.Lookup("follow").Value.String() == "true",
+			os.Stdout,
+		)
+	}
+
 	fmt.Printf("Compiling for languages: %v\n", languages)
 	if includeGRPC {
 		fmt.Println("Including gRPC code generation")
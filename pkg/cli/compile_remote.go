@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+)
+
+// maxFollowRetries is how often following a log reconnects without
+// receiving anything before giving up
+const maxFollowRetries = 3
+
+// runRemoteCompile asks the registry to compile a version, printing the
+// compiler output of each job until it finishes when following
+func runRemoteCompile(registry, module, version string, languages []string, includeGRPC, follow bool, out io.Writer) error {
+	if module == "" || version == "" {
+		return fmt.Errorf("module and version are required with --remote")
+	}
+
+	body, err := json.Marshal(api.CompileRequest{Languages: languages, IncludeGRPC: includeGRPC})
+	if err != nil {
+		return fmt.Errorf("failed to encode compile request: %w", err)
+	}
+	compileURL := fmt.Sprintf("%s/api/v1/modules/%s/versions/%s/compile", registry, module, version)
+	resp, err := http.Post(compileURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to request compilation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to request compilation: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	var compiled api.CompileResponse
+	if err := json.NewDecoder(resp.Body).Decode(&compiled); err != nil {
+		return fmt.Errorf("failed to decode compile response: %w", err)
+	}
+
+	// Servers without a compile queue compile before responding
+	if resp.StatusCode == http.StatusOK {
+		fmt.Fprintf(out, "Compiled %s@%s\n", module, version)
+		return printCompileResults(out, compiled.Results)
+	}
+
+	fmt.Fprintf(out, "Queued compilation of %s@%s\n", module, version)
+	for _, job := range compiled.Results {
+		fmt.Fprintf(out, "  %s: %s (%s)\n", job.Language, job.ID, job.Status)
+	}
+	if !follow {
+		return nil
+	}
+
+	results := make([]api.CompilationJobInfo, 0, len(compiled.Results))
+	for _, job := range compiled.Results {
+		fmt.Fprintf(out, "\n=== Compiling for %s ===\n", job.Language)
+		info, err := followCompileLogs(fmt.Sprintf("%s/%s/logs", compileURL, job.ID), out)
+		if err != nil {
+			return fmt.Errorf("failed to follow %s compilation: %w", job.Language, err)
+		}
+		results = append(results, *info)
+	}
+	fmt.Fprintln(out)
+	return printCompileResults(out, results)
+}
+
+// printCompileResults prints the outcome of compile jobs, failing when any
+// of them did not complete
+func printCompileResults(out io.Writer, results []api.CompilationJobInfo) error {
+	failed := 0
+	for _, job := range results {
+		switch job.Status {
+		case "completed":
+			fmt.Fprintf(out, "✓ %s completed in %s\n", job.Language, time.Duration(job.Duration)*time.Millisecond)
+		case "pending", "running":
+			fmt.Fprintf(out, "… %s %s\n", job.Language, job.Status)
+		default:
+			failed++
+			fmt.Fprintf(out, "✗ %s %s: %s\n", job.Language, job.Status, job.Error)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d compilations did not complete", failed, len(results))
+	}
+	return nil
+}
+
+// followCompileLogs prints the log stream of a compile job until it ends
+// with the finished job, reconnecting where it left off when the stream
+// breaks
+func followCompileLogs(logsURL string, out io.Writer) (*api.CompilationJobInfo, error) {
+	lastID := ""
+	for retries := 0; ; retries++ {
+		req, err := http.NewRequest(http.MethodGet, logsURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		if lastID != "" {
+			req.Header.Set("Last-Event-ID", lastID)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil && resp.StatusCode != http.StatusOK {
+			message, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(message)))
+		}
+		if err == nil {
+			var info *api.CompilationJobInfo
+			var received string
+			info, received, err = readCompileEvents(resp.Body, out)
+			resp.Body.Close()
+			if info != nil {
+				return info, nil
+			}
+			if received != "" {
+				lastID = received
+				retries = 0
+			}
+		}
+		if retries >= maxFollowRetries {
+			if err == nil {
+				err = fmt.Errorf("log stream ended before the job finished")
+			}
+			return nil, err
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// readCompileEvents prints the log events of a stream, returning the
+// finished job when the stream ends with it and the ID of the last log event
+// read
+func readCompileEvents(r io.Reader, out io.Writer) (*api.CompilationJobInfo, string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 2*1024*1024)
+
+	var lastID, id, event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "id":
+				id = value
+			case "event":
+				event = value
+			case "data":
+				data = append(data, value)
+			}
+			continue
+		}
+
+		// A blank line ends an event
+		switch event {
+		case "log":
+			fmt.Fprintln(out, strings.Join(data, "\n"))
+			if id != "" {
+				lastID = id
+			}
+		case "status":
+			var info api.CompilationJobInfo
+			if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &info); err != nil {
+				return nil, lastID, fmt.Errorf("failed to decode job status: %w", err)
+			}
+			return &info, lastID, nil
+		case "error":
+			return nil, lastID, fmt.Errorf("server error: %s", strings.Join(data, "\n"))
+		}
+		id, event, data = "", "", nil
+	}
+	return nil, lastID, scanner.Err()
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRemoteCompile_Follow(t *testing.T) {
+	var requested api.CompileRequest
+	var resumedFrom []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const base = "/api/v1/modules/users/versions/v1.0.0/compile"
+		switch {
+		case r.Method == "POST" && r.URL.Path == base:
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&requested))
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(api.CompileResponse{
+				JobID: "users-v1.0.0",
+				Results: []api.CompilationJobInfo{
+					{ID: "job-go", Language: "go", Status: "pending"},
+					{ID: "job-python", Language: "python", Status: "pending"},
+				},
+			})
+
+		case r.URL.Path == base+"/job-go/logs":
+			w.Header().Set("Content-Type", "text/event-stream")
+			resumedFrom = append(resumedFrom, r.Header.Get("Last-Event-ID"))
+			if r.Header.Get("Last-Event-ID") == "" {
+				// The first stream breaks before the job finishes
+				fmt.Fprint(w, "id: 1\nevent: log\ndata: Attempt 1 of 3 on w1\n\n")
+				return
+			}
+			fmt.Fprint(w, ": keep-alive\n\n")
+			fmt.Fprint(w, "id: 2\nevent: log\ndata: Completed in 1.2s\n\n")
+			fmt.Fprint(w, "event: status\ndata: {\"id\":\"job-go\",\"language\":\"go\",\"status\":\"completed\",\"duration_ms\":1200}\n\n")
+
+		case r.URL.Path == base+"/job-python/logs":
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "id: 7\nevent: log\ndata: users.proto:3:1: syntax error\ndata: Failed: protoc failed\n\n")
+			fmt.Fprint(w, "event: status\ndata: {\"id\":\"job-python\",\"language\":\"python\",\"status\":\"failed\",\"error\":\"protoc failed\"}\n\n")
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	err := runRemoteCompile(server.URL, "users", "v1.0.0", []string{"go", "python"}, true, true, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 2 compilations did not complete")
+
+	assert.Equal(t, []string{"go", "python"}, requested.Languages)
+	assert.True(t, requested.IncludeGRPC)
+	assert.Equal(t, []string{"", "1"}, resumedFrom, "broken streams resume after the last event")
+
+	output := out.String()
+	assert.Contains(t, output, "=== Compiling for go ===\nAttempt 1 of 3 on w1\nCompleted in 1.2s\n")
+	assert.Contains(t, output, "=== Compiling for python ===\nusers.proto:3:1: syntax error\nFailed: protoc failed\n")
+	assert.Contains(t, output, "✓ go completed in 1.2s")
+	assert.Contains(t, output, "✗ python failed: protoc failed")
+	assert.NotContains(t, output, "keep-alive")
+}
+
+func TestRunRemoteCompile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/versions/v9.9.9/") {
+			http.Error(w, "version not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(api.CompileResponse{
+			Results: []api.CompilationJobInfo{{ID: "job-go", Language: "go", Status: "pending"}},
+		})
+	}))
+	defer server.Close()
+
+	// Without following, the queued jobs are printed and left to run
+	var out bytes.Buffer
+	require.NoError(t, runRemoteCompile(server.URL, "users", "v1.0.0", []string{"go"}, false, false, &out))
+	assert.Contains(t, out.String(), "go: job-go (pending)")
+
+	err := runRemoteCompile(server.URL, "users", "v9.9.9", []string{"go"}, false, false, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "version not found")
+
+	err = runRemoteCompile(server.URL, "", "v1.0.0", []string{"go"}, false, false, &out)
+	assert.Error(t, err)
+}
@@ -0,0 +1,295 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/tabwriter"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/compatibility"
+	"github.com/platinummonkey/spoke/pkg/contracts"
+	"gopkg.in/yaml.v3"
+)
+
+func newConsumersCommand() *Command {
+	cmd := &Command{
+		Name:        "consumers",
+		Description: "Manage consumer usage contracts",
+		Subcommands: make(map[string]*Command),
+		Run:         runConsumers,
+	}
+	cmd.Subcommands["register"] = newConsumersRegisterCommand()
+	cmd.Subcommands["list"] = newConsumersListCommand()
+	return cmd
+}
+
+func runConsumers(args []string) error {
+	if len(args) == 0 {
+		return runConsumersHelp(args)
+	}
+
+	consumersCmd := newConsumersCommand()
+	if subcmd, ok := consumersCmd.Subcommands[args[0]]; ok {
+		return subcmd.Run(args[1:])
+	}
+
+	return fmt.Errorf("unknown consumers subcommand: %s", args[0])
+}
+
+func runConsumersHelp(args []string) error {
+	fmt.Println("Usage: spoke consumers <command> [args]")
+	fmt.Println("\nAvailable commands:")
+	fmt.Println("  register    Register which parts of a module a consumer uses")
+	fmt.Println("  list        List the consumers registered against a module")
+	fmt.Println("\nExamples:")
+	fmt.Println("  spoke consumers register -module payments -version v1.2.0 -consumer billing -scan ./")
+	fmt.Println("  spoke consumers register -uses spoke.consumer.yaml")
+	fmt.Println("  spoke consumers list -module payments")
+	return nil
+}
+
+func newConsumersRegisterCommand() *Command {
+	cmd := &Command{
+		Name:        "register",
+		Description: "Register which parts of a module a consumer uses",
+		Flags:       flag.NewFlagSet("consumers register", flag.ExitOnError),
+		Run:         runConsumersRegister,
+	}
+
+	cmd.Flags.String("module", "", "Module name")
+	cmd.Flags.String("version", "", "Module version the consumer is built against")
+	cmd.Flags.String("consumer", "", "Consumer (service) name")
+	cmd.Flags.String("scan", "", "Source directory to scan for usages of the module's generated code")
+	cmd.Flags.String("uses", "", "Workspace file (YAML) declaring the consumer's usages")
+	cmd.Flags.String("registry", "http://localhost:8080", "Registry URL")
+	cmd.Flags.Bool("dry-run", false, "Print the contract instead of registering it")
+
+	return cmd
+}
+
+func newConsumersListCommand() *Command {
+	cmd := &Command{
+		Name:        "list",
+		Description: "List the consumers registered against a module",
+		Flags:       flag.NewFlagSet("consumers list", flag.ExitOnError),
+		Run:         runConsumersList,
+	}
+
+	cmd.Flags.String("module", "", "Module name")
+	cmd.Flags.String("registry", "http://localhost:8080", "Registry URL")
+	cmd.Flags.Bool("json", false, "Output in JSON format")
+
+	return cmd
+}
+
+func runConsumersRegister(args []string) error {
+	cmd := newConsumersRegisterCommand()
+	if err := cmd.Flags.Parse(args); err != nil {
+		return err
+	}
+
+	scanDir := cmd.Flags.Lookup("scan").Value.String()
+	usesFile := cmd.Flags.Lookup("uses").Value.String()
+	registry := cmd.Flags.Lookup("registry").Value.String()
+	dryRun := cmd.Flags.Lookup("dry-run").Value.String() == "true"
+
+	if scanDir == "" && usesFile == "" {
+		return fmt.Errorf("either -scan or -uses is required")
+	}
+
+	// Declared usages come from the workspace file; flags override its header
+	contract := &contracts.Contract{Source: "declared"}
+	if usesFile != "" {
+		declared, err := loadContractFile(usesFile)
+		if err != nil {
+			return err
+		}
+		contract = declared
+		contract.Source = "declared"
+	}
+	if module := cmd.Flags.Lookup("module").Value.String(); module != "" {
+		contract.Module = module
+	}
+	if version := cmd.Flags.Lookup("version").Value.String(); version != "" {
+		contract.Version = version
+	}
+	if consumer := cmd.Flags.Lookup("consumer").Value.String(); consumer != "" {
+		contract.Consumer = consumer
+	}
+
+	if contract.Module == "" || contract.Version == "" || contract.Consumer == "" {
+		return fmt.Errorf("module, version and consumer are required")
+	}
+
+	if scanDir != "" {
+		version, err := fetchVersion(registry, contract.Module, contract.Version)
+		if err != nil {
+			return err
+		}
+		scanned, err := scanUsages(version, scanDir)
+		if err != nil {
+			return err
+		}
+		contract.Uses = mergeUsages(contract.Uses, scanned)
+		contract.Source = "scan"
+	}
+
+	if err := contract.Validate(); err != nil {
+		return err
+	}
+
+	if dryRun {
+		data, err := yaml.Marshal(contract)
+		if err != nil {
+			return fmt.Errorf("failed to encode contract: %w", err)
+		}
+		fmt.Print(string(data))
+		return nil
+	}
+
+	body, err := json.Marshal(contract)
+	if err != nil {
+		return fmt.Errorf("failed to encode contract: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/modules/%s/consumers/%s", registry, contract.Module, contract.Consumer)
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to register contract: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("registry returned error: %s - %s", resp.Status, string(respBody))
+	}
+
+	fmt.Printf("Registered %d usages of %s@%s for consumer %s\n",
+		len(contract.Uses), contract.Module, contract.Version, contract.Consumer)
+	return nil
+}
+
+func runConsumersList(args []string) error {
+	cmd := newConsumersListCommand()
+	if err := cmd.Flags.Parse(args); err != nil {
+		return err
+	}
+
+	module := cmd.Flags.Lookup("module").Value.String()
+	registry := cmd.Flags.Lookup("registry").Value.String()
+	outputJSON := cmd.Flags.Lookup("json").Value.String() == "true"
+
+	if module == "" {
+		return fmt.Errorf("module is required")
+	}
+
+	resp, err := http.Get(fmt.Sprintf("%s/modules/%s/consumers", registry, module))
+	if err != nil {
+		return fmt.Errorf("failed to connect to registry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("registry returned error: %s - %s", resp.Status, string(body))
+	}
+
+	var listing struct {
+		Consumers []*contracts.Contract `json:"consumers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(listing.Consumers)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONSUMER\tVERSION\tSOURCE\tUSAGES\tREGISTERED")
+	for _, c := range listing.Consumers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n",
+			c.Consumer, c.Version, c.Source, len(c.Uses), c.RegisteredAt.Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+// loadContractFile reads a workspace usage declaration
+func loadContractFile(path string) (*contracts.Contract, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage file: %w", err)
+	}
+
+	var contract contracts.Contract
+	if err := yaml.Unmarshal(data, &contract); err != nil {
+		return nil, fmt.Errorf("failed to parse usage file: %w", err)
+	}
+	return &contract, nil
+}
+
+// fetchVersion downloads a module version from the registry
+func fetchVersion(registry, module, version string) (*api.Version, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/modules/%s/versions/%s", registry, module, version))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("version not found")
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("registry returned error: %s - %s", resp.Status, string(body))
+	}
+
+	var versionData api.Version
+	if err := json.NewDecoder(resp.Body).Decode(&versionData); err != nil {
+		return nil, fmt.Errorf("failed to decode version: %w", err)
+	}
+	return &versionData, nil
+}
+
+// scanUsages matches a module version's schema against a consumer's sources
+func scanUsages(version *api.Version, dir string) ([]contracts.Usage, error) {
+	schema, err := compatibility.ParseSchemaFiles(compatibility.SourceFiles(version.Files))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse module schema: %w", err)
+	}
+
+	ids, err := contracts.ScanDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+	return ids.Usages(schema), nil
+}
+
+// mergeUsages appends scanned usages that were not already declared
+func mergeUsages(declared, scanned []contracts.Usage) []contracts.Usage {
+	seen := make(map[contracts.Usage]bool, len(declared))
+	merged := append([]contracts.Usage{}, declared...)
+	for _, u := range declared {
+		seen[u] = true
+	}
+	for _, u := range scanned {
+		if !seen[u] {
+			seen[u] = true
+			merged = append(merged, u)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/contracts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConsumersCommand(t *testing.T) {
+	cmd := newConsumersCommand()
+	assert.Equal(t, "consumers", cmd.Name)
+	assert.NotNil(t, cmd.Subcommands["register"])
+	assert.NotNil(t, cmd.Subcommands["list"])
+
+	register := newConsumersRegisterCommand()
+	for _, name := range []string{"module", "version", "consumer", "scan", "uses", "registry", "dry-run"} {
+		assert.NotNil(t, register.Flags.Lookup(name), "missing flag %s", name)
+	}
+}
+
+func TestRunConsumers_UnknownSubcommand(t *testing.T) {
+	err := runConsumers([]string{"nope"})
+	assert.Error(t, err)
+	assert.NoError(t, runConsumers(nil))
+}
+
+func TestRunConsumersRegister_RequiresSource(t *testing.T) {
+	err := runConsumersRegister([]string{"-module", "payments", "-version", "v1.0.0", "-consumer", "billing"})
+	assert.ErrorContains(t, err, "-scan or -uses")
+}
+
+func TestRunConsumersRegister_ScanAndDeclared(t *testing.T) {
+	proto := `syntax = "proto3";
+package payments.v1;
+message Charge { string id = 1; int64 amount = 2; }
+`
+	var received contracts.Contract
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/modules/payments/versions/v1.0.0":
+			json.NewEncoder(w).Encode(api.Version{
+				ModuleName: "payments",
+				Version:    "v1.0.0",
+				Files:      []api.File{{Path: "payments.proto", Content: proto}},
+			})
+		case r.Method == http.MethodPut && r.URL.Path == "/modules/payments/consumers/billing":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			json.NewEncoder(w).Encode(received)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	require.NoError(t, os.MkdirAll(src, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "main.go"),
+		[]byte("package main\nvar c = &paymentsv1.Charge{Amount: 1}\n"), 0644))
+
+	usesFile := filepath.Join(dir, "spoke.consumer.yaml")
+	require.NoError(t, os.WriteFile(usesFile, []byte(`consumer: billing
+module: payments
+version: v1.0.0
+uses:
+  - kind: field
+    name: payments.v1.Charge.id
+`), 0644))
+
+	err := runConsumersRegister([]string{"-uses", usesFile, "-scan", src, "-registry", server.URL})
+	require.NoError(t, err)
+
+	assert.Equal(t, "billing", received.Consumer)
+	assert.Equal(t, "scan", received.Source)
+	assert.Equal(t, []contracts.Usage{
+		{Kind: contracts.UsageField, Name: "payments.v1.Charge.id"},
+		{Kind: contracts.UsageMessage, Name: "payments.v1.Charge"},
+		{Kind: contracts.UsageField, Name: "payments.v1.Charge.amount"},
+	}, received.Uses)
+}
+
+func TestRunConsumersList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/modules/payments/consumers", r.URL.Path)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"consumers": []contracts.Contract{{Consumer: "billing", Module: "payments", Version: "v1.0.0"}},
+			"count":     1,
+		})
+	}))
+	defer server.Close()
+
+	assert.NoError(t, runConsumersList([]string{"-module", "payments", "-registry", server.URL}))
+	assert.Error(t, runConsumersList([]string{"-registry", server.URL}))
+}
@@ -39,6 +39,15 @@
 //		--languages go,python,typescript \
 //		--parallel
 //
+// Compile a registry version on the server, printing the compiler output:
+//
+//	spoke compile \
+//		--remote \
+//		--module user-service \
+//		--version v1.0.0 \
+//		--languages go,python \
+//		--follow
+//
 // validate: Validate proto file syntax
 //
 //	spoke validate --dir ./proto --recursive
@@ -59,6 +68,20 @@
 //
 //	spoke lint --dir ./proto --style-guide google
 //
+// Both check-compatibility and lint can write CI reports with --format
+// sarif, junit, checkstyle or github (inline pull request annotations):
+//
+//	spoke check-compatibility --old ./base --new ./proto --format sarif > compat.sarif
+//	spoke lint --dir ./proto --format junit > lint-report.xml
+//
+// fmt: Format proto files in place, keeping comments and @spoke directives
+//
+//	spoke fmt -w ./proto
+//
+// In CI, -d prints a diff and fails when any file is not formatted:
+//
+//	spoke fmt -d ./proto
+//
 // languages: List supported languages
 //
 //	spoke languages
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/platinummonkey/spoke/pkg/formatter"
+)
+
+// newFmtCommand creates a new fmt command
+func newFmtCommand() *Command {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+
+	var (
+		write = fs.Bool("w", false, "Write formatted output back to the files")
+		diff  = fs.Bool("d", false, "Print a diff for files that are not formatted and fail if there are any")
+	)
+
+	return &Command{
+		Name:        "fmt",
+		Description: "Format protobuf files, preserving comments and directives",
+		Flags:       fs,
+		Run: func(args []string) error {
+			if err := fs.Parse(args); err != nil {
+				return err
+			}
+
+			paths := fs.Args()
+			if len(paths) == 0 {
+				paths = []string{"."}
+			}
+			return runFmt(paths, *write, *diff)
+		},
+	}
+}
+
+// runFmt formats the given files and directories. Without flags the
+// formatted source is printed; -w rewrites files in place and -d prints a
+// diff, failing when a file needs formatting unless -w also fixed it.
+func runFmt(paths []string, write, diff bool) error {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		found, err := lintFindProtoFiles(path)
+		if err != nil {
+			return fmt.Errorf("failed to find proto files: %w", err)
+		}
+		files = append(files, found...)
+	}
+
+	var unformatted []string
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		formatted, err := formatter.Format(string(content))
+		if err != nil {
+			return fmt.Errorf("failed to format %s: %w", file, err)
+		}
+
+		changed := formatted != string(content)
+		if changed {
+			unformatted = append(unformatted, file)
+		}
+
+		if diff && changed {
+			d, err := formatter.Diff(file, string(content), formatted)
+			if err != nil {
+				return fmt.Errorf("failed to diff %s: %w", file, err)
+			}
+			fmt.Print(d)
+		}
+
+		if write {
+			if changed {
+				if err := writeFileAtomic(file, []byte(formatted)); err != nil {
+					return fmt.Errorf("failed to write %s: %w", file, err)
+				}
+			}
+		} else if !diff {
+			fmt.Print(formatted)
+		}
+	}
+
+	if diff && !write && len(unformatted) > 0 {
+		return fmt.Errorf("%d file(s) are not formatted", len(unformatted))
+	}
+	return nil
+}
+
+// writeFileAtomic replaces a file's content through a temporary file in the
+// same directory, so readers never see a partially written file. The file
+// keeps its permissions.
+func writeFileAtomic(path string, data []byte) error {
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
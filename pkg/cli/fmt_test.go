@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const unformattedProto = `syntax="proto3";
+package users;
+// @spoke:domain:users
+message User{
+    string id=1; // primary key
+}
+`
+
+const formattedUserProto = `syntax = "proto3";
+package users;
+// @spoke:domain:users
+message User {
+  string id = 1; // primary key
+}
+`
+
+func captureFmt(t *testing.T, paths []string, write, diff bool) (string, error) {
+	t.Helper()
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runFmt(paths, write, diff)
+
+	w.Close()
+	os.Stdout = old
+	out, _ := io.ReadAll(r)
+	return string(out), err
+}
+
+func TestNewFmtCommand(t *testing.T) {
+	cmd := newFmtCommand()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "fmt", cmd.Name)
+	assert.NotNil(t, cmd.Flags)
+	assert.NotNil(t, cmd.Flags.Lookup("w"))
+	assert.NotNil(t, cmd.Flags.Lookup("d"))
+}
+
+func TestRunFmt_Print(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "user.proto")
+	require.NoError(t, os.WriteFile(file, []byte(unformattedProto), 0644))
+
+	out, err := captureFmt(t, []string{file}, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, formattedUserProto, out)
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, unformattedProto, string(content), "file must not change without -w")
+}
+
+func TestRunFmt_Write(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "sub", "user.proto")
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	require.NoError(t, os.WriteFile(file, []byte(unformattedProto), 0644))
+
+	out, err := captureFmt(t, []string{dir}, true, false)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, formattedUserProto, string(content))
+
+	// Formatted files pass the diff check
+	out, err = captureFmt(t, []string{dir}, false, true)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestRunFmt_Diff(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "user.proto")
+	require.NoError(t, os.WriteFile(file, []byte(unformattedProto), 0644))
+
+	out, err := captureFmt(t, []string{dir}, false, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 file(s) are not formatted")
+	assert.Contains(t, out, "+++ "+file)
+	assert.Contains(t, out, "-message User{")
+	assert.Contains(t, out, "+message User {")
+}
+
+func TestRunFmt_Errors(t *testing.T) {
+	_, err := captureFmt(t, []string{filepath.Join(t.TempDir(), "missing.proto")}, false, false)
+	assert.ErrorContains(t, err, "failed to stat")
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "broken.proto")
+	require.NoError(t, os.WriteFile(file, []byte("message A {\n"), 0644))
+	_, err = captureFmt(t, []string{file}, true, false)
+	assert.ErrorContains(t, err, "failed to format")
+}
@@ -1,16 +1,20 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
-	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/formatter"
 	"github.com/platinummonkey/spoke/pkg/linter"
 	"github.com/platinummonkey/spoke/pkg/linter/rules"
+	"github.com/platinummonkey/spoke/pkg/reporting"
 )
 
 // newLintCommand creates a new lint command
@@ -20,8 +24,11 @@ func newLintCommand() *Command {
 	var (
 		dir           = fs.String("dir", ".", "Directory containing proto files")
 		configFile    = fs.String("config", "", "Path to lint config file (spoke-lint.yaml)")
-		format        = fs.String("format", "text", "Output format: text, json, github")
+		baselineFile  = fs.String("baseline", "", "Path to the lint baseline (default: spoke-lint-baseline.yaml in -dir, if present)")
+		writeBaseline = fs.Bool("write-baseline", false, "Record the current violations in the baseline and exit")
+		format        = fs.String("format", "text", "Output format: text, json, sarif, junit, checkstyle, github")
 		autoFix       = fs.Bool("fix", false, "Automatically fix violations")
+		fixDryRun     = fs.Bool("fix-dry-run", false, "Print the auto-fixes as a unified diff without writing them")
 		failOnError   = fs.Bool("fail-on-error", true, "Exit with error code on lint errors")
 		failOnWarning = fs.Bool("fail-on-warning", false, "Exit with error code on lint warnings")
 		verbose       = fs.Bool("verbose", false, "Verbose output")
@@ -37,12 +44,12 @@ func newLintCommand() *Command {
 				return err
 			}
 
-			return runLint(*dir, *configFile, *format, *autoFix, *failOnError, *failOnWarning, *verbose, *rulesOnly)
+			return runLint(*dir, *configFile, *baselineFile, *format, *autoFix, *fixDryRun, *writeBaseline, *failOnError, *failOnWarning, *verbose, *rulesOnly)
 		},
 	}
 }
 
-func runLint(dir, configFile, format string, autoFix, failOnError, failOnWarning, verbose, rulesOnly bool) error {
+func runLint(dir, configFile, baselineFile, format string, autoFix, fixDryRun, writeBaseline, failOnError, failOnWarning, verbose, rulesOnly bool) error {
 	// Load configuration
 	var config *linter.Config
 	var err error
@@ -66,9 +73,34 @@ func runLint(dir, configFile, format string, autoFix, failOnError, failOnWarning
 		engine.Registry().Register(rule)
 	}
 
+	// Register the rules of linter plugins, which cannot replace built-in
+	// rules
+	for _, rule := range linter.LoadPluginRules(context.Background(), config.Lint.PluginDirs) {
+		if _, exists := engine.Registry().GetRule(rule.Name()); exists {
+			fmt.Fprintf(os.Stderr, "Warning: skipping plugin rule %s, which is already defined\n", rule.Name())
+			continue
+		}
+		engine.Registry().Register(rule)
+	}
+
 	// List rules if requested
 	if rulesOnly {
-		return lintListRules(engine)
+		return lintListRules(engine, config)
+	}
+
+	// Report only violations the baseline does not accept, unless it is
+	// being rewritten
+	baselinePath := baselineFile
+	if baselinePath == "" {
+		baselinePath = filepath.Join(dir, linter.DefaultBaselineFile)
+	}
+	var baseline *linter.Baseline
+	if !writeBaseline {
+		baseline, err = lintLoadBaseline(baselinePath, baselineFile != "")
+		if err != nil {
+			return err
+		}
+		engine.SetBaseline(baseline)
 	}
 
 	// Find proto files
@@ -86,45 +118,122 @@ func runLint(dir, configFile, format string, autoFix, failOnError, failOnWarning
 		fmt.Printf("Linting %d proto files...\n", len(protoFiles))
 	}
 
-	// Parse and lint files
-	files := make(map[string]*protobuf.RootNode)
+	// Read files, applying fixes first if requested
+	sources := make(map[string]string)
 	for _, file := range protoFiles {
-		content, err := os.ReadFile(file)
+		data, err := os.ReadFile(file)
 		if err != nil {
 			return fmt.Errorf("failed to read %s: %w", file, err)
 		}
+		content := string(data)
 
-		ast, err := protobuf.ParseString(string(content))
-		if err != nil {
-			return fmt.Errorf("failed to parse %s: %w", file, err)
+		// Apply auto-fixes if requested, linting what remains afterwards
+		if autoFix || fixDryRun {
+			content, err = lintFixFile(engine, file, content, fixDryRun, verbose)
+			if err != nil {
+				return err
+			}
 		}
 
-		files[file] = ast
+		sources[file] = content
 	}
 
-	results := engine.LintFiles(files)
+	// Lint the files as one module, so imports between them resolve and
+	// module rules see all of them
+	results, err := engine.LintModuleSources(dir, sources)
+	if err != nil {
+		return fmt.Errorf("failed to parse proto files: %w", err)
+	}
 
-	// Apply auto-fix if requested
-	if autoFix {
-		if verbose {
-			fmt.Println("Applying auto-fixes...")
-		}
-		// TODO: Implement auto-fix application
-		fmt.Println("Auto-fix not yet implemented")
+	if writeBaseline {
+		return lintWriteBaseline(results, baselinePath)
 	}
 
 	// Generate summary
 	summary := engine.GenerateSummary(results)
 
+	// Baseline entries of files no longer linted are stale as a whole
+	var stale []linter.BaselineEntry
+	for _, result := range results {
+		stale = append(stale, result.StaleBaseline...)
+	}
+	if baseline != nil {
+		missing := baseline.MissingFiles(protoFiles)
+		summary.StaleBaseline += len(missing)
+		stale = append(stale, missing...)
+	}
+
 	// Output results
 	switch format {
 	case "json":
-		return lintOutputJSON(results, summary)
-	case "github":
-		return lintOutputGitHub(results)
+		return lintOutputJSON(results, summary, stale)
+	case "sarif", "junit", "checkstyle", "github":
+		return lintOutputReport(results, summary, engine.Registry(), reporting.Format(format), failOnError, failOnWarning)
 	default:
-		return lintOutputText(results, summary, verbose, failOnError, failOnWarning)
+		return lintOutputText(results, summary, stale, verbose, failOnError, failOnWarning)
+	}
+}
+
+// lintLoadBaseline loads the baseline at path. A baseline that was not asked
+// for explicitly is optional.
+func lintLoadBaseline(path string, explicit bool) (*linter.Baseline, error) {
+	if !explicit {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	baseline, err := linter.LoadBaseline(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load baseline: %w", err)
+	}
+	return baseline, nil
+}
+
+// lintWriteBaseline records the violations of results as accepted, relative
+// to the directory of the baseline file
+func lintWriteBaseline(results []linter.LintResult, path string) error {
+	baseline := linter.NewBaseline(results, filepath.Dir(path))
+	if err := baseline.Save(path); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote baseline with %d violations (%d entries) to %s\n", baseline.Violations(), len(baseline.Entries), path)
+	return nil
+}
+
+// lintFixFile applies the auto-fixes for one file. A dry run prints them as a
+// unified diff and returns the original content; otherwise the file is
+// rewritten and the fixed content returned.
+func lintFixFile(engine *linter.LintEngine, file, content string, dryRun, verbose bool) (string, error) {
+	result, err := engine.Fix(file, content)
+	if err != nil {
+		return "", fmt.Errorf("failed to fix %s: %w", file, err)
+	}
+
+	if verbose {
+		for _, skipped := range result.Skipped {
+			fmt.Printf("%s: skipped fix %q (%s): %s\n", file, skipped.Fix.Description, skipped.Rule, skipped.Reason)
+		}
+	}
+	if !result.Changed() {
+		return content, nil
+	}
+
+	if dryRun {
+		d, err := formatter.Diff(file, result.Original, result.Content)
+		if err != nil {
+			return "", fmt.Errorf("failed to diff %s: %w", file, err)
+		}
+		fmt.Print(d)
+		return content, nil
 	}
+
+	if err := writeFileAtomic(file, []byte(result.Content)); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	if verbose {
+		fmt.Printf("%s: applied %d fix(es)\n", file, len(result.Applied))
+	}
+	return result.Content, nil
 }
 
 func lintFindProtoFiles(dir string) ([]string, error) {
@@ -155,11 +264,17 @@ func lintFindProtoFiles(dir string) ([]string, error) {
 	return files, err
 }
 
-func lintListRules(engine *linter.LintEngine) error {
+func lintListRules(engine *linter.LintEngine, config *linter.Config) error {
 	allRules := engine.Registry().GetAllRules()
 
 	fmt.Printf("Available lint rules (%d):\n\n", len(allRules))
 
+	// Rules not selected by the presets in lint.use are marked off
+	enabled := make(map[string]bool)
+	for _, rule := range engine.Registry().GetEnabledRules(config) {
+		enabled[rule.Name()] = true
+	}
+
 	// Group by category
 	byCategory := make(map[linter.Category][]linter.Rule)
 	for _, rule := range allRules {
@@ -167,12 +282,26 @@ func lintListRules(engine *linter.LintEngine) error {
 		byCategory[cat] = append(byCategory[cat], rule)
 	}
 
-	for _, cat := range []linter.Category{
+	// Categories introduced by plugins follow the built-in ones
+	categories := []linter.Category{
 		linter.CategoryNaming,
 		linter.CategoryStyle,
 		linter.CategoryDocumentation,
 		linter.CategoryStructure,
-	} {
+		linter.CategoryAIP,
+	}
+	var extra []string
+	for cat := range byCategory {
+		if !slices.Contains(categories, cat) {
+			extra = append(extra, string(cat))
+		}
+	}
+	sort.Strings(extra)
+	for _, cat := range extra {
+		categories = append(categories, linter.Category(cat))
+	}
+
+	for _, cat := range categories {
 		rules := byCategory[cat]
 		if len(rules) == 0 {
 			continue
@@ -190,6 +319,12 @@ func lintListRules(engine *linter.LintEngine) error {
 			if rule.CanAutoFix() {
 				autofix = " [auto-fix]"
 			}
+			if pluginRule, ok := rule.(*linter.PluginRule); ok {
+				autofix += " [plugin: " + pluginRule.Plugin() + "]"
+			}
+			if !enabled[rule.Name()] {
+				autofix += " [off]"
+			}
 			fmt.Printf("  - %-25s [%s]%s\n    %s\n",
 				rule.Name(),
 				rule.Severity(),
@@ -200,10 +335,11 @@ func lintListRules(engine *linter.LintEngine) error {
 		fmt.Println()
 	}
 
+	fmt.Printf("Presets for lint.use: %s\n", strings.Join(linter.Presets(), ", "))
 	return nil
 }
 
-func lintOutputText(results []linter.LintResult, summary linter.Summary, verbose, failOnError, failOnWarning bool) error {
+func lintOutputText(results []linter.LintResult, summary linter.Summary, stale []linter.BaselineEntry, verbose, failOnError, failOnWarning bool) error {
 	hasViolations := false
 
 	for _, result := range results {
@@ -230,6 +366,17 @@ func lintOutputText(results []linter.LintResult, summary linter.Summary, verbose
 		}
 	}
 
+	if len(stale) > 0 {
+		fmt.Printf("\nStale baseline entries (fixed since the baseline was written; run with --write-baseline to drop them):\n")
+		for _, entry := range stale {
+			at := ""
+			if entry.Path != "" {
+				at = " at " + entry.Path
+			}
+			fmt.Printf("  %s: %s%s (%d fixed)\n", entry.File, entry.Rule, at, entry.Count)
+		}
+	}
+
 	// Print summary
 	fmt.Printf("\n")
 	fmt.Printf("Summary:\n")
@@ -238,6 +385,19 @@ func lintOutputText(results []linter.LintResult, summary linter.Summary, verbose
 	fmt.Printf("  Errors:     %d\n", summary.Errors)
 	fmt.Printf("  Warnings:   %d\n", summary.Warnings)
 	fmt.Printf("  Infos:      %d\n", summary.Infos)
+	if summary.Baselined > 0 || summary.StaleBaseline > 0 {
+		fmt.Printf("  Baselined:  %d\n", summary.Baselined)
+		fmt.Printf("  Stale:      %d\n", summary.StaleBaseline)
+	}
+	if q := summary.Quality; q != nil {
+		fmt.Printf("  Quality:    %.1f/100 (documentation %.1f%%, complexity %.1f, maintainability %.1f)\n",
+			q.QualityScore, q.DocumentationCoverage, q.ComplexityScore, q.MaintainabilityScore)
+		if verbose {
+			for _, result := range results {
+				fmt.Printf("    %s: %.1f\n", result.FilePath, result.Metrics.QualityScore)
+			}
+		}
+	}
 
 	// Exit with error if needed
 	if failOnError && summary.Errors > 0 {
@@ -255,13 +415,15 @@ func lintOutputText(results []linter.LintResult, summary linter.Summary, verbose
 	return nil
 }
 
-func lintOutputJSON(results []linter.LintResult, summary linter.Summary) error {
+func lintOutputJSON(results []linter.LintResult, summary linter.Summary, stale []linter.BaselineEntry) error {
 	output := struct {
-		Results []linter.LintResult `json:"results"`
-		Summary linter.Summary      `json:"summary"`
+		Results       []linter.LintResult    `json:"results"`
+		Summary       linter.Summary         `json:"summary"`
+		StaleBaseline []linter.BaselineEntry `json:"stale_baseline,omitempty"`
 	}{
-		Results: results,
-		Summary: summary,
+		Results:       results,
+		Summary:       summary,
+		StaleBaseline: stale,
 	}
 
 	encoder := json.NewEncoder(os.Stdout)
@@ -269,28 +431,18 @@ func lintOutputJSON(results []linter.LintResult, summary linter.Summary) error {
 	return encoder.Encode(output)
 }
 
-func lintOutputGitHub(results []linter.LintResult) error {
-	// GitHub Actions annotation format
-	// ::error file={name},line={line},col={col}::{message}
-	for _, result := range results {
-		for _, v := range result.Violations {
-			level := "error"
-			if v.Severity == linter.SeverityWarning {
-				level = "warning"
-			} else if v.Severity == linter.SeverityInfo {
-				level = "notice"
-			}
-
-			fmt.Printf("::%s file=%s,line=%d,col=%d::[%s] %s\n",
-				level,
-				result.FilePath,
-				v.Position.Line,
-				v.Position.Column,
-				v.Rule,
-				v.Message,
-			)
-		}
+// lintOutputReport writes results in a CI reporting format
+func lintOutputReport(results []linter.LintResult, summary linter.Summary, registry *linter.RuleRegistry, format reporting.Format, failOnError, failOnWarning bool) error {
+	report := reporting.FromLint(results, registry)
+	if err := reporting.Write(os.Stdout, format, report); err != nil {
+		return fmt.Errorf("failed to write %s report: %w", format, err)
 	}
 
+	if failOnError && summary.Errors > 0 {
+		return fmt.Errorf("lint failed with %d errors", summary.Errors)
+	}
+	if failOnWarning && summary.Warnings > 0 {
+		return fmt.Errorf("lint failed with %d warnings", summary.Warnings)
+	}
 	return nil
 }
@@ -1,8 +1,10 @@
 package cli
 
 import (
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -230,7 +232,7 @@ message testMessage {
 			wantErr:   false,
 		},
 		{
-			name: "auto-fix flag (not implemented yet)",
+			name: "auto-fix flag",
 			setupFiles: map[string]string{
 				"test.proto": validProto,
 			},
@@ -261,7 +263,7 @@ message testMessage {
 				require.NoError(t, err)
 			}
 
-			err := runLint(testDir, configPath, tt.format, tt.autoFix, true, false, tt.verbose, tt.rulesOnly)
+			err := runLint(testDir, configPath, "", tt.format, tt.autoFix, false, false, true, false, tt.verbose, tt.rulesOnly)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -287,7 +289,7 @@ message testMessage {
 	require.NoError(t, err)
 
 	// With fail-on-error=true and violations that are errors, should return error
-	err = runLint(testDir, "", "text", false, true, false, false, false)
+	err = runLint(testDir, "", "", "text", false, false, false, true, false, false, false)
 	// Naming violations are errors, so this should error with fail-on-error=true
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "lint failed")
@@ -308,7 +310,7 @@ message TestMessage {
 	require.NoError(t, err)
 
 	// With valid proto and fail-on-warning, should not error
-	err = runLint(testDir, "", "text", false, false, true, false, false)
+	err = runLint(testDir, "", "", "text", false, false, false, false, true, false, false)
 	assert.NoError(t, err)
 }
 
@@ -343,7 +345,7 @@ autofix:
 	err = os.WriteFile(configPath, []byte(configContent), 0644)
 	require.NoError(t, err)
 
-	err = runLint(testDir, configPath, "text", false, true, false, false, false)
+	err = runLint(testDir, configPath, "", "text", false, false, false, true, false, false, false)
 	assert.NoError(t, err)
 }
 
@@ -362,7 +364,7 @@ message TestMessage {
 	require.NoError(t, err)
 
 	// Try to use a non-existent config file
-	err = runLint(testDir, "/nonexistent/config.yaml", "text", false, true, false, false, false)
+	err = runLint(testDir, "/nonexistent/config.yaml", "", "text", false, false, false, true, false, false, false)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to load config")
 }
@@ -381,7 +383,7 @@ message {
 	err := os.WriteFile(protoPath, []byte(invalidProto), 0644)
 	require.NoError(t, err)
 
-	err = runLint(testDir, "", "text", false, true, false, false, false)
+	err = runLint(testDir, "", "", "text", false, false, false, true, false, false, false)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to parse")
 }
@@ -471,7 +473,7 @@ message TestMessage {
 
 	for _, format := range formats {
 		t.Run(format, func(t *testing.T) {
-			err := runLint(testDir, "", format, false, true, false, false, false)
+			err := runLint(testDir, "", "", format, false, false, false, true, false, false, false)
 			// Should not error on valid proto
 			assert.NoError(t, err)
 		})
@@ -495,7 +497,7 @@ message TestMessage` + string(rune('0'+i)) + ` {
 		require.NoError(t, err)
 	}
 
-	err := runLint(testDir, "", "text", false, true, false, true, false)
+	err := runLint(testDir, "", "", "text", false, false, false, true, false, true, false)
 	assert.NoError(t, err)
 }
 
@@ -516,7 +518,7 @@ message TestMessage {
 	require.NoError(t, err)
 
 	// Run with GitHub format to test that output path
-	err = runLint(testDir, "", "github", false, false, false, false, false)
+	err = runLint(testDir, "", "", "github", false, false, false, false, false, false, false)
 	// Should not error even with violations unless fail-on-error/warning is set
 	assert.NoError(t, err)
 }
@@ -537,7 +539,7 @@ message TestMessage {
 	require.NoError(t, err)
 
 	// Test text output with violations
-	err = runLint(testDir, "", "text", false, false, false, false, false)
+	err = runLint(testDir, "", "", "text", false, false, false, false, false, false, false)
 	assert.NoError(t, err)
 }
 
@@ -557,6 +559,253 @@ message TestMessage {
 	require.NoError(t, err)
 
 	// Test verbose output to hit the suggested fix printing path
-	err = runLint(testDir, "", "text", false, false, false, true, false)
+	err = runLint(testDir, "", "", "text", false, false, false, false, false, true, false)
 	assert.NoError(t, err)
 }
+
+func TestLintReportFormats(t *testing.T) {
+	invalidProto := `syntax = "proto3";
+package test;
+option go_package = "github.com/platinummonkey/spoke/test";
+
+message TestMessage {
+    string BadField = 1;
+}`
+
+	testDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "test.proto"), []byte(invalidProto), 0644))
+
+	for _, format := range []string{"sarif", "junit", "checkstyle", "github"} {
+		t.Run(format, func(t *testing.T) {
+			old := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			err := runLint(testDir, "", "", format, false, false, false, false, false, false, false)
+
+			w.Close()
+			os.Stdout = old
+			out, _ := io.ReadAll(r)
+
+			assert.NoError(t, err)
+			assert.Contains(t, string(out), "test.proto")
+		})
+	}
+}
+
+const fixableProto = `syntax = "proto3";
+package test;
+option go_package = "github.com/platinummonkey/spoke/test";
+
+enum status {
+  STATUS_UNSPECIFIED = 0;
+  statusActive = 1;
+}
+
+message Item {
+  status State = 1;
+}
+`
+
+func TestLintFix(t *testing.T) {
+	testDir := t.TempDir()
+	protoPath := filepath.Join(testDir, "test.proto")
+	require.NoError(t, os.WriteFile(protoPath, []byte(fixableProto), 0644))
+
+	err := runLint(testDir, "", "", "text", true, false, false, true, false, false, false)
+	assert.NoError(t, err)
+
+	fixed, err := os.ReadFile(protoPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(fixed), "enum Status {")
+	assert.Contains(t, string(fixed), "STATUS_ACTIVE = 1;")
+	assert.Contains(t, string(fixed), "Status state = 1;")
+
+	// Nothing is left to fix
+	err = runLint(testDir, "", "", "text", true, false, false, true, false, false, false)
+	assert.NoError(t, err)
+	again, err := os.ReadFile(protoPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(fixed), string(again))
+}
+
+func TestLintFixDryRun(t *testing.T) {
+	testDir := t.TempDir()
+	protoPath := filepath.Join(testDir, "test.proto")
+	require.NoError(t, os.WriteFile(protoPath, []byte(fixableProto), 0644))
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runLint(testDir, "", "", "text", false, true, false, false, false, false, false)
+
+	w.Close()
+	os.Stdout = old
+	out, _ := io.ReadAll(r)
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "+++ "+protoPath)
+	assert.Contains(t, string(out), "-enum status {")
+	assert.Contains(t, string(out), "+enum Status {")
+	assert.Contains(t, string(out), "+  Status state = 1;")
+
+	// The file is left untouched
+	content, err := os.ReadFile(protoPath)
+	require.NoError(t, err)
+	assert.Equal(t, fixableProto, string(content))
+}
+
+func TestLintBaseline(t *testing.T) {
+	testDir := t.TempDir()
+	protoPath := filepath.Join(testDir, "test.proto")
+	require.NoError(t, os.WriteFile(protoPath, []byte(fixableProto), 0644))
+
+	// The existing violations fail the lint until they are baselined
+	err := runLint(testDir, "", "", "text", false, false, false, true, false, false, false)
+	assert.Error(t, err)
+
+	err = runLint(testDir, "", "", "text", false, false, true, true, false, false, false)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(testDir, "spoke-lint-baseline.yaml"))
+
+	err = runLint(testDir, "", "", "text", false, false, false, true, false, false, false)
+	assert.NoError(t, err)
+
+	// New violations are reported, even when lines shift
+	changed := strings.Replace(fixableProto, "message Item {", "// An item.\nmessage Item {\n  string ItemName = 2;", 1)
+	require.NoError(t, os.WriteFile(protoPath, []byte(changed), 0644))
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err = runLint(testDir, "", "", "text", false, false, false, true, false, false, false)
+
+	w.Close()
+	os.Stdout = old
+	out, _ := io.ReadAll(r)
+
+	assert.Error(t, err)
+	assert.Contains(t, string(out), "ItemName")
+	assert.NotContains(t, string(out), "statusActive")
+}
+
+func TestLintBaselineStale(t *testing.T) {
+	testDir := t.TempDir()
+	protoPath := filepath.Join(testDir, "test.proto")
+	require.NoError(t, os.WriteFile(protoPath, []byte(fixableProto), 0644))
+	baselinePath := filepath.Join(t.TempDir(), "baseline.yaml")
+
+	err := runLint(testDir, "", baselinePath, "text", false, false, true, true, false, false, false)
+	require.NoError(t, err)
+
+	fixed := strings.Replace(fixableProto, "statusActive", "STATUS_ACTIVE", 1)
+	require.NoError(t, os.WriteFile(protoPath, []byte(fixed), 0644))
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err = runLint(testDir, "", baselinePath, "text", false, false, false, true, false, false, false)
+
+	w.Close()
+	os.Stdout = old
+	out, _ := io.ReadAll(r)
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "Stale baseline entries")
+	assert.Contains(t, string(out), "enum-value-naming at test.statusActive (1 fixed)")
+
+	// An explicit baseline must exist
+	err = runLint(testDir, "", filepath.Join(testDir, "missing.yaml"), "text", false, false, false, true, false, false, false)
+	assert.ErrorContains(t, err, "failed to load baseline")
+}
+
+func TestLintQualityScore(t *testing.T) {
+	testDir := t.TempDir()
+	protoPath := filepath.Join(testDir, "test.proto")
+	require.NoError(t, os.WriteFile(protoPath, []byte(fixableProto), 0644))
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runLint(testDir, "", "", "text", false, false, false, false, false, true, false)
+
+	w.Close()
+	os.Stdout = old
+	out, _ := io.ReadAll(r)
+
+	assert.NoError(t, err)
+	assert.Regexp(t, `Quality:\s+\d+\.\d/100 \(documentation \d+\.\d%, complexity \d+\.\d, maintainability \d+\.\d\)`, string(out))
+	assert.Contains(t, string(out), protoPath+": ")
+}
+
+func TestLintModule(t *testing.T) {
+	testDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "spoke-lint.yaml"), []byte("lint:\n  use: [basic]\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(testDir, "acme", "v1"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "acme", "v1", "common.proto"),
+		[]byte("syntax = \"proto3\";\npackage acme.v1;\n\nmessage Money {\n  int64 units = 1;\n}\n"), 0644))
+	orderPath := filepath.Join(testDir, "acme", "v1", "order.proto")
+	require.NoError(t, os.WriteFile(orderPath,
+		[]byte("syntax = \"proto3\";\npackage acme.v1;\n\nimport \"acme/v1/common.proto\";\nimport \"google/protobuf/duration.proto\";\n\nmessage Order {\n  Money total = 1;\n}\n"), 0644))
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	// The order file refers to a type of the common file, which only
+	// resolves when the files are parsed together
+	err := runLint(testDir, "", "", "text", false, false, false, false, false, false, false)
+
+	w.Close()
+	os.Stdout = old
+	out, _ := io.ReadAll(r)
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), orderPath)
+	assert.Contains(t, string(out), "Import 'google/protobuf/duration.proto' is not used")
+	assert.NotContains(t, string(out), "acme/v1/common.proto' is not used")
+}
+
+func TestLintPluginRules(t *testing.T) {
+	testDir := t.TempDir()
+	pluginDir := filepath.Join(testDir, "lint-plugins", "acme-rules")
+	require.NoError(t, os.MkdirAll(pluginDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "plugin.yaml"),
+		[]byte("id: acme-rules\nname: Acme Rules\nversion: 1.0.0\napi_version: 1.0.0\ntype: linter\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "linter_spec.yaml"),
+		[]byte("executable: lint.sh\nrules:\n  - name: acme-no-x-prefix\n    category: acme\n    severity: warning\n    description: Fields must not start with x_\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "lint.sh"),
+		[]byte("#!/bin/sh\ncat > /dev/null\necho '{\"violations\": [{\"rule\": \"acme-no-x-prefix\", \"file\": \"user.proto\", \"line\": 4, \"column\": 3, \"message\": \"Field x_name starts with x_\"}]}'\n"), 0755))
+
+	// Plugin directories are relative to the configuration, and plugin rules
+	// take severities like built-in ones
+	protoDir := filepath.Join(testDir, "protos")
+	require.NoError(t, os.MkdirAll(protoDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(protoDir, "spoke-lint.yaml"),
+		[]byte("lint:\n  use: [minimal]\n  plugin_dirs: [../lint-plugins]\n  rules:\n    acme-no-x-prefix: error\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(protoDir, "user.proto"),
+		[]byte("syntax = \"proto3\";\n\nmessage User {\n  string x_name = 1;\n}\n"), 0644))
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	listErr := runLint(protoDir, "", "", "text", false, false, false, false, false, false, true)
+	lintErr := runLint(protoDir, "", "", "text", false, false, false, true, false, false, false)
+
+	w.Close()
+	os.Stdout = old
+	out, _ := io.ReadAll(r)
+
+	assert.NoError(t, listErr)
+	assert.Contains(t, string(out), "Acme Rules:")
+	assert.Contains(t, string(out), "acme-no-x-prefix")
+	assert.Contains(t, string(out), "[plugin: acme-rules]")
+
+	assert.Error(t, lintErr)
+	assert.Contains(t, string(out), "[error] Field x_name starts with x_ (acme-no-x-prefix)")
+}
@@ -215,7 +215,7 @@ func runPush(args []string) error {
 			Branch:     "unknown",
 		}
 	} else {
-		fmt.Printf("Source info found:\n - Repository: %s\n - Branch: %s\n - Commit: %s\n", 
+		fmt.Printf("Source info found:\n - Repository: %s\n - Branch: %s\n - Commit: %s\n",
 			sourceInfo.Repository, sourceInfo.Branch, sourceInfo.CommitSHA)
 	}
 
@@ -239,6 +239,9 @@ func runPush(args []string) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		return rejectedPush(resp.Body)
+	}
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("failed to create version: %s", string(body))
@@ -246,4 +249,28 @@ func runPush(args []string) error {
 
 	fmt.Printf("Successfully pushed %d files to module %s version %s\n", len(files), module, version)
 	return nil
-} 
\ No newline at end of file
+}
+
+// rejectedPush prints the lint report of a version the registry rejected
+// under its module's governance and returns the rejection as an error
+func rejectedPush(body io.Reader) error {
+	var rejection struct {
+		Error string          `json:"error"`
+		Lint  *api.LintReport `json:"lint"`
+	}
+	data, _ := io.ReadAll(body)
+	if err := json.Unmarshal(data, &rejection); err != nil || rejection.Lint == nil {
+		return fmt.Errorf("failed to create version: %s", string(data))
+	}
+
+	for _, parseErr := range rejection.Lint.ParseErrors {
+		fmt.Printf("%s\n", parseErr)
+	}
+	for _, v := range rejection.Lint.Violations {
+		fmt.Printf("%s:%d:%d: %s: %s [%s]\n", v.File, v.Line, v.Column, v.Severity, v.Message, v.Rule)
+	}
+	for _, finding := range rejection.Lint.Validation {
+		fmt.Printf("%s: %s: %s [%s]\n", finding.File, finding.Location, finding.Message, finding.Rule)
+	}
+	return fmt.Errorf("%s", rejection.Error)
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -89,6 +90,44 @@ message TestMessage {
 	}
 }
 
+func TestPushCommand_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/modules/test/versions" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error": "version rejected by module governance: 1 lint violation(s) at or above error",
+				"lint": api.LintReport{
+					Errors: 1,
+					Violations: []api.LintViolation{
+						{File: "test.proto", Line: 4, Column: 1, Rule: "message-naming", Severity: "error", Message: "Message name must be PascalCase"},
+					},
+				},
+			})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	testDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(testDir, "test.proto"), []byte("syntax = \"proto3\";\npackage test;\n\nmessage test {}\n"), 0644)
+	require.NoError(t, err)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err = runPush([]string{"-module", "test", "-version", "v1.0.0", "-dir", testDir, "-registry", server.URL})
+
+	w.Close()
+	os.Stdout = oldStdout
+	out, _ := io.ReadAll(r)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "version rejected by module governance")
+	assert.Contains(t, string(out), "test.proto:4:1: error: Message name must be PascalCase [message-naming]")
+}
+
 func TestGetGitInfo(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -34,6 +34,8 @@ func NewRootCommand() *Command {
 	root.Subcommands["check-compatibility"] = newCheckCompatibilityCommand()
 	root.Subcommands["lint"] = newLintCommand()
 	root.Subcommands["languages"] = newLanguagesCommand()
+	root.Subcommands["consumers"] = newConsumersCommand()
+	root.Subcommands["fmt"] = newFmtCommand()
 
 	return root
 }
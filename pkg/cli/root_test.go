@@ -29,6 +29,8 @@ func TestNewRootCommand(t *testing.T) {
 		"check-compatibility",
 		"lint",
 		"languages",
+		"consumers",
+		"fmt",
 	}
 
 	for _, cmdName := range expectedCommands {
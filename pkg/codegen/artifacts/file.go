@@ -0,0 +1,567 @@
+package artifacts
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/platinummonkey/spoke/pkg/codegen"
+)
+
+// DownloadPathPrefix is the path the Spoke server serves artifact downloads
+// under
+const DownloadPathPrefix = "/artifacts/"
+
+// signingKeyFile holds the key signing download URLs when none is configured
+const signingKeyFile = ".signing-key"
+
+// defaultURLTTL is how long download URLs requested without a TTL stay valid,
+// as for presigned S3 URLs
+const defaultURLTTL = 15 * time.Minute
+
+// FileManager implements artifact storage in a local directory, for
+// deployments without S3. Artifacts use the key layout of S3Manager under
+// the directory, next to a file holding their metadata and checksum, and are
+// downloaded through signed, expiring URLs served by the Spoke server.
+type FileManager struct {
+	config     *Config
+	signingKey []byte
+}
+
+// fileMetadata is stored next to each artifact
+type fileMetadata struct {
+	Format         string            `json:"format"`
+	Hash           string            `json:"hash"`
+	Size           int64             `json:"size"`
+	CompressedSize int64             `json:"compressed_size"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// NewFileManager creates an artifact manager storing artifacts in
+// cfg.LocalDir. Without a configured URL signing key, a key is generated and
+// kept in the directory, so download URLs stay valid across restarts.
+func NewFileManager(cfg *Config) (*FileManager, error) {
+	if cfg == nil || cfg.LocalDir == "" {
+		return nil, fmt.Errorf("local directory required")
+	}
+	if err := os.MkdirAll(cfg.LocalDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+
+	signingKey := []byte(cfg.URLSigningKey)
+	if len(signingKey) == 0 {
+		var err error
+		signingKey, err = loadSigningKey(filepath.Join(cfg.LocalDir, signingKeyFile))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &FileManager{
+		config:     cfg,
+		signingKey: signingKey,
+	}, nil
+}
+
+// Store writes compiled artifacts to the directory
+func (m *FileManager) Store(ctx context.Context, req *StoreRequest) (*StoreResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("store request cannot be nil")
+	}
+	if err := validateNames(req.ModuleName, req.Version, req.Language); err != nil {
+		return nil, err
+	}
+
+	format := req.CompressionFormat
+	if format == "" {
+		format = m.config.CompressionFormat
+	}
+	compressed, size, err := writeArchive(req.Files, format)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(compressed)
+
+	key := m.buildKey(req.ModuleName, req.Version, req.Language, format)
+	if err := writeFileAtomic(m.path(key), compressed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUploadFailed, err)
+	}
+
+	meta, err := json.Marshal(&fileMetadata{
+		Format:         format,
+		Hash:           hex.EncodeToString(hash[:]),
+		Size:           size,
+		CompressedSize: int64(len(compressed)),
+		Metadata:       req.Metadata,
+		CreatedAt:      time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	if err := writeFileAtomic(m.path(m.metadataKey(req.ModuleName, req.Version, req.Language)), meta); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUploadFailed, err)
+	}
+
+	// An earlier store in another format is replaced
+	for _, other := range []string{"tar.gz", "zip", "none"} {
+		if other != format {
+			os.Remove(m.path(m.buildKey(req.ModuleName, req.Version, req.Language, other)))
+		}
+	}
+
+	return &StoreResult{
+		S3Key:          key,
+		Hash:           hex.EncodeToString(hash[:]),
+		Size:           size,
+		CompressedSize: int64(len(compressed)),
+	}, nil
+}
+
+// Retrieve reads compiled artifacts from the directory, verifying their
+// checksum when enabled
+func (m *FileManager) Retrieve(ctx context.Context, req *RetrieveRequest) (*RetrieveResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("retrieve request cannot be nil")
+	}
+	if err := validateNames(req.ModuleName, req.Version, req.Language); err != nil {
+		return nil, err
+	}
+
+	meta, err := m.readMetadata(req.ModuleName, req.Version, req.Language)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(m.path(m.buildKey(req.ModuleName, req.Version, req.Language, meta.Format)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrArtifactNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDownloadFailed, err)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if m.config.EnableChecksum && hash != meta.Hash {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, meta.Hash, hash)
+	}
+
+	files, err := readArchive(data, meta.Format)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecompressionFailed, err)
+	}
+	if req.ExtractTo != "" {
+		if err := extractFiles(req.ExtractTo, files); err != nil {
+			return nil, err
+		}
+	}
+
+	metadata := meta.Metadata
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	return &RetrieveResult{
+		Files:    files,
+		Metadata: metadata,
+		Hash:     hash,
+		Size:     int64(len(data)),
+	}, nil
+}
+
+// Delete removes compiled artifacts from the directory
+func (m *FileManager) Delete(ctx context.Context, moduleName, version, language string) error {
+	if err := validateNames(moduleName, version, language); err != nil {
+		return err
+	}
+
+	keys := []string{m.metadataKey(moduleName, version, language)}
+	for _, format := range []string{"tar.gz", "zip", "none"} {
+		keys = append(keys, m.buildKey(moduleName, version, language, format))
+	}
+	for _, key := range keys {
+		if err := os.Remove(m.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete artifact: %w", err)
+		}
+	}
+	return nil
+}
+
+// Exists checks if artifacts exist
+func (m *FileManager) Exists(ctx context.Context, moduleName, version, language string) (bool, error) {
+	if err := validateNames(moduleName, version, language); err != nil {
+		return false, err
+	}
+
+	_, err := os.Stat(m.path(m.metadataKey(moduleName, version, language)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetURL returns a signed URL, valid for ttl seconds, downloading artifacts
+// from the Spoke server. The URL is relative to the server when no base URL
+// is configured.
+func (m *FileManager) GetURL(ctx context.Context, moduleName, version, language string, ttl int) (string, error) {
+	if err := validateNames(moduleName, version, language); err != nil {
+		return "", err
+	}
+	meta, err := m.readMetadata(moduleName, version, language)
+	if err != nil {
+		return "", err
+	}
+
+	expiresIn := time.Duration(ttl) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = defaultURLTTL
+	}
+	key := m.buildKey(moduleName, version, language, meta.Format)
+	expires := time.Now().Add(expiresIn).Unix()
+
+	escaped := make([]string, 0, 4)
+	for _, segment := range strings.Split(key, "/") {
+		escaped = append(escaped, url.PathEscape(segment))
+	}
+	query := url.Values{
+		"expires":   {strconv.FormatInt(expires, 10)},
+		"signature": {m.sign(key, expires)},
+	}
+	return strings.TrimSuffix(m.config.BaseURL, "/") + DownloadPathPrefix + strings.Join(escaped, "/") + "?" + query.Encode(), nil
+}
+
+// Close releases resources
+func (m *FileManager) Close() error {
+	return nil
+}
+
+// RegisterRoutes registers the route serving downloads of signed URLs
+func (m *FileManager) RegisterRoutes(router *mux.Router) {
+	router.PathPrefix(DownloadPathPrefix).HandlerFunc(m.serveDownload).Methods("GET", "HEAD")
+}
+
+// serveDownload handles GET /artifacts/{key}?expires=...&signature=...
+func (m *FileManager) serveDownload(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, DownloadPathPrefix)
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil || !hmac.Equal([]byte(r.URL.Query().Get("signature")), []byte(m.sign(key, expires))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+	if time.Now().Unix() > expires {
+		http.Error(w, "URL expired", http.StatusForbidden)
+		return
+	}
+	if key == "" || path.Clean(key) != key || strings.HasPrefix(key, "/") || strings.HasPrefix(key, "..") {
+		http.Error(w, "invalid artifact key", http.StatusBadRequest)
+		return
+	}
+
+	f, err := os.Open(m.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, ErrArtifactNotFound.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType(key))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(key)))
+	w.Header().Set("Cache-Control", "private, max-age=0")
+	http.ServeContent(w, r, path.Base(key), info.ModTime(), f)
+}
+
+// sign returns the signature of a download URL of a key expiring at expires
+func (m *FileManager) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, m.signingKey)
+	fmt.Fprintf(mac, "%s\n%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// buildKey builds the key of an artifact, laid out as S3 keys are
+func (m *FileManager) buildKey(moduleName, version, language, format string) string {
+	// Format: {prefix}/{moduleName}/{version}/{language}.{extension}
+	return path.Join(m.config.S3Prefix, moduleName, version, language+archiveExtension(format))
+}
+
+// metadataKey builds the key of the metadata of an artifact
+func (m *FileManager) metadataKey(moduleName, version, language string) string {
+	return path.Join(m.config.S3Prefix, moduleName, version, language+".json")
+}
+
+// path returns the file a key is stored in
+func (m *FileManager) path(key string) string {
+	return filepath.Join(m.config.LocalDir, filepath.FromSlash(key))
+}
+
+// readMetadata reads the metadata stored next to an artifact
+func (m *FileManager) readMetadata(moduleName, version, language string) (*fileMetadata, error) {
+	data, err := os.ReadFile(m.path(m.metadataKey(moduleName, version, language)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrArtifactNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDownloadFailed, err)
+	}
+
+	var meta fileMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("%w: invalid metadata: %v", ErrDownloadFailed, err)
+	}
+	return &meta, nil
+}
+
+// validateNames rejects names that would place artifacts outside their
+// module's directory
+func validateNames(names ...string) error {
+	for _, name := range names {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("invalid artifact name %q", name)
+		}
+	}
+	return nil
+}
+
+// loadSigningKey reads the signing key kept in path, generating it first if
+// it does not exist
+func loadSigningKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil && len(key) > 0 {
+		return key, nil
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	key = []byte(hex.EncodeToString(random))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		// Another manager created the key meanwhile
+		return loadSigningKey(path)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to store signing key: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(key); err != nil {
+		return nil, fmt.Errorf("failed to store signing key: %w", err)
+	}
+	return key, nil
+}
+
+// writeFileAtomic writes a file through a temporary file, so readers never
+// see it partially written
+func writeFileAtomic(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// extractFiles writes files under dir
+func extractFiles(dir string, files []codegen.GeneratedFile) error {
+	for _, file := range files {
+		name := filepath.FromSlash(path.Clean("/" + file.Path))
+		target := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", file.Path, err)
+		}
+		if err := os.WriteFile(target, file.Content, 0644); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", file.Path, err)
+		}
+	}
+	return nil
+}
+
+// archiveExtension returns the file extension of a compression format
+func archiveExtension(format string) string {
+	switch format {
+	case "zip":
+		return ".zip"
+	case "none":
+		return ".tar"
+	default:
+		return ".tar.gz"
+	}
+}
+
+// contentType returns the content type of an artifact key
+func contentType(key string) string {
+	switch {
+	case strings.HasSuffix(key, ".zip"):
+		return "application/zip"
+	case strings.HasSuffix(key, ".tar.gz"):
+		return "application/gzip"
+	case strings.HasSuffix(key, ".tar"):
+		return "application/x-tar"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// writeArchive archives files in a compression format: "tar.gz", "zip", or
+// "none" for an uncompressed tar
+func writeArchive(files []codegen.GeneratedFile, format string) ([]byte, int64, error) {
+	var buf bytes.Buffer
+	var size int64
+	for _, file := range files {
+		size += int64(len(file.Content))
+	}
+
+	switch format {
+	case "tar.gz", "none":
+		var w io.Writer = &buf
+		var gzWriter *gzip.Writer
+		if format == "tar.gz" {
+			gzWriter = gzip.NewWriter(&buf)
+			w = gzWriter
+		}
+		tarWriter := tar.NewWriter(w)
+		for _, file := range files {
+			header := &tar.Header{
+				Name: file.Path,
+				Mode: 0644,
+				Size: int64(len(file.Content)),
+			}
+			if err := tarWriter.WriteHeader(header); err != nil {
+				return nil, 0, fmt.Errorf("%w: %v", ErrCompressionFailed, err)
+			}
+			if _, err := tarWriter.Write(file.Content); err != nil {
+				return nil, 0, fmt.Errorf("%w: %v", ErrCompressionFailed, err)
+			}
+		}
+		if err := tarWriter.Close(); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrCompressionFailed, err)
+		}
+		if gzWriter != nil {
+			if err := gzWriter.Close(); err != nil {
+				return nil, 0, fmt.Errorf("%w: %v", ErrCompressionFailed, err)
+			}
+		}
+	case "zip":
+		zipWriter := zip.NewWriter(&buf)
+		for _, file := range files {
+			w, err := zipWriter.Create(file.Path)
+			if err != nil {
+				return nil, 0, fmt.Errorf("%w: %v", ErrCompressionFailed, err)
+			}
+			if _, err := w.Write(file.Content); err != nil {
+				return nil, 0, fmt.Errorf("%w: %v", ErrCompressionFailed, err)
+			}
+		}
+		if err := zipWriter.Close(); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrCompressionFailed, err)
+		}
+	default:
+		return nil, 0, fmt.Errorf("%w: unknown compression format %q", ErrCompressionFailed, format)
+	}
+
+	return buf.Bytes(), size, nil
+}
+
+// readArchive reads the files of an archive written by writeArchive
+func readArchive(data []byte, format string) ([]codegen.GeneratedFile, error) {
+	var files []codegen.GeneratedFile
+
+	switch format {
+	case "tar.gz", "none":
+		var r io.Reader = bytes.NewReader(data)
+		if format == "tar.gz" {
+			gzReader, err := gzip.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			defer gzReader.Close()
+			r = gzReader
+		}
+		tarReader := tar.NewReader(r)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			content, err := io.ReadAll(tarReader)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, codegen.GeneratedFile{
+				Path:    header.Name,
+				Content: content,
+				Size:    header.Size,
+			})
+		}
+	case "zip":
+		zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range zipReader.File {
+			rc, err := entry.Open()
+			if err != nil {
+				return nil, err
+			}
+			content, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, codegen.GeneratedFile{
+				Path:    entry.Name,
+				Content: content,
+				Size:    int64(len(content)),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unknown compression format %q", format)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,248 @@
+package artifacts
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFileManager(t *testing.T) *FileManager {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.LocalDir = t.TempDir()
+	cfg.BaseURL = "https://spoke.example.com/"
+	m, err := NewFileManager(cfg)
+	require.NoError(t, err)
+	return m
+}
+
+func testStoreRequest(format string) *StoreRequest {
+	return &StoreRequest{
+		ModuleName: "users",
+		Version:    "v1.0.0",
+		Language:   "go",
+		Files: []codegen.GeneratedFile{
+			{Path: "users.pb.go", Content: []byte("package users")},
+			{Path: "v1/types.pb.go", Content: []byte("package v1")},
+		},
+		Metadata:          map[string]string{"plugin_version": "v1.31.0"},
+		CompressionFormat: format,
+	}
+}
+
+func TestNewFileManager(t *testing.T) {
+	_, err := NewFileManager(nil)
+	assert.Error(t, err)
+	_, err = NewFileManager(DefaultConfig())
+	assert.Error(t, err)
+
+	// The generated signing key is kept, so URLs survive restarts
+	cfg := DefaultConfig()
+	cfg.LocalDir = t.TempDir()
+	first, err := NewFileManager(cfg)
+	require.NoError(t, err)
+	second, err := NewFileManager(cfg)
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.signingKey)
+	assert.Equal(t, first.signingKey, second.signingKey)
+
+	info, err := os.Stat(filepath.Join(cfg.LocalDir, signingKeyFile))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	cfg.URLSigningKey = "configured"
+	third, err := NewFileManager(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("configured"), third.signingKey)
+}
+
+func TestFileManager_RoundTrip(t *testing.T) {
+	for _, format := range []string{"tar.gz", "zip", "none"} {
+		t.Run(format, func(t *testing.T) {
+			m := newTestFileManager(t)
+			ctx := context.Background()
+
+			stored, err := m.Store(ctx, testStoreRequest(format))
+			require.NoError(t, err)
+			assert.Equal(t, "compiled/users/v1.0.0/go"+archiveExtension(format), stored.S3Key)
+			assert.Empty(t, stored.S3Bucket)
+			assert.Equal(t, int64(23), stored.Size)
+			assert.Len(t, stored.Hash, 64)
+			assert.FileExists(t, filepath.Join(m.config.LocalDir, "compiled", "users", "v1.0.0", "go"+archiveExtension(format)))
+
+			exists, err := m.Exists(ctx, "users", "v1.0.0", "go")
+			require.NoError(t, err)
+			assert.True(t, exists)
+
+			extractTo := t.TempDir()
+			retrieved, err := m.Retrieve(ctx, &RetrieveRequest{ModuleName: "users", Version: "v1.0.0", Language: "go", ExtractTo: extractTo})
+			require.NoError(t, err)
+			assert.Equal(t, stored.Hash, retrieved.Hash)
+			assert.Equal(t, stored.CompressedSize, retrieved.Size)
+			assert.Equal(t, "v1.31.0", retrieved.Metadata["plugin_version"])
+			require.Len(t, retrieved.Files, 2)
+			assert.Equal(t, "v1/types.pb.go", retrieved.Files[1].Path)
+			assert.Equal(t, []byte("package v1"), retrieved.Files[1].Content)
+
+			content, err := os.ReadFile(filepath.Join(extractTo, "v1", "types.pb.go"))
+			require.NoError(t, err)
+			assert.Equal(t, "package v1", string(content))
+
+			require.NoError(t, m.Delete(ctx, "users", "v1.0.0", "go"))
+			exists, err = m.Exists(ctx, "users", "v1.0.0", "go")
+			require.NoError(t, err)
+			assert.False(t, exists)
+			_, err = m.Retrieve(ctx, &RetrieveRequest{ModuleName: "users", Version: "v1.0.0", Language: "go"})
+			assert.ErrorIs(t, err, ErrArtifactNotFound)
+		})
+	}
+}
+
+func TestFileManager_StoreReplacesFormat(t *testing.T) {
+	m := newTestFileManager(t)
+	ctx := context.Background()
+
+	_, err := m.Store(ctx, testStoreRequest("zip"))
+	require.NoError(t, err)
+	_, err = m.Store(ctx, testStoreRequest(""))
+	require.NoError(t, err)
+
+	dir := filepath.Join(m.config.LocalDir, "compiled", "users", "v1.0.0")
+	assert.NoFileExists(t, filepath.Join(dir, "go.zip"))
+	assert.FileExists(t, filepath.Join(dir, "go.tar.gz"), "the configured format is the default")
+
+	_, err = m.Store(ctx, testStoreRequest("rar"))
+	assert.ErrorIs(t, err, ErrCompressionFailed)
+}
+
+func TestFileManager_Checksum(t *testing.T) {
+	m := newTestFileManager(t)
+	ctx := context.Background()
+
+	stored, err := m.Store(ctx, testStoreRequest("tar.gz"))
+	require.NoError(t, err)
+	archive := filepath.Join(m.config.LocalDir, filepath.FromSlash(stored.S3Key))
+	data, err := os.ReadFile(archive)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(archive, data[:len(data)/2], 0644))
+
+	req := &RetrieveRequest{ModuleName: "users", Version: "v1.0.0", Language: "go"}
+	_, err = m.Retrieve(ctx, req)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+	// Without verification the corruption surfaces when decompressing
+	m.config.EnableChecksum = false
+	_, err = m.Retrieve(ctx, req)
+	assert.ErrorIs(t, err, ErrDecompressionFailed)
+}
+
+func TestFileManager_InvalidNames(t *testing.T) {
+	m := newTestFileManager(t)
+	ctx := context.Background()
+
+	req := testStoreRequest("tar.gz")
+	req.ModuleName = "../../etc"
+	_, err := m.Store(ctx, req)
+	assert.Error(t, err)
+
+	_, err = m.Exists(ctx, "users", "..", "go")
+	assert.Error(t, err)
+	_, err = m.GetURL(ctx, "users", "v1.0.0", "", 60)
+	assert.Error(t, err)
+	assert.Error(t, m.Delete(ctx, "users", "v1.0.0", `go\..`))
+}
+
+func TestFileManager_GetURL(t *testing.T) {
+	m := newTestFileManager(t)
+	ctx := context.Background()
+
+	_, err := m.GetURL(ctx, "users", "v1.0.0", "go", 60)
+	assert.ErrorIs(t, err, ErrArtifactNotFound)
+
+	_, err = m.Store(ctx, testStoreRequest("tar.gz"))
+	require.NoError(t, err)
+	rawURL, err := m.GetURL(ctx, "users", "v1.0.0", "go", 60)
+	require.NoError(t, err)
+
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	assert.Equal(t, "spoke.example.com", u.Host)
+	assert.Equal(t, "/artifacts/compiled/users/v1.0.0/go.tar.gz", u.Path)
+	expires, err := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Now().Add(time.Minute).Unix(), expires, 2)
+
+	// URLs requested without a TTL expire as presigned S3 URLs do
+	rawURL, err = m.GetURL(ctx, "users", "v1.0.0", "go", 0)
+	require.NoError(t, err)
+	u, err = url.Parse(rawURL)
+	require.NoError(t, err)
+	expires, err = strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Now().Add(defaultURLTTL).Unix(), expires, 2)
+}
+
+func TestFileManager_ServeDownload(t *testing.T) {
+	m := newTestFileManager(t)
+	m.config.BaseURL = ""
+	ctx := context.Background()
+	router := mux.NewRouter()
+	m.RegisterRoutes(router)
+
+	get := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	req := testStoreRequest("tar.gz")
+	req.Version = "v1.0.0+build.1"
+	stored, err := m.Store(ctx, req)
+	require.NoError(t, err)
+	target, err := m.GetURL(ctx, "users", "v1.0.0+build.1", "go", 60)
+	require.NoError(t, err)
+	assert.Contains(t, target, "/v1.0.0+build.1/")
+
+	rec := get("GET", target)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/gzip", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="go.tar.gz"`, rec.Header().Get("Content-Disposition"))
+	files, err := readArchive(rec.Body.Bytes(), "tar.gz")
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	rec = get("HEAD", target)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, strconv.FormatInt(stored.CompressedSize, 10), rec.Header().Get("Content-Length"))
+
+	// URLs are valid only as signed and until they expire
+	u, err := url.Parse(target)
+	require.NoError(t, err)
+	query := u.Query()
+	query.Set("signature", "00"+query.Get("signature")[2:])
+	assert.Equal(t, http.StatusForbidden, get("GET", u.Path+"?"+query.Encode()).Code)
+
+	assert.Equal(t, http.StatusForbidden, get("GET", "/artifacts/compiled/users/v1.0.0/go.json?"+u.RawQuery).Code)
+
+	expired := time.Now().Add(-time.Minute).Unix()
+	rec = get("GET", u.Path+"?expires="+strconv.FormatInt(expired, 10)+"&signature="+m.sign("compiled/users/v1.0.0+build.1/go.tar.gz", expired))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	body, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(body), "expired")
+
+	// Deleted artifacts are gone even for valid URLs
+	require.NoError(t, m.Delete(ctx, "users", "v1.0.0+build.1", "go"))
+	assert.Equal(t, http.StatusNotFound, get("GET", target).Code)
+}
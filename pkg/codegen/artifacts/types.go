@@ -30,11 +30,11 @@ type Manager interface {
 
 // StoreRequest represents a request to store compiled artifacts
 type StoreRequest struct {
-	ModuleName     string
-	Version        string
-	Language       string
-	Files          []codegen.GeneratedFile
-	Metadata       map[string]string
+	ModuleName        string
+	Version           string
+	Language          string
+	Files             []codegen.GeneratedFile
+	Metadata          map[string]string
 	CompressionFormat string // "zip", "tar.gz", or "none"
 }
 
@@ -49,27 +49,32 @@ type StoreResult struct {
 
 // RetrieveRequest represents a request to retrieve compiled artifacts
 type RetrieveRequest struct {
-	ModuleName     string
-	Version        string
-	Language       string
-	ExtractTo      string // Directory to extract files to
+	ModuleName string
+	Version    string
+	Language   string
+	ExtractTo  string // Directory to extract files to
 }
 
 // RetrieveResult represents the result of retrieving artifacts
 type RetrieveResult struct {
-	Files          []codegen.GeneratedFile
-	Metadata       map[string]string
-	Hash           string
-	Size           int64
+	Files    []codegen.GeneratedFile
+	Metadata map[string]string
+	Hash     string
+	Size     int64
 }
 
 // Config holds artifact manager configuration
 type Config struct {
-	S3Bucket           string
-	S3Prefix           string
-	S3Region           string
-	CompressionFormat  string // Default compression format
-	EnableChecksum     bool   // Verify checksums on download
+	S3Bucket          string
+	S3Prefix          string
+	S3Region          string
+	CompressionFormat string // Default compression format
+	EnableChecksum    bool   // Verify checksums on download
+
+	// Local storage, for FileManager
+	LocalDir      string // Directory artifacts are stored in
+	BaseURL       string // External URL of the Spoke server, prefixing download URLs
+	URLSigningKey string // Key signing download URLs; generated and kept in LocalDir when empty
 }
 
 // DefaultConfig returns default configuration
@@ -132,8 +132,10 @@ func (c *MemoryCache) Close() error {
 
 // metrics tracks cache metrics
 type metrics struct {
-	hits   atomic.Int64
-	misses atomic.Int64
+	hits     atomic.Int64
+	misses   atomic.Int64
+	rejected atomic.Int64
+	errors   atomic.Int64
 }
 
 func newMetrics() *metrics {
@@ -148,6 +150,14 @@ func (m *metrics) recordMiss() {
 	m.misses.Add(1)
 }
 
+func (m *metrics) recordRejected() {
+	m.rejected.Add(1)
+}
+
+func (m *metrics) recordError() {
+	m.errors.Add(1)
+}
+
 func (m *metrics) getHits() int64 {
 	return m.hits.Load()
 }
@@ -155,3 +165,25 @@ func (m *metrics) getHits() int64 {
 func (m *metrics) getMisses() int64 {
 	return m.misses.Load()
 }
+
+func (m *metrics) getRejected() int64 {
+	return m.rejected.Load()
+}
+
+func (m *metrics) getErrors() int64 {
+	return m.errors.Load()
+}
+
+// stats returns the statistics of the recorded metrics
+func (m *metrics) stats() *Stats {
+	stats := &Stats{
+		Hits:     m.getHits(),
+		Misses:   m.getMisses(),
+		Rejected: m.getRejected(),
+		Errors:   m.getErrors(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
@@ -14,4 +14,7 @@ var (
 
 	// ErrCacheFull is returned when the cache is full
 	ErrCacheFull = errors.New("cache full")
+
+	// ErrEntryTooLarge is returned when a result exceeds the maximum entry size
+	ErrEntryTooLarge = errors.New("cache entry too large")
 )
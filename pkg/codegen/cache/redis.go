@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/platinummonkey/spoke/pkg/codegen"
+)
+
+// RedisCache implements a cache shared by all servers and surviving restarts.
+// Results are stored as gzip-compressed JSON under the key prefix followed by
+// FormatCacheKey, so the results of a module version can be found by prefix.
+type RedisCache struct {
+	config  *RedisConfig
+	client  *redis.Client
+	metrics *metrics
+}
+
+// NewRedisCache creates a Redis cache and checks that Redis is reachable
+func NewRedisCache(config *RedisConfig) (*RedisCache, error) {
+	if config == nil || config.Addr == "" {
+		return nil, fmt.Errorf("redis address required")
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:         config.Addr,
+		Password:     config.Password,
+		DB:           config.DB,
+		DialTimeout:  5 * time.Second,
+		ReadTimeout:  3 * time.Second,
+		WriteTimeout: 3 * time.Second,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("%w: failed to connect to redis: %v", ErrCacheUnavailable, err)
+	}
+
+	return &RedisCache{
+		config:  config,
+		client:  client,
+		metrics: newMetrics(),
+	}, nil
+}
+
+// Get retrieves a cached compilation result
+func (c *RedisCache) Get(ctx context.Context, key *codegen.CacheKey) (*codegen.CompilationResult, error) {
+	if key == nil {
+		return nil, ErrInvalidCacheKey
+	}
+
+	data, err := c.client.Get(ctx, c.key(key)).Bytes()
+	if err == redis.Nil {
+		c.metrics.recordMiss()
+		return nil, ErrCacheMiss
+	} else if err != nil {
+		c.metrics.recordError()
+		return nil, fmt.Errorf("%w: redis get failed: %v", ErrCacheUnavailable, err)
+	}
+
+	result, err := decodeResult(data)
+	if err != nil {
+		// Drop the corrupt entry so the result is stored again
+		c.client.Del(ctx, c.key(key))
+		c.metrics.recordMiss()
+		return nil, ErrCacheMiss
+	}
+
+	c.metrics.recordHit()
+	return result, nil
+}
+
+// Set stores a compilation result in cache. Results larger than the maximum
+// entry size once compressed are not stored.
+func (c *RedisCache) Set(ctx context.Context, key *codegen.CacheKey, result *codegen.CompilationResult, ttl time.Duration) error {
+	if key == nil {
+		return ErrInvalidCacheKey
+	}
+	if result == nil {
+		return fmt.Errorf("result cannot be nil")
+	}
+	if ttl <= 0 {
+		ttl = c.config.TTL
+	}
+
+	data, err := encodeResult(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	if c.config.MaxEntrySize > 0 && int64(len(data)) > c.config.MaxEntrySize {
+		c.metrics.recordRejected()
+		return fmt.Errorf("%w: %d bytes compressed, limit is %d", ErrEntryTooLarge, len(data), c.config.MaxEntrySize)
+	}
+
+	if err := c.client.Set(ctx, c.key(key), data, ttl).Err(); err != nil {
+		c.metrics.recordError()
+		return fmt.Errorf("%w: redis set failed: %v", ErrCacheUnavailable, err)
+	}
+	return nil
+}
+
+// Delete removes a cached result
+func (c *RedisCache) Delete(ctx context.Context, key *codegen.CacheKey) error {
+	if key == nil {
+		return ErrInvalidCacheKey
+	}
+
+	if err := c.client.Del(ctx, c.key(key)).Err(); err != nil {
+		c.metrics.recordError()
+		return fmt.Errorf("%w: redis delete failed: %v", ErrCacheUnavailable, err)
+	}
+	return nil
+}
+
+// Invalidate removes all cached results for a module/version
+func (c *RedisCache) Invalidate(ctx context.Context, moduleName, version string) error {
+	if moduleName == "" || version == "" {
+		return fmt.Errorf("module name and version required")
+	}
+
+	pattern := escapePattern(c.config.KeyPrefix+moduleName+":"+version+":") + "*"
+	iter := c.client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		if err := c.client.Del(ctx, iter.Val()).Err(); err != nil {
+			c.metrics.recordError()
+			return fmt.Errorf("%w: failed to delete key %s: %v", ErrCacheUnavailable, iter.Val(), err)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		c.metrics.recordError()
+		return fmt.Errorf("%w: scan failed: %v", ErrCacheUnavailable, err)
+	}
+	return nil
+}
+
+// Stats returns cache statistics. Hits and misses are those of this server;
+// the item count is that of the shared cache.
+func (c *RedisCache) Stats(ctx context.Context) (*Stats, error) {
+	stats := c.metrics.stats()
+
+	iter := c.client.Scan(ctx, 0, escapePattern(c.config.KeyPrefix)+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		stats.ItemCount++
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("%w: scan failed: %v", ErrCacheUnavailable, err)
+	}
+	return stats, nil
+}
+
+// Close releases resources
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
+
+// key returns the Redis key of a cache key
+func (c *RedisCache) key(key *codegen.CacheKey) string {
+	return c.config.KeyPrefix + FormatCacheKey(key)
+}
+
+// encodeResult encodes a result as gzip-compressed JSON
+func encodeResult(result *codegen.CompilationResult) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(result); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeResult decodes a result encoded by encodeResult
+func decodeResult(data []byte) (*codegen.CompilationResult, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var result codegen.CompilationResult
+	if err := json.NewDecoder(zr).Decode(&result); err != nil {
+		return nil, err
+	}
+	// Reading to the end verifies the checksum of the stream
+	if _, err := io.Copy(io.Discard, zr); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// escapePattern escapes the glob characters of a Redis SCAN pattern
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
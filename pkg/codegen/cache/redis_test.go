@@ -0,0 +1,248 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/platinummonkey/spoke/pkg/codegen"
+)
+
+// setupRedisCacheTest starts miniredis and returns a cache connected to it
+func setupRedisCacheTest(t *testing.T) (*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	cache, err := NewRedisCache(DefaultRedisConfig(mr.Addr()))
+	if err != nil {
+		t.Fatalf("Failed to create Redis cache: %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+
+	return cache, mr
+}
+
+func testCacheKey(moduleName, version string) *codegen.CacheKey {
+	return GenerateCacheKey(moduleName, version, "go", "v1.31.0",
+		[]codegen.ProtoFile{{Path: "test.proto", Content: []byte("syntax = \"proto3\";")}},
+		nil, map[string]string{"go_module_path": "example.com/" + moduleName})
+}
+
+func testResult(content string) *codegen.CompilationResult {
+	return &codegen.CompilationResult{
+		Success:  true,
+		Language: "go",
+		GeneratedFiles: []codegen.GeneratedFile{
+			{Path: "test.pb.go", Content: []byte(content), Size: int64(len(content))},
+		},
+		PackageFiles: []codegen.GeneratedFile{
+			{Path: "go.mod", Content: []byte("module example.com/test\n")},
+		},
+		Duration: 2 * time.Second,
+	}
+}
+
+func TestNewRedisCache_Unavailable(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	addr := mr.Addr()
+	mr.Close()
+
+	if _, err := NewRedisCache(DefaultRedisConfig(addr)); !errors.Is(err, ErrCacheUnavailable) {
+		t.Errorf("Expected ErrCacheUnavailable, got: %v", err)
+	}
+	if _, err := NewRedisCache(&RedisConfig{}); err == nil {
+		t.Error("Expected error without address")
+	}
+}
+
+func TestRedisCache_GetSet(t *testing.T) {
+	cache, mr := setupRedisCacheTest(t)
+	ctx := context.Background()
+	key := testCacheKey("users", "v1.0.0")
+
+	if _, err := cache.Get(ctx, key); err != ErrCacheMiss {
+		t.Errorf("Expected cache miss, got: %v", err)
+	}
+
+	content := strings.Repeat("// generated code\n", 1000)
+	if err := cache.Set(ctx, key, testResult(content), time.Hour); err != nil {
+		t.Fatalf("Failed to set cache: %v", err)
+	}
+
+	cached, err := cache.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Failed to get from cache: %v", err)
+	}
+	if !cached.Success || cached.Language != "go" || cached.Duration != 2*time.Second {
+		t.Errorf("Unexpected cached result: %+v", cached)
+	}
+	if len(cached.GeneratedFiles) != 1 || string(cached.GeneratedFiles[0].Content) != content {
+		t.Errorf("Generated files not preserved: %+v", cached.GeneratedFiles)
+	}
+	if len(cached.PackageFiles) != 1 || cached.PackageFiles[0].Path != "go.mod" {
+		t.Errorf("Package files not preserved: %+v", cached.PackageFiles)
+	}
+
+	// Entries are compressed and keyed by prefix and cache key
+	raw, err := mr.Get(DefaultRedisConfig("").KeyPrefix + FormatCacheKey(key))
+	if err != nil {
+		t.Fatalf("Entry not stored under the expected key: %v", err)
+	}
+	if !bytes.HasPrefix([]byte(raw), []byte{0x1f, 0x8b}) {
+		t.Error("Expected entry to be gzip-compressed")
+	}
+	if len(raw) >= len(content) {
+		t.Errorf("Expected compressed entry smaller than %d bytes, got %d", len(content), len(raw))
+	}
+	if ttl := mr.TTL(DefaultRedisConfig("").KeyPrefix + FormatCacheKey(key)); ttl != time.Hour {
+		t.Errorf("Expected TTL of 1h, got %v", ttl)
+	}
+}
+
+func TestRedisCache_TTL(t *testing.T) {
+	cache, mr := setupRedisCacheTest(t)
+	ctx := context.Background()
+	key := testCacheKey("users", "v1.0.0")
+
+	// Entries stored without a TTL get the configured one
+	if err := cache.Set(ctx, key, testResult("package test"), 0); err != nil {
+		t.Fatalf("Failed to set cache: %v", err)
+	}
+	if ttl := mr.TTL(cache.key(key)); ttl != 24*time.Hour {
+		t.Errorf("Expected default TTL of 24h, got %v", ttl)
+	}
+
+	mr.FastForward(25 * time.Hour)
+	if _, err := cache.Get(ctx, key); err != ErrCacheMiss {
+		t.Errorf("Expected cache miss after expiry, got: %v", err)
+	}
+}
+
+func TestRedisCache_MaxEntrySize(t *testing.T) {
+	cache, mr := setupRedisCacheTest(t)
+	cache.config.MaxEntrySize = 64
+	ctx := context.Background()
+	key := testCacheKey("users", "v1.0.0")
+
+	err := cache.Set(ctx, key, testResult("package test"), time.Hour)
+	if !errors.Is(err, ErrEntryTooLarge) {
+		t.Fatalf("Expected ErrEntryTooLarge, got: %v", err)
+	}
+	if mr.Exists(cache.key(key)) {
+		t.Error("Expected oversized entry not to be stored")
+	}
+
+	stats, err := cache.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.Rejected != 1 {
+		t.Errorf("Expected 1 rejected entry, got %d", stats.Rejected)
+	}
+}
+
+func TestRedisCache_CorruptEntry(t *testing.T) {
+	cache, mr := setupRedisCacheTest(t)
+	ctx := context.Background()
+	key := testCacheKey("users", "v1.0.0")
+
+	mr.Set(cache.key(key), "not gzip")
+	if _, err := cache.Get(ctx, key); err != ErrCacheMiss {
+		t.Errorf("Expected cache miss for corrupt entry, got: %v", err)
+	}
+	if mr.Exists(cache.key(key)) {
+		t.Error("Expected corrupt entry to be deleted")
+	}
+}
+
+func TestRedisCache_DeleteInvalidate(t *testing.T) {
+	cache, mr := setupRedisCacheTest(t)
+	ctx := context.Background()
+
+	keys := []*codegen.CacheKey{
+		testCacheKey("users", "v1.0.0"),
+		testCacheKey("users", "v1.1.0"),
+		testCacheKey("orders", "v1.0.0"),
+		testCacheKey("users*", "v1.0.0"),
+	}
+	for _, key := range keys {
+		if err := cache.Set(ctx, key, testResult("package test"), time.Hour); err != nil {
+			t.Fatalf("Failed to set cache: %v", err)
+		}
+	}
+	mr.Set("other:users:v1.0.0:go", "unrelated")
+
+	if err := cache.Invalidate(ctx, "users", "v1.0.0"); err != nil {
+		t.Fatalf("Failed to invalidate: %v", err)
+	}
+	for i, want := range []bool{false, true, true, true} {
+		if got := mr.Exists(cache.key(keys[i])); got != want {
+			t.Errorf("Key %s: expected exists=%v, got %v", keys[i].ModuleName+"@"+keys[i].Version, want, got)
+		}
+	}
+	if !mr.Exists("other:users:v1.0.0:go") {
+		t.Error("Expected keys outside the prefix to be kept")
+	}
+
+	if err := cache.Delete(ctx, keys[1]); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if _, err := cache.Get(ctx, keys[1]); err != ErrCacheMiss {
+		t.Errorf("Expected cache miss after delete, got: %v", err)
+	}
+
+	if err := cache.Invalidate(ctx, "", "v1.0.0"); err == nil {
+		t.Error("Expected error for empty module name")
+	}
+}
+
+func TestRedisCache_Stats(t *testing.T) {
+	cache, mr := setupRedisCacheTest(t)
+	ctx := context.Background()
+	key := testCacheKey("users", "v1.0.0")
+
+	cache.Get(ctx, key)
+	cache.Set(ctx, key, testResult("package test"), time.Hour)
+	cache.Set(ctx, testCacheKey("orders", "v1.0.0"), testResult("package test"), time.Hour)
+	cache.Get(ctx, key)
+	cache.Get(ctx, key)
+	mr.Set("other", "unrelated")
+
+	stats, err := cache.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("Expected 2 hits and 1 miss, got %d hits and %d misses", stats.Hits, stats.Misses)
+	}
+	if stats.HitRate < 0.66 || stats.HitRate > 0.67 {
+		t.Errorf("Expected hit rate ~0.667, got %f", stats.HitRate)
+	}
+	if stats.ItemCount != 2 {
+		t.Errorf("Expected 2 items, got %d", stats.ItemCount)
+	}
+
+	// Failures of Redis are counted and reported as unavailability
+	mr.Close()
+	if _, err := cache.Get(ctx, key); !errors.Is(err, ErrCacheUnavailable) {
+		t.Errorf("Expected ErrCacheUnavailable, got: %v", err)
+	}
+	if err := cache.Set(ctx, key, testResult("package test"), time.Hour); !errors.Is(err, ErrCacheUnavailable) {
+		t.Errorf("Expected ErrCacheUnavailable, got: %v", err)
+	}
+	if got := cache.metrics.getErrors(); got != 2 {
+		t.Errorf("Expected 2 errors, got %d", got)
+	}
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+)
+
+// TieredCache layers a shared L2 cache, such as Redis, behind a local L1
+// cache. Results found in L2 are copied into L1, so a server restarted or
+// added to the fleet serves what any server compiled before.
+type TieredCache struct {
+	l1      Cache
+	l2      Cache
+	metrics *metrics
+}
+
+// NewTieredCache creates a cache looking up l1 before l2
+func NewTieredCache(l1, l2 Cache) *TieredCache {
+	return &TieredCache{
+		l1:      l1,
+		l2:      l2,
+		metrics: newMetrics(),
+	}
+}
+
+// Get retrieves a cached compilation result from L1, then from L2
+func (c *TieredCache) Get(ctx context.Context, key *codegen.CacheKey) (*codegen.CompilationResult, error) {
+	if key == nil {
+		return nil, ErrInvalidCacheKey
+	}
+
+	if result, err := c.l1.Get(ctx, key); err == nil {
+		c.metrics.recordHit()
+		return result, nil
+	}
+
+	result, err := c.l2.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrCacheUnavailable) {
+			c.metrics.recordError()
+		}
+		c.metrics.recordMiss()
+		return nil, err
+	}
+
+	// L1 holds entries for its own TTL, whatever the TTL in L2
+	_ = c.l1.Set(ctx, key, result, 0)
+	c.metrics.recordHit()
+	return result, nil
+}
+
+// Set stores a compilation result in both levels. A result L2 cannot store is
+// still kept in L1.
+func (c *TieredCache) Set(ctx context.Context, key *codegen.CacheKey, result *codegen.CompilationResult, ttl time.Duration) error {
+	if err := c.l1.Set(ctx, key, result, ttl); err != nil {
+		return err
+	}
+	if err := c.l2.Set(ctx, key, result, ttl); err != nil {
+		if errors.Is(err, ErrEntryTooLarge) {
+			c.metrics.recordRejected()
+		} else if errors.Is(err, ErrCacheUnavailable) {
+			c.metrics.recordError()
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes a cached result from both levels
+func (c *TieredCache) Delete(ctx context.Context, key *codegen.CacheKey) error {
+	if err := c.l1.Delete(ctx, key); err != nil {
+		return err
+	}
+	return c.l2.Delete(ctx, key)
+}
+
+// Invalidate removes all cached results for a module/version from both levels
+func (c *TieredCache) Invalidate(ctx context.Context, moduleName, version string) error {
+	if err := c.l1.Invalidate(ctx, moduleName, version); err != nil {
+		return err
+	}
+	return c.l2.Invalidate(ctx, moduleName, version)
+}
+
+// Stats returns cache statistics. Lookups served by either level count as
+// hits; the item count is that of L2, shared by all servers.
+func (c *TieredCache) Stats(ctx context.Context) (*Stats, error) {
+	stats := c.metrics.stats()
+
+	l2Stats, err := c.l2.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.ItemCount = l2Stats.ItemCount
+	return stats, nil
+}
+
+// Close releases the resources of both levels
+func (c *TieredCache) Close() error {
+	return errors.Join(c.l1.Close(), c.l2.Close())
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestTieredCache(t *testing.T, l2 *RedisCache) *TieredCache {
+	t.Helper()
+
+	l1, err := NewCache(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	return NewTieredCache(l1, l2)
+}
+
+func TestTieredCache_SharedAcrossServers(t *testing.T) {
+	l2, _ := setupRedisCacheTest(t)
+	ctx := context.Background()
+	key := testCacheKey("users", "v1.0.0")
+
+	first := newTestTieredCache(t, l2)
+	if _, err := first.Get(ctx, key); err != ErrCacheMiss {
+		t.Errorf("Expected cache miss, got: %v", err)
+	}
+	if err := first.Set(ctx, key, testResult("package test"), time.Hour); err != nil {
+		t.Fatalf("Failed to set cache: %v", err)
+	}
+
+	// A server with an empty memory cache, restarted or added, finds the
+	// result in Redis and keeps it in memory
+	second := newTestTieredCache(t, l2)
+	cached, err := second.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Failed to get from cache: %v", err)
+	}
+	if string(cached.GeneratedFiles[0].Content) != "package test" {
+		t.Errorf("Unexpected cached result: %+v", cached)
+	}
+	if _, err := second.l1.Get(ctx, key); err != nil {
+		t.Errorf("Expected L2 hit to be copied into L1, got: %v", err)
+	}
+
+	second.Get(ctx, key)
+	if got := l2.metrics.getHits(); got != 1 {
+		t.Errorf("Expected L1 to serve repeated lookups, got %d L2 hits", got)
+	}
+
+	stats, err := second.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.Hits != 2 || stats.Misses != 0 || stats.ItemCount != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestTieredCache_Invalidate(t *testing.T) {
+	l2, mr := setupRedisCacheTest(t)
+	ctx := context.Background()
+	key := testCacheKey("users", "v1.0.0")
+
+	cache := newTestTieredCache(t, l2)
+	if err := cache.Set(ctx, key, testResult("package test"), time.Hour); err != nil {
+		t.Fatalf("Failed to set cache: %v", err)
+	}
+	if err := cache.Invalidate(ctx, "users", "v1.0.0"); err != nil {
+		t.Fatalf("Failed to invalidate: %v", err)
+	}
+	if _, err := cache.Get(ctx, key); err != ErrCacheMiss {
+		t.Errorf("Expected cache miss after invalidate, got: %v", err)
+	}
+	if mr.Exists(l2.key(key)) {
+		t.Error("Expected entry to be removed from Redis")
+	}
+}
+
+func TestTieredCache_L2Failures(t *testing.T) {
+	l2, mr := setupRedisCacheTest(t)
+	ctx := context.Background()
+	key := testCacheKey("users", "v1.0.0")
+	cache := newTestTieredCache(t, l2)
+
+	// Results too large for Redis are still kept in memory
+	l2.config.MaxEntrySize = 64
+	if err := cache.Set(ctx, key, testResult("package test"), time.Hour); !errors.Is(err, ErrEntryTooLarge) {
+		t.Fatalf("Expected ErrEntryTooLarge, got: %v", err)
+	}
+	if _, err := cache.Get(ctx, key); err != nil {
+		t.Errorf("Expected result in memory, got: %v", err)
+	}
+
+	// Lookups missing memory report Redis being unavailable
+	mr.Close()
+	if _, err := cache.Get(ctx, testCacheKey("orders", "v1.0.0")); !errors.Is(err, ErrCacheUnavailable) {
+		t.Errorf("Expected ErrCacheUnavailable, got: %v", err)
+	}
+
+	stats := cache.metrics.stats()
+	if stats.Rejected != 1 || stats.Errors != 1 || stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
@@ -1,17 +1,31 @@
 package cache
 
 import (
+	"context"
 	"time"
 
+	"github.com/platinummonkey/spoke/pkg/codegen"
 	"github.com/platinummonkey/spoke/pkg/codegen/config"
 )
 
+// Cache stores compilation results
+type Cache interface {
+	Get(ctx context.Context, key *codegen.CacheKey) (*codegen.CompilationResult, error)
+	Set(ctx context.Context, key *codegen.CacheKey, result *codegen.CompilationResult, ttl time.Duration) error
+	Delete(ctx context.Context, key *codegen.CacheKey) error
+	Invalidate(ctx context.Context, moduleName, version string) error
+	Stats(ctx context.Context) (*Stats, error)
+	Close() error
+}
+
 // Stats represents cache statistics
 type Stats struct {
 	Hits      int64
 	Misses    int64
 	HitRate   float64
 	ItemCount int64
+	Rejected  int64 // Results not stored because they exceed the size limit
+	Errors    int64 // Operations that failed because the cache was unavailable
 }
 
 // Config holds cache configuration
@@ -27,3 +41,23 @@ func DefaultConfig() *Config {
 		TTL:     config.DefaultCacheTTL,
 	}
 }
+
+// RedisConfig holds Redis cache configuration
+type RedisConfig struct {
+	Addr         string
+	Password     string
+	DB           int
+	KeyPrefix    string        // Prefix of every key (default: "spoke:codegen:")
+	TTL          time.Duration // TTL for entries stored without one (default: 24 hours)
+	MaxEntrySize int64         // Max compressed size of an entry in bytes (default: 16MB)
+}
+
+// DefaultRedisConfig returns default Redis cache configuration
+func DefaultRedisConfig(addr string) *RedisConfig {
+	return &RedisConfig{
+		Addr:         addr,
+		KeyPrefix:    config.DefaultRedisCacheKeyPrefix,
+		TTL:          config.DefaultRedisCacheTTL,
+		MaxEntrySize: config.DefaultRedisCacheMaxEntrySize,
+	}
+}
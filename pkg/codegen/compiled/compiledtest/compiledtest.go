@@ -0,0 +1,217 @@
+// Package compiledtest provides an in-memory storage and a fake compiler for
+// testing code that compiles versions on demand, such as the package
+// registries serving compiled code.
+package compiledtest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+)
+
+// Storage is an in-memory api.Storage. Versions are returned as copies, so
+// a stored compilation is only seen by versions read after it is stored.
+type Storage struct {
+	Delay time.Duration // Wait after reading a version, widening races
+
+	mu       sync.Mutex
+	modules  map[string]*api.Module
+	versions map[string][]*api.Version
+	updates  int
+}
+
+// NewStorage creates an empty storage
+func NewStorage() *Storage {
+	return &Storage{
+		modules:  make(map[string]*api.Module),
+		versions: make(map[string][]*api.Version),
+	}
+}
+
+// Add stores a version of a module with one proto file named after it.
+// Versions of a module are created an hour apart, in the order they are
+// added. The stored version is returned, so tests can change it in place.
+func (s *Storage) Add(name, version string, deps ...string) *api.Version {
+	s.mu.Lock()
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC).Add(time.Duration(len(s.versions[name])) * time.Hour)
+	s.mu.Unlock()
+
+	v := &api.Version{
+		ModuleName:   name,
+		Version:      version,
+		Files:        []api.File{{Path: name + ".proto", Content: `syntax = "proto3";`}},
+		CreatedAt:    createdAt,
+		Dependencies: deps,
+	}
+	s.Put(v)
+	return v
+}
+
+// Put stores a version and its module, replacing a version with the same
+// number
+func (s *Storage) Put(version *api.Version) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.modules[version.ModuleName]; !ok {
+		s.modules[version.ModuleName] = &api.Module{Name: version.ModuleName}
+	}
+	for i, v := range s.versions[version.ModuleName] {
+		if v.Version == version.Version {
+			s.versions[version.ModuleName][i] = version
+			return
+		}
+	}
+	s.versions[version.ModuleName] = append(s.versions[version.ModuleName], version)
+}
+
+// Updates returns the number of times a version was updated
+func (s *Storage) Updates() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updates
+}
+
+func (s *Storage) CreateModule(module *api.Module) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.modules[module.Name] = module
+	return nil
+}
+
+func (s *Storage) GetModule(name string) (*api.Module, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.modules[name]; ok {
+		return m, nil
+	}
+	return nil, errors.New("module not found")
+}
+
+func (s *Storage) ListModules() ([]*api.Module, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	modules := make([]*api.Module, 0, len(s.modules))
+	for _, m := range s.modules {
+		modules = append(modules, m)
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Name < modules[j].Name })
+	return modules, nil
+}
+
+func (s *Storage) CreateVersion(version *api.Version) error {
+	s.Put(version)
+	return nil
+}
+
+func (s *Storage) GetVersion(moduleName, version string) (*api.Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range s.versions[moduleName] {
+		if v.Version == version {
+			copied := copyVersion(v)
+			time.Sleep(s.Delay)
+			return copied, nil
+		}
+	}
+	return nil, errors.New("version not found")
+}
+
+func (s *Storage) ListVersions(moduleName string) ([]*api.Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var versions []*api.Version
+	for _, v := range s.versions[moduleName] {
+		versions = append(versions, copyVersion(v))
+	}
+	return versions, nil
+}
+
+func (s *Storage) UpdateVersion(version *api.Version) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, v := range s.versions[version.ModuleName] {
+		if v.Version == version.Version {
+			s.versions[version.ModuleName][i] = version
+			s.updates++
+			return nil
+		}
+	}
+	return errors.New("version not found")
+}
+
+func (s *Storage) GetFile(moduleName, version, path string) (*api.File, error) {
+	return nil, nil
+}
+
+func copyVersion(v *api.Version) *api.Version {
+	copied := *v
+	copied.CompilationInfo = append([]api.CompilationInfo(nil), v.CompilationInfo...)
+	return &copied
+}
+
+// Compiler is a fake orchestrator.Orchestrator that records the requests it
+// compiles
+type Compiler struct {
+	// Generate returns the result of a request. By default each proto file
+	// compiles to its path with ".out" appended, holding its content, along
+	// with a "manifest" package file holding the module name.
+	Generate func(req *orchestrator.CompileRequest) *codegen.CompilationResult
+	Err      error // Returned by every compilation when set
+
+	mu       sync.Mutex
+	requests []*orchestrator.CompileRequest
+}
+
+func (c *Compiler) CompileSingle(ctx context.Context, req *orchestrator.CompileRequest) (*codegen.CompilationResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	if c.Generate != nil {
+		return c.Generate(req), nil
+	}
+
+	result := &codegen.CompilationResult{Language: req.Language, Success: true}
+	for _, file := range req.ProtoFiles {
+		result.GeneratedFiles = append(result.GeneratedFiles, codegen.GeneratedFile{Path: file.Path + ".out", Content: file.Content})
+	}
+	result.PackageFiles = []codegen.GeneratedFile{{Path: "manifest", Content: []byte(req.ModuleName)}}
+	return result, nil
+}
+
+func (c *Compiler) CompileAll(ctx context.Context, req *orchestrator.CompileRequest, languages []string) ([]*codegen.CompilationResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *Compiler) GetStatus(ctx context.Context, jobID string) (*codegen.CompilationJob, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *Compiler) Close() error { return nil }
+
+// Requests returns the requests compiled so far
+func (c *Compiler) Requests() []*orchestrator.CompileRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*orchestrator.CompileRequest(nil), c.requests...)
+}
+
+// Calls returns the number of requests compiled so far
+func (c *Compiler) Calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.requests)
+}
+
+var (
+	_ api.Storage               = (*Storage)(nil)
+	_ orchestrator.Orchestrator = (*Compiler)(nil)
+)
@@ -0,0 +1,128 @@
+package compiled
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"github.com/platinummonkey/spoke/pkg/codegen/queue"
+)
+
+// JobRunner runs queued compile jobs through the orchestrator, storing the
+// code compiled with the version as the compilation for the package named
+// after the module
+type JobRunner struct {
+	storage  api.Storage
+	compiler orchestrator.Orchestrator
+
+	mu    sync.Mutex
+	locks map[string]*versionLock
+}
+
+// versionLock serializes the updates of a version
+type versionLock struct {
+	sync.Mutex
+	holders int
+}
+
+// NewJobRunner creates a runner of compile jobs
+func NewJobRunner(storage api.Storage, compiler orchestrator.Orchestrator) *JobRunner {
+	return &JobRunner{
+		storage:  storage,
+		compiler: compiler,
+		locks:    make(map[string]*versionLock),
+	}
+}
+
+// Run compiles the version of a job for its language, writing the output of
+// the compilers to output
+func (r *JobRunner) Run(ctx context.Context, job *queue.Job, output io.Writer) (*codegen.CompilationResult, error) {
+	// Versions and dependencies are checked when jobs are enqueued; missing
+	// ones were deleted since and will not come back
+	version, err := r.storage.GetVersion(job.ModuleName, job.Version)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", queue.ErrPermanent, err)
+	}
+	deps, err := Dependencies(r.storage, version)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", queue.ErrPermanent, err)
+	}
+
+	result, err := r.compiler.CompileSingle(ctx, &orchestrator.CompileRequest{
+		ModuleName:   job.ModuleName,
+		Version:      job.Version,
+		ProtoFiles:   ProtoFiles(version.Files),
+		Dependencies: deps,
+		Language:     job.Language,
+		IncludeGRPC:  job.IncludeGRPC,
+		Options:      job.Options,
+		Output:       output,
+	})
+	if errors.Is(err, orchestrator.ErrLanguageNotSupported) {
+		return nil, fmt.Errorf("%w: %w", queue.ErrPermanent, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCompileFailed, err)
+	}
+	if !result.Success {
+		return result, nil
+	}
+
+	info := api.CompilationInfo{
+		Language:    api.Language(job.Language),
+		PackageName: job.ModuleName,
+		Version:     job.Version,
+	}
+	for _, file := range append(result.GeneratedFiles, result.PackageFiles...) {
+		info.Files = append(info.Files, api.File{Path: file.Path, Content: string(file.Content)})
+	}
+
+	if err := r.store(job, info); err != nil {
+		return nil, fmt.Errorf("failed to store compiled code: %w", err)
+	}
+	return result, nil
+}
+
+// store stores the compiled code of a job with its version. Jobs for other
+// languages of the version may finish at the same time, so the version is
+// updated atomically by the storage or, when it cannot, under a lock.
+func (r *JobRunner) store(job *queue.Job, info api.CompilationInfo) error {
+	if updater, ok := r.storage.(api.CompilationUpdater); ok {
+		return updater.PutCompilation(job.ModuleName, job.Version, info)
+	}
+
+	unlock := r.lockVersion(job.ModuleName + "@" + job.Version)
+	defer unlock()
+	version, err := r.storage.GetVersion(job.ModuleName, job.Version)
+	if err != nil {
+		return err
+	}
+	version.SetCompilation(info)
+	return r.storage.UpdateVersion(version)
+}
+
+// lockVersion locks a version against other jobs of this runner
+func (r *JobRunner) lockVersion(key string) (unlock func()) {
+	r.mu.Lock()
+	lock, ok := r.locks[key]
+	if !ok {
+		lock = &versionLock{}
+		r.locks[key] = lock
+	}
+	lock.holders++
+	r.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		r.mu.Lock()
+		if lock.holders--; lock.holders == 0 {
+			delete(r.locks, key)
+		}
+		r.mu.Unlock()
+	}
+}
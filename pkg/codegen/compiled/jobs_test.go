@@ -0,0 +1,108 @@
+package compiled
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled/compiledtest"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"github.com/platinummonkey/spoke/pkg/codegen/queue"
+	"github.com/platinummonkey/spoke/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobRunner_Run(t *testing.T) {
+	storage := newTestStorage()
+	compiler := &compiledtest.Compiler{}
+	runner := NewJobRunner(storage, compiler)
+	job := &queue.Job{ModuleName: "users", Version: "v1.0.0", Language: "python", IncludeGRPC: true}
+
+	var output bytes.Buffer
+	result, err := runner.Run(context.Background(), job, &output)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	require.Len(t, compiler.Requests(), 1)
+	assert.Same(t, &output, compiler.Requests()[0].Output, "compiler output goes to the job log")
+	assert.True(t, compiler.Requests()[0].IncludeGRPC)
+	require.Len(t, compiler.Requests()[0].Dependencies, 1)
+
+	version, err := storage.GetVersion("users", "v1.0.0")
+	require.NoError(t, err)
+	files, ok := Find(version, api.LanguagePython, "users")
+	require.True(t, ok)
+	assert.Equal(t, "users.proto.out", files[0].Path)
+
+	// Compiling again replaces the stored compilation
+	_, err = runner.Run(context.Background(), job, nil)
+	require.NoError(t, err)
+	version, err = storage.GetVersion("users", "v1.0.0")
+	require.NoError(t, err)
+	assert.Len(t, version.CompilationInfo, 1)
+}
+
+func TestJobRunner_Run_Errors(t *testing.T) {
+	storage := newTestStorage()
+
+	_, err := NewJobRunner(storage, &compiledtest.Compiler{}).Run(context.Background(), &queue.Job{ModuleName: "users", Version: "v9.9.9", Language: "go"}, nil)
+	assert.ErrorIs(t, err, queue.ErrPermanent)
+
+	job := &queue.Job{ModuleName: "users", Version: "v1.0.0", Language: "cobol"}
+	compiler := &compiledtest.Compiler{Err: fmt.Errorf("%w: cobol", orchestrator.ErrLanguageNotSupported)}
+	_, err = NewJobRunner(storage, compiler).Run(context.Background(), job, nil)
+	assert.ErrorIs(t, err, queue.ErrPermanent)
+
+	compiler = &compiledtest.Compiler{Err: errors.New("protoc exploded")}
+	_, err = NewJobRunner(storage, compiler).Run(context.Background(), job, nil)
+	assert.ErrorIs(t, err, ErrCompileFailed)
+	assert.NotErrorIs(t, err, queue.ErrPermanent, "compile failures are retried")
+	assert.Zero(t, storage.Updates())
+}
+
+func TestJobRunner_Run_Concurrent(t *testing.T) {
+	languages := []string{"go", "python", "java", "rust"}
+	runAll := func(t *testing.T, storage api.Storage) {
+		t.Helper()
+		runner := NewJobRunner(storage, &compiledtest.Compiler{})
+		var wg sync.WaitGroup
+		for _, language := range languages {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := runner.Run(context.Background(), &queue.Job{ModuleName: "users", Version: "v1.0.0", Language: language}, nil)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		version, err := storage.GetVersion("users", "v1.0.0")
+		require.NoError(t, err)
+		assert.Len(t, version.CompilationInfo, len(languages), "no compilation overwrites another")
+		for _, language := range languages {
+			_, ok := Find(version, api.Language(language), "users")
+			assert.True(t, ok, language)
+		}
+	}
+
+	t.Run("locked", func(t *testing.T) {
+		memory := newTestStorage()
+		memory.Delay = 20 * time.Millisecond
+		runAll(t, memory)
+	})
+
+	t.Run("storage", func(t *testing.T) {
+		fs, err := storage.NewFileSystemStorage(t.TempDir())
+		require.NoError(t, err)
+		for _, version := range testVersions() {
+			require.NoError(t, fs.CreateModule(&api.Module{Name: version.ModuleName}))
+			require.NoError(t, fs.CreateVersion(version))
+		}
+		runAll(t, fs)
+	})
+}
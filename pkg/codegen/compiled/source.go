@@ -0,0 +1,182 @@
+// Package compiled serves the code compiled for module versions to package
+// registries. A version compiled for a package is stored with the version,
+// so every download of it serves the same code; versions not yet compiled
+// are compiled on demand through the orchestrator.
+package compiled
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	// ErrCompileFailed is returned when compiling a version fails
+	ErrCompileFailed = errors.New("compilation failed")
+
+	// ErrCompilerUnavailable is returned when a version is not compiled and
+	// no compiler is configured to compile it
+	ErrCompilerUnavailable = errors.New("no compiler configured")
+)
+
+// Target describes the compilation of a version for a package
+type Target struct {
+	Language    api.Language
+	PackageName string // Package the code is compiled for, such as a Go module path
+	IncludeGRPC bool
+	Options     map[string]string // Orchestrator options
+}
+
+// Source returns the code compiled for versions, compiling it on demand
+type Source struct {
+	storage  api.Storage
+	compiler orchestrator.Orchestrator
+	compiles singleflight.Group
+}
+
+// NewSource creates a source of compiled code. With a nil compiler only
+// versions already compiled can be served.
+func NewSource(storage api.Storage, compiler orchestrator.Orchestrator) *Source {
+	return &Source{
+		storage:  storage,
+		compiler: compiler,
+	}
+}
+
+// Find returns the files of the compilation of a version for a package.
+// Compilations for other packages name and import their own packages and
+// cannot be served in its place.
+func Find(version *api.Version, language api.Language, packageName string) ([]api.File, bool) {
+	for _, info := range version.CompilationInfo {
+		if info.Language == language && info.PackageName == packageName {
+			return info.Files, true
+		}
+	}
+	return nil, false
+}
+
+// Files returns the code compiled for a version, compiling the version and
+// storing the result when it has not been compiled for the target yet
+func (s *Source) Files(ctx context.Context, version *api.Version, target Target) ([]api.File, error) {
+	if files, ok := Find(version, target.Language, target.PackageName); ok {
+		return files, nil
+	}
+
+	// Concurrent downloads of a version share one compilation, which
+	// outlives the request that started it
+	ctx = context.WithoutCancel(ctx)
+	key := fmt.Sprintf("%s@%s/%s/%s", version.ModuleName, version.Version, target.Language, target.PackageName)
+	files, err, _ := s.compiles.Do(key, func() (interface{}, error) {
+		return s.compile(ctx, version.ModuleName, version.Version, target)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files.([]api.File), nil
+}
+
+// compile compiles a version for a target and stores the result with the
+// version
+func (s *Source) compile(ctx context.Context, name, v string, target Target) ([]api.File, error) {
+	// Another download may have compiled the version meanwhile
+	version, err := s.storage.GetVersion(name, v)
+	if err != nil {
+		return nil, err
+	}
+	if files, ok := Find(version, target.Language, target.PackageName); ok {
+		return files, nil
+	}
+	if s.compiler == nil {
+		return nil, fmt.Errorf("%w to compile %s@%s", ErrCompilerUnavailable, name, v)
+	}
+
+	deps, err := Dependencies(s.storage, version)
+	if err != nil {
+		return nil, err
+	}
+	req := &orchestrator.CompileRequest{
+		ModuleName:   name,
+		Version:      v,
+		ProtoFiles:   ProtoFiles(version.Files),
+		Dependencies: deps,
+		Language:     string(target.Language),
+		IncludeGRPC:  target.IncludeGRPC,
+		Options:      target.Options,
+	}
+	result, err := s.compiler.CompileSingle(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCompileFailed, err)
+	}
+
+	info := api.CompilationInfo{
+		Language:    target.Language,
+		PackageName: target.PackageName,
+		Version:     v,
+	}
+	for _, file := range append(result.GeneratedFiles, result.PackageFiles...) {
+		info.Files = append(info.Files, api.File{Path: file.Path, Content: string(file.Content)})
+	}
+	version.CompilationInfo = append(version.CompilationInfo, info)
+	if err := s.storage.UpdateVersion(version); err != nil {
+		return nil, fmt.Errorf("failed to store compiled code: %w", err)
+	}
+	return info.Files, nil
+}
+
+// FindModule returns the module a registry serves as a package, given how
+// the registry names the package of a module. The module named candidate,
+// the package name stripped of what the registry adds, is tried first; other
+// modules are searched for those whose names map to the package differently,
+// such as "Payments_API" for payments-api.
+func FindModule(storage api.Storage, packageName, candidate string, name func(moduleName string) string) (string, bool, error) {
+	if _, err := storage.GetModule(candidate); err == nil && name(candidate) == packageName {
+		return candidate, true, nil
+	}
+
+	modules, err := storage.ListModules()
+	if err != nil {
+		return "", false, err
+	}
+	for _, m := range modules {
+		if name(m.Name) == packageName {
+			return m.Name, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// Dependencies fetches the proto files of the modules a version depends on
+func Dependencies(storage api.Storage, version *api.Version) ([]codegen.Dependency, error) {
+	var deps []codegen.Dependency
+	for _, dep := range version.Dependencies {
+		name, v, ok := strings.Cut(dep, "@")
+		if !ok {
+			continue
+		}
+		depVersion, err := storage.GetVersion(name, v)
+		if err != nil {
+			return nil, fmt.Errorf("dependency %s: %w", dep, err)
+		}
+		deps = append(deps, codegen.Dependency{
+			ModuleName: name,
+			Version:    v,
+			ProtoFiles: ProtoFiles(depVersion.Files),
+		})
+	}
+	return deps, nil
+}
+
+// ProtoFiles converts the files of a version to proto files
+func ProtoFiles(files []api.File) []codegen.ProtoFile {
+	result := make([]codegen.ProtoFile, 0, len(files))
+	for _, file := range files {
+		result = append(result, codegen.ProtoFile{Path: file.Path, Content: []byte(file.Content)})
+	}
+	return result
+}
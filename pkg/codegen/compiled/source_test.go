@@ -0,0 +1,146 @@
+package compiled
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled/compiledtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testVersions returns a users module version depending on a common one
+func testVersions() []*api.Version {
+	return []*api.Version{
+		{
+			ModuleName: "common",
+			Version:    "v1.0.0",
+			Files:      []api.File{{Path: "common.proto", Content: "common"}},
+		},
+		{
+			ModuleName:   "users",
+			Version:      "v1.0.0",
+			Files:        []api.File{{Path: "users.proto", Content: "users"}},
+			Dependencies: []string{"common@v1.0.0"},
+		},
+	}
+}
+
+func newTestStorage() *compiledtest.Storage {
+	storage := compiledtest.NewStorage()
+	for _, version := range testVersions() {
+		storage.Put(version)
+	}
+	return storage
+}
+
+func TestFind(t *testing.T) {
+	version := &api.Version{CompilationInfo: []api.CompilationInfo{
+		{Language: api.LanguageGo, PackageName: "example.com/users", Files: []api.File{{Path: "a.go"}}},
+		{Language: api.LanguagePython, PackageName: "users", Files: []api.File{{Path: "a.py"}}},
+	}}
+
+	files, ok := Find(version, api.LanguagePython, "users")
+	require.True(t, ok)
+	assert.Equal(t, "a.py", files[0].Path)
+
+	_, ok = Find(version, api.LanguageGo, "example.com/other")
+	assert.False(t, ok)
+	_, ok = Find(version, api.LanguageJava, "users")
+	assert.False(t, ok)
+}
+
+func TestFindModule(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	require.NoError(t, storage.CreateModule(&api.Module{Name: "payments"}))
+	require.NoError(t, storage.CreateModule(&api.Module{Name: "Billing_API"}))
+	name := func(moduleName string) string {
+		return "acme-" + strings.ReplaceAll(strings.ToLower(moduleName), "_", "-")
+	}
+
+	found, ok, err := FindModule(storage, "acme-payments", "payments", name)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "payments", found)
+
+	found, ok, err = FindModule(storage, "acme-billing-api", "billing-api", name)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "Billing_API", found, "modules named differently are searched")
+
+	_, ok, err = FindModule(storage, "acme-orders", "orders", name)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = FindModule(storage, "payments", "payments", name)
+	require.NoError(t, err)
+	assert.False(t, ok, "the candidate must map to the package")
+}
+
+func TestSource_Files(t *testing.T) {
+	storage := newTestStorage()
+	compiler := &compiledtest.Compiler{}
+	source := NewSource(storage, compiler)
+	target := Target{
+		Language:    api.LanguagePython,
+		PackageName: "acme-users",
+		IncludeGRPC: true,
+		Options:     map[string]string{"key": "value"},
+	}
+
+	version, err := storage.GetVersion("users", "v1.0.0")
+	require.NoError(t, err)
+	files, err := source.Files(context.Background(), version, target)
+	require.NoError(t, err)
+	assert.Equal(t, []api.File{
+		{Path: "users.proto.out", Content: "users"},
+		{Path: "manifest", Content: "users"},
+	}, files)
+
+	require.Len(t, compiler.Requests(), 1)
+	req := compiler.Requests()[0]
+	assert.Equal(t, "python", req.Language)
+	assert.True(t, req.IncludeGRPC)
+	assert.Equal(t, "value", req.Options["key"])
+	require.Len(t, req.Dependencies, 1)
+	assert.Equal(t, "common", req.Dependencies[0].ModuleName)
+	assert.Equal(t, "common.proto", req.Dependencies[0].ProtoFiles[0].Path)
+
+	// The compilation is stored and served without compiling again
+	assert.Equal(t, 1, storage.Updates())
+	stored, err := storage.GetVersion("users", "v1.0.0")
+	require.NoError(t, err)
+	again, err := source.Files(context.Background(), stored, target)
+	require.NoError(t, err)
+	assert.Equal(t, files, again)
+	assert.Len(t, compiler.Requests(), 1)
+
+	// A stale version does not compile again either
+	again, err = source.Files(context.Background(), version, target)
+	require.NoError(t, err)
+	assert.Equal(t, files, again)
+	assert.Len(t, compiler.Requests(), 1)
+}
+
+func TestSource_Files_Errors(t *testing.T) {
+	storage := newTestStorage()
+	version, err := storage.GetVersion("users", "v1.0.0")
+	require.NoError(t, err)
+	target := Target{Language: api.LanguageGo, PackageName: "example.com/users"}
+
+	_, err = NewSource(storage, nil).Files(context.Background(), version, target)
+	assert.ErrorIs(t, err, ErrCompilerUnavailable)
+
+	_, err = NewSource(storage, &compiledtest.Compiler{Err: errors.New("protoc exploded")}).Files(context.Background(), version, target)
+	assert.ErrorIs(t, err, ErrCompileFailed)
+	assert.ErrorContains(t, err, "protoc exploded")
+	assert.Zero(t, storage.Updates())
+
+	version.Dependencies = []string{"missing@v1.0.0"}
+	storage.Put(version)
+	_, err = NewSource(storage, &compiledtest.Compiler{}).Files(context.Background(), version, target)
+	assert.ErrorContains(t, err, "dependency missing@v1.0.0")
+}
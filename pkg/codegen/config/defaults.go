@@ -31,6 +31,31 @@ const (
 	// during development. 5 minutes provides a good balance - caching repeated builds
 	// during CI runs while ensuring developers see changes after a short delay.
 	DefaultCacheTTL = 5 * time.Minute
+
+	// DefaultRedisCacheTTL is the time-to-live for entries of the Redis cache
+	// Default: 24 hours
+	//
+	// Rationale: The Redis cache is shared by all servers and survives restarts,
+	// so it is kept far longer than the in-memory cache. A compilation is keyed
+	// by the hash of its inputs, so an entry never goes stale; the TTL only lets
+	// Redis reclaim results nobody asks for anymore.
+	DefaultRedisCacheTTL = 24 * time.Hour
+
+	// DefaultRedisCacheMaxEntrySize is the largest compressed result stored in
+	// the Redis cache
+	// Default: 16MB
+	//
+	// Rationale: Generated code compresses well, so few compressed results come
+	// near this. Larger results are compiled again rather than stored, keeping a
+	// few huge modules from evicting everything else from a shared Redis.
+	DefaultRedisCacheMaxEntrySize = int64(16 * 1024 * 1024)
+
+	// DefaultRedisCacheKeyPrefix prefixes the keys of the Redis cache
+	// Default: "spoke:codegen:"
+	//
+	// Rationale: Lets the compilation cache share a Redis database with other
+	// data, and lets its keys be found and invalidated by prefix.
+	DefaultRedisCacheKeyPrefix = "spoke:codegen:"
 )
 
 // Orchestrator Configuration Defaults
@@ -83,3 +108,14 @@ const (
 	// pull time, so this should be at least as long as compilation timeout.
 	DefaultDockerTimeout = 5 * time.Minute
 )
+
+// Host Execution Defaults
+const (
+	// DefaultHostMaxOutputBytes is the output limit for the host runner
+	// Default: 256MB
+	//
+	// Rationale: Without a container the generated files land on the host's
+	// disk. 256MB is far above what protoc emits for real schemas and stops a
+	// runaway plugin from filling the disk.
+	DefaultHostMaxOutputBytes = int64(256 * 1024 * 1024)
+)
@@ -214,6 +214,18 @@
 //   - Pin specific plugin versions (go plugin 1.32.0 always available)
 //   - No local installation required (just Docker)
 //
+// Without a Docker daemon, set Config.Runner to orchestrator.RunnerHost to run
+// the locally installed protoc and plugins in a temporary sandbox directory
+// (docker.HostRunner). Timeouts and CPU time are enforced; memory limits and
+// network isolation are not. Binaries using it call docker.RunHostProcess
+// first in main, so protoc and its plugins start under the limits on Linux.
+//
+// With orchestrator.RunnerInProcess, the module is compiled once in process
+// with protocompile. The protoc-gen-* plugins of every language are then run
+// directly over the plugin protocol on the same descriptors, without protoc
+// (pkg/codegen/inprocess). Plugin errors are reported at the file and line of
+// the descriptor they name.
+//
 // # Package Manager File Generation
 //
 // The system generates package manager files for each language:
@@ -9,9 +9,13 @@ var (
 	// ErrImagePullFailed is returned when image pull fails
 	ErrImagePullFailed = errors.New("failed to pull docker image")
 
-	// ErrContainerFailed is returned when container execution fails
+	// ErrContainerFailed is returned when container execution fails, or
+	// the protoc process of the host runner
 	ErrContainerFailed = errors.New("container execution failed")
 
+	// ErrProtocNotFound is returned when the host runner cannot find protoc
+	ErrProtocNotFound = errors.New("protoc is not available")
+
 	// ErrTimeout is returned when execution times out
 	ErrTimeout = errors.New("execution timeout")
 
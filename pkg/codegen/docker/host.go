@@ -0,0 +1,394 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/codegen/config"
+)
+
+// HostProcessCommand is the hidden subcommand binaries dispatch to
+// RunHostProcess, which starts protoc under the host runner's limits
+const HostProcessCommand = "__spoke-host-process"
+
+// HostConfig configures the host runner
+type HostConfig struct {
+	ProtocPath     string   // protoc binary; looked up in PATH when empty
+	PluginDirs     []string // Directories searched for protoc plugins before PATH
+	TempDir        string   // Parent of the sandbox directories; the system default when empty
+	MaxOutputBytes int64    // Limit on generated files plus stdout and stderr (see config.DefaultHostMaxOutputBytes)
+}
+
+// DefaultHostConfig returns the default host runner configuration
+func DefaultHostConfig() *HostConfig {
+	return &HostConfig{
+		MaxOutputBytes: config.DefaultHostMaxOutputBytes,
+	}
+}
+
+// HostRunner implements the Runner interface by running protoc and its
+// plugins directly on the host, for machines without a Docker daemon. Each
+// execution gets a temporary sandbox directory holding the proto files, the
+// output and the process's home and temp directories, and the container
+// paths /input and /output in protoc flags are mapped into it, so requests
+// and results are the same as with DockerRunner.
+//
+// The timeout, CPU and output limits are enforced on the process: CPULimit
+// cores for the length of the timeout is the CPU time the process may use,
+// and it may write MaxOutputBytes. On Linux, in binaries calling
+// RunHostProcess, the limits are applied before protoc starts, so the plugins
+// it starts inherit them; everywhere the
+// sandbox is measured while the process runs, and the process is killed
+// once its files outgrow the output limit. Memory limits and network
+// isolation are not enforced; use DockerRunner where they are needed.
+type HostRunner struct {
+	config     *HostConfig
+	protocPath string
+}
+
+// NewHostRunner creates a new host runner
+func NewHostRunner(cfg *HostConfig) (*HostRunner, error) {
+	if cfg == nil {
+		cfg = DefaultHostConfig()
+	}
+
+	protoc := cfg.ProtocPath
+	if protoc == "" {
+		protoc = "protoc"
+	}
+	protocPath, err := exec.LookPath(protoc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProtocNotFound, err)
+	}
+
+	return &HostRunner{
+		config:     cfg,
+		protocPath: protocPath,
+	}, nil
+}
+
+// Execute runs a compilation in a sandbox directory on the host
+func (r *HostRunner) Execute(ctx context.Context, req *ExecutionRequest) (*ExecutionResult, error) {
+	result := &ExecutionResult{
+		Success: false,
+	}
+
+	startTime := time.Now()
+	defer func() {
+		result.Duration = time.Since(startTime)
+	}()
+
+	// Set defaults
+	if req.CPULimit == 0 {
+		req.CPULimit = DefaultCPULimit
+	}
+	if req.Timeout == 0 {
+		req.Timeout = DefaultTimeout
+	}
+	maxOutput := r.config.MaxOutputBytes
+	if maxOutput <= 0 {
+		maxOutput = config.DefaultHostMaxOutputBytes
+	}
+
+	sandbox, err := os.MkdirTemp(r.config.TempDir, "spoke-host-*")
+	if err != nil {
+		result.Error = fmt.Errorf("failed to create sandbox directory: %v", err)
+		return result, result.Error
+	}
+	defer os.RemoveAll(sandbox)
+
+	inputDir := filepath.Join(sandbox, "input")
+	outputDir := filepath.Join(sandbox, "output")
+	tmpDir := filepath.Join(sandbox, "tmp")
+	for _, dir := range []string{inputDir, outputDir, tmpDir} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			result.Error = fmt.Errorf("failed to create sandbox directory: %v", err)
+			return result, result.Error
+		}
+	}
+
+	// Write proto files to input directory, keeping them inside it
+	for _, protoFile := range req.ProtoFiles {
+		filePath := filepath.Join(inputDir, protoFile.Path)
+		if !strings.HasPrefix(filePath, inputDir+string(filepath.Separator)) {
+			result.Error = fmt.Errorf("proto file path escapes the sandbox: %s", protoFile.Path)
+			return result, result.Error
+		}
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			result.Error = fmt.Errorf("failed to create proto file directory: %v", err)
+			return result, result.Error
+		}
+		if err := os.WriteFile(filePath, protoFile.Content, 0644); err != nil {
+			result.Error = fmt.Errorf("failed to write proto file: %v", err)
+			return result, result.Error
+		}
+	}
+
+	inputSize, err := dirSize(inputDir)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to measure proto files: %v", err)
+		return result, result.Error
+	}
+
+	limitCtx, cancelLimit := context.WithCancelCause(ctx)
+	defer cancelLimit(nil)
+	execCtx, cancel := context.WithTimeout(limitCtx, req.Timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: maxOutput}
+	stderr := &limitedBuffer{limit: maxOutput}
+	cmd := exec.CommandContext(execCtx, r.protocPath, r.buildProtocArgs(req, inputDir, outputDir)...)
+	cmd.Dir = sandbox
+	cmd.Env = r.buildEnv(req, sandbox, tmpDir)
+	cmd.Stdout = withOutput(stdout, req.Output)
+	cmd.Stderr = withOutput(stderr, req.Output)
+	cpuSeconds := uint64(math.Ceil(req.CPULimit * req.Timeout.Seconds()))
+	configureHostProcess(cmd, hostLimits{
+		CPUSeconds: cpuSeconds,
+		// One byte over the limit, so files past it are caught after the run
+		FileBytes: uint64(maxOutput) + 1,
+	})
+
+	if err := cmd.Start(); err != nil {
+		result.Error = fmt.Errorf("%w: start failed: %v", ErrContainerFailed, err)
+		return result, result.Error
+	}
+	exited := make(chan struct{})
+	go watchSandbox(execCtx, exited, sandbox, inputSize+maxOutput, cancelLimit)
+	waitErr := cmd.Wait()
+	close(exited)
+
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	if errors.Is(context.Cause(limitCtx), errSandboxFull) {
+		result.Error = fmt.Errorf("%w: output exceeds %d bytes", ErrResourceLimit, maxOutput)
+		return result, result.Error
+	}
+	if execCtx.Err() == context.DeadlineExceeded {
+		result.Error = ErrTimeout
+		return result, result.Error
+	}
+	if ctx.Err() != nil {
+		result.Error = ctx.Err()
+		return result, result.Error
+	}
+	if state := cmd.ProcessState; state != nil {
+		used := state.UserTime() + state.SystemTime()
+		if cpuExceeded(state) || used > time.Duration(cpuSeconds)*time.Second {
+			result.Error = fmt.Errorf("%w: CPU time %s exceeds %ds", ErrResourceLimit, used.Round(time.Millisecond), cpuSeconds)
+			return result, result.Error
+		}
+		if fileSizeExceeded(state) {
+			result.Error = fmt.Errorf("%w: output exceeds %d bytes", ErrResourceLimit, maxOutput)
+			return result, result.Error
+		}
+	}
+	if waitErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(waitErr, &exitErr) {
+			result.Error = fmt.Errorf("%w: %v", ErrContainerFailed, waitErr)
+			return result, result.Error
+		}
+		result.ExitCode = exitErr.ExitCode()
+	}
+	if stdout.truncated || stderr.truncated {
+		result.Error = fmt.Errorf("%w: output exceeds %d bytes", ErrResourceLimit, maxOutput)
+		return result, result.Error
+	}
+
+	// Plugins stopped at the file size limit fail protoc; report the limit
+	if size, err := dirSize(outputDir); err != nil {
+		result.Error = fmt.Errorf("failed to extract generated files: %v", err)
+		return result, result.Error
+	} else if size+int64(len(result.Stdout)+len(result.Stderr)) > maxOutput {
+		result.Error = fmt.Errorf("%w: output exceeds %d bytes", ErrResourceLimit, maxOutput)
+		return result, result.Error
+	}
+
+	// Check exit code
+	if result.ExitCode != 0 {
+		result.Error = fmt.Errorf("%w: exit code %d: %s", ErrContainerFailed, result.ExitCode, result.Stderr)
+		return result, result.Error
+	}
+
+	// Extract generated files from output directory
+	generatedFiles, err := extractGeneratedFiles(outputDir)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to extract generated files: %v", err)
+		return result, result.Error
+	}
+
+	if len(generatedFiles) == 0 {
+		result.Error = ErrNoGeneratedFiles
+		return result, result.Error
+	}
+
+	result.GeneratedFiles = generatedFiles
+	result.Success = true
+	return result, nil
+}
+
+// PullImage does nothing; the host runner uses the tools installed on the
+// host
+func (r *HostRunner) PullImage(ctx context.Context, image string) error {
+	return nil
+}
+
+// Cleanup does nothing; sandboxes are removed after each execution
+func (r *HostRunner) Cleanup(ctx context.Context) error {
+	return nil
+}
+
+// Close releases resources
+func (r *HostRunner) Close() error {
+	return nil
+}
+
+// buildProtocArgs builds the protoc arguments, mapping the container paths
+// used in the request's flags into the sandbox
+func (r *HostRunner) buildProtocArgs(req *ExecutionRequest, inputDir, outputDir string) []string {
+	args := []string{"--proto_path=" + inputDir}
+	for _, flag := range req.ProtocFlags {
+		args = append(args, hostFlag(flag, inputDir, outputDir))
+	}
+	for _, protoFile := range req.ProtoFiles {
+		args = append(args, filepath.Join(inputDir, protoFile.Path))
+	}
+	return args
+}
+
+// hostFlag maps /input and /output at the start of a flag's value, or of
+// the path after an out flag's options, to the sandbox directories
+func hostFlag(flag, inputDir, outputDir string) string {
+	eq := strings.IndexByte(flag, '=')
+	if eq < 0 {
+		return flag
+	}
+	name, value := flag[:eq+1], flag[eq+1:]
+
+	prefix := ""
+	if colon := strings.LastIndexByte(value, ':'); colon >= 0 && strings.HasSuffix(name, "_out=") {
+		prefix, value = value[:colon+1], value[colon+1:]
+	}
+	for container, host := range map[string]string{"/input": inputDir, "/output": outputDir} {
+		if value == container || strings.HasPrefix(value, container+"/") {
+			value = host + value[len(container):]
+			break
+		}
+	}
+	return name + prefix + value
+}
+
+// buildEnv builds the environment of the process: PATH with the plugin
+// directories first, the sandbox as home and temp directory, and the
+// request's variables. Nothing else is inherited from the runner.
+func (r *HostRunner) buildEnv(req *ExecutionRequest, home, tmpDir string) []string {
+	path := append([]string{}, r.config.PluginDirs...)
+	if p := os.Getenv("PATH"); p != "" {
+		path = append(path, p)
+	}
+
+	env := []string{
+		"PATH=" + strings.Join(path, string(os.PathListSeparator)),
+		"HOME=" + home,
+		"TMPDIR=" + tmpDir,
+	}
+	for k, v := range req.Env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	return env
+}
+
+// limitedBuffer keeps the first limit bytes written to it and notes whether
+// more were written
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int64
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - int64(b.buf.Len()); int64(len(p)) > remaining {
+		b.buf.Write(p[:max(remaining, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+// hostLimits are the resource limits of a host process
+type hostLimits struct {
+	CPUSeconds uint64 // CPU time
+	FileBytes  uint64 // Size of each file written
+}
+
+// errSandboxFull stops host processes whose files outgrow the output limit
+var errSandboxFull = errors.New("sandbox full")
+
+// sandboxCheckInterval is how often the sandbox of a running process is
+// measured
+const sandboxCheckInterval = 100 * time.Millisecond
+
+// watchSandbox measures a sandbox until the process exits, stopping the
+// process with errSandboxFull once its files exceed limit bytes
+func watchSandbox(ctx context.Context, exited <-chan struct{}, sandbox string, limit int64, stop context.CancelCauseFunc) {
+	ticker := time.NewTicker(sandboxCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-exited:
+			return
+		case <-ticker.C:
+		}
+
+		// Files come and go while the process runs; missing ones count as
+		// empty
+		var size int64
+		filepath.WalkDir(sandbox, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if !d.IsDir() {
+				if info, err := d.Info(); err == nil {
+					size += info.Size()
+				}
+			}
+			return nil
+		})
+		if size > limit {
+			stop(errSandboxFull)
+			return
+		}
+	}
+}
+
+// dirSize returns the total size of the files under dir
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
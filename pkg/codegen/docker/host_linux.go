@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// hostProcesses is set once the binary dispatches HostProcessCommand, so
+// host processes can be started through it
+var hostProcesses atomic.Bool
+
+// RunHostProcess is the entry point of host processes and must be called
+// first in main by binaries using the host runner. Started with
+// HostProcessCommand, the binary is a host process starting: it applies its
+// limits and becomes protoc, so protoc and every plugin it starts run under
+// them from their first instruction, and RunHostProcess does not return.
+// Otherwise it returns, and the host runner starts its processes this way
+// from then on; without it, the CPU time and output are only checked while
+// and after protoc runs.
+func RunHostProcess() {
+	if len(os.Args) > 1 && os.Args[1] == HostProcessCommand {
+		execLimited(os.Args[2:])
+	}
+	hostProcesses.Store(true)
+}
+
+// configureHostProcess runs the process in its own process group, so a
+// timeout kills protoc together with the plugins it started. When the binary
+// dispatches HostProcessCommand, the process is started through it, which
+// applies the limits before executing protoc. The process is sent SIGXCPU at
+// the CPU limit and killed a second later; writing a file past the size limit
+// sends it SIGXFSZ.
+func configureHostProcess(cmd *exec.Cmd, limits hostLimits) {
+	if hostProcesses.Load() {
+		spec := fmt.Sprintf("%d,%d", limits.CPUSeconds, limits.FileBytes)
+		cmd.Args = append([]string{"/proc/self/exe", HostProcessCommand, spec, cmd.Path}, cmd.Args[1:]...)
+		cmd.Path = "/proc/self/exe"
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// execLimited applies the limits of a host process started with args, the
+// limits followed by the program and its arguments, and executes the
+// program. It does not return.
+func execLimited(args []string) {
+	var limits hostLimits
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "spoke: usage: %s <cpu-seconds>,<file-bytes> <program> [args...]\n", HostProcessCommand)
+		os.Exit(127)
+	}
+	if _, err := fmt.Sscanf(args[0], "%d,%d", &limits.CPUSeconds, &limits.FileBytes); err != nil {
+		fmt.Fprintf(os.Stderr, "spoke: invalid host process limits %q\n", args[0])
+		os.Exit(127)
+	}
+	if err := unix.Setrlimit(unix.RLIMIT_CPU, &unix.Rlimit{Cur: limits.CPUSeconds, Max: limits.CPUSeconds + 1}); err != nil {
+		fmt.Fprintf(os.Stderr, "spoke: failed to limit CPU time: %v\n", err)
+		os.Exit(127)
+	}
+	if err := unix.Setrlimit(unix.RLIMIT_FSIZE, &unix.Rlimit{Cur: limits.FileBytes, Max: limits.FileBytes}); err != nil {
+		fmt.Fprintf(os.Stderr, "spoke: failed to limit file size: %v\n", err)
+		os.Exit(127)
+	}
+
+	err := unix.Exec(args[1], args[1:], os.Environ())
+	fmt.Fprintf(os.Stderr, "spoke: failed to start %s: %v\n", args[1], err)
+	os.Exit(127)
+}
+
+// cpuExceeded reports whether the process was stopped for exceeding its CPU
+// time
+func cpuExceeded(state *os.ProcessState) bool {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && status.Signal() == syscall.SIGXCPU
+}
+
+// fileSizeExceeded reports whether the process was stopped for writing a
+// file past its size limit
+func fileSizeExceeded(state *os.ProcessState) bool {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && status.Signal() == syscall.SIGXFSZ
+}
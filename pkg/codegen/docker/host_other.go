@@ -0,0 +1,28 @@
+//go:build !linux
+
+package docker
+
+import (
+	"os"
+	"os/exec"
+)
+
+// RunHostProcess does nothing; host processes are started directly
+func RunHostProcess() {}
+
+// configureHostProcess does nothing; a timeout kills protoc but not the
+// plugins it started, and the limits are checked while and after it runs
+// instead
+func configureHostProcess(cmd *exec.Cmd, limits hostLimits) {}
+
+// cpuExceeded reports false; the CPU time of the process is checked after it
+// exits instead
+func cpuExceeded(state *os.ProcessState) bool {
+	return false
+}
+
+// fileSizeExceeded reports false; the size of the output is checked while
+// the process runs instead
+func fileSizeExceeded(state *os.ProcessState) bool {
+	return false
+}
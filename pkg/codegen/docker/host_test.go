@@ -0,0 +1,306 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain dispatches host processes like the binaries using the host runner
+func TestMain(m *testing.M) {
+	RunHostProcess()
+	os.Exit(m.Run())
+}
+
+// fakeProtoc writes a protoc stand-in that runs script with the output
+// directory of its first --*_out flag in $out
+func fakeProtoc(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "protoc")
+	content := "#!/bin/sh\nout=\nfor arg in \"$@\"; do\n  case \"$arg\" in\n    --*_out=*) out=\"${arg#*_out=}\"; out=\"${out##*:}\" ;;\n  esac\ndone\n" + script
+	require.NoError(t, os.WriteFile(path, []byte(content), 0755))
+	return path
+}
+
+func newTestHostRunner(t *testing.T, script string) *HostRunner {
+	t.Helper()
+	runner, err := NewHostRunner(&HostConfig{ProtocPath: fakeProtoc(t, script)})
+	require.NoError(t, err)
+	return runner
+}
+
+func hostRequest() *ExecutionRequest {
+	return &ExecutionRequest{
+		Image:       "spoke/compiler-go",
+		ProtoFiles:  []codegen.ProtoFile{{Path: "acme/v1/user.proto", Content: []byte("syntax = \"proto3\";")}},
+		ProtocFlags: []string{"--go_out=/output", "--go_opt=paths=source_relative"},
+		Timeout:     10 * time.Second,
+	}
+}
+
+func TestNewHostRunner_ProtocNotFound(t *testing.T) {
+	_, err := NewHostRunner(&HostConfig{ProtocPath: filepath.Join(t.TempDir(), "missing-protoc")})
+	assert.ErrorIs(t, err, ErrProtocNotFound)
+}
+
+func TestHostRunner_Execute(t *testing.T) {
+	runner := newTestHostRunner(t, `mkdir -p "$out/acme/v1"
+echo "$@" > "$out/acme/v1/user.pb.go"
+echo "$HOME" > "$out/home.txt"
+echo generated
+`)
+	defer runner.Close()
+
+	result, err := runner.Execute(context.Background(), hostRequest())
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, "generated\n", result.Stdout)
+	assert.Greater(t, result.Duration, time.Duration(0))
+
+	files := make(map[string]string)
+	for _, file := range result.GeneratedFiles {
+		files[file.Path] = string(file.Content)
+		assert.Equal(t, int64(len(file.Content)), file.Size)
+	}
+	require.Contains(t, files, filepath.Join("acme", "v1", "user.pb.go"))
+	require.Contains(t, files, "home.txt")
+
+	// Container paths are mapped into the sandbox, which is removed after
+	args := strings.Fields(files[filepath.Join("acme", "v1", "user.pb.go")])
+	require.Len(t, args, 4)
+	sandbox := strings.TrimSpace(files["home.txt"])
+	assert.Equal(t, "--proto_path="+filepath.Join(sandbox, "input"), args[0])
+	assert.Equal(t, "--go_out="+filepath.Join(sandbox, "output"), args[1])
+	assert.Equal(t, "--go_opt=paths=source_relative", args[2])
+	assert.Equal(t, filepath.Join(sandbox, "input", "acme", "v1", "user.proto"), args[3])
+	assert.NoDirExists(t, sandbox)
+}
+
+func TestHostRunner_ExecuteFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		config  func(*HostConfig)
+		req     func(*ExecutionRequest)
+		wantErr error
+	}{
+		{
+			name:    "non-zero exit",
+			script:  "echo 'user.proto:1:1: syntax error' >&2\nexit 1\n",
+			wantErr: ErrContainerFailed,
+		},
+		{
+			name:    "no generated files",
+			script:  "exit 0\n",
+			wantErr: ErrNoGeneratedFiles,
+		},
+		{
+			name:    "timeout",
+			script:  "sleep 10\n",
+			req:     func(req *ExecutionRequest) { req.Timeout = 200 * time.Millisecond },
+			wantErr: ErrTimeout,
+		},
+		{
+			name:    "generated files over the output limit",
+			script:  "head -c 4096 /dev/zero > \"$out/big.bin\"\n",
+			config:  func(c *HostConfig) { c.MaxOutputBytes = 1024 },
+			wantErr: ErrResourceLimit,
+		},
+		{
+			name:    "stdout over the output limit",
+			script:  "head -c 4096 /dev/zero\ntouch \"$out/x.go\"\n",
+			config:  func(c *HostConfig) { c.MaxOutputBytes = 1024 },
+			wantErr: ErrResourceLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &HostConfig{ProtocPath: fakeProtoc(t, tt.script)}
+			if tt.config != nil {
+				tt.config(cfg)
+			}
+			runner, err := NewHostRunner(cfg)
+			require.NoError(t, err)
+
+			req := hostRequest()
+			if tt.req != nil {
+				tt.req(req)
+			}
+			start := time.Now()
+			result, err := runner.Execute(context.Background(), req)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.ErrorIs(t, result.Error, tt.wantErr)
+			assert.False(t, result.Success)
+			assert.Less(t, time.Since(start), 5*time.Second)
+		})
+	}
+}
+
+func TestHostRunner_ExitCode(t *testing.T) {
+	runner := newTestHostRunner(t, "echo 'missing plugin' >&2\nexit 3\n")
+
+	result, err := runner.Execute(context.Background(), hostRequest())
+	assert.ErrorIs(t, err, ErrContainerFailed)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, "missing plugin\n", result.Stderr)
+	assert.Contains(t, err.Error(), "exit code 3: missing plugin")
+}
+
+func TestHostRunner_Output(t *testing.T) {
+	runner := newTestHostRunner(t, "echo 'compiling user.proto'\necho 'warning: unused import' >&2\ntouch \"$out/user.pb.go\"\n")
+
+	var output syncBuffer
+	req := hostRequest()
+	req.Output = &output
+	result, err := runner.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "compiling user.proto\n", result.Stdout)
+	assert.Equal(t, "warning: unused import\n", result.Stderr)
+	assert.Contains(t, output.String(), "compiling user.proto\n")
+	assert.Contains(t, output.String(), "warning: unused import\n")
+}
+
+func TestHostRunner_Cancel(t *testing.T) {
+	runner := newTestHostRunner(t, "sleep 10\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := runner.Execute(ctx, hostRequest())
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+// syncBuffer is a buffer safe for concurrent writes
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestHostRunner_CPULimit(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("CPU time is only capped on Linux")
+	}
+	runner := newTestHostRunner(t, "while :; do :; done\n")
+
+	// A tenth of a core for ten seconds is one second of CPU time
+	req := hostRequest()
+	req.CPULimit = 0.1
+	start := time.Now()
+	_, err := runner.Execute(context.Background(), req)
+	assert.ErrorIs(t, err, ErrResourceLimit)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestHostRunner_LimitsInherited(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("limits are only applied before exec on Linux")
+	}
+	// The limits hold from the start in the plugins protoc starts
+	runner, err := NewHostRunner(&HostConfig{
+		ProtocPath:     fakeProtoc(t, "sh -c 'grep -E \"^Max (cpu time|file size)\" /proc/self/limits' > \"$out/limits.txt\"\n"),
+		MaxOutputBytes: 4096,
+	})
+	require.NoError(t, err)
+
+	req := hostRequest()
+	req.CPULimit = 0.5
+	result, err := runner.Execute(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, result.GeneratedFiles, 1)
+
+	limits := strings.Split(strings.TrimSpace(string(result.GeneratedFiles[0].Content)), "\n")
+	require.Len(t, limits, 2)
+	assert.Equal(t, []string{"Max", "cpu", "time", "5", "6", "seconds"}, strings.Fields(limits[0]))
+	assert.Equal(t, []string{"Max", "file", "size", "4097", "4097", "bytes"}, strings.Fields(limits[1]))
+}
+
+func TestHostRunner_SandboxFull(t *testing.T) {
+	// Many files each under the file size limit still outgrow the output
+	// limit, and are stopped while being written
+	runner, err := NewHostRunner(&HostConfig{
+		ProtocPath:     fakeProtoc(t, "i=0\nwhile :; do head -c 512 /dev/zero > \"$out/f$i.bin\"; i=$((i+1)); done\n"),
+		MaxOutputBytes: 64 * 1024,
+	})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = runner.Execute(context.Background(), hostRequest())
+	assert.ErrorIs(t, err, ErrResourceLimit)
+	assert.Less(t, time.Since(start), 5*time.Second, "stopped before the timeout")
+}
+
+func TestHostRunner_PathEscape(t *testing.T) {
+	runner := newTestHostRunner(t, "touch \"$out/x.go\"\n")
+
+	req := hostRequest()
+	req.ProtoFiles = []codegen.ProtoFile{{Path: "../../etc/evil.proto", Content: []byte("")}}
+	_, err := runner.Execute(context.Background(), req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "escapes the sandbox")
+}
+
+func TestHostRunner_Env(t *testing.T) {
+	pluginDir := t.TempDir()
+	runner, err := NewHostRunner(&HostConfig{
+		ProtocPath: fakeProtoc(t, "echo \"$PATH|$GOFLAGS|$SPOKE_SECRET\" > \"$out/env.txt\"\n"),
+		PluginDirs: []string{pluginDir},
+	})
+	require.NoError(t, err)
+
+	// Only the request's variables are passed on
+	t.Setenv("SPOKE_SECRET", "leaked")
+	req := hostRequest()
+	req.Env = map[string]string{"GOFLAGS": "-mod=mod"}
+	result, err := runner.Execute(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, result.GeneratedFiles, 1)
+
+	parts := strings.Split(strings.TrimSpace(string(result.GeneratedFiles[0].Content)), "|")
+	require.Len(t, parts, 3)
+	assert.True(t, strings.HasPrefix(parts[0], pluginDir+string(os.PathListSeparator)))
+	assert.Equal(t, "-mod=mod", parts[1])
+	assert.Empty(t, parts[2])
+}
+
+func TestHostFlag(t *testing.T) {
+	tests := []struct {
+		flag string
+		want string
+	}{
+		{"--go_out=/output", "--go_out=/sb/output"},
+		{"--go_out=/output/gen", "--go_out=/sb/output/gen"},
+		{"--grpc-java_out=lite:/output", "--grpc-java_out=lite:/sb/output"},
+		{"--proto_path=/input", "--proto_path=/sb/input"},
+		{"--go_opt=paths=source_relative", "--go_opt=paths=source_relative"},
+		{"--go_out=/outputs", "--go_out=/outputs"},
+		{"--experimental_allow_proto3_optional", "--experimental_allow_proto3_optional"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, hostFlag(tt.flag, "/sb/input", "/sb/output"), tt.flag)
+	}
+}
@@ -154,6 +154,24 @@ func (r *DockerRunner) Execute(ctx context.Context, req *ExecutionRequest) (*Exe
 		return result, result.Error
 	}
 
+	// Stream the container's output while it runs
+	var stdout, stderr bytes.Buffer
+	logsDone := make(chan struct{})
+	logs, err := r.client.ContainerLogs(ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+	})
+	if err == nil {
+		go func() {
+			defer close(logsDone)
+			defer logs.Close()
+			stdcopy.StdCopy(withOutput(&stdout, req.Output), withOutput(&stderr, req.Output), logs)
+		}()
+	} else {
+		close(logsDone)
+	}
+
 	// Wait for container with timeout
 	execCtx, cancel := context.WithTimeout(ctx, req.Timeout)
 	defer cancel()
@@ -161,30 +179,36 @@ func (r *DockerRunner) Execute(ctx context.Context, req *ExecutionRequest) (*Exe
 	statusCh, errCh := r.client.ContainerWait(execCtx, containerID, container.WaitConditionNotRunning)
 	select {
 	case err := <-errCh:
-		if err != nil {
+		if err != nil && execCtx.Err() == nil {
+			r.client.ContainerKill(context.Background(), containerID, "KILL")
+			waitForLogs(logs, logsDone)
 			result.Error = fmt.Errorf("%w: wait failed: %v", ErrContainerFailed, err)
 			return result, result.Error
 		}
 	case status := <-statusCh:
 		result.ExitCode = int(status.StatusCode)
 	case <-execCtx.Done():
-		result.Error = ErrTimeout
-		return result, result.Error
 	}
 
-	// Get container logs
-	logs, err := r.client.ContainerLogs(ctx, containerID, container.LogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
-	})
-	if err == nil {
-		var stdout, stderr bytes.Buffer
-		stdcopy.StdCopy(&stdout, &stderr, logs)
+	// Timed out or cancelled compilations stop at once rather than when the
+	// container is cleaned up
+	if execCtx.Err() != nil {
+		r.client.ContainerKill(context.Background(), containerID, "KILL")
+		waitForLogs(logs, logsDone)
 		result.Stdout = stdout.String()
 		result.Stderr = stderr.String()
-		logs.Close()
+		if ctx.Err() != nil {
+			result.Error = ctx.Err()
+		} else {
+			result.Error = ErrTimeout
+		}
+		return result, result.Error
 	}
 
+	<-logsDone
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
 	// Check exit code
 	if result.ExitCode != 0 {
 		result.Error = fmt.Errorf("%w: exit code %d: %s", ErrContainerFailed, result.ExitCode, result.Stderr)
@@ -208,6 +232,24 @@ func (r *DockerRunner) Execute(ctx context.Context, req *ExecutionRequest) (*Exe
 	return result, nil
 }
 
+// logsDrainTimeout is how long the output of a killed container may take to
+// be copied before its log stream is closed
+var logsDrainTimeout = 5 * time.Second
+
+// waitForLogs waits for the output of a stopped container to be copied, so
+// that nothing is written to the request's output after Execute returns.
+// The log stream is closed if it does not end within logsDrainTimeout.
+func waitForLogs(logs io.Closer, done <-chan struct{}) {
+	select {
+	case <-done:
+	case <-time.After(logsDrainTimeout):
+		if logs != nil {
+			logs.Close()
+		}
+		<-done
+	}
+}
+
 // PullImage ensures the Docker image is available locally
 func (r *DockerRunner) PullImage(ctx context.Context, imageRef string) error {
 	// Check cache
@@ -327,6 +369,12 @@ func (r *DockerRunner) buildProtocCommand(req *ExecutionRequest) []string {
 
 // extractGeneratedFiles reads all files from the output directory
 func (r *DockerRunner) extractGeneratedFiles(outputDir string) ([]codegen.GeneratedFile, error) {
+	return extractGeneratedFiles(outputDir)
+}
+
+// extractGeneratedFiles reads all files from an output directory, with
+// paths relative to it
+func extractGeneratedFiles(outputDir string) ([]codegen.GeneratedFile, error) {
 	var files []codegen.GeneratedFile
 
 	err := filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
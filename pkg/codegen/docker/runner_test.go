@@ -1,7 +1,9 @@
 package docker
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"os"
 	"strings"
 	"testing"
@@ -435,3 +437,39 @@ func TestExecute_EmptyProtoFiles(t *testing.T) {
 	// Should complete but likely fail or produce no files
 	assert.NotNil(t, result)
 }
+
+func TestWaitForLogs(t *testing.T) {
+	defer func(timeout time.Duration) { logsDrainTimeout = timeout }(logsDrainTimeout)
+	logsDrainTimeout = 50 * time.Millisecond
+
+	copyLogs := func(logs io.Reader, out *bytes.Buffer) <-chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			io.Copy(out, logs)
+		}()
+		return done
+	}
+
+	t.Run("stream ends", func(t *testing.T) {
+		var out bytes.Buffer
+		done := copyLogs(strings.NewReader("generated\n"), &out)
+
+		waitForLogs(io.NopCloser(nil), done)
+		assert.Equal(t, "generated\n", out.String())
+	})
+
+	t.Run("stream left open", func(t *testing.T) {
+		var out bytes.Buffer
+		logs, writer := io.Pipe()
+		defer writer.Close()
+		done := copyLogs(logs, &out)
+		_, err := writer.Write([]byte("partial\n"))
+		require.NoError(t, err)
+
+		start := time.Now()
+		waitForLogs(logs, done)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, "partial\n", out.String())
+	})
+}
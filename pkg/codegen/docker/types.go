@@ -2,6 +2,7 @@ package docker
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/platinummonkey/spoke/pkg/codegen"
@@ -26,35 +27,48 @@ type Runner interface {
 // ExecutionRequest represents a Docker execution request
 type ExecutionRequest struct {
 	// Docker configuration
-	Image         string
-	Tag           string
+	Image string
+	Tag   string
 
 	// Input files
-	ProtoFiles    []codegen.ProtoFile
-	WorkDir       string // Working directory inside container
+	ProtoFiles []codegen.ProtoFile
+	WorkDir    string // Working directory inside container
 
 	// Protoc command
-	ProtocFlags   []string
-	OutputDir     string // Output directory inside container
+	ProtocFlags []string
+	OutputDir   string // Output directory inside container
 
 	// Resource limits
-	MemoryLimit   int64         // Memory limit in bytes (see config.DefaultDockerMemoryLimit)
-	CPULimit      float64       // CPU limit (see config.DefaultDockerCPULimit)
-	Timeout       time.Duration // Execution timeout (see config.DefaultDockerTimeout)
+	MemoryLimit int64         // Memory limit in bytes (see config.DefaultDockerMemoryLimit)
+	CPULimit    float64       // CPU limit (see config.DefaultDockerCPULimit)
+	Timeout     time.Duration // Execution timeout (see config.DefaultDockerTimeout)
 
 	// Environment variables
-	Env           map[string]string
+	Env map[string]string
+
+	// Output receives the stdout and stderr of protoc and its plugins as
+	// they are written; nil discards them. Writes come from several
+	// goroutines and must not fail.
+	Output io.Writer
 }
 
 // ExecutionResult represents the result of a Docker execution
 type ExecutionResult struct {
-	Success       bool
-	ExitCode      int
-	Stdout        string
-	Stderr        string
-	Duration      time.Duration
+	Success        bool
+	ExitCode       int
+	Stdout         string
+	Stderr         string
+	Duration       time.Duration
 	GeneratedFiles []codegen.GeneratedFile
-	Error         error
+	Error          error
+}
+
+// withOutput returns a writer writing to buf and, when set, to output
+func withOutput(buf io.Writer, output io.Writer) io.Writer {
+	if output == nil {
+		return buf
+	}
+	return io.MultiWriter(buf, output)
 }
 
 // ResourceLimits defines default resource limits
@@ -0,0 +1,193 @@
+package inprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/protoutil"
+	"github.com/bufbuild/protocompile/reporter"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+)
+
+// FileSet is a module compiled into descriptors. It is compiled once and
+// shared by the plugin runs of every language.
+type FileSet struct {
+	// Files holds the module's files and everything they import, imports
+	// before the files that import them, as plugins expect them
+	Files []*descriptorpb.FileDescriptorProto
+
+	// ToGenerate lists the module's files, sorted; imported dependency and
+	// well-known files are not generated
+	ToGenerate []string
+
+	// locations maps the full names of the descriptors in the module's files,
+	// and the files' paths, to their source locations
+	locations map[string]Location
+}
+
+// Location is a position in a proto file. Line and Column are 1-based and
+// zero when only the file is known.
+type Location struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String formats the location as file:line:column
+func (l Location) String() string {
+	if l.Line == 0 {
+		return l.File
+	}
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+}
+
+// Compile compiles the module's proto files into a file set. Imports are
+// resolved from the module's files, then the dependencies' files, then the
+// well-known files bundled with protocompile. All compile errors are
+// reported, each with its file, line and column.
+func Compile(ctx context.Context, files []codegen.ProtoFile, deps []codegen.Dependency) (*FileSet, error) {
+	if len(files) == 0 {
+		return nil, ErrNoProtoFiles
+	}
+
+	sources := make(map[string]string)
+	for _, dep := range deps {
+		for _, file := range dep.ProtoFiles {
+			sources[file.Path] = string(file.Content)
+		}
+	}
+	paths := make([]string, 0, len(files))
+	seen := make(map[string]bool)
+	for _, file := range files {
+		if !seen[file.Path] {
+			seen[file.Path] = true
+			paths = append(paths, file.Path)
+		}
+		sources[file.Path] = string(file.Content)
+	}
+	sort.Strings(paths)
+
+	var errs []error
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(sources),
+		}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+		Reporter: reporter.NewReporter(func(err reporter.ErrorWithPos) error {
+			errs = append(errs, err)
+			return nil
+		}, nil),
+	}
+	compiled, err := compiler.Compile(ctx, paths...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %w", ErrCompile, errors.Join(errs...))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCompile, err)
+	}
+
+	set := &FileSet{
+		ToGenerate: paths,
+		locations:  make(map[string]Location),
+	}
+	added := make(map[string]bool)
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if added[fd.Path()] {
+			return
+		}
+		added[fd.Path()] = true
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		set.Files = append(set.Files, fileProto(fd))
+	}
+	for _, fd := range compiled {
+		add(fd)
+		set.addLocations(fd)
+	}
+	return set, nil
+}
+
+// Locate returns the source location of a descriptor, by its full name
+// without a leading dot, or of a file, by its path
+func (s *FileSet) Locate(name string) (Location, bool) {
+	loc, ok := s.locations[name]
+	return loc, ok
+}
+
+// fileProto returns the descriptor proto of a file, keeping the source info
+// of files compiled from source
+func fileProto(fd protoreflect.FileDescriptor) *descriptorpb.FileDescriptorProto {
+	if result, ok := fd.(linker.Result); ok {
+		return result.FileDescriptorProto()
+	}
+	return protoutil.ProtoFromFileDescriptor(fd)
+}
+
+// addLocations records the locations of a module file and its descriptors
+func (s *FileSet) addLocations(fd protoreflect.FileDescriptor) {
+	s.locations[fd.Path()] = Location{File: fd.Path()}
+	locations := fd.SourceLocations()
+	add := func(d protoreflect.Descriptor) {
+		loc := locations.ByDescriptor(d)
+		s.locations[string(d.FullName())] = Location{
+			File:   fd.Path(),
+			Line:   loc.StartLine + 1,
+			Column: loc.StartColumn + 1,
+		}
+	}
+
+	addExtensions := func(exts protoreflect.ExtensionDescriptors) {
+		for i := 0; i < exts.Len(); i++ {
+			add(exts.Get(i))
+		}
+	}
+	addEnums := func(enums protoreflect.EnumDescriptors) {
+		for i := 0; i < enums.Len(); i++ {
+			enum := enums.Get(i)
+			add(enum)
+			for j := 0; j < enum.Values().Len(); j++ {
+				add(enum.Values().Get(j))
+			}
+		}
+	}
+	var addMessages func(msgs protoreflect.MessageDescriptors)
+	addMessages = func(msgs protoreflect.MessageDescriptors) {
+		for i := 0; i < msgs.Len(); i++ {
+			msg := msgs.Get(i)
+			if msg.IsMapEntry() {
+				continue
+			}
+			add(msg)
+			for j := 0; j < msg.Fields().Len(); j++ {
+				add(msg.Fields().Get(j))
+			}
+			for j := 0; j < msg.Oneofs().Len(); j++ {
+				add(msg.Oneofs().Get(j))
+			}
+			addEnums(msg.Enums())
+			addExtensions(msg.Extensions())
+			addMessages(msg.Messages())
+		}
+	}
+
+	addMessages(fd.Messages())
+	addEnums(fd.Enums())
+	addExtensions(fd.Extensions())
+	for i := 0; i < fd.Services().Len(); i++ {
+		svc := fd.Services().Get(i)
+		add(svc)
+		for j := 0; j < svc.Methods().Len(); j++ {
+			add(svc.Methods().Get(j))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package inprocess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+)
+
+const userProto = `syntax = "proto3";
+package acme.v1;
+
+import "google/protobuf/timestamp.proto";
+import "common/v1/money.proto";
+
+message User {
+  string name = 1;
+  google.protobuf.Timestamp created_at = 2;
+  common.v1.Money balance = 3;
+
+  enum Role {
+    ROLE_UNSPECIFIED = 0;
+  }
+}
+
+service UserService {
+  rpc GetUser(User) returns (User);
+}
+`
+
+const moneyProto = `syntax = "proto3";
+package common.v1;
+
+message Money {
+  int64 units = 1;
+}
+`
+
+func moduleFiles() []codegen.ProtoFile {
+	return []codegen.ProtoFile{{Path: "acme/v1/user.proto", Content: []byte(userProto)}}
+}
+
+func moduleDeps() []codegen.Dependency {
+	return []codegen.Dependency{{
+		ModuleName: "common",
+		Version:    "v1.0.0",
+		ProtoFiles: []codegen.ProtoFile{{Path: "common/v1/money.proto", Content: []byte(moneyProto)}},
+	}}
+}
+
+func TestCompile(t *testing.T) {
+	set, err := Compile(context.Background(), moduleFiles(), moduleDeps())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"acme/v1/user.proto"}, set.ToGenerate)
+
+	// Imports come before the files that import them
+	var names []string
+	for _, file := range set.Files {
+		names = append(names, file.GetName())
+	}
+	assert.Equal(t, []string{"google/protobuf/timestamp.proto", "common/v1/money.proto", "acme/v1/user.proto"}, names)
+	assert.NotNil(t, set.Files[2].GetSourceCodeInfo())
+
+	tests := []struct {
+		name string
+		want Location
+	}{
+		{"acme.v1.User", Location{File: "acme/v1/user.proto", Line: 7, Column: 1}},
+		{"acme.v1.User.created_at", Location{File: "acme/v1/user.proto", Line: 9, Column: 3}},
+		{"acme.v1.User.Role", Location{File: "acme/v1/user.proto", Line: 12, Column: 3}},
+		{"acme.v1.User.ROLE_UNSPECIFIED", Location{File: "acme/v1/user.proto", Line: 13, Column: 5}},
+		{"acme.v1.UserService.GetUser", Location{File: "acme/v1/user.proto", Line: 18, Column: 3}},
+		{"acme/v1/user.proto", Location{File: "acme/v1/user.proto"}},
+	}
+	for _, tt := range tests {
+		loc, ok := set.Locate(tt.name)
+		require.True(t, ok, tt.name)
+		assert.Equal(t, tt.want, loc, tt.name)
+	}
+
+	// Only the module's descriptors are located
+	_, ok := set.Locate("common.v1.Money")
+	assert.False(t, ok)
+}
+
+func TestCompile_Errors(t *testing.T) {
+	files := []codegen.ProtoFile{
+		{Path: "acme/v1/a.proto", Content: []byte("syntax = \"proto3\";\npackage acme.v1;\n\nmessage A {\n  Missing m = 1;\n}\n")},
+		{Path: "acme/v1/b.proto", Content: []byte("syntax = \"proto3\";\npackage acme.v1;\n\nmessage B {\n  string x = 1;\n  string y = 1;\n}\n")},
+	}
+
+	_, err := Compile(context.Background(), files, nil)
+	require.ErrorIs(t, err, ErrCompile)
+	assert.Contains(t, err.Error(), "acme/v1/a.proto:5:3:")
+	assert.Contains(t, err.Error(), "acme/v1/b.proto:6:")
+
+	_, err = Compile(context.Background(), nil, nil)
+	assert.ErrorIs(t, err, ErrNoProtoFiles)
+}
+
+func TestLocation_String(t *testing.T) {
+	assert.Equal(t, "a.proto:3:5", Location{File: "a.proto", Line: 3, Column: 5}.String())
+	assert.Equal(t, "a.proto", Location{File: "a.proto"}.String())
+}
@@ -0,0 +1,26 @@
+package inprocess
+
+import "errors"
+
+var (
+	// ErrNoProtoFiles is returned when there are no proto files to compile
+	ErrNoProtoFiles = errors.New("no proto files provided")
+
+	// ErrCompile is returned when the proto files do not compile
+	ErrCompile = errors.New("proto compilation failed")
+
+	// ErrPluginNotFound is returned when a protoc plugin executable is not
+	// found in the plugin directories or PATH
+	ErrPluginNotFound = errors.New("protoc plugin not found")
+
+	// ErrPluginFailed is returned when a plugin exits with an error, reports
+	// one in its response or returns an invalid response
+	ErrPluginFailed = errors.New("protoc plugin failed")
+
+	// ErrOutputLimit is returned when a plugin's response exceeds the
+	// configured size
+	ErrOutputLimit = errors.New("plugin output exceeds the limit")
+
+	// ErrNoGeneratedFiles is returned when no files were generated
+	ErrNoGeneratedFiles = errors.New("no files were generated")
+)
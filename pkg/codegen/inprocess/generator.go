@@ -0,0 +1,405 @@
+package inprocess
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/config"
+)
+
+// Config configures in-process code generation
+type Config struct {
+	PluginDirs     []string // Directories searched for protoc-gen-* plugins before PATH
+	MaxOutputBytes int64    // Limit on each plugin's response (see config.DefaultHostMaxOutputBytes)
+}
+
+// DefaultConfig returns the default in-process generation configuration
+func DefaultConfig() *Config {
+	return &Config{
+		MaxOutputBytes: config.DefaultHostMaxOutputBytes,
+	}
+}
+
+// Invocation is one run of a protoc plugin
+type Invocation struct {
+	Plugin    string // Executable name, such as protoc-gen-go
+	Path      string // Executable path from a --plugin flag; looked up when empty
+	Parameter string // Parameter passed to the plugin, such as paths=source_relative
+	OutputDir string // Directory of the generated files relative to the output root
+
+	// Stderr receives the plugin's stderr as it is written; nil discards it.
+	// Writes must not fail.
+	Stderr io.Writer
+}
+
+// Generator generates code by running protoc plugins directly, without
+// protoc. The module is compiled once into a FileSet, and each plugin is
+// sent a CodeGeneratorRequest built from it on stdin and answers with a
+// CodeGeneratorResponse on stdout, as protoc would run it.
+//
+// Code generators built into protoc, such as --java_out and --python_out,
+// are run as protoc-gen-java and protoc-gen-python and must be installed
+// as plugins.
+type Generator struct {
+	config *Config
+}
+
+// NewGenerator creates a new in-process generator
+func NewGenerator(cfg *Config) *Generator {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	return &Generator{config: cfg}
+}
+
+// Invocations converts protoc flags into plugin invocations, in the order
+// of their --*_out flags: --NAME_out=[PARAM:]DIR runs protoc-gen-NAME,
+// --NAME_opt adds to its parameter and --plugin=protoc-gen-NAME=PATH sets
+// its executable. Output directories must be /output or below it, as for
+// the container runners. Other flags are ignored.
+func Invocations(flags []string) ([]Invocation, error) {
+	var invocations []Invocation
+	index := make(map[string]int)
+	opts := make(map[string][]string)
+	paths := make(map[string]string)
+
+	for _, flag := range flags {
+		name, value, ok := strings.Cut(strings.TrimPrefix(flag, "--"), "=")
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "plugin":
+			plugin, path, ok := strings.Cut(value, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid flag %s: expected --plugin=NAME=PATH", flag)
+			}
+			paths[plugin] = path
+		case strings.HasSuffix(name, "_out"):
+			plugin := "protoc-gen-" + strings.TrimSuffix(name, "_out")
+			inv := Invocation{Plugin: plugin}
+			dir := value
+			if colon := strings.LastIndexByte(value, ':'); colon >= 0 {
+				inv.Parameter, dir = value[:colon], value[colon+1:]
+			}
+			outputDir, err := outputDir(dir)
+			if err != nil {
+				return nil, fmt.Errorf("invalid flag %s: %w", flag, err)
+			}
+			inv.OutputDir = outputDir
+			index[plugin] = len(invocations)
+			invocations = append(invocations, inv)
+		case strings.HasSuffix(name, "_opt"):
+			plugin := "protoc-gen-" + strings.TrimSuffix(name, "_opt")
+			opts[plugin] = append(opts[plugin], value)
+		}
+	}
+
+	for plugin, values := range opts {
+		i, ok := index[plugin]
+		if !ok {
+			continue
+		}
+		if invocations[i].Parameter != "" {
+			values = append([]string{invocations[i].Parameter}, values...)
+		}
+		invocations[i].Parameter = strings.Join(values, ",")
+	}
+	for plugin, path := range paths {
+		if i, ok := index[plugin]; ok {
+			invocations[i].Path = path
+		}
+	}
+	return invocations, nil
+}
+
+// outputDir returns the directory of an output flag relative to /output
+func outputDir(dir string) (string, error) {
+	dir = path.Clean(dir)
+	if dir == "/output" {
+		return "", nil
+	}
+	if rel, ok := strings.CutPrefix(dir, "/output/"); ok {
+		return rel, nil
+	}
+	return "", fmt.Errorf("output directory %s is not under /output", dir)
+}
+
+// Generate runs the invocations in order on the file set and returns the
+// files they generated. Later plugins can insert into the files of earlier
+// ones at their insertion points.
+func (g *Generator) Generate(ctx context.Context, set *FileSet, invocations []Invocation) ([]codegen.GeneratedFile, error) {
+	var files []codegen.GeneratedFile
+	byName := make(map[string]int)
+
+	for _, inv := range invocations {
+		resp, err := g.Run(ctx, set, inv)
+		if err != nil {
+			return nil, err
+		}
+
+		last := -1
+		for _, file := range resp.GetFile() {
+			name := file.GetName()
+			switch {
+			case name == "" && last < 0:
+				return nil, &PluginError{Plugin: inv.Plugin, Message: "first generated file has no name"}
+			case name == "":
+				// Content without a name continues the previous file
+				files[last].Content = append(files[last].Content, file.GetContent()...)
+				files[last].Size = int64(len(files[last].Content))
+				continue
+			case !filepath.IsLocal(name):
+				return nil, &PluginError{Plugin: inv.Plugin, Message: fmt.Sprintf("generated file name %q is not a relative path", name)}
+			}
+			name = path.Join(inv.OutputDir, name)
+
+			i, exists := byName[name]
+			if point := file.GetInsertionPoint(); point != "" {
+				if !exists {
+					return nil, &PluginError{Plugin: inv.Plugin, Message: fmt.Sprintf("insertion point %s is in %s, which was not generated", point, name)}
+				}
+				content, ok := insert(files[i].Content, point, file.GetContent())
+				if !ok {
+					return nil, &PluginError{Plugin: inv.Plugin, Message: fmt.Sprintf("insertion point %s not found in %s", point, name)}
+				}
+				files[i].Content = content
+				files[i].Size = int64(len(content))
+				last = i
+				continue
+			}
+			if exists {
+				return nil, &PluginError{Plugin: inv.Plugin, Message: fmt.Sprintf("%s was generated more than once", name)}
+			}
+
+			byName[name] = len(files)
+			last = len(files)
+			files = append(files, codegen.GeneratedFile{
+				Path:    name,
+				Content: []byte(file.GetContent()),
+				Size:    int64(len(file.GetContent())),
+			})
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, ErrNoGeneratedFiles
+	}
+	return files, nil
+}
+
+// insert adds content before the line holding an insertion point, indented
+// like that line, as protoc does
+func insert(file []byte, point, content string) ([]byte, bool) {
+	marker := []byte("@@protoc_insertion_point(" + point + ")")
+	at := bytes.Index(file, marker)
+	if at < 0 {
+		return nil, false
+	}
+	lineStart := bytes.LastIndexByte(file[:at], '\n') + 1
+	prefix := file[lineStart:at]
+	indent := prefix[:len(prefix)-len(bytes.TrimLeft(prefix, " \t"))]
+
+	var buf bytes.Buffer
+	buf.Write(file[:lineStart])
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		if line != "\n" {
+			buf.Write(indent)
+		}
+		buf.WriteString(line)
+	}
+	if !strings.HasSuffix(content, "\n") && content != "" {
+		buf.WriteByte('\n')
+	}
+	buf.Write(file[lineStart:])
+	return buf.Bytes(), true
+}
+
+// Run sends one plugin the request for the file set and returns its
+// response. Errors the plugin reports are returned as a *PluginError,
+// located at the descriptor or file they name.
+func (g *Generator) Run(ctx context.Context, set *FileSet, inv Invocation) (*pluginpb.CodeGeneratorResponse, error) {
+	executable, err := g.lookPlugin(inv)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: set.ToGenerate,
+		ProtoFile:      set.Files,
+	}
+	if inv.Parameter != "" {
+		req.Parameter = proto.String(inv.Parameter)
+	}
+	input, err := proto.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal code generator request: %w", err)
+	}
+
+	maxOutput := g.config.MaxOutputBytes
+	if maxOutput <= 0 {
+		maxOutput = config.DefaultHostMaxOutputBytes
+	}
+
+	stdout := &limitedBuffer{limit: maxOutput}
+	stderr := &limitedBuffer{limit: maxOutput}
+	cmd := exec.CommandContext(ctx, executable)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if inv.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(stderr, inv.Stderr)
+	}
+	cmd.Env = g.buildEnv()
+	// Stop waiting for output held open by the plugin's children once it
+	// is killed
+	cmd.WaitDelay = time.Second
+	waitErr := cmd.Run()
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", inv.Plugin, ctx.Err())
+	}
+	if stdout.truncated {
+		return nil, fmt.Errorf("%w: %s response exceeds %d bytes", ErrOutputLimit, inv.Plugin, maxOutput)
+	}
+	if waitErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(waitErr, &exitErr) {
+			return nil, fmt.Errorf("%w: %s: %v", ErrPluginFailed, inv.Plugin, waitErr)
+		}
+		message := strings.TrimSpace(stderr.buf.String())
+		if message == "" {
+			message = waitErr.Error()
+		}
+		return nil, set.pluginError(inv.Plugin, message)
+	}
+
+	resp := &pluginpb.CodeGeneratorResponse{}
+	if err := proto.Unmarshal(stdout.buf.Bytes(), resp); err != nil {
+		return nil, fmt.Errorf("%w: %s returned an invalid response: %v", ErrPluginFailed, inv.Plugin, err)
+	}
+	if resp.Error != nil {
+		return nil, set.pluginError(inv.Plugin, resp.GetError())
+	}
+	return resp, nil
+}
+
+// lookPlugin finds the executable of an invocation
+func (g *Generator) lookPlugin(inv Invocation) (string, error) {
+	if inv.Path != "" {
+		executable, err := exec.LookPath(inv.Path)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrPluginNotFound, err)
+		}
+		return executable, nil
+	}
+	for _, dir := range g.config.PluginDirs {
+		if executable, err := exec.LookPath(filepath.Join(dir, inv.Plugin)); err == nil {
+			return executable, nil
+		}
+	}
+	executable, err := exec.LookPath(inv.Plugin)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrPluginNotFound, inv.Plugin)
+	}
+	return executable, nil
+}
+
+// buildEnv builds the environment of a plugin: PATH with the plugin
+// directories first and the temp directory as home. Nothing else is
+// inherited from the server.
+func (g *Generator) buildEnv() []string {
+	path := append([]string{}, g.config.PluginDirs...)
+	if p := os.Getenv("PATH"); p != "" {
+		path = append(path, p)
+	}
+	return []string{
+		"PATH=" + strings.Join(path, string(os.PathListSeparator)),
+		"HOME=" + os.TempDir(),
+		"TMPDIR=" + os.TempDir(),
+	}
+}
+
+// limitedBuffer keeps the first limit bytes written to it and notes whether
+// more were written
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int64
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - int64(b.buf.Len()); int64(len(p)) > remaining {
+		b.buf.Write(p[:max(remaining, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// PluginError is an error reported by a protoc plugin, located at the first
+// descriptor of the module it names, or the first file when it names no
+// descriptor
+type PluginError struct {
+	Plugin   string
+	Location Location
+	Message  string
+}
+
+// Error formats the error as plugin: file:line:column: message
+func (e *PluginError) Error() string {
+	if e.Location.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Plugin, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Plugin, e.Location, e.Message)
+}
+
+// Unwrap makes plugin errors match ErrPluginFailed
+func (e *PluginError) Unwrap() error {
+	return ErrPluginFailed
+}
+
+// namePattern matches the full names and file paths an error message can
+// mention
+var namePattern = regexp.MustCompile(`[A-Za-z_][\w./-]*`)
+
+// pluginError creates a plugin error, locating the message in the file set
+func (s *FileSet) pluginError(plugin, message string) *PluginError {
+	err := &PluginError{Plugin: plugin, Message: message}
+	var file *Location
+	for _, name := range namePattern.FindAllString(message, -1) {
+		name = strings.TrimRight(name, ".")
+		loc, ok := s.Locate(name)
+		if !ok {
+			continue
+		}
+		if loc.Line > 0 {
+			err.Location = loc
+			return err
+		}
+		if file == nil {
+			file = &loc
+		}
+	}
+	if file != nil {
+		err.Location = *file
+	}
+	return err
+}
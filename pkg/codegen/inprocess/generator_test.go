@@ -0,0 +1,203 @@
+package inprocess
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// fakePlugin installs a plugin in dir that saves its request to
+// name.request and answers with resp, after running script
+func fakePlugin(t *testing.T, dir, name string, resp *pluginpb.CodeGeneratorResponse, script string) {
+	t.Helper()
+	data, err := proto.Marshal(resp)
+	require.NoError(t, err)
+	base := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(base+".response", data, 0644))
+	content := "#!/bin/sh\ncat > \"" + base + ".request\"\n" + script + "cat \"" + base + ".response\"\n"
+	require.NoError(t, os.WriteFile(base, []byte(content), 0755))
+}
+
+// pluginRequest reads the request a fake plugin received
+func pluginRequest(t *testing.T, dir, name string) *pluginpb.CodeGeneratorRequest {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name+".request"))
+	require.NoError(t, err)
+	req := &pluginpb.CodeGeneratorRequest{}
+	require.NoError(t, proto.Unmarshal(data, req))
+	return req
+}
+
+func generatedFile(name, content string) *pluginpb.CodeGeneratorResponse_File {
+	return &pluginpb.CodeGeneratorResponse_File{Name: proto.String(name), Content: proto.String(content)}
+}
+
+func compileModule(t *testing.T) *FileSet {
+	t.Helper()
+	set, err := Compile(context.Background(), moduleFiles(), moduleDeps())
+	require.NoError(t, err)
+	return set
+}
+
+func TestInvocations(t *testing.T) {
+	invocations, err := Invocations([]string{
+		"--go_out=/output",
+		"--go_opt=paths=source_relative",
+		"--go-grpc_out=require_unimplemented_servers=false:/output/grpc",
+		"--go-grpc_opt=paths=source_relative",
+		"--plugin=protoc-gen-go-grpc=/opt/bin/grpc",
+		"--experimental_allow_proto3_optional",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Invocation{
+		{Plugin: "protoc-gen-go", Parameter: "paths=source_relative"},
+		{Plugin: "protoc-gen-go-grpc", Path: "/opt/bin/grpc", Parameter: "require_unimplemented_servers=false,paths=source_relative", OutputDir: "grpc"},
+	}, invocations)
+
+	_, err = Invocations([]string{"--go_out=/tmp/out"})
+	assert.ErrorContains(t, err, "not under /output")
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	dir := t.TempDir()
+	fakePlugin(t, dir, "protoc-gen-go", &pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{
+		generatedFile("acme/v1/user.pb.go", "package acmev1\n\ntype User struct {\n\t// @@protoc_insertion_point(struct_User)\n}\n"),
+		{Content: proto.String("\nfunc init() {}\n")},
+	}}, "")
+	fakePlugin(t, dir, "protoc-gen-extra", &pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{
+		{Name: proto.String("acme/v1/user.pb.go"), InsertionPoint: proto.String("struct_User"), Content: proto.String("Extra string\n")},
+		generatedFile("extra.txt", "extra"),
+	}}, "")
+
+	set := compileModule(t)
+	generator := NewGenerator(&Config{PluginDirs: []string{dir}})
+	files, err := generator.Generate(context.Background(), set, []Invocation{
+		{Plugin: "protoc-gen-go", Parameter: "paths=source_relative", OutputDir: "gen"},
+		{Plugin: "protoc-gen-extra", OutputDir: "gen"},
+	})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	assert.Equal(t, "gen/acme/v1/user.pb.go", files[0].Path)
+	assert.Equal(t, "package acmev1\n\ntype User struct {\n\tExtra string\n\t// @@protoc_insertion_point(struct_User)\n}\n\nfunc init() {}\n", string(files[0].Content))
+	assert.Equal(t, int64(len(files[0].Content)), files[0].Size)
+	assert.Equal(t, "gen/extra.txt", files[1].Path)
+
+	// Every plugin gets the same descriptors, with its own parameter
+	goReq := pluginRequest(t, dir, "protoc-gen-go")
+	assert.Equal(t, []string{"acme/v1/user.proto"}, goReq.GetFileToGenerate())
+	assert.Equal(t, "paths=source_relative", goReq.GetParameter())
+	require.Len(t, goReq.GetProtoFile(), 3)
+	assert.Equal(t, "acme/v1/user.proto", goReq.GetProtoFile()[2].GetName())
+	extraReq := pluginRequest(t, dir, "protoc-gen-extra")
+	assert.Nil(t, extraReq.Parameter)
+	assert.True(t, proto.Equal(goReq.GetProtoFile()[2], extraReq.GetProtoFile()[2]))
+}
+
+func TestGenerator_Stderr(t *testing.T) {
+	dir := t.TempDir()
+	fakePlugin(t, dir, "protoc-gen-go", &pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{
+		generatedFile("user.pb.go", "package acmev1\n"),
+	}}, "echo 'warning: field name is deprecated' >&2\n")
+
+	var stderr bytes.Buffer
+	_, err := NewGenerator(&Config{PluginDirs: []string{dir}}).Generate(context.Background(), compileModule(t), []Invocation{
+		{Plugin: "protoc-gen-go", Stderr: &stderr},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "warning: field name is deprecated\n", stderr.String())
+}
+
+func TestGenerator_PluginErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    *pluginpb.CodeGeneratorResponse
+		script  string
+		want    string
+		wantLoc Location
+	}{
+		{
+			name:    "reported error naming a field",
+			resp:    &pluginpb.CodeGeneratorResponse{Error: proto.String("field acme.v1.User.created_at: unsupported type")},
+			want:    "protoc-gen-go: acme/v1/user.proto:9:3: field acme.v1.User.created_at: unsupported type",
+			wantLoc: Location{File: "acme/v1/user.proto", Line: 9, Column: 3},
+		},
+		{
+			name:    "reported error naming a file",
+			resp:    &pluginpb.CodeGeneratorResponse{Error: proto.String("acme/v1/user.proto: missing go_package option")},
+			want:    "protoc-gen-go: acme/v1/user.proto: missing go_package option",
+			wantLoc: Location{File: "acme/v1/user.proto"},
+		},
+		{
+			name:    "crash",
+			resp:    &pluginpb.CodeGeneratorResponse{},
+			script:  "echo 'panic: method .acme.v1.UserService.GetUser' >&2\nexit 2\n",
+			want:    "protoc-gen-go: acme/v1/user.proto:18:3: panic: method .acme.v1.UserService.GetUser",
+			wantLoc: Location{File: "acme/v1/user.proto", Line: 18, Column: 3},
+		},
+		{
+			name: "insertion point missing",
+			resp: &pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{
+				generatedFile("a.go", "package a\n"),
+				{Name: proto.String("a.go"), InsertionPoint: proto.String("imports"), Content: proto.String("x")},
+			}},
+			want: "protoc-gen-go: insertion point imports not found in a.go",
+		},
+		{
+			name: "file outside the output directory",
+			resp: &pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{
+				generatedFile("../a.go", "package a\n"),
+			}},
+			want: `protoc-gen-go: generated file name "../a.go" is not a relative path`,
+		},
+	}
+
+	set := compileModule(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			fakePlugin(t, dir, "protoc-gen-go", tt.resp, tt.script)
+
+			_, err := NewGenerator(&Config{PluginDirs: []string{dir}}).Generate(context.Background(), set, []Invocation{{Plugin: "protoc-gen-go"}})
+			require.ErrorIs(t, err, ErrPluginFailed)
+			assert.EqualError(t, err, tt.want)
+			var pluginErr *PluginError
+			require.ErrorAs(t, err, &pluginErr)
+			assert.Equal(t, tt.wantLoc, pluginErr.Location)
+		})
+	}
+}
+
+func TestGenerator_Failures(t *testing.T) {
+	set := compileModule(t)
+	dir := t.TempDir()
+
+	_, err := NewGenerator(&Config{PluginDirs: []string{dir}}).Generate(context.Background(), set, []Invocation{{Plugin: "protoc-gen-missing"}})
+	assert.ErrorIs(t, err, ErrPluginNotFound)
+
+	fakePlugin(t, dir, "protoc-gen-empty", &pluginpb.CodeGeneratorResponse{}, "")
+	_, err = NewGenerator(&Config{PluginDirs: []string{dir}}).Generate(context.Background(), set, []Invocation{{Plugin: "protoc-gen-empty"}})
+	assert.ErrorIs(t, err, ErrNoGeneratedFiles)
+
+	fakePlugin(t, dir, "protoc-gen-big", &pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{
+		generatedFile("big.txt", string(make([]byte, 4096))),
+	}}, "")
+	_, err = NewGenerator(&Config{PluginDirs: []string{dir}, MaxOutputBytes: 1024}).Generate(context.Background(), set, []Invocation{{Plugin: "protoc-gen-big"}})
+	assert.ErrorIs(t, err, ErrOutputLimit)
+
+	fakePlugin(t, dir, "protoc-gen-slow", &pluginpb.CodeGeneratorResponse{}, "sleep 10\n")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = NewGenerator(&Config{PluginDirs: []string{dir}}).Generate(ctx, set, []Invocation{{Plugin: "protoc-gen-slow"}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -43,7 +43,7 @@ type PackageManagerSpec struct {
 	Name            string            `json:"name"`              // "go-modules", "pip", "npm", "maven"
 	ConfigFiles     []string          `json:"config_files"`      // ["go.mod", "go.sum"]
 	TemplateDir     string            `json:"template_dir"`      // Path to template files
-	DependencyMap   map[string]string `json:"dependency_map"`    // Map proto imports to package dependencies
+	DependencyMap   map[string]string `json:"dependency_map"`    // Map module dependencies to package names
 	DefaultVersions map[string]string `json:"default_versions"`  // Default package versions
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path"
 	"sync"
 	"time"
 
@@ -12,8 +13,18 @@ import (
 	"github.com/platinummonkey/spoke/pkg/codegen/artifacts"
 	"github.com/platinummonkey/spoke/pkg/codegen/cache"
 	"github.com/platinummonkey/spoke/pkg/codegen/docker"
+	"github.com/platinummonkey/spoke/pkg/codegen/inprocess"
 	"github.com/platinummonkey/spoke/pkg/codegen/languages"
 	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages/cargo"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages/composer"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages/gomod"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages/maven"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages/npm"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages/nuget"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages/pip"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages/rubygems"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages/swiftpm"
 	"github.com/platinummonkey/spoke/pkg/observability"
 )
 
@@ -29,6 +40,7 @@ type DefaultOrchestrator struct {
 	config           *Config
 	languageRegistry *languages.Registry
 	dockerRunner     docker.Runner
+	generator        *inprocess.Generator
 	packageRegistry  *packages.Registry
 	cache            CacheInterface
 	artifactsManager artifacts.Manager
@@ -49,29 +61,45 @@ func NewOrchestrator(config *Config) (*DefaultOrchestrator, error) {
 		return nil, fmt.Errorf("failed to initialize language registry: %w", err)
 	}
 
-	// Initialize Docker runner
-	dockerRunner, err := docker.NewDockerRunner()
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Docker runner: %v", err)
+	// Initialize the runner protoc executes in, or the in-process generator
+	var dockerRunner docker.Runner
+	var generator *inprocess.Generator
+	if config.Runner == RunnerInProcess {
+		generator = inprocess.NewGenerator(config.InProcess)
+	} else if dockerRunner, err = newRunner(config); err != nil {
+		return nil, err
 	}
 
 	// Initialize logger
 	logger := observability.NewLogger(observability.InfoLevel, os.Stdout)
 
 	// Initialize package generator registry
-	pkgRegistry := packages.NewRegistry()
-	// Register default package generators
-	// Generators will be registered as we implement them
-	// For now, the registry is empty but ready to accept registrations
+	pkgRegistry := newPackageRegistry()
 
 	// Initialize cache (optional)
-	var cacheInstance *cache.MemoryCache
+	var cacheInstance CacheInterface
 	if config.EnableCache {
 		cacheConfig := cache.DefaultConfig()
-		cacheInstance, err = cache.NewCache(cacheConfig)
+		memoryCache, err := cache.NewCache(cacheConfig)
 		if err != nil {
 			// Log error but continue without cache
 			logger.WithError(err).Warn("Failed to initialize cache, continuing without cache")
+		} else {
+			cacheInstance = memoryCache
+		}
+
+		// Layer a Redis cache shared by all servers behind the memory cache
+		if memoryCache != nil && config.RedisAddr != "" {
+			redisConfig := cache.DefaultRedisConfig(config.RedisAddr)
+			redisConfig.Password = config.RedisPassword
+			redisConfig.DB = config.RedisDB
+			redisCache, err := cache.NewRedisCache(redisConfig)
+			if err != nil {
+				// Log error but continue with the memory cache only
+				logger.WithError(err).Warn("Failed to connect to Redis cache, continuing with memory cache only")
+			} else {
+				cacheInstance = cache.NewTieredCache(memoryCache, redisCache)
+			}
 		}
 	}
 
@@ -90,12 +118,29 @@ func NewOrchestrator(config *Config) (*DefaultOrchestrator, error) {
 			// Log error but continue without S3
 			logger.WithError(err).Warn("Failed to initialize artifacts manager, continuing without S3")
 		}
+	} else if config.StorageDir != "" {
+		artifactsConfig := &artifacts.Config{
+			S3Prefix:          config.S3Prefix,
+			CompressionFormat: "tar.gz",
+			EnableChecksum:    true,
+			LocalDir:          config.StorageDir,
+			BaseURL:           config.ArtifactsBaseURL,
+			URLSigningKey:     config.ArtifactsSigningKey,
+		}
+		fileManager, err := artifacts.NewFileManager(artifactsConfig)
+		if err != nil {
+			// Log error but continue without local artifacts
+			logger.WithError(err).Warn("Failed to initialize artifacts manager, continuing without artifact storage")
+		} else {
+			artifactsManagerInstance = fileManager
+		}
 	}
 
 	return &DefaultOrchestrator{
 		config:           config,
 		languageRegistry: langRegistry,
 		dockerRunner:     dockerRunner,
+		generator:        generator,
 		packageRegistry:  pkgRegistry,
 		cache:            cacheInstance,
 		artifactsManager: artifactsManagerInstance,
@@ -104,8 +149,56 @@ func NewOrchestrator(config *Config) (*DefaultOrchestrator, error) {
 	}, nil
 }
 
+// newPackageRegistry creates a package generator registry holding the
+// generator of every supported package manager, by package manager name
+func newPackageRegistry() *packages.Registry {
+	registry := packages.NewRegistry()
+	for _, generator := range []packages.Generator{
+		gomod.NewGenerator(),
+		maven.NewGenerator(),
+		npm.NewGenerator(),
+		pip.NewGenerator(),
+		cargo.NewGenerator(),
+		nuget.NewGenerator(),
+		swiftpm.NewGenerator(),
+		rubygems.NewGenerator(),
+		composer.NewGenerator(),
+	} {
+		registry.Register(generator.GetName(), generator)
+	}
+	return registry
+}
+
+// newRunner creates the runner selected by the configuration
+func newRunner(config *Config) (docker.Runner, error) {
+	switch config.Runner {
+	case "", RunnerDocker:
+		runner, err := docker.NewDockerRunner()
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize Docker runner: %v", err)
+		}
+		return runner, nil
+	case RunnerHost:
+		runner, err := docker.NewHostRunner(config.HostRunner)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize host runner: %w", err)
+		}
+		return runner, nil
+	default:
+		return nil, fmt.Errorf("unknown runner %q, expected docker, host or inprocess", config.Runner)
+	}
+}
+
 // CompileSingle compiles proto files for a single language
 func (o *DefaultOrchestrator) CompileSingle(ctx context.Context, req *CompileRequest) (*codegen.CompilationResult, error) {
+	return o.compileSingle(ctx, req, nil)
+}
+
+// compileSingle compiles proto files for a single language. With the
+// in-process generator, set is the module compiled by CompileAll for the
+// languages it did not find in the cache, or nil to check the cache and
+// compile it here.
+func (o *DefaultOrchestrator) compileSingle(ctx context.Context, req *CompileRequest, set *inprocess.FileSet) (*codegen.CompilationResult, error) {
 	// Validate request
 	if err := o.validateRequest(req); err != nil {
 		return nil, err
@@ -127,51 +220,53 @@ func (o *DefaultOrchestrator) CompileSingle(ctx context.Context, req *CompileReq
 		Success:  false,
 	}
 
-	// Check cache if enabled
-	if o.cache != nil && o.config.EnableCache {
-		cacheKey := cache.GenerateCacheKey(
-			req.ModuleName,
-			req.Version,
-			req.Language,
-			langSpec.PluginVersion,
-			req.ProtoFiles,
-			req.Dependencies,
-			req.Options,
-		)
-
-		cachedResult, err := o.cache.Get(ctx, cacheKey)
-		if err == nil && cachedResult != nil {
-			// Cache hit!
-			cachedResult.CacheHit = true
+	// Check cache if enabled, unless CompileAll already has
+	if set == nil {
+		if cachedResult := o.lookupCache(ctx, req, langSpec); cachedResult != nil {
+			if req.Output != nil {
+				fmt.Fprintf(req.Output, "Using cached %s compilation\n", req.Language)
+			}
 			cachedResult.Duration = time.Since(startTime)
 			return cachedResult, nil
 		}
-		// Cache miss - continue with compilation
 	}
 
-	// Build Docker execution request
-	dockerReq := &docker.ExecutionRequest{
-		Image:       langSpec.DockerImage,
-		Tag:         langSpec.DockerTag,
-		ProtoFiles:  req.ProtoFiles,
-		ProtocFlags: o.buildProtocFlags(langSpec, req),
-		Timeout:     time.Duration(o.config.CompilationTimeout) * time.Second,
-	}
+	var generatedFiles []codegen.GeneratedFile
+	var duration time.Duration
+	if o.generator != nil {
+		// Run the plugins in process
+		generatedFiles, err = o.generateInProcess(ctx, langSpec, req, set)
+		duration = time.Since(startTime)
+	} else {
+		// Build Docker execution request
+		dockerReq := &docker.ExecutionRequest{
+			Image:       langSpec.DockerImage,
+			Tag:         langSpec.DockerTag,
+			ProtoFiles:  req.ProtoFiles,
+			ProtocFlags: o.buildProtocFlags(langSpec, req),
+			Timeout:     o.config.CompilationTimeout,
+			Output:      req.Output,
+		}
 
-	// Execute compilation in Docker
-	execResult, err := o.dockerRunner.Execute(ctx, dockerReq)
+		// Execute compilation in Docker
+		var execResult *docker.ExecutionResult
+		execResult, err = o.dockerRunner.Execute(ctx, dockerReq)
+		if err == nil {
+			generatedFiles, duration = execResult.GeneratedFiles, execResult.Duration
+		}
+	}
 	if err != nil {
 		result.Error = fmt.Errorf("compilation failed: %v", err).Error()
 		result.Duration = time.Since(startTime)
 		return result, err
 	}
 
-	result.GeneratedFiles = execResult.GeneratedFiles
-	result.Duration = execResult.Duration
+	result.GeneratedFiles = generatedFiles
+	result.Duration = duration
 
 	// Generate package manager files
 	if langSpec.PackageManager != nil {
-		pkgFiles, err := o.generatePackageFiles(langSpec, req)
+		pkgFiles, err := o.generatePackageFiles(langSpec, req, result.GeneratedFiles)
 		if err != nil {
 			// Non-fatal error - log but continue
 			result.Error = fmt.Sprintf("package generation warning: %v", err)
@@ -208,17 +303,7 @@ func (o *DefaultOrchestrator) CompileSingle(ctx context.Context, req *CompileReq
 
 	// Store in cache if enabled
 	if o.cache != nil && o.config.EnableCache {
-		cacheKey := cache.GenerateCacheKey(
-			req.ModuleName,
-			req.Version,
-			req.Language,
-			langSpec.PluginVersion,
-			req.ProtoFiles,
-			req.Dependencies,
-			req.Options,
-		)
-
-		if err := o.cache.Set(ctx, cacheKey, result, 24*time.Hour); err != nil {
+		if err := o.cache.Set(ctx, cacheKey(req, langSpec), result, 24*time.Hour); err != nil {
 			// Log error but don't fail the compilation
 			o.logger.WithError(err).Warn("Failed to store result in cache")
 		}
@@ -227,6 +312,33 @@ func (o *DefaultOrchestrator) CompileSingle(ctx context.Context, req *CompileReq
 	return result, nil
 }
 
+// lookupCache returns the cached result of a compilation, or nil when the
+// cache is disabled or does not hold it
+func (o *DefaultOrchestrator) lookupCache(ctx context.Context, req *CompileRequest, langSpec *languages.LanguageSpec) *codegen.CompilationResult {
+	if o.cache == nil || !o.config.EnableCache {
+		return nil
+	}
+	cachedResult, err := o.cache.Get(ctx, cacheKey(req, langSpec))
+	if err != nil || cachedResult == nil {
+		return nil
+	}
+	cachedResult.CacheHit = true
+	return cachedResult
+}
+
+// cacheKey returns the cache key of a compilation
+func cacheKey(req *CompileRequest, langSpec *languages.LanguageSpec) *codegen.CacheKey {
+	return cache.GenerateCacheKey(
+		req.ModuleName,
+		req.Version,
+		req.Language,
+		langSpec.PluginVersion,
+		req.ProtoFiles,
+		req.Dependencies,
+		req.Options,
+	)
+}
+
 // CompileAll compiles proto files for multiple languages in parallel
 func (o *DefaultOrchestrator) CompileAll(ctx context.Context, req *CompileRequest, languageIDs []string) ([]*codegen.CompilationResult, error) {
 	if len(languageIDs) == 0 {
@@ -244,13 +356,57 @@ func (o *DefaultOrchestrator) CompileAll(ctx context.Context, req *CompileReques
 		}
 	}
 
+	languageRequest := func(language string) *CompileRequest {
+		return &CompileRequest{
+			ModuleName:   req.ModuleName,
+			Version:      req.Version,
+			VersionID:    req.VersionID,
+			ProtoFiles:   req.ProtoFiles,
+			Dependencies: req.Dependencies,
+			IncludeGRPC:  req.IncludeGRPC,
+			Options:      req.Options,
+			StorageDir:   req.StorageDir,
+			S3Bucket:     req.S3Bucket,
+			Language:     language,
+		}
+	}
+
+	// When generating in process, the module is compiled once for every
+	// language the cache does not already hold, and not at all when it
+	// holds them all
+	results := make([]*codegen.CompilationResult, len(languageIDs))
+	pending := make([]int, 0, len(languageIDs))
+	var set *inprocess.FileSet
+	if o.generator != nil && len(req.ProtoFiles) > 0 {
+		for i, langID := range languageIDs {
+			langSpec, _ := o.languageRegistry.Get(langID)
+			if cachedResult := o.lookupCache(ctx, languageRequest(langID), langSpec); cachedResult != nil {
+				results[i] = cachedResult
+				continue
+			}
+			pending = append(pending, i)
+		}
+		if len(pending) == 0 {
+			return results, nil
+		}
+
+		var err error
+		if set, err = inprocess.Compile(ctx, req.ProtoFiles, req.Dependencies); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCompilationFailed, err)
+		}
+	} else {
+		for i := range languageIDs {
+			pending = append(pending, i)
+		}
+	}
+
 	// Create worker pool
 	maxWorkers := o.config.MaxParallelWorkers
 	if maxWorkers <= 0 {
 		maxWorkers = 5
 	}
-	if maxWorkers > len(languageIDs) {
-		maxWorkers = len(languageIDs)
+	if maxWorkers > len(pending) {
+		maxWorkers = len(pending)
 	}
 
 	// Channel for work distribution
@@ -259,12 +415,12 @@ func (o *DefaultOrchestrator) CompileAll(ctx context.Context, req *CompileReques
 		index    int
 	}
 
-	workCh := make(chan workItem, len(languageIDs))
+	workCh := make(chan workItem, len(pending))
 	resultCh := make(chan struct {
 		result *codegen.CompilationResult
 		index  int
 		err    error
-	}, len(languageIDs))
+	}, len(pending))
 
 	// Start workers
 	var wg sync.WaitGroup
@@ -277,21 +433,7 @@ func (o *DefaultOrchestrator) CompileAll(ctx context.Context, req *CompileReques
 			defer observability.RecoverPanic(o.logger, "orchestrator worker goroutine")
 
 			for work := range workCh {
-				// Create language-specific request
-				langReq := &CompileRequest{
-					ModuleName:   req.ModuleName,
-					Version:      req.Version,
-					VersionID:    req.VersionID,
-					ProtoFiles:   req.ProtoFiles,
-					Dependencies: req.Dependencies,
-					IncludeGRPC:  req.IncludeGRPC,
-					Options:      req.Options,
-					StorageDir:   req.StorageDir,
-					S3Bucket:     req.S3Bucket,
-					Language:     work.language,
-				}
-
-				result, err := o.CompileSingle(ctx, langReq)
+				result, err := o.compileSingle(ctx, languageRequest(work.language), set)
 				resultCh <- struct {
 					result *codegen.CompilationResult
 					index  int
@@ -302,8 +444,8 @@ func (o *DefaultOrchestrator) CompileAll(ctx context.Context, req *CompileReques
 	}
 
 	// Distribute work
-	for i, langID := range languageIDs {
-		workCh <- workItem{language: langID, index: i}
+	for _, i := range pending {
+		workCh <- workItem{language: languageIDs[i], index: i}
 	}
 	close(workCh)
 
@@ -319,7 +461,6 @@ func (o *DefaultOrchestrator) CompileAll(ctx context.Context, req *CompileReques
 	}()
 
 	// Collect results in order
-	results := make([]*codegen.CompilationResult, len(languageIDs))
 	var errs []error
 
 	for item := range resultCh {
@@ -414,11 +555,18 @@ func (o *DefaultOrchestrator) buildProtocFlags(langSpec *languages.LanguageSpec,
 	// Add language-specific flags
 	switch langSpec.ID {
 	case languages.LanguageGo:
+		importPaths := goImportPaths(req)
 		flags = append(flags, "--go_out=/output")
 		flags = append(flags, langSpec.ProtocFlags...)
+		for _, mapping := range importPaths {
+			flags = append(flags, "--go_opt="+mapping)
+		}
 		if req.IncludeGRPC && langSpec.SupportsGRPC {
 			flags = append(flags, "--go-grpc_out=/output")
 			flags = append(flags, langSpec.GRPCFlags...)
+			for _, mapping := range importPaths {
+				flags = append(flags, "--go-grpc_opt="+mapping)
+			}
 		}
 	case languages.LanguagePython:
 		flags = append(flags, "--python_out=/output")
@@ -439,8 +587,60 @@ func (o *DefaultOrchestrator) buildProtocFlags(langSpec *languages.LanguageSpec,
 	return flags
 }
 
-// generatePackageFiles generates package manager configuration files
-func (o *DefaultOrchestrator) generatePackageFiles(langSpec *languages.LanguageSpec, req *CompileRequest) ([]codegen.GeneratedFile, error) {
+// goImportPaths returns the M options of the Go plugins mapping each proto
+// file of the module, and of each dependency, to the package of its
+// directory within the Go module named by OptionGoModulePath
+func goImportPaths(req *CompileRequest) []string {
+	var mappings []string
+	add := func(modulePath string, files []codegen.ProtoFile) {
+		if modulePath == "" {
+			return
+		}
+		for _, file := range files {
+			importPath := modulePath
+			if dir := path.Dir(file.Path); dir != "." {
+				importPath += "/" + dir
+			}
+			mappings = append(mappings, "M"+file.Path+"="+importPath)
+		}
+	}
+
+	add(req.Options[OptionGoModulePath], req.ProtoFiles)
+	for _, dep := range req.Dependencies {
+		add(req.Options[OptionGoModulePath+":"+dep.ModuleName], dep.ProtoFiles)
+	}
+	return mappings
+}
+
+// generateInProcess runs the language's plugins on the compiled module,
+// compiling it first when set is nil
+func (o *DefaultOrchestrator) generateInProcess(ctx context.Context, langSpec *languages.LanguageSpec, req *CompileRequest, set *inprocess.FileSet) ([]codegen.GeneratedFile, error) {
+	if o.config.CompilationTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.config.CompilationTimeout)
+		defer cancel()
+	}
+
+	if set == nil {
+		var err error
+		if set, err = inprocess.Compile(ctx, req.ProtoFiles, req.Dependencies); err != nil {
+			return nil, err
+		}
+	}
+
+	invocations, err := inprocess.Invocations(o.buildProtocFlags(langSpec, req))
+	if err != nil {
+		return nil, err
+	}
+	for i := range invocations {
+		invocations[i].Stderr = req.Output
+	}
+	return o.generator.Generate(ctx, set, invocations)
+}
+
+// generatePackageFiles generates package manager configuration files,
+// depending on the packages of the module's dependencies
+func (o *DefaultOrchestrator) generatePackageFiles(langSpec *languages.LanguageSpec, req *CompileRequest, generatedFiles []codegen.GeneratedFile) ([]codegen.GeneratedFile, error) {
 	if langSpec.PackageManager == nil {
 		return nil, nil
 	}
@@ -451,11 +651,13 @@ func (o *DefaultOrchestrator) generatePackageFiles(langSpec *languages.LanguageS
 	}
 
 	pkgReq := &packages.GenerateRequest{
-		ModuleName:  req.ModuleName,
-		Version:     req.Version,
-		Language:    langSpec.ID,
-		IncludeGRPC: req.IncludeGRPC,
-		Options:     req.Options,
+		ModuleName:     req.ModuleName,
+		Version:        req.Version,
+		Language:       langSpec.ID,
+		GeneratedFiles: generatedFiles,
+		Dependencies:   packages.ResolveDependencies(generator, req.Dependencies, langSpec.PackageManager.DependencyMap),
+		IncludeGRPC:    req.IncludeGRPC,
+		Options:        req.Options,
 	}
 
 	return generator.Generate(pkgReq)
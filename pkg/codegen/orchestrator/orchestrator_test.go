@@ -1,24 +1,31 @@
 package orchestrator
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/platinummonkey/spoke/pkg/codegen"
 	"github.com/platinummonkey/spoke/pkg/codegen/artifacts"
 	"github.com/platinummonkey/spoke/pkg/codegen/cache"
 	pkgconfig "github.com/platinummonkey/spoke/pkg/codegen/config"
 	"github.com/platinummonkey/spoke/pkg/codegen/docker"
+	"github.com/platinummonkey/spoke/pkg/codegen/inprocess"
 	"github.com/platinummonkey/spoke/pkg/codegen/languages"
 	"github.com/platinummonkey/spoke/pkg/codegen/packages"
 	"github.com/platinummonkey/spoke/pkg/observability"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
 )
 
 // Mock Docker Runner
@@ -392,6 +399,182 @@ func TestGetStatus_NotFound(t *testing.T) {
 	assert.ErrorIs(t, err, ErrJobNotFound)
 }
 
+func TestNewOrchestrator_HostRunner(t *testing.T) {
+	protoc := filepath.Join(t.TempDir(), "protoc")
+	require.NoError(t, os.WriteFile(protoc, []byte("#!/bin/sh\n"), 0755))
+
+	cfg := DefaultConfig()
+	cfg.EnableCache = false
+	cfg.Runner = RunnerHost
+	cfg.HostRunner = &docker.HostConfig{ProtocPath: protoc}
+	orch, err := NewOrchestrator(cfg)
+	require.NoError(t, err)
+	defer orch.Close()
+	assert.IsType(t, &docker.HostRunner{}, orch.dockerRunner)
+
+	cfg.HostRunner = &docker.HostConfig{ProtocPath: filepath.Join(t.TempDir(), "missing")}
+	_, err = NewOrchestrator(cfg)
+	assert.ErrorIs(t, err, docker.ErrProtocNotFound)
+
+	cfg.Runner = "podman"
+	_, err = NewOrchestrator(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown runner")
+}
+
+func TestNewOrchestrator_RedisCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	cfg := DefaultConfig()
+	cfg.Runner = RunnerInProcess
+	cfg.RedisAddr = mr.Addr()
+	orch, err := NewOrchestrator(cfg)
+	require.NoError(t, err)
+	defer orch.Close()
+	assert.IsType(t, &cache.TieredCache{}, orch.cache)
+
+	// Servers start without Redis, using the memory cache only
+	mr.Close()
+	orch, err = NewOrchestrator(cfg)
+	require.NoError(t, err)
+	defer orch.Close()
+	assert.IsType(t, &cache.MemoryCache{}, orch.cache)
+}
+
+func TestNewOrchestrator_LocalArtifacts(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EnableCache = false
+	cfg.Runner = RunnerInProcess
+	cfg.StorageDir = t.TempDir()
+	orch, err := NewOrchestrator(cfg)
+	require.NoError(t, err)
+	defer orch.Close()
+	assert.IsType(t, &artifacts.FileManager{}, orch.artifactsManager)
+}
+
+// inProcessPlugin installs a plugin in dir that logs its name and
+// parameter to dir/calls and generates one file
+func inProcessPlugin(t *testing.T, dir, name, file string) {
+	t.Helper()
+	data, err := proto.Marshal(&pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{
+		{Name: proto.String(file), Content: proto.String("// generated by " + name + "\n")},
+	}})
+	require.NoError(t, err)
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path+".response", data, 0644))
+	script := fmt.Sprintf("#!/bin/sh\ncat > /dev/null\necho %s >> %s\ncat %s\n", name, filepath.Join(dir, "calls"), path+".response")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+}
+
+func newInProcessOrchestrator(t *testing.T, pluginDir string) *DefaultOrchestrator {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.EnableCache = false
+	cfg.Runner = RunnerInProcess
+	cfg.InProcess = &inprocess.Config{PluginDirs: []string{pluginDir}}
+	orch, err := NewOrchestrator(cfg)
+	require.NoError(t, err)
+	assert.Nil(t, orch.dockerRunner)
+	return orch
+}
+
+func TestCompileAll_InProcess(t *testing.T) {
+	dir := t.TempDir()
+	inProcessPlugin(t, dir, "protoc-gen-go", "acme/v1/user.pb.go")
+	inProcessPlugin(t, dir, "protoc-gen-go-grpc", "acme/v1/user_grpc.pb.go")
+	inProcessPlugin(t, dir, "protoc-gen-python", "acme/v1/user_pb2.py")
+	inProcessPlugin(t, dir, "protoc-gen-grpc_python", "acme/v1/user_pb2_grpc.py")
+	orch := newInProcessOrchestrator(t, dir)
+	defer orch.Close()
+
+	req := &CompileRequest{
+		ModuleName:  "acme",
+		Version:     "v1.0.0",
+		IncludeGRPC: true,
+		ProtoFiles: []codegen.ProtoFile{
+			{Path: "acme/v1/user.proto", Content: []byte("syntax = \"proto3\";\npackage acme.v1;\n\nmessage User {\n  string name = 1;\n}\n")},
+		},
+	}
+	results, err := orch.CompileAll(context.Background(), req, []string{"go", "python"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	paths := func(result *codegen.CompilationResult) []string {
+		var paths []string
+		for _, file := range result.GeneratedFiles {
+			paths = append(paths, file.Path)
+		}
+		return paths
+	}
+	assert.True(t, results[0].Success)
+	assert.Equal(t, []string{"acme/v1/user.pb.go", "acme/v1/user_grpc.pb.go"}, paths(results[0]))
+	assert.True(t, results[1].Success)
+	assert.Equal(t, []string{"acme/v1/user_pb2.py", "acme/v1/user_pb2_grpc.py"}, paths(results[1]))
+
+	calls, err := os.ReadFile(filepath.Join(dir, "calls"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"protoc-gen-go", "protoc-gen-go-grpc", "protoc-gen-python", "protoc-gen-grpc_python"}, strings.Fields(string(calls)))
+}
+
+func TestCompileAll_InProcessCached(t *testing.T) {
+	orch := newInProcessOrchestrator(t, t.TempDir())
+	defer orch.Close()
+	orch.config.EnableCache = true
+	orch.cache = &mockCache{
+		getFunc: func(ctx context.Context, key *codegen.CacheKey) (*codegen.CompilationResult, error) {
+			if key.Language != "go" && key.Language != "python" {
+				return nil, cache.ErrCacheMiss
+			}
+			return &codegen.CompilationResult{Language: key.Language, Success: true}, nil
+		},
+	}
+
+	// The module does not compile, so cached results are all that can be returned
+	req := &CompileRequest{
+		ModuleName: "acme",
+		Version:    "v1.0.0",
+		ProtoFiles: []codegen.ProtoFile{
+			{Path: "acme/v1/user.proto", Content: []byte("syntax = \"proto3\";\npackage acme.v1;\n\nmessage User {\n  Missing m = 1;\n}\n")},
+		},
+	}
+	results, err := orch.CompileAll(context.Background(), req, []string{"go", "python"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for i, language := range []string{"go", "python"} {
+		assert.Equal(t, language, results[i].Language)
+		assert.True(t, results[i].CacheHit)
+	}
+
+	// A single miss compiles the module
+	_, err = orch.CompileAll(context.Background(), req, []string{"go", "java"})
+	assert.ErrorIs(t, err, inprocess.ErrCompile)
+}
+
+func TestCompile_InProcessErrors(t *testing.T) {
+	dir := t.TempDir()
+	orch := newInProcessOrchestrator(t, dir)
+	defer orch.Close()
+
+	req := &CompileRequest{
+		ModuleName: "acme",
+		Version:    "v1.0.0",
+		Language:   "go",
+		ProtoFiles: []codegen.ProtoFile{
+			{Path: "acme/v1/user.proto", Content: []byte("syntax = \"proto3\";\npackage acme.v1;\n\nmessage User {\n  Missing m = 1;\n}\n")},
+		},
+	}
+	_, err := orch.CompileAll(context.Background(), req, []string{"go"})
+	assert.ErrorIs(t, err, ErrCompilationFailed)
+	assert.ErrorIs(t, err, inprocess.ErrCompile)
+	assert.Contains(t, err.Error(), "acme/v1/user.proto:5:3")
+
+	req.ProtoFiles[0].Content = []byte("syntax = \"proto3\";\npackage acme.v1;\n")
+	result, err := orch.CompileSingle(context.Background(), req)
+	assert.ErrorIs(t, err, inprocess.ErrPluginNotFound)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "protoc-gen-go")
+}
+
 func TestDefaultConfig(t *testing.T) {
 	cfg := DefaultConfig()
 	assert.NotNil(t, cfg)
@@ -400,6 +583,7 @@ func TestDefaultConfig(t *testing.T) {
 	assert.True(t, cfg.EnableMetrics)
 	assert.Equal(t, pkgconfig.DefaultCodeGenVersion, cfg.CodeGenVersion)
 	assert.Equal(t, pkgconfig.DefaultCompilationTimeout, cfg.CompilationTimeout)
+	assert.Equal(t, RunnerDocker, cfg.Runner)
 }
 
 func TestClose(t *testing.T) {
@@ -495,6 +679,37 @@ func TestCompileSingle_Success(t *testing.T) {
 	assert.False(t, result.CacheHit)
 }
 
+func TestCompileSingle_Output(t *testing.T) {
+	dockerRunner := &mockDockerRunner{
+		executeFunc: func(ctx context.Context, req *docker.ExecutionRequest) (*docker.ExecutionResult, error) {
+			require.NotNil(t, req.Output, "output is passed to the runner")
+			fmt.Fprintln(req.Output, "test.proto:1:1: warning: import unused")
+			return &docker.ExecutionResult{
+				Success:        true,
+				GeneratedFiles: []codegen.GeneratedFile{{Path: "test.pb.go", Content: []byte("package test")}},
+			}, nil
+		},
+	}
+
+	orch := newMockOrchestrator(t, dockerRunner, nil, nil)
+	defer orch.Close()
+
+	var output bytes.Buffer
+	req := &CompileRequest{
+		ModuleName: "test",
+		Version:    "v1.0.0",
+		Language:   "go",
+		ProtoFiles: []codegen.ProtoFile{
+			{Path: "test.proto", Content: []byte("syntax = \"proto3\";")},
+		},
+		Output: &output,
+	}
+
+	_, err := orch.CompileSingle(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "test.proto:1:1: warning: import unused\n", output.String())
+}
+
 func TestCompileSingle_DisabledLanguage(t *testing.T) {
 	dockerRunner := &mockDockerRunner{}
 	orch := newMockOrchestrator(t, dockerRunner, nil, nil)
@@ -968,6 +1183,50 @@ func TestBuildProtocFlags_CPP(t *testing.T) {
 	assert.Contains(t, flags, "--cpp_out=/output")
 }
 
+func TestBuildProtocFlags_GoModulePath(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EnableCache = false
+	cfg.Runner = RunnerInProcess
+	orch, err := NewOrchestrator(cfg)
+	require.NoError(t, err)
+	defer orch.Close()
+
+	langSpec, err := orch.languageRegistry.Get("go")
+	require.NoError(t, err)
+
+	req := &CompileRequest{
+		ProtoFiles: []codegen.ProtoFile{
+			{Path: "user.proto"},
+			{Path: "acme/v1/account.proto"},
+		},
+		Dependencies: []codegen.Dependency{
+			{ModuleName: "common", ProtoFiles: []codegen.ProtoFile{{Path: "common/types.proto"}}},
+			{ModuleName: "unmapped", ProtoFiles: []codegen.ProtoFile{{Path: "unmapped.proto"}}},
+		},
+		IncludeGRPC: true,
+		Options: map[string]string{
+			OptionGoModulePath:             "spoke.example.com/go/users",
+			OptionGoModulePath + ":common": "spoke.example.com/go/common/v2",
+		},
+	}
+
+	flags := orch.buildProtocFlags(langSpec, req)
+	for _, plugin := range []string{"go", "go-grpc"} {
+		assert.Contains(t, flags, "--"+plugin+"_opt=Muser.proto=spoke.example.com/go/users")
+		assert.Contains(t, flags, "--"+plugin+"_opt=Macme/v1/account.proto=spoke.example.com/go/users/acme/v1")
+		assert.Contains(t, flags, "--"+plugin+"_opt=Mcommon/types.proto=spoke.example.com/go/common/v2/common")
+	}
+	for _, flag := range flags {
+		assert.NotContains(t, flag, "unmapped.proto")
+	}
+
+	// Without the option, go_package decides
+	req.Options = nil
+	for _, flag := range orch.buildProtocFlags(langSpec, req) {
+		assert.NotContains(t, flag, "=M")
+	}
+}
+
 func TestBuildProtocFlags_GenericLanguage(t *testing.T) {
 	orch, err := NewOrchestrator(nil)
 	require.NoError(t, err)
@@ -1066,7 +1325,7 @@ func TestGeneratePackageFiles_NoPackageManager(t *testing.T) {
 	langSpec.PackageManager = nil
 	defer func() { langSpec.PackageManager = originalPM }()
 
-	files, err := orch.generatePackageFiles(langSpec, req)
+	files, err := orch.generatePackageFiles(langSpec, req, nil)
 	assert.NoError(t, err)
 	assert.Nil(t, files)
 }
@@ -1090,12 +1349,56 @@ func TestGeneratePackageFiles_GeneratorNotFound(t *testing.T) {
 	}
 	defer func() { langSpec.PackageManager = originalPM }()
 
-	files, err := orch.generatePackageFiles(langSpec, req)
+	files, err := orch.generatePackageFiles(langSpec, req, nil)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "package generator not found")
 	assert.Nil(t, files)
 }
 
+func TestNewPackageRegistry(t *testing.T) {
+	registry := newPackageRegistry()
+	for _, name := range []string{"go-modules", "maven", "npm", "pip", "cargo", "nuget", "swift-package", "gem", "composer"} {
+		generator, ok := registry.Get(name)
+		require.True(t, ok, name)
+		assert.Equal(t, name, generator.GetName())
+	}
+}
+
+func TestGeneratePackageFiles_DependencyMap(t *testing.T) {
+	orch := newMockOrchestrator(t, &mockDockerRunner{}, nil, nil)
+	orch.packageRegistry = newPackageRegistry()
+	defer orch.Close()
+
+	langSpec, err := orch.languageRegistry.Get("rust")
+	require.NoError(t, err)
+	originalPM := langSpec.PackageManager
+	langSpec.PackageManager = &languages.PackageManagerSpec{
+		Name:          "cargo",
+		DependencyMap: map[string]string{"common": "acme-common-protos"},
+	}
+	defer func() { langSpec.PackageManager = originalPM }()
+
+	req := &CompileRequest{
+		ModuleName: "user-service",
+		Version:    "v1.2.0",
+		Dependencies: []codegen.Dependency{
+			{ModuleName: "common", Version: "v1.0.0"},
+			{ModuleName: "Billing_API", Version: "v2.3.1"},
+		},
+	}
+	files, err := orch.generatePackageFiles(langSpec, req, []codegen.GeneratedFile{{Path: "acme.v1.rs"}})
+	require.NoError(t, err)
+
+	contents := make(map[string]string)
+	for _, file := range files {
+		contents[file.Path] = string(file.Content)
+	}
+	require.Contains(t, contents, "Cargo.toml")
+	assert.Contains(t, contents["Cargo.toml"], "acme-common-protos = \"1.0.0\"")
+	assert.Contains(t, contents["Cargo.toml"], "billing-api = \"2.3.1\"")
+	assert.Contains(t, contents["lib.rs"], "include!(\"acme.v1.rs\");")
+}
+
 func TestCompileSingle_CacheSetFailure(t *testing.T) {
 	cacheInstance := &mockCache{
 		getFunc: func(ctx context.Context, key *codegen.CacheKey) (*codegen.CompilationResult, error) {
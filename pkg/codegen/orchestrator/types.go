@@ -2,10 +2,13 @@ package orchestrator
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/platinummonkey/spoke/pkg/codegen"
 	"github.com/platinummonkey/spoke/pkg/codegen/config"
+	"github.com/platinummonkey/spoke/pkg/codegen/docker"
+	"github.com/platinummonkey/spoke/pkg/codegen/inprocess"
 )
 
 // Orchestrator coordinates the compilation process
@@ -26,26 +29,30 @@ type Orchestrator interface {
 // CompileRequest represents a compilation request
 type CompileRequest struct {
 	// Module information
-	ModuleName    string
-	Version       string
-	VersionID     int64
+	ModuleName string
+	Version    string
+	VersionID  int64
 
 	// Proto files (already fetched from storage)
-	ProtoFiles    []codegen.ProtoFile
+	ProtoFiles []codegen.ProtoFile
 
 	// Dependencies (already resolved)
-	Dependencies  []codegen.Dependency
+	Dependencies []codegen.Dependency
 
 	// Language (for single language compilation)
-	Language      string
+	Language string
 
 	// Compilation options
-	IncludeGRPC   bool
-	Options       map[string]string
+	IncludeGRPC bool
+	Options     map[string]string
 
 	// Storage configuration
-	StorageDir    string // Local storage directory
-	S3Bucket      string // S3 bucket for artifacts
+	StorageDir string // Local storage directory
+	S3Bucket   string // S3 bucket for artifacts
+
+	// Output receives the output of protoc and its plugins as they run; nil
+	// discards it. Writes come from several goroutines and must not fail.
+	Output io.Writer
 }
 
 // Config holds orchestrator configuration
@@ -54,25 +61,55 @@ type Config struct {
 	MaxParallelWorkers int // Maximum number of parallel compilations
 
 	// Feature flags
-	EnableCache        bool
-	EnableMetrics      bool
-	CodeGenVersion     string // "v1" or "v2"
+	EnableCache    bool
+	EnableMetrics  bool
+	CodeGenVersion string // "v1" or "v2"
 
 	// Storage
-	StorageDir         string
-	S3Bucket           string
-	S3Prefix           string
-	S3Region           string
+	StorageDir          string // Local artifact storage, used when S3Bucket is empty
+	S3Bucket            string
+	S3Prefix            string
+	S3Region            string
+	ArtifactsBaseURL    string // External URL of the server, prefixing local artifact download URLs
+	ArtifactsSigningKey string // Key signing local artifact download URLs; kept in StorageDir when empty
 
 	// Cache configuration
-	RedisAddr          string // Redis address for L2 cache
-	RedisPassword      string
-	RedisDB            int
+	RedisAddr     string // Redis address for L2 cache
+	RedisPassword string
+	RedisDB       int
 
 	// Timeouts
 	CompilationTimeout time.Duration // Maximum time for a single compilation
+
+	// Execution
+	Runner     RunnerType         // How compilations run; docker when empty
+	HostRunner *docker.HostConfig // Host runner options; defaults when nil
+	InProcess  *inprocess.Config  // In-process generation options; defaults when nil
 }
 
+// RunnerType selects how compilations execute
+type RunnerType string
+
+const (
+	// RunnerDocker runs protoc in Docker containers
+	RunnerDocker RunnerType = "docker"
+
+	// RunnerHost runs protoc directly on the host, for machines without a
+	// Docker daemon
+	RunnerHost RunnerType = "host"
+
+	// RunnerInProcess compiles the module once in process and runs the
+	// protoc plugins directly, without protoc
+	RunnerInProcess RunnerType = "inprocess"
+)
+
+// OptionGoModulePath is the CompileRequest option naming the Go module the
+// generated Go code is served as. Each proto file then generates the package
+// of its directory within that module, whatever its go_package option says.
+// The same option suffixed with ":" and a dependency's module name does so
+// for the files of that dependency, so imports across modules resolve.
+const OptionGoModulePath = "go_module_path"
+
 // DefaultConfig returns default configuration
 func DefaultConfig() *Config {
 	return &Config{
@@ -81,5 +118,6 @@ func DefaultConfig() *Config {
 		EnableMetrics:      true,
 		CodeGenVersion:     config.DefaultCodeGenVersion,
 		CompilationTimeout: config.DefaultCompilationTimeout,
+		Runner:             RunnerDocker,
 	}
 }
@@ -0,0 +1,183 @@
+package cargo
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+)
+
+//go:embed Cargo.toml.tmpl
+var cargoTomlTemplate string
+
+// Generator generates Cargo crate files for prost generated code
+type Generator struct{}
+
+// NewGenerator creates a new Cargo generator
+func NewGenerator() *Generator {
+	return &Generator{}
+}
+
+// Generate creates package manager files for Cargo
+func (g *Generator) Generate(req *packages.GenerateRequest) ([]codegen.GeneratedFile, error) {
+	var files []codegen.GeneratedFile
+
+	// Generate Cargo.toml
+	cargoToml, err := g.generateCargoToml(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Cargo.toml: %v", err)
+	}
+	files = append(files, cargoToml)
+
+	// Generate lib.rs
+	libRs := generateLibRs(req.GeneratedFiles)
+	files = append(files, libRs)
+
+	// Generate README.md
+	readme := codegen.GeneratedFile{
+		Path:    "README.md",
+		Content: []byte(fmt.Sprintf("# %s\n\nProtocol Buffer generated code for Rust.\n\n## Installation\n\n```toml\n[dependencies]\n%s = \"%s\"\n```\n", req.ModuleName, convertToCrateName(req.ModuleName), packages.SemVer(req.Version))),
+		Size:    0,
+	}
+	readme.Size = int64(len(readme.Content))
+	files = append(files, readme)
+
+	return files, nil
+}
+
+// GetName returns the name of the package manager
+func (g *Generator) GetName() string {
+	return "cargo"
+}
+
+// GetConfigFiles returns the list of config files this generator creates
+func (g *Generator) GetConfigFiles() []string {
+	return []string{"Cargo.toml", "lib.rs", "README.md"}
+}
+
+// PackageName returns the crate name of the code generated for a module
+func (g *Generator) PackageName(moduleName string) string {
+	return convertToCrateName(moduleName)
+}
+
+func (g *Generator) generateCargoToml(req *packages.GenerateRequest) (codegen.GeneratedFile, error) {
+	tmpl, err := template.New("Cargo.toml").Funcs(template.FuncMap{
+		"default": func(def, val string) string {
+			if val == "" {
+				return def
+			}
+			return val
+		},
+	}).Parse(cargoTomlTemplate)
+	if err != nil {
+		return codegen.GeneratedFile{}, err
+	}
+
+	data := map[string]interface{}{
+		"CrateName":    convertToCrateName(req.ModuleName),
+		"Version":      packages.SemVer(req.Version),
+		"ModuleName":   req.ModuleName,
+		"ProstVersion": "0.12",
+		"TonicVersion": "0.10",
+		"IncludeGRPC":  req.IncludeGRPC,
+		"Dependencies": convertDependencies(req.Dependencies),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return codegen.GeneratedFile{}, err
+	}
+
+	return codegen.GeneratedFile{
+		Path:    "Cargo.toml",
+		Content: buf.Bytes(),
+		Size:    int64(buf.Len()),
+	}, nil
+}
+
+// rustModule is a module of lib.rs with the generated files it includes
+type rustModule struct {
+	includes []string
+	children map[string]*rustModule
+}
+
+// generateLibRs generates the crate root, which includes each file prost
+// generated, named after its proto package, in the module of that package:
+// acme.v1.rs is included in acme::v1, as is tonic's acme.v1.tonic.rs
+func generateLibRs(generated []codegen.GeneratedFile) codegen.GeneratedFile {
+	root := &rustModule{children: make(map[string]*rustModule)}
+	for _, file := range generated {
+		name := path.Base(file.Path)
+		if !strings.HasSuffix(name, ".rs") || name == "lib.rs" || name == "mod.rs" {
+			continue
+		}
+		pkg := strings.TrimSuffix(strings.TrimSuffix(name, ".rs"), ".tonic")
+
+		module := root
+		for _, part := range strings.Split(pkg, ".") {
+			child, ok := module.children[part]
+			if !ok {
+				child = &rustModule{children: make(map[string]*rustModule)}
+				module.children[part] = child
+			}
+			module = child
+		}
+		module.includes = append(module.includes, file.Path)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by Spoke. DO NOT EDIT.\n")
+	writeRustModule(&buf, root, 0)
+
+	return codegen.GeneratedFile{
+		Path:    "lib.rs",
+		Content: buf.Bytes(),
+		Size:    int64(buf.Len()),
+	}
+}
+
+func writeRustModule(buf *bytes.Buffer, module *rustModule, depth int) {
+	indent := strings.Repeat("    ", depth)
+	sort.Strings(module.includes)
+	for _, include := range module.includes {
+		fmt.Fprintf(buf, "%sinclude!(%q);\n", indent, include)
+	}
+
+	names := make([]string, 0, len(module.children))
+	for name := range module.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(buf, "%spub mod %s {\n", indent, name)
+		writeRustModule(buf, module.children[name], depth+1)
+		fmt.Fprintf(buf, "%s}\n", indent)
+	}
+}
+
+// convertToCrateName converts a module name to a valid crate name
+func convertToCrateName(moduleName string) string {
+	// Example: "User Service" -> "user-service"
+	name := strings.ToLower(moduleName)
+	name = strings.ReplaceAll(name, "_", "-")
+	name = strings.ReplaceAll(name, " ", "-")
+	return name
+}
+
+// convertDependencies converts package dependencies to Cargo format
+func convertDependencies(deps []packages.Dependency) []map[string]string {
+	result := make([]map[string]string, 0, len(deps))
+	for _, dep := range deps {
+		result = append(result, map[string]string{
+			"Name":    dep.Name,
+			"Version": packages.SemVer(dep.Version),
+		})
+	}
+	return result
+}
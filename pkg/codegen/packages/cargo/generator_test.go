@@ -0,0 +1,90 @@
+package cargo
+
+import (
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_GetName(t *testing.T) {
+	gen := NewGenerator()
+	assert.Equal(t, "cargo", gen.GetName())
+}
+
+func TestGenerator_GetConfigFiles(t *testing.T) {
+	gen := NewGenerator()
+	assert.ElementsMatch(t, []string{"Cargo.toml", "lib.rs", "README.md"}, gen.GetConfigFiles())
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	gen := NewGenerator()
+
+	req := &packages.GenerateRequest{
+		ModuleName:  "user-service",
+		Version:     "v1.2.0",
+		Language:    "rust",
+		IncludeGRPC: true,
+		GeneratedFiles: []codegen.GeneratedFile{
+			{Path: "acme.v1.rs"},
+			{Path: "acme.v1.tonic.rs"},
+			{Path: "acme.common.rs"},
+			{Path: "google.api.rs"},
+		},
+		Dependencies: []packages.Dependency{
+			{Name: "common-protos", Version: "v1.0.0"},
+		},
+	}
+
+	files, err := gen.Generate(req)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+
+	fileMap := make(map[string]string)
+	for _, f := range files {
+		fileMap[f.Path] = string(f.Content)
+		assert.Equal(t, int64(len(f.Content)), f.Size)
+	}
+
+	cargoToml := fileMap["Cargo.toml"]
+	assert.Contains(t, cargoToml, "name = \"user-service\"")
+	assert.Contains(t, cargoToml, "version = \"1.2.0\"")
+	assert.Contains(t, cargoToml, "prost = \"0.12\"")
+	assert.Contains(t, cargoToml, "tonic = \"0.10\"")
+	assert.Contains(t, cargoToml, "common-protos = \"1.0.0\"")
+
+	assert.Equal(t, `// Code generated by Spoke. DO NOT EDIT.
+pub mod acme {
+    pub mod common {
+        include!("acme.common.rs");
+    }
+    pub mod v1 {
+        include!("acme.v1.rs");
+        include!("acme.v1.tonic.rs");
+    }
+}
+pub mod google {
+    pub mod api {
+        include!("google.api.rs");
+    }
+}
+`, fileMap["lib.rs"])
+
+	assert.Contains(t, fileMap["README.md"], "user-service = \"1.2.0\"")
+}
+
+func TestGenerator_Generate_NoGRPC(t *testing.T) {
+	gen := NewGenerator()
+
+	files, err := gen.Generate(&packages.GenerateRequest{ModuleName: "user-service", Version: "1.0.0"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(files[0].Content), "tonic")
+}
+
+func TestConvertToCrateName(t *testing.T) {
+	assert.Equal(t, "user-service", convertToCrateName("User_Service"))
+	assert.Equal(t, "my-protos", convertToCrateName("my protos"))
+	assert.Equal(t, "user-service", NewGenerator().PackageName("user_service"))
+}
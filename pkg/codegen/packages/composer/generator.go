@@ -0,0 +1,111 @@
+package composer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+)
+
+// Generator generates Composer package files
+type Generator struct{}
+
+// NewGenerator creates a new Composer generator
+func NewGenerator() *Generator {
+	return &Generator{}
+}
+
+// Generate creates package manager files for Composer
+func (g *Generator) Generate(req *packages.GenerateRequest) ([]codegen.GeneratedFile, error) {
+	var files []codegen.GeneratedFile
+
+	// Generate composer.json
+	composerJSON, err := g.generateComposerJSON(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate composer.json: %v", err)
+	}
+	files = append(files, composerJSON)
+
+	// Generate README.md
+	readme := codegen.GeneratedFile{
+		Path:    "README.md",
+		Content: []byte(fmt.Sprintf("# %s\n\nProtocol Buffer generated code for PHP.\n\n## Installation\n\n```bash\ncomposer require %s:%s\n```\n", req.ModuleName, convertToPackageName(req.ModuleName), packages.SemVer(req.Version))),
+		Size:    0,
+	}
+	readme.Size = int64(len(readme.Content))
+	files = append(files, readme)
+
+	return files, nil
+}
+
+// GetName returns the name of the package manager
+func (g *Generator) GetName() string {
+	return "composer"
+}
+
+// GetConfigFiles returns the list of config files this generator creates
+func (g *Generator) GetConfigFiles() []string {
+	return []string{"composer.json", "README.md"}
+}
+
+// PackageName returns the Composer package name of the code generated for a
+// module
+func (g *Generator) PackageName(moduleName string) string {
+	return convertToPackageName(moduleName)
+}
+
+func (g *Generator) generateComposerJSON(req *packages.GenerateRequest) (codegen.GeneratedFile, error) {
+	require := map[string]string{
+		"php":             ">=8.1",
+		"google/protobuf": "^3.25",
+	}
+	if req.IncludeGRPC {
+		require["grpc/grpc"] = "^1.57"
+	}
+
+	// Add dependencies
+	for _, dep := range req.Dependencies {
+		require[dep.Name] = "^" + packages.SemVer(dep.Version)
+	}
+
+	pkg := map[string]interface{}{
+		"name":        convertToPackageName(req.ModuleName),
+		"description": fmt.Sprintf("Protocol Buffer generated code for %s", req.ModuleName),
+		"version":     packages.SemVer(req.Version),
+		"type":        "library",
+		"require":     require,
+		// protoc writes classes and their GPBMetadata in directories named
+		// after their namespaces
+		"autoload": map[string]interface{}{
+			"psr-4": map[string]string{"": "./"},
+		},
+	}
+
+	// Marshal to JSON with indentation, keeping version constraints readable
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(pkg); err != nil {
+		return codegen.GeneratedFile{}, err
+	}
+
+	return codegen.GeneratedFile{
+		Path:    "composer.json",
+		Content: buf.Bytes(),
+		Size:    int64(buf.Len()),
+	}, nil
+}
+
+// convertToPackageName converts a module name to a valid Composer package
+// name
+func convertToPackageName(moduleName string) string {
+	// Example: "User_Service" -> "spoke/user-service"
+	name := strings.ToLower(moduleName)
+	name = strings.ReplaceAll(name, "_", "-")
+	name = strings.ReplaceAll(name, " ", "-")
+	return "spoke/" + name
+}
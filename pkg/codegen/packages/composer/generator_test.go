@@ -0,0 +1,67 @@
+package composer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_GetName(t *testing.T) {
+	gen := NewGenerator()
+	assert.Equal(t, "composer", gen.GetName())
+}
+
+func TestGenerator_GetConfigFiles(t *testing.T) {
+	gen := NewGenerator()
+	assert.ElementsMatch(t, []string{"composer.json", "README.md"}, gen.GetConfigFiles())
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	gen := NewGenerator()
+
+	req := &packages.GenerateRequest{
+		ModuleName:  "user-service",
+		Version:     "v1.2.0",
+		Language:    "php",
+		IncludeGRPC: true,
+		Dependencies: []packages.Dependency{
+			{Name: "acme/common-protos", Version: "v1.0.0"},
+		},
+	}
+
+	files, err := gen.Generate(req)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "composer.json", files[0].Path)
+	assert.Contains(t, string(files[0].Content), `"php": ">=8.1"`)
+
+	var pkg map[string]interface{}
+	require.NoError(t, json.Unmarshal(files[0].Content, &pkg))
+	assert.Equal(t, "spoke/user-service", pkg["name"])
+	assert.Equal(t, "1.2.0", pkg["version"])
+	assert.Equal(t, map[string]interface{}{
+		"php":                ">=8.1",
+		"google/protobuf":    "^3.25",
+		"grpc/grpc":          "^1.57",
+		"acme/common-protos": "^1.0.0",
+	}, pkg["require"])
+	assert.Equal(t, map[string]interface{}{"psr-4": map[string]interface{}{"": "./"}}, pkg["autoload"])
+
+	assert.Contains(t, string(files[1].Content), "composer require spoke/user-service:1.2.0")
+}
+
+func TestGenerator_Generate_NoGRPC(t *testing.T) {
+	gen := NewGenerator()
+
+	files, err := gen.Generate(&packages.GenerateRequest{ModuleName: "user-service", Version: "1.0.0"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(files[0].Content), "grpc/grpc")
+}
+
+func TestConvertToPackageName(t *testing.T) {
+	assert.Equal(t, "spoke/user-service", convertToPackageName("User_Service"))
+	assert.Equal(t, "spoke/my-protos", NewGenerator().PackageName("my protos"))
+}
@@ -58,6 +58,11 @@ func (g *Generator) GetConfigFiles() []string {
 	return []string{"go.mod", "README.md"}
 }
 
+// PackageName returns the Go module path of the code generated for a module
+func (g *Generator) PackageName(moduleName string) string {
+	return convertToGoModulePath(moduleName, "")
+}
+
 func (g *Generator) generateGoMod(req *packages.GenerateRequest) (codegen.GeneratedFile, error) {
 	tmpl, err := template.New("go.mod").Funcs(template.FuncMap{
 		"default": func(def, val string) string {
@@ -34,9 +34,10 @@ func (g *Generator) Generate(req *packages.GenerateRequest) ([]codegen.Generated
 	files = append(files, pomXML)
 
 	// Generate README.md
+	groupID, artifactID := coordinates(req)
 	readme := codegen.GeneratedFile{
 		Path:    "README.md",
-		Content: []byte(fmt.Sprintf("# %s\n\nProtocol Buffer generated code for Java.\n\n## Installation\n\n```xml\n<dependency>\n    <groupId>com.spoke.generated</groupId>\n    <artifactId>%s</artifactId>\n    <version>%s</version>\n</dependency>\n```\n", req.ModuleName, convertToArtifactId(req.ModuleName), req.Version)),
+		Content: []byte(fmt.Sprintf("# %s\n\nProtocol Buffer generated code for Java.\n\n## Installation\n\n```xml\n<dependency>\n    <groupId>%s</groupId>\n    <artifactId>%s</artifactId>\n    <version>%s</version>\n</dependency>\n```\n", req.ModuleName, groupID, artifactID, req.Version)),
 		Size:    0,
 	}
 	readme.Size = int64(len(readme.Content))
@@ -55,6 +56,12 @@ func (g *Generator) GetConfigFiles() []string {
 	return []string{"pom.xml", "README.md"}
 }
 
+// PackageName returns the groupId:artifactId of the code generated for a
+// module
+func (g *Generator) PackageName(moduleName string) string {
+	return "com.spoke.generated:" + convertToArtifactId(moduleName)
+}
+
 func (g *Generator) generatePomXML(req *packages.GenerateRequest) (codegen.GeneratedFile, error) {
 	tmpl, err := template.New("pom.xml").Funcs(template.FuncMap{
 		"default": func(def, val string) string {
@@ -68,9 +75,10 @@ func (g *Generator) generatePomXML(req *packages.GenerateRequest) (codegen.Gener
 		return codegen.GeneratedFile{}, err
 	}
 
+	groupID, artifactID := coordinates(req)
 	data := map[string]interface{}{
-		"GroupId":         "com.spoke.generated",
-		"ArtifactId":      convertToArtifactId(req.ModuleName),
+		"GroupId":         groupID,
+		"ArtifactId":      artifactID,
 		"Version":         req.Version,
 		"ModuleName":      req.ModuleName,
 		"ProtobufVersion": "3.25.1",
@@ -91,6 +99,15 @@ func (g *Generator) generatePomXML(req *packages.GenerateRequest) (codegen.Gener
 	}, nil
 }
 
+// coordinates returns the groupId and artifactId of a request, which the
+// package_name option overrides as groupId:artifactId
+func coordinates(req *packages.GenerateRequest) (string, string) {
+	if groupID, artifactID, ok := strings.Cut(req.Options[packages.OptionPackageName], ":"); ok {
+		return groupID, artifactID
+	}
+	return "com.spoke.generated", convertToArtifactId(req.ModuleName)
+}
+
 // convertToArtifactId converts a module name to a valid Maven artifact ID
 func convertToArtifactId(moduleName string) string {
 	// Example: "user-service" -> "user-service"
@@ -319,3 +319,25 @@ func TestGenerator_GenerateReadme(t *testing.T) {
 	assert.Contains(t, *readme, "<artifactId>test-api</artifactId>")
 	assert.Contains(t, *readme, "<version>3.1.4</version>")
 }
+
+func TestGenerator_Generate_PackageNameOption(t *testing.T) {
+	gen := NewGenerator()
+
+	req := &packages.GenerateRequest{
+		ModuleName: "payments",
+		Version:    "1.0.0",
+		Options:    map[string]string{packages.OptionPackageName: "com.ourorg.proto:payments-proto"},
+	}
+
+	files, err := gen.Generate(req)
+	require.NoError(t, err)
+
+	fileMap := make(map[string]string)
+	for _, f := range files {
+		fileMap[f.Path] = string(f.Content)
+	}
+	assert.Contains(t, fileMap["pom.xml"], "<groupId>com.ourorg.proto</groupId>")
+	assert.Contains(t, fileMap["pom.xml"], "<artifactId>payments-proto</artifactId>")
+	assert.Contains(t, fileMap["README.md"], "<groupId>com.ourorg.proto</groupId>")
+	assert.Contains(t, fileMap["README.md"], "<artifactId>payments-proto</artifactId>")
+}
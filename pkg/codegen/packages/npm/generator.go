@@ -42,7 +42,7 @@ func (g *Generator) Generate(req *packages.GenerateRequest) ([]codegen.Generated
 	// Generate README.md
 	readme := codegen.GeneratedFile{
 		Path:    "README.md",
-		Content: []byte(fmt.Sprintf("# %s\n\nProtocol Buffer generated code for JavaScript/TypeScript.\n\n## Installation\n\n```bash\nnpm install %s\n```\n", req.ModuleName, convertToNPMPackageName(req.ModuleName))),
+		Content: []byte(fmt.Sprintf("# %s\n\nProtocol Buffer generated code for JavaScript/TypeScript.\n\n## Installation\n\n```bash\nnpm install %s\n```\n", req.ModuleName, packageName(req))),
 		Size:    0,
 	}
 	readme.Size = int64(len(readme.Content))
@@ -61,11 +61,15 @@ func (g *Generator) GetConfigFiles() []string {
 	return []string{"package.json", "tsconfig.json", "README.md"}
 }
 
-func (g *Generator) generatePackageJSON(req *packages.GenerateRequest) (codegen.GeneratedFile, error) {
-	packageName := convertToNPMPackageName(req.ModuleName)
+// PackageName returns the npm package name of the code generated for a
+// module
+func (g *Generator) PackageName(moduleName string) string {
+	return convertToNPMPackageName(moduleName)
+}
 
+func (g *Generator) generatePackageJSON(req *packages.GenerateRequest) (codegen.GeneratedFile, error) {
 	pkg := map[string]interface{}{
-		"name":        packageName,
+		"name":        packageName(req),
 		"version":     req.Version,
 		"description": fmt.Sprintf("Protocol Buffer generated code for %s", req.ModuleName),
 		"main":        "index.js",
@@ -139,6 +143,15 @@ func (g *Generator) generateTSConfig(req *packages.GenerateRequest) (codegen.Gen
 	}, nil
 }
 
+// packageName returns the npm package name of a request, which the
+// package_name option overrides
+func packageName(req *packages.GenerateRequest) string {
+	if name := req.Options[packages.OptionPackageName]; name != "" {
+		return name
+	}
+	return convertToNPMPackageName(req.ModuleName)
+}
+
 // convertToNPMPackageName converts a module name to a valid npm package name
 func convertToNPMPackageName(moduleName string) string {
 	// Example: "user-service" -> "@spoke/user-service"
@@ -195,6 +195,30 @@ func TestGenerator_GenerateREADME(t *testing.T) {
 	assert.Contains(t, *readme, "## Installation")
 }
 
+func TestGenerator_Generate_PackageNameOption(t *testing.T) {
+	gen := NewGenerator()
+
+	req := &packages.GenerateRequest{
+		ModuleName: "payments",
+		Version:    "1.0.0",
+		Language:   "typescript",
+		Options:    map[string]string{packages.OptionPackageName: "@ourorg/payments-proto"},
+	}
+
+	files, err := gen.Generate(req)
+	require.NoError(t, err)
+
+	fileMap := make(map[string][]byte)
+	for _, f := range files {
+		fileMap[f.Path] = f.Content
+	}
+
+	var pkg map[string]interface{}
+	require.NoError(t, json.Unmarshal(fileMap["package.json"], &pkg))
+	assert.Equal(t, "@ourorg/payments-proto", pkg["name"])
+	assert.Contains(t, string(fileMap["README.md"]), "npm install @ourorg/payments-proto")
+}
+
 func TestConvertToNPMPackageName(t *testing.T) {
 	tests := []struct {
 		name       string
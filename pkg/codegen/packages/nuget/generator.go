@@ -0,0 +1,125 @@
+package nuget
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+)
+
+//go:embed Package.csproj.tmpl
+var csprojTemplate string
+
+// Generator generates NuGet package files
+type Generator struct{}
+
+// NewGenerator creates a new NuGet generator
+func NewGenerator() *Generator {
+	return &Generator{}
+}
+
+// Generate creates package manager files for NuGet
+func (g *Generator) Generate(req *packages.GenerateRequest) ([]codegen.GeneratedFile, error) {
+	var files []codegen.GeneratedFile
+
+	// Generate Package.csproj
+	csproj, err := g.generateCsproj(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Package.csproj: %v", err)
+	}
+	files = append(files, csproj)
+
+	// Generate README.md
+	readme := codegen.GeneratedFile{
+		Path:    "README.md",
+		Content: []byte(fmt.Sprintf("# %s\n\nProtocol Buffer generated code for C#.\n\n## Installation\n\n```bash\ndotnet add package %s --version %s\n```\n", req.ModuleName, convertToPackageId(req.ModuleName), packages.SemVer(req.Version))),
+		Size:    0,
+	}
+	readme.Size = int64(len(readme.Content))
+	files = append(files, readme)
+
+	return files, nil
+}
+
+// GetName returns the name of the package manager
+func (g *Generator) GetName() string {
+	return "nuget"
+}
+
+// GetConfigFiles returns the list of config files this generator creates
+func (g *Generator) GetConfigFiles() []string {
+	return []string{"Package.csproj", "README.md"}
+}
+
+// PackageName returns the NuGet package ID of the code generated for a
+// module
+func (g *Generator) PackageName(moduleName string) string {
+	return convertToPackageId(moduleName)
+}
+
+func (g *Generator) generateCsproj(req *packages.GenerateRequest) (codegen.GeneratedFile, error) {
+	tmpl, err := template.New("Package.csproj").Funcs(template.FuncMap{
+		"default": func(def, val string) string {
+			if val == "" {
+				return def
+			}
+			return val
+		},
+	}).Parse(csprojTemplate)
+	if err != nil {
+		return codegen.GeneratedFile{}, err
+	}
+
+	data := map[string]interface{}{
+		"PackageId":       convertToPackageId(req.ModuleName),
+		"Version":         packages.SemVer(req.Version),
+		"ModuleName":      req.ModuleName,
+		"ProtobufVersion": "3.25.1",
+		"GRPCVersion":     "2.59.0",
+		"IncludeGRPC":     req.IncludeGRPC,
+		"Dependencies":    convertDependencies(req.Dependencies),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return codegen.GeneratedFile{}, err
+	}
+
+	return codegen.GeneratedFile{
+		Path:    "Package.csproj",
+		Content: buf.Bytes(),
+		Size:    int64(buf.Len()),
+	}, nil
+}
+
+// convertToPackageId converts a module name to a NuGet package ID
+func convertToPackageId(moduleName string) string {
+	// Example: "user-service" -> "Spoke.Generated.UserService"
+	parts := strings.FieldsFunc(moduleName, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var name strings.Builder
+	for _, part := range parts {
+		runes := []rune(part)
+		name.WriteRune(unicode.ToUpper(runes[0]))
+		name.WriteString(string(runes[1:]))
+	}
+	return "Spoke.Generated." + name.String()
+}
+
+// convertDependencies converts package dependencies to NuGet format
+func convertDependencies(deps []packages.Dependency) []map[string]string {
+	result := make([]map[string]string, 0, len(deps))
+	for _, dep := range deps {
+		result = append(result, map[string]string{
+			"Name":    dep.Name,
+			"Version": packages.SemVer(dep.Version),
+		})
+	}
+	return result
+}
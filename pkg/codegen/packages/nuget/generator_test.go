@@ -0,0 +1,71 @@
+package nuget
+
+import (
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_GetName(t *testing.T) {
+	gen := NewGenerator()
+	assert.Equal(t, "nuget", gen.GetName())
+}
+
+func TestGenerator_GetConfigFiles(t *testing.T) {
+	gen := NewGenerator()
+	assert.ElementsMatch(t, []string{"Package.csproj", "README.md"}, gen.GetConfigFiles())
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	gen := NewGenerator()
+
+	req := &packages.GenerateRequest{
+		ModuleName:  "user-service",
+		Version:     "v1.2.0",
+		Language:    "csharp",
+		IncludeGRPC: true,
+		Dependencies: []packages.Dependency{
+			{Name: "Acme.Common.Protos", Version: "v1.0.0"},
+		},
+	}
+
+	files, err := gen.Generate(req)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	csproj := string(files[0].Content)
+	assert.Equal(t, "Package.csproj", files[0].Path)
+	assert.Contains(t, csproj, "<PackageId>Spoke.Generated.UserService</PackageId>")
+	assert.Contains(t, csproj, "<Version>1.2.0</Version>")
+	assert.Contains(t, csproj, `<PackageReference Include="Google.Protobuf" Version="3.25.1" />`)
+	assert.Contains(t, csproj, `<PackageReference Include="Grpc.Core.Api" Version="2.59.0" />`)
+	assert.Contains(t, csproj, `<PackageReference Include="Acme.Common.Protos" Version="1.0.0" />`)
+
+	assert.Equal(t, "README.md", files[1].Path)
+	assert.Contains(t, string(files[1].Content), "dotnet add package Spoke.Generated.UserService --version 1.2.0")
+}
+
+func TestGenerator_Generate_NoGRPC(t *testing.T) {
+	gen := NewGenerator()
+
+	files, err := gen.Generate(&packages.GenerateRequest{ModuleName: "user-service", Version: "1.0.0"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(files[0].Content), "Grpc")
+}
+
+func TestConvertToPackageId(t *testing.T) {
+	tests := []struct {
+		moduleName string
+		expected   string
+	}{
+		{"user-service", "Spoke.Generated.UserService"},
+		{"billing_api v2", "Spoke.Generated.BillingApiV2"},
+		{"Common", "Spoke.Generated.Common"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, convertToPackageId(tt.moduleName), tt.moduleName)
+		assert.Equal(t, tt.expected, NewGenerator().PackageName(tt.moduleName), tt.moduleName)
+	}
+}
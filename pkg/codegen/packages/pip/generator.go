@@ -46,7 +46,7 @@ func (g *Generator) Generate(req *packages.GenerateRequest) ([]codegen.Generated
 	// Generate README.md
 	readme := codegen.GeneratedFile{
 		Path:    "README.md",
-		Content: []byte(fmt.Sprintf("# %s\n\nProtocol Buffer generated code for Python.\n\n## Installation\n\n```bash\npip install %s\n```\n", req.ModuleName, convertToPythonPackageName(req.ModuleName))),
+		Content: []byte(fmt.Sprintf("# %s\n\nProtocol Buffer generated code for Python.\n\n## Installation\n\n```bash\npip install %s\n```\n", req.ModuleName, projectName(req))),
 		Size:    0,
 	}
 	readme.Size = int64(len(readme.Content))
@@ -65,6 +65,12 @@ func (g *Generator) GetConfigFiles() []string {
 	return []string{"setup.py", "pyproject.toml", "README.md"}
 }
 
+// PackageName returns the Python package name of the code generated for a
+// module
+func (g *Generator) PackageName(moduleName string) string {
+	return convertToPythonPackageName(moduleName)
+}
+
 func (g *Generator) generateSetupPy(req *packages.GenerateRequest) (codegen.GeneratedFile, error) {
 	tmpl, err := template.New("setup.py").Funcs(template.FuncMap{
 		"default": func(def, val string) string {
@@ -79,6 +85,7 @@ func (g *Generator) generateSetupPy(req *packages.GenerateRequest) (codegen.Gene
 	}
 
 	data := map[string]interface{}{
+		"Name":            projectName(req),
 		"PackageName":     convertToPythonPackageName(req.ModuleName),
 		"Version":         req.Version,
 		"ModuleName":      req.ModuleName,
@@ -114,6 +121,7 @@ func (g *Generator) generatePyproject(req *packages.GenerateRequest) (codegen.Ge
 	}
 
 	data := map[string]interface{}{
+		"Name":            projectName(req),
 		"PackageName":     convertToPythonPackageName(req.ModuleName),
 		"Version":         req.Version,
 		"ModuleName":      req.ModuleName,
@@ -135,6 +143,16 @@ func (g *Generator) generatePyproject(req *packages.GenerateRequest) (codegen.Ge
 	}, nil
 }
 
+// projectName returns the distribution name of a request, which the
+// package_name option overrides. The import package keeps the name derived
+// from the module.
+func projectName(req *packages.GenerateRequest) string {
+	if name := req.Options[packages.OptionPackageName]; name != "" {
+		return name
+	}
+	return convertToPythonPackageName(req.ModuleName)
+}
+
 // convertToPythonPackageName converts a module name to a valid Python package name
 func convertToPythonPackageName(moduleName string) string {
 	// Example: "user-service" -> "user_service"
@@ -221,6 +221,29 @@ func TestGenerator_GenerateREADME(t *testing.T) {
 	assert.Contains(t, *readme, "## Installation")
 }
 
+func TestGenerator_Generate_PackageNameOption(t *testing.T) {
+	gen := NewGenerator()
+
+	req := &packages.GenerateRequest{
+		ModuleName: "user-service",
+		Version:    "1.0.0",
+		Language:   "python",
+		Options:    map[string]string{packages.OptionPackageName: "ourorg-user-service"},
+	}
+
+	files, err := gen.Generate(req)
+	require.NoError(t, err)
+
+	fileMap := make(map[string]string)
+	for _, f := range files {
+		fileMap[f.Path] = string(f.Content)
+	}
+	assert.Contains(t, fileMap["setup.py"], `name="ourorg-user-service"`)
+	assert.Contains(t, fileMap["pyproject.toml"], `name = "ourorg-user-service"`)
+	assert.Contains(t, fileMap["pyproject.toml"], `packages = ["user_service"]`, "the import package keeps its name")
+	assert.Contains(t, fileMap["README.md"], "pip install ourorg-user-service")
+}
+
 func TestConvertToPythonPackageName(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -0,0 +1,120 @@
+package rubygems
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+)
+
+//go:embed gemspec.tmpl
+var gemspecTemplate string
+
+// Generator generates RubyGems files
+type Generator struct{}
+
+// NewGenerator creates a new RubyGems generator
+func NewGenerator() *Generator {
+	return &Generator{}
+}
+
+// Generate creates package manager files for RubyGems
+func (g *Generator) Generate(req *packages.GenerateRequest) ([]codegen.GeneratedFile, error) {
+	var files []codegen.GeneratedFile
+
+	// Generate the gemspec
+	gemspec, err := g.generateGemspec(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate gemspec: %v", err)
+	}
+	files = append(files, gemspec)
+
+	// Generate README.md
+	readme := codegen.GeneratedFile{
+		Path:    "README.md",
+		Content: []byte(fmt.Sprintf("# %s\n\nProtocol Buffer generated code for Ruby.\n\n## Installation\n\n```bash\ngem install %s -v %s\n```\n", req.ModuleName, convertToGemName(req.ModuleName), packages.SemVer(req.Version))),
+		Size:    0,
+	}
+	readme.Size = int64(len(readme.Content))
+	files = append(files, readme)
+
+	return files, nil
+}
+
+// GetName returns the name of the package manager
+func (g *Generator) GetName() string {
+	return "gem"
+}
+
+// GetConfigFiles returns the list of config files this generator creates;
+// the gemspec is named after the gem
+func (g *Generator) GetConfigFiles() []string {
+	return []string{"gemspec", "README.md"}
+}
+
+// PackageName returns the gem name of the code generated for a module
+func (g *Generator) PackageName(moduleName string) string {
+	return convertToGemName(moduleName)
+}
+
+func (g *Generator) generateGemspec(req *packages.GenerateRequest) (codegen.GeneratedFile, error) {
+	tmpl, err := template.New("gemspec").Funcs(template.FuncMap{
+		"default": func(def, val string) string {
+			if val == "" {
+				return def
+			}
+			return val
+		},
+	}).Parse(gemspecTemplate)
+	if err != nil {
+		return codegen.GeneratedFile{}, err
+	}
+
+	gemName := convertToGemName(req.ModuleName)
+	data := map[string]interface{}{
+		"GemName":         gemName,
+		"Version":         packages.SemVer(req.Version),
+		"ModuleName":      req.ModuleName,
+		"ProtobufVersion": "3.25",
+		"GRPCVersion":     "1.60",
+		"IncludeGRPC":     req.IncludeGRPC,
+		"Dependencies":    convertDependencies(req.Dependencies),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return codegen.GeneratedFile{}, err
+	}
+
+	return codegen.GeneratedFile{
+		Path:    gemName + ".gemspec",
+		Content: buf.Bytes(),
+		Size:    int64(buf.Len()),
+	}, nil
+}
+
+// convertToGemName converts a module name to a valid gem name
+func convertToGemName(moduleName string) string {
+	// Example: "user-service" -> "user_service"
+	name := strings.ToLower(moduleName)
+	name = strings.ReplaceAll(name, "-", "_")
+	name = strings.ReplaceAll(name, " ", "_")
+	return name
+}
+
+// convertDependencies converts package dependencies to RubyGems format,
+// allowing compatible releases of each dependency's version
+func convertDependencies(deps []packages.Dependency) []map[string]string {
+	result := make([]map[string]string, 0, len(deps))
+	for _, dep := range deps {
+		result = append(result, map[string]string{
+			"Name":    dep.Name,
+			"Version": "~> " + packages.SemVer(dep.Version),
+		})
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+package rubygems
+
+import (
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_GetName(t *testing.T) {
+	gen := NewGenerator()
+	assert.Equal(t, "gem", gen.GetName())
+}
+
+func TestGenerator_GetConfigFiles(t *testing.T) {
+	gen := NewGenerator()
+	assert.ElementsMatch(t, []string{"gemspec", "README.md"}, gen.GetConfigFiles())
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	gen := NewGenerator()
+
+	req := &packages.GenerateRequest{
+		ModuleName:  "user-service",
+		Version:     "v1.2.0",
+		Language:    "ruby",
+		IncludeGRPC: true,
+		Dependencies: []packages.Dependency{
+			{Name: "acme_common", Version: "v1.0.0"},
+		},
+	}
+
+	files, err := gen.Generate(req)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	gemspec := string(files[0].Content)
+	assert.Equal(t, "user_service.gemspec", files[0].Path)
+	assert.Contains(t, gemspec, `spec.name          = "user_service"`)
+	assert.Contains(t, gemspec, `spec.version       = "1.2.0"`)
+	assert.Contains(t, gemspec, `spec.add_dependency "google-protobuf", "~> 3.25"`)
+	assert.Contains(t, gemspec, `spec.add_dependency "grpc", "~> 1.60"`)
+	assert.Contains(t, gemspec, `spec.add_dependency "acme_common", "~> 1.0.0"`)
+
+	assert.Contains(t, string(files[1].Content), "gem install user_service -v 1.2.0")
+}
+
+func TestGenerator_Generate_NoGRPC(t *testing.T) {
+	gen := NewGenerator()
+
+	files, err := gen.Generate(&packages.GenerateRequest{ModuleName: "user-service", Version: "1.0.0"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(files[0].Content), `"grpc"`)
+}
+
+func TestConvertToGemName(t *testing.T) {
+	assert.Equal(t, "user_service", convertToGemName("User-Service"))
+	assert.Equal(t, "my_protos", NewGenerator().PackageName("my protos"))
+}
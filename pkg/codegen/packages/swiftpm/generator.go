@@ -0,0 +1,146 @@
+package swiftpm
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+)
+
+//go:embed Package.swift.tmpl
+var packageSwiftTemplate string
+
+// Generator generates Swift Package Manager files
+type Generator struct{}
+
+// NewGenerator creates a new Swift Package Manager generator
+func NewGenerator() *Generator {
+	return &Generator{}
+}
+
+// Generate creates package manager files for Swift Package Manager
+func (g *Generator) Generate(req *packages.GenerateRequest) ([]codegen.GeneratedFile, error) {
+	var files []codegen.GeneratedFile
+
+	// Generate Package.swift
+	packageSwift, err := g.generatePackageSwift(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Package.swift: %v", err)
+	}
+	files = append(files, packageSwift)
+
+	// Generate README.md
+	readme := codegen.GeneratedFile{
+		Path:    "README.md",
+		Content: []byte(fmt.Sprintf("# %s\n\nProtocol Buffer generated code for Swift.\n\n## Installation\n\n```swift\n.package(url: \"%s\", from: \"%s\")\n```\n", req.ModuleName, convertToPackageURL(req.ModuleName), packages.SemVer(req.Version))),
+		Size:    0,
+	}
+	readme.Size = int64(len(readme.Content))
+	files = append(files, readme)
+
+	return files, nil
+}
+
+// GetName returns the name of the package manager
+func (g *Generator) GetName() string {
+	return "swift-package"
+}
+
+// GetConfigFiles returns the list of config files this generator creates
+func (g *Generator) GetConfigFiles() []string {
+	return []string{"Package.swift", "README.md"}
+}
+
+// PackageName returns the repository URL of the package generated for a
+// module, which identifies Swift packages
+func (g *Generator) PackageName(moduleName string) string {
+	return convertToPackageURL(moduleName)
+}
+
+func (g *Generator) generatePackageSwift(req *packages.GenerateRequest) (codegen.GeneratedFile, error) {
+	tmpl, err := template.New("Package.swift").Funcs(template.FuncMap{
+		"default": func(def, val string) string {
+			if val == "" {
+				return def
+			}
+			return val
+		},
+	}).Parse(packageSwiftTemplate)
+	if err != nil {
+		return codegen.GeneratedFile{}, err
+	}
+
+	data := map[string]interface{}{
+		"PackageName":     convertToTargetName(req.ModuleName),
+		"Version":         packages.SemVer(req.Version),
+		"ModuleName":      req.ModuleName,
+		"ProtobufVersion": "1.25.0",
+		"GRPCVersion":     "1.21.0",
+		"IncludeGRPC":     req.IncludeGRPC,
+		"Dependencies":    convertDependencies(req.Dependencies),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return codegen.GeneratedFile{}, err
+	}
+
+	return codegen.GeneratedFile{
+		Path:    "Package.swift",
+		Content: buf.Bytes(),
+		Size:    int64(buf.Len()),
+	}, nil
+}
+
+// convertToTargetName converts a module name to a Swift target and product
+// name
+func convertToTargetName(moduleName string) string {
+	// Example: "user-service" -> "UserService"
+	parts := strings.FieldsFunc(moduleName, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var name strings.Builder
+	for _, part := range parts {
+		runes := []rune(part)
+		name.WriteRune(unicode.ToUpper(runes[0]))
+		name.WriteString(string(runes[1:]))
+	}
+	return name.String()
+}
+
+// convertToPackageURL converts a module name to the repository URL of its
+// generated package
+func convertToPackageURL(moduleName string) string {
+	// Example: "user-service" -> "https://github.com/spoke-generated/user-service.git"
+	name := strings.ToLower(moduleName)
+	name = strings.ReplaceAll(name, "_", "-")
+	name = strings.ReplaceAll(name, " ", "-")
+	return "https://github.com/spoke-generated/" + name + ".git"
+}
+
+// convertDependencies converts package dependencies to Swift Package
+// Manager format. The package is identified by the last component of its
+// URL and the product is named after the module.
+func convertDependencies(deps []packages.Dependency) []map[string]string {
+	result := make([]map[string]string, 0, len(deps))
+	for _, dep := range deps {
+		pkg := strings.ToLower(strings.TrimSuffix(path.Base(dep.Name), ".git"))
+		product := convertToTargetName(dep.Module)
+		if product == "" {
+			product = convertToTargetName(pkg)
+		}
+		result = append(result, map[string]string{
+			"URL":     dep.Name,
+			"Package": pkg,
+			"Product": product,
+			"Version": packages.SemVer(dep.Version),
+		})
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+package swiftpm
+
+import (
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_GetName(t *testing.T) {
+	gen := NewGenerator()
+	assert.Equal(t, "swift-package", gen.GetName())
+}
+
+func TestGenerator_GetConfigFiles(t *testing.T) {
+	gen := NewGenerator()
+	assert.ElementsMatch(t, []string{"Package.swift", "README.md"}, gen.GetConfigFiles())
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	gen := NewGenerator()
+
+	req := &packages.GenerateRequest{
+		ModuleName:  "user-service",
+		Version:     "v1.2.0",
+		Language:    "swift",
+		IncludeGRPC: true,
+		Dependencies: []packages.Dependency{
+			{Name: "https://github.com/spoke-generated/common.git", Version: "v1.0.0", Module: "common"},
+			{Name: "https://github.com/acme/Billing-Protos.git", Version: "2.0.0"},
+		},
+	}
+
+	files, err := gen.Generate(req)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	manifest := string(files[0].Content)
+	assert.Equal(t, "Package.swift", files[0].Path)
+	assert.Contains(t, manifest, `name: "UserService"`)
+	assert.Contains(t, manifest, `.package(url: "https://github.com/apple/swift-protobuf.git", from: "1.25.0")`)
+	assert.Contains(t, manifest, `.package(url: "https://github.com/grpc/grpc-swift.git", from: "1.21.0")`)
+	assert.Contains(t, manifest, `.package(url: "https://github.com/spoke-generated/common.git", from: "1.0.0")`)
+	assert.Contains(t, manifest, `.product(name: "Common", package: "common")`)
+	assert.Contains(t, manifest, `.product(name: "BillingProtos", package: "billing-protos")`)
+	assert.Contains(t, manifest, `.product(name: "GRPC", package: "grpc-swift")`)
+
+	assert.Contains(t, string(files[1].Content), `.package(url: "https://github.com/spoke-generated/user-service.git", from: "1.2.0")`)
+}
+
+func TestGenerator_Generate_NoGRPC(t *testing.T) {
+	gen := NewGenerator()
+
+	files, err := gen.Generate(&packages.GenerateRequest{ModuleName: "user-service", Version: "1.0.0"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(files[0].Content), "grpc-swift")
+}
+
+func TestConvertNames(t *testing.T) {
+	assert.Equal(t, "UserService", convertToTargetName("user-service"))
+	assert.Equal(t, "BillingApi", convertToTargetName("billing_api"))
+	assert.Equal(t, "https://github.com/spoke-generated/user-service.git", NewGenerator().PackageName("User_Service"))
+}
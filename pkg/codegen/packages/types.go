@@ -19,28 +19,75 @@ type Generator interface {
 // GenerateRequest represents a package generation request
 type GenerateRequest struct {
 	// Module information
-	ModuleName    string
-	Version       string
+	ModuleName string
+	Version    string
 
 	// Language
-	Language      string
+	Language string
 
 	// Generated proto files (for detecting what was generated)
 	GeneratedFiles []codegen.GeneratedFile
 
 	// Dependencies
-	Dependencies  []Dependency
+	Dependencies []Dependency
 
 	// Options
-	IncludeGRPC   bool
-	Options       map[string]string
+	IncludeGRPC bool
+	Options     map[string]string
 }
 
 // Dependency represents a module dependency for package managers
 type Dependency struct {
-	Name     string
-	Version  string
+	Name       string
+	Version    string
 	ImportPath string // Language-specific import path
+	Module     string // Spoke module the package is generated from, when known
+}
+
+// OptionPackageName is the GenerateRequest option naming the package
+// generated for the module, in place of the name the generator derives from
+// the module name
+const OptionPackageName = "package_name"
+
+// PackageNamer is implemented by generators that know the name of the
+// package they generate for a module, so that dependencies on other modules
+// refer to the packages generated for those modules
+type PackageNamer interface {
+	PackageName(moduleName string) string
+}
+
+// ResolveDependencies maps a module's dependencies on other modules to the
+// package dependencies of a generator. A module in depMap maps to the
+// package named there; any other module to the package the generator names
+// for it, or the module name when the generator names no packages. The
+// package name is also the dependency's import path.
+func ResolveDependencies(gen Generator, deps []codegen.Dependency, depMap map[string]string) []Dependency {
+	result := make([]Dependency, 0, len(deps))
+	for _, dep := range deps {
+		name, ok := depMap[dep.ModuleName]
+		if !ok {
+			name = dep.ModuleName
+			if namer, ok := gen.(PackageNamer); ok {
+				name = namer.PackageName(dep.ModuleName)
+			}
+		}
+		result = append(result, Dependency{
+			Name:       name,
+			Version:    dep.Version,
+			ImportPath: name,
+			Module:     dep.ModuleName,
+		})
+	}
+	return result
+}
+
+// SemVer returns a version without the leading v of Go and git tags, as
+// most package managers expect it
+func SemVer(version string) string {
+	if len(version) > 1 && version[0] == 'v' && version[1] >= '0' && version[1] <= '9' {
+		return version[1:]
+	}
+	return version
 }
 
 // Registry manages package generators
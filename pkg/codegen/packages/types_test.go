@@ -347,3 +347,64 @@ func TestMockGenerator_GetConfigFiles(t *testing.T) {
 		t.Errorf("GetConfigFiles()[1] mismatch, expected 'config2.yaml', got '%s'", files[1])
 	}
 }
+
+// namingGenerator is a mock generator that names the packages of modules
+type namingGenerator struct {
+	mockGenerator
+}
+
+func (n *namingGenerator) PackageName(moduleName string) string {
+	return "pkg-" + moduleName
+}
+
+func TestResolveDependencies(t *testing.T) {
+	deps := []codegen.Dependency{
+		{ModuleName: "common", Version: "v1.0.0"},
+		{ModuleName: "billing", Version: "v2.1.0"},
+	}
+	depMap := map[string]string{"common": "acme-common"}
+
+	tests := []struct {
+		name     string
+		gen      Generator
+		expected []string
+	}{
+		{"without namer", &mockGenerator{name: "plain"}, []string{"acme-common", "billing"}},
+		{"with namer", &namingGenerator{mockGenerator{name: "naming"}}, []string{"acme-common", "pkg-billing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ResolveDependencies(tt.gen, deps, depMap)
+			if len(result) != len(deps) {
+				t.Fatalf("ResolveDependencies() returned %d dependencies, expected %d", len(result), len(deps))
+			}
+			for i, dep := range result {
+				if dep.Name != tt.expected[i] || dep.ImportPath != tt.expected[i] {
+					t.Errorf("dependency %d: expected name and import path '%s', got '%s' and '%s'", i, tt.expected[i], dep.Name, dep.ImportPath)
+				}
+				if dep.Module != deps[i].ModuleName {
+					t.Errorf("dependency %d: expected module '%s', got '%s'", i, deps[i].ModuleName, dep.Module)
+				}
+				if dep.Version != deps[i].Version {
+					t.Errorf("dependency %d: expected version '%s', got '%s'", i, deps[i].Version, dep.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestSemVer(t *testing.T) {
+	tests := map[string]string{
+		"v1.2.3":  "1.2.3",
+		"1.2.3":   "1.2.3",
+		"v":       "v",
+		"version": "version",
+		"":        "",
+	}
+	for input, expected := range tests {
+		if got := SemVer(input); got != expected {
+			t.Errorf("SemVer(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
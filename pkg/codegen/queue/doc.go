@@ -0,0 +1,47 @@
+// Package queue provides a durable queue of compile jobs and the worker pool
+// running them.
+//
+// # Overview
+//
+// Compiling a module version for a language is queued as a Job in a Store
+// backed by a database table, so jobs survive restarts and every server sees
+// every job. Workers, in the server or in the dedicated spoke-worker binary,
+// claim jobs in priority order and hold a lease on them while they run; jobs
+// of workers that die are claimed again once their lease expires.
+//
+// # Jobs
+//
+//	store.Enqueue(ctx, &queue.Job{
+//		ModuleName: "users",
+//		Version:    "v1.0.0",
+//		Language:   "go",
+//		Priority:   10,
+//		DedupKey:   cache.FormatCacheKey(key),
+//	})
+//
+// Enqueuing a job while an identical job, one with the same DedupKey, is
+// still waiting for its first run returns that job instead, raising its
+// priority if needed. Failed jobs are retried with exponential backoff up to MaxAttempts
+// times, unless the failure is wrapped with ErrPermanent. Pending jobs are
+// cancelled at once; running jobs are cancelled by their worker at its next
+// heartbeat.
+//
+// Finished jobs are kept as the compile history of each module version:
+//
+//	jobs, _ := store.List(ctx, queue.Filter{ModuleName: "users", Version: "v1.0.0"})
+//
+// # Stores
+//
+// NewPostgresStore uses the compile_jobs table created by the migrations.
+// NewSQLiteStore creates the table in a SQLite database file, for
+// deployments using filesystem storage.
+//
+// # Workers
+//
+//	pool := queue.NewPool(store, runner, queue.DefaultPoolConfig())
+//	pool.Start()
+//	defer pool.Stop()
+//
+// A Runner compiles the job; compiled.JobRunner compiles module versions
+// through the orchestrator and stores the code with the version.
+package queue
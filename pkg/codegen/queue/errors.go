@@ -0,0 +1,22 @@
+package queue
+
+import "errors"
+
+var (
+	// ErrJobNotFound is returned when a job does not exist
+	ErrJobNotFound = errors.New("compile job not found")
+
+	// ErrJobFinished is returned when cancelling a job that already finished
+	ErrJobFinished = errors.New("compile job already finished")
+
+	// ErrNoJobs is returned by Claim when no job is ready to run
+	ErrNoJobs = errors.New("no compile jobs ready")
+
+	// ErrLeaseLost is returned when a worker updates a job it no longer
+	// holds, because its lease expired and another worker claimed the job
+	ErrLeaseLost = errors.New("compile job lease lost")
+
+	// ErrPermanent marks failures retrying cannot fix, such as compiling a
+	// version that does not exist
+	ErrPermanent = errors.New("permanent failure")
+)
@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/observability"
+)
+
+// MaxLogBytes bounds the log kept for one run of a job; later output is
+// dropped so a chatty plugin cannot fill the database
+const MaxLogBytes = 1 << 20
+
+// logFlushInterval is the longest output waits before it is stored
+const logFlushInterval = 250 * time.Millisecond
+
+// logTruncated ends logs that outgrew MaxLogBytes
+const logTruncated = "[log truncated]\n"
+
+// logWriter stores the output of a job in its log. Output is stored in
+// whole lines, at most every logFlushInterval, so readers following the log
+// see lines as they are completed. Writes never fail; output that cannot be
+// stored is reported and dropped.
+type logWriter struct {
+	store  Store
+	jobID  string
+	logger *observability.Logger
+
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	written   int
+	truncated bool
+	closed    bool
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newLogWriter starts storing output in the log of a job
+func newLogWriter(store Store, jobID string, logger *observability.Logger) *logWriter {
+	w := &logWriter{
+		store:  store,
+		jobID:  jobID,
+		logger: logger,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.flushLoop()
+	return w
+}
+
+// Write buffers output until its lines are complete
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed || w.truncated {
+		return len(p), nil
+	}
+
+	data := p
+	if room := MaxLogBytes - w.written - w.buf.Len(); len(data) > room {
+		data = data[:max(room, 0)]
+		w.truncated = true
+	}
+	w.buf.Write(data)
+	if w.truncated {
+		if w.buf.Len() > 0 && w.buf.Bytes()[w.buf.Len()-1] != '\n' {
+			w.buf.WriteByte('\n')
+		}
+		w.buf.WriteString(logTruncated)
+	}
+	return len(p), nil
+}
+
+// Printf writes a line of its own to the log. Its lines are kept even when
+// the output was truncated.
+func (w *logWriter) Printf(format string, args ...interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	if w.buf.Len() > 0 && w.buf.Bytes()[w.buf.Len()-1] != '\n' {
+		w.buf.WriteByte('\n')
+	}
+	fmt.Fprintf(&w.buf, format+"\n", args...)
+}
+
+// Close stores the remaining output, complete or not, and stops the writer.
+// Later writes are dropped.
+func (w *logWriter) Close() {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+
+		w.mu.Lock()
+		w.closed = true
+		data := w.buf.String()
+		w.buf.Reset()
+		w.mu.Unlock()
+		w.append(data)
+	})
+}
+
+// flushLoop stores the complete lines buffered until the writer closes
+func (w *logWriter) flushLoop() {
+	defer close(w.done)
+	ticker := time.NewTicker(logFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		w.mu.Lock()
+		var data string
+		if i := bytes.LastIndexByte(w.buf.Bytes(), '\n'); i >= 0 {
+			data = string(w.buf.Next(i + 1))
+			w.written += len(data)
+		}
+		w.mu.Unlock()
+		w.append(data)
+	}
+}
+
+// append stores output, even while the pool stops
+func (w *logWriter) append(data string) {
+	if data == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := w.store.AppendLog(ctx, w.jobID, data); err != nil {
+		w.logger.WithError(err).Warn("Failed to store compile job log")
+	}
+}
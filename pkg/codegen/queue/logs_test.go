@@ -0,0 +1,72 @@
+package queue
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/observability"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readLog(t *testing.T, store Store, id string) string {
+	t.Helper()
+	chunks, err := store.Logs(context.Background(), id, 0)
+	require.NoError(t, err)
+	var log strings.Builder
+	for _, chunk := range chunks {
+		log.WriteString(chunk.Data)
+	}
+	return log.String()
+}
+
+func TestLogWriter(t *testing.T) {
+	store := newTestStore(t)
+	job, _, err := store.Enqueue(context.Background(), testJob("go"))
+	require.NoError(t, err)
+
+	w := newLogWriter(store, job.ID, observability.NewLogger(observability.InfoLevel, io.Discard))
+	w.Printf("Attempt %d", 1)
+	_, err = w.Write([]byte("users.proto:3:1: "))
+	require.NoError(t, err)
+
+	// Complete lines are stored while the job runs; partial ones wait
+	require.Eventually(t, func() bool {
+		return readLog(t, store, job.ID) == "Attempt 1\n"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = w.Write([]byte("syntax error"))
+	require.NoError(t, err)
+	w.Printf("Failed")
+	w.Close()
+	w.Close()
+	w.Printf("dropped")
+	_, err = w.Write([]byte("dropped"))
+	require.NoError(t, err, "writes never fail")
+
+	assert.Equal(t, "Attempt 1\nusers.proto:3:1: syntax error\nFailed\n", readLog(t, store, job.ID))
+}
+
+func TestLogWriter_Truncates(t *testing.T) {
+	store := newTestStore(t)
+	job, _, err := store.Enqueue(context.Background(), testJob("go"))
+	require.NoError(t, err)
+
+	w := newLogWriter(store, job.ID, observability.NewLogger(observability.InfoLevel, io.Discard))
+	line := strings.Repeat("x", 1023) + "\n"
+	for i := 0; i < 2*MaxLogBytes/len(line); i++ {
+		n, err := w.Write([]byte(line))
+		require.NoError(t, err)
+		require.Equal(t, len(line), n)
+	}
+	w.Printf("Completed")
+	w.Close()
+
+	log := readLog(t, store, job.ID)
+	assert.LessOrEqual(t, len(log), MaxLogBytes+len(logTruncated)+len("Completed\n"))
+	assert.True(t, strings.HasSuffix(log, logTruncated+"Completed\n"), "outcome lines survive truncation")
+	assert.Equal(t, 1, strings.Count(log, logTruncated))
+}
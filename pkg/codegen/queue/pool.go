@@ -0,0 +1,294 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/observability"
+)
+
+// errCancelRequested cancels the context of a job whose cancellation was
+// requested
+var errCancelRequested = errors.New("compile job cancelled")
+
+// PoolConfig configures a worker pool
+type PoolConfig struct {
+	Workers       int           // Jobs run concurrently
+	PollInterval  time.Duration // Wait between claims while no job is ready
+	LeaseDuration time.Duration // Time a job stays claimed without a heartbeat
+	BackoffBase   time.Duration // Delay before the first retry, doubled for each later one
+	BackoffMax    time.Duration // Longest delay between retries
+	JobTimeout    time.Duration // Longest a job runs; unlimited when zero
+}
+
+// DefaultPoolConfig returns the default worker pool configuration
+func DefaultPoolConfig() *PoolConfig {
+	return &PoolConfig{
+		Workers:       2,
+		PollInterval:  time.Second,
+		LeaseDuration: 30 * time.Second,
+		BackoffBase:   5 * time.Second,
+		BackoffMax:    5 * time.Minute,
+		JobTimeout:    10 * time.Minute,
+	}
+}
+
+// Pool runs compile jobs claimed from a store
+type Pool struct {
+	store  Store
+	runner Runner
+	config *PoolConfig
+	id     string
+	logger *observability.Logger
+
+	mu      sync.Mutex
+	started bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewPool creates a worker pool. A nil config uses DefaultPoolConfig.
+func NewPool(store Store, runner Runner, config *PoolConfig) *Pool {
+	if config == nil {
+		config = DefaultPoolConfig()
+	}
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "worker"
+	}
+	return &Pool{
+		store:  store,
+		runner: runner,
+		config: config,
+		id:     fmt.Sprintf("%s-%s", host, uuid.New().String()[:8]),
+		logger: observability.NewLogger(observability.InfoLevel, os.Stdout),
+	}
+}
+
+// Start starts the workers
+func (p *Pool) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return
+	}
+	p.started = true
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	for i := 0; i < p.config.Workers; i++ {
+		workerID := fmt.Sprintf("%s-%d", p.id, i)
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			defer observability.RecoverPanic(p.logger, "compile queue worker goroutine")
+			p.work(workerID)
+		}()
+	}
+	p.logger.WithField("workers", p.config.Workers).Info("Compile workers started")
+}
+
+// Stop stops the workers and waits for them to exit. Running jobs are
+// interrupted and returned to the queue without counting as an attempt.
+func (p *Pool) Stop() {
+	p.mu.Lock()
+	if !p.started {
+		p.mu.Unlock()
+		return
+	}
+	p.started = false
+	p.cancel()
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+// work claims and runs jobs until the pool stops
+func (p *Pool) work(workerID string) {
+	for p.ctx.Err() == nil {
+		job, err := p.store.Claim(p.ctx, workerID, p.config.LeaseDuration)
+		if err != nil {
+			if !errors.Is(err, ErrNoJobs) && p.ctx.Err() == nil {
+				p.logger.WithError(err).Warn("Failed to claim compile job")
+			}
+			select {
+			case <-p.ctx.Done():
+			case <-time.After(p.config.PollInterval):
+			}
+			continue
+		}
+		p.run(job)
+	}
+}
+
+// run runs a claimed job and records its outcome
+func (p *Pool) run(job *Job) {
+	logger := p.logger.WithFields(map[string]interface{}{
+		"job_id":   job.ID,
+		"module":   job.ModuleName,
+		"version":  job.Version,
+		"language": job.Language,
+		"attempt":  job.Attempts,
+	})
+
+	// Jobs claimed again after their workers died still count the attempts
+	// of those workers, so a job crashing workers cannot run forever
+	output := newLogWriter(p.store, job.ID, logger)
+	if job.Attempts > job.MaxAttempts {
+		job.Status = codegen.JobStatusFailed
+		job.Error = fmt.Sprintf("abandoned after %d attempts", job.MaxAttempts)
+		job.Attempts = job.MaxAttempts
+		output.Printf("Compile job %s", job.Error)
+		output.Close()
+		p.finish(job, logger)
+		return
+	}
+
+	jobCtx, cancelJob := context.WithCancelCause(p.ctx)
+	defer cancelJob(nil)
+	runCtx := jobCtx
+	if p.config.JobTimeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(jobCtx, p.config.JobTimeout)
+		defer cancel()
+	}
+
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		p.heartbeat(jobCtx, cancelJob, job, logger)
+	}()
+
+	logger.Info("Running compile job")
+	output.Printf("Attempt %d of %d on %s", job.Attempts, job.MaxAttempts, job.WorkerID)
+	start := time.Now()
+	result, err := p.runner.Run(runCtx, job, output)
+	if err == nil && (result == nil || !result.Success) {
+		err = errors.New("compilation failed")
+		if result != nil && result.Error != "" {
+			err = errors.New(result.Error)
+		}
+	}
+	cause := context.Cause(jobCtx)
+	cancelJob(nil)
+	<-heartbeatDone
+
+	now := time.Now()
+	job.Duration = now.Sub(start)
+	job.CompletedAt = &now
+	switch {
+	case errors.Is(cause, ErrLeaseLost):
+		// Another worker holds the job now and records its outcome
+		logger.Warn("Compile job lease lost, abandoning job")
+		output.Printf("Lease lost, abandoning attempt")
+		output.Close()
+		return
+	case errors.Is(cause, errCancelRequested):
+		job.Status = codegen.JobStatusCancelled
+		job.Error = errCancelRequested.Error()
+		output.Printf("Cancelled after %s", job.Duration.Round(time.Millisecond))
+	case p.ctx.Err() != nil:
+		// Interrupted by shutdown; another worker runs the job again
+		job.Status = codegen.JobStatusPending
+		job.Attempts--
+		job.RunAt = now
+		job.CompletedAt = nil
+		output.Printf("Interrupted by worker shutdown, returning job to the queue")
+	case err == nil:
+		job.Status = codegen.JobStatusCompleted
+		job.Error = ""
+		job.CacheHit = result.CacheHit
+		output.Printf("Completed in %s", job.Duration.Round(time.Millisecond))
+	case errors.Is(err, ErrPermanent) || job.Attempts >= job.MaxAttempts:
+		job.Status = codegen.JobStatusFailed
+		job.Error = err.Error()
+		output.Printf("Failed: %s", job.Error)
+	default:
+		delay := p.backoff(job.Attempts)
+		logger.WithError(err).WithField("retry_in", delay.String()).Warn("Compile job failed, retrying")
+		job.Status = codegen.JobStatusPending
+		job.Error = err.Error()
+		job.RunAt = now.Add(delay)
+		job.CompletedAt = nil
+		output.Printf("Failed: %s; retrying in %s", job.Error, delay)
+	}
+	// The log is complete before the job is, so readers seeing it finish
+	// have seen all of its output
+	output.Close()
+	p.finish(job, logger)
+}
+
+// heartbeat extends the lease on a job until its context is done,
+// cancelling the job when its cancellation is requested or its lease lost
+func (p *Pool) heartbeat(ctx context.Context, cancel context.CancelCauseFunc, job *Job, logger *observability.Logger) {
+	interval := p.config.LeaseDuration / 3
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := p.store.Heartbeat(ctx, job.ID, job.WorkerID, p.config.LeaseDuration)
+		switch {
+		case errors.Is(err, ErrLeaseLost):
+			cancel(ErrLeaseLost)
+			return
+		case err != nil:
+			if ctx.Err() == nil {
+				logger.WithError(err).Warn("Failed to extend compile job lease")
+			}
+		case current.CancelRequested:
+			logger.Info("Cancelling compile job")
+			cancel(errCancelRequested)
+			return
+		}
+	}
+}
+
+// finish records the outcome of a job, even while the pool stops
+func (p *Pool) finish(job *Job, logger *observability.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := p.store.Finish(ctx, job); err != nil {
+		logger.WithError(err).Error("Failed to record compile job outcome")
+		return
+	}
+	switch job.Status {
+	case codegen.JobStatusCompleted:
+		logger.WithField("duration_ms", job.Duration.Milliseconds()).Info("Compile job completed")
+	case codegen.JobStatusFailed:
+		logger.WithField("error", job.Error).Warn("Compile job failed")
+	case codegen.JobStatusCancelled:
+		logger.Info("Compile job cancelled")
+	}
+}
+
+// backoff returns the delay before retrying a job after a failed attempt
+func (p *Pool) backoff(attempts int) time.Duration {
+	delay := p.config.BackoffBase
+	for i := 1; i < attempts && delay < p.config.BackoffMax; i++ {
+		delay *= 2
+	}
+	if p.config.BackoffMax > 0 && delay > p.config.BackoffMax {
+		delay = p.config.BackoffMax
+	}
+	return delay
+}
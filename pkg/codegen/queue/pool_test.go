@@ -0,0 +1,227 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// funcRunner runs jobs with a function
+type funcRunner func(ctx context.Context, job *Job, output io.Writer) (*codegen.CompilationResult, error)
+
+func (f funcRunner) Run(ctx context.Context, job *Job, output io.Writer) (*codegen.CompilationResult, error) {
+	return f(ctx, job, output)
+}
+
+func testPoolConfig() *PoolConfig {
+	return &PoolConfig{
+		Workers:       2,
+		PollInterval:  10 * time.Millisecond,
+		LeaseDuration: 300 * time.Millisecond,
+		BackoffBase:   10 * time.Millisecond,
+		BackoffMax:    20 * time.Millisecond,
+		JobTimeout:    5 * time.Second,
+	}
+}
+
+func waitForStatus(t *testing.T, store Store, id string, status codegen.JobStatus) *Job {
+	t.Helper()
+	var job *Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = store.Get(context.Background(), id)
+		return err == nil && job.Status == status
+	}, 5*time.Second, 10*time.Millisecond, "job never became %s", status)
+	return job
+}
+
+func TestPool_RunsJobs(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	ran := map[string]int{}
+	pool := NewPool(store, funcRunner(func(ctx context.Context, job *Job, output io.Writer) (*codegen.CompilationResult, error) {
+		mu.Lock()
+		ran[job.Language]++
+		mu.Unlock()
+		return &codegen.CompilationResult{Success: true, CacheHit: job.Language == "go"}, nil
+	}), testPoolConfig())
+	pool.Start()
+	defer pool.Stop()
+
+	var ids []string
+	for _, language := range []string{"go", "python", "java"} {
+		job, _, err := store.Enqueue(ctx, testJob(language))
+		require.NoError(t, err)
+		ids = append(ids, job.ID)
+	}
+
+	for _, id := range ids {
+		job := waitForStatus(t, store, id, codegen.JobStatusCompleted)
+		assert.Equal(t, 1, job.Attempts)
+		assert.Equal(t, job.Language == "go", job.CacheHit)
+	}
+	mu.Lock()
+	assert.Equal(t, map[string]int{"go": 1, "python": 1, "java": 1}, ran)
+	mu.Unlock()
+}
+
+func TestPool_Retries(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	pool := NewPool(store, funcRunner(func(ctx context.Context, job *Job, output io.Writer) (*codegen.CompilationResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[job.Language]++
+		switch job.Language {
+		case "go":
+			if calls["go"] < 2 {
+				return nil, errors.New("protoc crashed")
+			}
+			return &codegen.CompilationResult{Success: true}, nil
+		case "python":
+			return &codegen.CompilationResult{Success: false, Error: "plugin failed"}, nil
+		default:
+			return nil, fmt.Errorf("%w: version not found", ErrPermanent)
+		}
+	}), testPoolConfig())
+	pool.Start()
+	defer pool.Stop()
+
+	recovered, _, err := store.Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	failing, _, err := store.Enqueue(ctx, testJob("python"))
+	require.NoError(t, err)
+	permanent, _, err := store.Enqueue(ctx, testJob("java"))
+	require.NoError(t, err)
+
+	job := waitForStatus(t, store, recovered.ID, codegen.JobStatusCompleted)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Empty(t, job.Error)
+
+	job = waitForStatus(t, store, failing.ID, codegen.JobStatusFailed)
+	assert.Equal(t, DefaultMaxAttempts, job.Attempts)
+	assert.Equal(t, "plugin failed", job.Error)
+
+	job = waitForStatus(t, store, permanent.ID, codegen.JobStatusFailed)
+	assert.Equal(t, 1, job.Attempts, "permanent failures are not retried")
+}
+
+func TestPool_Logs(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	pool := NewPool(store, funcRunner(func(ctx context.Context, job *Job, output io.Writer) (*codegen.CompilationResult, error) {
+		fmt.Fprintln(output, "users.proto:3:1: syntax error")
+		if job.Attempts == 1 {
+			return nil, errors.New("protoc failed")
+		}
+		return &codegen.CompilationResult{Success: true}, nil
+	}), testPoolConfig())
+	pool.Start()
+	defer pool.Stop()
+
+	job, _, err := store.Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	waitForStatus(t, store, job.ID, codegen.JobStatusCompleted)
+
+	// The log is complete once the job is, keeping every attempt
+	log := readLog(t, store, job.ID)
+	assert.Contains(t, log, "Attempt 1 of 3 on ")
+	assert.Contains(t, log, "Failed: protoc failed; retrying in ")
+	assert.Contains(t, log, "Attempt 2 of 3 on ")
+	assert.Equal(t, 2, strings.Count(log, "users.proto:3:1: syntax error\n"))
+	assert.Contains(t, log, "Completed in ")
+}
+
+func TestPool_Cancel(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	pool := NewPool(store, funcRunner(func(ctx context.Context, job *Job, output io.Writer) (*codegen.CompilationResult, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}), testPoolConfig())
+	pool.Start()
+	defer pool.Stop()
+
+	job, _, err := store.Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	<-started
+	_, err = store.Cancel(ctx, job.ID)
+	require.NoError(t, err)
+
+	cancelled := waitForStatus(t, store, job.ID, codegen.JobStatusCancelled)
+	assert.NotNil(t, cancelled.CompletedAt)
+}
+
+func TestPool_StopReleasesJobs(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	pool := NewPool(store, funcRunner(func(ctx context.Context, job *Job, output io.Writer) (*codegen.CompilationResult, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}), testPoolConfig())
+	pool.Start()
+
+	job, _, err := store.Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	<-started
+	pool.Stop()
+
+	released, err := store.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, codegen.JobStatusPending, released.Status)
+	assert.Equal(t, 0, released.Attempts, "interrupted runs are not attempts")
+}
+
+func TestPool_AbandonsCrashingJobs(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	// Every worker running the job died before finishing it
+	job, _, err := store.Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	for i := 0; i < DefaultMaxAttempts; i++ {
+		_, err := store.Claim(ctx, "dead", -time.Second)
+		require.NoError(t, err)
+	}
+
+	pool := NewPool(store, funcRunner(func(ctx context.Context, job *Job, output io.Writer) (*codegen.CompilationResult, error) {
+		t.Error("abandoned jobs must not run")
+		return nil, nil
+	}), testPoolConfig())
+	pool.Start()
+	defer pool.Stop()
+
+	failed := waitForStatus(t, store, job.ID, codegen.JobStatusFailed)
+	assert.Contains(t, failed.Error, "abandoned")
+	assert.Equal(t, DefaultMaxAttempts, failed.Attempts)
+}
+
+func TestPool_Backoff(t *testing.T) {
+	pool := NewPool(nil, nil, &PoolConfig{BackoffBase: time.Second, BackoffMax: 5 * time.Second})
+	assert.Equal(t, time.Second, pool.backoff(1))
+	assert.Equal(t, 2*time.Second, pool.backoff(2))
+	assert.Equal(t, 4*time.Second, pool.backoff(3))
+	assert.Equal(t, 5*time.Second, pool.backoff(4))
+	assert.Equal(t, 5*time.Second, pool.backoff(100))
+}
@@ -0,0 +1,430 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+	"github.com/platinummonkey/spoke/pkg/codegen"
+)
+
+// dialect is the SQL dialect of a store's database
+type dialect int
+
+const (
+	dialectPostgres dialect = iota
+	dialectSQLite
+)
+
+// sqliteSchema creates the compile_jobs table in SQLite databases; Postgres
+// databases get it from the migrations
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS compile_jobs (
+    id TEXT PRIMARY KEY,
+    module_name TEXT NOT NULL,
+    version TEXT NOT NULL,
+    language TEXT NOT NULL,
+    include_grpc BOOLEAN NOT NULL DEFAULT FALSE,
+    options TEXT NOT NULL DEFAULT '{}',
+    priority INTEGER NOT NULL DEFAULT 0,
+    dedup_key TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    error_message TEXT NOT NULL DEFAULT '',
+    cache_hit BOOLEAN NOT NULL DEFAULT FALSE,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    worker_id TEXT NOT NULL DEFAULT '',
+    cancel_requested BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL,
+    run_at TIMESTAMP NOT NULL,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    lease_expires_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_compile_jobs_ready ON compile_jobs(status, priority DESC, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_compile_jobs_dedup ON compile_jobs(dedup_key)
+    WHERE status = 'pending' AND started_at IS NULL AND dedup_key <> '';
+CREATE INDEX IF NOT EXISTS idx_compile_jobs_version ON compile_jobs(module_name, version, created_at DESC);
+CREATE TABLE IF NOT EXISTS compile_job_logs (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id TEXT NOT NULL REFERENCES compile_jobs(id) ON DELETE CASCADE,
+    data TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_compile_job_logs_job ON compile_job_logs(job_id, seq);
+`
+
+// jobColumns lists the columns scanned by scanJob, in order
+const jobColumns = `id, module_name, version, language, include_grpc, options, priority, dedup_key,
+	status, attempts, max_attempts, error_message, cache_hit, duration_ms, worker_id, cancel_requested,
+	created_at, run_at, started_at, completed_at, lease_expires_at`
+
+// SQLStore stores compile jobs in the compile_jobs table of a Postgres or
+// SQLite database
+type SQLStore struct {
+	db      *sql.DB
+	dialect dialect
+	ownsDB  bool
+}
+
+// NewPostgresStore creates a job store using the compile_jobs table of a
+// Postgres database
+func NewPostgresStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db, dialect: dialectPostgres}
+}
+
+// NewSQLiteStore creates a job store in a SQLite database file, creating the
+// file and its compile_jobs table when missing. Several processes can share
+// the file.
+func NewSQLiteStore(path string) (*SQLStore, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job database: %w", err)
+	}
+	// SQLite allows one writer at a time; a single connection queues writes
+	// in the process rather than failing them as busy
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create job tables: %w", err)
+	}
+	return &SQLStore{db: db, dialect: dialectSQLite, ownsDB: true}, nil
+}
+
+// Enqueue adds a job, or returns the job with the same dedup key waiting
+// for its first run. A unique index on waiting jobs' dedup keys backs this,
+// so replicas enqueuing the same job at once create it once.
+func (s *SQLStore) Enqueue(ctx context.Context, job *Job) (*Job, bool, error) {
+	if job == nil || job.ModuleName == "" || job.Version == "" || job.Language == "" {
+		return nil, false, fmt.Errorf("module name, version and language required")
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	created := *job
+	created.Status = codegen.JobStatusPending
+	created.Attempts = 0
+	created.CreatedAt = now
+	if created.MaxAttempts <= 0 {
+		created.MaxAttempts = DefaultMaxAttempts
+	}
+	if created.RunAt.IsZero() {
+		created.RunAt = now
+	}
+	options, err := json.Marshal(created.Options)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode options: %w", err)
+	}
+
+	// A job waiting under the key may start between the insert conflicting
+	// with it and reading it; inserting again then succeeds
+	for attempt := 0; attempt < 3; attempt++ {
+		created.ID = uuid.New().String()
+		result, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO compile_jobs
+			(id, module_name, version, language, include_grpc, options, priority, dedup_key, status, max_attempts, created_at, run_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT DO NOTHING`),
+			created.ID, created.ModuleName, created.Version, created.Language, created.IncludeGRPC, string(options),
+			created.Priority, created.DedupKey, created.Status, created.MaxAttempts, created.CreatedAt, created.RunAt.UTC())
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to insert job: %w", err)
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return nil, false, fmt.Errorf("failed to insert job: %w", err)
+		} else if n == 1 {
+			return &created, true, nil
+		}
+
+		existing, err := s.waitingJob(ctx, created.DedupKey, created.Priority)
+		if err == nil {
+			return existing, false, nil
+		} else if !errors.Is(err, ErrJobNotFound) {
+			return nil, false, err
+		}
+	}
+	return nil, false, fmt.Errorf("failed to insert job: dedup key %q kept conflicting", created.DedupKey)
+}
+
+// waitingJob returns the job with a dedup key waiting for its first run,
+// raising its priority to at least the given one
+func (s *SQLStore) waitingJob(ctx context.Context, dedupKey string, priority int) (*Job, error) {
+	if _, err := s.db.ExecContext(ctx, s.rebind(`UPDATE compile_jobs SET priority = ?
+		WHERE dedup_key = ? AND status = ? AND started_at IS NULL AND priority < ?`),
+		priority, dedupKey, codegen.JobStatusPending, priority); err != nil {
+		return nil, fmt.Errorf("failed to raise job priority: %w", err)
+	}
+	return s.queryJob(ctx, s.db, "WHERE dedup_key = ? AND status = ? AND started_at IS NULL",
+		dedupKey, codegen.JobStatusPending)
+}
+
+// Claim leases the next job ready to run to a worker. Pending jobs whose
+// time has come run by priority, then age; running jobs whose lease expired
+// are claimed again.
+func (s *SQLStore) Claim(ctx context.Context, workerID string, lease time.Duration) (*Job, error) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	lock := ""
+	if s.dialect == dialectPostgres {
+		lock = "FOR UPDATE SKIP LOCKED"
+	}
+
+	query := fmt.Sprintf(`UPDATE compile_jobs
+		SET status = ?, worker_id = ?, attempts = attempts + 1, started_at = ?, lease_expires_at = ?
+		WHERE id = (
+			SELECT id FROM compile_jobs
+			WHERE (status = ? AND run_at <= ?) OR (status = ? AND lease_expires_at < ?)
+			ORDER BY priority DESC, created_at, id
+			LIMIT 1 %s
+		) AND (status = ? OR lease_expires_at < ?)
+		RETURNING %s`, lock, jobColumns)
+	job, err := scanJob(s.db.QueryRowContext(ctx, s.rebind(query),
+		codegen.JobStatusRunning, workerID, now, now.Add(lease),
+		codegen.JobStatusPending, now, codegen.JobStatusRunning, now,
+		codegen.JobStatusPending, now))
+	if errors.Is(err, ErrJobNotFound) {
+		return nil, ErrNoJobs
+	}
+	return job, err
+}
+
+// Heartbeat extends the lease of a worker on a running job
+func (s *SQLStore) Heartbeat(ctx context.Context, id, workerID string, lease time.Duration) (*Job, error) {
+	query := fmt.Sprintf(`UPDATE compile_jobs SET lease_expires_at = ?
+		WHERE id = ? AND worker_id = ? AND status = ?
+		RETURNING %s`, jobColumns)
+	job, err := scanJob(s.db.QueryRowContext(ctx, s.rebind(query),
+		time.Now().UTC().Add(lease).Truncate(time.Microsecond), id, workerID, codegen.JobStatusRunning))
+	if errors.Is(err, ErrJobNotFound) {
+		return nil, ErrLeaseLost
+	}
+	return job, err
+}
+
+// Finish records the outcome of a job run by a worker
+func (s *SQLStore) Finish(ctx context.Context, job *Job) error {
+	var completedAt interface{}
+	if job.CompletedAt != nil {
+		completedAt = job.CompletedAt.UTC().Truncate(time.Microsecond)
+	}
+
+	result, err := s.db.ExecContext(ctx, s.rebind(`UPDATE compile_jobs
+		SET status = ?, attempts = ?, error_message = ?, cache_hit = ?, duration_ms = ?, run_at = ?,
+			completed_at = ?, lease_expires_at = NULL
+		WHERE id = ? AND worker_id = ? AND status = ?`),
+		job.Status, job.Attempts, job.Error, job.CacheHit, job.Duration.Milliseconds(),
+		job.RunAt.UTC().Truncate(time.Microsecond), completedAt,
+		job.ID, job.WorkerID, codegen.JobStatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Cancel cancels a pending job, or requests a running job be cancelled
+func (s *SQLStore) Cancel(ctx context.Context, id string) (*Job, error) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	result, err := s.db.ExecContext(ctx, s.rebind(`UPDATE compile_jobs SET status = ?, completed_at = ?
+		WHERE id = ? AND status = ?`),
+		codegen.JobStatusCancelled, now, id, codegen.JobStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		if _, err := s.db.ExecContext(ctx, s.rebind(`UPDATE compile_jobs SET cancel_requested = ?
+			WHERE id = ? AND status = ?`),
+			true, id, codegen.JobStatusRunning); err != nil {
+			return nil, fmt.Errorf("failed to cancel job: %w", err)
+		}
+	}
+
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Finished() && job.Status != codegen.JobStatusCancelled {
+		return job, ErrJobFinished
+	}
+	return job, nil
+}
+
+// Get returns a job
+func (s *SQLStore) Get(ctx context.Context, id string) (*Job, error) {
+	return s.queryJob(ctx, s.db, "WHERE id = ?", id)
+}
+
+// List returns jobs, most recent first
+func (s *SQLStore) List(ctx context.Context, filter Filter) ([]*Job, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.ModuleName != "" {
+		conditions = append(conditions, "module_name = ?")
+		args = append(args, filter.ModuleName)
+	}
+	if filter.Version != "" {
+		conditions = append(conditions, "version = ?")
+		args = append(args, filter.Version)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := "SELECT " + jobColumns + " FROM compile_jobs"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id LIMIT " + strconv.Itoa(limit)
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// AppendLog adds output to the log of a job. Bytes that are not valid
+// UTF-8 text are replaced.
+func (s *SQLStore) AppendLog(ctx context.Context, id string, data string) error {
+	data = strings.ReplaceAll(strings.ToValidUTF8(data, "\uFFFD"), "\x00", "")
+	if data == "" {
+		return nil
+	}
+	if _, err := s.db.ExecContext(ctx, s.rebind("INSERT INTO compile_job_logs (job_id, data, created_at) VALUES (?, ?, ?)"),
+		id, data, time.Now().UTC().Truncate(time.Microsecond)); err != nil {
+		return fmt.Errorf("failed to append job log: %w", err)
+	}
+	return nil
+}
+
+// Logs returns the chunks of a job's log numbered after the given chunk
+func (s *SQLStore) Logs(ctx context.Context, id string, after int64) ([]LogChunk, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind("SELECT seq, data, created_at FROM compile_job_logs WHERE job_id = ? AND seq > ? ORDER BY seq"),
+		id, after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job log: %w", err)
+	}
+	defer rows.Close()
+
+	chunks := []LogChunk{}
+	for rows.Next() {
+		var chunk LogChunk
+		if err := rows.Scan(&chunk.Seq, &chunk.Data, &chunk.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan job log: %w", err)
+		}
+		chunk.CreatedAt = chunk.CreatedAt.UTC()
+		chunks = append(chunks, chunk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read job log: %w", err)
+	}
+	return chunks, nil
+}
+
+// Close releases resources. The database of a Postgres store belongs to
+// the caller and stays open.
+func (s *SQLStore) Close() error {
+	if s.ownsDB {
+		return s.db.Close()
+	}
+	return nil
+}
+
+// querier runs queries on a database or in a transaction
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// queryJob returns the job selected by a WHERE clause
+func (s *SQLStore) queryJob(ctx context.Context, q querier, where string, args ...interface{}) (*Job, error) {
+	return scanJob(q.QueryRowContext(ctx, s.rebind("SELECT "+jobColumns+" FROM compile_jobs "+where), args...))
+}
+
+// rebind replaces the ? placeholders of a query with $1, $2, ... for
+// Postgres
+func (s *SQLStore) rebind(query string) string {
+	if s.dialect != dialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// scanner scans a row
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanJob scans a row of jobColumns
+func scanJob(row scanner) (*Job, error) {
+	var job Job
+	var status, options string
+	var durationMS int64
+	var startedAt, completedAt, leaseExpiresAt sql.NullTime
+	err := row.Scan(&job.ID, &job.ModuleName, &job.Version, &job.Language, &job.IncludeGRPC, &options,
+		&job.Priority, &job.DedupKey, &status, &job.Attempts, &job.MaxAttempts, &job.Error, &job.CacheHit,
+		&durationMS, &job.WorkerID, &job.CancelRequested, &job.CreatedAt, &job.RunAt,
+		&startedAt, &completedAt, &leaseExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to scan job: %w", err)
+	}
+
+	job.Status = codegen.JobStatus(status)
+	job.Duration = time.Duration(durationMS) * time.Millisecond
+	if options != "" && options != "null" {
+		if err := json.Unmarshal([]byte(options), &job.Options); err != nil {
+			return nil, fmt.Errorf("failed to decode job options: %w", err)
+		}
+	}
+	job.CreatedAt = job.CreatedAt.UTC()
+	job.RunAt = job.RunAt.UTC()
+	job.StartedAt = nullTime(startedAt)
+	job.CompletedAt = nullTime(completedAt)
+	job.LeaseExpiresAt = nullTime(leaseExpiresAt)
+	return &job, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
@@ -0,0 +1,333 @@
+package queue
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *SQLStore {
+	t.Helper()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "jobs.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func testJob(language string) *Job {
+	return &Job{
+		ModuleName: "users",
+		Version:    "v1.0.0",
+		Language:   language,
+		Options:    map[string]string{"paths": "source_relative"},
+		DedupKey:   "users@v1.0.0/" + language,
+	}
+}
+
+func TestSQLStore_Enqueue(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	_, _, err := store.Enqueue(ctx, &Job{ModuleName: "users"})
+	assert.Error(t, err)
+
+	job, created, err := store.Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.NotEmpty(t, job.ID)
+	assert.Equal(t, codegen.JobStatusPending, job.Status)
+	assert.Equal(t, DefaultMaxAttempts, job.MaxAttempts)
+
+	got, err := store.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "source_relative", got.Options["paths"])
+	assert.True(t, got.CreatedAt.Equal(job.CreatedAt))
+	assert.Nil(t, got.StartedAt)
+
+	// An identical pending job is merged, taking the higher priority
+	dup := testJob("go")
+	dup.Priority = 5
+	merged, created, err := store.Enqueue(ctx, dup)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, job.ID, merged.ID)
+	assert.Equal(t, 5, merged.Priority)
+
+	// Jobs without a dedup key are never merged
+	first, _, err := store.Enqueue(ctx, &Job{ModuleName: "users", Version: "v1.0.0", Language: "python"})
+	require.NoError(t, err)
+	second, created, err := store.Enqueue(ctx, &Job{ModuleName: "users", Version: "v1.0.0", Language: "python"})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	_, err = store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestSQLStore_Enqueue_Concurrent(t *testing.T) {
+	// Replicas sharing the database enqueue the same job at once
+	path := filepath.Join(t.TempDir(), "jobs.db")
+	var stores []*SQLStore
+	for i := 0; i < 2; i++ {
+		store, err := NewSQLiteStore(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { store.Close() })
+		stores = append(stores, store)
+	}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ids := map[string]bool{}
+	createdCount := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job, created, err := stores[i%2].Enqueue(ctx, testJob("go"))
+			if !assert.NoError(t, err) {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			ids[job.ID] = true
+			if created {
+				createdCount++
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, ids, 1, "identical jobs are queued once")
+	assert.Equal(t, 1, createdCount)
+
+	// Jobs retried after starting no longer merge with new requests, and
+	// both can wait at once
+	job, err := stores[0].Claim(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+	fresh, created, err := stores[0].Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	assert.True(t, created)
+	job.Status = codegen.JobStatusPending
+	require.NoError(t, stores[0].Finish(ctx, job))
+	again, created, err := stores[1].Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, fresh.ID, again.ID)
+}
+
+func TestSQLStore_Claim(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	_, err := store.Claim(ctx, "w1", time.Minute)
+	assert.ErrorIs(t, err, ErrNoJobs)
+
+	low, _, err := store.Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	high := testJob("python")
+	high.Priority = 10
+	high, _, err = store.Enqueue(ctx, high)
+	require.NoError(t, err)
+	later := testJob("java")
+	later.RunAt = time.Now().Add(time.Hour)
+	_, _, err = store.Enqueue(ctx, later)
+	require.NoError(t, err)
+
+	claimed, err := store.Claim(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, high.ID, claimed.ID, "higher priorities run first")
+	assert.Equal(t, codegen.JobStatusRunning, claimed.Status)
+	assert.Equal(t, "w1", claimed.WorkerID)
+	assert.Equal(t, 1, claimed.Attempts)
+	require.NotNil(t, claimed.LeaseExpiresAt)
+	require.NotNil(t, claimed.StartedAt)
+
+	claimed, err = store.Claim(ctx, "w2", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, low.ID, claimed.ID)
+
+	_, err = store.Claim(ctx, "w3", time.Minute)
+	assert.ErrorIs(t, err, ErrNoJobs, "jobs scheduled later are not ready")
+
+	// Running jobs are not merged with new ones
+	_, created, err := store.Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	assert.True(t, created)
+}
+
+func TestSQLStore_ExpiredLease(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	job, _, err := store.Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	_, err = store.Claim(ctx, "w1", -time.Second)
+	require.NoError(t, err)
+
+	reclaimed, err := store.Claim(ctx, "w2", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, reclaimed.ID)
+	assert.Equal(t, "w2", reclaimed.WorkerID)
+	assert.Equal(t, 2, reclaimed.Attempts)
+
+	_, err = store.Heartbeat(ctx, job.ID, "w1", time.Minute)
+	assert.ErrorIs(t, err, ErrLeaseLost)
+	stale := *reclaimed
+	stale.WorkerID = "w1"
+	stale.Status = codegen.JobStatusCompleted
+	assert.ErrorIs(t, store.Finish(ctx, &stale), ErrLeaseLost)
+
+	beat, err := store.Heartbeat(ctx, job.ID, "w2", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, beat.LeaseExpiresAt.After(time.Now().Add(59*time.Minute)))
+}
+
+func TestSQLStore_Finish(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	_, _, err := store.Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	job, err := store.Claim(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+
+	// A retried job waits for its backoff
+	job.Status = codegen.JobStatusPending
+	job.Error = "protoc crashed"
+	job.RunAt = time.Now().Add(time.Hour)
+	require.NoError(t, store.Finish(ctx, job))
+	_, err = store.Claim(ctx, "w1", time.Minute)
+	assert.ErrorIs(t, err, ErrNoJobs)
+
+	got, err := store.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, codegen.JobStatusPending, got.Status)
+	assert.Equal(t, "protoc crashed", got.Error)
+	assert.Nil(t, got.LeaseExpiresAt)
+
+	_, _, err = store.Enqueue(ctx, testJob("python"))
+	require.NoError(t, err)
+	job, err = store.Claim(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+	now := time.Now()
+	job.Status = codegen.JobStatusCompleted
+	job.CacheHit = true
+	job.Duration = 1500 * time.Millisecond
+	job.CompletedAt = &now
+	require.NoError(t, store.Finish(ctx, job))
+
+	got, err = store.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, codegen.JobStatusCompleted, got.Status)
+	assert.True(t, got.CacheHit)
+	assert.Equal(t, 1500*time.Millisecond, got.Duration)
+	require.NotNil(t, got.CompletedAt)
+	assert.Equal(t, "w1", got.WorkerID)
+	assert.ErrorIs(t, store.Finish(ctx, job), ErrLeaseLost, "finished jobs cannot finish again")
+}
+
+func TestSQLStore_Cancel(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	pending, _, err := store.Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	cancelled, err := store.Cancel(ctx, pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, codegen.JobStatusCancelled, cancelled.Status)
+	assert.NotNil(t, cancelled.CompletedAt)
+
+	running, _, err := store.Enqueue(ctx, testJob("python"))
+	require.NoError(t, err)
+	_, err = store.Claim(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+	requested, err := store.Cancel(ctx, running.ID)
+	require.NoError(t, err)
+	assert.Equal(t, codegen.JobStatusRunning, requested.Status)
+	assert.True(t, requested.CancelRequested)
+	beat, err := store.Heartbeat(ctx, running.ID, "w1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, beat.CancelRequested)
+
+	beat.Status = codegen.JobStatusFailed
+	require.NoError(t, store.Finish(ctx, beat))
+	_, err = store.Cancel(ctx, running.ID)
+	assert.ErrorIs(t, err, ErrJobFinished)
+
+	_, err = store.Cancel(ctx, "missing")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestSQLStore_List(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	for _, language := range []string{"go", "python", "java"} {
+		_, _, err := store.Enqueue(ctx, testJob(language))
+		require.NoError(t, err)
+	}
+	other := testJob("go")
+	other.Version = "v2.0.0"
+	_, _, err := store.Enqueue(ctx, other)
+	require.NoError(t, err)
+	_, err = store.Claim(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+
+	jobs, err := store.List(ctx, Filter{ModuleName: "users", Version: "v1.0.0"})
+	require.NoError(t, err)
+	require.Len(t, jobs, 3)
+	assert.Equal(t, "java", jobs[0].Language, "most recent first")
+
+	jobs, err = store.List(ctx, Filter{ModuleName: "users", Status: codegen.JobStatusRunning})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "go", jobs[0].Language)
+
+	jobs, err = store.List(ctx, Filter{Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, jobs, 2)
+
+	jobs, err = store.List(ctx, Filter{ModuleName: "orders"})
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
+func TestSQLStore_Rebind(t *testing.T) {
+	store := NewPostgresStore(nil)
+	assert.Equal(t, "SELECT * FROM t WHERE a = $1 AND b = $2", store.rebind("SELECT * FROM t WHERE a = ? AND b = ?"))
+	assert.NoError(t, store.Close())
+}
+
+func TestSQLStore_Logs(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	job, _, err := store.Enqueue(ctx, testJob("go"))
+	require.NoError(t, err)
+	require.NoError(t, store.AppendLog(ctx, job.ID, "protoc --go_out=.\n"))
+	require.NoError(t, store.AppendLog(ctx, job.ID, ""))
+	require.NoError(t, store.AppendLog(ctx, job.ID, "bad\x00byte \xff\n"))
+
+	chunks, err := store.Logs(ctx, job.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, chunks, 2, "empty output is not stored")
+	assert.Equal(t, "protoc --go_out=.\n", chunks[0].Data)
+	assert.Equal(t, "badbyte �\n", chunks[1].Data)
+	assert.False(t, chunks[0].CreatedAt.IsZero())
+
+	after, err := store.Logs(ctx, job.ID, chunks[0].Seq)
+	require.NoError(t, err)
+	require.Len(t, after, 1)
+	assert.Equal(t, chunks[1].Seq, after[0].Seq)
+
+	chunks, err = store.Logs(ctx, "missing", 0)
+	require.NoError(t, err)
+	assert.Empty(t, chunks)
+}
@@ -0,0 +1,110 @@
+package queue
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/codegen"
+)
+
+// DefaultMaxAttempts is how many times a job runs before it fails, for jobs
+// enqueued without MaxAttempts
+const DefaultMaxAttempts = 3
+
+// Job is the compilation of a module version for one language
+type Job struct {
+	ID          string
+	ModuleName  string
+	Version     string
+	Language    string
+	IncludeGRPC bool
+	Options     map[string]string
+	Priority    int    // Jobs with higher priorities run first
+	DedupKey    string // Pending jobs with the same key are merged; empty never merges
+
+	Status          codegen.JobStatus
+	Attempts        int
+	MaxAttempts     int
+	Error           string
+	CacheHit        bool
+	Duration        time.Duration
+	WorkerID        string
+	CancelRequested bool
+
+	CreatedAt      time.Time
+	RunAt          time.Time // Earliest time the job runs, later when retrying
+	StartedAt      *time.Time
+	CompletedAt    *time.Time
+	LeaseExpiresAt *time.Time
+}
+
+// Finished reports whether the job completed, failed or was cancelled
+func (j *Job) Finished() bool {
+	switch j.Status {
+	case codegen.JobStatusCompleted, codegen.JobStatusFailed, codegen.JobStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// LogChunk is output of a job. Chunks are numbered in the order they were
+// written and hold whole lines, except the last chunk of a run.
+type LogChunk struct {
+	Seq       int64
+	Data      string
+	CreatedAt time.Time
+}
+
+// Filter selects jobs to list
+type Filter struct {
+	ModuleName string
+	Version    string
+	Status     codegen.JobStatus // All statuses when empty
+	Limit      int               // 100 when zero
+}
+
+// Store persists compile jobs
+type Store interface {
+	// Enqueue adds a job, or returns the job with the same dedup key
+	// waiting for its first run. The bool reports whether the job was added.
+	Enqueue(ctx context.Context, job *Job) (*Job, bool, error)
+
+	// Claim leases the next job ready to run to a worker, returning ErrNoJobs
+	// when there is none
+	Claim(ctx context.Context, workerID string, lease time.Duration) (*Job, error)
+
+	// Heartbeat extends the lease of a worker on a running job, returning
+	// the job so the worker sees cancellation requests
+	Heartbeat(ctx context.Context, id, workerID string, lease time.Duration) (*Job, error)
+
+	// Finish records the outcome of a job run by a worker: its status,
+	// error, attempts, result and, when retried, the time it runs again
+	Finish(ctx context.Context, job *Job) error
+
+	// Cancel cancels a pending job, or requests a running job be cancelled
+	Cancel(ctx context.Context, id string) (*Job, error)
+
+	// Get returns a job
+	Get(ctx context.Context, id string) (*Job, error)
+
+	// List returns jobs, most recent first
+	List(ctx context.Context, filter Filter) ([]*Job, error)
+
+	// AppendLog adds output to the log of a job
+	AppendLog(ctx context.Context, id string, data string) error
+
+	// Logs returns the chunks of a job's log numbered after the given
+	// chunk, or the whole log when after is zero
+	Logs(ctx context.Context, id string, after int64) ([]LogChunk, error)
+
+	// Close releases resources
+	Close() error
+}
+
+// Runner runs compile jobs, writing the output of the compilers to output
+// as they run. Writes to output never fail and may come from several
+// goroutines.
+type Runner interface {
+	Run(ctx context.Context, job *Job, output io.Writer) (*codegen.CompilationResult, error)
+}
@@ -29,6 +29,7 @@ type Comparator struct {
 	mode       CompatibilityMode
 	oldSchema  *SchemaGraph
 	newSchema  *SchemaGraph
+	diff       *SchemaDiff
 	violations []Violation
 }
 
@@ -54,6 +55,9 @@ type Violation struct {
 	WireBreaking   bool
 	SourceBreaking bool
 	Suggestion     string
+	Kind           ChangeKind // Structural change the violation was derived from
+	File           string     // Source file of the changed element, when known
+	Line           int        // Line of the changed element in File, 0 when unknown
 }
 
 // ViolationLevel indicates the severity
@@ -80,12 +84,13 @@ const (
 	CategoryReservedChange
 	CategoryPackageChange
 	CategoryImportChange
+	CategoryFeatureChange
 )
 
 func (vc ViolationCategory) String() string {
 	return []string{
 		"field_change", "type_change", "enum_change", "service_change",
-		"reserved_change", "package_change", "import_change",
+		"reserved_change", "package_change", "import_change", "feature_change",
 	}[vc]
 }
 
@@ -95,6 +100,7 @@ type CheckResult struct {
 	Mode       string
 	Violations []Violation
 	Summary    Summary
+	Diff       *SchemaDiff // Structural changes the violations were derived from
 }
 
 // Summary provides an overview of violations
@@ -121,12 +127,11 @@ func (c *Comparator) Compare() (*CheckResult, error) {
 		}, nil
 	}
 
-	// Run comparison checks
-	c.comparePackages()
-	c.compareImports()
-	c.compareMessages()
-	c.compareEnums()
-	c.compareServices()
+	// Derive violations from the structural diff
+	c.diff = Diff(c.oldSchema, c.newSchema)
+	for _, change := range c.diff.Changes {
+		c.evaluate(change)
+	}
 
 	// Determine if compatible
 	compatible := true
@@ -145,207 +150,374 @@ func (c *Comparator) Compare() (*CheckResult, error) {
 		Mode:       c.mode.String(),
 		Violations: c.violations,
 		Summary:    summary,
+		Diff:       c.diff,
 	}, nil
 }
 
-func (c *Comparator) comparePackages() {
-	if c.oldSchema.Package != c.newSchema.Package {
-		c.addViolation(Violation{
-			Rule:           "PACKAGE_CHANGED",
-			Level:          ViolationLevelError,
-			Category:       CategoryPackageChange,
-			Location:       "package",
-			OldValue:       c.oldSchema.Package,
-			NewValue:       c.newSchema.Package,
-			Message:        "Package name changed - breaks all imports",
-			WireBreaking:   false,
-			SourceBreaking: true,
-			Suggestion:     "Create a new package instead of renaming. Consider backward compatibility aliases.",
-		})
-	}
-}
-
-func (c *Comparator) compareImports() {
-	// TODO: Implement import comparison
-}
-
-func (c *Comparator) compareMessages() {
-	// Check for removed messages
-	for msgName, oldMsg := range c.oldSchema.Messages {
-		if _, exists := c.newSchema.Messages[msgName]; !exists {
-			c.addViolation(Violation{
-				Rule:           "MESSAGE_REMOVED",
-				Level:          ViolationLevelError,
-				Category:       CategoryTypeChange,
-				Location:       msgName,
-				Message:        fmt.Sprintf("Message %s was removed", msgName),
-				WireBreaking:   true,
-				SourceBreaking: true,
-				Suggestion:     "Do not remove messages. Mark as deprecated instead.",
-			})
-			continue
+// evaluate derives the violation, if any, for a single structural change
+func (c *Comparator) evaluate(change Change) {
+	forward := c.mode == CompatibilityModeForward || c.mode == CompatibilityModeForwardTransitive
+
+	v := Violation{
+		Kind:     change.Kind,
+		Location: change.Location,
+		File:     change.File,
+		Line:     change.Line,
+	}
+
+	switch change.Kind {
+	case ChangePackageChanged:
+		v.Rule = "PACKAGE_CHANGED"
+		v.Level = ViolationLevelError
+		v.Category = CategoryPackageChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = "Package name changed - breaks all imports"
+		v.SourceBreaking = true
+		v.Suggestion = "Create a new package instead of renaming. Consider backward compatibility aliases."
+
+	case ChangeSyntaxChanged:
+		v.Rule = "SYNTAX_CHANGED"
+		v.Level = ViolationLevelInfo
+		v.Category = CategoryFeatureChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("Syntax changed from %s to %s", change.OldValue, change.NewValue)
+		v.Suggestion = "Feature changes caused by the migration are reported separately; set features explicitly to keep the previous behavior."
+
+	case ChangeImportRemoved:
+		v.Rule = "IMPORT_REMOVED"
+		v.Level = ViolationLevelInfo
+		v.Category = CategoryImportChange
+		v.Message = fmt.Sprintf("Import %s was removed", change.Name)
+		if change.OldValue == "public" {
+			// Dependents may rely on types re-exported through a public import
+			v.Level = ViolationLevelWarning
+			v.SourceBreaking = true
+			v.Suggestion = "Keep public imports until dependents import the file directly."
 		}
 
-		newMsg := c.newSchema.Messages[msgName]
-		c.compareMessageFields(oldMsg, newMsg)
-		c.compareNestedMessages(oldMsg, newMsg)
-	}
-
-	// Check for added messages (info only)
-	for msgName := range c.newSchema.Messages {
-		if _, exists := c.oldSchema.Messages[msgName]; !exists {
-			c.addViolation(Violation{
-				Rule:           "MESSAGE_ADDED",
-				Level:          ViolationLevelInfo,
-				Category:       CategoryTypeChange,
-				Location:       msgName,
-				Message:        fmt.Sprintf("Message %s was added", msgName),
-				WireBreaking:   false,
-				SourceBreaking: false,
-			})
+	case ChangeImportAdded:
+		v.Rule = "IMPORT_ADDED"
+		v.Level = ViolationLevelInfo
+		v.Category = CategoryImportChange
+		v.Message = fmt.Sprintf("Import %s was added", change.Name)
+
+	case ChangeMessageRemoved:
+		v.Rule = "MESSAGE_REMOVED"
+		v.Level = ViolationLevelError
+		v.Category = CategoryTypeChange
+		v.Message = fmt.Sprintf("Message %s was removed", change.Name)
+		v.WireBreaking = true
+		v.SourceBreaking = true
+		v.Suggestion = "Do not remove messages. Mark as deprecated instead."
+
+	case ChangeMessageAdded:
+		v.Rule = "MESSAGE_ADDED"
+		v.Level = ViolationLevelInfo
+		v.Category = CategoryTypeChange
+		v.Message = fmt.Sprintf("Message %s was added", change.Name)
+
+	case ChangeFieldRemoved:
+		v.Rule = "FIELD_REMOVED"
+		v.Level = ViolationLevelError
+		if forward {
+			v.Level = ViolationLevelWarning
 		}
-	}
-}
-
-func (c *Comparator) compareMessageFields(oldMsg, newMsg *Message) {
-	// Check for removed fields
-	for fieldNum, oldField := range oldMsg.Fields {
-		newField, exists := newMsg.Fields[fieldNum]
-		if !exists {
-			level := ViolationLevelError
-			if c.mode == CompatibilityModeForward || c.mode == CompatibilityModeForwardTransitive {
-				level = ViolationLevelWarning
-			}
-			c.addViolation(Violation{
-				Rule:           "FIELD_REMOVED",
-				Level:          level,
-				Category:       CategoryFieldChange,
-				Location:       fmt.Sprintf("%s.%s", oldMsg.FullName, oldField.Name),
-				Message:        fmt.Sprintf("Field %d (%s) was removed", fieldNum, oldField.Name),
-				WireBreaking:   false,
-				SourceBreaking: true,
-				Suggestion:     "Mark field as reserved instead of removing it.",
-			})
-			continue
+		v.Category = CategoryFieldChange
+		v.Message = fmt.Sprintf("Field %d (%s) was removed", change.Number, change.Name)
+		v.SourceBreaking = true
+		v.Suggestion = "Mark field as reserved instead of removing it."
+
+	case ChangeFieldAdded:
+		v.Category = CategoryFieldChange
+		if change.NewField != nil && change.NewField.Label == FieldLabelRequired {
+			v.Rule = "REQUIRED_FIELD_ADDED"
+			v.Level = ViolationLevelError
+			v.Message = fmt.Sprintf("Required field %d (%s) was added", change.Number, change.Name)
+			v.WireBreaking = true
+			v.SourceBreaking = true
+			v.Suggestion = "New fields must be optional or repeated."
+		} else {
+			v.Rule = "FIELD_ADDED"
+			v.Level = ViolationLevelInfo
+			v.Message = fmt.Sprintf("Field %d (%s) was added", change.Number, change.Name)
 		}
 
-		c.compareField(oldMsg.FullName, oldField, newField)
-	}
-
-	// Check for added fields (info, but check for required fields)
-	for fieldNum, newField := range newMsg.Fields {
-		if _, exists := oldMsg.Fields[fieldNum]; !exists {
-			if newField.Label == FieldLabelRequired {
-				c.addViolation(Violation{
-					Rule:           "REQUIRED_FIELD_ADDED",
-					Level:          ViolationLevelError,
-					Category:       CategoryFieldChange,
-					Location:       fmt.Sprintf("%s.%s", newMsg.FullName, newField.Name),
-					Message:        fmt.Sprintf("Required field %d (%s) was added", fieldNum, newField.Name),
-					WireBreaking:   true,
-					SourceBreaking: true,
-					Suggestion:     "New fields must be optional or repeated.",
-				})
-			} else {
-				c.addViolation(Violation{
-					Rule:           "FIELD_ADDED",
-					Level:          ViolationLevelInfo,
-					Category:       CategoryFieldChange,
-					Location:       fmt.Sprintf("%s.%s", newMsg.FullName, newField.Name),
-					Message:        fmt.Sprintf("Field %d (%s) was added", fieldNum, newField.Name),
-					WireBreaking:   false,
-					SourceBreaking: false,
-				})
-			}
+	case ChangeFieldNumberChanged:
+		v.Rule = "FIELD_NUMBER_CHANGED"
+		v.Level = ViolationLevelError
+		v.Category = CategoryFieldChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("Field %s number changed from %s to %s", change.Name, change.OldValue, change.NewValue)
+		v.WireBreaking = true
+		v.SourceBreaking = true
+		v.Suggestion = "Never renumber fields. Reserve the old number and add a new field."
+
+	case ChangeFieldRenamed:
+		v.Rule = "FIELD_NAME_CHANGED"
+		v.Level = ViolationLevelWarning
+		v.Category = CategoryFieldChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("Field %d name changed from %s to %s", change.Number, change.OldValue, change.NewValue)
+		v.SourceBreaking = true
+		v.Suggestion = "Field name changes break source code compatibility."
+
+	case ChangeFieldTypeChanged:
+		if c.isTypeCompatible(change.OldField.Type, change.NewField.Type) {
+			return
+		}
+		v.Rule = "FIELD_TYPE_CHANGED"
+		v.Level = ViolationLevelError
+		v.Category = CategoryTypeChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("Field %s type changed from %s to %s (incompatible)", change.Name, change.OldValue, change.NewValue)
+		v.WireBreaking = true
+		v.SourceBreaking = true
+		v.Suggestion = "Type changes must be wire-compatible (e.g., int32 ↔ int64, sint32 ↔ sint64)."
+
+	case ChangeFieldLabelChanged:
+		v.Rule = "FIELD_LABEL_CHANGED"
+		v.Level = ViolationLevelError
+		v.WireBreaking = true
+		// Allow optional → repeated in proto3
+		if change.OldField.Label == FieldLabelOptional && change.NewField.Label == FieldLabelRepeated {
+			v.Level = ViolationLevelWarning
+			v.WireBreaking = false
+		}
+		v.Category = CategoryFieldChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("Field %s label changed from %s to %s", change.Name, change.OldValue, change.NewValue)
+		v.SourceBreaking = true
+		v.Suggestion = "Avoid changing field labels. Use new field numbers instead."
+
+	case ChangeFieldOneOfChanged:
+		v.Rule = "FIELD_ONEOF_CHANGED"
+		v.Level = ViolationLevelError
+		v.Category = CategoryFieldChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("Field %s oneof membership changed", change.Name)
+		v.WireBreaking = true
+		v.SourceBreaking = true
+		v.Suggestion = "Do not move fields in/out of oneofs."
+
+	case ChangeFieldPresenceChanged:
+		v.Rule = "FIELD_PRESENCE_CHANGED"
+		v.Level = ViolationLevelWarning
+		v.Category = CategoryFeatureChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("Field %s presence changed from %s to %s", change.Name, change.OldValue, change.NewValue)
+		v.SourceBreaking = true
+		v.Suggestion = "Presence changes add or remove has-accessors and change whether default values are serialized. Keep the previous presence with features.field_presence."
+
+	case ChangeFieldEncodingChanged:
+		v.Rule = "FIELD_MESSAGE_ENCODING_CHANGED"
+		v.Level = ViolationLevelError
+		v.Category = CategoryFeatureChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("Field %s message encoding changed from %s to %s", change.Name, change.OldValue, change.NewValue)
+		v.WireBreaking = true
+		v.Suggestion = "Delimited and length-prefixed messages are not wire compatible. Add a new field instead."
+
+	case ChangeFieldPackedChanged:
+		v.Rule = "FIELD_PACKED_CHANGED"
+		v.Level = ViolationLevelInfo
+		v.Category = CategoryFeatureChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("Field %s packed encoding changed from %s to %s", change.Name, change.OldValue, change.NewValue)
+
+	case ChangeFieldUTF8ValidationChanged:
+		v.Rule = "FIELD_UTF8_VALIDATION_CHANGED"
+		v.Level = ViolationLevelInfo
+		v.Category = CategoryFeatureChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("Field %s UTF-8 validation changed from %s to %s", change.Name, change.OldValue, change.NewValue)
+		if change.NewField != nil && change.NewField.ValidateUTF8 {
+			// Data written without validation may now be rejected by readers
+			v.Level = ViolationLevelWarning
+			v.Suggestion = "Make sure no producer writes invalid UTF-8, or keep features.utf8_validation = NONE."
 		}
-	}
-}
-
-func (c *Comparator) compareField(msgName string, oldField, newField *Field) {
-	location := fmt.Sprintf("%s.%s", msgName, oldField.Name)
-
-	// Check field name change
-	if oldField.Name != newField.Name {
-		c.addViolation(Violation{
-			Rule:           "FIELD_NAME_CHANGED",
-			Level:          ViolationLevelWarning,
-			Category:       CategoryFieldChange,
-			Location:       location,
-			OldValue:       oldField.Name,
-			NewValue:       newField.Name,
-			Message:        fmt.Sprintf("Field %d name changed from %s to %s", oldField.Number, oldField.Name, newField.Name),
-			WireBreaking:   false,
-			SourceBreaking: true,
-			Suggestion:     "Field name changes break source code compatibility.",
-		})
-	}
-
-	// Check field type change
-	if !c.isTypeCompatible(oldField.Type, newField.Type) {
-		c.addViolation(Violation{
-			Rule:           "FIELD_TYPE_CHANGED",
-			Level:          ViolationLevelError,
-			Category:       CategoryTypeChange,
-			Location:       location,
-			OldValue:       oldField.Type.String(),
-			NewValue:       newField.Type.String(),
-			Message:        fmt.Sprintf("Field %s type changed from %s to %s (incompatible)", oldField.Name, oldField.Type, newField.Type),
-			WireBreaking:   true,
-			SourceBreaking: true,
-			Suggestion:     "Type changes must be wire-compatible (e.g., int32 ↔ int64, sint32 ↔ sint64).",
-		})
-	}
 
-	// Check label change (optional/required/repeated)
-	if oldField.Label != newField.Label {
-		level := ViolationLevelError
-		wireBreaking := true
+	case ChangeEnumTypeChanged:
+		v.Rule = "ENUM_TYPE_CHANGED"
+		v.Level = ViolationLevelWarning
+		v.Category = CategoryEnumChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("Enum %s changed from %s to %s", change.Name, change.OldValue, change.NewValue)
+		v.SourceBreaking = true
+		v.Suggestion = "Open and closed enums handle unknown values differently. Keep the previous behavior with features.enum_type."
+
+	case ChangeEnumRemoved:
+		v.Rule = "ENUM_REMOVED"
+		v.Level = ViolationLevelError
+		v.Category = CategoryEnumChange
+		v.Message = fmt.Sprintf("Enum %s was removed", change.Name)
+		v.WireBreaking = true
+		v.SourceBreaking = true
+		v.Suggestion = "Do not remove enums. Mark as deprecated instead."
+
+	case ChangeEnumAdded:
+		v.Rule = "ENUM_ADDED"
+		v.Level = ViolationLevelInfo
+		v.Category = CategoryEnumChange
+		v.Message = fmt.Sprintf("Enum %s was added", change.Name)
+
+	case ChangeEnumValueRemoved:
+		v.Rule = "ENUM_VALUE_REMOVED"
+		v.Level = ViolationLevelError
+		if forward {
+			v.Level = ViolationLevelWarning
+		}
+		v.Category = CategoryEnumChange
+		v.Message = fmt.Sprintf("Enum value %d (%s) was removed", change.Number, change.Name)
+		v.SourceBreaking = true
+		v.Suggestion = "Do not remove enum values. Mark as deprecated or reserve the number."
+
+	case ChangeEnumValueNumberChanged:
+		v.Rule = "ENUM_VALUE_NUMBER_CHANGED"
+		v.Level = ViolationLevelError
+		v.Category = CategoryEnumChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("Enum value %s number changed from %s to %s", change.Name, change.OldValue, change.NewValue)
+		v.WireBreaking = true
+		v.SourceBreaking = true
+		v.Suggestion = "Never change enum value numbers."
+
+	case ChangeEnumValueAdded:
+		v.Rule = "ENUM_VALUE_ADDED"
+		v.Level = ViolationLevelInfo
+		v.Category = CategoryEnumChange
+		v.Message = fmt.Sprintf("Enum value %d (%s) was added", change.Number, change.Name)
+		if forward && change.NewEnum != nil && change.NewEnum.Closed {
+			// Old readers of a closed enum move unknown values to unknown fields
+			v.Level = ViolationLevelWarning
+			v.Suggestion = "Readers on the old schema will not see this value in closed enum fields."
+		}
 
-		// Allow optional → repeated in proto3
-		if oldField.Label == FieldLabelOptional && newField.Label == FieldLabelRepeated {
-			level = ViolationLevelWarning
-			wireBreaking = false
+	case ChangeServiceRemoved:
+		v.Rule = "SERVICE_REMOVED"
+		v.Level = ViolationLevelError
+		v.Category = CategoryServiceChange
+		v.Message = fmt.Sprintf("Service %s was removed", change.Name)
+		v.WireBreaking = true
+		v.SourceBreaking = true
+		v.Suggestion = "Do not remove services. Mark as deprecated instead."
+
+	case ChangeServiceAdded:
+		v.Rule = "SERVICE_ADDED"
+		v.Level = ViolationLevelInfo
+		v.Category = CategoryServiceChange
+		v.Message = fmt.Sprintf("Service %s was added", change.Name)
+
+	case ChangeMethodRemoved:
+		v.Rule = "RPC_REMOVED"
+		v.Level = ViolationLevelError
+		v.Category = CategoryServiceChange
+		v.Message = fmt.Sprintf("RPC method %s was removed", change.Name)
+		v.WireBreaking = true
+		v.SourceBreaking = true
+		v.Suggestion = "Do not remove RPC methods. Mark as deprecated instead."
+
+	case ChangeMethodAdded:
+		v.Rule = "RPC_ADDED"
+		v.Level = ViolationLevelInfo
+		v.Category = CategoryServiceChange
+		v.Message = fmt.Sprintf("RPC method %s was added", change.Name)
+
+	case ChangeMethodInputChanged, ChangeMethodOutputChanged:
+		direction := "input"
+		v.Rule = "RPC_INPUT_TYPE_CHANGED"
+		if change.Kind == ChangeMethodOutputChanged {
+			direction = "output"
+			v.Rule = "RPC_OUTPUT_TYPE_CHANGED"
+		}
+		v.Level = ViolationLevelError
+		v.Category = CategoryServiceChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("RPC %s %s type changed from %s to %s", change.Name, direction, change.OldValue, change.NewValue)
+		v.WireBreaking = true
+		v.SourceBreaking = true
+		v.Suggestion = "Create a new RPC method instead of changing types."
+
+	case ChangeMethodClientStreamingChanged, ChangeMethodServerStreamingChanged:
+		direction := "client"
+		v.Rule = "RPC_CLIENT_STREAMING_CHANGED"
+		if change.Kind == ChangeMethodServerStreamingChanged {
+			direction = "server"
+			v.Rule = "RPC_SERVER_STREAMING_CHANGED"
 		}
+		v.Level = ViolationLevelError
+		v.Category = CategoryServiceChange
+		v.OldValue, v.NewValue = change.OldValue, change.NewValue
+		v.Message = fmt.Sprintf("RPC %s %s streaming changed", change.Name, direction)
+		v.WireBreaking = true
+		v.SourceBreaking = true
+		v.Suggestion = "Cannot change streaming behavior. Create a new RPC method."
+
+	case ChangeReservedNumberReused:
+		v.Rule = "RESERVED_NUMBER_REUSED"
+		v.Level = ViolationLevelError
+		v.Category = CategoryReservedChange
+		v.NewValue = change.NewValue
+		v.Message = fmt.Sprintf("%s uses number %d, which was reserved", change.Name, change.Number)
+		v.WireBreaking = true
+		v.Suggestion = "Reserved numbers may still appear in stored or in-flight data. Use a new number."
+
+	case ChangeReservedNameReused:
+		v.Rule = "RESERVED_NAME_REUSED"
+		v.Level = ViolationLevelError
+		v.Category = CategoryReservedChange
+		v.NewValue = change.NewValue
+		v.Message = fmt.Sprintf("%s uses a name that was reserved", change.Name)
+		v.SourceBreaking = true
+		v.Suggestion = "Reserved names may still appear in JSON and text format data. Use a new name."
+
+	case ChangeReservedRemoved:
+		v.Rule = "RESERVED_REMOVED"
+		v.Level = ViolationLevelWarning
+		v.Category = CategoryReservedChange
+		v.OldValue = change.OldValue
+		v.Message = fmt.Sprintf("Reservation %s was removed from %s", change.OldValue, change.Name)
+		v.Suggestion = "Keep reservations so removed numbers and names cannot be reused."
+
+	case ChangeExtensionRangeRemoved:
+		v.Rule = "EXTENSION_RANGE_REMOVED"
+		v.Level = ViolationLevelError
+		v.Category = CategoryReservedChange
+		v.OldValue = change.OldValue
+		v.Message = fmt.Sprintf("Extension range %s was removed from %s", change.OldValue, change.Name)
+		v.SourceBreaking = true
+		v.Suggestion = "Extensions declared against the range no longer compile. Keep existing extension ranges."
+
+	case ChangeExtensionRangeAdded:
+		v.Rule = "EXTENSION_RANGE_ADDED"
+		v.Level = ViolationLevelInfo
+		v.Category = CategoryReservedChange
+		v.NewValue = change.NewValue
+		v.Message = fmt.Sprintf("Extension range %s was added to %s", change.NewValue, change.Name)
+
+	case ChangeExtensionRemoved:
+		v.Rule = "EXTENSION_REMOVED"
+		v.Level = ViolationLevelError
+		if forward {
+			v.Level = ViolationLevelWarning
+		}
+		v.Category = CategoryFieldChange
+		v.OldValue = change.OldValue
+		v.Message = fmt.Sprintf("Extension %s (%d) was removed", change.Name, change.Number)
+		v.SourceBreaking = true
+		v.Suggestion = "Keep the extension and mark it deprecated instead."
 
-		c.addViolation(Violation{
-			Rule:           "FIELD_LABEL_CHANGED",
-			Level:          level,
-			Category:       CategoryFieldChange,
-			Location:       location,
-			OldValue:       oldField.Label.String(),
-			NewValue:       newField.Label.String(),
-			Message:        fmt.Sprintf("Field %s label changed from %s to %s", oldField.Name, oldField.Label, newField.Label),
-			WireBreaking:   wireBreaking,
-			SourceBreaking: true,
-			Suggestion:     "Avoid changing field labels. Use new field numbers instead.",
-		})
-	}
+	case ChangeExtensionAdded:
+		v.Rule = "EXTENSION_ADDED"
+		v.Level = ViolationLevelInfo
+		v.Category = CategoryFieldChange
+		v.NewValue = change.NewValue
+		v.Message = fmt.Sprintf("Extension %s (%d) was added", change.Name, change.Number)
 
-	// Check oneof membership change
-	if oldField.InOneOf != newField.InOneOf {
-		c.addViolation(Violation{
-			Rule:           "FIELD_ONEOF_CHANGED",
-			Level:          ViolationLevelError,
-			Category:       CategoryFieldChange,
-			Location:       location,
-			OldValue:       oldField.InOneOf,
-			NewValue:       newField.InOneOf,
-			Message:        fmt.Sprintf("Field %s oneof membership changed", oldField.Name),
-			WireBreaking:   true,
-			SourceBreaking: true,
-			Suggestion:     "Do not move fields in/out of oneofs.",
-		})
+	default:
+		return
 	}
-}
 
-func (c *Comparator) compareNestedMessages(oldMsg, newMsg *Message) {
-	// Recursively compare nested messages
-	for nestedName, oldNested := range oldMsg.Nested {
-		if newNested, exists := newMsg.Nested[nestedName]; exists {
-			c.compareMessageFields(oldNested, newNested)
-			c.compareNestedMessages(oldNested, newNested)
-		}
-	}
+	c.addViolation(v)
 }
 
 // isTypeCompatible checks if two field types are wire-compatible
@@ -381,241 +553,6 @@ func (c *Comparator) isTypeCompatible(oldType, newType FieldType) bool {
 	return false
 }
 
-func (c *Comparator) compareEnums() {
-	// Check for removed enums
-	for enumName, oldEnum := range c.oldSchema.Enums {
-		if _, exists := c.newSchema.Enums[enumName]; !exists {
-			c.addViolation(Violation{
-				Rule:           "ENUM_REMOVED",
-				Level:          ViolationLevelError,
-				Category:       CategoryEnumChange,
-				Location:       enumName,
-				Message:        fmt.Sprintf("Enum %s was removed", enumName),
-				WireBreaking:   true,
-				SourceBreaking: true,
-				Suggestion:     "Do not remove enums. Mark as deprecated instead.",
-			})
-			continue
-		}
-
-		newEnum := c.newSchema.Enums[enumName]
-		c.compareEnumValues(oldEnum, newEnum)
-	}
-
-	// Check for added enums (info only)
-	for enumName := range c.newSchema.Enums {
-		if _, exists := c.oldSchema.Enums[enumName]; !exists {
-			c.addViolation(Violation{
-				Rule:           "ENUM_ADDED",
-				Level:          ViolationLevelInfo,
-				Category:       CategoryEnumChange,
-				Location:       enumName,
-				Message:        fmt.Sprintf("Enum %s was added", enumName),
-				WireBreaking:   false,
-				SourceBreaking: false,
-			})
-		}
-	}
-}
-
-func (c *Comparator) compareEnumValues(oldEnum, newEnum *Enum) {
-	// Check for removed enum values
-	for valueNum, oldValue := range oldEnum.Values {
-		if _, exists := newEnum.Values[valueNum]; !exists {
-			level := ViolationLevelError
-			if c.mode == CompatibilityModeForward || c.mode == CompatibilityModeForwardTransitive {
-				level = ViolationLevelWarning
-			}
-			c.addViolation(Violation{
-				Rule:           "ENUM_VALUE_REMOVED",
-				Level:          level,
-				Category:       CategoryEnumChange,
-				Location:       fmt.Sprintf("%s.%s", oldEnum.FullName, oldValue.Name),
-				Message:        fmt.Sprintf("Enum value %d (%s) was removed", valueNum, oldValue.Name),
-				WireBreaking:   false,
-				SourceBreaking: true,
-				Suggestion:     "Do not remove enum values. Mark as deprecated or reserve the number.",
-			})
-		}
-	}
-
-	// Check for changed enum value numbers (by name)
-	for valueName, oldValue := range oldEnum.ValuesByName {
-		if newValue, exists := newEnum.ValuesByName[valueName]; exists {
-			if oldValue.Number != newValue.Number {
-				c.addViolation(Violation{
-					Rule:           "ENUM_VALUE_NUMBER_CHANGED",
-					Level:          ViolationLevelError,
-					Category:       CategoryEnumChange,
-					Location:       fmt.Sprintf("%s.%s", oldEnum.FullName, valueName),
-					OldValue:       fmt.Sprintf("%d", oldValue.Number),
-					NewValue:       fmt.Sprintf("%d", newValue.Number),
-					Message:        fmt.Sprintf("Enum value %s number changed from %d to %d", valueName, oldValue.Number, newValue.Number),
-					WireBreaking:   true,
-					SourceBreaking: true,
-					Suggestion:     "Never change enum value numbers.",
-				})
-			}
-		}
-	}
-
-	// Check for added enum values (info)
-	for valueNum, newValue := range newEnum.Values {
-		if _, exists := oldEnum.Values[valueNum]; !exists {
-			c.addViolation(Violation{
-				Rule:           "ENUM_VALUE_ADDED",
-				Level:          ViolationLevelInfo,
-				Category:       CategoryEnumChange,
-				Location:       fmt.Sprintf("%s.%s", newEnum.FullName, newValue.Name),
-				Message:        fmt.Sprintf("Enum value %d (%s) was added", valueNum, newValue.Name),
-				WireBreaking:   false,
-				SourceBreaking: false,
-			})
-		}
-	}
-}
-
-func (c *Comparator) compareServices() {
-	// Check for removed services
-	for svcName, oldSvc := range c.oldSchema.Services {
-		if _, exists := c.newSchema.Services[svcName]; !exists {
-			c.addViolation(Violation{
-				Rule:           "SERVICE_REMOVED",
-				Level:          ViolationLevelError,
-				Category:       CategoryServiceChange,
-				Location:       svcName,
-				Message:        fmt.Sprintf("Service %s was removed", svcName),
-				WireBreaking:   true,
-				SourceBreaking: true,
-				Suggestion:     "Do not remove services. Mark as deprecated instead.",
-			})
-			continue
-		}
-
-		newSvc := c.newSchema.Services[svcName]
-		c.compareServiceMethods(oldSvc, newSvc)
-	}
-
-	// Check for added services (info)
-	for svcName := range c.newSchema.Services {
-		if _, exists := c.oldSchema.Services[svcName]; !exists {
-			c.addViolation(Violation{
-				Rule:           "SERVICE_ADDED",
-				Level:          ViolationLevelInfo,
-				Category:       CategoryServiceChange,
-				Location:       svcName,
-				Message:        fmt.Sprintf("Service %s was added", svcName),
-				WireBreaking:   false,
-				SourceBreaking: false,
-			})
-		}
-	}
-}
-
-func (c *Comparator) compareServiceMethods(oldSvc, newSvc *Service) {
-	// Check for removed methods
-	for methodName, oldMethod := range oldSvc.Methods {
-		if _, exists := newSvc.Methods[methodName]; !exists {
-			c.addViolation(Violation{
-				Rule:           "RPC_REMOVED",
-				Level:          ViolationLevelError,
-				Category:       CategoryServiceChange,
-				Location:       fmt.Sprintf("%s.%s", oldSvc.FullName, methodName),
-				Message:        fmt.Sprintf("RPC method %s was removed", methodName),
-				WireBreaking:   true,
-				SourceBreaking: true,
-				Suggestion:     "Do not remove RPC methods. Mark as deprecated instead.",
-			})
-			continue
-		}
-
-		newMethod := newSvc.Methods[methodName]
-		c.compareMethod(oldSvc.FullName, oldMethod, newMethod)
-	}
-
-	// Check for added methods (info)
-	for methodName := range newSvc.Methods {
-		if _, exists := oldSvc.Methods[methodName]; !exists {
-			c.addViolation(Violation{
-				Rule:           "RPC_ADDED",
-				Level:          ViolationLevelInfo,
-				Category:       CategoryServiceChange,
-				Location:       fmt.Sprintf("%s.%s", newSvc.FullName, methodName),
-				Message:        fmt.Sprintf("RPC method %s was added", methodName),
-				WireBreaking:   false,
-				SourceBreaking: false,
-			})
-		}
-	}
-}
-
-func (c *Comparator) compareMethod(svcName string, oldMethod, newMethod *Method) {
-	location := fmt.Sprintf("%s.%s", svcName, oldMethod.Name)
-
-	// Check input type change
-	if oldMethod.InputType != newMethod.InputType {
-		c.addViolation(Violation{
-			Rule:           "RPC_INPUT_TYPE_CHANGED",
-			Level:          ViolationLevelError,
-			Category:       CategoryServiceChange,
-			Location:       location,
-			OldValue:       oldMethod.InputType,
-			NewValue:       newMethod.InputType,
-			Message:        fmt.Sprintf("RPC %s input type changed from %s to %s", oldMethod.Name, oldMethod.InputType, newMethod.InputType),
-			WireBreaking:   true,
-			SourceBreaking: true,
-			Suggestion:     "Create a new RPC method instead of changing types.",
-		})
-	}
-
-	// Check output type change
-	if oldMethod.OutputType != newMethod.OutputType {
-		c.addViolation(Violation{
-			Rule:           "RPC_OUTPUT_TYPE_CHANGED",
-			Level:          ViolationLevelError,
-			Category:       CategoryServiceChange,
-			Location:       location,
-			OldValue:       oldMethod.OutputType,
-			NewValue:       newMethod.OutputType,
-			Message:        fmt.Sprintf("RPC %s output type changed from %s to %s", oldMethod.Name, oldMethod.OutputType, newMethod.OutputType),
-			WireBreaking:   true,
-			SourceBreaking: true,
-			Suggestion:     "Create a new RPC method instead of changing types.",
-		})
-	}
-
-	// Check streaming changes
-	if oldMethod.ClientStreaming != newMethod.ClientStreaming {
-		c.addViolation(Violation{
-			Rule:           "RPC_CLIENT_STREAMING_CHANGED",
-			Level:          ViolationLevelError,
-			Category:       CategoryServiceChange,
-			Location:       location,
-			OldValue:       fmt.Sprintf("%v", oldMethod.ClientStreaming),
-			NewValue:       fmt.Sprintf("%v", newMethod.ClientStreaming),
-			Message:        fmt.Sprintf("RPC %s client streaming changed", oldMethod.Name),
-			WireBreaking:   true,
-			SourceBreaking: true,
-			Suggestion:     "Cannot change streaming behavior. Create a new RPC method.",
-		})
-	}
-
-	if oldMethod.ServerStreaming != newMethod.ServerStreaming {
-		c.addViolation(Violation{
-			Rule:           "RPC_SERVER_STREAMING_CHANGED",
-			Level:          ViolationLevelError,
-			Category:       CategoryServiceChange,
-			Location:       location,
-			OldValue:       fmt.Sprintf("%v", oldMethod.ServerStreaming),
-			NewValue:       fmt.Sprintf("%v", newMethod.ServerStreaming),
-			Message:        fmt.Sprintf("RPC %s server streaming changed", oldMethod.Name),
-			WireBreaking:   true,
-			SourceBreaking: true,
-			Suggestion:     "Cannot change streaming behavior. Create a new RPC method.",
-		})
-	}
-}
-
 func (c *Comparator) addViolation(v Violation) {
 	c.violations = append(c.violations, v)
 }
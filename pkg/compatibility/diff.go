@@ -0,0 +1,785 @@
+package compatibility
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ChangeKind identifies a single structural difference between two schemas
+type ChangeKind string
+
+const (
+	ChangePackageChanged               ChangeKind = "package_changed"
+	ChangeSyntaxChanged                ChangeKind = "syntax_changed"
+	ChangeImportAdded                  ChangeKind = "import_added"
+	ChangeImportRemoved                ChangeKind = "import_removed"
+	ChangeMessageAdded                 ChangeKind = "message_added"
+	ChangeMessageRemoved               ChangeKind = "message_removed"
+	ChangeFieldAdded                   ChangeKind = "field_added"
+	ChangeFieldRemoved                 ChangeKind = "field_removed"
+	ChangeFieldRenamed                 ChangeKind = "field_renamed"
+	ChangeFieldTypeChanged             ChangeKind = "type_changed"
+	ChangeFieldLabelChanged            ChangeKind = "label_changed"
+	ChangeFieldNumberChanged           ChangeKind = "field_number_changed"
+	ChangeFieldOneOfChanged            ChangeKind = "oneof_changed"
+	ChangeFieldPresenceChanged         ChangeKind = "presence_changed"
+	ChangeFieldEncodingChanged         ChangeKind = "message_encoding_changed"
+	ChangeFieldPackedChanged           ChangeKind = "packed_changed"
+	ChangeFieldUTF8ValidationChanged   ChangeKind = "utf8_validation_changed"
+	ChangeEnumAdded                    ChangeKind = "enum_added"
+	ChangeEnumRemoved                  ChangeKind = "enum_removed"
+	ChangeEnumValueAdded               ChangeKind = "enum_value_added"
+	ChangeEnumValueRemoved             ChangeKind = "enum_value_removed"
+	ChangeEnumValueNumberChanged       ChangeKind = "enum_value_number_changed"
+	ChangeEnumTypeChanged              ChangeKind = "enum_type_changed"
+	ChangeServiceAdded                 ChangeKind = "service_added"
+	ChangeServiceRemoved               ChangeKind = "service_removed"
+	ChangeMethodAdded                  ChangeKind = "method_added"
+	ChangeMethodRemoved                ChangeKind = "method_removed"
+	ChangeMethodInputChanged           ChangeKind = "method_input_changed"
+	ChangeMethodOutputChanged          ChangeKind = "method_output_changed"
+	ChangeMethodClientStreamingChanged ChangeKind = "method_client_streaming_changed"
+	ChangeMethodServerStreamingChanged ChangeKind = "method_server_streaming_changed"
+	ChangeReservedNumberReused         ChangeKind = "reserved_number_reused"
+	ChangeReservedNameReused           ChangeKind = "reserved_name_reused"
+	ChangeReservedRemoved              ChangeKind = "reserved_removed"
+	ChangeExtensionRangeAdded          ChangeKind = "extension_range_added"
+	ChangeExtensionRangeRemoved        ChangeKind = "extension_range_removed"
+	ChangeExtensionAdded               ChangeKind = "extension_added"
+	ChangeExtensionRemoved             ChangeKind = "extension_removed"
+)
+
+// Change is one structural difference between an old and a new SchemaGraph.
+// Changes carry no verdict; the Comparator derives violations from them
+// according to the compatibility mode. File and Line point into the new
+// schema, except for removals, which point at the old declaration.
+type Change struct {
+	Kind     ChangeKind
+	Location string // Fully qualified element, e.g. pkg.User.email
+	File     string // Source file of the element, when known
+	Line     int    // Line of the element in File, 0 when unknown
+	Name     string // Element name (field, value, method, ...)
+	Number   int    // Field or enum value number, when applicable
+	OldValue string
+	NewValue string
+
+	// Field and enum details are kept for rules that depend on more than the values above
+	OldField *Field
+	NewField *Field
+	OldEnum  *Enum
+	NewEnum  *Enum
+}
+
+// SchemaDiff is the ordered list of structural changes between two schemas
+type SchemaDiff struct {
+	Changes []Change
+}
+
+// Diff computes the structural differences between two schema graphs.
+// The result is deterministic: elements are visited in sorted order.
+func Diff(oldSchema, newSchema *SchemaGraph) *SchemaDiff {
+	d := &SchemaDiff{Changes: make([]Change, 0)}
+
+	if oldSchema.Package != newSchema.Package {
+		d.add(Change{
+			Kind:     ChangePackageChanged,
+			Location: "package",
+			OldValue: oldSchema.Package,
+			NewValue: newSchema.Package,
+		})
+	}
+
+	if oldSyntax, newSyntax := syntaxLabel(oldSchema), syntaxLabel(newSchema); oldSyntax != newSyntax {
+		d.add(Change{
+			Kind:     ChangeSyntaxChanged,
+			Location: "syntax",
+			OldValue: oldSyntax,
+			NewValue: newSyntax,
+		})
+	}
+
+	d.diffImports(oldSchema.Imports, newSchema.Imports)
+	d.diffMessages(oldSchema.Messages, newSchema.Messages)
+	d.diffEnums(oldSchema.Enums, newSchema.Enums)
+	d.diffServices(oldSchema.Services, newSchema.Services)
+	d.diffExtensions(oldSchema.Extensions, newSchema.Extensions)
+
+	return d
+}
+
+// Filter returns the changes of the given kinds
+func (d *SchemaDiff) Filter(kinds ...ChangeKind) []Change {
+	filtered := make([]Change, 0)
+	for _, change := range d.Changes {
+		for _, kind := range kinds {
+			if change.Kind == kind {
+				filtered = append(filtered, change)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// syntaxLabel names the syntax of a schema, e.g. "proto3" or "edition 2023"
+func syntaxLabel(schema *SchemaGraph) string {
+	if schema.Syntax == "editions" && schema.Edition != "" {
+		return "edition " + schema.Edition
+	}
+	return schema.Syntax
+}
+
+func (d *SchemaDiff) add(c Change) {
+	d.Changes = append(d.Changes, c)
+}
+
+func (d *SchemaDiff) diffImports(oldImports, newImports []Import) {
+	oldByPath := make(map[string]Import, len(oldImports))
+	for _, imp := range oldImports {
+		oldByPath[imp.Path] = imp
+	}
+	newByPath := make(map[string]Import, len(newImports))
+	for _, imp := range newImports {
+		newByPath[imp.Path] = imp
+	}
+
+	for _, path := range sortedKeys(oldByPath) {
+		if _, exists := newByPath[path]; !exists {
+			old := oldByPath[path]
+			d.add(Change{
+				Kind:     ChangeImportRemoved,
+				Location: path,
+				Name:     path,
+				OldValue: importModifier(old),
+			})
+		}
+	}
+	for _, path := range sortedKeys(newByPath) {
+		if _, exists := oldByPath[path]; !exists {
+			d.add(Change{
+				Kind:     ChangeImportAdded,
+				Location: path,
+				Name:     path,
+				NewValue: importModifier(newByPath[path]),
+			})
+		}
+	}
+}
+
+func importModifier(imp Import) string {
+	switch {
+	case imp.Public:
+		return "public"
+	case imp.Weak:
+		return "weak"
+	default:
+		return ""
+	}
+}
+
+func (d *SchemaDiff) diffMessages(oldMessages, newMessages map[string]*Message) {
+	for _, name := range sortedKeys(oldMessages) {
+		oldMsg := oldMessages[name]
+		newMsg, exists := newMessages[name]
+		if !exists {
+			d.add(Change{
+				Kind:     ChangeMessageRemoved,
+				Location: messageLocation(oldMsg, name),
+				File:     oldMsg.File,
+				Line:     oldMsg.Line,
+				Name:     name,
+				OldValue: name,
+			})
+			continue
+		}
+
+		d.diffFields(oldMsg, newMsg)
+		d.diffReserved(messageLocation(newMsg, name), newMsg.File, newMsg.Line, oldMsg.Reserved, newMsg.Reserved, fieldUses(newMsg))
+		d.diffExtensionRanges(messageLocation(newMsg, name), newMsg, oldMsg.ExtensionRanges, newMsg.ExtensionRanges)
+		d.diffMessages(oldMsg.Nested, newMsg.Nested)
+		d.diffEnums(oldMsg.NestedEnums, newMsg.NestedEnums)
+	}
+
+	for _, name := range sortedKeys(newMessages) {
+		if _, exists := oldMessages[name]; !exists {
+			newMsg := newMessages[name]
+			d.add(Change{
+				Kind:     ChangeMessageAdded,
+				Location: messageLocation(newMsg, name),
+				File:     newMsg.File,
+				Line:     newMsg.Line,
+				Name:     name,
+				NewValue: name,
+			})
+		}
+	}
+}
+
+func messageLocation(msg *Message, key string) string {
+	if msg.FullName != "" {
+		return msg.FullName
+	}
+	return key
+}
+
+// diffFields compares fields by number, which is what the wire format sees.
+// A field that keeps its name but moves to a different number is reported
+// as a number change in addition to the removal and addition.
+func (d *SchemaDiff) diffFields(oldMsg, newMsg *Message) {
+	for _, num := range sortedKeys(oldMsg.Fields) {
+		oldField := oldMsg.Fields[num]
+		location := fmt.Sprintf("%s.%s", oldMsg.FullName, oldField.Name)
+
+		newField, exists := newMsg.Fields[num]
+		if !exists {
+			d.add(Change{
+				Kind:     ChangeFieldRemoved,
+				Location: location,
+				File:     oldMsg.File,
+				Line:     oldField.Line,
+				Name:     oldField.Name,
+				Number:   num,
+				OldValue: fieldSignature(oldField),
+				OldField: oldField,
+			})
+			if moved, ok := newMsg.FieldsByName[oldField.Name]; ok && moved.Number != num {
+				d.add(Change{
+					Kind:     ChangeFieldNumberChanged,
+					Location: location,
+					File:     newMsg.File,
+					Line:     moved.Line,
+					Name:     oldField.Name,
+					Number:   num,
+					OldValue: fmt.Sprintf("%d", num),
+					NewValue: fmt.Sprintf("%d", moved.Number),
+					OldField: oldField,
+					NewField: moved,
+				})
+			}
+			continue
+		}
+
+		d.diffField(newMsg.File, location, oldField, newField)
+	}
+
+	for _, num := range sortedKeys(newMsg.Fields) {
+		if _, exists := oldMsg.Fields[num]; !exists {
+			newField := newMsg.Fields[num]
+			d.add(Change{
+				Kind:     ChangeFieldAdded,
+				Location: fmt.Sprintf("%s.%s", newMsg.FullName, newField.Name),
+				File:     newMsg.File,
+				Line:     newField.Line,
+				Name:     newField.Name,
+				Number:   num,
+				NewValue: fieldSignature(newField),
+				NewField: newField,
+			})
+		}
+	}
+}
+
+func (d *SchemaDiff) diffField(file, location string, oldField, newField *Field) {
+	base := Change{
+		Location: location,
+		File:     file,
+		Line:     newField.Line,
+		Name:     oldField.Name,
+		Number:   oldField.Number,
+		OldField: oldField,
+		NewField: newField,
+	}
+
+	if oldField.Name != newField.Name {
+		c := base
+		c.Kind = ChangeFieldRenamed
+		c.OldValue, c.NewValue = oldField.Name, newField.Name
+		d.add(c)
+	}
+	if oldField.Type != newField.Type {
+		c := base
+		c.Kind = ChangeFieldTypeChanged
+		c.OldValue, c.NewValue = oldField.Type.String(), newField.Type.String()
+		d.add(c)
+	}
+	if oldField.Label != newField.Label {
+		c := base
+		c.Kind = ChangeFieldLabelChanged
+		c.OldValue, c.NewValue = oldField.Label.String(), newField.Label.String()
+		d.add(c)
+	}
+	if oldField.InOneOf != newField.InOneOf {
+		c := base
+		c.Kind = ChangeFieldOneOfChanged
+		c.OldValue, c.NewValue = oldField.InOneOf, newField.InOneOf
+		d.add(c)
+	}
+
+	// Feature changes; presence of required and repeated fields is covered
+	// by label changes
+	if oldField.Label == FieldLabelOptional && newField.Label == FieldLabelOptional &&
+		oldField.Presence != newField.Presence {
+		c := base
+		c.Kind = ChangeFieldPresenceChanged
+		c.OldValue, c.NewValue = oldField.Presence.String(), newField.Presence.String()
+		d.add(c)
+	}
+	if oldField.Delimited != newField.Delimited {
+		c := base
+		c.Kind = ChangeFieldEncodingChanged
+		c.OldValue, c.NewValue = messageEncoding(oldField), messageEncoding(newField)
+		d.add(c)
+	}
+	if oldField.Packed != nil && newField.Packed != nil && *oldField.Packed != *newField.Packed {
+		c := base
+		c.Kind = ChangeFieldPackedChanged
+		c.OldValue, c.NewValue = fmt.Sprintf("%v", *oldField.Packed), fmt.Sprintf("%v", *newField.Packed)
+		d.add(c)
+	}
+	if oldField.Type == FieldTypeString && newField.Type == FieldTypeString &&
+		oldField.ValidateUTF8 != newField.ValidateUTF8 {
+		c := base
+		c.Kind = ChangeFieldUTF8ValidationChanged
+		c.OldValue, c.NewValue = utf8Validation(oldField), utf8Validation(newField)
+		d.add(c)
+	}
+}
+
+func messageEncoding(f *Field) string {
+	if f.Delimited {
+		return "delimited"
+	}
+	return "length_prefixed"
+}
+
+func utf8Validation(f *Field) string {
+	if f.ValidateUTF8 {
+		return "verify"
+	}
+	return "none"
+}
+
+func enumType(e *Enum) string {
+	if e.Closed {
+		return "closed"
+	}
+	return "open"
+}
+
+func fieldSignature(f *Field) string {
+	typeName := f.TypeName
+	if typeName == "" {
+		typeName = f.Type.String()
+	}
+	return fmt.Sprintf("%s %s = %d", typeName, f.Name, f.Number)
+}
+
+func (d *SchemaDiff) diffEnums(oldEnums, newEnums map[string]*Enum) {
+	for _, name := range sortedKeys(oldEnums) {
+		oldEnum := oldEnums[name]
+		newEnum, exists := newEnums[name]
+		if !exists {
+			d.add(Change{
+				Kind:     ChangeEnumRemoved,
+				Location: enumLocation(oldEnum, name),
+				File:     oldEnum.File,
+				Line:     oldEnum.Line,
+				Name:     name,
+				OldValue: name,
+			})
+			continue
+		}
+		if oldEnum.Closed != newEnum.Closed {
+			d.add(Change{
+				Kind:     ChangeEnumTypeChanged,
+				Location: enumLocation(newEnum, name),
+				File:     newEnum.File,
+				Line:     newEnum.Line,
+				Name:     name,
+				OldValue: enumType(oldEnum),
+				NewValue: enumType(newEnum),
+				OldEnum:  oldEnum,
+				NewEnum:  newEnum,
+			})
+		}
+		d.diffEnumValues(oldEnum, newEnum)
+		d.diffReserved(enumLocation(newEnum, name), newEnum.File, newEnum.Line, oldEnum.Reserved, newEnum.Reserved, enumValueUses(newEnum))
+	}
+
+	for _, name := range sortedKeys(newEnums) {
+		if _, exists := oldEnums[name]; !exists {
+			newEnum := newEnums[name]
+			d.add(Change{
+				Kind:     ChangeEnumAdded,
+				Location: enumLocation(newEnum, name),
+				File:     newEnum.File,
+				Line:     newEnum.Line,
+				Name:     name,
+				NewValue: name,
+			})
+		}
+	}
+}
+
+func enumLocation(enum *Enum, key string) string {
+	if enum.FullName != "" {
+		return enum.FullName
+	}
+	return key
+}
+
+func (d *SchemaDiff) diffEnumValues(oldEnum, newEnum *Enum) {
+	for _, num := range sortedKeys(oldEnum.Values) {
+		if _, exists := newEnum.Values[num]; !exists {
+			oldValue := oldEnum.Values[num]
+			d.add(Change{
+				Kind:     ChangeEnumValueRemoved,
+				Location: fmt.Sprintf("%s.%s", oldEnum.FullName, oldValue.Name),
+				File:     oldEnum.File,
+				Line:     oldValue.Line,
+				Name:     oldValue.Name,
+				Number:   num,
+				OldValue: oldValue.Name,
+			})
+		}
+	}
+
+	for _, name := range sortedKeys(oldEnum.ValuesByName) {
+		oldValue := oldEnum.ValuesByName[name]
+		if newValue, exists := newEnum.ValuesByName[name]; exists && oldValue.Number != newValue.Number {
+			d.add(Change{
+				Kind:     ChangeEnumValueNumberChanged,
+				Location: fmt.Sprintf("%s.%s", oldEnum.FullName, name),
+				File:     newEnum.File,
+				Line:     newValue.Line,
+				Name:     name,
+				Number:   oldValue.Number,
+				OldValue: fmt.Sprintf("%d", oldValue.Number),
+				NewValue: fmt.Sprintf("%d", newValue.Number),
+			})
+		}
+	}
+
+	for _, num := range sortedKeys(newEnum.Values) {
+		if _, exists := oldEnum.Values[num]; !exists {
+			newValue := newEnum.Values[num]
+			d.add(Change{
+				Kind:     ChangeEnumValueAdded,
+				Location: fmt.Sprintf("%s.%s", newEnum.FullName, newValue.Name),
+				File:     newEnum.File,
+				Line:     newValue.Line,
+				Name:     newValue.Name,
+				Number:   num,
+				NewValue: newValue.Name,
+				OldEnum:  oldEnum,
+				NewEnum:  newEnum,
+			})
+		}
+	}
+}
+
+// reservedUse is a field or enum value checked against the reservations of
+// the previous version
+type reservedUse struct {
+	name   string
+	number int
+	line   int
+}
+
+func fieldUses(msg *Message) []reservedUse {
+	uses := make([]reservedUse, 0, len(msg.Fields))
+	for _, num := range sortedKeys(msg.Fields) {
+		f := msg.Fields[num]
+		uses = append(uses, reservedUse{name: f.Name, number: f.Number, line: f.Line})
+	}
+	return uses
+}
+
+func enumValueUses(enum *Enum) []reservedUse {
+	uses := make([]reservedUse, 0, len(enum.Values))
+	for _, num := range sortedKeys(enum.Values) {
+		v := enum.Values[num]
+		uses = append(uses, reservedUse{name: v.Name, number: v.Number, line: v.Line})
+	}
+	return uses
+}
+
+// diffReserved reports new fields or enum values that take a number or name
+// the old version reserved, and reservations that were dropped without
+// being reused
+func (d *SchemaDiff) diffReserved(location, file string, line int, oldReserved, newReserved *Reserved, uses []reservedUse) {
+	if oldReserved == nil {
+		return
+	}
+
+	reusedNumbers := make(map[int]bool)
+	reusedNames := make(map[string]bool)
+	for _, use := range uses {
+		if oldReserved.ContainsNumber(use.number) {
+			reusedNumbers[use.number] = true
+			d.add(Change{
+				Kind:     ChangeReservedNumberReused,
+				Location: location + "." + use.name,
+				File:     file,
+				Line:     use.line,
+				Name:     use.name,
+				Number:   use.number,
+				NewValue: fmt.Sprintf("%d", use.number),
+			})
+		}
+		if oldReserved.ContainsName(use.name) {
+			reusedNames[use.name] = true
+			d.add(Change{
+				Kind:     ChangeReservedNameReused,
+				Location: location + "." + use.name,
+				File:     file,
+				Line:     use.line,
+				Name:     use.name,
+				Number:   use.number,
+				NewValue: use.name,
+			})
+		}
+	}
+
+	newIntervals := newReserved.intervals()
+	for _, rng := range oldReserved.intervals() {
+		if coversRange(newIntervals, rng) || rangeReused(rng, reusedNumbers) {
+			continue
+		}
+		d.add(Change{
+			Kind:     ChangeReservedRemoved,
+			Location: location,
+			File:     file,
+			Line:     line,
+			Name:     location,
+			OldValue: rangeLabel(rng),
+		})
+	}
+	for _, name := range oldReserved.Names {
+		if newReserved.ContainsName(name) || reusedNames[name] {
+			continue
+		}
+		d.add(Change{
+			Kind:     ChangeReservedRemoved,
+			Location: location,
+			File:     file,
+			Line:     line,
+			Name:     location,
+			OldValue: fmt.Sprintf("%q", name),
+		})
+	}
+}
+
+// rangeReused reports whether a number of rng is reused; that change is
+// reported on its own
+func rangeReused(rng [2]int, reused map[int]bool) bool {
+	for num := range reused {
+		if num >= rng[0] && num <= rng[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeLabel formats an inclusive range as in proto source, e.g. "5" or "10 to 20"
+func rangeLabel(rng [2]int) string {
+	if rng[0] == rng[1] {
+		return fmt.Sprintf("%d", rng[0])
+	}
+	if rng[1] == maxFieldNumber {
+		return fmt.Sprintf("%d to max", rng[0])
+	}
+	return fmt.Sprintf("%d to %d", rng[0], rng[1])
+}
+
+// maxFieldNumber is the largest field number, written as max in ranges
+const maxFieldNumber = 536870911
+
+// diffExtensionRanges reports extension ranges that were removed or narrowed,
+// which breaks extensions declared against the old ranges, and new ones
+func (d *SchemaDiff) diffExtensionRanges(location string, newMsg *Message, oldRanges, newRanges [][2]int) {
+	for _, rng := range oldRanges {
+		if coversRange(newRanges, rng) {
+			continue
+		}
+		d.add(Change{
+			Kind:     ChangeExtensionRangeRemoved,
+			Location: location,
+			File:     newMsg.File,
+			Line:     newMsg.Line,
+			Name:     location,
+			OldValue: rangeLabel(rng),
+		})
+	}
+	for _, rng := range newRanges {
+		if coversRange(oldRanges, rng) {
+			continue
+		}
+		d.add(Change{
+			Kind:     ChangeExtensionRangeAdded,
+			Location: location,
+			File:     newMsg.File,
+			Line:     newMsg.Line,
+			Name:     location,
+			NewValue: rangeLabel(rng),
+		})
+	}
+}
+
+// diffExtensions compares extensions by fully qualified name. Extensions
+// kept under the same name are compared like regular fields.
+func (d *SchemaDiff) diffExtensions(oldExts, newExts map[string]*Extension) {
+	for _, name := range sortedKeys(oldExts) {
+		oldExt := oldExts[name]
+		newExt, exists := newExts[name]
+		if !exists {
+			d.add(Change{
+				Kind:     ChangeExtensionRemoved,
+				Location: name,
+				File:     oldExt.File,
+				Line:     oldExt.Line,
+				Name:     name,
+				Number:   oldExt.Number,
+				OldValue: extensionSignature(oldExt),
+				OldField: &oldExt.Field,
+			})
+			continue
+		}
+
+		if oldExt.Number != newExt.Number {
+			d.add(Change{
+				Kind:     ChangeFieldNumberChanged,
+				Location: name,
+				File:     newExt.File,
+				Line:     newExt.Line,
+				Name:     name,
+				Number:   oldExt.Number,
+				OldValue: fmt.Sprintf("%d", oldExt.Number),
+				NewValue: fmt.Sprintf("%d", newExt.Number),
+				OldField: &oldExt.Field,
+				NewField: &newExt.Field,
+			})
+		}
+		d.diffField(newExt.File, name, &oldExt.Field, &newExt.Field)
+	}
+
+	for _, name := range sortedKeys(newExts) {
+		if _, exists := oldExts[name]; !exists {
+			newExt := newExts[name]
+			d.add(Change{
+				Kind:     ChangeExtensionAdded,
+				Location: name,
+				File:     newExt.File,
+				Line:     newExt.Line,
+				Name:     name,
+				Number:   newExt.Number,
+				NewValue: extensionSignature(newExt),
+				NewField: &newExt.Field,
+			})
+		}
+	}
+}
+
+func extensionSignature(ext *Extension) string {
+	return fmt.Sprintf("extend %s { %s }", ext.Extendee, fieldSignature(&ext.Field))
+}
+
+func (d *SchemaDiff) diffServices(oldServices, newServices map[string]*Service) {
+	for _, name := range sortedKeys(oldServices) {
+		oldSvc := oldServices[name]
+		newSvc, exists := newServices[name]
+		if !exists {
+			d.add(Change{
+				Kind:     ChangeServiceRemoved,
+				Location: name,
+				File:     oldSvc.File,
+				Line:     oldSvc.Line,
+				Name:     name,
+				OldValue: name,
+			})
+			continue
+		}
+		d.diffMethods(oldSvc, newSvc)
+	}
+
+	for _, name := range sortedKeys(newServices) {
+		if _, exists := oldServices[name]; !exists {
+			d.add(Change{
+				Kind:     ChangeServiceAdded,
+				Location: name,
+				File:     newServices[name].File,
+				Line:     newServices[name].Line,
+				Name:     name,
+				NewValue: name,
+			})
+		}
+	}
+}
+
+func (d *SchemaDiff) diffMethods(oldSvc, newSvc *Service) {
+	for _, name := range sortedKeys(oldSvc.Methods) {
+		oldMethod := oldSvc.Methods[name]
+		location := fmt.Sprintf("%s.%s", oldSvc.FullName, name)
+
+		newMethod, exists := newSvc.Methods[name]
+		if !exists {
+			d.add(Change{
+				Kind:     ChangeMethodRemoved,
+				Location: location,
+				File:     oldSvc.File,
+				Line:     oldMethod.Line,
+				Name:     name,
+				OldValue: name,
+			})
+			continue
+		}
+
+		base := Change{Location: location, File: newSvc.File, Line: newMethod.Line, Name: name}
+		if oldMethod.InputType != newMethod.InputType {
+			c := base
+			c.Kind = ChangeMethodInputChanged
+			c.OldValue, c.NewValue = oldMethod.InputType, newMethod.InputType
+			d.add(c)
+		}
+		if oldMethod.OutputType != newMethod.OutputType {
+			c := base
+			c.Kind = ChangeMethodOutputChanged
+			c.OldValue, c.NewValue = oldMethod.OutputType, newMethod.OutputType
+			d.add(c)
+		}
+		if oldMethod.ClientStreaming != newMethod.ClientStreaming {
+			c := base
+			c.Kind = ChangeMethodClientStreamingChanged
+			c.OldValue, c.NewValue = fmt.Sprintf("%v", oldMethod.ClientStreaming), fmt.Sprintf("%v", newMethod.ClientStreaming)
+			d.add(c)
+		}
+		if oldMethod.ServerStreaming != newMethod.ServerStreaming {
+			c := base
+			c.Kind = ChangeMethodServerStreamingChanged
+			c.OldValue, c.NewValue = fmt.Sprintf("%v", oldMethod.ServerStreaming), fmt.Sprintf("%v", newMethod.ServerStreaming)
+			d.add(c)
+		}
+	}
+
+	for _, name := range sortedKeys(newSvc.Methods) {
+		if _, exists := oldSvc.Methods[name]; !exists {
+			d.add(Change{
+				Kind:     ChangeMethodAdded,
+				Location: fmt.Sprintf("%s.%s", newSvc.FullName, name),
+				File:     newSvc.File,
+				Line:     newSvc.Methods[name].Line,
+				Name:     name,
+				NewValue: name,
+			})
+		}
+	}
+}
+
+func sortedKeys[K int | string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
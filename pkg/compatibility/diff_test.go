@@ -0,0 +1,248 @@
+package compatibility
+
+import (
+	"testing"
+)
+
+const diffOldProto = `syntax = "proto3";
+package shop.v1;
+
+message Order {
+  string id = 1;
+  string note = 2;
+  int32 quantity = 3;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OPEN = 1;
+  STATUS_CLOSED = 2;
+}
+
+service OrderService {
+  rpc GetOrder(Order) returns (Order);
+  rpc DeleteOrder(Order) returns (Order);
+}
+`
+
+const diffNewProto = `syntax = "proto3";
+package shop.v1;
+
+message Order {
+  string id = 1;
+  int64 quantity = 3;
+  string customer = 4;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OPEN = 1;
+}
+
+service OrderService {
+  rpc GetOrder(Order) returns (Order);
+}
+`
+
+func TestDiff_Kinds(t *testing.T) {
+	oldSchema, err := ParseSchemaFiles([]SourceFile{{Path: "shop/order.proto", Content: diffOldProto}})
+	if err != nil {
+		t.Fatalf("ParseSchemaFiles(old) error = %v", err)
+	}
+	newSchema, err := ParseSchemaFiles([]SourceFile{{Path: "shop/order.proto", Content: diffNewProto}})
+	if err != nil {
+		t.Fatalf("ParseSchemaFiles(new) error = %v", err)
+	}
+
+	diff := Diff(oldSchema, newSchema)
+
+	want := map[ChangeKind]string{
+		ChangeFieldRemoved:     "shop.v1.Order.note",
+		ChangeFieldTypeChanged: "shop.v1.Order.quantity",
+		ChangeFieldAdded:       "shop.v1.Order.customer",
+		ChangeEnumValueRemoved: "shop.v1.Status.STATUS_CLOSED",
+		ChangeMethodRemoved:    "shop.v1.OrderService.DeleteOrder",
+	}
+	for kind, location := range want {
+		changes := diff.Filter(kind)
+		if len(changes) != 1 {
+			t.Errorf("Filter(%s) returned %d changes, want 1", kind, len(changes))
+			continue
+		}
+		if changes[0].Location != location {
+			t.Errorf("%s location = %q, want %q", kind, changes[0].Location, location)
+		}
+		if changes[0].File != "shop/order.proto" {
+			t.Errorf("%s file = %q, want shop/order.proto", kind, changes[0].File)
+		}
+	}
+
+	if len(diff.Changes) != len(want) {
+		t.Errorf("Diff() returned %d changes, want %d: %+v", len(diff.Changes), len(want), diff.Changes)
+	}
+}
+
+func TestDiff_SameNameInPackages(t *testing.T) {
+	billing := "syntax = \"proto3\";\npackage billing.v1;\n\nmessage Order {\n  string id = 1;\n}\n\nenum Status {\n  STATUS_UNSPECIFIED = 0;\n}\n"
+	oldSchema, err := ParseSchemaFiles([]SourceFile{
+		{Path: "shop/order.proto", Content: diffOldProto},
+		{Path: "billing/order.proto", Content: billing},
+	})
+	if err != nil {
+		t.Fatalf("ParseSchemaFiles(old) error = %v", err)
+	}
+	for _, name := range []string{"billing.v1.Order", "shop.v1.Order"} {
+		if oldSchema.Messages[name] == nil {
+			t.Errorf("Messages[%q] is missing", name)
+		}
+	}
+	for _, name := range []string{"billing.v1.Status", "shop.v1.Status"} {
+		if oldSchema.Enums[name] == nil {
+			t.Errorf("Enums[%q] is missing", name)
+		}
+	}
+
+	// Removing one package's types leaves the other's in place
+	newSchema, err := ParseSchemaFiles([]SourceFile{{Path: "shop/order.proto", Content: diffOldProto}})
+	if err != nil {
+		t.Fatalf("ParseSchemaFiles(new) error = %v", err)
+	}
+	diff := Diff(oldSchema, newSchema)
+	for kind, location := range map[ChangeKind]string{
+		ChangeMessageRemoved: "billing.v1.Order",
+		ChangeEnumRemoved:    "billing.v1.Status",
+	} {
+		changes := diff.Filter(kind)
+		if len(changes) != 1 || changes[0].Location != location || changes[0].File != "billing/order.proto" {
+			t.Errorf("Filter(%s) = %+v, want one change at %s in billing/order.proto", kind, changes, location)
+		}
+	}
+	if len(diff.Changes) != 2 {
+		t.Errorf("Diff() returned %d changes, want 2: %+v", len(diff.Changes), diff.Changes)
+	}
+}
+
+func TestDiff_Deterministic(t *testing.T) {
+	oldSchema, _ := ParseSchema(diffOldProto)
+	newSchema, _ := ParseSchema(diffNewProto)
+
+	first := Diff(oldSchema, newSchema)
+	for i := 0; i < 10; i++ {
+		again := Diff(oldSchema, newSchema)
+		for j := range first.Changes {
+			if first.Changes[j].Kind != again.Changes[j].Kind || first.Changes[j].Location != again.Changes[j].Location {
+				t.Fatalf("Diff() order differs between runs at index %d", j)
+			}
+		}
+	}
+}
+
+func TestDiff_FieldNumberChanged(t *testing.T) {
+	oldSchema, _ := ParseSchema("syntax = \"proto3\";\nmessage A { string name = 1; }\n")
+	newSchema, _ := ParseSchema("syntax = \"proto3\";\nmessage A { string name = 2; }\n")
+
+	changes := Diff(oldSchema, newSchema).Filter(ChangeFieldNumberChanged)
+	if len(changes) != 1 {
+		t.Fatalf("expected one field number change, got %d", len(changes))
+	}
+	if changes[0].OldValue != "1" || changes[0].NewValue != "2" {
+		t.Errorf("number change = %s -> %s, want 1 -> 2", changes[0].OldValue, changes[0].NewValue)
+	}
+
+	result, err := CheckCompatibility(oldSchema, newSchema, CompatibilityModeBackward)
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	found := false
+	for _, v := range result.Violations {
+		if v.Rule == "FIELD_NUMBER_CHANGED" && v.Level == ViolationLevelError {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected FIELD_NUMBER_CHANGED violation")
+	}
+}
+
+func TestCheckResult_Entries(t *testing.T) {
+	oldSchema, _ := ParseSchemaFiles([]SourceFile{{Path: "shop/order.proto", Content: diffOldProto}})
+	newSchema, _ := ParseSchemaFiles([]SourceFile{{Path: "shop/order.proto", Content: diffNewProto}})
+
+	result, err := CheckCompatibility(oldSchema, newSchema, CompatibilityModeBackward)
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	if result.Diff == nil {
+		t.Fatal("CheckResult.Diff is nil")
+	}
+
+	entries := result.Entries()
+	if len(entries) != len(result.Violations) {
+		t.Fatalf("Entries() returned %d entries, want %d", len(entries), len(result.Violations))
+	}
+
+	bySeverity := map[Severity]int{}
+	for _, e := range entries {
+		bySeverity[e.Severity]++
+		switch e.Severity {
+		case SeverityBreaking:
+			if e.MigrationTip == "" {
+				t.Errorf("breaking entry %s at %s has no migration tip", e.Type, e.Location)
+			}
+		case SeverityNonBreaking:
+			if e.MigrationTip != "" {
+				t.Errorf("non-breaking entry %s at %s has a migration tip", e.Type, e.Location)
+			}
+		}
+	}
+
+	// note removed, quantity int32->int64 is compatible, STATUS_CLOSED and DeleteOrder removed
+	if bySeverity[SeverityBreaking] != 3 {
+		t.Errorf("breaking entries = %d, want 3", bySeverity[SeverityBreaking])
+	}
+	if bySeverity[SeverityNonBreaking] != 1 {
+		t.Errorf("non-breaking entries = %d, want 1", bySeverity[SeverityNonBreaking])
+	}
+}
+
+func TestSeverityForLevel(t *testing.T) {
+	tests := []struct {
+		level ViolationLevel
+		want  Severity
+	}{
+		{ViolationLevelError, SeverityBreaking},
+		{ViolationLevelWarning, SeverityWarning},
+		{ViolationLevelInfo, SeverityNonBreaking},
+	}
+
+	for _, tt := range tests {
+		if got := SeverityForLevel(tt.level); got != tt.want {
+			t.Errorf("SeverityForLevel(%s) = %s, want %s", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestDiff_Lines(t *testing.T) {
+	oldSchema, _ := ParseSchemaFiles([]SourceFile{{Path: "shop/order.proto", Content: diffOldProto}})
+	newSchema, _ := ParseSchemaFiles([]SourceFile{{Path: "shop/order.proto", Content: diffNewProto}})
+
+	diff := Diff(oldSchema, newSchema)
+
+	// Removals point at the old declaration, everything else at the new one
+	want := map[ChangeKind]int{
+		ChangeFieldRemoved:     6,
+		ChangeFieldTypeChanged: 6,
+		ChangeFieldAdded:       7,
+		ChangeEnumValueRemoved: 13,
+		ChangeMethodRemoved:    18,
+	}
+	for kind, line := range want {
+		changes := diff.Filter(kind)
+		if len(changes) != 1 {
+			t.Fatalf("Filter(%s) returned %d changes, want 1", kind, len(changes))
+		}
+		if changes[0].Line != line {
+			t.Errorf("%s line = %d, want %d", kind, changes[0].Line, line)
+		}
+	}
+}
@@ -71,6 +71,23 @@
 //
 //	fmt.Println("Schema change is compatible!")
 //
+// # Structural Diff
+//
+// Every check starts from Diff, which lists the structural changes between two
+// SchemaGraphs (fields added, types changed, methods removed, ...) without
+// judging them. The Comparator grades each Change into a Violation according
+// to the mode, and CheckResult.Entries renders the graded changes with a
+// severity and migration tip for human-readable diffs:
+//
+//	oldSchema, _ := compatibility.ParseSchemaFiles(oldFiles)
+//	newSchema, _ := compatibility.ParseSchemaFiles(newFiles)
+//	result, _ := compatibility.CheckCompatibility(oldSchema, newSchema, compatibility.CompatibilityModeBackward)
+//	for _, entry := range result.Entries() {
+//		fmt.Printf("%s %s: %s\n", entry.Severity, entry.Location, entry.MigrationTip)
+//	}
+//
+// pkg/docs/diff and the /modules/{name}/diff endpoint are built on this model.
+//
 // # Breaking Change Detection
 //
 // The comparator detects two types of breaking changes:
@@ -196,7 +213,8 @@
 //
 //	type SchemaGraph struct {
 //		Package  string              // Package name
-//		Syntax   string              // "proto2" or "proto3"
+//		Syntax   string              // "proto2", "proto3" or "editions"
+//		Edition  string              // e.g. "2023" for edition files
 //		Imports  []Import            // Import statements
 //		Messages map[string]*Message // All messages by fully qualified name
 //		Enums    map[string]*Enum    // All enums
@@ -212,6 +230,7 @@
 //		Fields       map[int]*Field      // Field number → Field
 //		FieldsByName map[string]*Field   // Field name → Field
 //		Reserved     *Reserved           // Reserved numbers/names
+//		ExtensionRanges [][2]int         // Inclusive extension ranges
 //		Nested       map[string]*Message // Nested messages
 //		NestedEnums  map[string]*Enum    // Nested enums
 //		OneOfs       map[string]*OneOf   // OneOf groups
@@ -227,8 +246,18 @@
 //		TypeName     string     // For message/enum types
 //		InOneOf      string     // OneOf group name if applicable
 //		Deprecated   bool
+//		Presence     FieldPresence // explicit, implicit or legacy_required
+//		Packed       *bool      // Packed encoding of repeated scalars
+//		Delimited    bool       // message_encoding = DELIMITED
+//		ValidateUTF8 bool       // utf8_validation = VERIFY
 //	}
 //
+// Presence, encoding and enum openness (Enum.Closed) are resolved from
+// edition features. For proto2 and proto3 files they are the behavior the
+// syntax implies, so migrating a file to editions only reports a change
+// when the migration actually alters behavior. Only edition 2023 files can
+// be parsed; edition 2024 files are rejected until protocompile supports it.
+//
 // # Comparison Rules
 //
 // The comparator applies comprehensive rules:
@@ -246,6 +275,21 @@
 //   - Adding enum values is safe (unrecognized values → default)
 //   - Enum value 0 must always exist (proto3 default value)
 //
+// Feature Comparison:
+//   - Syntax or edition changes are informational
+//   - Field presence changes (explicit ↔ implicit) are source breaking
+//   - Message encoding changes (length-prefixed ↔ delimited) are wire breaking
+//   - Packed encoding changes are safe; parsers accept both encodings
+//   - Enabling UTF-8 validation may reject previously accepted data
+//   - Switching an enum between open and closed changes unknown value handling
+//   - Adding values to closed enums warns in forward modes
+//
+// Reserved and Extension Comparison:
+//   - Fields and enum values must not reuse reserved numbers or names
+//   - Dropping a reservation that is not reused is a warning
+//   - Removing or narrowing an extension range breaks existing extensions
+//   - Extensions are compared by fully qualified name like regular fields
+//
 // Service Comparison:
 //   - RPC method signatures must remain stable
 //   - Removing RPC methods breaks clients
@@ -378,7 +422,23 @@
 //		string email = 11;
 //	}
 //
-// The comparator validates reserved fields aren't violated.
+// SchemaGraph records the reservations of every message and enum in
+// Reserved. A new version that gives a field or enum value a number or
+// name reserved in the old version is reported as RESERVED_NUMBER_REUSED
+// or RESERVED_NAME_REUSED; rewriting reservations (2, 3 → 2 to 3) is not
+// a change.
+//
+// Extension ranges and extend blocks are tracked too. Messages list their
+// ranges in ExtensionRanges, and SchemaGraph.Extensions holds every
+// extension by fully qualified name:
+//
+//	message Order {
+//		extensions 100 to 199;  // Shrinking this range is EXTENSION_RANGE_REMOVED
+//	}
+//
+//	extend Order {
+//		optional int32 rank = 100;  // shop.v1.rank
+//	}
 //
 // # Proto2 vs Proto3
 //
@@ -0,0 +1,283 @@
+package compatibility
+
+import (
+	"fmt"
+	"testing"
+)
+
+const proto3Order = `syntax = "proto3";
+package shop.v1;
+
+message Order {
+  string id = 1;
+  int32 quantity = 2;
+  optional string note = 3;
+  repeated int32 tags = 4;
+  Order parent = 5;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OPEN = 1;
+}
+`
+
+func parseEditionsSchema(t *testing.T, content string) *SchemaGraph {
+	t.Helper()
+	schema, err := ParseSchemaFiles([]SourceFile{{Path: "shop/order.proto", Content: content}})
+	if err != nil {
+		t.Fatalf("ParseSchemaFiles() error = %v", err)
+	}
+	return schema
+}
+
+func violationsByRule(result *CheckResult) map[string]Violation {
+	byRule := make(map[string]Violation)
+	for _, v := range result.Violations {
+		byRule[v.Rule] = v
+	}
+	return byRule
+}
+
+func TestSchemaGraphBuilder_Editions(t *testing.T) {
+	schema := parseEditionsSchema(t, `edition = "2023";
+package shop.v1;
+
+message Order {
+  string id = 1 [features.field_presence = IMPLICIT];
+  int32 quantity = 2;
+  repeated int32 tags = 3 [features.repeated_field_encoding = EXPANDED];
+  Order parent = 4 [features.message_encoding = DELIMITED];
+  string raw = 5 [features.utf8_validation = NONE];
+}
+
+enum Status {
+  option features.enum_type = CLOSED;
+  STATUS_UNSPECIFIED = 0;
+}
+`)
+
+	if schema.Syntax != "editions" || schema.Edition != "2023" {
+		t.Fatalf("syntax = %q edition = %q, want editions 2023", schema.Syntax, schema.Edition)
+	}
+
+	order := schema.Messages["shop.v1.Order"]
+	if got := order.FieldsByName["id"].Presence; got != FieldPresenceImplicit {
+		t.Errorf("id presence = %v, want implicit", got)
+	}
+	if got := order.FieldsByName["quantity"].Presence; got != FieldPresenceExplicit {
+		t.Errorf("quantity presence = %v, want explicit", got)
+	}
+	if packed := order.FieldsByName["tags"].Packed; packed == nil || *packed {
+		t.Errorf("tags packed = %v, want false", packed)
+	}
+	if !order.FieldsByName["parent"].Delimited {
+		t.Error("parent should be delimited")
+	}
+	if order.FieldsByName["raw"].ValidateUTF8 {
+		t.Error("raw should not validate UTF-8")
+	}
+	if !order.FieldsByName["id"].ValidateUTF8 {
+		t.Error("id should validate UTF-8")
+	}
+	if !schema.Enums["shop.v1.Status"].Closed {
+		t.Error("Status should be closed")
+	}
+}
+
+func TestComparator_Proto3ToEditionsMigration(t *testing.T) {
+	oldSchema := parseEditionsSchema(t, proto3Order)
+
+	// A faithful migration keeps proto3 semantics through features
+	migrated := parseEditionsSchema(t, `edition = "2023";
+package shop.v1;
+
+option features.field_presence = IMPLICIT;
+
+message Order {
+  string id = 1;
+  int32 quantity = 2;
+  string note = 3 [features.field_presence = EXPLICIT];
+  repeated int32 tags = 4;
+  Order parent = 5;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OPEN = 1;
+}
+`)
+
+	result, err := CheckCompatibility(oldSchema, migrated, CompatibilityModeFull)
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	if len(result.Violations) != 1 || result.Violations[0].Rule != "SYNTAX_CHANGED" {
+		t.Fatalf("violations = %+v, want only SYNTAX_CHANGED", result.Violations)
+	}
+	if v := result.Violations[0]; v.OldValue != "proto3" || v.NewValue != "edition 2023" || v.Level != ViolationLevelInfo {
+		t.Errorf("SYNTAX_CHANGED = %+v", v)
+	}
+
+	// Without the file feature, scalar fields switch to explicit presence
+	naive := parseEditionsSchema(t, `edition = "2023";
+package shop.v1;
+
+message Order {
+  string id = 1;
+  int32 quantity = 2;
+  string note = 3;
+  repeated int32 tags = 4;
+  Order parent = 5;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OPEN = 1;
+}
+`)
+
+	result, err = CheckCompatibility(oldSchema, naive, CompatibilityModeBackward)
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	if !result.Compatible {
+		t.Error("presence changes should not make the schema incompatible")
+	}
+	presence := make(map[string]Violation)
+	for _, v := range result.Violations {
+		if v.Rule == "FIELD_PRESENCE_CHANGED" {
+			presence[v.Location] = v
+		}
+	}
+	if len(presence) != 2 {
+		t.Fatalf("presence violations = %+v, want id and quantity", presence)
+	}
+	v, ok := presence["shop.v1.Order.id"]
+	if !ok {
+		t.Fatal("missing presence change for shop.v1.Order.id")
+	}
+	if v.Level != ViolationLevelWarning || !v.SourceBreaking || v.WireBreaking {
+		t.Errorf("presence violation = %+v", v)
+	}
+	if v.OldValue != "implicit" || v.NewValue != "explicit" {
+		t.Errorf("presence change = %s -> %s", v.OldValue, v.NewValue)
+	}
+}
+
+func TestComparator_FeatureChanges(t *testing.T) {
+	oldSchema := parseEditionsSchema(t, `edition = "2023";
+package shop.v1;
+
+message Order {
+  repeated int32 tags = 1;
+  Order parent = 2;
+  string raw = 3 [features.utf8_validation = NONE];
+}
+
+enum Status {
+  option features.enum_type = CLOSED;
+  STATUS_UNSPECIFIED = 0;
+}
+`)
+	newSchema := parseEditionsSchema(t, `edition = "2023";
+package shop.v1;
+
+message Order {
+  repeated int32 tags = 1 [features.repeated_field_encoding = EXPANDED];
+  Order parent = 2 [features.message_encoding = DELIMITED];
+  string raw = 3;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+}
+`)
+
+	result, err := CheckCompatibility(oldSchema, newSchema, CompatibilityModeBackward)
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	if result.Compatible {
+		t.Error("message encoding change should be incompatible")
+	}
+
+	tests := []struct {
+		rule  string
+		level ViolationLevel
+		wire  bool
+		old   string
+		new   string
+	}{
+		{"FIELD_PACKED_CHANGED", ViolationLevelInfo, false, "true", "false"},
+		{"FIELD_MESSAGE_ENCODING_CHANGED", ViolationLevelError, true, "length_prefixed", "delimited"},
+		{"FIELD_UTF8_VALIDATION_CHANGED", ViolationLevelWarning, false, "none", "verify"},
+		{"ENUM_TYPE_CHANGED", ViolationLevelWarning, false, "closed", "open"},
+	}
+
+	byRule := violationsByRule(result)
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			v, ok := byRule[tt.rule]
+			if !ok {
+				t.Fatalf("missing violation %s", tt.rule)
+			}
+			if v.Level != tt.level {
+				t.Errorf("level = %v, want %v", v.Level, tt.level)
+			}
+			if v.WireBreaking != tt.wire {
+				t.Errorf("wire breaking = %v, want %v", v.WireBreaking, tt.wire)
+			}
+			if v.OldValue != tt.old || v.NewValue != tt.new {
+				t.Errorf("change = %s -> %s, want %s -> %s", v.OldValue, v.NewValue, tt.old, tt.new)
+			}
+		})
+	}
+}
+
+func TestComparator_ClosedEnumValueAdded(t *testing.T) {
+	const closedEnum = `syntax = "proto2";
+package shop.v1;
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+%s}
+`
+	oldSchema := parseEditionsSchema(t, fmt.Sprintf(closedEnum, ""))
+	newSchema := parseEditionsSchema(t, fmt.Sprintf(closedEnum, "  STATUS_OPEN = 1;\n"))
+
+	tests := []struct {
+		mode  CompatibilityMode
+		level ViolationLevel
+	}{
+		{CompatibilityModeBackward, ViolationLevelInfo},
+		{CompatibilityModeForward, ViolationLevelWarning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			result, err := CheckCompatibility(oldSchema, newSchema, tt.mode)
+			if err != nil {
+				t.Fatalf("CheckCompatibility() error = %v", err)
+			}
+			v, ok := violationsByRule(result)["ENUM_VALUE_ADDED"]
+			if !ok {
+				t.Fatal("missing ENUM_VALUE_ADDED")
+			}
+			if v.Level != tt.level {
+				t.Errorf("level = %v, want %v", v.Level, tt.level)
+			}
+		})
+	}
+
+	// Values added to open enums stay informational in every mode
+	open := parseEditionsSchema(t, proto3Order)
+	openNew := parseEditionsSchema(t, proto3Order+"enum Extra { EXTRA_UNSPECIFIED = 0; }\n")
+	openNew.Enums["shop.v1.Status"].Values[2] = &EnumValue{Name: "STATUS_CLOSED", Number: 2}
+	result, err := CheckCompatibility(open, openNew, CompatibilityModeForward)
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	if v := violationsByRule(result)["ENUM_VALUE_ADDED"]; v.Level != ViolationLevelInfo {
+		t.Errorf("open enum value added level = %v, want INFO", v.Level)
+	}
+}
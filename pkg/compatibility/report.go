@@ -0,0 +1,121 @@
+package compatibility
+
+// Severity classifies a change for human-readable diffs and migration guides
+type Severity string
+
+const (
+	SeverityBreaking    Severity = "breaking"
+	SeverityNonBreaking Severity = "non_breaking"
+	SeverityWarning     Severity = "warning"
+)
+
+// SeverityForLevel maps a violation level to its diff severity
+func SeverityForLevel(level ViolationLevel) Severity {
+	switch level {
+	case ViolationLevelError:
+		return SeverityBreaking
+	case ViolationLevelWarning:
+		return SeverityWarning
+	default:
+		return SeverityNonBreaking
+	}
+}
+
+// MigrationTip returns guidance for consumers affected by a change of the given kind
+func MigrationTip(kind ChangeKind) string {
+	switch kind {
+	case ChangePackageChanged:
+		return "Update all imports and fully qualified type references to the new package"
+	case ChangeImportRemoved:
+		return "Import the file directly if you relied on types it re-exported"
+	case ChangeFieldRemoved:
+		return "Remove all references to this field in your code"
+	case ChangeFieldRenamed:
+		return "Update all field references to use the new name"
+	case ChangeFieldTypeChanged:
+		return "Update code to handle the new field type"
+	case ChangeMessageRemoved:
+		return "Remove all usages of this message type"
+	case ChangeEnumRemoved:
+		return "Replace enum usages with alternative type"
+	case ChangeEnumValueRemoved:
+		return "Update code that uses this enum value"
+	case ChangeEnumValueNumberChanged:
+		return "Regenerate code and redeploy producers and consumers together; persisted values are reinterpreted"
+	case ChangeServiceRemoved:
+		return "Remove all service client implementations"
+	case ChangeMethodRemoved:
+		return "Remove all calls to this method"
+	case ChangeMethodInputChanged, ChangeMethodOutputChanged:
+		return "Update clients and servers to the new request/response message"
+	case ChangeMethodClientStreamingChanged, ChangeMethodServerStreamingChanged:
+		return "Rewrite call sites for the new streaming mode"
+	case ChangeFieldNumberChanged:
+		return "This is a critical breaking change - regenerate all code and redeploy all services"
+	case ChangeFieldLabelChanged:
+		return "Update code to handle the new field cardinality (single vs repeated)"
+	case ChangeFieldOneOfChanged:
+		return "Update code that sets or switches on the oneof"
+	case ChangeFieldPresenceChanged:
+		return "Update code that checks whether the field is set; has-accessors are added or removed"
+	case ChangeFieldEncodingChanged:
+		return "Regenerate code and redeploy producers and consumers together"
+	case ChangeFieldUTF8ValidationChanged:
+		return "Make sure producers only write valid UTF-8 to this field"
+	case ChangeEnumTypeChanged:
+		return "Update code that handles unrecognized enum values"
+	case ChangeEnumValueAdded:
+		return "Update readers before producers start sending the new value"
+	case ChangeReservedNumberReused, ChangeReservedNameReused:
+		return "Make sure no stored or in-flight data still uses the reserved field before upgrading"
+	case ChangeExtensionRangeRemoved:
+		return "Move extensions of this message to numbers inside the remaining extension ranges"
+	case ChangeExtensionRemoved:
+		return "Remove all references to this extension in your code"
+	default:
+		return ""
+	}
+}
+
+// DiffEntry is the human-readable form of a violation: the change it was
+// derived from, its severity and a migration tip for affected consumers.
+type DiffEntry struct {
+	Type           ChangeKind `json:"type"`
+	Rule           string     `json:"rule"`
+	Severity       Severity   `json:"severity"`
+	Location       string     `json:"location"`
+	File           string     `json:"file,omitempty"`
+	Line           int        `json:"line,omitempty"`
+	OldValue       string     `json:"old_value,omitempty"`
+	NewValue       string     `json:"new_value,omitempty"`
+	Description    string     `json:"description"`
+	MigrationTip   string     `json:"migration_tip,omitempty"`
+	WireBreaking   bool       `json:"wire_breaking"`
+	SourceBreaking bool       `json:"source_breaking"`
+}
+
+// Entries renders the violations of a check as diff entries, in diff order
+func (r *CheckResult) Entries() []DiffEntry {
+	entries := make([]DiffEntry, 0, len(r.Violations))
+	for _, v := range r.Violations {
+		severity := SeverityForLevel(v.Level)
+		entry := DiffEntry{
+			Type:           v.Kind,
+			Rule:           v.Rule,
+			Severity:       severity,
+			Location:       v.Location,
+			File:           v.File,
+			Line:           v.Line,
+			OldValue:       v.OldValue,
+			NewValue:       v.NewValue,
+			Description:    v.Message,
+			WireBreaking:   v.WireBreaking,
+			SourceBreaking: v.SourceBreaking,
+		}
+		if severity != SeverityNonBreaking {
+			entry.MigrationTip = MigrationTip(v.Kind)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
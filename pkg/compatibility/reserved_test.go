@@ -0,0 +1,261 @@
+package compatibility
+
+import (
+	"testing"
+)
+
+const reservedOrder = `syntax = "proto2";
+package shop.v1;
+
+import "google/protobuf/descriptor.proto";
+
+extend google.protobuf.FieldOptions {
+  optional bool sensitive = 50001;
+}
+
+message Order {
+  reserved 2, 10 to 20;
+  reserved "legacy";
+  extensions 100 to 199;
+
+  optional string id = 1 [(sensitive) = true];
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  reserved 5 to 7;
+  reserved "STATUS_OLD";
+}
+`
+
+func TestSchemaGraphBuilder_ReservedAndExtensions(t *testing.T) {
+	schema := parseEditionsSchema(t, reservedOrder)
+
+	order := schema.Messages["shop.v1.Order"]
+	if order.Reserved == nil {
+		t.Fatal("Order.Reserved is nil")
+	}
+	if len(order.Reserved.Numbers) != 1 || order.Reserved.Numbers[0] != 2 {
+		t.Errorf("reserved numbers = %v, want [2]", order.Reserved.Numbers)
+	}
+	if len(order.Reserved.Ranges) != 1 || order.Reserved.Ranges[0] != [2]int{10, 20} {
+		t.Errorf("reserved ranges = %v, want [[10 20]]", order.Reserved.Ranges)
+	}
+	if !order.Reserved.ContainsName("legacy") || order.Reserved.ContainsName("id") {
+		t.Errorf("reserved names = %v", order.Reserved.Names)
+	}
+	for _, n := range []int{2, 10, 15, 20} {
+		if !order.Reserved.ContainsNumber(n) {
+			t.Errorf("%d should be reserved", n)
+		}
+	}
+	if order.Reserved.ContainsNumber(21) {
+		t.Error("21 should not be reserved")
+	}
+	if len(order.ExtensionRanges) != 1 || order.ExtensionRanges[0] != [2]int{100, 199} {
+		t.Errorf("extension ranges = %v, want [[100 199]]", order.ExtensionRanges)
+	}
+	if got := order.FieldsByName["id"].Options["(shop.v1.sensitive)"]; got != "true" {
+		t.Errorf("id sensitive option = %q, want true", got)
+	}
+
+	status := schema.Enums["shop.v1.Status"]
+	if status.Reserved == nil || !status.Reserved.ContainsNumber(6) || !status.Reserved.ContainsName("STATUS_OLD") {
+		t.Errorf("Status.Reserved = %+v", status.Reserved)
+	}
+
+	ext, ok := schema.Extensions["shop.v1.sensitive"]
+	if !ok {
+		t.Fatalf("extensions = %v, want shop.v1.sensitive", schema.Extensions)
+	}
+	if ext.Extendee != "google.protobuf.FieldOptions" || ext.Number != 50001 || ext.File != "shop/order.proto" {
+		t.Errorf("extension = %+v", ext)
+	}
+}
+
+func TestComparator_ReservedReused(t *testing.T) {
+	oldSchema := parseEditionsSchema(t, reservedOrder)
+	newSchema := parseEditionsSchema(t, `syntax = "proto2";
+package shop.v1;
+
+import "google/protobuf/descriptor.proto";
+
+extend google.protobuf.FieldOptions {
+  optional bool sensitive = 50001;
+}
+
+message Order {
+  extensions 100 to 199;
+
+  optional string id = 1 [(sensitive) = true];
+  optional string legacy = 12;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OLD = 6;
+}
+`)
+
+	result, err := CheckCompatibility(oldSchema, newSchema, CompatibilityModeBackward)
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	if result.Compatible {
+		t.Error("reusing reserved numbers should be incompatible")
+	}
+
+	counts := make(map[string]int)
+	byLocation := make(map[string]Violation)
+	for _, v := range result.Violations {
+		counts[v.Rule]++
+		byLocation[v.Rule+" "+v.Location] = v
+	}
+
+	if counts["RESERVED_NUMBER_REUSED"] != 2 || counts["RESERVED_NAME_REUSED"] != 2 {
+		t.Fatalf("violations = %+v, want number and name reuse for Order.legacy and Status.STATUS_OLD", result.Violations)
+	}
+	v, ok := byLocation["RESERVED_NUMBER_REUSED shop.v1.Order.legacy"]
+	if !ok {
+		t.Fatal("missing RESERVED_NUMBER_REUSED for shop.v1.Order.legacy")
+	}
+	if v.Level != ViolationLevelError || !v.WireBreaking || v.Category != CategoryReservedChange {
+		t.Errorf("RESERVED_NUMBER_REUSED = %+v", v)
+	}
+	if _, ok := byLocation["RESERVED_NAME_REUSED shop.v1.Status.STATUS_OLD"]; !ok {
+		t.Error("missing RESERVED_NAME_REUSED for shop.v1.Status.STATUS_OLD")
+	}
+
+	// Only the reservation of 2 was dropped without being reused
+	if counts["RESERVED_REMOVED"] != 1 {
+		t.Fatalf("RESERVED_REMOVED count = %d, want 1", counts["RESERVED_REMOVED"])
+	}
+	removed := byLocation["RESERVED_REMOVED shop.v1.Order"]
+	if removed.OldValue != "2" || removed.Level != ViolationLevelWarning {
+		t.Errorf("RESERVED_REMOVED = %+v", removed)
+	}
+}
+
+func TestComparator_ReservedRewritten(t *testing.T) {
+	oldSchema := parseEditionsSchema(t, `syntax = "proto3";
+package shop.v1;
+
+message Order {
+  reserved 2, 3, 10 to 20;
+  string id = 1;
+}
+`)
+	newSchema := parseEditionsSchema(t, `syntax = "proto3";
+package shop.v1;
+
+message Order {
+  reserved 2 to 3, 10 to 15, 16 to 30;
+  string id = 1;
+}
+`)
+
+	result, err := CheckCompatibility(oldSchema, newSchema, CompatibilityModeFull)
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("violations = %+v, want none", result.Violations)
+	}
+}
+
+func TestComparator_ExtensionChanges(t *testing.T) {
+	oldSchema := parseEditionsSchema(t, `syntax = "proto2";
+package shop.v1;
+
+message Order {
+  extensions 100 to 199;
+  optional string id = 1;
+}
+
+extend Order {
+  optional int32 rank = 100;
+  optional string channel = 101;
+}
+`)
+	newSchema := parseEditionsSchema(t, `syntax = "proto2";
+package shop.v1;
+
+message Order {
+  extensions 100 to 149, 500 to max;
+  optional string id = 1;
+}
+
+extend Order {
+  optional int64 rank = 100;
+  optional string source = 102;
+}
+`)
+
+	result, err := CheckCompatibility(oldSchema, newSchema, CompatibilityModeBackward)
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	if result.Compatible {
+		t.Error("removing an extension range should be incompatible")
+	}
+
+	tests := []struct {
+		rule     string
+		location string
+		level    ViolationLevel
+		old      string
+		new      string
+	}{
+		{"EXTENSION_RANGE_REMOVED", "shop.v1.Order", ViolationLevelError, "100 to 199", ""},
+		{"EXTENSION_RANGE_ADDED", "shop.v1.Order", ViolationLevelInfo, "", "500 to max"},
+		{"EXTENSION_REMOVED", "shop.v1.channel", ViolationLevelError, "extend shop.v1.Order { string channel = 101 }", ""},
+		{"EXTENSION_ADDED", "shop.v1.source", ViolationLevelInfo, "", "extend shop.v1.Order { string source = 102 }"},
+	}
+
+	byRule := violationsByRule(result)
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			v, ok := byRule[tt.rule]
+			if !ok {
+				t.Fatalf("missing violation %s in %+v", tt.rule, result.Violations)
+			}
+			if v.Location != tt.location {
+				t.Errorf("location = %q, want %q", v.Location, tt.location)
+			}
+			if v.Level != tt.level {
+				t.Errorf("level = %v, want %v", v.Level, tt.level)
+			}
+			if v.OldValue != tt.old || v.NewValue != tt.new {
+				t.Errorf("change = %q -> %q, want %q -> %q", v.OldValue, v.NewValue, tt.old, tt.new)
+			}
+		})
+	}
+
+	// int32 -> int64 is wire compatible, so the kept extension is not reported
+	if _, ok := byRule["FIELD_TYPE_CHANGED"]; ok {
+		t.Error("compatible extension type change should not be reported")
+	}
+}
+
+func TestCoversRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals [][2]int
+		rng       [2]int
+		want      bool
+	}{
+		{"exact", [][2]int{{5, 10}}, [2]int{5, 10}, true},
+		{"adjacent intervals", [][2]int{{8, 10}, {5, 7}}, [2]int{5, 10}, true},
+		{"overlapping intervals", [][2]int{{5, 8}, {6, 12}}, [2]int{7, 11}, true},
+		{"gap", [][2]int{{5, 7}, {9, 10}}, [2]int{5, 10}, false},
+		{"narrowed", [][2]int{{5, 8}}, [2]int{5, 10}, false},
+		{"empty", nil, [2]int{1, 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := coversRange(tt.intervals, tt.rng); got != tt.want {
+				t.Errorf("coversRange(%v, %v) = %v, want %v", tt.intervals, tt.rng, got, tt.want)
+			}
+		})
+	}
+}
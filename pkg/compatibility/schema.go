@@ -1,6 +1,8 @@
 package compatibility
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/platinummonkey/spoke/pkg/api/protobuf"
@@ -9,11 +11,13 @@ import (
 // SchemaGraph represents a complete parsed protobuf schema with enhanced metadata
 type SchemaGraph struct {
 	Package      string
-	Syntax       string // "proto2" or "proto3"
+	Syntax       string // "proto2", "proto3" or "editions"
+	Edition      string // Edition number (e.g. "2023") when Syntax is "editions"
 	Imports      []Import
-	Messages     map[string]*Message  // Fully qualified name -> Message
-	Enums        map[string]*Enum     // Fully qualified name -> Enum
-	Services     map[string]*Service  // Fully qualified name -> Service
+	Messages     map[string]*Message     // Fully qualified name -> Message
+	Enums        map[string]*Enum        // Fully qualified name -> Enum
+	Services     map[string]*Service     // Fully qualified name -> Service
+	Extensions   map[string]*Extension   // Fully qualified extension name -> Extension
 	Dependencies map[string]*SchemaGraph // Import path -> dependency graph
 }
 
@@ -26,15 +30,18 @@ type Import struct {
 
 // Message represents a protobuf message with all fields
 type Message struct {
-	Name         string
-	FullName     string // package.Message or package.Outer.Inner
-	Fields       map[int]*Field  // Field number -> Field (for fast lookup)
-	FieldsByName map[string]*Field
-	Reserved     *Reserved
-	Nested       map[string]*Message
-	NestedEnums  map[string]*Enum
-	OneOfs       map[string]*OneOf
-	Options      map[string]string
+	Name            string
+	FullName        string         // package.Message or package.Outer.Inner
+	Fields          map[int]*Field // Field number -> Field (for fast lookup)
+	FieldsByName    map[string]*Field
+	Reserved        *Reserved
+	Nested          map[string]*Message
+	NestedEnums     map[string]*Enum
+	OneOfs          map[string]*OneOf
+	Options         map[string]string
+	ExtensionRanges [][2]int // Inclusive field number ranges open to extensions
+	File            string   // Source file the message is declared in
+	Line            int      // Declaration line in File, 0 when unknown
 }
 
 // Field represents a message field with complete metadata
@@ -47,10 +54,23 @@ type Field struct {
 	IsMap        bool
 	MapKeyType   string
 	MapValueType string
-	InOneOf      string     // OneOf name if part of oneof
+	InOneOf      string // OneOf name if part of oneof
 	Deprecated   bool
-	Packed       *bool
+	Presence     FieldPresence // Resolved field presence of singular fields
+	Packed       *bool         // Packed encoding of repeated scalar fields
+	Delimited    bool          // Message encoded as a group (message_encoding = DELIMITED)
+	ValidateUTF8 bool          // String contents are validated (utf8_validation = VERIFY)
+	Options      map[string]string
 	DefaultValue string
+	Line         int
+}
+
+// Extension is a field declared in an extend block
+type Extension struct {
+	Field
+	FullName string // package.name, or package.Message.name when nested in a message
+	Extendee string // Fully qualified name of the extended message
+	File     string
 }
 
 // FieldType represents the protobuf field type
@@ -99,14 +119,32 @@ func (fl FieldLabel) String() string {
 	return []string{"optional", "required", "repeated"}[fl]
 }
 
+// FieldPresence describes whether a field tracks if it was set. It is
+// resolved from edition features, or implied by the syntax of proto2 and
+// proto3 files. Repeated fields never track presence.
+type FieldPresence int
+
+const (
+	FieldPresenceExplicit FieldPresence = iota
+	FieldPresenceImplicit
+	FieldPresenceLegacyRequired
+)
+
+func (fp FieldPresence) String() string {
+	return []string{"explicit", "implicit", "legacy_required"}[fp]
+}
+
 // Enum represents an enum with values
 type Enum struct {
 	Name         string
 	FullName     string
-	Values       map[int]*EnumValue  // Number -> Value
+	Values       map[int]*EnumValue // Number -> Value
 	ValuesByName map[string]*EnumValue
 	Reserved     *Reserved
 	Options      map[string]string
+	Closed       bool // Unknown values are kept as unknown fields (enum_type = CLOSED)
+	File         string
+	Line         int
 }
 
 // EnumValue represents an enum value
@@ -114,6 +152,7 @@ type EnumValue struct {
 	Name       string
 	Number     int
 	Deprecated bool
+	Line       int
 }
 
 // Service represents a gRPC service
@@ -121,6 +160,8 @@ type Service struct {
 	Name     string
 	FullName string
 	Methods  map[string]*Method
+	File     string
+	Line     int
 }
 
 // Method represents an RPC method
@@ -131,15 +172,96 @@ type Method struct {
 	ClientStreaming bool
 	ServerStreaming bool
 	Deprecated      bool
+	Line            int
 }
 
 // Reserved tracks reserved fields
 type Reserved struct {
 	Numbers []int
-	Ranges  [][2]int
+	Ranges  [][2]int // Inclusive ranges
 	Names   []string
 }
 
+// ContainsNumber reports whether a field or enum value number is reserved
+func (r *Reserved) ContainsNumber(n int) bool {
+	if r == nil {
+		return false
+	}
+	for _, num := range r.Numbers {
+		if num == n {
+			return true
+		}
+	}
+	for _, rng := range r.Ranges {
+		if n >= rng[0] && n <= rng[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsName reports whether a field or enum value name is reserved
+func (r *Reserved) ContainsName(name string) bool {
+	if r == nil {
+		return false
+	}
+	for _, reserved := range r.Names {
+		if reserved == name {
+			return true
+		}
+	}
+	return false
+}
+
+// intervals returns the reserved numbers and ranges as inclusive ranges
+func (r *Reserved) intervals() [][2]int {
+	if r == nil {
+		return nil
+	}
+	intervals := make([][2]int, 0, len(r.Numbers)+len(r.Ranges))
+	for _, num := range r.Numbers {
+		intervals = append(intervals, [2]int{num, num})
+	}
+	return append(intervals, r.Ranges...)
+}
+
+// coversRange reports whether the union of inclusive intervals contains
+// every number of rng
+func coversRange(intervals [][2]int, rng [2]int) bool {
+	sorted := append([][2]int(nil), intervals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i][0] < sorted[j][0] })
+
+	next := rng[0]
+	for _, interval := range sorted {
+		if interval[0] > next {
+			break
+		}
+		if interval[1] >= next {
+			next = interval[1] + 1
+		}
+		if next > rng[1] {
+			return true
+		}
+	}
+	return next > rng[1]
+}
+
+// buildReserved converts reserved declarations, returning nil when there are none
+func buildReserved(ranges []protobuf.Range, names []string) *Reserved {
+	if len(ranges) == 0 && len(names) == 0 {
+		return nil
+	}
+	reserved := &Reserved{Names: append([]string(nil), names...)}
+	for _, r := range ranges {
+		if r.Start == r.End {
+			reserved.Numbers = append(reserved.Numbers, r.Start)
+		} else {
+			reserved.Ranges = append(reserved.Ranges, [2]int{r.Start, r.End})
+		}
+	}
+	return reserved
+}
+
 // OneOf represents a oneof group
 type OneOf struct {
 	Name   string
@@ -149,9 +271,56 @@ type OneOf struct {
 // SchemaGraphBuilder converts protobuf AST to SchemaGraph
 type SchemaGraphBuilder struct {
 	currentPackage string
+	currentFile    string
 	imports        map[string]*SchemaGraph
 }
 
+// SourceFile is a named proto source used to build a multi-file SchemaGraph
+type SourceFile struct {
+	Path    string
+	Content string
+}
+
+// StoredFile is a stored proto file type, such as api.File, that converts
+// directly into a SourceFile
+type StoredFile interface {
+	~struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	}
+}
+
+// SourceFiles converts the stored files of a module version into sources
+func SourceFiles[F StoredFile](files []F) []SourceFile {
+	sources := make([]SourceFile, 0, len(files))
+	for _, f := range files {
+		sources = append(sources, SourceFile(f))
+	}
+	return sources
+}
+
+// ParseSchema parses a single proto source into a SchemaGraph
+func ParseSchema(content string) (*SchemaGraph, error) {
+	return ParseSchemaFiles([]SourceFile{{Path: "input.proto", Content: content}})
+}
+
+// ParseSchemaFiles parses every file of a module version and merges them
+// into one SchemaGraph, recording which file declared each element.
+func ParseSchemaFiles(files []SourceFile) (*SchemaGraph, error) {
+	asts := make(map[string]*protobuf.RootNode, len(files))
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		ast, err := protobuf.ParseWithDescriptor(f.Path, f.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", f.Path, err)
+		}
+		asts[f.Path] = ast
+		paths = append(paths, f.Path)
+	}
+
+	return NewSchemaGraphBuilder().BuildFromFiles(paths, asts)
+}
+
 // NewSchemaGraphBuilder creates a new builder
 func NewSchemaGraphBuilder() *SchemaGraphBuilder {
 	return &SchemaGraphBuilder{
@@ -162,35 +331,111 @@ func NewSchemaGraphBuilder() *SchemaGraphBuilder {
 // BuildFromAST converts a protobuf AST to a SchemaGraph
 func (b *SchemaGraphBuilder) BuildFromAST(ast *protobuf.RootNode) (*SchemaGraph, error) {
 	graph := &SchemaGraph{
-		Package:  b.extractPackage(ast),
-		Syntax:   b.extractSyntax(ast),
-		Imports:  b.extractImports(ast),
-		Messages: make(map[string]*Message),
-		Enums:    make(map[string]*Enum),
-		Services: make(map[string]*Service),
+		Package:    b.extractPackage(ast),
+		Syntax:     b.extractSyntax(ast),
+		Edition:    b.extractEdition(ast),
+		Imports:    b.extractImports(ast),
+		Messages:   make(map[string]*Message),
+		Enums:      make(map[string]*Enum),
+		Services:   make(map[string]*Service),
+		Extensions: make(map[string]*Extension),
 	}
 
 	b.currentPackage = graph.Package
+	b.addDefinitions(graph, ast)
+
+	return graph, nil
+}
+
+// BuildFromFiles merges the ASTs of several files, visited in the given
+// path order, into a single SchemaGraph. The package and syntax are taken
+// from the first file that declares them.
+func (b *SchemaGraphBuilder) BuildFromFiles(paths []string, asts map[string]*protobuf.RootNode) (*SchemaGraph, error) {
+	graph := &SchemaGraph{
+		Messages:   make(map[string]*Message),
+		Enums:      make(map[string]*Enum),
+		Services:   make(map[string]*Service),
+		Extensions: make(map[string]*Extension),
+	}
 
-	// Extract top-level messages
+	for _, path := range paths {
+		ast, ok := asts[path]
+		if !ok {
+			return nil, fmt.Errorf("no AST for file %s", path)
+		}
+		if graph.Package == "" {
+			graph.Package = b.extractPackage(ast)
+		}
+		if graph.Syntax == "" && ast.Syntax != nil {
+			graph.Syntax = ast.Syntax.Value
+			graph.Edition = ast.Syntax.Edition
+		}
+		graph.Imports = append(graph.Imports, b.extractImports(ast)...)
+
+		b.currentPackage = b.extractPackage(ast)
+		b.currentFile = path
+		b.addDefinitions(graph, ast)
+	}
+	b.currentFile = ""
+
+	if graph.Syntax == "" {
+		graph.Syntax = "proto2"
+	}
+
+	return graph, nil
+}
+
+// addDefinitions adds the top-level messages, enums and services of ast to graph
+func (b *SchemaGraphBuilder) addDefinitions(graph *SchemaGraph, ast *protobuf.RootNode) {
+	// Extract top-level messages and the extensions declared inside them
 	for _, msgNode := range ast.Messages {
 		msg := b.buildMessage(msgNode, "")
-		graph.Messages[msg.Name] = msg
+		graph.Messages[msg.FullName] = msg
+		b.addNestedExtensions(graph, msgNode, msg.FullName)
 	}
 
+	// Extract top-level extensions
+	b.addExtensions(graph, ast.Extensions, b.currentPackage)
+
 	// Extract top-level enums
 	for _, enumNode := range ast.Enums {
 		enum := b.buildEnum(enumNode, "")
-		graph.Enums[enum.Name] = enum
+		graph.Enums[enum.FullName] = enum
 	}
 
 	// Extract services
 	for _, svcNode := range ast.Services {
 		svc := b.buildService(svcNode)
-		graph.Services[svc.Name] = svc
+		graph.Services[svc.FullName] = svc
 	}
+}
 
-	return graph, nil
+// addExtensions adds the fields of extend blocks declared in scope, the
+// package or the full name of the enclosing message
+func (b *SchemaGraphBuilder) addExtensions(graph *SchemaGraph, extends []*protobuf.ExtendNode, scope string) {
+	for _, extend := range extends {
+		for _, fieldNode := range extend.Fields {
+			fullName := fieldNode.Name
+			if scope != "" {
+				fullName = scope + "." + fieldNode.Name
+			}
+			graph.Extensions[fullName] = &Extension{
+				Field:    *b.buildField(fieldNode, ""),
+				FullName: fullName,
+				Extendee: extend.Extendee,
+				File:     b.currentFile,
+			}
+		}
+	}
+}
+
+// addNestedExtensions adds the extensions declared inside a message and its
+// nested messages
+func (b *SchemaGraphBuilder) addNestedExtensions(graph *SchemaGraph, msgNode *protobuf.MessageNode, fullName string) {
+	b.addExtensions(graph, msgNode.Extensions, fullName)
+	for _, nested := range msgNode.Nested {
+		b.addNestedExtensions(graph, nested, fullName+"."+nested.Name)
+	}
 }
 
 func (b *SchemaGraphBuilder) extractPackage(ast *protobuf.RootNode) string {
@@ -207,6 +452,13 @@ func (b *SchemaGraphBuilder) extractSyntax(ast *protobuf.RootNode) string {
 	return "proto2" // default
 }
 
+func (b *SchemaGraphBuilder) extractEdition(ast *protobuf.RootNode) string {
+	if ast.Syntax != nil {
+		return ast.Syntax.Edition
+	}
+	return ""
+}
+
 func (b *SchemaGraphBuilder) extractImports(ast *protobuf.RootNode) []Import {
 	var imports []Import
 	for _, imp := range ast.Imports {
@@ -230,7 +482,13 @@ func (b *SchemaGraphBuilder) buildMessage(msgNode *protobuf.MessageNode, parentP
 		Nested:       make(map[string]*Message),
 		NestedEnums:  make(map[string]*Enum),
 		OneOfs:       make(map[string]*OneOf),
-		Options:      make(map[string]string),
+		Options:      b.buildOptions(msgNode.Options),
+		Reserved:     buildReserved(msgNode.ReservedRanges, msgNode.ReservedNames),
+		File:         b.currentFile,
+		Line:         msgNode.Pos.Line,
+	}
+	for _, r := range msgNode.ExtensionRanges {
+		msg.ExtensionRanges = append(msg.ExtensionRanges, [2]int{r.Start, r.End})
 	}
 
 	// Extract fields
@@ -279,6 +537,8 @@ func (b *SchemaGraphBuilder) buildField(fieldNode *protobuf.FieldNode, oneofName
 		Label:      b.parseFieldLabel(fieldNode),
 		InOneOf:    oneofName,
 		Deprecated: b.hasDeprecatedOption(fieldNode.Options),
+		Options:    b.buildOptions(fieldNode.Options),
+		Line:       fieldNode.Pos.Line,
 	}
 
 	// Check if it's a map field (proto3: map<key, value>)
@@ -286,9 +546,45 @@ func (b *SchemaGraphBuilder) buildField(fieldNode *protobuf.FieldNode, oneofName
 		field.IsMap = true
 	}
 
+	b.applyFeatures(field, fieldNode)
+
 	return field
 }
 
+// applyFeatures records the presence and encoding a field resolves to.
+// Fields without resolved features keep the zero values.
+func (b *SchemaGraphBuilder) applyFeatures(field *Field, fieldNode *protobuf.FieldNode) {
+	switch {
+	case fieldNode.Required:
+		field.Presence = FieldPresenceLegacyRequired
+	case fieldNode.Repeated:
+		field.Presence = FieldPresenceImplicit
+	case fieldNode.Features != nil && !fieldNode.HasPresence:
+		field.Presence = FieldPresenceImplicit
+	}
+
+	features := fieldNode.Features
+	if features == nil {
+		return
+	}
+	if fieldNode.Repeated && features.RepeatedFieldEncoding != "" && isPackableType(field.Type) {
+		packed := features.RepeatedFieldEncoding == protobuf.EncodingPacked
+		field.Packed = &packed
+	}
+	field.Delimited = features.MessageEncoding == protobuf.MessageEncodingDelimited
+	field.ValidateUTF8 = field.Type == FieldTypeString && features.UTF8Validation == protobuf.UTF8Verify
+}
+
+// isPackableType reports whether repeated fields of a type can be packed.
+// Custom types are reported as messages, so enums are not recognized here.
+func isPackableType(ft FieldType) bool {
+	switch ft {
+	case FieldTypeString, FieldTypeBytes, FieldTypeMessage, FieldTypeMap, FieldTypeUnknown:
+		return false
+	}
+	return true
+}
+
 func (b *SchemaGraphBuilder) buildEnum(enumNode *protobuf.EnumNode, parentPrefix string) *Enum {
 	fullName := b.makeFullName(parentPrefix, enumNode.Name)
 
@@ -297,7 +593,11 @@ func (b *SchemaGraphBuilder) buildEnum(enumNode *protobuf.EnumNode, parentPrefix
 		FullName:     fullName,
 		Values:       make(map[int]*EnumValue),
 		ValuesByName: make(map[string]*EnumValue),
-		Options:      make(map[string]string),
+		Options:      b.buildOptions(enumNode.Options),
+		Reserved:     buildReserved(enumNode.ReservedRanges, enumNode.ReservedNames),
+		Closed:       !enumNode.IsOpenEnum(),
+		File:         b.currentFile,
+		Line:         enumNode.Pos.Line,
 	}
 
 	for _, valNode := range enumNode.Values {
@@ -305,6 +605,7 @@ func (b *SchemaGraphBuilder) buildEnum(enumNode *protobuf.EnumNode, parentPrefix
 			Name:       valNode.Name,
 			Number:     valNode.Number,
 			Deprecated: b.hasDeprecatedOption(valNode.Options),
+			Line:       valNode.Pos.Line,
 		}
 		enum.Values[val.Number] = val
 		enum.ValuesByName[val.Name] = val
@@ -320,6 +621,8 @@ func (b *SchemaGraphBuilder) buildService(svcNode *protobuf.ServiceNode) *Servic
 		Name:     svcNode.Name,
 		FullName: fullName,
 		Methods:  make(map[string]*Method),
+		File:     b.currentFile,
+		Line:     svcNode.Pos.Line,
 	}
 
 	for _, rpcNode := range svcNode.RPCs {
@@ -330,6 +633,7 @@ func (b *SchemaGraphBuilder) buildService(svcNode *protobuf.ServiceNode) *Servic
 			ClientStreaming: rpcNode.ClientStreaming,
 			ServerStreaming: rpcNode.ServerStreaming,
 			Deprecated:      b.hasDeprecatedOption(rpcNode.Options),
+			Line:            rpcNode.Pos.Line,
 		}
 		svc.Methods[method.Name] = method
 	}
@@ -401,6 +705,16 @@ func (b *SchemaGraphBuilder) parseFieldLabel(fieldNode *protobuf.FieldNode) Fiel
 	return FieldLabelOptional // proto3 default
 }
 
+// buildOptions maps option names, e.g. "deprecated" or "(acme.sensitive)",
+// to their values in proto text syntax
+func (b *SchemaGraphBuilder) buildOptions(options []*protobuf.OptionNode) map[string]string {
+	opts := make(map[string]string, len(options))
+	for _, opt := range options {
+		opts[opt.Name] = opt.Value
+	}
+	return opts
+}
+
 func (b *SchemaGraphBuilder) hasDeprecatedOption(options []*protobuf.OptionNode) bool {
 	for _, opt := range options {
 		if opt.Name == "deprecated" && opt.Value == "true" {
@@ -226,3 +226,15 @@ func TestEnum_ValueLookup(t *testing.T) {
 		t.Errorf("ValuesByName[ACTIVE].Number = %d, want %d", val.Number, 1)
 	}
 }
+
+func TestSourceFiles(t *testing.T) {
+	type storedFile struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	}
+
+	got := SourceFiles([]storedFile{{Path: "a.proto", Content: "syntax = \"proto3\";"}})
+	if len(got) != 1 || got[0].Path != "a.proto" || got[0].Content != "syntax = \"proto3\";" {
+		t.Errorf("SourceFiles() = %+v", got)
+	}
+}
@@ -21,6 +21,9 @@ type Config struct {
 
 	// Observability configuration
 	Observability ObservabilityConfig
+
+	// Code generation configuration
+	Codegen CodegenConfig
 }
 
 // ServerConfig holds HTTP server configuration
@@ -52,12 +55,58 @@ type ObservabilityConfig struct {
 	OTelInsecure       bool // Use insecure gRPC connection
 }
 
+// CodegenConfig holds code generation settings
+type CodegenConfig struct {
+	// Runner selects how compilations run: docker, host or inprocess
+	Runner string
+
+	// Redis cache of compilation results shared by all servers, disabled when
+	// CacheRedisAddr is empty
+	CacheRedisAddr     string
+	CacheRedisPassword string
+	CacheRedisDB       int
+
+	// Local storage of compiled artifacts, disabled when ArtifactsDir is empty
+	// or an S3 bucket is configured
+	ArtifactsDir        string
+	ArtifactsBaseURL    string // External server URL, prefixing download URLs
+	ArtifactsSigningKey string // Kept in ArtifactsDir when empty
+
+	// Workers running queued compile jobs in the server; compile requests
+	// are still queued for spoke-worker processes when zero
+	Workers int
+
+	// Go module proxy, disabled when GoProxyModulePrefix is empty
+	GoProxyModulePrefix string
+	GoProxyIncludeGRPC  bool
+
+	// npm registry, disabled when NPMScope is empty
+	NPMScope       string
+	NPMNameSuffix  string
+	NPMLanguage    string // typescript or javascript
+	NPMIncludeGRPC bool
+	NPMBaseURL     string // External registry URL, derived from requests when empty
+
+	// Python package index
+	PyPIEnabled     bool
+	PyPINamePrefix  string
+	PyPINameSuffix  string
+	PyPIIncludeGRPC bool
+
+	// Maven repository, disabled when MavenGroupID is empty
+	MavenGroupID        string
+	MavenArtifactSuffix string
+	MavenLanguage       string // java or kotlin
+	MavenIncludeGRPC    bool
+}
+
 // LoadConfig loads configuration from environment variables
 func LoadConfig() (*Config, error) {
 	cfg := &Config{
 		Server:        loadServerConfig(),
 		Storage:       loadStorageConfig(),
 		Observability: loadObservabilityConfig(),
+		Codegen:       loadCodegenConfig(),
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -177,6 +226,41 @@ func loadObservabilityConfig() ObservabilityConfig {
 	return cfg
 }
 
+// loadCodegenConfig loads code generation configuration from environment
+func loadCodegenConfig() CodegenConfig {
+	return CodegenConfig{
+		Runner:              getEnv("SPOKE_CODEGEN_RUNNER", "docker"),
+		CacheRedisAddr:      getEnv("SPOKE_CODEGEN_REDIS_ADDR", ""),
+		CacheRedisPassword:  getEnv("SPOKE_CODEGEN_REDIS_PASSWORD", ""),
+		CacheRedisDB:        getEnvInt("SPOKE_CODEGEN_REDIS_DB", 0),
+		ArtifactsDir:        getEnv("SPOKE_CODEGEN_ARTIFACTS_DIR", ""),
+		ArtifactsBaseURL:    getEnv("SPOKE_CODEGEN_ARTIFACTS_BASE_URL", ""),
+		ArtifactsSigningKey: getEnv("SPOKE_CODEGEN_ARTIFACTS_SIGNING_KEY", ""),
+		Workers:             getEnvInt("SPOKE_CODEGEN_WORKERS", 2),
+		GoProxyModulePrefix: getEnv("SPOKE_GOPROXY_MODULE_PREFIX", ""),
+		GoProxyIncludeGRPC:  getEnvBool("SPOKE_GOPROXY_INCLUDE_GRPC", true),
+		NPMScope:            getEnv("SPOKE_NPM_SCOPE", ""),
+		NPMNameSuffix:       getEnv("SPOKE_NPM_NAME_SUFFIX", ""),
+		NPMLanguage:         getEnv("SPOKE_NPM_LANGUAGE", "typescript"),
+		NPMIncludeGRPC:      getEnvBool("SPOKE_NPM_INCLUDE_GRPC", false),
+		NPMBaseURL:          getEnv("SPOKE_NPM_BASE_URL", ""),
+		PyPIEnabled:         getEnvBool("SPOKE_PYPI_ENABLED", false),
+		PyPINamePrefix:      getEnv("SPOKE_PYPI_NAME_PREFIX", ""),
+		PyPINameSuffix:      getEnv("SPOKE_PYPI_NAME_SUFFIX", ""),
+		PyPIIncludeGRPC:     getEnvBool("SPOKE_PYPI_INCLUDE_GRPC", false),
+		MavenGroupID:        getEnv("SPOKE_MAVEN_GROUP_ID", ""),
+		MavenArtifactSuffix: getEnv("SPOKE_MAVEN_ARTIFACT_SUFFIX", ""),
+		MavenLanguage:       getEnv("SPOKE_MAVEN_LANGUAGE", "java"),
+		MavenIncludeGRPC:    getEnvBool("SPOKE_MAVEN_INCLUDE_GRPC", false),
+	}
+}
+
+// LocalArtifacts reports whether compiled artifacts are stored in
+// Codegen.ArtifactsDir and downloaded from the server
+func (c *Config) LocalArtifacts() bool {
+	return c.Codegen.ArtifactsDir != "" && c.Storage.S3Bucket == ""
+}
+
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
 	// Validate server config
@@ -224,6 +308,16 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate code generation config
+	switch c.Codegen.Runner {
+	case "", "docker", "host", "inprocess":
+	default:
+		return fmt.Errorf("invalid codegen runner: %s (must be docker, host, or inprocess)", c.Codegen.Runner)
+	}
+	if c.Codegen.Workers < 0 {
+		return fmt.Errorf("codegen workers must not be negative")
+	}
+
 	return nil
 }
 
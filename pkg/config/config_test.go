@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -717,6 +718,117 @@ func TestLoadObservabilityConfig(t *testing.T) {
 	}
 }
 
+func TestLoadCodegenConfig(t *testing.T) {
+	envVars := []string{
+		"SPOKE_CODEGEN_RUNNER",
+		"SPOKE_CODEGEN_REDIS_ADDR",
+		"SPOKE_CODEGEN_REDIS_PASSWORD",
+		"SPOKE_CODEGEN_REDIS_DB",
+		"SPOKE_CODEGEN_ARTIFACTS_DIR",
+		"SPOKE_CODEGEN_ARTIFACTS_BASE_URL",
+		"SPOKE_CODEGEN_ARTIFACTS_SIGNING_KEY",
+		"SPOKE_CODEGEN_WORKERS",
+		"SPOKE_GOPROXY_MODULE_PREFIX",
+		"SPOKE_GOPROXY_INCLUDE_GRPC",
+		"SPOKE_NPM_SCOPE",
+		"SPOKE_NPM_NAME_SUFFIX",
+		"SPOKE_NPM_LANGUAGE",
+		"SPOKE_NPM_INCLUDE_GRPC",
+		"SPOKE_NPM_BASE_URL",
+		"SPOKE_PYPI_ENABLED",
+		"SPOKE_PYPI_NAME_PREFIX",
+		"SPOKE_PYPI_NAME_SUFFIX",
+		"SPOKE_PYPI_INCLUDE_GRPC",
+		"SPOKE_MAVEN_GROUP_ID",
+		"SPOKE_MAVEN_ARTIFACT_SUFFIX",
+		"SPOKE_MAVEN_LANGUAGE",
+		"SPOKE_MAVEN_INCLUDE_GRPC",
+	}
+	for _, k := range envVars {
+		t.Setenv(k, "")
+	}
+
+	got := loadCodegenConfig()
+	want := CodegenConfig{Runner: "docker", Workers: 2, GoProxyIncludeGRPC: true, NPMLanguage: "typescript", MavenLanguage: "java"}
+	if got != want {
+		t.Errorf("loadCodegenConfig() = %+v, want %+v", got, want)
+	}
+
+	t.Setenv("SPOKE_CODEGEN_RUNNER", "inprocess")
+	t.Setenv("SPOKE_CODEGEN_REDIS_ADDR", "redis:6379")
+	t.Setenv("SPOKE_CODEGEN_REDIS_PASSWORD", "secret")
+	t.Setenv("SPOKE_CODEGEN_REDIS_DB", "2")
+	t.Setenv("SPOKE_CODEGEN_ARTIFACTS_DIR", "/var/lib/spoke/artifacts")
+	t.Setenv("SPOKE_CODEGEN_ARTIFACTS_BASE_URL", "https://spoke.example.com")
+	t.Setenv("SPOKE_CODEGEN_ARTIFACTS_SIGNING_KEY", "signing-secret")
+	t.Setenv("SPOKE_CODEGEN_WORKERS", "0")
+	t.Setenv("SPOKE_GOPROXY_MODULE_PREFIX", "spoke.example.com/go")
+	t.Setenv("SPOKE_GOPROXY_INCLUDE_GRPC", "false")
+	t.Setenv("SPOKE_NPM_SCOPE", "ourorg")
+	t.Setenv("SPOKE_NPM_NAME_SUFFIX", "-proto")
+	t.Setenv("SPOKE_NPM_LANGUAGE", "javascript")
+	t.Setenv("SPOKE_NPM_INCLUDE_GRPC", "true")
+	t.Setenv("SPOKE_NPM_BASE_URL", "https://spoke.example.com/npm")
+	t.Setenv("SPOKE_PYPI_ENABLED", "true")
+	t.Setenv("SPOKE_PYPI_NAME_PREFIX", "ourorg-")
+	t.Setenv("SPOKE_PYPI_NAME_SUFFIX", "-proto")
+	t.Setenv("SPOKE_PYPI_INCLUDE_GRPC", "true")
+	t.Setenv("SPOKE_MAVEN_GROUP_ID", "com.ourorg.proto")
+	t.Setenv("SPOKE_MAVEN_ARTIFACT_SUFFIX", "-proto")
+	t.Setenv("SPOKE_MAVEN_LANGUAGE", "kotlin")
+	t.Setenv("SPOKE_MAVEN_INCLUDE_GRPC", "true")
+	got = loadCodegenConfig()
+	want = CodegenConfig{
+		Runner:              "inprocess",
+		CacheRedisAddr:      "redis:6379",
+		CacheRedisPassword:  "secret",
+		CacheRedisDB:        2,
+		ArtifactsDir:        "/var/lib/spoke/artifacts",
+		ArtifactsBaseURL:    "https://spoke.example.com",
+		ArtifactsSigningKey: "signing-secret",
+		Workers:             0,
+		GoProxyModulePrefix: "spoke.example.com/go",
+		NPMScope:            "ourorg",
+		NPMNameSuffix:       "-proto",
+		NPMLanguage:         "javascript",
+		NPMIncludeGRPC:      true,
+		NPMBaseURL:          "https://spoke.example.com/npm",
+		PyPIEnabled:         true,
+		PyPINamePrefix:      "ourorg-",
+		PyPINameSuffix:      "-proto",
+		PyPIIncludeGRPC:     true,
+		MavenGroupID:        "com.ourorg.proto",
+		MavenArtifactSuffix: "-proto",
+		MavenLanguage:       "kotlin",
+		MavenIncludeGRPC:    true,
+	}
+	if got != want {
+		t.Errorf("loadCodegenConfig() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLocalArtifacts(t *testing.T) {
+	tests := []struct {
+		name         string
+		artifactsDir string
+		s3Bucket     string
+		want         bool
+	}{
+		{"artifacts dir", "/var/lib/spoke/artifacts", "", true},
+		{"no artifacts dir", "", "", false},
+		{"s3 bucket configured", "/var/lib/spoke/artifacts", "spoke", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Codegen: CodegenConfig{ArtifactsDir: tt.artifactsDir}}
+			cfg.Storage.S3Bucket = tt.s3Bucket
+			if got := cfg.LocalArtifacts(); got != tt.want {
+				t.Errorf("LocalArtifacts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // TestConfigValidate tests the Config.Validate method
 func TestConfigValidate(t *testing.T) {
 	// Import storage to use Config type
@@ -1008,6 +1120,43 @@ func TestConfigValidate(t *testing.T) {
 			t.Errorf("Validate() unexpected error = %v", err)
 		}
 	})
+
+	t.Run("invalid codegen runner", func(t *testing.T) {
+		cfg := Config{
+			Server: ServerConfig{
+				Port:       "8080",
+				HealthPort: "9090",
+			},
+			Codegen: CodegenConfig{Runner: "podman"},
+		}
+		cfg.Storage.Type = "filesystem"
+		cfg.Storage.FilesystemRoot = "/tmp/spoke"
+
+		err := cfg.Validate()
+		if err == nil {
+			t.Error("Validate() expected error, got nil")
+		}
+		if err != nil && !strings.Contains(err.Error(), "invalid codegen runner") {
+			t.Errorf("Validate() error = %v, want 'invalid codegen runner'", err.Error())
+		}
+	})
+
+	t.Run("negative codegen workers", func(t *testing.T) {
+		cfg := Config{
+			Server: ServerConfig{
+				Port:       "8080",
+				HealthPort: "9090",
+			},
+			Codegen: CodegenConfig{Runner: "docker", Workers: -1},
+		}
+		cfg.Storage.Type = "filesystem"
+		cfg.Storage.FilesystemRoot = "/tmp/spoke"
+
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), "codegen workers") {
+			t.Errorf("Validate() error = %v, want 'codegen workers'", err)
+		}
+	})
 }
 
 // TestLoadConfig tests the LoadConfig function
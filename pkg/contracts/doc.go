@@ -0,0 +1,35 @@
+// Package contracts records which parts of a module each consumer uses, so
+// breaking-change checks can tell who a proposed change actually breaks.
+//
+// # Overview
+//
+// A usage contract is registered by a consumer (usually a service) against a
+// module version. It lists the messages, fields, enum values and RPCs the
+// consumer relies on. Contracts are either declared by hand in a workspace
+// file or produced by `spoke consumers register --scan`, which matches the
+// identifiers of the module schema against the consumer's generated code.
+//
+// # Breakage Detection
+//
+// Breakages pairs a compatibility.CheckResult with the registered contracts
+// and returns, per consumer, the violations that touch something it uses.
+// Removing a field nobody reads therefore breaks no consumer, while removing
+// one listed in a contract names every service that depends on it:
+//
+//	result, _ := compatibility.CheckCompatibility(oldSchema, newSchema, mode)
+//	list, _ := store.List(ctx, "payments")
+//	for _, b := range contracts.Breakages(result, list) {
+//		fmt.Printf("%s breaks on %d changes\n", b.Consumer, len(b.Changes))
+//	}
+//
+// # Storage
+//
+// FileStore keeps one JSON document per consumer under a directory and suits
+// filesystem deployments. PostgresStore uses the consumer_contracts table
+// created by migration 012.
+//
+// # Related Packages
+//
+//   - pkg/compatibility: Produces the violations matched against contracts
+//   - pkg/dependencies: Includes registered consumers in impact analysis
+package contracts
@@ -0,0 +1,111 @@
+package contracts
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/platinummonkey/spoke/pkg/httputil"
+)
+
+// Handlers provides HTTP handlers for consumer usage contracts
+type Handlers struct {
+	store Store
+}
+
+// NewHandlers creates new contract handlers
+func NewHandlers(store Store) *Handlers {
+	return &Handlers{store: store}
+}
+
+// RegisterRoutes registers contract routes
+func (h *Handlers) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/modules/{name}/consumers", h.listContracts).Methods("GET")
+	router.HandleFunc("/modules/{name}/consumers/{consumer}", h.registerContract).Methods("PUT")
+	router.HandleFunc("/modules/{name}/consumers/{consumer}", h.getContract).Methods("GET")
+	router.HandleFunc("/modules/{name}/consumers/{consumer}", h.deleteContract).Methods("DELETE")
+}
+
+// registerContract handles PUT /modules/{name}/consumers/{consumer}
+func (h *Handlers) registerContract(w http.ResponseWriter, r *http.Request) {
+	vars := httputil.GetPathVars(r)
+
+	var contract Contract
+	if !httputil.ParseJSONOrError(w, r, &contract) {
+		return
+	}
+
+	// Path wins over the body
+	contract.Module = vars["name"]
+	contract.Consumer = vars["consumer"]
+	contract.RegisteredAt = time.Time{}
+	if contract.Uses == nil {
+		contract.Uses = []Usage{}
+	}
+
+	if err := h.store.Register(r.Context(), &contract); err != nil {
+		if errors.Is(err, ErrInvalidContract) {
+			httputil.WriteBadRequest(w, err.Error())
+			return
+		}
+		if errors.Is(err, ErrModuleNotFound) {
+			httputil.WriteNotFoundError(w, err.Error())
+			return
+		}
+		httputil.WriteInternalError(w, err)
+		return
+	}
+
+	httputil.WriteSuccess(w, contract)
+}
+
+// listContracts handles GET /modules/{name}/consumers
+func (h *Handlers) listContracts(w http.ResponseWriter, r *http.Request) {
+	moduleName := httputil.GetPathVars(r)["name"]
+
+	contracts, err := h.store.List(r.Context(), moduleName)
+	if err != nil {
+		httputil.WriteInternalError(w, err)
+		return
+	}
+
+	httputil.WriteSuccess(w, map[string]interface{}{
+		"module":    moduleName,
+		"consumers": contracts,
+		"count":     len(contracts),
+	})
+}
+
+// getContract handles GET /modules/{name}/consumers/{consumer}
+func (h *Handlers) getContract(w http.ResponseWriter, r *http.Request) {
+	vars := httputil.GetPathVars(r)
+
+	contract, err := h.store.Get(r.Context(), vars["name"], vars["consumer"])
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httputil.WriteNotFoundError(w, "contract not found")
+			return
+		}
+		httputil.WriteInternalError(w, err)
+		return
+	}
+
+	httputil.WriteSuccess(w, contract)
+}
+
+// deleteContract handles DELETE /modules/{name}/consumers/{consumer}
+func (h *Handlers) deleteContract(w http.ResponseWriter, r *http.Request) {
+	vars := httputil.GetPathVars(r)
+
+	if err := h.store.Delete(r.Context(), vars["name"], vars["consumer"]); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httputil.WriteNotFoundError(w, "contract not found")
+			return
+		}
+		httputil.WriteInternalError(w, err)
+		return
+	}
+
+	httputil.WriteNoContent(w)
+}
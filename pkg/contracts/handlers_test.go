@@ -0,0 +1,90 @@
+package contracts
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(t *testing.T) *mux.Router {
+	router := mux.NewRouter()
+	NewHandlers(newTestFileStore(t, "payments")).RegisterRoutes(router)
+	return router
+}
+
+func TestHandlers_RegisterAndGet(t *testing.T) {
+	router := newTestRouter(t)
+
+	body, _ := json.Marshal(Contract{
+		Consumer: "ignored",
+		Version:  "v1.0.0",
+		Uses:     []Usage{{Kind: UsageField, Name: "payments.v1.Charge.amount"}},
+	})
+	req := httptest.NewRequest("PUT", "/modules/payments/consumers/billing", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var registered Contract
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&registered))
+	assert.Equal(t, "billing", registered.Consumer, "path overrides body")
+	assert.Equal(t, "payments", registered.Module)
+
+	req = httptest.NewRequest("GET", "/modules/payments/consumers/billing", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest("GET", "/modules/payments/consumers", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var listing struct {
+		Consumers []Contract `json:"consumers"`
+		Count     int        `json:"count"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&listing))
+	assert.Equal(t, 1, listing.Count)
+
+	req = httptest.NewRequest("DELETE", "/modules/payments/consumers/billing", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	req = httptest.NewRequest("GET", "/modules/payments/consumers/billing", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandlers_RegisterErrors(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{"invalid json", "/modules/payments/consumers/billing", "{", http.StatusBadRequest},
+		{"missing version", "/modules/payments/consumers/billing", `{"uses":[]}`, http.StatusBadRequest},
+		{"unknown kind", "/modules/payments/consumers/billing", `{"version":"v1","uses":[{"kind":"x","name":"y"}]}`, http.StatusBadRequest},
+		{"unknown module", "/modules/other/consumers/billing", `{"version":"v1"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package contracts
+
+import (
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/compatibility"
+)
+
+// Breakage lists the changes of a compatibility check that affect one consumer
+type Breakage struct {
+	Consumer string                    `json:"consumer"`
+	Version  string                    `json:"version"`
+	Affected []Usage                   `json:"affected"`
+	Changes  []compatibility.DiffEntry `json:"changes"`
+}
+
+// Breakages returns the consumers broken by a compatibility check. Only
+// error and warning violations are considered; a violation breaks a consumer
+// when it touches an element the consumer uses or the container of one (the
+// message of a used field). Changes to the members of a used message, enum
+// or service only break consumers that use those members. Consumers without
+// any breakage are omitted.
+func Breakages(result *compatibility.CheckResult, contracts []*Contract) []Breakage {
+	if result == nil {
+		return []Breakage{}
+	}
+
+	entries := result.Entries()
+	breakages := make([]Breakage, 0)
+	for _, contract := range contracts {
+		var breakage *Breakage
+		seen := make(map[Usage]bool)
+
+		for i, v := range result.Violations {
+			if v.Level == compatibility.ViolationLevelInfo {
+				continue
+			}
+
+			matched := false
+			for _, use := range contract.Uses {
+				if !affects(v, use) {
+					continue
+				}
+				matched = true
+				if breakage == nil {
+					breakage = &Breakage{Consumer: contract.Consumer, Version: contract.Version}
+				}
+				if !seen[use] {
+					seen[use] = true
+					breakage.Affected = append(breakage.Affected, use)
+				}
+			}
+			if matched {
+				breakage.Changes = append(breakage.Changes, entries[i])
+			}
+		}
+
+		if breakage != nil {
+			breakages = append(breakages, *breakage)
+		}
+	}
+	return breakages
+}
+
+// affects reports whether a violation touches a used element
+func affects(v compatibility.Violation, use Usage) bool {
+	if v.Kind == compatibility.ChangePackageChanged {
+		return v.OldValue == "" || strings.HasPrefix(use.Name, v.OldValue+".")
+	}
+	if v.Location == "" {
+		return false
+	}
+
+	// The changed element itself, or a container of the used element
+	return use.Name == v.Location || strings.HasPrefix(use.Name, v.Location+".")
+}
@@ -0,0 +1,196 @@
+package contracts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/compatibility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const paymentsV1 = `syntax = "proto3";
+package payments.v1;
+
+message Charge {
+  string id = 1;
+  int64 amount = 2;
+  string memo = 3;
+  string customer_id = 4;
+}
+
+message Refund {
+  string id = 1;
+}
+
+service Payments {
+  rpc CreateCharge(Charge) returns (Charge);
+  rpc CreateRefund(Refund) returns (Refund);
+}
+`
+
+// memo removed, Refund and CreateRefund removed
+const paymentsV2 = `syntax = "proto3";
+package payments.v1;
+
+message Charge {
+  string id = 1;
+  int64 amount = 2;
+  string customer_id = 4;
+}
+
+service Payments {
+  rpc CreateCharge(Charge) returns (Charge);
+}
+`
+
+func checkPayments(t *testing.T) *compatibility.CheckResult {
+	oldSchema, err := compatibility.ParseSchema(paymentsV1)
+	require.NoError(t, err)
+	newSchema, err := compatibility.ParseSchema(paymentsV2)
+	require.NoError(t, err)
+	result, err := compatibility.CheckCompatibility(oldSchema, newSchema, compatibility.CompatibilityModeBackward)
+	require.NoError(t, err)
+	return result
+}
+
+func TestBreakages(t *testing.T) {
+	result := checkPayments(t)
+
+	registered := []*Contract{
+		{
+			Consumer: "billing",
+			Version:  "v1.0.0",
+			Uses: []Usage{
+				{Kind: UsageField, Name: "payments.v1.Charge.amount"},
+				{Kind: UsageRPC, Name: "payments.v1.Payments.CreateCharge"},
+			},
+		},
+		{
+			Consumer: "support",
+			Version:  "v1.0.0",
+			Uses: []Usage{
+				{Kind: UsageField, Name: "payments.v1.Charge.memo"},
+				{Kind: UsageField, Name: "payments.v1.Refund.id"},
+			},
+		},
+		{
+			Consumer: "gateway",
+			Version:  "v1.0.0",
+			Uses:     []Usage{{Kind: UsageService, Name: "payments.v1.Payments"}},
+		},
+		{
+			Consumer: "refunds",
+			Version:  "v1.0.0",
+			Uses:     []Usage{{Kind: UsageMessage, Name: "payments.v1.Refund"}},
+		},
+	}
+
+	breakages := Breakages(result, registered)
+	require.Len(t, breakages, 2, "billing and gateway only use unchanged elements")
+
+	support := breakages[0]
+	assert.Equal(t, "support", support.Consumer)
+	assert.ElementsMatch(t, registered[1].Uses, support.Affected)
+	locations := make([]string, 0, len(support.Changes))
+	for _, c := range support.Changes {
+		locations = append(locations, c.Location)
+	}
+	assert.ElementsMatch(t, []string{"payments.v1.Charge.memo", "payments.v1.Refund"}, locations)
+
+	refunds := breakages[1]
+	assert.Equal(t, "refunds", refunds.Consumer)
+	require.Len(t, refunds.Changes, 1)
+	assert.Equal(t, "payments.v1.Refund", refunds.Changes[0].Location)
+}
+
+func TestBreakages_ScannedContracts(t *testing.T) {
+	schema, err := compatibility.ParseSchema(paymentsV1)
+	require.NoError(t, err)
+
+	scan := func(source string) *Contract {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(source), 0644))
+		ids, err := ScanDir(dir)
+		require.NoError(t, err)
+		return &Contract{Consumer: "svc", Version: "v1.0.0", Uses: ids.Usages(schema)}
+	}
+
+	// Removing fields and RPCs of a used message and service that the
+	// consumer never touches does not break it
+	charges := scan(`package main
+
+func charge(c paymentsv1.PaymentsClient) {
+	c.CreateCharge(ctx, &paymentsv1.Charge{Amount: 100})
+}
+`)
+	assert.Empty(t, Breakages(checkPayments(t), []*Contract{charges}))
+
+	memos := scan(`package main
+
+func memo(c *paymentsv1.Charge) string {
+	return c.GetMemo()
+}
+`)
+	breakages := Breakages(checkPayments(t), []*Contract{memos})
+	require.Len(t, breakages, 1)
+	assert.Equal(t, []Usage{{Kind: UsageField, Name: "payments.v1.Charge.memo"}}, breakages[0].Affected)
+	require.Len(t, breakages[0].Changes, 1)
+	assert.Equal(t, "payments.v1.Charge.memo", breakages[0].Changes[0].Location)
+}
+
+func TestBreakages_PackageChange(t *testing.T) {
+	oldSchema, _ := compatibility.ParseSchema("syntax = \"proto3\";\npackage a.v1;\nmessage M { string id = 1; }\n")
+	newSchema, _ := compatibility.ParseSchema("syntax = \"proto3\";\npackage a.v2;\nmessage M { string id = 1; }\n")
+	result, err := compatibility.CheckCompatibility(oldSchema, newSchema, compatibility.CompatibilityModeBackward)
+	require.NoError(t, err)
+
+	breakages := Breakages(result, []*Contract{
+		{Consumer: "svc", Uses: []Usage{{Kind: UsageField, Name: "a.v1.M.id"}}},
+	})
+	require.Len(t, breakages, 1)
+	assert.Equal(t, "svc", breakages[0].Consumer)
+}
+
+func TestBreakages_NilResult(t *testing.T) {
+	assert.Empty(t, Breakages(nil, []*Contract{{Consumer: "svc"}}))
+}
+
+func TestScanDir_Usages(t *testing.T) {
+	dir := t.TempDir()
+
+	// Generated code declares everything and must be ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "payments.pb.go"),
+		[]byte("package paymentsv1\ntype Refund struct{}\nfunc (x *Refund) GetId() string { return \"\" }\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gen.go"),
+		[]byte("// Code generated by protoc-gen-go-grpc. DO NOT EDIT.\npackage paymentsv1\ntype PaymentsClient interface{ CreateRefund() }\n"), 0644))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(`package main
+
+func charge(c paymentsv1.PaymentsClient) {
+	req := &paymentsv1.Charge{Amount: 100, CustomerId: "c1"}
+	c.CreateCharge(ctx, req)
+}
+`), 0644))
+
+	ids, err := ScanDir(dir)
+	require.NoError(t, err)
+
+	schema, err := compatibility.ParseSchema(paymentsV1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Usage{
+		{Kind: UsageMessage, Name: "payments.v1.Charge"},
+		{Kind: UsageField, Name: "payments.v1.Charge.amount"},
+		{Kind: UsageField, Name: "payments.v1.Charge.customer_id"},
+		{Kind: UsageService, Name: "payments.v1.Payments"},
+		{Kind: UsageRPC, Name: "payments.v1.Payments.CreateCharge"},
+	}, ids.Usages(schema))
+}
+
+func TestToCamelCase(t *testing.T) {
+	assert.Equal(t, "CustomerId", toCamelCase("customer_id"))
+	assert.Equal(t, "Amount", toCamelCase("amount"))
+	assert.Equal(t, "create_charge", toSnakeCase("CreateCharge"))
+}
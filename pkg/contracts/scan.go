@@ -0,0 +1,209 @@
+package contracts
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/compatibility"
+)
+
+var identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// scannedExtensions are the source files considered by ScanDir
+var scannedExtensions = map[string]bool{
+	".go": true, ".py": true, ".java": true, ".kt": true, ".ts": true,
+	".js": true, ".rs": true, ".cs": true, ".swift": true, ".dart": true,
+	".cc": true, ".cpp": true, ".h": true, ".rb": true, ".php": true,
+}
+
+// Identifiers is the set of identifiers referenced by a consumer's sources
+type Identifiers map[string]bool
+
+// ScanDir collects the identifiers used by the source files under dir.
+// Generated protobuf code is skipped: it declares every element of the
+// schema, so only the code that calls into it says what the consumer uses.
+func ScanDir(dir string) (Identifiers, error) {
+	ids := make(Identifiers)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !scannedExtensions[filepath.Ext(path)] || isGeneratedFileName(d.Name()) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		ids.Add(string(content))
+		return nil
+	})
+	return ids, err
+}
+
+// Add records the identifiers of a source file unless it is generated code
+func (ids Identifiers) Add(content string) {
+	if isGeneratedContent(content) {
+		return
+	}
+	for _, id := range identifierPattern.FindAllString(content, -1) {
+		ids[id] = true
+	}
+}
+
+func isGeneratedFileName(name string) bool {
+	for _, suffix := range []string{".pb.go", "_pb2.py", "_pb2_grpc.py", "_pb.js", "_pb.ts", ".pb.cc", ".pb.h"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGeneratedContent detects the standard generated-code header in the
+// first lines of a file
+func isGeneratedContent(content string) bool {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for i := 0; i < 10 && scanner.Scan(); i++ {
+		line := scanner.Text()
+		if strings.Contains(line, "DO NOT EDIT") || strings.Contains(line, "Generated by the protocol buffer compiler") {
+			return true
+		}
+	}
+	return false
+}
+
+// Usages derives the usage list of a schema from scanned identifiers. The
+// match is name based: a message is used when its generated type name
+// appears, a field when its accessor or attribute name appears alongside a
+// used message, an RPC when its method name appears alongside a client or
+// server of its service. Results are sorted for stable contracts.
+func (ids Identifiers) Usages(schema *compatibility.SchemaGraph) []Usage {
+	usages := make([]Usage, 0)
+	if schema == nil {
+		return usages
+	}
+
+	var visitMessage func(msg *compatibility.Message, typeName string)
+	visitMessage = func(msg *compatibility.Message, typeName string) {
+		if !ids[typeName] && !ids[msg.Name] {
+			return
+		}
+		usages = append(usages, Usage{Kind: UsageMessage, Name: msg.FullName})
+		for _, field := range msg.FieldsByName {
+			if ids.usesField(field.Name) {
+				usages = append(usages, Usage{Kind: UsageField, Name: msg.FullName + "." + field.Name})
+			}
+		}
+		for _, nested := range msg.Nested {
+			visitMessage(nested, typeName+"_"+nested.Name)
+		}
+		for _, enum := range msg.NestedEnums {
+			usages = append(usages, ids.enumUsages(enum, typeName+"_"+enum.Name)...)
+		}
+	}
+
+	for _, msg := range schema.Messages {
+		visitMessage(msg, msg.Name)
+	}
+	for _, enum := range schema.Enums {
+		usages = append(usages, ids.enumUsages(enum, enum.Name)...)
+	}
+	for _, svc := range schema.Services {
+		if !ids.usesService(svc.Name) {
+			continue
+		}
+		usages = append(usages, Usage{Kind: UsageService, Name: svc.FullName})
+		for _, method := range svc.Methods {
+			if ids[method.Name] || ids[lowerFirst(method.Name)] || ids[toSnakeCase(method.Name)] {
+				usages = append(usages, Usage{Kind: UsageRPC, Name: svc.FullName + "." + method.Name})
+			}
+		}
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Name != usages[j].Name {
+			return usages[i].Name < usages[j].Name
+		}
+		return usages[i].Kind < usages[j].Kind
+	})
+	return usages
+}
+
+func (ids Identifiers) enumUsages(enum *compatibility.Enum, typeName string) []Usage {
+	var usages []Usage
+	enumUsed := ids[typeName] || ids[enum.Name]
+	for _, value := range enum.ValuesByName {
+		if ids[value.Name] || ids[typeName+"_"+value.Name] {
+			enumUsed = true
+			usages = append(usages, Usage{Kind: UsageEnumValue, Name: enum.FullName + "." + value.Name})
+		}
+	}
+	if enumUsed {
+		usages = append(usages, Usage{Kind: UsageEnum, Name: enum.FullName})
+	}
+	return usages
+}
+
+func (ids Identifiers) usesField(name string) bool {
+	camel := toCamelCase(name)
+	return ids[camel] || ids["Get"+camel] || ids["get"+camel] || ids["set"+camel] ||
+		ids[lowerFirst(camel)] || ids[name]
+}
+
+func (ids Identifiers) usesService(name string) bool {
+	return ids[name] || ids[name+"Client"] || ids[name+"Server"] || ids[name+"Stub"] ||
+		ids["New"+name+"Client"] || ids["Register"+name+"Server"]
+}
+
+// toCamelCase converts a proto field name to its generated Go/Java form
+func toCamelCase(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper && r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func toSnakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,277 @@
+package contracts
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store persists usage contracts. A consumer holds at most one contract per
+// module; registering again replaces the previous one.
+type Store interface {
+	Register(ctx context.Context, contract *Contract) error
+	Get(ctx context.Context, module, consumer string) (*Contract, error)
+	List(ctx context.Context, module string) ([]*Contract, error)
+	Delete(ctx context.Context, module, consumer string) error
+}
+
+// FileStore stores contracts next to the module data of filesystem storage,
+// as JSON documents under <root>/<module>/consumers/<consumer>.json
+type FileStore struct {
+	root string
+	mu   sync.RWMutex
+}
+
+// NewFileStore creates a contract store for a filesystem storage root
+func NewFileStore(root string) (*FileStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create root directory: %w", err)
+	}
+	return &FileStore{root: root}, nil
+}
+
+func (s *FileStore) consumersDir(module string) string {
+	return filepath.Join(s.root, module, "consumers")
+}
+
+func (s *FileStore) contractPath(module, consumer string) string {
+	return filepath.Join(s.consumersDir(module), consumer+".json")
+}
+
+// safePathSegment rejects names that would escape the storage root
+func safePathSegment(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// Register stores or replaces a consumer's contract
+func (s *FileStore) Register(ctx context.Context, contract *Contract) error {
+	if err := contract.Validate(); err != nil {
+		return err
+	}
+	if !safePathSegment(contract.Module) {
+		return fmt.Errorf("%w: invalid module name %q", ErrInvalidContract, contract.Module)
+	}
+	if contract.RegisteredAt.IsZero() {
+		contract.RegisteredAt = time.Now().UTC()
+	}
+
+	data, err := json.MarshalIndent(contract, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal contract: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Contracts can only be registered against existing modules
+	if _, err := os.Stat(filepath.Join(s.root, contract.Module, "module.json")); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrModuleNotFound, contract.Module)
+		}
+		return fmt.Errorf("failed to stat module: %w", err)
+	}
+	if err := os.MkdirAll(s.consumersDir(contract.Module), 0755); err != nil {
+		return fmt.Errorf("failed to create consumers directory: %w", err)
+	}
+	if err := os.WriteFile(s.contractPath(contract.Module, contract.Consumer), data, 0644); err != nil {
+		return fmt.Errorf("failed to write contract: %w", err)
+	}
+	return nil
+}
+
+// Get returns a consumer's contract for a module
+func (s *FileStore) Get(ctx context.Context, module, consumer string) (*Contract, error) {
+	if !safePathSegment(module) || !safePathSegment(consumer) {
+		return nil, ErrNotFound
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, err := os.ReadFile(s.contractPath(module, consumer))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read contract: %w", err)
+	}
+
+	var contract Contract
+	if err := json.Unmarshal(data, &contract); err != nil {
+		return nil, fmt.Errorf("failed to parse contract: %w", err)
+	}
+	return &contract, nil
+}
+
+// List returns all contracts registered against a module, ordered by consumer
+func (s *FileStore) List(ctx context.Context, module string) ([]*Contract, error) {
+	if !safePathSegment(module) {
+		return []*Contract{}, nil
+	}
+
+	s.mu.RLock()
+	entries, err := os.ReadDir(s.consumersDir(module))
+	s.mu.RUnlock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Contract{}, nil
+		}
+		return nil, fmt.Errorf("failed to list contracts: %w", err)
+	}
+
+	contracts := make([]*Contract, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		contract, err := s.Get(ctx, module, strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		contracts = append(contracts, contract)
+	}
+
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].Consumer < contracts[j].Consumer
+	})
+	return contracts, nil
+}
+
+// Delete removes a consumer's contract
+func (s *FileStore) Delete(ctx context.Context, module, consumer string) error {
+	if !safePathSegment(module) || !safePathSegment(consumer) {
+		return ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.contractPath(module, consumer)); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete contract: %w", err)
+	}
+	return nil
+}
+
+// PostgresStore stores contracts in the consumer_contracts table
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a contract store backed by PostgreSQL
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Register stores or replaces a consumer's contract
+func (s *PostgresStore) Register(ctx context.Context, contract *Contract) error {
+	if err := contract.Validate(); err != nil {
+		return err
+	}
+	if contract.RegisteredAt.IsZero() {
+		contract.RegisteredAt = time.Now().UTC()
+	}
+
+	uses, err := json.Marshal(contract.Uses)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usages: %w", err)
+	}
+
+	query := `
+		INSERT INTO consumer_contracts (module_name, consumer, version, source, uses, registered_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (module_name, consumer) DO UPDATE SET
+			version = EXCLUDED.version,
+			source = EXCLUDED.source,
+			uses = EXCLUDED.uses,
+			registered_at = EXCLUDED.registered_at
+	`
+	_, err = s.db.ExecContext(ctx, query,
+		contract.Module, contract.Consumer, contract.Version, contract.Source, uses, contract.RegisteredAt)
+	if err != nil {
+		return fmt.Errorf("failed to register contract: %w", err)
+	}
+	return nil
+}
+
+// Get returns a consumer's contract for a module
+func (s *PostgresStore) Get(ctx context.Context, module, consumer string) (*Contract, error) {
+	query := `
+		SELECT module_name, consumer, version, COALESCE(source, ''), uses, registered_at
+		FROM consumer_contracts
+		WHERE module_name = $1 AND consumer = $2
+	`
+	contract, err := scanContract(s.db.QueryRowContext(ctx, query, module, consumer))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contract: %w", err)
+	}
+	return contract, nil
+}
+
+// List returns all contracts registered against a module, ordered by consumer
+func (s *PostgresStore) List(ctx context.Context, module string) ([]*Contract, error) {
+	query := `
+		SELECT module_name, consumer, version, COALESCE(source, ''), uses, registered_at
+		FROM consumer_contracts
+		WHERE module_name = $1
+		ORDER BY consumer
+	`
+	rows, err := s.db.QueryContext(ctx, query, module)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contracts: %w", err)
+	}
+	defer rows.Close()
+
+	contracts := make([]*Contract, 0)
+	for rows.Next() {
+		contract, err := scanContract(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan contract: %w", err)
+		}
+		contracts = append(contracts, contract)
+	}
+	return contracts, rows.Err()
+}
+
+// Delete removes a consumer's contract
+func (s *PostgresStore) Delete(ctx context.Context, module, consumer string) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM consumer_contracts WHERE module_name = $1 AND consumer = $2`, module, consumer)
+	if err != nil {
+		return fmt.Errorf("failed to delete contract: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanContract(row rowScanner) (*Contract, error) {
+	var contract Contract
+	var uses []byte
+	if err := row.Scan(&contract.Module, &contract.Consumer, &contract.Version,
+		&contract.Source, &uses, &contract.RegisteredAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(uses, &contract.Uses); err != nil {
+		return nil, fmt.Errorf("failed to parse usages: %w", err)
+	}
+	return &contract, nil
+}
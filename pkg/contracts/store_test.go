@@ -0,0 +1,150 @@
+package contracts
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFileStore(t *testing.T, modules ...string) *FileStore {
+	root := t.TempDir()
+	for _, m := range modules {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, m), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, m, "module.json"), []byte(`{}`), 0644))
+	}
+	store, err := NewFileStore(root)
+	require.NoError(t, err)
+	return store
+}
+
+func TestFileStore_RegisterListDelete(t *testing.T) {
+	ctx := context.Background()
+	store := newTestFileStore(t, "payments")
+
+	for _, consumer := range []string{"ledger", "billing"} {
+		err := store.Register(ctx, &Contract{
+			Consumer: consumer,
+			Module:   "payments",
+			Version:  "v1.0.0",
+			Uses:     []Usage{{Kind: UsageField, Name: "payments.v1.Charge.amount"}},
+		})
+		require.NoError(t, err)
+	}
+
+	list, err := store.List(ctx, "payments")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "billing", list[0].Consumer)
+	assert.Equal(t, "ledger", list[1].Consumer)
+	assert.False(t, list[0].RegisteredAt.IsZero())
+
+	// Registering again replaces the contract
+	err = store.Register(ctx, &Contract{Consumer: "billing", Module: "payments", Version: "v1.1.0"})
+	require.NoError(t, err)
+	got, err := store.Get(ctx, "payments", "billing")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", got.Version)
+	assert.Empty(t, got.Uses)
+
+	require.NoError(t, store.Delete(ctx, "payments", "billing"))
+	_, err = store.Get(ctx, "payments", "billing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, store.Delete(ctx, "payments", "billing"), ErrNotFound)
+}
+
+func TestFileStore_Errors(t *testing.T) {
+	ctx := context.Background()
+	store := newTestFileStore(t, "payments")
+
+	err := store.Register(ctx, &Contract{Consumer: "billing", Module: "unknown", Version: "v1.0.0"})
+	assert.ErrorIs(t, err, ErrModuleNotFound)
+
+	err = store.Register(ctx, &Contract{Consumer: "../billing", Module: "payments", Version: "v1.0.0"})
+	assert.ErrorIs(t, err, ErrInvalidContract)
+
+	err = store.Register(ctx, &Contract{Consumer: "billing", Module: "..", Version: "v1.0.0"})
+	assert.ErrorIs(t, err, ErrInvalidContract)
+
+	err = store.Register(ctx, &Contract{
+		Consumer: "billing", Module: "payments", Version: "v1.0.0",
+		Uses: []Usage{{Kind: "table", Name: "x"}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidContract)
+
+	list, err := store.List(ctx, "other")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestPostgresStore_Register(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	mock.ExpectExec("INSERT INTO consumer_contracts").
+		WithArgs("payments", "billing", "v1.0.0", "declared", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = store.Register(context.Background(), &Contract{
+		Consumer: "billing",
+		Module:   "payments",
+		Version:  "v1.0.0",
+		Source:   "declared",
+		Uses:     []Usage{{Kind: UsageRPC, Name: "payments.v1.Payments.Charge"}},
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_ListAndGet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	uses, _ := json.Marshal([]Usage{{Kind: UsageMessage, Name: "payments.v1.Charge"}})
+	now := time.Now()
+
+	columns := []string{"module_name", "consumer", "version", "source", "uses", "registered_at"}
+	mock.ExpectQuery("SELECT (.+) FROM consumer_contracts").
+		WithArgs("payments").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("payments", "billing", "v1.0.0", "scan", uses, now).
+			AddRow("payments", "ledger", "v1.0.0", "", []byte("[]"), now))
+
+	list, err := store.List(context.Background(), "payments")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "payments.v1.Charge", list[0].Uses[0].Name)
+	assert.Empty(t, list[1].Uses)
+
+	mock.ExpectQuery("SELECT (.+) FROM consumer_contracts").
+		WithArgs("payments", "missing").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = store.Get(context.Background(), "payments", "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_DeleteMissing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM consumer_contracts").
+		WithArgs("payments", "billing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = NewPostgresStore(db).Delete(context.Background(), "payments", "billing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
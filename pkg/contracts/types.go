@@ -0,0 +1,71 @@
+package contracts
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Common errors
+var (
+	ErrNotFound        = errors.New("contract not found")
+	ErrInvalidContract = errors.New("invalid contract")
+	ErrModuleNotFound  = errors.New("module not found")
+)
+
+// UsageKind is the kind of schema element a consumer uses
+type UsageKind string
+
+const (
+	UsageMessage   UsageKind = "message"
+	UsageField     UsageKind = "field"
+	UsageEnum      UsageKind = "enum"
+	UsageEnumValue UsageKind = "enum_value"
+	UsageService   UsageKind = "service"
+	UsageRPC       UsageKind = "rpc"
+)
+
+// Usage is a single schema element a consumer relies on, identified by its
+// fully qualified name (e.g. payments.v1.Charge.amount for a field)
+type Usage struct {
+	Kind UsageKind `json:"kind" yaml:"kind"`
+	Name string    `json:"name" yaml:"name"`
+}
+
+// Contract is the set of elements of a module version used by one consumer
+type Contract struct {
+	Consumer     string    `json:"consumer" yaml:"consumer"`
+	Module       string    `json:"module" yaml:"module"`
+	Version      string    `json:"version" yaml:"version"`
+	Source       string    `json:"source,omitempty" yaml:"source,omitempty"` // "scan" or "declared"
+	Uses         []Usage   `json:"uses" yaml:"uses"`
+	RegisteredAt time.Time `json:"registered_at" yaml:"-"`
+}
+
+var consumerNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// Validate checks that the contract identifies its consumer and module and
+// only lists known usage kinds
+func (c *Contract) Validate() error {
+	if !consumerNamePattern.MatchString(c.Consumer) {
+		return fmt.Errorf("%w: consumer name %q must be alphanumeric with . _ -", ErrInvalidContract, c.Consumer)
+	}
+	if c.Module == "" {
+		return fmt.Errorf("%w: module is required", ErrInvalidContract)
+	}
+	if c.Version == "" {
+		return fmt.Errorf("%w: version is required", ErrInvalidContract)
+	}
+	for _, u := range c.Uses {
+		switch u.Kind {
+		case UsageMessage, UsageField, UsageEnum, UsageEnumValue, UsageService, UsageRPC:
+		default:
+			return fmt.Errorf("%w: unknown usage kind %q", ErrInvalidContract, u.Kind)
+		}
+		if u.Name == "" {
+			return fmt.Errorf("%w: usage of kind %s has no name", ErrInvalidContract, u.Kind)
+		}
+	}
+	return nil
+}
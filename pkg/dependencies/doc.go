@@ -42,6 +42,16 @@
 //			module.Name, module.UsageCount)
 //	}
 //
+// Registered consumers (see pkg/contracts) are part of the impact. Recording
+// the breakages of a proposed version names the consumers it would break:
+//
+//	graph.AddConsumer(contract)
+//	graph.SetBrokenConsumers("common", contracts.Breakages(result, registered))
+//	impact := graph.GetImpactAnalysis("common", "v1.0.0")
+//	for _, b := range impact.BrokenConsumers {
+//		fmt.Printf("  - %s breaks on %d changes\n", b.Consumer, len(b.Changes))
+//	}
+//
 // Generate lockfile:
 //
 //	lockfile := graph.GenerateLockfile()
@@ -50,5 +60,6 @@
 // # Related Packages
 //
 //   - pkg/compatibility: Breaking change detection
+//   - pkg/contracts: Consumer usage contracts
 //   - pkg/codegen: Uses dependency graph for compilation
 package dependencies
@@ -6,6 +6,7 @@ import (
 
 	"github.com/platinummonkey/spoke/pkg/api"
 	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/contracts"
 )
 
 // Dependency represents a module dependency
@@ -17,8 +18,10 @@ type Dependency struct {
 
 // DependencyGraph represents the dependency graph
 type DependencyGraph struct {
-	nodes map[string]*Node
-	edges map[string][]string // key -> list of dependencies
+	nodes     map[string]*Node
+	edges     map[string][]string              // key -> list of dependencies
+	consumers map[string][]*contracts.Contract // module@version -> registered consumer contracts
+	broken    map[string][]contracts.Breakage  // module -> consumers broken by a proposed change
 }
 
 // Node represents a node in the dependency graph
@@ -31,11 +34,25 @@ type Node struct {
 // NewDependencyGraph creates a new dependency graph
 func NewDependencyGraph() *DependencyGraph {
 	return &DependencyGraph{
-		nodes: make(map[string]*Node),
-		edges: make(map[string][]string),
+		nodes:     make(map[string]*Node),
+		edges:     make(map[string][]string),
+		consumers: make(map[string][]*contracts.Contract),
+		broken:    make(map[string][]contracts.Breakage),
 	}
 }
 
+// AddConsumer records a consumer's usage contract against the module
+// version it uses
+func (g *DependencyGraph) AddConsumer(contract *contracts.Contract) {
+	key := moduleVersionKey(contract.Module, contract.Version)
+	g.consumers[key] = append(g.consumers[key], contract)
+}
+
+// SetBrokenConsumers records the consumers a proposed change to a module breaks
+func (g *DependencyGraph) SetBrokenConsumers(module string, breakages []contracts.Breakage) {
+	g.broken[module] = breakages
+}
+
 // AddNode adds a node to the graph
 func (g *DependencyGraph) AddNode(module, version string, deps []Dependency) {
 	key := moduleVersionKey(module, version)
@@ -238,22 +255,36 @@ func (g *DependencyGraph) GetImpactAnalysis(module, version string) *ImpactAnaly
 		traverse(dep.Module, dep.Version)
 	}
 
+	registered := g.consumers[moduleVersionKey(module, version)]
+	consumers := make([]Dependency, 0, len(registered))
+	for _, contract := range registered {
+		consumers = append(consumers, Dependency{
+			Module:  contract.Consumer,
+			Version: contract.Version,
+			Type:    "consumer",
+		})
+	}
+
 	return &ImpactAnalysis{
-		Module:             module,
-		Version:            version,
-		DirectDependents:   directDependents,
+		Module:               module,
+		Version:              version,
+		DirectDependents:     directDependents,
 		TransitiveDependents: allDependents,
-		TotalImpact:        len(directDependents) + len(allDependents),
+		Consumers:            consumers,
+		BrokenConsumers:      g.broken[module],
+		TotalImpact:          len(directDependents) + len(allDependents) + len(consumers),
 	}
 }
 
 // ImpactAnalysis represents the impact of changes
 type ImpactAnalysis struct {
-	Module               string       `json:"module"`
-	Version              string       `json:"version"`
-	DirectDependents     []Dependency `json:"direct_dependents"`
-	TransitiveDependents []Dependency `json:"transitive_dependents"`
-	TotalImpact          int          `json:"total_impact"`
+	Module               string               `json:"module"`
+	Version              string               `json:"version"`
+	DirectDependents     []Dependency         `json:"direct_dependents"`
+	TransitiveDependents []Dependency         `json:"transitive_dependents"`
+	Consumers            []Dependency         `json:"consumers"`                  // Services with a registered usage contract
+	BrokenConsumers      []contracts.Breakage `json:"broken_consumers,omitempty"` // Set when a proposed version was checked
+	TotalImpact          int                  `json:"total_impact"`
 }
 
 func moduleVersionKey(module, version string) string {
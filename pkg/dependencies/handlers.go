@@ -2,15 +2,19 @@ package dependencies
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/compatibility"
+	"github.com/platinummonkey/spoke/pkg/contracts"
 )
 
 // DependencyHandlers provides HTTP handlers for dependencies
 type DependencyHandlers struct {
-	resolver *DependencyResolver
+	resolver  *DependencyResolver
+	contracts contracts.Store
 }
 
 // NewDependencyHandlers creates new dependency handlers
@@ -20,6 +24,11 @@ func NewDependencyHandlers(storage api.Storage) *DependencyHandlers {
 	}
 }
 
+// SetContractStore enables consumer contracts in impact analysis
+func (h *DependencyHandlers) SetContractStore(store contracts.Store) {
+	h.contracts = store
+}
+
 // RegisterRoutes registers dependency routes
 func (h *DependencyHandlers) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/modules/{name}/versions/{version}/dependencies", h.getDependencies).Methods("GET")
@@ -103,10 +112,25 @@ func (h *DependencyHandlers) getDependents(w http.ResponseWriter, r *http.Reques
 }
 
 // getImpact handles GET /modules/{name}/versions/{version}/impact
+//
+// With ?proposed=<version> the proposed version is checked against this one
+// (in the compatibility mode given by ?mode=, BACKWARD by default) and the
+// registered consumers it breaks are reported.
 func (h *DependencyHandlers) getImpact(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	moduleName := vars["name"]
 	version := vars["version"]
+	proposed := r.URL.Query().Get("proposed")
+
+	mode := compatibility.CompatibilityModeBackward
+	if modeStr := r.URL.Query().Get("mode"); modeStr != "" {
+		parsed, err := compatibility.ParseCompatibilityMode(modeStr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mode = parsed
+	}
 
 	graph, err := h.resolver.BuildDependencyGraph(moduleName, version)
 	if err != nil {
@@ -114,12 +138,58 @@ func (h *DependencyHandlers) getImpact(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.contracts != nil {
+		registered, err := h.contracts.List(r.Context(), moduleName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Only consumers of this version are affected by a change to it
+		pinned := make([]*contracts.Contract, 0, len(registered))
+		for _, contract := range registered {
+			graph.AddConsumer(contract)
+			if contract.Version == version {
+				pinned = append(pinned, contract)
+			}
+		}
+
+		if proposed != "" {
+			result, status, err := h.checkProposed(moduleName, version, proposed, mode)
+			if err != nil {
+				http.Error(w, err.Error(), status)
+				return
+			}
+			graph.SetBrokenConsumers(moduleName, contracts.Breakages(result, pinned))
+		}
+	}
+
 	impact := graph.GetImpactAnalysis(moduleName, version)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(impact)
 }
 
+// checkProposed runs a compatibility check of a proposed version against a base version
+func (h *DependencyHandlers) checkProposed(moduleName, base, proposed string, mode compatibility.CompatibilityMode) (*compatibility.CheckResult, int, error) {
+	schemas := make([]*compatibility.SchemaGraph, 0, 2)
+	for _, v := range []string{base, proposed} {
+		ver, err := h.resolver.storage.GetVersion(moduleName, v)
+		if err != nil {
+			return nil, http.StatusNotFound, fmt.Errorf("version %s not found: %w", v, err)
+		}
+		schema, err := compatibility.ParseSchemaFiles(compatibility.SourceFiles(ver.Files))
+		if err != nil {
+			return nil, http.StatusUnprocessableEntity, fmt.Errorf("failed to parse version %s: %w", v, err)
+		}
+		schemas = append(schemas, schema)
+	}
+	result, err := compatibility.CheckCompatibility(schemas[0], schemas[1], mode)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return result, http.StatusOK, nil
+}
+
 // getLockfile handles GET /modules/{name}/versions/{version}/lockfile
 func (h *DependencyHandlers) getLockfile(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -2,12 +2,16 @@ package dependencies
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/gorilla/mux"
+	"github.com/platinummonkey/spoke/pkg/contracts"
 )
 
 func TestDependencyHandlers_GetDependencies(t *testing.T) {
@@ -504,3 +508,96 @@ func TestDependencyHandlers_RegisterRoutes(t *testing.T) {
 		t.Errorf("Expected at least 7 routes registered, got %d", routeCount)
 	}
 }
+
+func TestDependencyHandlers_GetImpactWithConsumers(t *testing.T) {
+	storage := newMockStorage()
+	storage.addVersion("payments", "v1.0.0", `syntax = "proto3";
+package payments.v1;
+message Charge { string id = 1; string memo = 2; }`)
+	storage.addVersion("payments", "v2.0.0", `syntax = "proto3";
+package payments.v1;
+message Charge { string id = 1; }`)
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "payments"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "payments", "module.json"), []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := contracts.NewFileStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for consumer, field := range map[string]string{"billing": "id", "support": "memo"} {
+		err := store.Register(context.Background(), &contracts.Contract{
+			Consumer: consumer,
+			Module:   "payments",
+			Version:  "v1.0.0",
+			Uses:     []contracts.Usage{{Kind: contracts.UsageField, Name: "payments.v1.Charge." + field}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Consumers of other versions are not affected by this one
+	err = store.Register(context.Background(), &contracts.Contract{
+		Consumer: "ledger",
+		Module:   "payments",
+		Version:  "v0.9.0",
+		Uses:     []contracts.Usage{{Kind: contracts.UsageField, Name: "payments.v1.Charge.memo"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handlers := NewDependencyHandlers(storage)
+	handlers.SetContractStore(store)
+	router := mux.NewRouter()
+	handlers.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/modules/payments/versions/v1.0.0/impact?proposed=v2.0.0", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var impact ImpactAnalysis
+	if err := json.NewDecoder(w.Body).Decode(&impact); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(impact.Consumers) != 2 {
+		t.Errorf("Expected 2 consumers, got %d", len(impact.Consumers))
+	}
+	for _, consumer := range impact.Consumers {
+		if consumer.Version != "v1.0.0" {
+			t.Errorf("Expected only consumers of v1.0.0, got %+v", consumer)
+		}
+	}
+	if impact.TotalImpact != 2 {
+		t.Errorf("Expected total impact 2, got %d", impact.TotalImpact)
+	}
+	if len(impact.BrokenConsumers) != 1 || impact.BrokenConsumers[0].Consumer != "support" {
+		t.Errorf("Expected only support to be broken, got %+v", impact.BrokenConsumers)
+	}
+
+	// Unknown proposed version
+	req = httptest.NewRequest("GET", "/modules/payments/versions/v1.0.0/impact?proposed=v9.0.0", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	// Invalid mode
+	req = httptest.NewRequest("GET", "/modules/payments/versions/v1.0.0/impact?proposed=v2.0.0&mode=SIDEWAYS", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/compatibility"
 )
 
 func TestNewAnalyzer(t *testing.T) {
@@ -75,757 +76,178 @@ func TestCompare(t *testing.T) {
 	}
 }
 
-func TestBuildMessageMap(t *testing.T) {
+func TestCompare_RealChanges(t *testing.T) {
 	analyzer := NewAnalyzer()
 
-	tests := []struct {
-		name  string
-		files []api.File
-	}{
-		{
-			name:  "empty files",
-			files: []api.File{},
-		},
-		{
-			name: "single file",
-			files: []api.File{
-				{Path: "test.proto", Content: "syntax = \"proto3\";"},
-			},
-		},
-		{
-			name: "multiple files",
-			files: []api.File{
-				{Path: "test1.proto", Content: "syntax = \"proto3\";"},
-				{Path: "test2.proto", Content: "syntax = \"proto3\";"},
-			},
-		},
-	}
+	from := &api.Version{
+		Version: "v1.0.0",
+		Files: []api.File{
+			{Path: "user.proto", Content: `syntax = "proto3";
+package users.v1;
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := analyzer.buildMessageMap(tt.files)
-			if result == nil {
-				t.Fatal("buildMessageMap() returned nil")
-			}
-			// Currently returns empty map as it's a placeholder
-			if len(result) != 0 {
-				t.Errorf("buildMessageMap() returned %d messages, expected 0 (placeholder)", len(result))
-			}
-		})
-	}
+message User {
+  string id = 1;
+  string name = 2;
+  repeated string tags = 3;
 }
 
-func TestBuildEnumMap(t *testing.T) {
-	analyzer := NewAnalyzer()
+enum Role {
+  ROLE_UNSPECIFIED = 0;
+  ROLE_ADMIN = 1;
+}
 
-	tests := []struct {
-		name  string
-		files []api.File
-	}{
-		{
-			name:  "empty files",
-			files: []api.File{},
-		},
-		{
-			name: "single file",
-			files: []api.File{
-				{Path: "test.proto", Content: "syntax = \"proto3\";"},
-			},
+service UserService {
+  rpc GetUser(User) returns (User);
+  rpc PurgeUser(User) returns (User);
+}
+`},
 		},
 	}
+	to := &api.Version{
+		Version: "v2.0.0",
+		Files: []api.File{
+			{Path: "user.proto", Content: `syntax = "proto3";
+package users.v1;
+
+message User {
+  string id = 1;
+  bool tags = 3;
+  string email = 4;
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := analyzer.buildEnumMap(tt.files)
-			if result == nil {
-				t.Fatal("buildEnumMap() returned nil")
-			}
-			if len(result) != 0 {
-				t.Errorf("buildEnumMap() returned %d enums, expected 0 (placeholder)", len(result))
-			}
-		})
-	}
+enum Role {
+  ROLE_UNSPECIFIED = 0;
 }
 
-func TestBuildServiceMap(t *testing.T) {
-	analyzer := NewAnalyzer()
+message Team {
+  string id = 1;
+}
 
-	tests := []struct {
-		name  string
-		files []api.File
-	}{
-		{
-			name:  "empty files",
-			files: []api.File{},
-		},
-		{
-			name: "single file",
-			files: []api.File{
-				{Path: "test.proto", Content: "syntax = \"proto3\";"},
-			},
+service UserService {
+  rpc GetUser(User) returns (User);
+}
+`},
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := analyzer.buildServiceMap(tt.files)
-			if result == nil {
-				t.Fatal("buildServiceMap() returned nil")
-			}
-			if len(result) != 0 {
-				t.Errorf("buildServiceMap() returned %d services, expected 0 (placeholder)", len(result))
-			}
-		})
+	result, err := analyzer.Compare(from, to)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
 	}
-}
-
-func TestCompareMessages(t *testing.T) {
-	analyzer := NewAnalyzer()
 
 	tests := []struct {
-		name        string
-		oldMessages map[string]*messageInfo
-		newMessages map[string]*messageInfo
-		wantChanges int
+		changeType ChangeType
+		severity   Severity
+		location   string
 	}{
-		{
-			name:        "both empty",
-			oldMessages: map[string]*messageInfo{},
-			newMessages: map[string]*messageInfo{},
-			wantChanges: 0,
-		},
-		{
-			name: "message removed",
-			oldMessages: map[string]*messageInfo{
-				"User": {
-					Name:   "User",
-					Fields: map[string]*fieldInfo{},
-					File:   "user.proto",
-				},
-			},
-			newMessages: map[string]*messageInfo{},
-			wantChanges: 1,
-		},
-		{
-			name:        "message added",
-			oldMessages: map[string]*messageInfo{},
-			newMessages: map[string]*messageInfo{
-				"User": {
-					Name:   "User",
-					Fields: map[string]*fieldInfo{},
-					File:   "user.proto",
-				},
-			},
-			wantChanges: 1,
-		},
-		{
-			name: "message unchanged",
-			oldMessages: map[string]*messageInfo{
-				"User": {
-					Name:   "User",
-					Fields: map[string]*fieldInfo{},
-					File:   "user.proto",
-				},
-			},
-			newMessages: map[string]*messageInfo{
-				"User": {
-					Name:   "User",
-					Fields: map[string]*fieldInfo{},
-					File:   "user.proto",
-				},
-			},
-			wantChanges: 0,
-		},
+		{FieldRemoved, Breaking, "user.proto:field users.v1.User.name"},
+		{TypeChanged, Breaking, "user.proto:field users.v1.User.tags"},
+		{LabelChanged, Breaking, "user.proto:field users.v1.User.tags"},
+		{FieldAdded, NonBreaking, "user.proto:field users.v1.User.email"},
+		{MessageAdded, NonBreaking, "user.proto:message users.v1.Team"},
+		{EnumValueRemoved, Breaking, "user.proto:value users.v1.Role.ROLE_ADMIN"},
+		{MethodRemoved, Breaking, "user.proto:method users.v1.UserService.PurgeUser"},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			changes := analyzer.compareMessages(tt.oldMessages, tt.newMessages)
-			if changes == nil {
-				t.Fatal("compareMessages() returned nil")
-			}
-			if len(changes) != tt.wantChanges {
-				t.Errorf("compareMessages() returned %d changes, want %d", len(changes), tt.wantChanges)
-			}
-
-			// Verify change properties for message removed
-			if tt.name == "message removed" && len(changes) > 0 {
-				change := changes[0]
-				if change.Type != MessageRemoved {
-					t.Errorf("Change type = %v, want %v", change.Type, MessageRemoved)
-				}
-				if change.Severity != Breaking {
-					t.Errorf("Change severity = %v, want %v", change.Severity, Breaking)
-				}
-				if change.OldValue != "User" {
-					t.Errorf("Change OldValue = %v, want User", change.OldValue)
-				}
-				if change.MigrationTip == "" {
-					t.Error("MigrationTip should not be empty for breaking changes")
+		t.Run(string(tt.changeType), func(t *testing.T) {
+			var found *Change
+			for i := range result.Changes {
+				if result.Changes[i].Type == tt.changeType {
+					found = &result.Changes[i]
+					break
 				}
 			}
-
-			// Verify change properties for message added
-			if tt.name == "message added" && len(changes) > 0 {
-				change := changes[0]
-				if change.Type != MessageAdded {
-					t.Errorf("Change type = %v, want %v", change.Type, MessageAdded)
-				}
-				if change.Severity != NonBreaking {
-					t.Errorf("Change severity = %v, want %v", change.Severity, NonBreaking)
-				}
-				if change.NewValue != "User" {
-					t.Errorf("Change NewValue = %v, want User", change.NewValue)
-				}
+			if found == nil {
+				t.Fatalf("no %s change in %+v", tt.changeType, result.Changes)
 			}
-		})
-	}
-}
-
-func TestCompareFields(t *testing.T) {
-	analyzer := NewAnalyzer()
-
-	tests := []struct {
-		name        string
-		oldMsg      *messageInfo
-		newMsg      *messageInfo
-		wantChanges int
-		checkChange func(t *testing.T, changes []Change)
-	}{
-		{
-			name: "field removed",
-			oldMsg: &messageInfo{
-				Name: "User",
-				File: "user.proto",
-				Fields: map[string]*fieldInfo{
-					"name": {Name: "name", Type: "string", Number: 1},
-				},
-			},
-			newMsg: &messageInfo{
-				Name:   "User",
-				File:   "user.proto",
-				Fields: map[string]*fieldInfo{},
-			},
-			wantChanges: 1,
-			checkChange: func(t *testing.T, changes []Change) {
-				if changes[0].Type != FieldRemoved {
-					t.Errorf("Type = %v, want %v", changes[0].Type, FieldRemoved)
-				}
-				if changes[0].Severity != Breaking {
-					t.Errorf("Severity = %v, want %v", changes[0].Severity, Breaking)
-				}
-			},
-		},
-		{
-			name: "field added",
-			oldMsg: &messageInfo{
-				Name:   "User",
-				File:   "user.proto",
-				Fields: map[string]*fieldInfo{},
-			},
-			newMsg: &messageInfo{
-				Name: "User",
-				File: "user.proto",
-				Fields: map[string]*fieldInfo{
-					"email": {Name: "email", Type: "string", Number: 2},
-				},
-			},
-			wantChanges: 1,
-			checkChange: func(t *testing.T, changes []Change) {
-				if changes[0].Type != FieldAdded {
-					t.Errorf("Type = %v, want %v", changes[0].Type, FieldAdded)
-				}
-				if changes[0].Severity != NonBreaking {
-					t.Errorf("Severity = %v, want %v", changes[0].Severity, NonBreaking)
-				}
-			},
-		},
-		{
-			name: "type changed",
-			oldMsg: &messageInfo{
-				Name: "User",
-				File: "user.proto",
-				Fields: map[string]*fieldInfo{
-					"age": {Name: "age", Type: "int32", Number: 1},
-				},
-			},
-			newMsg: &messageInfo{
-				Name: "User",
-				File: "user.proto",
-				Fields: map[string]*fieldInfo{
-					"age": {Name: "age", Type: "string", Number: 1},
-				},
-			},
-			wantChanges: 1,
-			checkChange: func(t *testing.T, changes []Change) {
-				if changes[0].Type != TypeChanged {
-					t.Errorf("Type = %v, want %v", changes[0].Type, TypeChanged)
-				}
-				if changes[0].OldValue != "int32" {
-					t.Errorf("OldValue = %v, want int32", changes[0].OldValue)
-				}
-				if changes[0].NewValue != "string" {
-					t.Errorf("NewValue = %v, want string", changes[0].NewValue)
-				}
-			},
-		},
-		{
-			name: "field number changed",
-			oldMsg: &messageInfo{
-				Name: "User",
-				File: "user.proto",
-				Fields: map[string]*fieldInfo{
-					"id": {Name: "id", Type: "int32", Number: 1},
-				},
-			},
-			newMsg: &messageInfo{
-				Name: "User",
-				File: "user.proto",
-				Fields: map[string]*fieldInfo{
-					"id": {Name: "id", Type: "int32", Number: 2},
-				},
-			},
-			wantChanges: 1,
-			checkChange: func(t *testing.T, changes []Change) {
-				if changes[0].Type != FieldNumberChanged {
-					t.Errorf("Type = %v, want %v", changes[0].Type, FieldNumberChanged)
-				}
-				if changes[0].OldValue != "1" {
-					t.Errorf("OldValue = %v, want 1", changes[0].OldValue)
-				}
-				if changes[0].NewValue != "2" {
-					t.Errorf("NewValue = %v, want 2", changes[0].NewValue)
-				}
-			},
-		},
-		{
-			name: "label changed",
-			oldMsg: &messageInfo{
-				Name: "User",
-				File: "user.proto",
-				Fields: map[string]*fieldInfo{
-					"tags": {Name: "tags", Type: "string", Number: 1, Label: "optional"},
-				},
-			},
-			newMsg: &messageInfo{
-				Name: "User",
-				File: "user.proto",
-				Fields: map[string]*fieldInfo{
-					"tags": {Name: "tags", Type: "string", Number: 1, Label: "repeated"},
-				},
-			},
-			wantChanges: 1,
-			checkChange: func(t *testing.T, changes []Change) {
-				if changes[0].Type != LabelChanged {
-					t.Errorf("Type = %v, want %v", changes[0].Type, LabelChanged)
-				}
-				if changes[0].OldValue != "optional" {
-					t.Errorf("OldValue = %v, want optional", changes[0].OldValue)
-				}
-				if changes[0].NewValue != "repeated" {
-					t.Errorf("NewValue = %v, want repeated", changes[0].NewValue)
-				}
-			},
-		},
-		{
-			name: "no changes",
-			oldMsg: &messageInfo{
-				Name: "User",
-				File: "user.proto",
-				Fields: map[string]*fieldInfo{
-					"name": {Name: "name", Type: "string", Number: 1, Label: "optional"},
-				},
-			},
-			newMsg: &messageInfo{
-				Name: "User",
-				File: "user.proto",
-				Fields: map[string]*fieldInfo{
-					"name": {Name: "name", Type: "string", Number: 1, Label: "optional"},
-				},
-			},
-			wantChanges: 0,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			changes := analyzer.compareFields(tt.oldMsg, tt.newMsg)
-			if changes == nil {
-				t.Fatal("compareFields() returned nil")
+			if found.Severity != tt.severity {
+				t.Errorf("Severity = %v, want %v", found.Severity, tt.severity)
+			}
+			if found.Location != tt.location {
+				t.Errorf("Location = %q, want %q", found.Location, tt.location)
 			}
-			if len(changes) != tt.wantChanges {
-				t.Errorf("compareFields() returned %d changes, want %d", len(changes), tt.wantChanges)
+			if found.Description == "" {
+				t.Error("Description should not be empty")
 			}
-			if tt.checkChange != nil && len(changes) > 0 {
-				tt.checkChange(t, changes)
+			if tt.severity == Breaking && found.MigrationTip == "" {
+				t.Error("MigrationTip should not be empty for breaking changes")
 			}
 		})
 	}
-}
-
-func TestCompareEnums(t *testing.T) {
-	analyzer := NewAnalyzer()
 
-	tests := []struct {
-		name        string
-		oldEnums    map[string]*enumInfo
-		newEnums    map[string]*enumInfo
-		wantChanges int
-	}{
-		{
-			name:        "both empty",
-			oldEnums:    map[string]*enumInfo{},
-			newEnums:    map[string]*enumInfo{},
-			wantChanges: 0,
-		},
-		{
-			name: "enum removed",
-			oldEnums: map[string]*enumInfo{
-				"Status": {
-					Name:   "Status",
-					Values: map[string]int{},
-					File:   "status.proto",
-				},
-			},
-			newEnums:    map[string]*enumInfo{},
-			wantChanges: 1,
-		},
-		{
-			name:     "enum added",
-			oldEnums: map[string]*enumInfo{},
-			newEnums: map[string]*enumInfo{
-				"Status": {
-					Name:   "Status",
-					Values: map[string]int{},
-					File:   "status.proto",
-				},
-			},
-			wantChanges: 1,
-		},
-		{
-			name: "enum unchanged",
-			oldEnums: map[string]*enumInfo{
-				"Status": {
-					Name:   "Status",
-					Values: map[string]int{},
-					File:   "status.proto",
-				},
-			},
-			newEnums: map[string]*enumInfo{
-				"Status": {
-					Name:   "Status",
-					Values: map[string]int{},
-					File:   "status.proto",
-				},
-			},
-			wantChanges: 0,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			changes := analyzer.compareEnums(tt.oldEnums, tt.newEnums)
-			if changes == nil {
-				t.Fatal("compareEnums() returned nil")
-			}
-			if len(changes) != tt.wantChanges {
-				t.Errorf("compareEnums() returned %d changes, want %d", len(changes), tt.wantChanges)
-			}
-
-			// Verify change properties for enum removed
-			if tt.name == "enum removed" && len(changes) > 0 {
-				change := changes[0]
-				if change.Type != EnumRemoved {
-					t.Errorf("Change type = %v, want %v", change.Type, EnumRemoved)
-				}
-				if change.Severity != Breaking {
-					t.Errorf("Change severity = %v, want %v", change.Severity, Breaking)
-				}
-			}
-
-			// Verify change properties for enum added
-			if tt.name == "enum added" && len(changes) > 0 {
-				change := changes[0]
-				if change.Type != EnumAdded {
-					t.Errorf("Change type = %v, want %v", change.Type, EnumAdded)
-				}
-				if change.Severity != NonBreaking {
-					t.Errorf("Change severity = %v, want %v", change.Severity, NonBreaking)
-				}
-			}
-		})
+	if got := CountByType(result.Changes, MessageAdded); got != 1 {
+		t.Errorf("CountByType(MessageAdded) = %d, want 1", got)
 	}
 }
 
-func TestCompareEnumValues(t *testing.T) {
+func TestCompare_MultipleFiles(t *testing.T) {
 	analyzer := NewAnalyzer()
 
-	tests := []struct {
-		name        string
-		oldEnum     *enumInfo
-		newEnum     *enumInfo
-		wantChanges int
-	}{
-		{
-			name: "enum value removed",
-			oldEnum: &enumInfo{
-				Name:   "Status",
-				File:   "status.proto",
-				Values: map[string]int{"ACTIVE": 0, "INACTIVE": 1},
-			},
-			newEnum: &enumInfo{
-				Name:   "Status",
-				File:   "status.proto",
-				Values: map[string]int{"ACTIVE": 0},
-			},
-			wantChanges: 1,
+	from := &api.Version{
+		Version: "v1.0.0",
+		Files: []api.File{
+			{Path: "a.proto", Content: "syntax = \"proto3\";\npackage demo;\nmessage A { string id = 1; }\n"},
+			{Path: "b.proto", Content: "syntax = \"proto3\";\npackage demo;\nmessage B { string id = 1; }\n"},
 		},
-		{
-			name: "enum value added",
-			oldEnum: &enumInfo{
-				Name:   "Status",
-				File:   "status.proto",
-				Values: map[string]int{"ACTIVE": 0},
-			},
-			newEnum: &enumInfo{
-				Name:   "Status",
-				File:   "status.proto",
-				Values: map[string]int{"ACTIVE": 0, "PENDING": 2},
-			},
-			wantChanges: 1,
-		},
-		{
-			name: "no changes",
-			oldEnum: &enumInfo{
-				Name:   "Status",
-				File:   "status.proto",
-				Values: map[string]int{"ACTIVE": 0, "INACTIVE": 1},
-			},
-			newEnum: &enumInfo{
-				Name:   "Status",
-				File:   "status.proto",
-				Values: map[string]int{"ACTIVE": 0, "INACTIVE": 1},
-			},
-			wantChanges: 0,
+	}
+	to := &api.Version{
+		Version: "v1.1.0",
+		Files: []api.File{
+			{Path: "a.proto", Content: "syntax = \"proto3\";\npackage demo;\nmessage A { string id = 1; }\n"},
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			changes := analyzer.compareEnumValues(tt.oldEnum, tt.newEnum)
-			if changes == nil {
-				t.Fatal("compareEnumValues() returned nil")
-			}
-			if len(changes) != tt.wantChanges {
-				t.Errorf("compareEnumValues() returned %d changes, want %d", len(changes), tt.wantChanges)
-			}
-
-			if tt.name == "enum value removed" && len(changes) > 0 {
-				change := changes[0]
-				if change.Type != EnumValueRemoved {
-					t.Errorf("Change type = %v, want %v", change.Type, EnumValueRemoved)
-				}
-				if change.Severity != Breaking {
-					t.Errorf("Change severity = %v, want %v", change.Severity, Breaking)
-				}
-			}
-
-			if tt.name == "enum value added" && len(changes) > 0 {
-				change := changes[0]
-				if change.Type != EnumValueAdded {
-					t.Errorf("Change type = %v, want %v", change.Type, EnumValueAdded)
-				}
-				if change.Severity != NonBreaking {
-					t.Errorf("Change severity = %v, want %v", change.Severity, NonBreaking)
-				}
-			}
-		})
+	result, err := analyzer.Compare(from, to)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if len(result.Changes) != 1 {
+		t.Fatalf("Compare() returned %d changes, want 1: %+v", len(result.Changes), result.Changes)
+	}
+	if result.Changes[0].Type != MessageRemoved {
+		t.Errorf("Type = %v, want %v", result.Changes[0].Type, MessageRemoved)
+	}
+	if result.Changes[0].Location != "b.proto:message demo.B" {
+		t.Errorf("Location = %q, want b.proto:message demo.B", result.Changes[0].Location)
 	}
 }
 
-func TestCompareServices(t *testing.T) {
-	analyzer := NewAnalyzer()
-
-	tests := []struct {
-		name        string
-		oldServices map[string]*serviceInfo
-		newServices map[string]*serviceInfo
-		wantChanges int
-	}{
-		{
-			name:        "both empty",
-			oldServices: map[string]*serviceInfo{},
-			newServices: map[string]*serviceInfo{},
-			wantChanges: 0,
-		},
-		{
-			name: "service removed",
-			oldServices: map[string]*serviceInfo{
-				"UserService": {
-					Name:    "UserService",
-					Methods: map[string]*methodInfo{},
-					File:    "user.proto",
-				},
-			},
-			newServices: map[string]*serviceInfo{},
-			wantChanges: 1,
-		},
-		{
-			name:        "service added",
-			oldServices: map[string]*serviceInfo{},
-			newServices: map[string]*serviceInfo{
-				"UserService": {
-					Name:    "UserService",
-					Methods: map[string]*methodInfo{},
-					File:    "user.proto",
-				},
-			},
-			wantChanges: 1,
-		},
-		{
-			name: "service unchanged",
-			oldServices: map[string]*serviceInfo{
-				"UserService": {
-					Name:    "UserService",
-					Methods: map[string]*methodInfo{},
-					File:    "user.proto",
-				},
-			},
-			newServices: map[string]*serviceInfo{
-				"UserService": {
-					Name:    "UserService",
-					Methods: map[string]*methodInfo{},
-					File:    "user.proto",
-				},
-			},
-			wantChanges: 0,
-		},
+func TestCompare_ModeAffectsSeverity(t *testing.T) {
+	from := &api.Version{
+		Version: "v1",
+		Files:   []api.File{{Path: "a.proto", Content: "syntax = \"proto3\";\nmessage A { string id = 1; string name = 2; }\n"}},
+	}
+	to := &api.Version{
+		Version: "v2",
+		Files:   []api.File{{Path: "a.proto", Content: "syntax = \"proto3\";\nmessage A { string id = 1; }\n"}},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			changes := analyzer.compareServices(tt.oldServices, tt.newServices)
-			if changes == nil {
-				t.Fatal("compareServices() returned nil")
-			}
-			if len(changes) != tt.wantChanges {
-				t.Errorf("compareServices() returned %d changes, want %d", len(changes), tt.wantChanges)
-			}
-
-			if tt.name == "service removed" && len(changes) > 0 {
-				change := changes[0]
-				if change.Type != ServiceRemoved {
-					t.Errorf("Change type = %v, want %v", change.Type, ServiceRemoved)
-				}
-				if change.Severity != Breaking {
-					t.Errorf("Change severity = %v, want %v", change.Severity, Breaking)
-				}
-			}
+	backward, err := NewAnalyzer().Compare(from, to)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	forward, err := NewAnalyzerWithMode(compatibility.CompatibilityModeForward).Compare(from, to)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
 
-			if tt.name == "service added" && len(changes) > 0 {
-				change := changes[0]
-				if change.Type != ServiceAdded {
-					t.Errorf("Change type = %v, want %v", change.Type, ServiceAdded)
-				}
-				if change.Severity != NonBreaking {
-					t.Errorf("Change severity = %v, want %v", change.Severity, NonBreaking)
-				}
-			}
-		})
+	if len(backward.Changes) != 1 || backward.Changes[0].Severity != Breaking {
+		t.Errorf("BACKWARD field removal = %+v, want one breaking change", backward.Changes)
+	}
+	if len(forward.Changes) != 1 || forward.Changes[0].Severity != Warning {
+		t.Errorf("FORWARD field removal = %+v, want one warning", forward.Changes)
 	}
 }
 
-func TestCompareMethods(t *testing.T) {
+func TestCompare_InvalidProto(t *testing.T) {
 	analyzer := NewAnalyzer()
 
-	tests := []struct {
-		name        string
-		oldSvc      *serviceInfo
-		newSvc      *serviceInfo
-		wantChanges int
-	}{
-		{
-			name: "method removed",
-			oldSvc: &serviceInfo{
-				Name: "UserService",
-				File: "user.proto",
-				Methods: map[string]*methodInfo{
-					"GetUser": {Name: "GetUser", InputType: "GetUserRequest", OutputType: "User"},
-				},
-			},
-			newSvc: &serviceInfo{
-				Name:    "UserService",
-				File:    "user.proto",
-				Methods: map[string]*methodInfo{},
-			},
-			wantChanges: 1,
-		},
-		{
-			name: "method added",
-			oldSvc: &serviceInfo{
-				Name:    "UserService",
-				File:    "user.proto",
-				Methods: map[string]*methodInfo{},
-			},
-			newSvc: &serviceInfo{
-				Name: "UserService",
-				File: "user.proto",
-				Methods: map[string]*methodInfo{
-					"CreateUser": {Name: "CreateUser", InputType: "CreateUserRequest", OutputType: "User"},
-				},
-			},
-			wantChanges: 1,
-		},
-		{
-			name: "no changes",
-			oldSvc: &serviceInfo{
-				Name: "UserService",
-				File: "user.proto",
-				Methods: map[string]*methodInfo{
-					"GetUser": {Name: "GetUser", InputType: "GetUserRequest", OutputType: "User"},
-				},
-			},
-			newSvc: &serviceInfo{
-				Name: "UserService",
-				File: "user.proto",
-				Methods: map[string]*methodInfo{
-					"GetUser": {Name: "GetUser", InputType: "GetUserRequest", OutputType: "User"},
-				},
-			},
-			wantChanges: 0,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			changes := analyzer.compareMethods(tt.oldSvc, tt.newSvc)
-			if changes == nil {
-				t.Fatal("compareMethods() returned nil")
-			}
-			if len(changes) != tt.wantChanges {
-				t.Errorf("compareMethods() returned %d changes, want %d", len(changes), tt.wantChanges)
-			}
+	from := &api.Version{Version: "v1", Files: []api.File{{Path: "bad.proto", Content: "message {"}}}
+	to := &api.Version{Version: "v2"}
 
-			if tt.name == "method removed" && len(changes) > 0 {
-				change := changes[0]
-				if change.Type != MethodRemoved {
-					t.Errorf("Change type = %v, want %v", change.Type, MethodRemoved)
-				}
-				if change.Severity != Breaking {
-					t.Errorf("Change severity = %v, want %v", change.Severity, Breaking)
-				}
-			}
-
-			if tt.name == "method added" && len(changes) > 0 {
-				change := changes[0]
-				if change.Type != MethodAdded {
-					t.Errorf("Change type = %v, want %v", change.Type, MethodAdded)
-				}
-				if change.Severity != NonBreaking {
-					t.Errorf("Change severity = %v, want %v", change.Severity, NonBreaking)
-				}
-			}
-		})
+	if _, err := analyzer.Compare(from, to); err == nil {
+		t.Error("Compare() expected error for unparseable proto")
 	}
 }
 
@@ -1,34 +1,37 @@
 package diff
 
-// ChangeType represents the type of change detected
-type ChangeType string
+import "github.com/platinummonkey/spoke/pkg/compatibility"
+
+// ChangeType represents the type of change detected. It is the structural
+// change kind produced by the compatibility engine.
+type ChangeType = compatibility.ChangeKind
 
 const (
-	FieldAdded        ChangeType = "field_added"
-	FieldRemoved      ChangeType = "field_removed"
-	FieldRenamed      ChangeType = "field_renamed"
-	TypeChanged       ChangeType = "type_changed"
-	MessageAdded      ChangeType = "message_added"
-	MessageRemoved    ChangeType = "message_removed"
-	EnumAdded         ChangeType = "enum_added"
-	EnumRemoved       ChangeType = "enum_removed"
-	EnumValueAdded    ChangeType = "enum_value_added"
-	EnumValueRemoved  ChangeType = "enum_value_removed"
-	ServiceAdded      ChangeType = "service_added"
-	ServiceRemoved    ChangeType = "service_removed"
-	MethodAdded       ChangeType = "method_added"
-	MethodRemoved     ChangeType = "method_removed"
-	FieldNumberChanged ChangeType = "field_number_changed"
-	LabelChanged      ChangeType = "label_changed"
+	FieldAdded         = compatibility.ChangeFieldAdded
+	FieldRemoved       = compatibility.ChangeFieldRemoved
+	FieldRenamed       = compatibility.ChangeFieldRenamed
+	TypeChanged        = compatibility.ChangeFieldTypeChanged
+	MessageAdded       = compatibility.ChangeMessageAdded
+	MessageRemoved     = compatibility.ChangeMessageRemoved
+	EnumAdded          = compatibility.ChangeEnumAdded
+	EnumRemoved        = compatibility.ChangeEnumRemoved
+	EnumValueAdded     = compatibility.ChangeEnumValueAdded
+	EnumValueRemoved   = compatibility.ChangeEnumValueRemoved
+	ServiceAdded       = compatibility.ChangeServiceAdded
+	ServiceRemoved     = compatibility.ChangeServiceRemoved
+	MethodAdded        = compatibility.ChangeMethodAdded
+	MethodRemoved      = compatibility.ChangeMethodRemoved
+	FieldNumberChanged = compatibility.ChangeFieldNumberChanged
+	LabelChanged       = compatibility.ChangeFieldLabelChanged
 )
 
 // Severity represents the severity level of a change
-type Severity string
+type Severity = compatibility.Severity
 
 const (
-	Breaking    Severity = "breaking"
-	NonBreaking Severity = "non_breaking"
-	Warning     Severity = "warning"
+	Breaking    = compatibility.SeverityBreaking
+	NonBreaking = compatibility.SeverityNonBreaking
+	Warning     = compatibility.SeverityWarning
 )
 
 // Change represents a single change between two versions
@@ -49,7 +52,9 @@ type DiffResult struct {
 	Changes     []Change `json:"changes"`
 }
 
-// GetSeverity determines the severity of a change based on its type
+// GetSeverity determines the default severity of a change based on its type
+// alone. Changes returned by Analyzer carry the mode-aware severity derived by
+// the compatibility engine instead.
 func GetSeverity(changeType ChangeType) Severity {
 	switch changeType {
 	case FieldRemoved, FieldRenamed, TypeChanged, MessageRemoved,
@@ -73,28 +78,5 @@ func GetSeverity(changeType ChangeType) Severity {
 
 // GetMigrationTip provides a migration tip based on change type
 func GetMigrationTip(changeType ChangeType, location string) string {
-	switch changeType {
-	case FieldRemoved:
-		return "Remove all references to this field in your code"
-	case FieldRenamed:
-		return "Update all field references to use the new name"
-	case TypeChanged:
-		return "Update code to handle the new field type"
-	case MessageRemoved:
-		return "Remove all usages of this message type"
-	case EnumRemoved:
-		return "Replace enum usages with alternative type"
-	case EnumValueRemoved:
-		return "Update code that uses this enum value"
-	case ServiceRemoved:
-		return "Remove all service client implementations"
-	case MethodRemoved:
-		return "Remove all calls to this method"
-	case FieldNumberChanged:
-		return "This is a critical breaking change - regenerate all code and redeploy all services"
-	case LabelChanged:
-		return "Update code to handle the new field cardinality (single vs repeated)"
-	default:
-		return ""
-	}
+	return compatibility.MigrationTip(changeType)
 }
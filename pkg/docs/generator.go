@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/platinummonkey/spoke/pkg/api"
 	"github.com/platinummonkey/spoke/pkg/api/protobuf"
 )
 
@@ -17,6 +18,7 @@ type Documentation struct {
 	Services    []*ServiceDoc
 	Imports     []string
 	Options     map[string]string
+	Quality     *api.QualityScore // Lint quality of the version, nil when unknown
 }
 
 // MessageDoc represents documentation for a message
@@ -102,7 +104,9 @@ func (g *Generator) Generate(ast *protobuf.RootNode) (*Documentation, error) {
 	}
 
 	// Extract syntax
-	if ast.Syntax != nil {
+	if ast.Syntax.IsEditions() {
+		doc.Syntax = "edition " + ast.Syntax.Edition
+	} else if ast.Syntax != nil {
 		doc.Syntax = ast.Syntax.Value
 	}
 
@@ -69,6 +69,7 @@ func (h *DocsHandlers) getVersionDocs(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to generate documentation: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	doc.Quality = ver.Quality
 
 	// Export to HTML
 	html, err := h.htmlExporter.ExportWithVersion(doc, version)
@@ -112,6 +113,7 @@ func (h *DocsHandlers) getVersionDocsMarkdown(w http.ResponseWriter, r *http.Req
 		http.Error(w, "failed to generate documentation: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	doc.Quality = ver.Quality
 
 	// Export to Markdown
 	markdown := h.markdownExporter.ExportWithVersion(doc, version)
@@ -152,6 +154,7 @@ func (h *DocsHandlers) getVersionDocsJSON(w http.ResponseWriter, r *http.Request
 		http.Error(w, "failed to generate documentation: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	doc.Quality = ver.Quality
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(doc)
@@ -285,6 +285,9 @@ const htmlTemplate = `<!DOCTYPE html>
             {{ if .Version }}
             <span class="badge">Version {{ .Version }}</span>
             {{ end }}
+            {{ with .Quality }}
+            <span class="badge" title="Documentation {{ printf "%.1f" .DocumentationCoverage }}%, complexity {{ printf "%.1f" .Complexity }}, maintainability {{ printf "%.1f" .Maintainability }}">Quality {{ printf "%.0f" .Score }}/100</span>
+            {{ end }}
         </div>
     </header>
 
@@ -3,6 +3,8 @@ package docs
 import (
 	"strings"
 	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/api"
 )
 
 func TestHTMLExporter_Export(t *testing.T) {
@@ -59,6 +61,36 @@ func TestHTMLExporter_ExportWithVersion(t *testing.T) {
 	}
 }
 
+func TestHTMLExporter_WithQuality(t *testing.T) {
+	exporter := NewHTMLExporter()
+
+	doc := &Documentation{
+		PackageName: "test.package",
+		Quality:     &api.QualityScore{Score: 87.4, DocumentationCoverage: 92.5, Complexity: 12, Maintainability: 95},
+	}
+
+	html, err := exporter.ExportWithVersion(doc, "v1.0.0")
+	if err != nil {
+		t.Fatalf("ExportWithVersion failed: %v", err)
+	}
+
+	if !strings.Contains(html, "Quality 87/100") {
+		t.Error("Expected quality badge in HTML")
+	}
+	if !strings.Contains(html, "Documentation 92.5%") {
+		t.Error("Expected quality breakdown in HTML")
+	}
+
+	doc.Quality = nil
+	html, err = exporter.ExportWithVersion(doc, "v1.0.0")
+	if err != nil {
+		t.Fatalf("ExportWithVersion failed: %v", err)
+	}
+	if strings.Contains(html, "Quality ") {
+		t.Error("Expected no quality badge without a score")
+	}
+}
+
 func TestHTMLExporter_WithServices(t *testing.T) {
 	exporter := NewHTMLExporter()
 
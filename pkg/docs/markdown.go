@@ -25,6 +25,11 @@ func (e *MarkdownExporter) Export(doc *Documentation) string {
 		b.WriteString(fmt.Sprintf("**Syntax:** `%s`\n\n", doc.Syntax))
 	}
 
+	if doc.Quality != nil {
+		b.WriteString(fmt.Sprintf("**Quality:** %.1f/100 (documentation %.1f%%, complexity %.1f, maintainability %.1f)\n\n",
+			doc.Quality.Score, doc.Quality.DocumentationCoverage, doc.Quality.Complexity, doc.Quality.Maintainability))
+	}
+
 	if doc.Description != "" {
 		b.WriteString(fmt.Sprintf("%s\n\n", doc.Description))
 	}
@@ -3,6 +3,8 @@ package docs
 import (
 	"strings"
 	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/api"
 )
 
 func TestNewMarkdownExporter(t *testing.T) {
@@ -756,6 +758,20 @@ func TestMarkdownExporter_ExportWithVersion(t *testing.T) {
 	}
 }
 
+func TestMarkdownExporter_ExportWithVersion_Quality(t *testing.T) {
+	exporter := NewMarkdownExporter()
+	doc := &Documentation{
+		PackageName: "test.package",
+		Quality:     &api.QualityScore{Score: 87.4, DocumentationCoverage: 92.5, Complexity: 12, Maintainability: 95},
+	}
+
+	result := exporter.ExportWithVersion(doc, "v1.0.0")
+
+	if !strings.Contains(result, "**Quality:** 87.4/100 (documentation 92.5%, complexity 12.0, maintainability 95.0)") {
+		t.Errorf("Expected quality score, got:\n%s", result)
+	}
+}
+
 func TestMarkdownExporter_ExportWithVersion_EmptyDoc(t *testing.T) {
 	exporter := NewMarkdownExporter()
 	doc := &Documentation{
@@ -0,0 +1,20 @@
+package formatter
+
+import (
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Diff returns a unified diff from the original to the formatted source of a
+// file, or an empty string when they are identical
+func Diff(filename, original, formatted string) (string, error) {
+	if original == formatted {
+		return "", nil
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(original),
+		B:        difflib.SplitLines(formatted),
+		FromFile: filename + ".orig",
+		ToFile:   filename,
+		Context:  3,
+	})
+}
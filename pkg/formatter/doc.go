@@ -0,0 +1,38 @@
+// Package formatter rewrites protobuf source files in a canonical layout.
+//
+// # Overview
+//
+// Unlike validation.Serializer, which regenerates proto text from the AST,
+// the formatter works on the token stream of the original file. It only
+// changes whitespace, so every comment, @spoke directive and option keeps its
+// text and its position relative to the surrounding declarations.
+//
+// # Layout
+//
+//   - Two-space indentation per nesting level
+//   - One statement per line; statements split across lines are joined
+//   - Line breaks inside brackets and aggregate option values are kept
+//   - Runs of blank lines collapse to one, and blocks do not start or end
+//     with a blank line
+//   - Trailing comments stay on the line they follow
+//
+// Formatting is idempotent: formatting already formatted source is a no-op.
+// As a safety check the output is re-tokenized and compared with the input,
+// and an error is returned instead of output that would change the file.
+//
+// # Usage Example
+//
+//	formatted, err := formatter.Format(content)
+//	if err != nil {
+//		return err
+//	}
+//	if formatted != content {
+//		diff, _ := formatter.Diff("user.proto", content, formatted)
+//		fmt.Print(diff)
+//	}
+//
+// # Related Packages
+//
+//   - pkg/validation: Semantic validation and AST-based normalization
+//   - pkg/cli: The spoke fmt command
+package formatter
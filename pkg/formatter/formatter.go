@@ -0,0 +1,312 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Options controls the canonical layout
+type Options struct {
+	Indent string // Indentation of one nesting level, two spaces by default
+}
+
+// DefaultOptions returns the canonical layout settings
+func DefaultOptions() *Options {
+	return &Options{Indent: "  "}
+}
+
+// blockKeywords start declarations whose braces open a block rather than an
+// aggregate option value
+var blockKeywords = map[string]bool{
+	"message": true,
+	"enum":    true,
+	"service": true,
+	"oneof":   true,
+	"extend":  true,
+	"rpc":     true,
+}
+
+// Format rewrites proto source in the canonical layout. Every comment,
+// including @spoke directives, keeps its position relative to the
+// declarations around it; only whitespace changes. Formatting formatted
+// source returns it unchanged.
+func Format(src string) (string, error) {
+	return FormatWithOptions(src, DefaultOptions())
+}
+
+// FormatWithOptions formats src with the given layout settings
+func FormatWithOptions(src string, opts *Options) (string, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	tokens, err := lex(src)
+	if err != nil {
+		return "", err
+	}
+
+	p := &printer{indent: opts.Indent, atBlockStart: true, lastOpenedAt: -1}
+	for _, tok := range tokens {
+		if err := p.print(tok); err != nil {
+			return "", err
+		}
+	}
+	if len(p.brackets) > 0 {
+		return "", fmt.Errorf("unclosed %q at end of file", p.brackets[len(p.brackets)-1].text)
+	}
+	if p.depth > 0 {
+		return "", fmt.Errorf("unclosed block at end of file")
+	}
+	formatted := p.finish()
+
+	// Only whitespace may change; anything else is a formatter bug
+	if err := sameTokens(tokens, formatted); err != nil {
+		return "", err
+	}
+	return formatted, nil
+}
+
+// printer lays out tokens line by line
+type printer struct {
+	indent string
+	lines  []string
+	cur    strings.Builder
+	open   bool // cur holds a line in progress
+
+	depth        int     // Block nesting
+	brackets     []token // Open (, [, < and aggregate { of the current statement
+	atBlockStart bool    // No line was started since the block opened
+
+	stmtFirst    string // First token of the current statement
+	stmtGroup    bool   // The statement declares a group
+	needNewline  bool   // The next token must start a new line
+	prev         token  // Last code token written
+	prevPrev     token
+	prevComment  bool // The last thing written on the line was a comment
+	lastOpenedAt int  // Line index of the last block '{', -1 otherwise
+}
+
+func (p *printer) print(tok token) error {
+	if tok.isComment() {
+		p.printComment(tok)
+		return nil
+	}
+
+	switch {
+	case tok.is("}") && len(p.brackets) == 0:
+		return p.closeBlock()
+	case tok.is(";") && len(p.brackets) == 0 && p.stmtFirst == "" && p.open && strings.HasSuffix(p.cur.String(), "}"):
+		// Stray semicolon after a block, e.g. "message A {};"
+		p.cur.WriteString(";")
+		return nil
+	}
+
+	closer := tok.is(")") || tok.is("]") || tok.is(">") || (tok.is("}") && len(p.brackets) > 0)
+	if p.breakBefore(tok) {
+		n := len(p.brackets)
+		if closer {
+			n--
+		}
+		p.newLine(tok.newlines, p.lineIndent(n, closer))
+	} else if p.open && p.cur.Len() > 0 && p.spaceBefore(tok) {
+		p.cur.WriteString(" ")
+	} else if !p.open {
+		p.newLine(0, p.depth)
+	}
+	p.cur.WriteString(tok.text)
+	p.prevComment = false
+	p.needNewline = false
+
+	if err := p.track(tok, closer); err != nil {
+		return err
+	}
+	p.prevPrev, p.prev = p.prev, tok
+	return nil
+}
+
+// track updates bracket, block and statement state after tok was written
+func (p *printer) track(tok token, closer bool) error {
+	if p.stmtFirst == "" && len(p.brackets) == 0 {
+		p.stmtFirst = tok.text
+	}
+	if tok.kind == tokenIdent && tok.text == "group" {
+		p.stmtGroup = true
+	}
+
+	switch {
+	case tok.is("(") || tok.is("[") || tok.is("<"):
+		p.brackets = append(p.brackets, tok)
+	case tok.is("{"):
+		if len(p.brackets) == 0 && (blockKeywords[p.stmtFirst] || p.stmtGroup) {
+			p.depth++
+			p.resetStatement()
+			p.needNewline = true
+			p.atBlockStart = true
+			p.lastOpenedAt = len(p.lines)
+			return nil
+		}
+		p.brackets = append(p.brackets, tok)
+	case closer:
+		if len(p.brackets) == 0 {
+			return fmt.Errorf("line %d: unexpected %q", tok.line, tok.text)
+		}
+		open := p.brackets[len(p.brackets)-1]
+		if pairs[open.text] != tok.text {
+			return fmt.Errorf("line %d: %q does not match %q from line %d", tok.line, tok.text, open.text, open.line)
+		}
+		p.brackets = p.brackets[:len(p.brackets)-1]
+	case tok.is(";") && len(p.brackets) == 0:
+		p.resetStatement()
+		p.needNewline = true
+	}
+	return nil
+}
+
+var pairs = map[string]string{"(": ")", "[": "]", "<": ">", "{": "}"}
+
+func (p *printer) resetStatement() {
+	p.stmtFirst = ""
+	p.stmtGroup = false
+}
+
+func (p *printer) closeBlock() error {
+	if p.depth == 0 {
+		return fmt.Errorf("unexpected \"}\"")
+	}
+	p.depth--
+
+	// Empty blocks stay on the declaration line
+	if p.open && p.lastOpenedAt == len(p.lines) && !p.prevComment && strings.HasSuffix(p.cur.String(), "{") {
+		p.cur.WriteString("}")
+	} else {
+		p.atBlockStart = false
+		p.newLine(0, p.depth)
+		p.cur.WriteString("}")
+	}
+	p.resetStatement()
+	p.needNewline = true
+	p.prevComment = false
+	p.lastOpenedAt = -1
+	p.prevPrev, p.prev = p.prev, token{kind: tokenPunct, text: "}"}
+	return nil
+}
+
+func (p *printer) printComment(tok token) {
+	// Trailing comments stay on the line of the code they follow
+	if tok.newlines == 0 && p.open && p.cur.Len() > 0 {
+		p.cur.WriteString(" ")
+		p.cur.WriteString(tok.text)
+		p.prevComment = true
+		if tok.kind == tokenLineComment {
+			p.needNewline = true
+		}
+		return
+	}
+
+	indent := p.depth
+	if p.stmtFirst != "" || len(p.brackets) > 0 {
+		indent = p.lineIndent(len(p.brackets), false)
+	}
+	p.newLine(tok.newlines, indent)
+	p.cur.WriteString(tok.text)
+	p.prevComment = true
+	p.needNewline = tok.kind == tokenLineComment
+}
+
+// breakBefore reports whether tok starts a new line. Statements are joined
+// onto one line; line breaks inside brackets and aggregate values are kept.
+func (p *printer) breakBefore(tok token) bool {
+	if !p.open {
+		return false
+	}
+	if p.needNewline {
+		return true
+	}
+	if tok.newlines == 0 {
+		return false
+	}
+	return len(p.brackets) > 0 || p.stmtFirst == "" || p.prevComment
+}
+
+// lineIndent is the indentation of a line continuing the current statement
+// with n brackets open
+func (p *printer) lineIndent(n int, closer bool) int {
+	if closer || n > 0 {
+		return p.depth + n
+	}
+	if p.stmtFirst == "" {
+		return p.depth
+	}
+	return p.depth + 1
+}
+
+// newLine finishes the current line and starts one at the given indentation,
+// keeping a single blank line where the source had one or more between
+// statements
+func (p *printer) newLine(newlines, indent int) {
+	blank := newlines >= 2 && !p.atBlockStart && p.stmtFirst == "" && len(p.brackets) == 0
+	if p.open {
+		p.lines = append(p.lines, p.cur.String())
+		p.cur.Reset()
+	}
+	if blank && len(p.lines) > 0 {
+		p.lines = append(p.lines, "")
+	}
+	p.cur.WriteString(strings.Repeat(p.indent, indent))
+	p.open = true
+	p.atBlockStart = false
+}
+
+// spaceBefore decides the spacing between the previous token and tok on the
+// same line
+func (p *printer) spaceBefore(tok token) bool {
+	prev := p.prev
+	switch {
+	case tok.is(";") || tok.is(",") || tok.is(")") || tok.is("]") || tok.is(">") || tok.is(":"):
+		return false
+	case p.prevComment:
+		return true
+	case tok.is("}"):
+		return false
+	case prev.is("(") || prev.is("[") || prev.is("<") || prev.is("{") || prev.is("-") || prev.is("+"):
+		return false
+	case tok.is("("):
+		// rpc Method(Request) but returns (Response) and option (name)
+		return !(prev.kind == tokenIdent && p.prevPrev.kind == tokenIdent && p.prevPrev.text == "rpc")
+	case tok.is("<"):
+		return !(prev.kind == tokenIdent && prev.text == "map")
+	case tok.kind == tokenIdent && strings.HasPrefix(tok.text, ".") && prev.is(")"):
+		// (custom.option).field
+		return false
+	}
+	return true
+}
+
+// finish returns the formatted source with a single trailing newline
+func (p *printer) finish() string {
+	if p.open {
+		p.lines = append(p.lines, p.cur.String())
+	}
+	if len(p.lines) == 0 {
+		return ""
+	}
+	return strings.Join(p.lines, "\n") + "\n"
+}
+
+// sameTokens checks that formatted source has exactly the tokens of the
+// original
+func sameTokens(original []token, formatted string) error {
+	tokens, err := lex(formatted)
+	if err != nil {
+		return fmt.Errorf("formatted output does not parse: %w", err)
+	}
+	if len(tokens) != len(original) {
+		return fmt.Errorf("formatting changed the number of tokens from %d to %d", len(original), len(tokens))
+	}
+	for i := range tokens {
+		if tokens[i].text != original[i].text {
+			return fmt.Errorf("line %d: formatting changed %q to %q", original[i].line, original[i].text, tokens[i].text)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+)
+
+const messyProto = `syntax="proto3";
+// @spoke:domain:user
+package   user.v1;
+
+
+import "google/protobuf/timestamp.proto";
+option go_package="example.com/user/v1";
+option (shop.meta) = {
+  owner: "team-a"
+  tags: ["x", "y"]
+};
+
+/* User is
+ * a person. */
+message User{
+
+    // Unique id
+    // @spoke:option:immutable
+    string id=1; // trailing
+    int32 age = 2 [deprecated=true,(shop.sensitive)=true];
+  map< string,string > labels = 3;
+  enum Role { ROLE_UNSPECIFIED=0; ROLE_ADMIN = -1; }
+  oneof contact {
+      string email = 4;
+  }
+  reserved 5,10 to max;
+  reserved "legacy";
+  message Empty {}
+
+
+}
+service UserService{
+  rpc GetUser ( GetUserRequest ) returns ( User ) {
+    option (google.api.http).get="/v1/users/{id}";
+  }
+  rpc Watch(stream GetUserRequest) returns (stream User);
+}
+`
+
+const formattedProto = `syntax = "proto3";
+// @spoke:domain:user
+package user.v1;
+
+import "google/protobuf/timestamp.proto";
+option go_package = "example.com/user/v1";
+option (shop.meta) = {
+  owner: "team-a"
+  tags: ["x", "y"]
+};
+
+/* User is
+ * a person. */
+message User {
+  // Unique id
+  // @spoke:option:immutable
+  string id = 1; // trailing
+  int32 age = 2 [deprecated = true, (shop.sensitive) = true];
+  map<string, string> labels = 3;
+  enum Role {
+    ROLE_UNSPECIFIED = 0;
+    ROLE_ADMIN = -1;
+  }
+  oneof contact {
+    string email = 4;
+  }
+  reserved 5, 10 to max;
+  reserved "legacy";
+  message Empty {}
+}
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User) {
+    option (google.api.http).get = "/v1/users/{id}";
+  }
+  rpc Watch(stream GetUserRequest) returns (stream User);
+}
+`
+
+func TestFormat(t *testing.T) {
+	got, err := Format(messyProto)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if got != formattedProto {
+		t.Errorf("Format() mismatch:\n%s", mustDiff(t, formattedProto, got))
+	}
+}
+
+func TestFormat_Idempotent(t *testing.T) {
+	inputs := map[string]string{
+		"messy":     messyProto,
+		"formatted": formattedProto,
+		"comments":  commentsProto,
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			once, err := Format(input)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			twice, err := Format(once)
+			if err != nil {
+				t.Fatalf("second Format() error = %v", err)
+			}
+			if once != twice {
+				t.Errorf("formatting is not idempotent:\n%s", mustDiff(t, once, twice))
+			}
+		})
+	}
+}
+
+const commentsProto = `syntax = "proto3"; /* after syntax */
+package a.v1;
+message Order {
+  string id = 1 [ // why deprecated
+    deprecated = true
+  ];
+  string name // split
+    = 2;
+  /* inline */ string note = 3;
+  int64 total = 4 /* cents */;
+  // dangling at end
+}
+`
+
+func TestFormat_PreservesComments(t *testing.T) {
+	got, err := Format(commentsProto)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	want := `syntax = "proto3"; /* after syntax */
+package a.v1;
+message Order {
+  string id = 1 [ // why deprecated
+    deprecated = true
+  ];
+  string name // split
+    = 2;
+  /* inline */ string note = 3;
+  int64 total = 4 /* cents */;
+  // dangling at end
+}
+`
+	if got != want {
+		t.Errorf("Format() mismatch:\n%s", mustDiff(t, want, got))
+	}
+}
+
+func TestFormat_BlankLines(t *testing.T) {
+	got, err := Format("\n\nsyntax = \"proto3\";\n\n\n\npackage a;\nmessage A {\n\n  string a = 1;\n\n\n  string b = 2;\n\n}\n\n\n")
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	want := "syntax = \"proto3\";\n\npackage a;\nmessage A {\n  string a = 1;\n\n  string b = 2;\n}\n"
+	if got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
+
+func TestFormat_PreservesLiterals(t *testing.T) {
+	input := `syntax = "proto2";
+message A {
+  optional string s = 1 [default = 'it\'s "quoted"\n'];
+  optional double d = 2 [default = -1.5e-3];
+  optional int32 h = 3 [default = 0x1F];
+  optional float f = 4 [default = -inf];
+  optional group Result = 5 {
+    optional string url = 6;
+  }
+}
+`
+	got, err := Format(input)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if got != input {
+		t.Errorf("Format() mismatch:\n%s", mustDiff(t, input, got))
+	}
+}
+
+func TestFormat_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"unterminated string", `option a = "abc;`, "unterminated string"},
+		{"unterminated comment", "/* never closed", "unterminated block comment"},
+		{"unclosed block", "message A {\n", "unclosed block"},
+		{"unclosed bracket", "message A { string a = 1 [deprecated = true; }", "does not match"},
+		{"extra brace", "message A {}\n}", "unexpected"},
+		{"bad character", "message A { string a = 1 @ }", "unexpected character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Format(tt.input)
+			if err == nil {
+				t.Fatal("Format() error = nil")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Format() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatWithOptions_Indent(t *testing.T) {
+	got, err := FormatWithOptions("message A { string a = 1; }", &Options{Indent: "\t"})
+	if err != nil {
+		t.Fatalf("FormatWithOptions() error = %v", err)
+	}
+	if want := "message A {\n\tstring a = 1;\n}\n"; got != want {
+		t.Errorf("FormatWithOptions() = %q, want %q", got, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	diff, err := Diff("a.proto", "message A {string a=1;}\n", "message A {\n  string a = 1;\n}\n")
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	for _, want := range []string{"--- a.proto.orig", "+++ a.proto", "-message A {string a=1;}", "+  string a = 1;"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("Diff() missing %q:\n%s", want, diff)
+		}
+	}
+
+	same, err := Diff("a.proto", "x\n", "x\n")
+	if err != nil || same != "" {
+		t.Errorf("Diff() of identical input = %q, %v; want empty", same, err)
+	}
+}
+
+func mustDiff(t *testing.T, want, got string) string {
+	t.Helper()
+	diff, err := Diff("want", want, got)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	return diff
+}
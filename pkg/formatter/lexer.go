@@ -0,0 +1,125 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind classifies a source token
+type tokenKind int
+
+const (
+	tokenIdent tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenPunct
+	tokenLineComment
+	tokenBlockComment
+)
+
+// token is a lexical token with the exact source text it was read from, so
+// strings, numbers and comments are reproduced byte for byte
+type token struct {
+	kind     tokenKind
+	text     string
+	line     int
+	newlines int // Line breaks between the previous token and this one
+}
+
+func (t token) isComment() bool {
+	return t.kind == tokenLineComment || t.kind == tokenBlockComment
+}
+
+func (t token) is(text string) bool {
+	return t.kind == tokenPunct && t.text == text
+}
+
+// lex splits proto source into tokens. Unlike protobuf.Scanner it keeps
+// string escapes as written and understands signs and hex numbers.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	line := 1
+	newlines := 0
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			newlines++
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+			continue
+		}
+
+		start := i
+		var kind tokenKind
+		switch {
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			kind = tokenLineComment
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			kind = tokenBlockComment
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated block comment", line)
+			}
+			i += end + 4
+		case c == '"' || c == '\'':
+			kind = tokenString
+			i++
+			for i < len(src) && src[i] != c {
+				if src[i] == '\n' {
+					return nil, fmt.Errorf("line %d: unterminated string", line)
+				}
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			i++
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			kind = tokenNumber
+			for i < len(src) && (isIdentChar(src[i]) || src[i] == '.') {
+				// Exponent signs belong to the number, e.g. 1e-5
+				if (src[i] == 'e' || src[i] == 'E') && i+1 < len(src) && (src[i+1] == '+' || src[i+1] == '-') &&
+					!strings.HasPrefix(strings.ToLower(src[start:]), "0x") {
+					i++
+				}
+				i++
+			}
+		case isIdentChar(c) || c == '.':
+			kind = tokenIdent
+			for i < len(src) && (isIdentChar(src[i]) || src[i] == '.') {
+				i++
+			}
+		case strings.IndexByte(";,={}[]()<>:-+/", c) >= 0:
+			kind = tokenPunct
+			i++
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+		}
+
+		text := src[start:i]
+		tokens = append(tokens, token{kind: kind, text: text, line: line, newlines: newlines})
+		line += strings.Count(text, "\n")
+		newlines = 0
+	}
+
+	return tokens, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
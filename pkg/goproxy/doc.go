@@ -0,0 +1,44 @@
+// Package goproxy serves the Go code generated for modules over the Go
+// module proxy protocol, so `go get` fetches it from Spoke directly instead
+// of consumers vendoring generated code.
+//
+// # Module Paths
+//
+// Modules are served under a configured module path prefix. With the prefix
+// spoke.example.com/go, version v1.4.0 of module "users" is the Go module
+// spoke.example.com/go/users at v1.4.0, and v2 versions are served as
+// spoke.example.com/go/users/v2, following Go's major version rule. Versions
+// that are not canonical semantic versions (commit hashes, v1.2, builds
+// with metadata) are not served; a missing leading v is added.
+//
+// # Protocol
+//
+// Handlers registers the endpoints of the protocol under /go/:
+//
+//	GET /go/{module}/@v/list              versions, one per line
+//	GET /go/{module}/@v/{version}.info    version and push time as JSON
+//	GET /go/{module}/@v/{version}.mod     go.mod
+//	GET /go/{module}/@v/{version}.zip     module zip
+//	GET /go/{module}/@latest              info of the latest release
+//
+// Consumers point the go command at it:
+//
+//	GOPROXY=https://spoke.example.com/go,https://proxy.golang.org,direct \
+//	GONOSUMDB=spoke.example.com/go \
+//	go get spoke.example.com/go/users@v1.4.0
+//
+// # Generated Code
+//
+// Zips hold the Go code compiled for the version. When a version has no Go
+// compilation for its module path, the first download compiles it through
+// the orchestrator with the Go plugins mapping each proto file to the package
+// of its directory in the module, and in the modules of its dependencies, so
+// imports resolve across modules. The compilation is stored with the version
+// and served from then on.
+//
+// The go.mod is written by the proxy, requiring the protobuf runtime, gRPC
+// when enabled, and the modules of the version's dependencies. Zips contain
+// that go.mod and the .go files in path order without timestamps, so every
+// download of a version is byte for byte the same and matches the hashes
+// recorded in go.sum.
+package goproxy
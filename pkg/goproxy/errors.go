@@ -0,0 +1,16 @@
+package goproxy
+
+import "errors"
+
+var (
+	// ErrModuleNotFound is returned for module paths outside the configured
+	// prefix or naming no Spoke module
+	ErrModuleNotFound = errors.New("module not found")
+
+	// ErrVersionNotFound is returned for versions the module does not have,
+	// or that are not semantic versions of the requested major version
+	ErrVersionNotFound = errors.New("version not found")
+
+	// ErrNoGoFiles is returned when compiling a version produced no Go code
+	ErrNoGoFiles = errors.New("no generated Go files")
+)
@@ -0,0 +1,222 @@
+package goproxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"golang.org/x/mod/module"
+)
+
+// PathPrefix is where the proxy is served; GOPROXY points at it
+const PathPrefix = "/go/"
+
+// Handlers serves the Go module proxy protocol
+type Handlers struct {
+	storage api.Storage
+	source  *compiled.Source
+	config  *Config
+}
+
+// NewHandlers creates Go module proxy handlers. Versions without Go code
+// compiled for their module path are compiled on demand by compiler; with
+// a nil compiler only compiled versions can be downloaded.
+func NewHandlers(storage api.Storage, compiler orchestrator.Orchestrator, config *Config) *Handlers {
+	return &Handlers{
+		storage: storage,
+		source:  compiled.NewSource(storage, compiler),
+		config:  config,
+	}
+}
+
+// RegisterRoutes registers the proxy routes
+func (h *Handlers) RegisterRoutes(router *mux.Router) {
+	router.PathPrefix(PathPrefix).HandlerFunc(h.serve).Methods("GET")
+}
+
+// serve handles GET /go/{module}/@v/list, /@v/{version}.info, .mod and .zip,
+// and /go/{module}/@latest, where {module} is an escaped module path
+func (h *Handlers) serve(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, PathPrefix)
+
+	if escaped, ok := strings.CutSuffix(rest, "/@latest"); ok {
+		h.serveLatest(w, escaped)
+		return
+	}
+
+	escaped, file, ok := strings.Cut(rest, "/@v/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if file == "list" {
+		h.serveList(w, escaped)
+		return
+	}
+
+	dot := strings.LastIndexByte(file, '.')
+	if dot < 0 {
+		http.NotFound(w, r)
+		return
+	}
+	gv, err := module.UnescapeVersion(file[:dot])
+	if err != nil {
+		writeError(w, ErrVersionNotFound)
+		return
+	}
+
+	switch file[dot:] {
+	case ".info":
+		h.serveInfo(w, escaped, gv)
+	case ".mod":
+		h.serveMod(w, escaped, gv)
+	case ".zip":
+		h.serveZip(w, r, escaped, gv)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveList writes the versions of a module, one per line
+func (h *Handlers) serveList(w http.ResponseWriter, escaped string) {
+	_, name, pathMajor, err := h.resolveEscaped(escaped)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	versions, err := h.versions(name, pathMajor)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	for _, v := range versions {
+		buf.WriteString(v.goVersion + "\n")
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// serveLatest writes the info of the latest version of a module
+func (h *Handlers) serveLatest(w http.ResponseWriter, escaped string) {
+	_, name, pathMajor, err := h.resolveEscaped(escaped)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	versions, err := h.versions(name, pathMajor)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	v, ok := latest(versions)
+	if !ok {
+		writeError(w, ErrVersionNotFound)
+		return
+	}
+	writeInfo(w, v.goVersion, v.version)
+}
+
+// serveInfo writes the info of a version
+func (h *Handlers) serveInfo(w http.ResponseWriter, escaped, gv string) {
+	_, name, pathMajor, err := h.resolveEscaped(escaped)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	version, err := h.lookup(name, pathMajor, gv)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeInfo(w, gv, version)
+}
+
+// serveMod writes the go.mod of a version
+func (h *Handlers) serveMod(w http.ResponseWriter, escaped, gv string) {
+	modulePath, name, pathMajor, err := h.resolveEscaped(escaped)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	version, err := h.lookup(name, pathMajor, gv)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(h.goMod(modulePath, version))
+}
+
+// serveZip writes the module zip of a version, compiling it when needed
+func (h *Handlers) serveZip(w http.ResponseWriter, r *http.Request, escaped, gv string) {
+	modulePath, name, pathMajor, err := h.resolveEscaped(escaped)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	version, err := h.lookup(name, pathMajor, gv)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	files, err := h.goFiles(r.Context(), modulePath, version)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Build the zip before writing, so failures are reported as errors
+	// rather than truncated zips
+	var buf bytes.Buffer
+	mod := module.Version{Path: modulePath, Version: gv}
+	if err := writeZip(&buf, mod, h.goMod(modulePath, version), files); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Write(buf.Bytes())
+}
+
+// resolveEscaped unescapes a module path and resolves it to a Spoke module
+func (h *Handlers) resolveEscaped(escaped string) (string, string, string, error) {
+	modulePath, err := module.UnescapePath(escaped)
+	if err != nil {
+		return "", "", "", ErrModuleNotFound
+	}
+	name, pathMajor, err := h.resolve(modulePath)
+	if err != nil {
+		return "", "", "", err
+	}
+	return modulePath, name, pathMajor, nil
+}
+
+// writeInfo writes the JSON info of a version
+func writeInfo(w http.ResponseWriter, gv string, version *api.Version) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Info{Version: gv, Time: version.CreatedAt.UTC()})
+}
+
+// writeError writes an error as plain text, which the go command shows to
+// the user. Missing modules and versions are 404 Not Found, so the go
+// command falls back to the next proxy in GOPROXY.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrModuleNotFound), errors.Is(err, ErrVersionNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNoGoFiles):
+		// The version has nothing to download, now or later
+		status = http.StatusGone
+	case errors.Is(err, compiled.ErrCompilerUnavailable):
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,358 @@
+package goproxy
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled/compiledtest"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+const testPrefix = "spoke.example.com/go"
+
+// generate generates one Go file per proto file
+func generate(req *orchestrator.CompileRequest) *codegen.CompilationResult {
+	result := &codegen.CompilationResult{Language: req.Language, Success: true}
+	for _, file := range req.ProtoFiles {
+		name := strings.TrimSuffix(file.Path, ".proto")
+		result.GeneratedFiles = append(result.GeneratedFiles, codegen.GeneratedFile{
+			Path:    "pb/" + name + ".pb.go",
+			Content: []byte(fmt.Sprintf("package pb\n\n// Module %s %s\n", req.ModuleName, req.Version)),
+		})
+	}
+	result.PackageFiles = []codegen.GeneratedFile{
+		{Path: "go.mod", Content: []byte("module github.com/spoke-generated/" + req.ModuleName + "\n")},
+		{Path: "README.md", Content: []byte(time.Now().String())},
+	}
+	return result
+}
+
+func newCompiler() *compiledtest.Compiler {
+	return &compiledtest.Compiler{Generate: generate}
+}
+
+func newTestRouter(storage api.Storage, compiler orchestrator.Orchestrator) *mux.Router {
+	router := mux.NewRouter()
+	NewHandlers(storage, compiler, DefaultConfig(testPrefix)).RegisterRoutes(router)
+	return router
+}
+
+func get(t *testing.T, router http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+func TestConfig_ModulePath(t *testing.T) {
+	cfg := DefaultConfig(testPrefix)
+	assert.Equal(t, "spoke.example.com/go/users", cfg.ModulePath("users", "v1.4.0"))
+	assert.Equal(t, "spoke.example.com/go/users", cfg.ModulePath("users", "v0.1.0"))
+	assert.Equal(t, "spoke.example.com/go/users/v2", cfg.ModulePath("users", "v2.0.0"))
+	assert.Equal(t, "spoke.example.com/go/users/v12", cfg.ModulePath("users", "v12.1.0-rc.1"))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig(testPrefix).Validate())
+	assert.Error(t, DefaultConfig("").Validate())
+	assert.Error(t, DefaultConfig("no dots here").Validate())
+}
+
+func TestGoVersion(t *testing.T) {
+	tests := map[string]string{
+		"v1.2.3":        "v1.2.3",
+		"1.2.3":         "v1.2.3",
+		"v1.2.3-beta.1": "v1.2.3-beta.1",
+		"v1.2":          "",
+		"v1.2.3+build":  "",
+		"abc1234":       "",
+		"":              "",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, goVersion(input), input)
+	}
+}
+
+func TestHandlers_List(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	for _, v := range []string{"v1.1.0", "1.0.0", "v2.0.0", "v1.2.0-beta.1", "abc1234", "v0.9.0"} {
+		storage.Add("users", v)
+	}
+	router := newTestRouter(storage, newCompiler())
+
+	w := get(t, router, "/go/spoke.example.com/go/users/@v/list")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "v0.9.0\nv1.0.0\nv1.1.0\nv1.2.0-beta.1\n", w.Body.String())
+
+	w = get(t, router, "/go/spoke.example.com/go/users/v2/@v/list")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "v2.0.0\n", w.Body.String())
+
+	w = get(t, router, "/go/spoke.example.com/go/users/v3/@v/list")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestHandlers_Latest(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("users", "v1.0.0")
+	storage.Add("users", "v1.1.0")
+	storage.Add("users", "v1.2.0-beta.1")
+	storage.Add("drafts", "v0.1.0-alpha")
+	router := newTestRouter(storage, newCompiler())
+
+	w := get(t, router, "/go/spoke.example.com/go/users/@latest")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var info Info
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&info))
+	assert.Equal(t, "v1.1.0", info.Version, "releases are preferred over pre-releases")
+
+	w = get(t, router, "/go/spoke.example.com/go/drafts/@latest")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&info))
+	assert.Equal(t, "v0.1.0-alpha", info.Version)
+
+	w = get(t, router, "/go/spoke.example.com/go/users/v2/@latest")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandlers_Info(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("users", "1.0.0")
+	router := newTestRouter(storage, newCompiler())
+
+	w := get(t, router, "/go/spoke.example.com/go/users/@v/v1.0.0.info")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"Version":"v1.0.0","Time":"2026-03-01T12:00:00Z"}`, w.Body.String())
+}
+
+func TestHandlers_Mod(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("common", "v1.0.0")
+	storage.Add("billing", "v2.1.0")
+	storage.Add("users", "v1.0.0", "common@v1.0.0", "billing@v2.1.0", "scratch@abc1234")
+	router := newTestRouter(storage, newCompiler())
+
+	w := get(t, router, "/go/spoke.example.com/go/users/@v/v1.0.0.mod")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `module spoke.example.com/go/users
+
+go 1.21
+
+require (
+	google.golang.org/protobuf v1.31.0
+	google.golang.org/grpc v1.60.0
+	spoke.example.com/go/billing/v2 v2.1.0
+	spoke.example.com/go/common v1.0.0
+)
+`, w.Body.String())
+}
+
+func TestHandlers_Zip(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("common", "v1.0.0")
+	storage.Add("users", "v2.0.0", "common@v1.0.0")
+	compiler := newCompiler()
+	router := newTestRouter(storage, compiler)
+
+	w := get(t, router, "/go/spoke.example.com/go/users/v2/@v/v2.0.0.zip")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	first := w.Body.Bytes()
+
+	zr, err := zip.NewReader(bytes.NewReader(first), int64(len(first)))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		assert.True(t, f.Modified.IsZero() || f.Modified.Year() <= 1980, "zip entries carry no timestamps")
+	}
+	assert.Equal(t, []string{
+		"spoke.example.com/go/users/v2@v2.0.0/go.mod",
+		"spoke.example.com/go/users/v2@v2.0.0/pb/users.pb.go",
+	}, names, "only the proxy's go.mod and the .go files are zipped")
+
+	// The compilation maps the module and its dependencies to their paths
+	require.Equal(t, 1, compiler.Calls())
+	req := compiler.Requests()[0]
+	assert.Equal(t, "go", req.Language)
+	assert.True(t, req.IncludeGRPC)
+	assert.Equal(t, "spoke.example.com/go/users/v2", req.Options[orchestrator.OptionGoModulePath])
+	assert.Equal(t, "spoke.example.com/go/common", req.Options[orchestrator.OptionGoModulePath+":common"])
+	require.Len(t, req.Dependencies, 1)
+	assert.Equal(t, "common.proto", req.Dependencies[0].ProtoFiles[0].Path)
+
+	// It is stored, and later downloads serve the same bytes without
+	// compiling again
+	assert.Equal(t, 1, storage.Updates())
+	stored, err := storage.GetVersion("users", "v2.0.0")
+	require.NoError(t, err)
+	require.Len(t, stored.CompilationInfo, 1)
+	assert.Equal(t, api.LanguageGo, stored.CompilationInfo[0].Language)
+	assert.Equal(t, "spoke.example.com/go/users/v2", stored.CompilationInfo[0].PackageName)
+
+	w = get(t, router, "/go/spoke.example.com/go/users/v2/@v/v2.0.0.zip")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, first, w.Body.Bytes())
+	assert.Equal(t, 1, compiler.Calls())
+}
+
+func TestHandlers_Zip_ConcurrentDownloadsCompileOnce(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("users", "v1.0.0")
+	compiler := newCompiler()
+	router := newTestRouter(storage, compiler)
+
+	var wg sync.WaitGroup
+	bodies := make([][]byte, 8)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/go/spoke.example.com/go/users/@v/v1.0.0.zip", nil))
+			bodies[i] = w.Body.Bytes()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, body := range bodies[1:] {
+		assert.Equal(t, bodies[0], body)
+	}
+	assert.Equal(t, 1, compiler.Calls())
+	assert.Equal(t, 1, storage.Updates())
+}
+
+func TestHandlers_Zip_CompiledForOtherPath(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	v := storage.Add("users", "v1.0.0")
+	v.CompilationInfo = []api.CompilationInfo{{
+		Language:    api.LanguageGo,
+		PackageName: "users",
+		Files:       []api.File{{Path: "users.pb.go", Content: "package users\n"}},
+	}}
+	compiler := newCompiler()
+	router := newTestRouter(storage, compiler)
+
+	w := get(t, router, "/go/spoke.example.com/go/users/@v/v1.0.0.zip")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1, compiler.Calls(), "code compiled for another path is recompiled")
+}
+
+func TestHandlers_Errors(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("users", "v1.0.0")
+	storage.Add("Billing", "v1.0.0")
+	storage.Add("empty", "v1.0.0").CompilationInfo = []api.CompilationInfo{{
+		Language:    api.LanguageGo,
+		PackageName: testPrefix + "/empty",
+		Files:       []api.File{{Path: "README.md", Content: "nothing here"}},
+	}}
+
+	tests := []struct {
+		name     string
+		compiler orchestrator.Orchestrator
+		path     string
+		status   int
+	}{
+		{"outside prefix", newCompiler(), "/go/github.com/acme/users/@v/list", http.StatusNotFound},
+		{"unknown module", newCompiler(), "/go/spoke.example.com/go/orders/@v/list", http.StatusNotFound},
+		{"nested path", newCompiler(), "/go/spoke.example.com/go/users/sub/@v/list", http.StatusNotFound},
+		{"v1 suffix", newCompiler(), "/go/spoke.example.com/go/users/v1/@v/list", http.StatusNotFound},
+		{"unknown version", newCompiler(), "/go/spoke.example.com/go/users/@v/v1.9.0.info", http.StatusNotFound},
+		{"wrong major", newCompiler(), "/go/spoke.example.com/go/users/v2/@v/v1.0.0.mod", http.StatusNotFound},
+		{"unknown file", newCompiler(), "/go/spoke.example.com/go/users/@v/v1.0.0.tar", http.StatusNotFound},
+		{"unescaped upper case", newCompiler(), "/go/spoke.example.com/go/Billing/@v/list", http.StatusNotFound},
+		{"escaped upper case", newCompiler(), "/go/spoke.example.com/go/!billing/@v/list", http.StatusOK},
+		{"compile failure", &compiledtest.Compiler{Err: errors.New("protoc exploded")}, "/go/spoke.example.com/go/users/@v/v1.0.0.zip", http.StatusInternalServerError},
+		{"no compiler", nil, "/go/spoke.example.com/go/users/@v/v1.0.0.zip", http.StatusServiceUnavailable},
+		{"no go files", nil, "/go/spoke.example.com/go/empty/@v/v1.0.0.zip", http.StatusGone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(t, newTestRouter(storage, tt.compiler), tt.path)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+// TestHandlers_GoModDownload downloads a module through the proxy with the
+// go command, which verifies the zip layout and hashes it for go.sum
+func TestHandlers_GoModDownload(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go command test in short mode")
+	}
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not available")
+	}
+
+	storage := compiledtest.NewStorage()
+	storage.Add("users", "v1.0.0")
+	server := httptest.NewServer(newTestRouter(storage, newCompiler()))
+	defer server.Close()
+
+	download := func() map[string]string {
+		cmd := exec.Command(goCmd, "mod", "download", "-json", testPrefix+"/users@v1.0.0")
+		cmd.Dir = t.TempDir()
+		cmd.Env = append(os.Environ(),
+			"GOPROXY="+server.URL+"/go",
+			"GOSUMDB=off",
+			"GOFLAGS=-modcacherw",
+			"GOMODCACHE="+filepath.Join(t.TempDir(), "modcache"),
+			"GOTOOLCHAIN=local",
+		)
+		out, err := cmd.Output()
+		require.NoError(t, err, string(out))
+		var result map[string]string
+		require.NoError(t, json.Unmarshal(out, &result))
+		require.Empty(t, result["Error"])
+		return result
+	}
+
+	first := download()
+	second := download()
+	assert.Equal(t, first["Sum"], second["Sum"])
+	assert.Equal(t, first["GoModSum"], second["GoModSum"])
+
+	w := get(t, server.Config.Handler, "/go/spoke.example.com/go/users/@v/v1.0.0.zip")
+	zipFile := filepath.Join(t.TempDir(), "users.zip")
+	require.NoError(t, os.WriteFile(zipFile, w.Body.Bytes(), 0644))
+	sum, err := dirhash.HashZip(zipFile, dirhash.Hash1)
+	require.NoError(t, err)
+	assert.Equal(t, sum, first["Sum"])
+
+	entries, err := os.ReadDir(first["Dir"] + "/pb")
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"users.pb.go"}, names)
+
+	goMod, err := os.ReadFile(first["GoMod"])
+	require.NoError(t, err)
+	assert.Contains(t, string(goMod), "module spoke.example.com/go/users\n")
+}
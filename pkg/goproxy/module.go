@@ -0,0 +1,218 @@
+package goproxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	modzip "golang.org/x/mod/zip"
+)
+
+// moduleVersion is a version of a Spoke module served as a Go version
+type moduleVersion struct {
+	goVersion string
+	version   *api.Version
+}
+
+// goVersion returns the canonical Go version of a Spoke version, adding the
+// leading v, or "" when the version is no canonical semantic version
+func goVersion(version string) string {
+	v := version
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	if !semver.IsValid(v) || semver.Canonical(v) != v {
+		return ""
+	}
+	return v
+}
+
+// resolve returns the Spoke module a Go module path is served from and the
+// major version suffix of the path, such as "/v2"
+func (h *Handlers) resolve(modulePath string) (string, string, error) {
+	rest, ok := strings.CutPrefix(modulePath, h.config.ModulePrefix+"/")
+	if !ok {
+		return "", "", ErrModuleNotFound
+	}
+	name, pathMajor, ok := module.SplitPathVersion(rest)
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", "", ErrModuleNotFound
+	}
+	if _, err := h.storage.GetModule(name); err != nil {
+		return "", "", fmt.Errorf("%w: %s", ErrModuleNotFound, name)
+	}
+	return name, pathMajor, nil
+}
+
+// versions returns the versions of a module served at a major version
+// suffix, in semantic version order
+func (h *Handlers) versions(name, pathMajor string) ([]moduleVersion, error) {
+	versions, err := h.storage.ListVersions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []moduleVersion
+	for _, v := range versions {
+		gv := goVersion(v.Version)
+		if gv == "" || module.CheckPathMajor(gv, pathMajor) != nil {
+			continue
+		}
+		result = append(result, moduleVersion{goVersion: gv, version: v})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return semver.Compare(result[i].goVersion, result[j].goVersion) < 0
+	})
+	return result, nil
+}
+
+// lookup returns the version of a module served as a Go version
+func (h *Handlers) lookup(name, pathMajor, gv string) (*api.Version, error) {
+	versions, err := h.versions(name, pathMajor)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.goVersion == gv {
+			return h.storage.GetVersion(name, v.version.Version)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s@%s", ErrVersionNotFound, name, gv)
+}
+
+// latest returns the highest release version, or the highest pre-release
+// when the module has no releases
+func latest(versions []moduleVersion) (moduleVersion, bool) {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if semver.Prerelease(versions[i].goVersion) == "" {
+			return versions[i], true
+		}
+	}
+	if len(versions) > 0 {
+		return versions[len(versions)-1], true
+	}
+	return moduleVersion{}, false
+}
+
+// dependencies returns the Go module paths and versions of the Spoke
+// modules a version depends on, skipping those not served by the proxy
+func (h *Handlers) dependencies(version *api.Version) []module.Version {
+	var deps []module.Version
+	for _, dep := range version.Dependencies {
+		name, v, ok := strings.Cut(dep, "@")
+		if !ok {
+			continue
+		}
+		gv := goVersion(v)
+		if gv == "" {
+			continue
+		}
+		deps = append(deps, module.Version{Path: h.config.ModulePath(name, gv), Version: gv})
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Path < deps[j].Path })
+	return deps
+}
+
+// goMod returns the go.mod of a version. It is written by the proxy rather
+// than taken from the compiled files, so it always names the served module
+// path and stays byte for byte the same.
+func (h *Handlers) goMod(modulePath string, version *api.Version) []byte {
+	requires := []module.Version{{Path: "google.golang.org/protobuf", Version: h.config.ProtobufVersion}}
+	if h.config.IncludeGRPC {
+		requires = append(requires, module.Version{Path: "google.golang.org/grpc", Version: h.config.GRPCVersion})
+	}
+	requires = append(requires, h.dependencies(version)...)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "module %s\n\ngo %s\n\nrequire (\n", modulePath, h.config.GoVersion)
+	for _, req := range requires {
+		fmt.Fprintf(&buf, "\t%s %s\n", req.Path, req.Version)
+	}
+	buf.WriteString(")\n")
+	return buf.Bytes()
+}
+
+// goFiles returns the Go code generated for a version, compiling it first
+// when the version has no Go code compiled for the module path. Each proto
+// file, of the module and of its dependencies, generates the package of its
+// directory in the module serving it.
+func (h *Handlers) goFiles(ctx context.Context, modulePath string, version *api.Version) ([]api.File, error) {
+	options := map[string]string{orchestrator.OptionGoModulePath: modulePath}
+	for _, dep := range version.Dependencies {
+		name, v, ok := strings.Cut(dep, "@")
+		if !ok {
+			continue
+		}
+		if gv := goVersion(v); gv != "" {
+			options[orchestrator.OptionGoModulePath+":"+name] = h.config.ModulePath(name, gv)
+		}
+	}
+
+	return h.source.Files(ctx, version, compiled.Target{
+		Language:    api.LanguageGo,
+		PackageName: modulePath,
+		IncludeGRPC: h.config.IncludeGRPC,
+		Options:     options,
+	})
+}
+
+// writeZip writes the module zip of a version: its go.mod and the .go files
+// of its Go code. Files are written in path order without timestamps, so the
+// zip, and the hash go.sum records for it, never change.
+func writeZip(w io.Writer, mod module.Version, goMod []byte, files []api.File) error {
+	zipFiles := []modzip.File{memFile{path: "go.mod", content: goMod}}
+	for _, file := range files {
+		name := path.Clean(strings.TrimPrefix(file.Path, "/"))
+		if path.Ext(name) != ".go" || strings.HasPrefix(name, "../") {
+			continue
+		}
+		zipFiles = append(zipFiles, memFile{path: name, content: []byte(file.Content)})
+	}
+	if len(zipFiles) == 1 {
+		return fmt.Errorf("%w: %s@%s", ErrNoGoFiles, mod.Path, mod.Version)
+	}
+	sort.Slice(zipFiles, func(i, j int) bool { return zipFiles[i].Path() < zipFiles[j].Path() })
+
+	return modzip.Create(w, mod, zipFiles)
+}
+
+// memFile is a module zip file held in memory
+type memFile struct {
+	path    string
+	content []byte
+}
+
+func (f memFile) Path() string {
+	return f.path
+}
+
+func (f memFile) Lstat() (fs.FileInfo, error) {
+	return memFileInfo{f}, nil
+}
+
+func (f memFile) Open() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(f.content)), nil
+}
+
+// memFileInfo describes a memFile
+type memFileInfo struct {
+	file memFile
+}
+
+func (i memFileInfo) Name() string       { return path.Base(i.file.path) }
+func (i memFileInfo) Size() int64        { return int64(len(i.file.content)) }
+func (i memFileInfo) Mode() fs.FileMode  { return 0644 }
+func (i memFileInfo) ModTime() time.Time { return time.Time{} }
+func (i memFileInfo) IsDir() bool        { return false }
+func (i memFileInfo) Sys() interface{}   { return nil }
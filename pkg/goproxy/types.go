@@ -0,0 +1,74 @@
+package goproxy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+)
+
+// Config holds Go module proxy configuration
+type Config struct {
+	// ModulePrefix is the module path prefix modules are served under:
+	// module "users" is the Go module ModulePrefix/users, and its v2
+	// versions are ModulePrefix/users/v2
+	ModulePrefix string
+
+	// IncludeGRPC generates gRPC service code along with the messages when
+	// compiling on demand
+	IncludeGRPC bool
+
+	// Versions written to the go.mod of served modules
+	GoVersion       string
+	ProtobufVersion string // google.golang.org/protobuf
+	GRPCVersion     string // google.golang.org/grpc, required with IncludeGRPC
+}
+
+// DefaultConfig returns the default configuration for a module prefix
+func DefaultConfig(modulePrefix string) *Config {
+	return &Config{
+		ModulePrefix:    modulePrefix,
+		IncludeGRPC:     true,
+		GoVersion:       "1.21",
+		ProtobufVersion: "v1.31.0",
+		GRPCVersion:     "v1.60.0",
+	}
+}
+
+// Validate checks that the module prefix is a valid module path
+func (c *Config) Validate() error {
+	if c.ModulePrefix == "" {
+		return fmt.Errorf("module prefix is required")
+	}
+	if err := module.CheckPath(c.ModulePrefix + "/module"); err != nil {
+		return fmt.Errorf("invalid module prefix %q: %w", c.ModulePrefix, err)
+	}
+	return nil
+}
+
+// ModulePath returns the Go module path a Spoke module is served as at a
+// version, which carries the major version suffix from v2 on
+func (c *Config) ModulePath(moduleName, version string) string {
+	path := c.ModulePrefix + "/" + moduleName
+	if major := majorSuffix(version); major != "" {
+		path += "/" + major
+	}
+	return path
+}
+
+// Info is the JSON served for .info and @latest requests
+type Info struct {
+	Version string    // Canonical Go version
+	Time    time.Time // When the version was pushed
+}
+
+// majorSuffix returns the major version path suffix of a Go version, "v2"
+// for v2.1.0, or "" for v0 and v1
+func majorSuffix(version string) string {
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	if major == "0" || major == "1" || major == "" {
+		return ""
+	}
+	return "v" + major
+}
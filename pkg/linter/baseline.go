@@ -0,0 +1,239 @@
+package linter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultBaselineFile is the baseline file name looked up in the linted
+// directory
+const DefaultBaselineFile = "spoke-lint-baseline.yaml"
+
+// Baseline records accepted violations so that only new ones are reported.
+// Violations are keyed by file, rule and the path of the declaration they
+// are about, not by line, so edits elsewhere in a file keep them matched.
+type Baseline struct {
+	Version string          `yaml:"version"`
+	Entries []BaselineEntry `yaml:"entries"`
+
+	// Root is the directory entry file paths are relative to. Paths linted
+	// by the engine are made relative to it before matching; when empty
+	// they are matched as given.
+	Root string `yaml:"-"`
+}
+
+// BaselineEntry is the number of accepted violations of a rule at one
+// declaration
+type BaselineEntry struct {
+	File  string `yaml:"file" json:"file"`
+	Rule  string `yaml:"rule" json:"rule"`
+	Path  string `yaml:"path,omitempty" json:"path,omitempty"` // Declaration, such as acme.v1.User.name; empty for the file
+	Count int    `yaml:"count" json:"count"`
+}
+
+type baselineKey struct {
+	file, rule, path string
+}
+
+// NewBaseline records the violations of results. File paths are stored
+// relative to root when it is not empty.
+func NewBaseline(results []LintResult, root string) *Baseline {
+	counts := make(map[baselineKey]int)
+	for _, result := range results {
+		file := baselinePath(root, result.FilePath)
+		for _, v := range result.Violations {
+			counts[baselineKey{file: file, rule: v.Rule, path: v.NodePath}]++
+		}
+		for _, v := range result.Baselined {
+			counts[baselineKey{file: file, rule: v.Rule, path: v.NodePath}]++
+		}
+	}
+
+	b := &Baseline{Version: "v1", Root: root, Entries: make([]BaselineEntry, 0, len(counts))}
+	for key, count := range counts {
+		b.Entries = append(b.Entries, BaselineEntry{File: key.file, Rule: key.rule, Path: key.path, Count: count})
+	}
+	sort.Slice(b.Entries, func(i, j int) bool {
+		a, c := b.Entries[i], b.Entries[j]
+		if a.File != c.File {
+			return a.File < c.File
+		}
+		if a.Path != c.Path {
+			return a.Path < c.Path
+		}
+		return a.Rule < c.Rule
+	})
+	return b
+}
+
+// LoadBaseline reads a baseline file. Its entries are relative to the
+// directory holding it.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	var b Baseline
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	for _, entry := range b.Entries {
+		if entry.File == "" || entry.Rule == "" || entry.Count < 1 {
+			return nil, fmt.Errorf("invalid baseline %s: entry %+v needs a file, a rule and a positive count", path, entry)
+		}
+	}
+	b.Root = filepath.Dir(path)
+	return &b, nil
+}
+
+// Save writes the baseline to path
+func (b *Baseline) Save(path string) error {
+	data, err := yaml.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	header := "# Accepted lint violations. Regenerate with spoke lint --write-baseline.\n"
+	if err := os.WriteFile(path, append([]byte(header), data...), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// Violations returns the number of violations the baseline accepts
+func (b *Baseline) Violations() int {
+	total := 0
+	for _, entry := range b.Entries {
+		total += entry.Count
+	}
+	return total
+}
+
+// MissingFiles returns the entries of files other than the given ones, such
+// as files that were deleted or renamed since the baseline was written
+func (b *Baseline) MissingFiles(filePaths []string) []BaselineEntry {
+	linted := make(map[string]bool, len(filePaths))
+	for _, path := range filePaths {
+		linted[baselinePath(b.Root, path)] = true
+	}
+	var missing []BaselineEntry
+	for _, entry := range b.Entries {
+		if !linted[entry.File] {
+			missing = append(missing, entry)
+		}
+	}
+	return missing
+}
+
+// apply moves the violations of result the baseline accepts to
+// result.Baselined and records entries of the file that matched fewer
+// violations than they accept as stale
+func (b *Baseline) apply(result *LintResult) {
+	file := baselinePath(b.Root, result.FilePath)
+	remaining := make(map[baselineKey]int)
+	var keys []baselineKey
+	for _, entry := range b.Entries {
+		if entry.File != file {
+			continue
+		}
+		key := baselineKey{file: file, rule: entry.Rule, path: entry.Path}
+		if _, seen := remaining[key]; !seen {
+			keys = append(keys, key)
+		}
+		remaining[key] += entry.Count
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	kept := result.Violations[:0]
+	for _, v := range result.Violations {
+		key := baselineKey{file: file, rule: v.Rule, path: v.NodePath}
+		if remaining[key] > 0 {
+			remaining[key]--
+			result.Baselined = append(result.Baselined, v)
+			continue
+		}
+		kept = append(kept, v)
+	}
+	result.Violations = kept
+
+	for _, key := range keys {
+		if count := remaining[key]; count > 0 {
+			result.StaleBaseline = append(result.StaleBaseline, BaselineEntry{File: key.file, Rule: key.rule, Path: key.path, Count: count})
+		}
+	}
+}
+
+// baselinePath returns a file path relative to root in slash form
+func baselinePath(root, path string) string {
+	if root != "" {
+		if rel, err := filepath.Rel(root, path); err == nil {
+			path = rel
+		} else if absRoot, err := filepath.Abs(root); err == nil {
+			if absPath, err := filepath.Abs(path); err == nil {
+				if rel, err := filepath.Rel(absRoot, absPath); err == nil {
+					path = rel
+				}
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// nodePaths returns a function resolving a position to the fully qualified
+// name of the innermost declaration at it, or "" for the file itself.
+// Declarations are matched by their start first, as rules report at a
+// node's position, and otherwise by the span containing the position.
+func nodePaths(ast *protobuf.RootNode) func(protobuf.Position) string {
+	type span struct {
+		start, end protobuf.Position
+		symbol     string
+	}
+	starts := make(map[[2]int]string)
+	var spans []span
+	for node, decl := range declarations(ast) {
+		start, end := node.Position(), node.End()
+		if start.Line == 0 {
+			continue
+		}
+		key := [2]int{start.Line, start.Column}
+		// Should declarations share a start, prefer the innermost one
+		if existing, ok := starts[key]; !ok || len(decl.symbol) > len(existing) {
+			starts[key] = decl.symbol
+		}
+		if end.Line > 0 {
+			spans = append(spans, span{start: start, end: end, symbol: decl.symbol})
+		}
+	}
+
+	return func(pos protobuf.Position) string {
+		if symbol, ok := starts[[2]int{pos.Line, pos.Column}]; ok {
+			return symbol
+		}
+		best := ""
+		var bestStart protobuf.Position
+		for _, s := range spans {
+			if positionBefore(pos, s.start) || positionBefore(s.end, pos) {
+				continue
+			}
+			inner := positionBefore(bestStart, s.start) || (bestStart == s.start && len(s.symbol) > len(best))
+			if best == "" || inner {
+				best, bestStart = s.symbol, s.start
+			}
+		}
+		return best
+	}
+}
+
+func positionBefore(a, b protobuf.Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Column < b.Column
+}
@@ -0,0 +1,141 @@
+package linter_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+const baselineProto = `syntax = "proto3";
+package acme.v1;
+
+message user_profile {
+  string UserName = 1;
+  string email = 2;
+}
+`
+
+func lintSource(t *testing.T, engine *linter.LintEngine, filePath, content string) linter.LintResult {
+	t.Helper()
+	result, err := engine.LintSource(filePath, content)
+	if err != nil {
+		t.Fatalf("LintSource() error = %v", err)
+	}
+	return result
+}
+
+func TestLintEngine_NodePaths(t *testing.T) {
+	engine := newFixEngine(linter.DefaultConfig())
+	result := lintSource(t, engine, "acme/v1/user.proto", baselineProto)
+
+	paths := make(map[string]string)
+	for _, v := range result.Violations {
+		paths[v.Rule] = v.NodePath
+	}
+	if paths["message-naming"] != "acme.v1.user_profile" {
+		t.Errorf("message-naming path = %q", paths["message-naming"])
+	}
+	if paths["field-naming"] != "acme.v1.user_profile.UserName" {
+		t.Errorf("field-naming path = %q", paths["field-naming"])
+	}
+}
+
+func TestBaseline_ReportsOnlyNewViolations(t *testing.T) {
+	engine := newFixEngine(linter.DefaultConfig())
+	before := lintSource(t, engine, "acme/v1/user.proto", baselineProto)
+	if len(before.Violations) == 0 {
+		t.Fatal("Expected violations to baseline")
+	}
+	baseline := linter.NewBaseline([]linter.LintResult{before}, "")
+
+	// Lines move and a new violation appears
+	changed := strings.Replace(baselineProto, "message user_profile {", "// A user.\n\nmessage user_profile {\n  string PhoneNumber = 3;", 1)
+	engine.SetBaseline(baseline)
+	after := lintSource(t, engine, "acme/v1/user.proto", changed)
+
+	if len(after.Violations) != 1 || after.Violations[0].NodePath != "acme.v1.user_profile.PhoneNumber" {
+		t.Errorf("Expected only the PhoneNumber violation, got %+v", after.Violations)
+	}
+	if len(after.Baselined) != len(before.Violations) {
+		t.Errorf("Baselined %d violations, want %d", len(after.Baselined), len(before.Violations))
+	}
+	if len(after.StaleBaseline) != 0 {
+		t.Errorf("Unexpected stale entries: %+v", after.StaleBaseline)
+	}
+
+	summary := engine.GenerateSummary([]linter.LintResult{after})
+	if summary.TotalViolations != 1 || summary.Baselined != len(before.Violations) {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
+
+func TestBaseline_StaleEntries(t *testing.T) {
+	engine := newFixEngine(linter.DefaultConfig())
+	baseline := linter.NewBaseline([]linter.LintResult{lintSource(t, engine, "acme/v1/user.proto", baselineProto)}, "")
+
+	engine.SetBaseline(baseline)
+	fixed := strings.Replace(baselineProto, "UserName", "user_name", 1)
+	result := lintSource(t, engine, "acme/v1/user.proto", fixed)
+
+	if len(result.Violations) != 0 {
+		t.Errorf("Unexpected violations: %+v", result.Violations)
+	}
+	if len(result.StaleBaseline) != 1 {
+		t.Fatalf("Expected 1 stale entry, got %+v", result.StaleBaseline)
+	}
+	stale := result.StaleBaseline[0]
+	if stale.Rule != "field-naming" || stale.Path != "acme.v1.user_profile.UserName" || stale.Count != 1 {
+		t.Errorf("Unexpected stale entry: %+v", stale)
+	}
+}
+
+func TestBaseline_SaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "acme", "v1", "user.proto")
+
+	engine := newFixEngine(linter.DefaultConfig())
+	result := lintSource(t, engine, file, baselineProto)
+	baseline := linter.NewBaseline([]linter.LintResult{result}, dir)
+	for _, entry := range baseline.Entries {
+		if entry.File != "acme/v1/user.proto" {
+			t.Errorf("Entry file %q is not relative to the root", entry.File)
+		}
+	}
+
+	path := filepath.Join(dir, linter.DefaultBaselineFile)
+	if err := baseline.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := linter.LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline() error = %v", err)
+	}
+	if loaded.Violations() != len(result.Violations) || len(loaded.Entries) != len(baseline.Entries) {
+		t.Errorf("Loaded %d violations in %d entries, want %d in %d", loaded.Violations(), len(loaded.Entries), len(result.Violations), len(baseline.Entries))
+	}
+
+	engine.SetBaseline(loaded)
+	if result := lintSource(t, engine, file, baselineProto); len(result.Violations) != 0 {
+		t.Errorf("Expected the loaded baseline to accept every violation, got %+v", result.Violations)
+	}
+
+	if missing := loaded.MissingFiles([]string{file}); len(missing) != 0 {
+		t.Errorf("Unexpected missing entries: %+v", missing)
+	}
+	if missing := loaded.MissingFiles(nil); len(missing) != len(loaded.Entries) {
+		t.Errorf("Expected every entry of an unlinted file, got %+v", missing)
+	}
+}
+
+func TestLoadBaseline_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), linter.DefaultBaselineFile)
+	if err := os.WriteFile(path, []byte("version: v1\nentries:\n  - file: a.proto\n    count: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := linter.LoadBaseline(path); err == nil || !strings.Contains(err.Error(), "needs a file, a rule") {
+		t.Errorf("LoadBaseline() error = %v", err)
+	}
+}
@@ -1,27 +1,31 @@
 package linter
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the linting configuration
 type Config struct {
-	Version string       `yaml:"version"`
-	Lint    LintRules    `yaml:"lint"`
+	Version string        `yaml:"version"`
+	Lint    LintRules     `yaml:"lint"`
 	Quality QualityConfig `yaml:"quality"`
 	AutoFix AutoFixConfig `yaml:"autofix"`
 }
 
 // LintRules contains rule configuration
 type LintRules struct {
-	Use        []string               `yaml:"use"` // Style guides: "google", "uber"
-	Rules      map[string]interface{} `yaml:"rules"`
-	Ignore     []string               `yaml:"ignore"`
-	Files      map[string]FileRules   `yaml:"files"`
-	Categories map[string]string      `yaml:"categories"` // category -> severity
+	Use         []string               `yaml:"use"`   // Presets: "google", "uber", "minimal", "basic", "default", "comments", "aip"
+	Rules       map[string]interface{} `yaml:"rules"` // Rule name -> true, false, "off" or a severity
+	Ignore      []string               `yaml:"ignore"`
+	Files       map[string]FileRules   `yaml:"files"`
+	Categories  map[string]string      `yaml:"categories"`   // category -> severity or "off"
+	CustomRules []CustomRuleConfig     `yaml:"custom_rules"` // Rules declared in the configuration
+	PluginDirs  []string               `yaml:"plugin_dirs"`  // Directories searched for linter plugins besides the defaults
 }
 
 // FileRules contains per-file rule overrides
@@ -31,10 +35,10 @@ type FileRules struct {
 
 // QualityConfig configures quality metrics
 type QualityConfig struct {
-	Enabled               bool                      `yaml:"enabled"`
+	Enabled               bool                        `yaml:"enabled"`
 	DocumentationCoverage DocumentationCoverageConfig `yaml:"documentation_coverage"`
-	Complexity            ComplexityConfig          `yaml:"complexity"`
-	Maintainability       MaintainabilityConfig     `yaml:"maintainability"`
+	Complexity            ComplexityConfig            `yaml:"complexity"`
+	Maintainability       MaintainabilityConfig       `yaml:"maintainability"`
 }
 
 // DocumentationCoverageConfig for documentation metrics
@@ -58,8 +62,139 @@ type MaintainabilityConfig struct {
 
 // AutoFixConfig configures automatic fixing
 type AutoFixConfig struct {
-	Enabled bool            `yaml:"enabled"`
-	Rules   map[string]bool `yaml:"rules"`
+	Enabled        bool            `yaml:"enabled"`
+	Rules          map[string]bool `yaml:"rules"`           // Set a rule to false to never auto-fix it
+	ReserveRenamed bool            `yaml:"reserve_renamed"` // Reserve the old name of renamed fields and enum values
+}
+
+// AutoFixEnabled reports whether fixes of a rule may be applied
+func (c *Config) AutoFixEnabled(rule string) bool {
+	if enabled, ok := c.AutoFix.Rules[rule]; ok {
+		return enabled
+	}
+	return true
+}
+
+// Validate checks that presets and severities in the configuration are known
+func (c *Config) Validate() error {
+	for _, name := range c.Lint.Use {
+		if _, ok := PresetRules(name); !ok {
+			return fmt.Errorf("unknown lint preset %q, expected one of %v", name, Presets())
+		}
+	}
+	for name, value := range c.Lint.Rules {
+		if _, _, err := parseRuleSetting(value); err != nil {
+			return fmt.Errorf("rule %s: %w", name, err)
+		}
+	}
+	for category, value := range c.Lint.Categories {
+		if _, _, err := parseRuleSetting(value); err != nil {
+			return fmt.Errorf("category %s: %w", category, err)
+		}
+	}
+	names := make(map[string]bool)
+	for _, custom := range c.Lint.CustomRules {
+		if _, err := NewCustomRule(custom); err != nil {
+			return err
+		}
+		if names[custom.Name] {
+			return fmt.Errorf("custom rule %s is declared twice", custom.Name)
+		}
+		names[custom.Name] = true
+	}
+	return nil
+}
+
+// ruleEnabled reports whether rule runs. An explicit lint.rules entry wins,
+// then the rule's category; otherwise built-in rules run when a preset in
+// lint.use selects them, or always when no preset is used. Rules outside
+// every preset, such as custom rules, run unless disabled. Opt-in rules,
+// such as the AIP rules, run only when a used preset or an explicit
+// category setting selects them.
+func (c *Config) ruleEnabled(rule Rule) bool {
+	if enabled, _, ok := c.ruleSetting(rule.Name()); ok {
+		return enabled
+	}
+	categoryEnabled, _, categorySet := c.categorySetting(rule.Category())
+	if categorySet && !categoryEnabled {
+		return false
+	}
+	if isOptIn(rule.Name()) {
+		// Opt-in rules run only when asked for, by preset or category
+		return categorySet || c.usesPresetWith(rule.Name())
+	}
+	if len(c.Lint.Use) == 0 || !inAnyPreset(rule.Name()) {
+		return true
+	}
+	return c.usesPresetWith(rule.Name())
+}
+
+// usesPresetWith reports whether a preset in lint.use enables rule
+func (c *Config) usesPresetWith(rule string) bool {
+	for _, name := range c.Lint.Use {
+		rules, _ := PresetRules(name)
+		for _, r := range rules {
+			if r == rule {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// severityOverride returns the severity configured for a rule, or for its
+// category, if any
+func (c *Config) severityOverride(rule Rule) (Severity, bool) {
+	if _, severity, ok := c.ruleSetting(rule.Name()); ok && severity != "" {
+		return severity, true
+	}
+	if _, severity, ok := c.categorySetting(rule.Category()); ok && severity != "" {
+		return severity, true
+	}
+	return "", false
+}
+
+func (c *Config) ruleSetting(name string) (bool, Severity, bool) {
+	value, ok := c.Lint.Rules[name]
+	if !ok {
+		return false, "", false
+	}
+	enabled, severity, err := parseRuleSetting(value)
+	if err != nil {
+		return false, "", false
+	}
+	return enabled, severity, true
+}
+
+func (c *Config) categorySetting(category Category) (bool, Severity, bool) {
+	value, ok := c.Lint.Categories[string(category)]
+	if !ok {
+		return false, "", false
+	}
+	enabled, severity, err := parseRuleSetting(value)
+	if err != nil {
+		return false, "", false
+	}
+	return enabled, severity, true
+}
+
+// parseRuleSetting interprets a rule or category setting: a bool, "off", or
+// a severity that enables the rule with that severity
+func parseRuleSetting(value interface{}) (bool, Severity, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, "", nil
+	case string:
+		switch s := Severity(strings.ToLower(v)); s {
+		case "off":
+			return false, "", nil
+		case "on":
+			return true, "", nil
+		case SeverityError, SeverityWarning, SeverityInfo:
+			return true, s, nil
+		}
+	}
+	return false, "", fmt.Errorf("invalid setting %v, expected true, false, off, error, warning or info", value)
 }
 
 // DefaultConfig returns default linting configuration
@@ -108,6 +243,17 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
+	// Plugin directories are relative to the configuration file
+	for i, dir := range config.Lint.PluginDirs {
+		if !filepath.IsAbs(dir) {
+			config.Lint.PluginDirs[i] = filepath.Join(filepath.Dir(path), dir)
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
 	return &config, nil
 }
 
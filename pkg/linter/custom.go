@@ -0,0 +1,429 @@
+package linter
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+)
+
+// Node kinds a custom rule can select
+const (
+	KindMessage   = "message"
+	KindField     = "field"
+	KindEnum      = "enum"
+	KindEnumValue = "enum_value"
+	KindService   = "service"
+	KindRPC       = "rpc"
+)
+
+var customRuleKinds = []string{KindMessage, KindField, KindEnum, KindEnumValue, KindService, KindRPC}
+
+// kindLabels name the node kinds in messages
+var kindLabels = map[string]string{
+	KindMessage:   "message",
+	KindField:     "field",
+	KindEnum:      "enum",
+	KindEnumValue: "enum value",
+	KindService:   "service",
+	KindRPC:       "RPC",
+}
+
+// CustomRuleConfig declares a lint rule in spoke-lint.yaml. The rule selects
+// declarations of one kind, narrows them down with Where, and reports those
+// that do not satisfy Require:
+//
+//	lint:
+//	  custom_rules:
+//	    - name: id-fields-are-strings
+//	      select: field
+//	      where:
+//	        name: "_id$"
+//	      require:
+//	        type: "^string$"
+//	      severity: error
+//	      message: "{{.Parent}}.{{.Name}} must be a string, not {{.Type}}"
+type CustomRuleConfig struct {
+	Name        string               `yaml:"name"`
+	Description string               `yaml:"description"`
+	Category    string               `yaml:"category"` // Defaults to style
+	Severity    string               `yaml:"severity"` // Defaults to warning
+	Select      string               `yaml:"select"`   // message, field, enum, enum_value, service or rpc
+	Where       *CustomRulePredicate `yaml:"where"`    // Declarations to check; all of the kind when empty
+	Require     CustomRulePredicate  `yaml:"require"`  // What checked declarations must satisfy
+	Message     string               `yaml:"message"`  // text/template over .Rule, .Kind, .Name, .FullName, .Parent and .Type
+}
+
+// CustomRulePredicate holds when every condition it sets holds. Regular
+// expressions use Go syntax and match anywhere unless anchored.
+type CustomRulePredicate struct {
+	Name       string               `yaml:"name"`        // Regex on the declared name
+	Type       string               `yaml:"type"`        // Regex on a field's type or an RPC's request type
+	Output     string               `yaml:"output"`      // Regex on an RPC's response type
+	Parent     string               `yaml:"parent"`      // Regex on the enclosing message, enum or service name
+	Options    map[string]string    `yaml:"options"`     // Option name, such as deprecated or (google.api.http), to a regex on its value
+	Comment    string               `yaml:"comment"`     // Regex on the leading comment text
+	HasComment *bool                `yaml:"has_comment"` // Whether a leading comment is present
+	Directives map[string]string    `yaml:"directives"`  // @spoke directive option, such as domain, to a regex on its value
+	Repeated   *bool                `yaml:"repeated"`    // Whether a field is repeated
+	Not        *CustomRulePredicate `yaml:"not"`         // Holds when the nested predicate does not
+}
+
+// empty reports whether the predicate sets no condition
+func (p *CustomRulePredicate) empty() bool {
+	return p.Name == "" && p.Type == "" && p.Output == "" && p.Parent == "" && len(p.Options) == 0 &&
+		p.Comment == "" && p.HasComment == nil && len(p.Directives) == 0 && p.Repeated == nil && p.Not == nil
+}
+
+// CustomRule is a rule declared in the configuration
+type CustomRule struct {
+	config   CustomRuleConfig
+	category Category
+	severity Severity
+	where    *predicate
+	require  *predicate
+	message  *template.Template // nil for the default message
+}
+
+// NewCustomRule compiles a declared rule
+func NewCustomRule(config CustomRuleConfig) (*CustomRule, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("custom rule needs a name")
+	}
+	if inAnyPreset(config.Name) {
+		return nil, fmt.Errorf("custom rule %s has the name of a built-in rule", config.Name)
+	}
+	if !containsString(customRuleKinds, config.Select) {
+		return nil, fmt.Errorf("custom rule %s: select must be one of %v, got %q", config.Name, customRuleKinds, config.Select)
+	}
+
+	rule := &CustomRule{config: config, category: CategoryStyle, severity: SeverityWarning}
+	if config.Category != "" {
+		rule.category = Category(config.Category)
+		if !containsCategory(rule.category) {
+			return nil, fmt.Errorf("custom rule %s: unknown category %q", config.Name, config.Category)
+		}
+	}
+	if config.Severity != "" {
+		_, severity, err := parseRuleSetting(config.Severity)
+		if err != nil || severity == "" {
+			return nil, fmt.Errorf("custom rule %s: severity must be error, warning or info, got %q", config.Name, config.Severity)
+		}
+		rule.severity = severity
+	}
+
+	var err error
+	if config.Where != nil {
+		if rule.where, err = compilePredicate(config.Where); err != nil {
+			return nil, fmt.Errorf("custom rule %s: where: %w", config.Name, err)
+		}
+	}
+	if config.Require.empty() {
+		return nil, fmt.Errorf("custom rule %s: require must set at least one condition", config.Name)
+	}
+	if rule.require, err = compilePredicate(&config.Require); err != nil {
+		return nil, fmt.Errorf("custom rule %s: require: %w", config.Name, err)
+	}
+
+	if config.Message != "" {
+		if rule.message, err = template.New(config.Name).Option("missingkey=error").Parse(config.Message); err != nil {
+			return nil, fmt.Errorf("custom rule %s: message: %w", config.Name, err)
+		}
+	}
+	return rule, nil
+}
+
+func (r *CustomRule) Name() string        { return r.config.Name }
+func (r *CustomRule) Category() Category  { return r.category }
+func (r *CustomRule) Severity() Severity  { return r.severity }
+func (r *CustomRule) CanAutoFix() bool    { return false }
+func (r *CustomRule) Description() string { return r.config.Description }
+
+// AutoFix is not supported by custom rules
+func (r *CustomRule) AutoFix(node *protobuf.RootNode, violation Violation) (*Fix, error) {
+	return nil, nil
+}
+
+// Check reports the selected declarations that do not satisfy the rule
+func (r *CustomRule) Check(node *protobuf.RootNode, ctx *LintContext) []Violation {
+	violations := make([]Violation, 0)
+	walkCustomNodes(node, func(n *customNode) {
+		if n.kind != r.config.Select {
+			return
+		}
+		if r.where != nil && !r.where.matches(n) {
+			return
+		}
+		if r.require.matches(n) {
+			return
+		}
+		violations = append(violations, Violation{
+			Rule:     r.Name(),
+			Severity: r.Severity(),
+			Category: r.Category(),
+			Message:  r.render(n),
+			Position: n.node.Position(),
+		})
+	})
+	return violations
+}
+
+// render fills in the message template. Without one, or when it fails, the
+// message names the declaration, the rule and its description.
+func (r *CustomRule) render(n *customNode) string {
+	kind := kindLabels[n.kind]
+	if r.message != nil {
+		var buf bytes.Buffer
+		err := r.message.Execute(&buf, map[string]string{
+			"Rule":     r.config.Name,
+			"Kind":     kind,
+			"Name":     n.name,
+			"FullName": n.fullName,
+			"Parent":   n.parent,
+			"Type":     strings.TrimPrefix(n.typeName, "."),
+		})
+		if err == nil {
+			return buf.String()
+		}
+	}
+	message := strings.ToUpper(kind[:1]) + kind[1:] + " '" + n.name + "' violates " + r.config.Name
+	if r.config.Description != "" {
+		message += ": " + r.config.Description
+	}
+	return message
+}
+
+// customNode is a declaration as custom rules see it
+type customNode struct {
+	kind       string
+	node       protobuf.Node
+	name       string
+	fullName   string
+	parent     string // Name of the enclosing message, enum or service
+	typeName   string // Field type or RPC request type
+	output     string // RPC response type
+	repeated   bool
+	options    []*protobuf.OptionNode
+	comments   []*protobuf.CommentNode
+	directives []*protobuf.SpokeDirectiveNode
+}
+
+// walkCustomNodes calls fn for every declaration of a file: each message
+// followed by its fields, nested messages and enums, then top-level enums,
+// then services followed by their RPCs
+func walkCustomNodes(root *protobuf.RootNode, fn func(n *customNode)) {
+	scope := ""
+	if root.Package != nil {
+		scope = root.Package.Name
+	}
+
+	walkEnum := func(enum *protobuf.EnumNode, scope, parent string) {
+		fullName := qualify(scope, enum.Name)
+		fn(&customNode{kind: KindEnum, node: enum, name: enum.Name, fullName: fullName, parent: parent,
+			options: enum.Options, comments: enum.Comments, directives: enum.SpokeDirectives})
+		for _, value := range enum.Values {
+			fn(&customNode{kind: KindEnumValue, node: value, name: value.Name, fullName: qualify(scope, value.Name), parent: enum.Name,
+				options: value.Options, comments: value.Comments, directives: value.SpokeDirectives})
+		}
+	}
+
+	var walkMessage func(msg *protobuf.MessageNode, scope, parent string)
+	walkMessage = func(msg *protobuf.MessageNode, scope, parent string) {
+		fullName := qualify(scope, msg.Name)
+		fn(&customNode{kind: KindMessage, node: msg, name: msg.Name, fullName: fullName, parent: parent,
+			options: msg.Options, comments: msg.Comments, directives: msg.SpokeDirectives})
+		for _, field := range msg.Fields {
+			fn(&customNode{kind: KindField, node: field, name: field.Name, fullName: qualify(fullName, field.Name), parent: msg.Name,
+				typeName: field.Type, repeated: field.Repeated,
+				options: field.Options, comments: field.Comments, directives: field.SpokeDirectives})
+		}
+		for _, nested := range msg.Nested {
+			walkMessage(nested, fullName, msg.Name)
+		}
+		for _, enum := range msg.Enums {
+			walkEnum(enum, fullName, msg.Name)
+		}
+	}
+
+	for _, msg := range root.Messages {
+		walkMessage(msg, scope, "")
+	}
+	for _, enum := range root.Enums {
+		walkEnum(enum, scope, "")
+	}
+	for _, svc := range root.Services {
+		fullName := qualify(scope, svc.Name)
+		fn(&customNode{kind: KindService, node: svc, name: svc.Name, fullName: fullName,
+			options: svc.Options, comments: svc.Comments, directives: svc.SpokeDirectives})
+		for _, rpc := range svc.RPCs {
+			fn(&customNode{kind: KindRPC, node: rpc, name: rpc.Name, fullName: qualify(fullName, rpc.Name), parent: svc.Name,
+				typeName: rpc.InputType, output: rpc.OutputType,
+				options: rpc.Options, comments: rpc.Comments, directives: rpc.SpokeDirectives})
+		}
+	}
+}
+
+// predicate is a compiled CustomRulePredicate
+type predicate struct {
+	name, typeName, output, parent, comment *regexp.Regexp
+	options, directives                     map[string]*regexp.Regexp
+	hasComment, repeated                    *bool
+	not                                     *predicate
+}
+
+func compilePredicate(p *CustomRulePredicate) (*predicate, error) {
+	compiled := &predicate{hasComment: p.HasComment, repeated: p.Repeated}
+	var err error
+	for _, re := range []struct {
+		field string
+		expr  string
+		dst   **regexp.Regexp
+	}{
+		{"name", p.Name, &compiled.name},
+		{"type", p.Type, &compiled.typeName},
+		{"output", p.Output, &compiled.output},
+		{"parent", p.Parent, &compiled.parent},
+		{"comment", p.Comment, &compiled.comment},
+	} {
+		if re.expr == "" {
+			continue
+		}
+		if *re.dst, err = regexp.Compile(re.expr); err != nil {
+			return nil, fmt.Errorf("%s: %w", re.field, err)
+		}
+	}
+	if compiled.options, err = compileRegexMap("options", p.Options); err != nil {
+		return nil, err
+	}
+	if compiled.directives, err = compileRegexMap("directives", p.Directives); err != nil {
+		return nil, err
+	}
+	if p.Not != nil {
+		if compiled.not, err = compilePredicate(p.Not); err != nil {
+			return nil, fmt.Errorf("not: %w", err)
+		}
+	}
+	return compiled, nil
+}
+
+func compileRegexMap(field string, exprs map[string]string) (map[string]*regexp.Regexp, error) {
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+	compiled := make(map[string]*regexp.Regexp, len(exprs))
+	for key, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", field, key, err)
+		}
+		compiled[key] = re
+	}
+	return compiled, nil
+}
+
+// matches reports whether every condition of the predicate holds for n
+func (p *predicate) matches(n *customNode) bool {
+	for _, check := range []struct {
+		re    *regexp.Regexp
+		value string
+	}{
+		{p.name, n.name},
+		{p.typeName, strings.TrimPrefix(n.typeName, ".")},
+		{p.output, strings.TrimPrefix(n.output, ".")},
+		{p.parent, n.parent},
+		{p.comment, commentText(n.comments)},
+	} {
+		if check.re != nil && !check.re.MatchString(check.value) {
+			return false
+		}
+	}
+	if p.hasComment != nil && HasDocComment(n.comments) != *p.hasComment {
+		return false
+	}
+	if p.repeated != nil && (n.kind != KindField || n.repeated != *p.repeated) {
+		return false
+	}
+	for name, re := range p.options {
+		if !optionMatches(n.options, name, re) {
+			return false
+		}
+	}
+	for option, re := range p.directives {
+		if !directiveMatches(n.directives, option, re) {
+			return false
+		}
+	}
+	if p.not != nil && p.not.matches(n) {
+		return false
+	}
+	return true
+}
+
+func optionMatches(options []*protobuf.OptionNode, name string, re *regexp.Regexp) bool {
+	for _, opt := range options {
+		if opt.Name == name && re.MatchString(opt.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+func directiveMatches(directives []*protobuf.SpokeDirectiveNode, option string, re *regexp.Regexp) bool {
+	for _, d := range directives {
+		if d.Option == option && re.MatchString(d.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// commentText returns the text of comments without comment markers
+func commentText(comments []*protobuf.CommentNode) string {
+	lines := make([]string, 0, len(comments))
+	for _, c := range comments {
+		for _, line := range strings.Split(c.Text, "\n") {
+			line = strings.TrimSpace(line)
+			line = strings.TrimPrefix(line, "//")
+			line = strings.TrimPrefix(line, "/*")
+			line = strings.TrimSuffix(line, "*/")
+			line = strings.TrimPrefix(strings.TrimSpace(line), "*")
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsCategory(category Category) bool {
+	for _, c := range []Category{CategoryNaming, CategoryStyle, CategoryDocumentation, CategoryStructure, CategoryAIP} {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// customRules compiles the custom rules of the configuration, skipping any
+// that Validate rejects
+func (c *Config) customRules() []Rule {
+	rules := make([]Rule, 0, len(c.Lint.CustomRules))
+	for _, config := range c.Lint.CustomRules {
+		if rule, err := NewCustomRule(config); err == nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
@@ -0,0 +1,207 @@
+package linter_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+const customRulesConfig = `version: v1
+lint:
+  use: [minimal]
+  custom_rules:
+    - name: id-fields-are-strings
+      description: IDs are opaque strings
+      select: field
+      where:
+        name: "_id$"
+      require:
+        type: "^string$"
+      severity: error
+      message: "{{.Parent}}.{{.Name}} must be a string, not {{.Type}}"
+    - name: rpc-documented
+      category: documentation
+      select: rpc
+      where:
+        not:
+          options:
+            deprecated: "true"
+      require:
+        has_comment: true
+    - name: admin-domain
+      select: message
+      where:
+        name: "^Admin"
+      require:
+        directives:
+          domain: "^admin\\."
+    - name: status-values-prefixed
+      select: enum_value
+      where:
+        parent: "Status$"
+      require:
+        name: "^STATUS_"
+`
+
+const customRulesProto = `syntax = "proto3";
+package acme.v1;
+
+message User {
+  int64 user_id = 1;
+  string org_id = 2;
+  repeated int32 group_id = 3;
+}
+
+// @spoke:domain:admin.acme.com
+message AdminUser {
+  string name = 1;
+}
+
+message AdminGroup {
+  string name = 1;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  ACTIVE = 1;
+}
+
+service UserService {
+  // Gets a user.
+  rpc GetUser(User) returns (User);
+  rpc ListUsers(User) returns (User);
+  rpc OldUsers(User) returns (User) {
+    option deprecated = true;
+  }
+}
+`
+
+func loadCustomConfig(t *testing.T, yaml string) *linter.Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "spoke-lint.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := linter.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	return config
+}
+
+func TestCustomRules(t *testing.T) {
+	engine := newFixEngine(loadCustomConfig(t, customRulesConfig))
+	result := lintSource(t, engine, "acme/v1/user.proto", customRulesProto)
+
+	got := make(map[string][]string)
+	for _, v := range result.Violations {
+		got[v.Rule] = append(got[v.Rule], v.Message)
+	}
+
+	want := map[string][]string{
+		"id-fields-are-strings":  {"User.user_id must be a string, not int64", "User.group_id must be a string, not int32"},
+		"rpc-documented":         {"RPC 'ListUsers' violates rpc-documented"},
+		"admin-domain":           {"Message 'AdminGroup' violates admin-domain"},
+		"status-values-prefixed": {"Enum value 'ACTIVE' violates status-values-prefixed"},
+	}
+	for rule, messages := range want {
+		if strings.Join(got[rule], "|") != strings.Join(messages, "|") {
+			t.Errorf("%s: got %q, want %q", rule, got[rule], messages)
+		}
+	}
+
+	rule, ok := engine.Registry().GetRule("id-fields-are-strings")
+	if !ok {
+		t.Fatal("Custom rule is not registered")
+	}
+	if rule.Severity() != linter.SeverityError || rule.Category() != linter.CategoryStyle || rule.Description() != "IDs are opaque strings" {
+		t.Errorf("Unexpected rule settings: %s %s %q", rule.Severity(), rule.Category(), rule.Description())
+	}
+	for _, v := range result.Violations {
+		if v.Rule == "rpc-documented" && (v.Severity != linter.SeverityWarning || v.Category != linter.CategoryDocumentation) {
+			t.Errorf("Unexpected rpc-documented violation: %+v", v)
+		}
+	}
+}
+
+func TestCustomRules_ConfiguredLikeBuiltIns(t *testing.T) {
+	config := loadCustomConfig(t, customRulesConfig+`  rules:
+    rpc-documented: off
+    admin-domain: info
+`)
+	engine := newFixEngine(config)
+	result := lintSource(t, engine, "acme/v1/user.proto", customRulesProto)
+
+	for _, v := range result.Violations {
+		if v.Rule == "rpc-documented" {
+			t.Errorf("Disabled custom rule reported: %s", v.Message)
+		}
+		if v.Rule == "admin-domain" && v.Severity != linter.SeverityInfo {
+			t.Errorf("admin-domain severity = %s, want info", v.Severity)
+		}
+	}
+}
+
+func TestCustomRules_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    linter.CustomRuleConfig
+		wantErr string
+	}{
+		{
+			name:    "missing name",
+			rule:    linter.CustomRuleConfig{Select: "field", Require: linter.CustomRulePredicate{Name: "x"}},
+			wantErr: "needs a name",
+		},
+		{
+			name:    "unknown kind",
+			rule:    linter.CustomRuleConfig{Name: "r", Select: "oneof", Require: linter.CustomRulePredicate{Name: "x"}},
+			wantErr: "select must be one of",
+		},
+		{
+			name:    "built-in name",
+			rule:    linter.CustomRuleConfig{Name: "field-naming", Select: "field", Require: linter.CustomRulePredicate{Name: "x"}},
+			wantErr: "name of a built-in rule",
+		},
+		{
+			name:    "empty require",
+			rule:    linter.CustomRuleConfig{Name: "r", Select: "field"},
+			wantErr: "require must set at least one condition",
+		},
+		{
+			name:    "bad regex",
+			rule:    linter.CustomRuleConfig{Name: "r", Select: "field", Require: linter.CustomRulePredicate{Not: &linter.CustomRulePredicate{Type: "("}}},
+			wantErr: "require: not: type",
+		},
+		{
+			name:    "bad severity",
+			rule:    linter.CustomRuleConfig{Name: "r", Select: "field", Severity: "loud", Require: linter.CustomRulePredicate{Name: "x"}},
+			wantErr: "severity must be",
+		},
+		{
+			name:    "bad template",
+			rule:    linter.CustomRuleConfig{Name: "r", Select: "field", Message: "{{.Name", Require: linter.CustomRulePredicate{Name: "x"}},
+			wantErr: "message",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := linter.DefaultConfig()
+			config.Lint.CustomRules = []linter.CustomRuleConfig{tt.rule}
+			err := config.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	config := linter.DefaultConfig()
+	rule := linter.CustomRuleConfig{Name: "r", Select: "field", Require: linter.CustomRulePredicate{Name: "x"}}
+	config.Lint.CustomRules = []linter.CustomRuleConfig{rule, rule}
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "declared twice") {
+		t.Errorf("Validate() error = %v, want a duplicate error", err)
+	}
+}
@@ -7,9 +7,34 @@
 //
 // # Style Guides
 //
-// Google: Standard protobuf style guide
-// Uber: Uber's proto conventions
-// Custom: Organization-specific rules
+// lint.use in spoke-lint.yaml selects presets of built-in rules:
+//
+//	google    Google protobuf style guide: naming, file names, deprecations
+//	uber      Uber style guide: buf DEFAULT plus comments and coverage
+//	minimal   buf MINIMAL: package defined, directory matches package,
+//	          no import cycles, no name declared twice
+//	basic     buf BASIC: MINIMAL plus naming and unused imports
+//	default   buf DEFAULT: BASIC plus enum, package version and RPC conventions
+//	comments  buf COMMENTS: every declaration has a comment
+//	aip       Google API Improvement Proposals: standard methods,
+//	          pagination, update masks, resource patterns, HTTP bindings
+//
+// lint.rules turns single rules on or off or changes their severity, and
+// lint.categories does the same for a whole category:
+//
+//	lint:
+//	  use: [default, comments]
+//	  rules:
+//	    package-version-suffix: off
+//	    comment-field: info
+//	  categories:
+//	    documentation: warning
+//
+// Custom rules registered with the engine are outside every preset and run
+// unless turned off. The AIP rules are opt-in: they only run when the aip
+// preset is used or the aip category is set, and need the google.api
+// annotations (google/api/annotations.proto, google/api/resource.proto)
+// imported to check HTTP bindings and resources.
 //
 // # Rule Categories
 //
@@ -17,38 +42,119 @@
 // Style: Indentation, line length, import ordering
 // Documentation: Comment coverage requirements
 // Structure: Message complexity, field count limits
+// AIP: Resource-oriented API design (https://google.aip.dev)
 //
 // # Usage Example
 //
 // Lint with auto-fix:
 //
-//	config := &linter.Config{
-//		StyleGuide: linter.StyleGuideGoogle,
-//		AutoFix:    true,
-//		Rules: map[string]bool{
-//			"message_naming":    true,
-//			"field_naming":      true,
-//			"require_comments":  true,
-//		},
+//	config := linter.DefaultConfig()
+//	config.AutoFix.ReserveRenamed = true
+//
+//	engine := linter.NewLintEngine(config)
+//	for _, rule := range rules.DefaultRules() {
+//		engine.Registry().Register(rule)
+//	}
+//
+//	result, err := engine.Fix("user.proto", protoContent)
+//	if err != nil {
+//		return err
+//	}
+//	if result.Changed() {
+//		fmt.Printf("Applied %d fixes, skipped %d\n",
+//			len(result.Applied), len(result.Skipped))
+//		fmt.Println(result.Content)
 //	}
 //
-//	engine := linter.NewEngine(config)
-//	result := engine.Lint(protoContent)
+// Renaming fixes follow the renamed name through the file: field types, RPC
+// inputs and outputs, and enum default values are updated with it.
 //
-//	fmt.Printf("Violations: %d errors, %d warnings\n",
-//		result.Errors, result.Warnings)
+// # Baselines
 //
-//	if result.AutoFixed {
-//		fmt.Println("Applied fixes:")
-//		fmt.Println(result.FixedContent)
-//	}
+// A baseline records the violations a codebase already has so that only new
+// ones are reported while rules are adopted. Entries are keyed by file,
+// rule and the declaration a violation is about (such as acme.v1.User.name),
+// not by line, so unrelated edits keep them matched. Entries matching fewer
+// violations than recorded are reported as stale so the baseline shrinks:
+//
+//	baseline := linter.NewBaseline(results, dir)
+//	err := baseline.Save(filepath.Join(dir, linter.DefaultBaselineFile))
+//
+//	engine.SetBaseline(baseline)
+//	result := engine.Lint("user.proto", ast)
+//	// result.Violations are new; result.Baselined were accepted;
+//	// result.StaleBaseline lists entries that were fixed
+//
+// spoke lint --write-baseline writes spoke-lint-baseline.yaml, which later
+// runs pick up from the linted directory.
+//
+// # Custom Rules
+//
+// Organization-specific conventions are declared in spoke-lint.yaml rather
+// than written in Go. A custom rule selects nodes of one kind (message,
+// field, enum, enum_value, service or rpc), narrows them with where and
+// reports those failing require. Predicates match the name, type, output,
+// parent, options, comment and @spoke directives by regular expression and
+// can be negated with not:
+//
+//	lint:
+//	  custom_rules:
+//	    - name: id-fields-are-strings
+//	      select: field
+//	      where: {name: "_id$"}
+//	      require: {type: "^string$"}
+//	      severity: error
+//	      message: "{{.Parent}}.{{.Name}} must be a string, not {{.Type}}"
 //
-// Quality metrics:
+// Custom rules are registered with the built-in ones and can be turned off
+// or have their severity changed under lint.rules like any other rule.
+//
+// # Plugin Rules
+//
+// Rule packs can also ship as linter plugins, discovered by plugins.Loader in
+// the default plugin directories and those under lint.plugin_dirs. A plugin
+// is an executable run once per module: it reads the enabled rules and the
+// files of the module, with their ASTs, as JSON on standard input and writes
+// violations as JSON to standard output. LoadPluginRules returns the rules of
+// the plugins found, which are configured like built-in rules.
+//
+// # Module Analysis
+//
+// LintModule and LintModuleSources lint the files of a module together.
+// Files are parsed with their imports among each other resolved, and module
+// rules check them as a whole using a symbol table of every declaration:
+//
+//	import-no-cycle         files importing each other in a cycle
+//	import-used             imports nothing of the file uses
+//	package-same-directory  packages split across directories
+//	symbol-unique           names declared by two files
+//	type-referenced         messages and enums nothing refers to (opt-in)
+//
+// Their violations are reported in the results of the files they are about,
+// with Violation.File set to the import path:
+//
+//	results, err := engine.LintModuleSources(dir, sources)
+//
+// Lint and LintSource treat a file as a module of its own, so of imports
+// outside it only those of well-known types and google.api annotations can
+// be judged unused.
+//
+// # Quality Metrics
+//
+// With quality.enabled, each result carries FileMetrics: declaration counts,
+// documentation coverage, a complexity score rating message nesting and size
+// against quality.complexity, and a maintainability score lowered by long
+// files and violations. They are weighted into a 0-100 quality score using
+// the weights of the quality section; AggregateMetrics combines files, such
+// as the files of a module version, into one QualityReport:
 //
 //	fmt.Printf("Documentation coverage: %.1f%%\n",
-//		result.Metrics.DocCoverage * 100)
-//	fmt.Printf("Average message complexity: %.1f\n",
-//		result.Metrics.AvgComplexity)
+//		result.Metrics.DocumentationCoverage)
+//	report := linter.AggregateMetrics(results, &config.Quality)
+//	fmt.Printf("Quality: %.1f/100\n", report.QualityScore)
+//
+// The server scores each version when it is created and stores the score
+// with it, from where analytics health and the documentation pages show it.
 //
 // # Related Packages
 //
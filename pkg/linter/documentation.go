@@ -0,0 +1,84 @@
+package linter
+
+import (
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+)
+
+// DocCoverage counts the declarations of a file that carry a comment
+type DocCoverage struct {
+	Documented int
+	Total      int
+}
+
+// Percent returns the share of documented declarations, 100 for a file
+// without declarations
+func (c DocCoverage) Percent() float64 {
+	if c.Total == 0 {
+		return 100
+	}
+	return float64(c.Documented) * 100 / float64(c.Total)
+}
+
+func (c *DocCoverage) add(comments []*protobuf.CommentNode) {
+	c.Total++
+	if HasDocComment(comments) {
+		c.Documented++
+	}
+}
+
+// MeasureDocCoverage counts the documented messages, fields, enums, enum
+// values, services and RPCs of a file
+func MeasureDocCoverage(ast *protobuf.RootNode) DocCoverage {
+	var coverage DocCoverage
+
+	addEnum := func(enum *protobuf.EnumNode) {
+		coverage.add(enum.Comments)
+		for _, value := range enum.Values {
+			coverage.add(value.Comments)
+		}
+	}
+
+	var addMessage func(msg *protobuf.MessageNode)
+	addMessage = func(msg *protobuf.MessageNode) {
+		coverage.add(msg.Comments)
+		for _, field := range msg.Fields {
+			coverage.add(field.Comments)
+		}
+		for _, nested := range msg.Nested {
+			addMessage(nested)
+		}
+		for _, enum := range msg.Enums {
+			addEnum(enum)
+		}
+	}
+
+	for _, msg := range ast.Messages {
+		addMessage(msg)
+	}
+	for _, enum := range ast.Enums {
+		addEnum(enum)
+	}
+	for _, svc := range ast.Services {
+		coverage.add(svc.Comments)
+		for _, rpc := range svc.RPCs {
+			coverage.add(rpc.Comments)
+		}
+	}
+	return coverage
+}
+
+// HasDocComment reports whether comments hold any text beyond comment markers
+func HasDocComment(comments []*protobuf.CommentNode) bool {
+	for _, c := range comments {
+		text := strings.TrimSpace(c.Text)
+		text = strings.TrimPrefix(text, "//")
+		text = strings.TrimPrefix(text, "/*")
+		text = strings.TrimSuffix(text, "*/")
+		if strings.Trim(text, " \t\r\n*/") != "" {
+			return true
+		}
+	}
+	return false
+}
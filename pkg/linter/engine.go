@@ -1,6 +1,8 @@
 package linter
 
 import (
+	"fmt"
+
 	"github.com/platinummonkey/spoke/pkg/api/protobuf"
 )
 
@@ -8,6 +10,7 @@ import (
 type LintEngine struct {
 	config   *Config
 	registry *RuleRegistry
+	baseline *Baseline
 }
 
 // NewLintEngine creates a new lint engine
@@ -16,9 +19,16 @@ func NewLintEngine(config *Config) *LintEngine {
 		config = DefaultConfig()
 	}
 
+	// Rules declared in the configuration are registered up front; built-in
+	// rules are registered by the caller
+	registry := NewRuleRegistry()
+	for _, rule := range config.customRules() {
+		registry.Register(rule)
+	}
+
 	return &LintEngine{
 		config:   config,
-		registry: NewRuleRegistry(),
+		registry: registry,
 	}
 }
 
@@ -27,8 +37,49 @@ func (e *LintEngine) Registry() *RuleRegistry {
 	return e.registry
 }
 
+// SetBaseline makes the engine report only violations the baseline does not
+// accept. Accepted violations move to LintResult.Baselined, and baseline
+// entries that no longer match are listed in LintResult.StaleBaseline. A nil
+// baseline reports every violation.
+func (e *LintEngine) SetBaseline(baseline *Baseline) {
+	e.baseline = baseline
+}
+
 // Lint runs all enabled rules against a proto file
 func (e *LintEngine) Lint(filePath string, ast *protobuf.RootNode) LintResult {
+	return e.lint(filePath, ast, "")
+}
+
+// LintSource parses and lints proto source. Unlike Lint, rules see the
+// source text, so their suggested fixes carry exact edits.
+func (e *LintEngine) LintSource(filePath, content string) (LintResult, error) {
+	result, _, err := e.lintSource(filePath, content)
+	return result, err
+}
+
+func (e *LintEngine) lintSource(filePath, content string) (LintResult, *protobuf.RootNode, error) {
+	ast, err := protobuf.ParseWithDescriptor(filePath, content)
+	if err != nil {
+		return LintResult{}, nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+	return e.lint(filePath, ast, content), ast, nil
+}
+
+func (e *LintEngine) lint(filePath string, ast *protobuf.RootNode, content string) LintResult {
+	result := e.check(filePath, ast, content)
+
+	// A file linted on its own is a module of one file, so module rules
+	// still see the well-known files it imports
+	module := NewModule(map[string]*protobuf.RootNode{filePath: ast})
+	module.Sources = map[string]string{filePath: content}
+	result.Violations = append(result.Violations, e.checkModule(module)[filePath]...)
+
+	e.finish(&result, ast, content)
+	return result
+}
+
+// check runs the enabled per-file rules against a file
+func (e *LintEngine) check(filePath string, ast *protobuf.RootNode, content string) LintResult {
 	result := LintResult{
 		FilePath:   filePath,
 		Violations: make([]Violation, 0),
@@ -41,31 +92,53 @@ func (e *LintEngine) Lint(filePath string, ast *protobuf.RootNode) LintResult {
 	ctx := &LintContext{
 		FilePath: filePath,
 		AST:      ast,
+		Content:  content,
 		Config:   e.config,
 	}
 
 	// Run each rule
 	for _, rule := range rules {
+		if _, ok := rule.(ModuleRule); ok {
+			continue
+		}
 		violations := rule.Check(ast, ctx)
+		e.applySeverity(rule, violations)
 		result.Violations = append(result.Violations, violations...)
 	}
 
+	return result
+}
+
+// applySeverity sets the severity configured for a rule on its violations
+func (e *LintEngine) applySeverity(rule Rule, violations []Violation) {
+	if severity, ok := e.config.severityOverride(rule); ok {
+		for i := range violations {
+			violations[i].Severity = severity
+		}
+	}
+}
+
+// finish keys the violations of a file by declaration, applies the
+// baseline and measures the file
+func (e *LintEngine) finish(result *LintResult, ast *protobuf.RootNode, content string) {
+	// Key violations by declaration so baselines survive line changes
+	nodePath := nodePaths(ast)
+	for i := range result.Violations {
+		result.Violations[i].NodePath = nodePath(result.Violations[i].Position)
+	}
+	if e.baseline != nil {
+		e.baseline.apply(result)
+	}
+
 	// Calculate metrics if enabled
 	if e.config.Quality.Enabled {
-		result.Metrics = e.calculateMetrics(ast)
+		result.Metrics = measureFile(result, ast, content, &e.config.Quality)
 	}
-
-	return result
 }
 
-// LintFiles lints multiple files
+// LintFiles lints multiple files as one module, keyed by import path
 func (e *LintEngine) LintFiles(files map[string]*protobuf.RootNode) []LintResult {
-	results := make([]LintResult, 0, len(files))
-	for path, ast := range files {
-		result := e.Lint(path, ast)
-		results = append(results, result)
-	}
-	return results
+	return e.LintModule("", files)
 }
 
 // GenerateSummary creates a summary of lint results
@@ -76,6 +149,8 @@ func (e *LintEngine) GenerateSummary(results []LintResult) Summary {
 
 	for _, result := range results {
 		summary.TotalViolations += len(result.Violations)
+		summary.Baselined += len(result.Baselined)
+		summary.StaleBaseline += len(result.StaleBaseline)
 		for _, v := range result.Violations {
 			switch v.Severity {
 			case SeverityError:
@@ -88,24 +163,22 @@ func (e *LintEngine) GenerateSummary(results []LintResult) Summary {
 		}
 	}
 
-	return summary
-}
-
-func (e *LintEngine) calculateMetrics(ast *protobuf.RootNode) FileMetrics {
-	// TODO: Implement quality metrics calculation
-	return FileMetrics{
-		MessageCount:          len(ast.Messages),
-		DocumentationCoverage: 0.0,
-		ComplexityScore:       0.0,
+	if e.config.Quality.Enabled {
+		quality := AggregateMetrics(results, &e.config.Quality)
+		summary.Quality = &quality
 	}
+
+	return summary
 }
 
 // LintResult contains the result of linting a single file
 type LintResult struct {
-	FilePath    string
-	Violations  []Violation
-	Metrics     FileMetrics
-	FixedCount  int
+	FilePath      string
+	Violations    []Violation
+	Baselined     []Violation     // Violations accepted by the engine's baseline
+	StaleBaseline []BaselineEntry // Baseline entries of the file matching fewer violations than recorded
+	Metrics       FileMetrics
+	FixedCount    int
 }
 
 // Violation represents a linting violation
@@ -115,6 +188,8 @@ type Violation struct {
 	Category     Category
 	Message      string
 	Position     protobuf.Position
+	NodePath     string // Declaration the violation is about, such as acme.v1.User.name; empty for the file
+	File         string // Import path of the file a module rule's violation is in; empty for per-file rules
 	SuggestedFix *Fix
 }
 
@@ -135,6 +210,7 @@ const (
 	CategoryStyle         Category = "style"
 	CategoryDocumentation Category = "documentation"
 	CategoryStructure     Category = "structure"
+	CategoryAIP           Category = "aip" // Opt-in API design rules
 )
 
 // Fix represents an automatic fix
@@ -154,13 +230,23 @@ type Change struct {
 
 // FileMetrics contains quality metrics for a file
 type FileMetrics struct {
-	FilePath              string
-	MessageCount          int
-	FieldCount            int
-	CommentedMessages     int
-	CommentedFields       int
-	DocumentationCoverage float64
-	ComplexityScore       float64
+	FilePath               string
+	Lines                  int // Up to the last declaration when linted without source
+	MessageCount           int // Including nested messages
+	FieldCount             int
+	EnumCount              int
+	ServiceCount           int
+	RPCCount               int
+	CommentedMessages      int
+	CommentedFields        int
+	Declarations           int // Messages, fields, enums, enum values, services and RPCs
+	DocumentedDeclarations int
+	MaxMessageDepth        int     // Nesting depth of the deepest message, 1 for top-level messages
+	MaxFieldCount          int     // Fields of the largest message
+	DocumentationCoverage  float64 // Percent of documented declarations
+	ComplexityScore        float64 // 0-100, lower is better
+	MaintainabilityScore   float64 // 0-100, higher is better
+	QualityScore           float64 // Weighted score, 0-100, higher is better
 }
 
 // Summary provides an overview of all lint results
@@ -170,11 +256,15 @@ type Summary struct {
 	Errors          int
 	Warnings        int
 	Infos           int
+	Baselined       int            // Violations accepted by the baseline
+	StaleBaseline   int            // Baseline entries that no longer match
+	Quality         *QualityReport // Aggregated quality metrics, nil when disabled
 }
 
 // LintContext provides context during rule checking
 type LintContext struct {
 	FilePath string
 	AST      *protobuf.RootNode
+	Content  string // Source text of the file, empty when only the AST is known
 	Config   *Config
 }
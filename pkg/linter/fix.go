@@ -0,0 +1,233 @@
+package linter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+)
+
+// maxFixPasses bounds how often Fix re-lints a file to pick up fixes that
+// were skipped because they overlapped fixes applied in an earlier pass
+const maxFixPasses = 5
+
+// FixResult describes the fixes applied to one file
+type FixResult struct {
+	FilePath string
+	Original string
+	Content  string // Source with all applied fixes
+	Applied  []*Fix
+	Skipped  []SkippedFix
+}
+
+// Changed reports whether any fix modified the source
+func (r *FixResult) Changed() bool {
+	return r.Content != r.Original
+}
+
+// SkippedFix is a fix that could not be applied
+type SkippedFix struct {
+	Rule   string
+	Fix    *Fix
+	Reason string
+}
+
+// ruleFix is a fix together with the rule that produced it
+type ruleFix struct {
+	rule string
+	fix  *Fix
+}
+
+// ApplyFixes applies fixes to content. Each fix is applied whole or not at
+// all, in order: a fix is skipped when one of its changes overlaps a change
+// of an earlier fix, or when the text it replaces is not what the source
+// holds at that range. Identical changes from different fixes are applied
+// once. Offsets are byte offsets into content.
+func ApplyFixes(content string, fixes []*Fix) (string, []*Fix, []SkippedFix) {
+	ruleFixes := make([]ruleFix, len(fixes))
+	for i, fix := range fixes {
+		ruleFixes[i] = ruleFix{fix: fix}
+	}
+	return applyRuleFixes(content, ruleFixes)
+}
+
+func applyRuleFixes(content string, fixes []ruleFix) (string, []*Fix, []SkippedFix) {
+	var accepted []Change
+	var applied []*Fix
+	var skipped []SkippedFix
+
+	for _, rf := range fixes {
+		if rf.fix == nil || len(rf.fix.Changes) == 0 {
+			continue
+		}
+		var pending []Change
+		reason := ""
+		for _, change := range rf.fix.Changes {
+			if err := checkChange(content, change); err != nil {
+				reason = err.Error()
+				break
+			}
+			if containsChange(accepted, change) || containsChange(pending, change) {
+				continue
+			}
+			if overlapsAny(accepted, change) || overlapsAny(pending, change) {
+				reason = fmt.Sprintf("overlaps another fix at line %d", change.StartPos.Line)
+				break
+			}
+			pending = append(pending, change)
+		}
+		if reason != "" {
+			skipped = append(skipped, SkippedFix{Rule: rf.rule, Fix: rf.fix, Reason: reason})
+			continue
+		}
+		accepted = append(accepted, pending...)
+		applied = append(applied, rf.fix)
+	}
+
+	// An insertion goes before a replacement starting at the same offset;
+	// insertions at the same offset keep the order they were accepted in
+	sort.SliceStable(accepted, func(i, j int) bool {
+		a, b := accepted[i], accepted[j]
+		if a.StartPos.Offset != b.StartPos.Offset {
+			return a.StartPos.Offset < b.StartPos.Offset
+		}
+		return a.StartPos.Offset == a.EndPos.Offset && b.StartPos.Offset != b.EndPos.Offset
+	})
+	var sb strings.Builder
+	last := 0
+	for _, change := range accepted {
+		sb.WriteString(content[last:change.StartPos.Offset])
+		sb.WriteString(change.NewText)
+		last = change.EndPos.Offset
+	}
+	sb.WriteString(content[last:])
+
+	return sb.String(), applied, skipped
+}
+
+// checkChange verifies that a change still applies to content
+func checkChange(content string, c Change) error {
+	start, end := c.StartPos.Offset, c.EndPos.Offset
+	if start < 0 || end < start || end > len(content) {
+		return fmt.Errorf("change at line %d is outside the file", c.StartPos.Line)
+	}
+	if content[start:end] != c.OldText {
+		return fmt.Errorf("stale change at line %d: expected %q, found %q", c.StartPos.Line, c.OldText, content[start:end])
+	}
+	return nil
+}
+
+func containsChange(changes []Change, c Change) bool {
+	for _, other := range changes {
+		if other.StartPos.Offset == c.StartPos.Offset && other.EndPos.Offset == c.EndPos.Offset &&
+			other.NewText == c.NewText && other.OldText == c.OldText {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapsAny reports whether c edits text another change also edits.
+// Insertions conflict with replacements strictly around them and with other
+// insertions at the same offset, which may each depend on what is already
+// there, such as the next free field number; the later one is retried on
+// the next pass.
+func overlapsAny(changes []Change, c Change) bool {
+	for _, other := range changes {
+		if overlaps(other, c) {
+			return true
+		}
+	}
+	return false
+}
+
+func overlaps(a, b Change) bool {
+	aStart, aEnd := a.StartPos.Offset, a.EndPos.Offset
+	bStart, bEnd := b.StartPos.Offset, b.EndPos.Offset
+	switch {
+	case aStart == aEnd && bStart == bEnd:
+		return aStart == bStart
+	case aStart == aEnd:
+		return bStart < aStart && aStart < bEnd
+	case bStart == bEnd:
+		return aStart < bStart && bStart < aEnd
+	}
+	return aStart < bEnd && bStart < aEnd
+}
+
+// Fix lints proto source and applies the auto-fixes of enabled rules. Fixes
+// skipped because they overlap others are retried against the updated
+// source until no fix applies. The result is checked to still parse.
+func (e *LintEngine) Fix(filePath, content string) (*FixResult, error) {
+	result := &FixResult{
+		FilePath: filePath,
+		Original: content,
+		Content:  content,
+	}
+
+	for pass := 0; pass < maxFixPasses; pass++ {
+		lint, ast, err := e.lintSource(filePath, result.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		fixes := e.collectFixes(ast, lint.Violations)
+		if len(fixes) == 0 {
+			break
+		}
+
+		fixed, applied, skipped := applyRuleFixes(result.Content, fixes)
+		result.Skipped = skipped
+		if len(applied) == 0 {
+			break
+		}
+
+		if _, err := protobuf.ParseWithDescriptor(filePath, fixed); err != nil {
+			return nil, fmt.Errorf("fixes produced invalid proto: %w", err)
+		}
+		result.Content = fixed
+		result.Applied = append(result.Applied, applied...)
+		if len(skipped) == 0 {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// collectFixes asks each fixable, enabled rule for the fixes of its
+// violations, ordered by where they start in the file so conflicts between
+// rules resolve the same way on every run
+func (e *LintEngine) collectFixes(ast *protobuf.RootNode, violations []Violation) []ruleFix {
+	var fixes []ruleFix
+	for _, v := range violations {
+		if !e.config.AutoFixEnabled(v.Rule) {
+			continue
+		}
+		rule, ok := e.registry.GetRule(v.Rule)
+		if !ok || !rule.CanAutoFix() {
+			continue
+		}
+		fix, err := rule.AutoFix(ast, v)
+		if err != nil || fix == nil {
+			continue
+		}
+		fixes = append(fixes, ruleFix{rule: v.Rule, fix: fix})
+	}
+	sort.SliceStable(fixes, func(i, j int) bool {
+		a, b := fixStart(fixes[i].fix), fixStart(fixes[j].fix)
+		if a != b {
+			return a < b
+		}
+		return fixes[i].rule < fixes[j].rule
+	})
+	return fixes
+}
+
+func fixStart(fix *Fix) int {
+	if len(fix.Changes) == 0 {
+		return 0
+	}
+	return fix.Changes[0].StartPos.Offset
+}
@@ -0,0 +1,292 @@
+package linter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+	"github.com/platinummonkey/spoke/pkg/linter/rules"
+)
+
+func change(start, end int, oldText, newText string) linter.Change {
+	return linter.Change{
+		StartPos: protobuf.Position{Line: 1, Offset: start},
+		EndPos:   protobuf.Position{Line: 1, Offset: end},
+		OldText:  oldText,
+		NewText:  newText,
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	content := "alpha beta gamma"
+
+	tests := []struct {
+		name        string
+		fixes       []*linter.Fix
+		want        string
+		wantApplied int
+		wantSkipped int
+	}{
+		{
+			name: "non-overlapping fixes are merged",
+			fixes: []*linter.Fix{
+				{Changes: []linter.Change{change(11, 16, "gamma", "GAMMA")}},
+				{Changes: []linter.Change{change(0, 5, "alpha", "ALPHA")}},
+			},
+			want:        "ALPHA beta GAMMA",
+			wantApplied: 2,
+		},
+		{
+			name: "overlapping fix is skipped whole",
+			fixes: []*linter.Fix{
+				{Changes: []linter.Change{change(0, 10, "alpha beta", "ab")}},
+				{Changes: []linter.Change{change(11, 16, "gamma", "G"), change(6, 10, "beta", "B")}},
+			},
+			want:        "ab gamma",
+			wantApplied: 1,
+			wantSkipped: 1,
+		},
+		{
+			name: "stale fix is skipped",
+			fixes: []*linter.Fix{
+				{Changes: []linter.Change{change(0, 5, "delta", "DELTA")}},
+			},
+			want:        content,
+			wantSkipped: 1,
+		},
+		{
+			name: "identical changes apply once",
+			fixes: []*linter.Fix{
+				{Changes: []linter.Change{change(6, 10, "beta", "BETA")}},
+				{Changes: []linter.Change{change(6, 10, "beta", "BETA"), change(0, 5, "alpha", "ALPHA")}},
+			},
+			want:        "ALPHA BETA gamma",
+			wantApplied: 2,
+		},
+		{
+			name: "insertions next to replacements",
+			fixes: []*linter.Fix{
+				{Changes: []linter.Change{change(6, 10, "beta", "BETA")}},
+				{Changes: []linter.Change{change(6, 6, "", "<"), change(10, 10, "", ">")}},
+			},
+			want:        "alpha <BETA> gamma",
+			wantApplied: 2,
+		},
+		{
+			name: "second insertion at the same offset is skipped",
+			fixes: []*linter.Fix{
+				{Changes: []linter.Change{change(5, 5, "", " one")}},
+				{Changes: []linter.Change{change(5, 5, "", " two")}},
+			},
+			want:        "alpha one beta gamma",
+			wantApplied: 1,
+			wantSkipped: 1,
+		},
+		{
+			name: "insertion inside a replacement is skipped",
+			fixes: []*linter.Fix{
+				{Changes: []linter.Change{change(6, 10, "beta", "BETA")}},
+				{Changes: []linter.Change{change(8, 8, "", "-")}},
+			},
+			want:        "alpha BETA gamma",
+			wantApplied: 1,
+			wantSkipped: 1,
+		},
+		{
+			name: "change outside the file is skipped",
+			fixes: []*linter.Fix{
+				{Changes: []linter.Change{change(15, 30, "a", "b")}},
+			},
+			want:        content,
+			wantSkipped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, applied, skipped := linter.ApplyFixes(content, tt.fixes)
+			if got != tt.want {
+				t.Errorf("ApplyFixes() = %q, want %q", got, tt.want)
+			}
+			if len(applied) != tt.wantApplied {
+				t.Errorf("Expected %d applied fixes, got %d", tt.wantApplied, len(applied))
+			}
+			if len(skipped) != tt.wantSkipped {
+				t.Errorf("Expected %d skipped fixes, got %d: %v", tt.wantSkipped, len(skipped), skipped)
+			}
+			for _, s := range skipped {
+				if s.Reason == "" {
+					t.Error("Expected skipped fix to have a reason")
+				}
+			}
+		})
+	}
+}
+
+func newFixEngine(config *linter.Config) *linter.LintEngine {
+	engine := linter.NewLintEngine(config)
+	for _, rule := range rules.DefaultRules() {
+		engine.Registry().Register(rule)
+	}
+	return engine
+}
+
+const renameProto = `syntax = "proto2";
+package test.fix;
+
+enum state {
+  STATE_UNSPECIFIED = 0;
+  stateActive = 1;
+}
+
+message order_item {
+  optional state Status = 1 [default = stateActive];
+  map<string, order_item> Children = 2;
+  optional .test.fix.order_item parent = 3;
+}
+
+service orders {
+  rpc Get(order_item) returns (test.fix.order_item);
+}
+`
+
+func TestLintEngine_FixRenamesReferences(t *testing.T) {
+	engine := newFixEngine(linter.DefaultConfig())
+
+	result, err := engine.Fix("test.proto", renameProto)
+	if err != nil {
+		t.Fatalf("Fix() error = %v", err)
+	}
+
+	want := `syntax = "proto2";
+package test.fix;
+
+enum State {
+  STATE_UNSPECIFIED = 0;
+  STATE_ACTIVE = 1;
+}
+
+message OrderItem {
+  optional State status = 1 [default = STATE_ACTIVE];
+  map<string, OrderItem> children = 2;
+  optional .test.fix.OrderItem parent = 3;
+}
+
+service Orders {
+  rpc Get(OrderItem) returns (test.fix.OrderItem);
+}
+`
+	if result.Content != want {
+		t.Errorf("Fix() content =\n%s\nwant\n%s", result.Content, want)
+	}
+	if !result.Changed() {
+		t.Error("Expected result to report a change")
+	}
+	if len(result.Skipped) != 0 {
+		t.Errorf("Expected no skipped fixes, got %v", result.Skipped)
+	}
+	if len(result.Applied) != 6 {
+		t.Errorf("Expected 6 applied fixes, got %d", len(result.Applied))
+	}
+
+	// Fixing fixed source changes nothing
+	again, err := engine.Fix("test.proto", result.Content)
+	if err != nil {
+		t.Fatalf("Fix() error = %v", err)
+	}
+	if again.Changed() || len(again.Applied) != 0 {
+		t.Errorf("Expected no further fixes, got %d", len(again.Applied))
+	}
+}
+
+func TestLintEngine_FixReservesRenamed(t *testing.T) {
+	config := linter.DefaultConfig()
+	config.AutoFix.ReserveRenamed = true
+	engine := newFixEngine(config)
+
+	content := `syntax = "proto3";
+package test;
+
+enum Color {
+  COLOR_UNSPECIFIED = 0;
+  colorRed = 1;
+}
+
+message Paint {
+  Color MainColor = 1;
+  string name = 2; }
+`
+	result, err := engine.Fix("test.proto", content)
+	if err != nil {
+		t.Fatalf("Fix() error = %v", err)
+	}
+
+	want := `syntax = "proto3";
+package test;
+
+enum Color {
+  COLOR_UNSPECIFIED = 0;
+  COLOR_RED = 1;
+  reserved "colorRed";
+}
+
+message Paint {
+  Color main_color = 1;
+  string name = 2; reserved "MainColor"; }
+`
+	if result.Content != want {
+		t.Errorf("Fix() content =\n%s\nwant\n%s", result.Content, want)
+	}
+}
+
+func TestLintEngine_FixRespectsConfig(t *testing.T) {
+	config := linter.DefaultConfig()
+	config.AutoFix.Rules = map[string]bool{"field-naming": false}
+	engine := newFixEngine(config)
+
+	content := `syntax = "proto3";
+package test;
+
+message widget {
+  string WidgetId = 1;
+}
+`
+	result, err := engine.Fix("test.proto", content)
+	if err != nil {
+		t.Fatalf("Fix() error = %v", err)
+	}
+	if !strings.Contains(result.Content, "message Widget {") {
+		t.Errorf("Expected message to be renamed, got\n%s", result.Content)
+	}
+	if !strings.Contains(result.Content, "string WidgetId = 1;") {
+		t.Errorf("Expected field fix to be disabled, got\n%s", result.Content)
+	}
+}
+
+func TestLintEngine_FixInvalidProto(t *testing.T) {
+	engine := newFixEngine(linter.DefaultConfig())
+
+	if _, err := engine.Fix("test.proto", "message {"); err == nil {
+		t.Error("Expected error for invalid proto")
+	}
+}
+
+func TestLintEngine_LintSource(t *testing.T) {
+	engine := newFixEngine(linter.DefaultConfig())
+
+	result, err := engine.LintSource("test.proto", renameProto)
+	if err != nil {
+		t.Fatalf("LintSource() error = %v", err)
+	}
+	for _, v := range result.Violations {
+		if v.Rule != "enum-value-naming" {
+			continue
+		}
+		if v.Position.Line != 6 || v.Position.Column != 3 {
+			t.Errorf("Expected violation at 6:3, got %d:%d", v.Position.Line, v.Position.Column)
+		}
+		return
+	}
+	t.Error("Expected an enum value naming violation")
+}
@@ -0,0 +1,164 @@
+package linter
+
+import (
+	"math"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+)
+
+// Penalties subtracted from the maintainability score per violation,
+// together capped at maxViolationPenalty
+var violationPenalties = map[Severity]float64{
+	SeverityError:   5,
+	SeverityWarning: 2,
+	SeverityInfo:    0.5,
+}
+
+const maxViolationPenalty = 50.0
+
+// QualityReport is the weighted quality of a set of files, such as a module
+// version. Scores range from 0 to 100.
+type QualityReport struct {
+	Files                 int     `json:"files"`
+	DocumentationCoverage float64 `json:"documentation_coverage"` // Percent of documented declarations
+	ComplexityScore       float64 `json:"complexity_score"`       // Lower is better
+	MaintainabilityScore  float64 `json:"maintainability_score"`  // Higher is better
+	QualityScore          float64 `json:"quality_score"`          // Weighted score, higher is better
+}
+
+// measureFile computes the metrics of a linted file. Without content the
+// file length is taken from the end of its last declaration.
+func measureFile(result *LintResult, ast *protobuf.RootNode, content string, config *QualityConfig) FileMetrics {
+	metrics := FileMetrics{FilePath: result.FilePath}
+	if content != "" {
+		metrics.Lines = strings.Count(strings.TrimRight(content, "\n"), "\n") + 1
+	} else {
+		for node := range declarations(ast) {
+			if end := node.End().Line; end > metrics.Lines {
+				metrics.Lines = end
+			}
+		}
+	}
+
+	var addMessage func(msg *protobuf.MessageNode, depth int)
+	addMessage = func(msg *protobuf.MessageNode, depth int) {
+		metrics.MessageCount++
+		if HasDocComment(msg.Comments) {
+			metrics.CommentedMessages++
+		}
+		if depth > metrics.MaxMessageDepth {
+			metrics.MaxMessageDepth = depth
+		}
+
+		// Fields of oneofs are listed with the message's fields
+		metrics.FieldCount += len(msg.Fields)
+		for _, field := range msg.Fields {
+			if HasDocComment(field.Comments) {
+				metrics.CommentedFields++
+			}
+		}
+		if len(msg.Fields) > metrics.MaxFieldCount {
+			metrics.MaxFieldCount = len(msg.Fields)
+		}
+
+		metrics.EnumCount += len(msg.Enums)
+		for _, nested := range msg.Nested {
+			addMessage(nested, depth+1)
+		}
+	}
+	for _, msg := range ast.Messages {
+		addMessage(msg, 1)
+	}
+	metrics.EnumCount += len(ast.Enums)
+	metrics.ServiceCount = len(ast.Services)
+	for _, svc := range ast.Services {
+		metrics.RPCCount += len(svc.RPCs)
+	}
+
+	coverage := MeasureDocCoverage(ast)
+	metrics.Declarations = coverage.Total
+	metrics.DocumentedDeclarations = coverage.Documented
+	metrics.DocumentationCoverage = round1(coverage.Percent())
+
+	metrics.ComplexityScore = complexityScore(metrics.MaxMessageDepth, metrics.MaxFieldCount, config.Complexity)
+
+	maintainability := 100.0
+	if limit := config.Maintainability.MaxFileLines; limit > 0 && metrics.Lines > limit {
+		maintainability -= math.Min(float64(metrics.Lines-limit)/float64(limit)*50, 50)
+	}
+	penalty := 0.0
+	for _, violations := range [][]Violation{result.Violations, result.Baselined} {
+		for _, v := range violations {
+			penalty += violationPenalties[v.Severity]
+		}
+	}
+	maintainability -= math.Min(penalty, maxViolationPenalty)
+	metrics.MaintainabilityScore = round1(math.Max(maintainability, 0))
+
+	metrics.QualityScore = qualityScore(metrics.DocumentationCoverage, metrics.ComplexityScore, metrics.MaintainabilityScore, config)
+	return metrics
+}
+
+// complexityScore rates the deepest message nesting and the largest message
+// against their limits. A file at both limits scores 50; twice over scores
+// 100.
+func complexityScore(depth, fields int, config ComplexityConfig) float64 {
+	ratio := func(value, limit int) float64 {
+		if limit <= 0 {
+			return 0
+		}
+		return math.Min(float64(value)/float64(limit)*50, 100)
+	}
+	return round1((ratio(depth, config.MaxMessageDepth) + ratio(fields, config.MaxFieldCount)) / 2)
+}
+
+// qualityScore weighs documentation, simplicity and maintainability as
+// configured. Without weights the three count equally.
+func qualityScore(coverage, complexity, maintainability float64, config *QualityConfig) float64 {
+	docWeight := config.DocumentationCoverage.Weight
+	complexityWeight := config.Complexity.Weight
+	maintainabilityWeight := config.Maintainability.Weight
+	total := docWeight + complexityWeight + maintainabilityWeight
+	if total <= 0 {
+		docWeight, complexityWeight, maintainabilityWeight, total = 1, 1, 1, 3
+	}
+
+	score := docWeight*coverage + complexityWeight*(100-complexity) + maintainabilityWeight*maintainability
+	return round1(score / total)
+}
+
+// AggregateMetrics combines the metrics of results into one report.
+// Documentation coverage counts the declarations of all files; complexity
+// and maintainability are averaged weighing each file by its declarations.
+func AggregateMetrics(results []LintResult, config *QualityConfig) QualityReport {
+	report := QualityReport{Files: len(results)}
+	if len(results) == 0 {
+		return report
+	}
+
+	var documented, declarations int
+	var complexity, maintainability, weights float64
+	for _, result := range results {
+		m := result.Metrics
+		documented += m.DocumentedDeclarations
+		declarations += m.Declarations
+		weight := float64(m.Declarations)
+		if weight == 0 {
+			weight = 1
+		}
+		complexity += weight * m.ComplexityScore
+		maintainability += weight * m.MaintainabilityScore
+		weights += weight
+	}
+
+	report.DocumentationCoverage = round1(DocCoverage{Documented: documented, Total: declarations}.Percent())
+	report.ComplexityScore = round1(complexity / weights)
+	report.MaintainabilityScore = round1(maintainability / weights)
+	report.QualityScore = qualityScore(report.DocumentationCoverage, report.ComplexityScore, report.MaintainabilityScore, config)
+	return report
+}
+
+func round1(value float64) float64 {
+	return math.Round(value*10) / 10
+}
@@ -0,0 +1,137 @@
+package linter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+const metricsProto = `syntax = "proto3";
+package acme.v1;
+
+// A user.
+message User {
+  // The user's name.
+  string name = 1;
+  Address address = 2;
+
+  message Address {
+    string street = 1;
+    oneof kind {
+      string city = 2;
+      string region = 3;
+    }
+
+    message Geo {
+      double lat = 1;
+    }
+  }
+}
+
+enum Role {
+  ROLE_UNSPECIFIED = 0;
+}
+
+service UserService {
+  rpc GetUser(User) returns (User);
+}
+`
+
+func TestLintEngine_FileMetrics(t *testing.T) {
+	config := linter.DefaultConfig()
+	config.Lint.Use = []string{"minimal"}
+	config.Quality.Complexity.MaxMessageDepth = 3
+	config.Quality.Complexity.MaxFieldCount = 3
+	engine := newFixEngine(config)
+	m := lintSource(t, engine, "acme/v1/user.proto", metricsProto).Metrics
+
+	if m.Lines != 29 || m.MessageCount != 3 || m.FieldCount != 6 || m.EnumCount != 1 || m.ServiceCount != 1 || m.RPCCount != 1 {
+		t.Errorf("Unexpected counts: %+v", m)
+	}
+	if m.MaxMessageDepth != 3 || m.MaxFieldCount != 3 {
+		t.Errorf("Depth %d and largest message %d, want 3 and 3", m.MaxMessageDepth, m.MaxFieldCount)
+	}
+	if m.CommentedMessages != 1 || m.CommentedFields != 1 {
+		t.Errorf("Commented %d messages and %d fields, want 1 and 1", m.CommentedMessages, m.CommentedFields)
+	}
+	// 3 messages, 6 fields, an enum, its value, a service and an RPC
+	if m.Declarations != 13 || m.DocumentedDeclarations != 2 || m.DocumentationCoverage != 15.4 {
+		t.Errorf("Unexpected coverage: %d of %d, %.1f%%", m.DocumentedDeclarations, m.Declarations, m.DocumentationCoverage)
+	}
+	// Both at their limits
+	if m.ComplexityScore != 50 {
+		t.Errorf("ComplexityScore = %.1f, want 50", m.ComplexityScore)
+	}
+	if m.QualityScore <= 0 || m.QualityScore >= 100 {
+		t.Errorf("QualityScore = %.1f", m.QualityScore)
+	}
+}
+
+func TestLintEngine_MaintainabilityScore(t *testing.T) {
+	config := linter.DefaultConfig()
+	config.Lint.Use = []string{"basic"}
+	config.Quality.Maintainability.MaxFileLines = 20
+	engine := newFixEngine(config)
+
+	clean := lintSource(t, engine, "acme/v1/user.proto", "syntax = \"proto3\";\npackage acme.v1;\n\nmessage User {\n  string name = 1;\n}\n")
+	if clean.Metrics.MaintainabilityScore != 100 {
+		t.Errorf("Clean file maintainability = %.1f, want 100", clean.Metrics.MaintainabilityScore)
+	}
+
+	// Twice the line limit and two naming errors
+	long := "syntax = \"proto3\";\npackage acme.v1;\n\nmessage user {\n  string UserName = 1;\n}\n" + strings.Repeat("// Padding.\n", 34)
+	result := lintSource(t, engine, "acme/v1/user.proto", long)
+	if len(result.Violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %+v", result.Violations)
+	}
+	if result.Metrics.MaintainabilityScore != 40 {
+		t.Errorf("Maintainability = %.1f, want 40", result.Metrics.MaintainabilityScore)
+	}
+}
+
+func TestLintEngine_QualityWeights(t *testing.T) {
+	config := linter.DefaultConfig()
+	config.Quality.DocumentationCoverage.Weight = 1
+	config.Quality.Complexity.Weight = 0
+	config.Quality.Maintainability.Weight = 0
+	engine := newFixEngine(config)
+
+	m := lintSource(t, engine, "acme/v1/user.proto", metricsProto).Metrics
+	if m.QualityScore != m.DocumentationCoverage {
+		t.Errorf("QualityScore = %.1f, want the documentation coverage %.1f", m.QualityScore, m.DocumentationCoverage)
+	}
+}
+
+func TestAggregateMetrics(t *testing.T) {
+	config := linter.DefaultConfig()
+	engine := newFixEngine(config)
+	documented := "syntax = \"proto3\";\npackage acme.v1;\n\n// A user.\nmessage User {\n  // The name.\n  string name = 1;\n}\n"
+	results := []linter.LintResult{
+		lintSource(t, engine, "acme/v1/user.proto", documented),
+		lintSource(t, engine, "acme/v1/all.proto", metricsProto),
+	}
+
+	report := linter.AggregateMetrics(results, &config.Quality)
+	if report.Files != 2 {
+		t.Errorf("Files = %d, want 2", report.Files)
+	}
+	// 4 of 15 declarations are documented
+	if report.DocumentationCoverage != 26.7 {
+		t.Errorf("DocumentationCoverage = %.1f, want 26.7", report.DocumentationCoverage)
+	}
+	if report.QualityScore <= results[1].Metrics.QualityScore || report.QualityScore >= results[0].Metrics.QualityScore {
+		t.Errorf("QualityScore %.1f is not between the file scores %.1f and %.1f",
+			report.QualityScore, results[1].Metrics.QualityScore, results[0].Metrics.QualityScore)
+	}
+
+	summary := engine.GenerateSummary(results)
+	if summary.Quality == nil || *summary.Quality != report {
+		t.Errorf("Summary quality = %+v, want %+v", summary.Quality, report)
+	}
+
+	config.Quality.Enabled = false
+	if summary := newFixEngine(config).GenerateSummary(results); summary.Quality != nil {
+		t.Errorf("Expected no quality with metrics disabled, got %+v", summary.Quality)
+	}
+}
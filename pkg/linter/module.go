@@ -0,0 +1,132 @@
+package linter
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+)
+
+// Module is a set of files linted together, such as the files of a module
+// version, keyed by import path
+type Module struct {
+	Files   map[string]*protobuf.RootNode
+	Sources map[string]string // Source text of the files, when known
+	Symbols *protobuf.SymbolTable
+}
+
+// NewModule indexes the declarations of files keyed by import path
+func NewModule(files map[string]*protobuf.RootNode) *Module {
+	return &Module{
+		Files:   files,
+		Symbols: protobuf.NewSymbolTable(files),
+	}
+}
+
+// Paths returns the import paths of the files of the module, sorted
+func (m *Module) Paths() []string {
+	paths := make([]string, 0, len(m.Files))
+	for path := range m.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ModuleRule is a rule that checks the files of a module together, such as
+// for import cycles or names declared in two files. Its violations set File
+// to the import path of the file they are reported in. Module rules do not
+// run in the per-file pass; their Check is not called.
+type ModuleRule interface {
+	Rule
+	CheckModule(module *Module, ctx *LintContext) []Violation
+}
+
+// checkModule runs the enabled module rules and groups their violations by
+// file
+func (e *LintEngine) checkModule(module *Module) map[string][]Violation {
+	ctx := &LintContext{Config: e.config}
+	byFile := make(map[string][]Violation)
+	for _, rule := range e.registry.GetEnabledRules(e.config) {
+		moduleRule, ok := rule.(ModuleRule)
+		if !ok {
+			continue
+		}
+		violations := moduleRule.CheckModule(module, ctx)
+		e.applySeverity(rule, violations)
+		for _, v := range violations {
+			byFile[v.File] = append(byFile[v.File], v)
+		}
+	}
+	return byFile
+}
+
+// LintModule lints files as one module: each file with the per-file rules
+// and all of them together with the module rules. Files are keyed by path
+// and imported relative to root, so root/acme/v1/user.proto is imported as
+// acme/v1/user.proto; with an empty root the keys are the import paths.
+// Results are ordered by path.
+func (e *LintEngine) LintModule(root string, files map[string]*protobuf.RootNode) []LintResult {
+	return e.lintModule(root, files, nil)
+}
+
+// LintModuleSources parses the files of a module together and lints them
+// like LintModule. Rules see the source text, as with LintSource. Files that
+// fail to parse are left out of the results and their errors joined.
+func (e *LintEngine) LintModuleSources(root string, sources map[string]string) ([]LintResult, error) {
+	byImport := make(map[string]string, len(sources))
+	imported := make(map[string]string, len(sources))
+	for path, content := range sources {
+		importPath := moduleImportPath(root, path)
+		byImport[importPath] = path
+		imported[importPath] = content
+	}
+
+	parsed, err := protobuf.ParseFiles(imported)
+	files := make(map[string]*protobuf.RootNode, len(parsed))
+	for importPath, ast := range parsed {
+		files[byImport[importPath]] = ast
+	}
+	return e.lintModule(root, files, sources), err
+}
+
+func (e *LintEngine) lintModule(root string, files map[string]*protobuf.RootNode, sources map[string]string) []LintResult {
+	paths := make([]string, 0, len(files))
+	imported := make(map[string]*protobuf.RootNode, len(files))
+	for path, ast := range files {
+		paths = append(paths, path)
+		imported[moduleImportPath(root, path)] = ast
+	}
+	sort.Strings(paths)
+
+	module := NewModule(imported)
+	module.Sources = make(map[string]string, len(sources))
+	for path, content := range sources {
+		module.Sources[moduleImportPath(root, path)] = content
+	}
+	moduleViolations := e.checkModule(module)
+
+	results := make([]LintResult, 0, len(files))
+	for _, path := range paths {
+		ast, content := files[path], sources[path]
+		result := e.check(path, ast, content)
+		result.Violations = append(result.Violations, moduleViolations[moduleImportPath(root, path)]...)
+		e.finish(&result, ast, content)
+		results = append(results, result)
+	}
+	return results
+}
+
+// moduleImportPath is the path a file of a module rooted at root is
+// imported by
+func moduleImportPath(root, path string) string {
+	if root == "" {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,129 @@
+package linter_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+// moduleViolations lists the rules violated in each result, in order
+func moduleViolations(results []linter.LintResult) map[string][]string {
+	out := make(map[string][]string)
+	for _, result := range results {
+		for _, v := range result.Violations {
+			out[result.FilePath] = append(out[result.FilePath], v.Rule)
+		}
+	}
+	return out
+}
+
+func TestLintEngine_LintModuleSources(t *testing.T) {
+	config := linter.DefaultConfig()
+	config.Lint.Use = []string{"basic"}
+	engine := newFixEngine(config)
+
+	root := filepath.Join("protos", "acme")
+	common := filepath.Join(root, "acme", "v1", "common.proto")
+	order := filepath.Join(root, "acme", "v1", "order.proto")
+	dup := filepath.Join(root, "other", "v1", "dup.proto")
+	results, err := engine.LintModuleSources(root, map[string]string{
+		common: "syntax = \"proto3\";\npackage acme.v1;\n\nmessage Money {\n  int64 units = 1;\n}\n",
+		order: "syntax = \"proto3\";\npackage acme.v1;\n\n" +
+			"import \"acme/v1/common.proto\";\nimport \"google/protobuf/empty.proto\";\n\n" +
+			"message Order {\n  Money total = 1;\n}\n",
+		dup: "syntax = \"proto3\";\npackage acme.v1;\n\nmessage Money {}\n",
+	})
+	if err != nil {
+		t.Fatalf("LintModuleSources() error = %v", err)
+	}
+
+	var paths []string
+	for _, result := range results {
+		paths = append(paths, result.FilePath)
+	}
+	if want := []string{common, order, dup}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("Results for %v, want %v", paths, want)
+	}
+
+	want := map[string][]string{
+		common: {"package-same-directory"},
+		order:  {"import-used", "package-same-directory"},
+		dup:    {"package-directory-match", "package-same-directory", "symbol-unique"},
+	}
+	if got := moduleViolations(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("Violations = %v, want %v", got, want)
+	}
+
+	// Module violations name their file by import path and are keyed by
+	// declaration like any other
+	for _, v := range results[2].Violations {
+		if v.Rule != "symbol-unique" {
+			continue
+		}
+		if v.File != "other/v1/dup.proto" || v.NodePath != "acme.v1.Money" || v.Position.Line != 4 {
+			t.Errorf("Unexpected violation %+v", v)
+		}
+	}
+}
+
+func TestLintEngine_LintModuleSourcesParseError(t *testing.T) {
+	engine := newFixEngine(linter.DefaultConfig())
+	results, err := engine.LintModuleSources("", map[string]string{
+		"acme/v1/ok.proto":     "syntax = \"proto3\";\npackage acme.v1;\n",
+		"acme/v1/broken.proto": "syntax = \"proto3\";\nmessage {\n",
+	})
+	if err == nil || !strings.Contains(err.Error(), "acme/v1/broken.proto") {
+		t.Errorf("Expected an error for the broken file, got %v", err)
+	}
+	if len(results) != 1 || results[0].FilePath != "acme/v1/ok.proto" {
+		t.Errorf("Expected only the file that parses, got %+v", results)
+	}
+}
+
+func TestLintEngine_ModuleRulesSingleFile(t *testing.T) {
+	config := linter.DefaultConfig()
+	config.Lint.Use = []string{"basic"}
+	engine := newFixEngine(config)
+
+	// A file linted on its own still has its well-known imports checked
+	result := lintSource(t, engine, "acme/v1/user.proto",
+		"syntax = \"proto3\";\npackage acme.v1;\n\nimport \"google/protobuf/empty.proto\";\nimport \"acme/v1/other.proto\";\n\nmessage User {}\n")
+	if got := moduleViolations([]linter.LintResult{result})["acme/v1/user.proto"]; !reflect.DeepEqual(got, []string{"import-used"}) {
+		t.Errorf("Violations = %v, want [import-used]", got)
+	}
+
+	// Module rules follow severity overrides
+	config.Lint.Rules["import-used"] = "error"
+	result = lintSource(t, newFixEngine(config), "acme/v1/user.proto",
+		"syntax = \"proto3\";\npackage acme.v1;\n\nimport \"google/protobuf/empty.proto\";\n\nmessage User {}\n")
+	if len(result.Violations) != 1 || result.Violations[0].Severity != linter.SeverityError {
+		t.Errorf("Expected one error, got %+v", result.Violations)
+	}
+}
+
+func TestLintEngine_TypeReferencedOptIn(t *testing.T) {
+	files := map[string]string{
+		"acme/v1/user.proto": "syntax = \"proto3\";\npackage acme.v1;\n\nmessage User {}\n",
+	}
+
+	results, err := newFixEngine(linter.DefaultConfig()).LintModuleSources("", files)
+	if err != nil {
+		t.Fatalf("LintModuleSources() error = %v", err)
+	}
+	if len(results[0].Violations) != 0 {
+		t.Errorf("Expected type-referenced to be off by default, got %+v", results[0].Violations)
+	}
+
+	config := linter.DefaultConfig()
+	config.Lint.Rules["type-referenced"] = true
+	results, err = newFixEngine(config).LintModuleSources("", files)
+	if err != nil {
+		t.Fatalf("LintModuleSources() error = %v", err)
+	}
+	if got := moduleViolations(results)["acme/v1/user.proto"]; !reflect.DeepEqual(got, []string{"type-referenced"}) {
+		t.Errorf("Violations = %v, want [type-referenced]", got)
+	}
+}
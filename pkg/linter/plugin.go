@@ -0,0 +1,195 @@
+package linter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/plugins"
+	"github.com/sirupsen/logrus"
+)
+
+// LoadPluginRules discovers the linter plugins in the default plugin
+// directories and in dirs, and returns their rules. Plugins that fail to
+// load are logged and skipped.
+func LoadPluginRules(ctx context.Context, dirs []string) []Rule {
+	log := logrus.New()
+	log.SetLevel(logrus.WarnLevel)
+	loader := plugins.NewLoader(append(plugins.GetDefaultPluginDirectories(), dirs...), log)
+
+	discovered, _ := loader.DiscoverPlugins(ctx)
+	var rules []Rule
+	for _, plugin := range discovered {
+		if linterPlugin, ok := plugin.(plugins.LinterPlugin); ok {
+			rules = append(rules, PluginRules(linterPlugin)...)
+		}
+	}
+	return rules
+}
+
+// PluginRule is a rule of a linter plugin. The plugin runs out of process,
+// once per module for all of its enabled rules, so its rules are module
+// rules. They are off when lint.rules or lint.categories turns them off and
+// otherwise run like rules outside every preset.
+type PluginRule struct {
+	spec     plugins.LintRuleSpec
+	category Category
+	severity Severity
+	runner   *pluginRunner
+}
+
+// PluginRules returns the rules a linter plugin declares
+func PluginRules(plugin plugins.LinterPlugin) []Rule {
+	spec := plugin.GetLinterSpec()
+	if spec == nil {
+		return nil
+	}
+	runner := &pluginRunner{plugin: plugin}
+	rules := make([]Rule, 0, len(spec.Rules))
+	for _, ruleSpec := range spec.Rules {
+		rule := &PluginRule{spec: ruleSpec, category: CategoryStyle, severity: SeverityWarning, runner: runner}
+		if ruleSpec.Category != "" {
+			rule.category = Category(ruleSpec.Category)
+		}
+		if severity, ok := parseSeverity(ruleSpec.Severity); ok {
+			rule.severity = severity
+		}
+		runner.rules = append(runner.rules, rule)
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (r *PluginRule) Name() string        { return r.spec.Name }
+func (r *PluginRule) Category() Category  { return r.category }
+func (r *PluginRule) Severity() Severity  { return r.severity }
+func (r *PluginRule) CanAutoFix() bool    { return false }
+func (r *PluginRule) Description() string { return r.spec.Description }
+
+// Plugin returns the ID of the plugin that declares the rule
+func (r *PluginRule) Plugin() string { return r.runner.plugin.Manifest().ID }
+
+// AutoFix is not supported by plugin rules
+func (r *PluginRule) AutoFix(node *protobuf.RootNode, violation Violation) (*Fix, error) {
+	return nil, fmt.Errorf("rule %s does not support auto-fix", r.Name())
+}
+
+// Check does nothing; plugin rules check whole modules
+func (r *PluginRule) Check(node *protobuf.RootNode, ctx *LintContext) []Violation {
+	return nil
+}
+
+// CheckModule returns the violations of the rule the plugin reports for the
+// module. A plugin that fails to run is reported as a violation of the first
+// of its rules on the first file of the module.
+func (r *PluginRule) CheckModule(module *Module, ctx *LintContext) []Violation {
+	violations, err := r.runner.run(module, ctx.Config)
+	if err != nil {
+		if r.runner.firstEnabled(ctx.Config) != r || len(module.Files) == 0 {
+			return nil
+		}
+		return []Violation{{
+			Rule:     r.Name(),
+			Severity: SeverityError,
+			Category: r.Category(),
+			Message:  err.Error(),
+			File:     module.Paths()[0],
+		}}
+	}
+	return violations[r.Name()]
+}
+
+// pluginRunner runs a linter plugin once per module and hands each of its
+// rules their share of the violations
+type pluginRunner struct {
+	plugin plugins.LinterPlugin
+	rules  []*PluginRule
+
+	mu         sync.Mutex
+	module     *Module
+	violations map[string][]Violation
+	err        error
+}
+
+func (p *pluginRunner) run(module *Module, config *Config) (map[string][]Violation, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.module == module {
+		return p.violations, p.err
+	}
+	p.module = module
+	p.violations, p.err = p.lint(module, config)
+	return p.violations, p.err
+}
+
+func (p *pluginRunner) firstEnabled(config *Config) *PluginRule {
+	for _, rule := range p.rules {
+		if config.ruleEnabled(rule) {
+			return rule
+		}
+	}
+	return nil
+}
+
+func (p *pluginRunner) lint(module *Module, config *Config) (map[string][]Violation, error) {
+	enabled := make(map[string]*PluginRule)
+	req := &plugins.LintRequest{ProtocolVersion: plugins.LintProtocolVersion}
+	for _, rule := range p.rules {
+		if config.ruleEnabled(rule) {
+			enabled[rule.Name()] = rule
+			req.Rules = append(req.Rules, rule.Name())
+		}
+	}
+	for _, path := range module.Paths() {
+		req.Files = append(req.Files, plugins.LintFile{
+			Path:    path,
+			Content: module.Sources[path],
+			AST:     module.Files[path],
+		})
+	}
+
+	resp, err := p.plugin.Lint(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Violations of rules the plugin does not declare or that are off, and
+	// of files outside the module, are dropped
+	violations := make(map[string][]Violation)
+	for _, v := range resp.Violations {
+		rule, ok := enabled[v.Rule]
+		if !ok {
+			continue
+		}
+		if v.File == "" && len(module.Files) == 1 {
+			v.File = module.Paths()[0]
+		}
+		if _, ok := module.Files[v.File]; !ok {
+			continue
+		}
+		severity, ok := parseSeverity(v.Severity)
+		if !ok {
+			severity = rule.Severity()
+		}
+		violations[v.Rule] = append(violations[v.Rule], Violation{
+			Rule:     v.Rule,
+			Severity: severity,
+			Category: rule.Category(),
+			Message:  v.Message,
+			Position: protobuf.Position{Line: v.Line, Column: v.Column},
+			NodePath: v.NodePath,
+			File:     v.File,
+		})
+	}
+	return violations, nil
+}
+
+// parseSeverity parses error, warning or info
+func parseSeverity(value string) (Severity, bool) {
+	_, severity, err := parseRuleSetting(value)
+	if err != nil || severity == "" {
+		return "", false
+	}
+	return severity, true
+}
@@ -0,0 +1,149 @@
+package linter_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/linter"
+	"github.com/platinummonkey/spoke/pkg/plugins"
+)
+
+// fakeLinterPlugin answers lint requests in process
+type fakeLinterPlugin struct {
+	requests []*plugins.LintRequest
+	response *plugins.LintResponse
+	err      error
+}
+
+func (p *fakeLinterPlugin) Manifest() *plugins.Manifest {
+	return &plugins.Manifest{ID: "acme-rules", Type: plugins.PluginTypeLinter}
+}
+func (p *fakeLinterPlugin) Load() error   { return nil }
+func (p *fakeLinterPlugin) Unload() error { return nil }
+
+func (p *fakeLinterPlugin) GetLinterSpec() *plugins.LinterSpec {
+	return &plugins.LinterSpec{
+		Executable: "acme-lint",
+		Rules: []plugins.LintRuleSpec{
+			{Name: "acme-field-prefix", Category: "acme", Severity: "error", Description: "Fields must not start with x_"},
+			{Name: "acme-message-suffix", Description: "Messages must not end in Data"},
+		},
+	}
+}
+
+func (p *fakeLinterPlugin) Lint(ctx context.Context, req *plugins.LintRequest) (*plugins.LintResponse, error) {
+	p.requests = append(p.requests, req)
+	return p.response, p.err
+}
+
+func newPluginEngine(config *linter.Config, plugin plugins.LinterPlugin) *linter.LintEngine {
+	engine := newFixEngine(config)
+	for _, rule := range linter.PluginRules(plugin) {
+		engine.Registry().Register(rule)
+	}
+	return engine
+}
+
+func TestPluginRules(t *testing.T) {
+	rules := linter.PluginRules(&fakeLinterPlugin{})
+	if len(rules) != 2 {
+		t.Fatalf("Expected 2 rules, got %d", len(rules))
+	}
+	if rules[0].Category() != "acme" || rules[0].Severity() != linter.SeverityError {
+		t.Errorf("Unexpected first rule %s [%s]", rules[0].Category(), rules[0].Severity())
+	}
+	if rules[1].Category() != linter.CategoryStyle || rules[1].Severity() != linter.SeverityWarning {
+		t.Errorf("Expected style warning defaults, got %s [%s]", rules[1].Category(), rules[1].Severity())
+	}
+	if plugin := rules[0].(*linter.PluginRule).Plugin(); plugin != "acme-rules" {
+		t.Errorf("Plugin() = %s", plugin)
+	}
+	if _, ok := rules[0].(linter.ModuleRule); !ok {
+		t.Error("Plugin rules should be module rules")
+	}
+}
+
+func TestPluginRules_LintModule(t *testing.T) {
+	plugin := &fakeLinterPlugin{response: &plugins.LintResponse{Violations: []plugins.LintViolation{
+		{Rule: "acme-field-prefix", File: "acme/v1/user.proto", Line: 5, Column: 3, Message: "Field x_name starts with x_", NodePath: "acme.v1.User.x_name"},
+		{Rule: "acme-message-suffix", File: "acme/v1/team.proto", Line: 4, Column: 1, Severity: "info", Message: "Message TeamData ends in Data"},
+		{Rule: "acme-unknown", File: "acme/v1/user.proto", Line: 1, Message: "Undeclared rule"},
+		{Rule: "acme-field-prefix", File: "other.proto", Line: 1, Message: "Outside the module"},
+	}}}
+	config := linter.DefaultConfig()
+	config.Lint.Use = []string{"minimal"}
+	engine := newPluginEngine(config, plugin)
+
+	results, err := engine.LintModuleSources("", map[string]string{
+		"acme/v1/user.proto": "syntax = \"proto3\";\npackage acme.v1;\n\nmessage User {\n  string x_name = 1;\n}\n",
+		"acme/v1/team.proto": "syntax = \"proto3\";\npackage acme.v1;\n\nmessage TeamData {}\n",
+	})
+	if err != nil {
+		t.Fatalf("LintModuleSources() error = %v", err)
+	}
+
+	// The plugin runs once for the module, with all its enabled rules
+	if len(plugin.requests) != 1 {
+		t.Fatalf("Plugin ran %d times, want once", len(plugin.requests))
+	}
+	req := plugin.requests[0]
+	if !reflect.DeepEqual(req.Rules, []string{"acme-field-prefix", "acme-message-suffix"}) {
+		t.Errorf("Requested rules %v", req.Rules)
+	}
+	if len(req.Files) != 2 || req.Files[0].Path != "acme/v1/team.proto" || req.Files[0].AST == nil || req.Files[0].Content == "" {
+		t.Errorf("Unexpected request files %+v", req.Files)
+	}
+
+	want := map[string][]string{
+		"acme/v1/team.proto": {"acme-message-suffix"},
+		"acme/v1/user.proto": {"acme-field-prefix"},
+	}
+	if got := moduleViolations(results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Violations = %v, want %v", got, want)
+	}
+	team, user := results[0].Violations[0], results[1].Violations[0]
+	if team.Severity != linter.SeverityInfo {
+		t.Errorf("Expected the severity reported by the plugin, got %s", team.Severity)
+	}
+	if user.Severity != linter.SeverityError || user.Position.Line != 5 || user.NodePath != "acme.v1.User.x_name" {
+		t.Errorf("Unexpected violation %+v", user)
+	}
+}
+
+func TestPluginRules_Config(t *testing.T) {
+	plugin := &fakeLinterPlugin{response: &plugins.LintResponse{Violations: []plugins.LintViolation{
+		{Rule: "acme-field-prefix", Line: 5, Message: "Field x_name starts with x_"},
+		{Rule: "acme-message-suffix", Line: 4, Message: "Message UserData ends in Data"},
+	}}}
+	config := linter.DefaultConfig()
+	config.Lint.Rules["acme-field-prefix"] = "warning"
+	config.Lint.Rules["acme-message-suffix"] = "off"
+	engine := newPluginEngine(config, plugin)
+
+	// Violations that name no file are in the file linted on its own
+	result := lintSource(t, engine, "acme/v1/user.proto",
+		"syntax = \"proto3\";\npackage acme.v1;\n\nmessage UserData {\n  string x_name = 1;\n}\n")
+
+	if !reflect.DeepEqual(plugin.requests[0].Rules, []string{"acme-field-prefix"}) {
+		t.Errorf("Requested rules %v, want only the enabled rule", plugin.requests[0].Rules)
+	}
+	if len(result.Violations) != 1 || result.Violations[0].Severity != linter.SeverityWarning {
+		t.Errorf("Expected one warning, got %+v", result.Violations)
+	}
+}
+
+func TestPluginRules_Failure(t *testing.T) {
+	plugin := &fakeLinterPlugin{err: errors.New("linter plugin acme-rules failed: exit status 2")}
+	engine := newPluginEngine(linter.DefaultConfig(), plugin)
+
+	result := lintSource(t, engine, "acme/v1/user.proto", "syntax = \"proto3\";\npackage acme.v1;\n")
+	if len(result.Violations) != 1 {
+		t.Fatalf("Expected the failure once, got %+v", result.Violations)
+	}
+	v := result.Violations[0]
+	if v.Rule != "acme-field-prefix" || v.Severity != linter.SeverityError || v.Message != "linter plugin acme-rules failed: exit status 2" {
+		t.Errorf("Unexpected violation %+v", v)
+	}
+}
@@ -0,0 +1,143 @@
+package linter
+
+import (
+	"sort"
+	"strings"
+)
+
+// Preset names accepted in lint.use
+const (
+	PresetGoogle   = "google"   // Google protobuf style guide
+	PresetUber     = "uber"     // Uber protobuf style guide (V2)
+	PresetMinimal  = "minimal"  // buf MINIMAL: package and file layout
+	PresetBasic    = "basic"    // buf BASIC: MINIMAL plus naming
+	PresetDefault  = "default"  // buf DEFAULT: BASIC plus API conventions
+	PresetComments = "comments" // buf COMMENTS: every declaration is documented
+	PresetAIP      = "aip"      // Google API Improvement Proposals (opt-in)
+)
+
+var (
+	minimalRules = []string{
+		"package-defined",
+		"package-directory-match",
+		"package-same-directory",
+		"import-no-cycle",
+		"symbol-unique",
+	}
+
+	importRules = []string{
+		"import-used",
+	}
+
+	namingRules = []string{
+		"message-naming",
+		"field-naming",
+		"enum-naming",
+		"enum-value-naming",
+		"service-naming",
+		"rpc-naming",
+		"package-naming",
+	}
+
+	commentRules = []string{
+		"comment-message",
+		"comment-field",
+		"comment-enum",
+		"comment-service",
+		"comment-rpc",
+	}
+
+	deprecationRules = []string{
+		"deprecated-comment",
+		"deprecated-reference",
+	}
+
+	apiRules = []string{
+		"enum-zero-value-suffix",
+		"enum-value-prefix",
+		"file-naming",
+		"package-version-suffix",
+		"rpc-request-response-unique",
+		"rpc-request-standard-name",
+		"rpc-response-standard-name",
+		"service-suffix",
+	}
+
+	// aipRules check resource-oriented API design (https://google.aip.dev).
+	// They are opt-in: only lint.use: [aip] or a category setting enables
+	// them.
+	aipRules = []string{
+		"aip-standard-method-request",
+		"aip-standard-method-response",
+		"aip-pagination",
+		"aip-update-field-mask",
+		"aip-resource-pattern",
+		"aip-http-binding",
+	}
+
+	// optInRules only run when selected by preset, category or name:
+	// the AIP rules and rules that are noisy for typical modules
+	optInRules = concat(aipRules, []string{"type-referenced"})
+)
+
+// presets maps each preset to the built-in rules it enables
+var presets = map[string][]string{
+	PresetGoogle:   concat(namingRules, []string{"file-naming"}, deprecationRules),
+	PresetUber:     concat(minimalRules, importRules, namingRules, apiRules, commentRules, deprecationRules, []string{"documentation-coverage"}),
+	PresetMinimal:  minimalRules,
+	PresetBasic:    concat(minimalRules, importRules, namingRules),
+	PresetDefault:  concat(minimalRules, importRules, namingRules, apiRules),
+	PresetComments: commentRules,
+	PresetAIP:      aipRules,
+}
+
+func concat(lists ...[]string) []string {
+	var out []string
+	for _, list := range lists {
+		out = append(out, list...)
+	}
+	return out
+}
+
+// Presets returns the names of the built-in presets
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PresetRules returns the rules a preset enables. Preset names are case
+// insensitive, so buf's DEFAULT and default are the same preset.
+func PresetRules(name string) ([]string, bool) {
+	rules, ok := presets[strings.ToLower(name)]
+	if !ok {
+		return nil, false
+	}
+	return append([]string(nil), rules...), true
+}
+
+// inAnyPreset reports whether a rule belongs to a built-in preset. Rules
+// outside every preset, such as custom rules, are not selected by lint.use.
+func inAnyPreset(rule string) bool {
+	for _, rules := range presets {
+		for _, r := range rules {
+			if r == rule {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isOptIn reports whether a rule only runs when explicitly selected
+func isOptIn(rule string) bool {
+	for _, r := range optInRules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package linter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// violatingRule reports one violation per file
+type violatingRule struct {
+	mockRule
+}
+
+func (r *violatingRule) Check(node *protobuf.RootNode, ctx *LintContext) []Violation {
+	return []Violation{{Rule: r.name, Severity: r.severity, Category: r.category, Message: "violation"}}
+}
+
+func newPresetRegistry() *RuleRegistry {
+	registry := NewRuleRegistry()
+	for _, name := range []string{"message-naming", "comment-message", "package-defined", "documentation-coverage"} {
+		category := CategoryNaming
+		if name == "comment-message" || name == "documentation-coverage" {
+			category = CategoryDocumentation
+		}
+		registry.Register(&mockRule{name: name, category: category, severity: SeverityError})
+	}
+	registry.Register(&mockRule{name: "team-custom", category: CategoryStyle, severity: SeverityWarning})
+	registry.Register(&mockRule{name: "aip-pagination", category: CategoryAIP, severity: SeverityError})
+	return registry
+}
+
+func enabledNames(rules []Rule) []string {
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, rule.Name())
+	}
+	return names
+}
+
+func TestPresets(t *testing.T) {
+	assert.Equal(t, []string{"aip", "basic", "comments", "default", "google", "minimal", "uber"}, Presets())
+
+	rules, ok := PresetRules("DEFAULT")
+	require.True(t, ok)
+	assert.Contains(t, rules, "package-directory-match")
+	assert.Contains(t, rules, "rpc-request-response-unique")
+	assert.NotContains(t, rules, "comment-message")
+
+	rules, ok = PresetRules("uber")
+	require.True(t, ok)
+	assert.Contains(t, rules, "comment-rpc")
+	assert.Contains(t, rules, "package-version-suffix")
+
+	rules, ok = PresetRules("aip")
+	require.True(t, ok)
+	assert.Contains(t, rules, "aip-http-binding")
+
+	_, ok = PresetRules("acme")
+	assert.False(t, ok)
+}
+
+func TestRuleRegistry_GetEnabledRulesByConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		lint       LintRules
+		wantEnable []string
+	}{
+		{
+			name:       "no presets enables everything",
+			lint:       LintRules{},
+			wantEnable: []string{"comment-message", "documentation-coverage", "message-naming", "package-defined", "team-custom"},
+		},
+		{
+			name:       "google preset",
+			lint:       LintRules{Use: []string{"google"}},
+			wantEnable: []string{"message-naming", "team-custom"},
+		},
+		{
+			name:       "presets combine",
+			lint:       LintRules{Use: []string{"minimal", "comments"}},
+			wantEnable: []string{"comment-message", "package-defined", "team-custom"},
+		},
+		{
+			name: "rules override presets",
+			lint: LintRules{
+				Use:   []string{"google"},
+				Rules: map[string]interface{}{"message-naming": false, "comment-message": "warning", "team-custom": "off"},
+			},
+			wantEnable: []string{"comment-message"},
+		},
+		{
+			name: "category off",
+			lint: LintRules{
+				Use:        []string{"uber"},
+				Rules:      map[string]interface{}{"documentation-coverage": true},
+				Categories: map[string]string{"documentation": "off"},
+			},
+			wantEnable: []string{"documentation-coverage", "message-naming", "package-defined", "team-custom"},
+		},
+		{
+			name:       "aip preset opts in",
+			lint:       LintRules{Use: []string{"google", "aip"}},
+			wantEnable: []string{"aip-pagination", "message-naming", "team-custom"},
+		},
+		{
+			name:       "aip category opts in",
+			lint:       LintRules{Categories: map[string]string{"aip": "warning"}},
+			wantEnable: []string{"aip-pagination", "comment-message", "documentation-coverage", "message-naming", "package-defined", "team-custom"},
+		},
+	}
+
+	registry := newPresetRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Lint: tt.lint}
+			assert.Equal(t, tt.wantEnable, enabledNames(registry.GetEnabledRules(config)))
+		})
+	}
+
+	assert.Len(t, registry.GetEnabledRules(nil), 6)
+}
+
+func TestLintEngine_SeverityOverrides(t *testing.T) {
+	config := DefaultConfig()
+	config.Lint.Use = nil
+	config.Lint.Rules["message-naming"] = "info"
+	config.Lint.Categories["style"] = "error"
+	engine := NewLintEngine(config)
+	engine.Registry().Register(&violatingRule{mockRule{name: "message-naming", category: CategoryNaming, severity: SeverityError}})
+	engine.Registry().Register(&violatingRule{mockRule{name: "team-custom", category: CategoryStyle, severity: SeverityWarning}})
+	engine.Registry().Register(&violatingRule{mockRule{name: "comment-message", category: CategoryDocumentation, severity: SeverityWarning}})
+
+	result := engine.Lint("test.proto", &protobuf.RootNode{})
+	severities := make(map[string]Severity)
+	for _, v := range result.Violations {
+		severities[v.Rule] = v.Severity
+	}
+	assert.Equal(t, map[string]Severity{
+		"message-naming":  SeverityInfo,
+		"team-custom":     SeverityError,
+		"comment-message": SeverityWarning,
+	}, severities)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	config := DefaultConfig()
+	assert.NoError(t, config.Validate())
+
+	config.Lint.Use = []string{"google", "BASIC"}
+	config.Lint.Rules["message-naming"] = "warning"
+	config.Lint.Categories["documentation"] = "off"
+	assert.NoError(t, config.Validate())
+
+	config.Lint.Rules["message-naming"] = "loud"
+	assert.ErrorContains(t, config.Validate(), "rule message-naming")
+
+	config.Lint.Rules["message-naming"] = true
+	config.Lint.Use = []string{"acme"}
+	assert.ErrorContains(t, config.Validate(), `unknown lint preset "acme"`)
+}
+
+func TestLoadConfig_UnknownPreset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spoke-lint.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("version: v1\nlint:\n  use:\n    - acme\n"), 0644))
+
+	_, err := LoadConfig(path)
+	assert.ErrorContains(t, err, "unknown lint preset")
+}
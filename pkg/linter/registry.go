@@ -1,6 +1,8 @@
 package linter
 
 import (
+	"sort"
+
 	"github.com/platinummonkey/spoke/pkg/api/protobuf"
 )
 
@@ -44,24 +46,34 @@ func (r *RuleRegistry) GetRule(name string) (Rule, bool) {
 	return rule, ok
 }
 
-// GetAllRules returns all registered rules
+// GetAllRules returns all registered rules, ordered by name
 func (r *RuleRegistry) GetAllRules() []Rule {
 	rules := make([]Rule, 0, len(r.rules))
 	for _, rule := range r.rules {
 		rules = append(rules, rule)
 	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Name() < rules[j].Name()
+	})
 	return rules
 }
 
-// GetEnabledRules returns rules enabled by config
+// GetEnabledRules returns the rules enabled by config: the rules of the
+// presets in lint.use, adjusted by lint.rules and lint.categories. All rules
+// are enabled when config is nil.
 func (r *RuleRegistry) GetEnabledRules(config *Config) []Rule {
-	// TODO: Filter rules based on config
-	// - Check config.Lint.Use for style guides
-	// - Check config.Lint.Rules for enabled/disabled rules
-	// - Apply severity overrides
+	all := r.GetAllRules()
+	if config == nil {
+		return all
+	}
 
-	// For now, return all rules
-	return r.GetAllRules()
+	rules := make([]Rule, 0, len(all))
+	for _, rule := range all {
+		if config.ruleEnabled(rule) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
 }
 
 // GetRulesByCategory returns rules in a specific category
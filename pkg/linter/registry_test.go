@@ -174,7 +174,7 @@ func TestRuleRegistry_GetEnabledRules(t *testing.T) {
 
 	config := DefaultConfig()
 
-	// Currently GetEnabledRules returns all rules (TODO implementation)
+	// Rules outside every preset run whichever presets are used
 	enabledRules := registry.GetEnabledRules(config)
 	assert.Equal(t, 3, len(enabledRules))
 
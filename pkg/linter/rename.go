@@ -0,0 +1,286 @@
+package linter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+)
+
+// declaration is a named node with the fully qualified name it declares and
+// the node it is declared in
+type declaration struct {
+	symbol string
+	parent protobuf.Node
+}
+
+// RenameFix builds a fix that renames decl to newName and updates every
+// reference to it in the file, such as field types, RPC inputs and outputs,
+// and enum default values. When AutoFix.ReserveRenamed is configured,
+// renaming a field or enum value also adds a reserved entry for the old name
+// so it cannot be reused with a different meaning.
+//
+// Without exact source positions, which the AST only has when it was parsed
+// from ctx.Content, the fix holds a single change at the declaration that
+// ApplyFixes will report as stale.
+func RenameFix(ctx *LintContext, decl protobuf.Node, oldName, newName, description string) *Fix {
+	fix := &Fix{Description: description}
+
+	changes, err := renameChanges(ctx, decl, oldName, newName)
+	if err != nil {
+		fix.Changes = []Change{{
+			FilePath: ctx.FilePath,
+			StartPos: decl.Position(),
+			EndPos:   decl.Position(),
+			OldText:  oldName,
+			NewText:  newName,
+		}}
+		return fix
+	}
+	fix.Changes = changes
+	return fix
+}
+
+func renameChanges(ctx *LintContext, decl protobuf.Node, oldName, newName string) ([]Change, error) {
+	if ctx == nil || ctx.AST == nil || ctx.Content == "" {
+		return nil, fmt.Errorf("no source to rename in")
+	}
+
+	namePos, nameEnd := nameSpan(decl)
+	if nameEnd.Offset <= namePos.Offset || nameEnd.Offset > len(ctx.Content) || ctx.Content[namePos.Offset:nameEnd.Offset] != oldName {
+		return nil, fmt.Errorf("no source position for %s", oldName)
+	}
+
+	decls := declarations(ctx.AST)
+	info, ok := decls[decl]
+	if !ok {
+		return nil, fmt.Errorf("%s is not declared in the file", oldName)
+	}
+
+	changes := []Change{{
+		FilePath: ctx.FilePath,
+		StartPos: namePos,
+		EndPos:   nameEnd,
+		OldText:  oldName,
+		NewText:  newName,
+	}}
+
+	// References name the renamed declaration, or something nested in it, by
+	// a suffix of its fully qualified name
+	depth := strings.Count(info.symbol, ".")
+	for _, ref := range ctx.AST.References {
+		if ref.Symbol != info.symbol && !strings.HasPrefix(ref.Symbol, info.symbol+".") {
+			continue
+		}
+		text := strings.TrimPrefix(ref.Text, ".")
+		parts := strings.Split(text, ".")
+		index := depth - (strings.Count(ref.Symbol, ".") + 1 - len(parts))
+		if index < 0 || index >= len(parts) || parts[index] != oldName {
+			continue
+		}
+		offset := ref.Pos.Offset + len(ref.Text) - len(text)
+		for _, part := range parts[:index] {
+			offset += len(part) + 1
+		}
+		changes = append(changes, Change{
+			FilePath: ctx.FilePath,
+			StartPos: protobuf.Position{Line: ref.Pos.Line, Offset: offset},
+			EndPos:   protobuf.Position{Line: ref.Pos.Line, Offset: offset + len(oldName)},
+			OldText:  oldName,
+			NewText:  newName,
+		})
+	}
+
+	if ctx.Config != nil && ctx.Config.AutoFix.ReserveRenamed {
+		if change, ok := reserveChange(ctx, decl, info.parent, oldName); ok {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// reserveChange inserts a reserved statement for a renamed field or enum
+// value before the closing brace of its message or enum
+func reserveChange(ctx *LintContext, decl, parent protobuf.Node, oldName string) (Change, bool) {
+	switch p := parent.(type) {
+	case *protobuf.MessageNode:
+		if _, ok := decl.(*protobuf.FieldNode); !ok || containsName(p.ReservedNames, oldName) {
+			return Change{}, false
+		}
+	case *protobuf.EnumNode:
+		if containsName(p.ReservedNames, oldName) {
+			return Change{}, false
+		}
+	default:
+		return Change{}, false
+	}
+
+	// Editions spell reserved names as identifiers
+	name := strconv.Quote(oldName)
+	if ctx.AST.Syntax != nil && ctx.AST.Syntax.Edition != "" {
+		name = oldName
+	}
+	return insertChange(ctx, parent, decl, "reserved "+name+";")
+}
+
+// insertChange inserts statement before the closing brace of parent. When
+// the brace is on its own line the statement gets a line of its own,
+// indented like sibling; otherwise it goes on the brace's line.
+func insertChange(ctx *LintContext, parent, sibling protobuf.Node, statement string) (Change, bool) {
+	content := ctx.Content
+	brace := parent.End().Offset - 1
+	if brace <= 0 || brace >= len(content) || content[brace] != '}' {
+		return Change{}, false
+	}
+
+	lineStart := strings.LastIndexByte(content[:brace], '\n') + 1
+	insertAt, text := brace, statement+" "
+	if strings.TrimSpace(content[lineStart:brace]) == "" {
+		indent := content[lineStart:brace] + "  "
+		if sibling != nil {
+			start := sibling.Position().Offset
+			line := strings.LastIndexByte(content[:start], '\n') + 1
+			if strings.TrimSpace(content[line:start]) == "" {
+				indent = content[line:start]
+			}
+		}
+		insertAt, text = lineStart, indent+statement+"\n"
+	}
+
+	pos := protobuf.Position{Line: parent.End().Line, Offset: insertAt}
+	return Change{
+		FilePath: ctx.FilePath,
+		StartPos: pos,
+		EndPos:   pos,
+		NewText:  text,
+	}, true
+}
+
+// InsertFix builds a fix that adds statement, such as a field declaration,
+// at the end of parent's body. It returns nil when parent has no exact
+// source position in ctx.Content.
+func InsertFix(ctx *LintContext, parent protobuf.Node, statement, description string) *Fix {
+	if ctx == nil || ctx.Content == "" {
+		return nil
+	}
+	var sibling protobuf.Node
+	switch p := parent.(type) {
+	case *protobuf.MessageNode:
+		if len(p.Fields) > 0 {
+			sibling = p.Fields[len(p.Fields)-1]
+		}
+	case *protobuf.EnumNode:
+		if len(p.Values) > 0 {
+			sibling = p.Values[len(p.Values)-1]
+		}
+	case *protobuf.ServiceNode:
+		if len(p.RPCs) > 0 {
+			sibling = p.RPCs[len(p.RPCs)-1]
+		}
+	}
+	change, ok := insertChange(ctx, parent, sibling, statement)
+	if !ok {
+		return nil
+	}
+	return &Fix{Description: description, Changes: []Change{change}}
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// nameSpan returns the span of the name of a declaration
+func nameSpan(node protobuf.Node) (protobuf.Position, protobuf.Position) {
+	switch n := node.(type) {
+	case *protobuf.MessageNode:
+		return n.NamePos, n.NameEnd
+	case *protobuf.FieldNode:
+		return n.NamePos, n.NameEnd
+	case *protobuf.EnumNode:
+		return n.NamePos, n.NameEnd
+	case *protobuf.EnumValueNode:
+		return n.NamePos, n.NameEnd
+	case *protobuf.ServiceNode:
+		return n.NamePos, n.NameEnd
+	case *protobuf.RPCNode:
+		return n.NamePos, n.NameEnd
+	case *protobuf.OneOfNode:
+		return n.NamePos, n.NameEnd
+	}
+	return protobuf.Position{}, protobuf.Position{}
+}
+
+// declarations indexes the named nodes of a file by node
+func declarations(root *protobuf.RootNode) map[protobuf.Node]declaration {
+	decls := make(map[protobuf.Node]declaration)
+	scope := ""
+	if root.Package != nil {
+		scope = root.Package.Name
+	}
+
+	var addEnum func(enum *protobuf.EnumNode, scope string, parent protobuf.Node)
+	addEnum = func(enum *protobuf.EnumNode, scope string, parent protobuf.Node) {
+		decls[enum] = declaration{symbol: qualify(scope, enum.Name), parent: parent}
+		// Enum values are scoped as siblings of their enum
+		for _, value := range enum.Values {
+			decls[value] = declaration{symbol: qualify(scope, value.Name), parent: enum}
+		}
+	}
+
+	addFields := func(fields []*protobuf.FieldNode, scope string, parent protobuf.Node) {
+		for _, field := range fields {
+			decls[field] = declaration{symbol: qualify(scope, field.Name), parent: parent}
+		}
+	}
+
+	var addMessage func(msg *protobuf.MessageNode, scope string, parent protobuf.Node)
+	addMessage = func(msg *protobuf.MessageNode, scope string, parent protobuf.Node) {
+		symbol := qualify(scope, msg.Name)
+		decls[msg] = declaration{symbol: symbol, parent: parent}
+		addFields(msg.Fields, symbol, msg)
+		for _, oneof := range msg.OneOfs {
+			decls[oneof] = declaration{symbol: qualify(symbol, oneof.Name), parent: msg}
+		}
+		for _, nested := range msg.Nested {
+			addMessage(nested, symbol, msg)
+		}
+		for _, enum := range msg.Enums {
+			addEnum(enum, symbol, msg)
+		}
+		for _, ext := range msg.Extensions {
+			addFields(ext.Fields, symbol, ext)
+		}
+	}
+
+	for _, msg := range root.Messages {
+		addMessage(msg, scope, root)
+	}
+	for _, enum := range root.Enums {
+		addEnum(enum, scope, root)
+	}
+	for _, ext := range root.Extensions {
+		addFields(ext.Fields, scope, ext)
+	}
+	for _, svc := range root.Services {
+		symbol := qualify(scope, svc.Name)
+		decls[svc] = declaration{symbol: symbol, parent: root}
+		for _, rpc := range svc.RPCs {
+			decls[rpc] = declaration{symbol: qualify(symbol, rpc.Name), parent: svc}
+		}
+	}
+	return decls
+}
+
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
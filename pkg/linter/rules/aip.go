@@ -0,0 +1,516 @@
+package rules
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+// Well-known types of AIP-style APIs
+const (
+	emptyType     = "google.protobuf.Empty"
+	fieldMaskType = "google.protobuf.FieldMask"
+	operationType = "google.longrunning.Operation"
+	fieldMaskFile = "google/protobuf/field_mask.proto"
+)
+
+// maxFieldNumber is the largest valid field number
+const maxFieldNumber = 536870911
+
+// standardMethodVerbs are the AIP standard method verbs (AIP-131 to AIP-135)
+var standardMethodVerbs = []string{"Get", "List", "Create", "Update", "Delete"}
+
+// standardMethod is an RPC recognized as an AIP standard method by its name,
+// such as GetBook or ListBooks
+type standardMethod struct {
+	rpc      *protobuf.RPCNode
+	verb     string // Get, List, Create, Update or Delete
+	resource string // Resource name; plural for List
+	request  string // Fully qualified request type
+	response string // Fully qualified response type
+}
+
+// requestName is the name AIP gives the method's request message
+func (m standardMethod) requestName() string {
+	return m.verb + m.resource + "Request"
+}
+
+// resourceField is the request field holding the resource of a Create or
+// Update method
+func (m standardMethod) resourceField() string {
+	return toSnakeCase(m.resource)
+}
+
+// aipFile indexes what the AIP rules look up in a file: its messages by
+// fully qualified name, its standard methods and how often each message is
+// used as a request or response
+type aipFile struct {
+	scope    string
+	messages map[string]*protobuf.MessageNode
+	methods  []standardMethod
+	uses     map[string]int
+}
+
+func newAIPFile(node *protobuf.RootNode) *aipFile {
+	f := &aipFile{
+		scope:    packageName(node),
+		messages: make(map[string]*protobuf.MessageNode),
+		uses:     make(map[string]int),
+	}
+	forEachMessage(node, func(msg *protobuf.MessageNode, fullName string) {
+		f.messages[fullName] = msg
+	})
+	for _, svc := range node.Services {
+		for _, rpc := range svc.RPCs {
+			request := canonicalType(f.scope, rpc.InputType)
+			response := canonicalType(f.scope, rpc.OutputType)
+			f.uses[request]++
+			f.uses[response]++
+			if m, ok := parseStandardMethod(rpc); ok {
+				m.request, m.response = request, response
+				f.methods = append(f.methods, m)
+			}
+		}
+	}
+	return f
+}
+
+// parseStandardMethod recognizes a standard method by its RPC name. Streaming
+// RPCs are never standard methods.
+func parseStandardMethod(rpc *protobuf.RPCNode) (standardMethod, bool) {
+	if rpc.ClientStreaming || rpc.ServerStreaming {
+		return standardMethod{}, false
+	}
+	for _, verb := range standardMethodVerbs {
+		resource := strings.TrimPrefix(rpc.Name, verb)
+		if resource == rpc.Name || resource == "" || !isPascalCase(resource) {
+			continue
+		}
+		return standardMethod{rpc: rpc, verb: verb, resource: resource}, true
+	}
+	return standardMethod{}, false
+}
+
+// message returns the message declared in the file with the given fully
+// qualified name, or nil when it is declared elsewhere
+func (f *aipFile) message(fullName string) *protobuf.MessageNode {
+	return f.messages[fullName]
+}
+
+// resourceMessage returns the message of the file named after a resource
+func (f *aipFile) resourceMessage(resource string) *protobuf.MessageNode {
+	return f.messages[qualify(f.scope, resource)]
+}
+
+// renameMessageFix renames a request or response message when that is
+// mechanical: the message is declared in the file, used by one RPC only,
+// and the new name is free
+func (f *aipFile) renameMessageFix(ctx *linter.LintContext, fullName, newName string) *linter.Fix {
+	msg := f.message(fullName)
+	if msg == nil || f.uses[fullName] != 1 {
+		return nil
+	}
+	scope := strings.TrimSuffix(fullName, msg.Name)
+	if _, taken := f.messages[scope+newName]; taken {
+		return nil
+	}
+	return linter.RenameFix(ctx, msg, msg.Name, newName, "Rename to "+newName)
+}
+
+// shortName returns the last component of a fully qualified name
+func shortName(fullName string) string {
+	return fullName[strings.LastIndex(fullName, ".")+1:]
+}
+
+func fieldByName(msg *protobuf.MessageNode, name string) *protobuf.FieldNode {
+	for _, field := range msg.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// nextFieldNumber returns the lowest field number above every field of msg
+// that is neither reserved nor an extension number, or 0 when there is none
+func nextFieldNumber(msg *protobuf.MessageNode) int {
+	n := 1
+	for _, field := range msg.Fields {
+		if field.Number >= n {
+			n = field.Number + 1
+		}
+	}
+	for moved := true; moved; {
+		moved = false
+		for _, ranges := range [][]protobuf.Range{msg.ReservedRanges, msg.ExtensionRanges} {
+			for _, r := range ranges {
+				if r.Contains(n) {
+					n, moved = r.End+1, true
+				}
+			}
+		}
+	}
+	if n > maxFieldNumber {
+		return 0
+	}
+	return n
+}
+
+// addFieldFix builds a fix adding a field to msg with the next free number
+func addFieldFix(ctx *linter.LintContext, msg *protobuf.MessageNode, typeName, name string) *linter.Fix {
+	number := nextFieldNumber(msg)
+	if number == 0 {
+		return nil
+	}
+	return linter.InsertFix(ctx, msg, typeName+" "+name+" = "+strconv.Itoa(number)+";", "Add field "+name)
+}
+
+// typeIs reports whether a field type names the fully qualified type want
+func typeIs(fieldType, want string) bool {
+	return strings.TrimPrefix(fieldType, ".") == want
+}
+
+// AIPStandardMethodRequestRule checks the request messages of standard
+// methods: they are named after the method and carry the fields AIP-131 to
+// AIP-135 require
+type AIPStandardMethodRequestRule struct {
+	BaseRule
+}
+
+// NewAIPStandardMethodRequestRule creates a new standard method request rule
+func NewAIPStandardMethodRequestRule() *AIPStandardMethodRequestRule {
+	return &AIPStandardMethodRequestRule{
+		BaseRule: BaseRule{
+			RuleName:        "aip-standard-method-request",
+			RuleCategory:    linter.CategoryAIP,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Standard method requests must be named VerbResourceRequest and have the fields of AIP-131 to AIP-135",
+			AutoFixable:     true,
+		},
+	}
+}
+
+// Check validates standard method requests
+func (r *AIPStandardMethodRequestRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+	f := newAIPFile(node)
+
+	for _, m := range f.methods {
+		if want := m.requestName(); shortName(m.request) != want {
+			violations = append(violations, linter.Violation{
+				Rule:         r.Name(),
+				Severity:     r.Severity(),
+				Category:     r.Category(),
+				Message:      m.verb + " method '" + m.rpc.Name + "' request '" + shortName(m.request) + "' should be named '" + want + "'",
+				Position:     m.rpc.Position(),
+				SuggestedFix: f.renameMessageFix(ctx, m.request, want),
+			})
+		}
+
+		request := f.message(m.request)
+		if request == nil {
+			continue
+		}
+		switch m.verb {
+		case "Get", "Delete":
+			violations = append(violations, r.checkField(ctx, m, request, "name", "string", "string")...)
+		case "Create", "Update":
+			typeName := ""
+			if res := f.resourceMessage(m.resource); res != nil {
+				typeName = m.resource
+			}
+			violations = append(violations, r.checkField(ctx, m, request, m.resourceField(), qualify(f.scope, m.resource), typeName)...)
+		}
+	}
+	return violations
+}
+
+// checkField requires request to have a field name of type want. typeName
+// is how a fix spells the type; without one, a missing field is not fixed.
+func (r *AIPStandardMethodRequestRule) checkField(ctx *linter.LintContext, m standardMethod, request *protobuf.MessageNode, name, want, typeName string) []linter.Violation {
+	field := fieldByName(request, name)
+	if field != nil && !field.Repeated && typeIs(field.Type, want) {
+		return nil
+	}
+	v := linter.Violation{
+		Rule:     r.Name(),
+		Severity: r.Severity(),
+		Category: r.Category(),
+		Position: request.Position(),
+	}
+	if field == nil {
+		v.Message = m.verb + " request '" + request.Name + "' must have a field '" + name + "' of type " + shortName(want)
+		if typeName != "" {
+			v.SuggestedFix = addFieldFix(ctx, request, typeName, name)
+		}
+	} else {
+		v.Message = "Field '" + name + "' of " + m.verb + " request '" + request.Name + "' must be a singular " + shortName(want)
+		v.Position = field.Position()
+	}
+	return []linter.Violation{v}
+}
+
+// AutoFix renames the request or adds the missing field
+func (r *AIPStandardMethodRequestRule) AutoFix(node *protobuf.RootNode, violation linter.Violation) (*linter.Fix, error) {
+	return violation.SuggestedFix, nil
+}
+
+// AIPStandardMethodResponseRule checks the responses of standard methods:
+// Get, Create and Update return the resource, Delete returns Empty or the
+// resource, and List returns a ListResourcesResponse holding the resources
+type AIPStandardMethodResponseRule struct {
+	BaseRule
+}
+
+// NewAIPStandardMethodResponseRule creates a new standard method response
+// rule
+func NewAIPStandardMethodResponseRule() *AIPStandardMethodResponseRule {
+	return &AIPStandardMethodResponseRule{
+		BaseRule: BaseRule{
+			RuleName:        "aip-standard-method-response",
+			RuleCategory:    linter.CategoryAIP,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Standard methods must return the resource, Empty, or a ListResourcesResponse as AIP-131 to AIP-135 require",
+			AutoFixable:     true,
+		},
+	}
+}
+
+// Check validates standard method responses
+func (r *AIPStandardMethodResponseRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+	f := newAIPFile(node)
+	report := func(m standardMethod, message string, pos protobuf.Position, fix *linter.Fix) {
+		violations = append(violations, linter.Violation{
+			Rule:         r.Name(),
+			Severity:     r.Severity(),
+			Category:     r.Category(),
+			Message:      message,
+			Position:     pos,
+			SuggestedFix: fix,
+		})
+	}
+
+	for _, m := range f.methods {
+		got := shortName(m.response)
+		switch m.verb {
+		case "Get", "Create", "Update":
+			if got == m.resource || (m.verb != "Get" && m.response == operationType) {
+				continue
+			}
+			report(m, m.verb+" method '"+m.rpc.Name+"' should return the resource '"+m.resource+"', not '"+got+"'", m.rpc.Position(), nil)
+		case "Delete":
+			if m.response == emptyType || m.response == operationType || got == m.resource {
+				continue
+			}
+			report(m, "Delete method '"+m.rpc.Name+"' should return google.protobuf.Empty or the resource '"+m.resource+"', not '"+got+"'", m.rpc.Position(), nil)
+		case "List":
+			want := "List" + m.resource + "Response"
+			if got != want {
+				report(m, "List method '"+m.rpc.Name+"' response '"+got+"' should be named '"+want+"'", m.rpc.Position(), f.renameMessageFix(ctx, m.response, want))
+			}
+			if response := f.message(m.response); response != nil {
+				r.checkListField(ctx, m, response, report)
+			}
+		}
+	}
+	return violations
+}
+
+// checkListField requires a List response to hold the resources in a
+// repeated field named after their plural
+func (r *AIPStandardMethodResponseRule) checkListField(ctx *linter.LintContext, m standardMethod, response *protobuf.MessageNode, report func(standardMethod, string, protobuf.Position, *linter.Fix)) {
+	want := toSnakeCase(m.resource)
+	var repeated []*protobuf.FieldNode
+	for _, field := range response.Fields {
+		if !field.Repeated {
+			continue
+		}
+		if field.Name == want {
+			return
+		}
+		repeated = append(repeated, field)
+	}
+
+	message := "List response '" + response.Name + "' should hold the resources in a repeated field '" + want + "'"
+	if len(repeated) != 1 {
+		report(m, message, response.Position(), nil)
+		return
+	}
+	// A single repeated field under another name is the resource list
+	field := repeated[0]
+	var fix *linter.Fix
+	if fieldByName(response, want) == nil {
+		fix = linter.RenameFix(ctx, field, field.Name, want, "Rename to "+want)
+	}
+	report(m, message, field.Position(), fix)
+}
+
+// AutoFix renames the response or its resource field
+func (r *AIPStandardMethodResponseRule) AutoFix(node *protobuf.RootNode, violation linter.Violation) (*linter.Fix, error) {
+	return violation.SuggestedFix, nil
+}
+
+// AIPPaginationRule checks that List methods, and any RPC that takes a page
+// token, paginate with page_size, page_token and next_page_token (AIP-158)
+type AIPPaginationRule struct {
+	BaseRule
+}
+
+// NewAIPPaginationRule creates a new pagination rule
+func NewAIPPaginationRule() *AIPPaginationRule {
+	return &AIPPaginationRule{
+		BaseRule: BaseRule{
+			RuleName:        "aip-pagination",
+			RuleCategory:    linter.CategoryAIP,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Paginated methods must use int32 page_size, string page_token and string next_page_token (AIP-158)",
+			AutoFixable:     true,
+		},
+	}
+}
+
+// paginationField is a field pagination requires of a request or response
+type paginationField struct {
+	name     string
+	typeName string
+	response bool
+}
+
+var paginationFields = []paginationField{
+	{name: "page_size", typeName: "int32"},
+	{name: "page_token", typeName: "string"},
+	{name: "next_page_token", typeName: "string", response: true},
+}
+
+// Check validates pagination fields
+func (r *AIPPaginationRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+	f := newAIPFile(node)
+
+	for _, svc := range node.Services {
+		for _, rpc := range svc.RPCs {
+			request := f.message(canonicalType(f.scope, rpc.InputType))
+			response := f.message(canonicalType(f.scope, rpc.OutputType))
+			m, standard := parseStandardMethod(rpc)
+			paginated := standard && m.verb == "List"
+			if !paginated && (request == nil || fieldByName(request, "page_token") == nil) {
+				continue
+			}
+
+			for _, pf := range paginationFields {
+				msg := request
+				if pf.response {
+					msg = response
+				}
+				if msg == nil {
+					continue
+				}
+				field := fieldByName(msg, pf.name)
+				switch {
+				case field == nil:
+					violations = append(violations, linter.Violation{
+						Rule:         r.Name(),
+						Severity:     r.Severity(),
+						Category:     r.Category(),
+						Message:      "Paginated RPC '" + rpc.Name + "' needs field '" + pf.typeName + " " + pf.name + "' in '" + msg.Name + "'",
+						Position:     msg.Position(),
+						SuggestedFix: addFieldFix(ctx, msg, pf.typeName, pf.name),
+					})
+				case field.Type != pf.typeName || field.Repeated:
+					violations = append(violations, linter.Violation{
+						Rule:     r.Name(),
+						Severity: r.Severity(),
+						Category: r.Category(),
+						Message:  "Pagination field '" + pf.name + "' of '" + msg.Name + "' must be a singular " + pf.typeName,
+						Position: field.Position(),
+					})
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// AutoFix adds the missing pagination field
+func (r *AIPPaginationRule) AutoFix(node *protobuf.RootNode, violation linter.Violation) (*linter.Fix, error) {
+	return violation.SuggestedFix, nil
+}
+
+// AIPUpdateFieldMaskRule checks that Update requests take the fields to
+// update as a google.protobuf.FieldMask named update_mask (AIP-134)
+type AIPUpdateFieldMaskRule struct {
+	BaseRule
+}
+
+// NewAIPUpdateFieldMaskRule creates a new update field mask rule
+func NewAIPUpdateFieldMaskRule() *AIPUpdateFieldMaskRule {
+	return &AIPUpdateFieldMaskRule{
+		BaseRule: BaseRule{
+			RuleName:        "aip-update-field-mask",
+			RuleCategory:    linter.CategoryAIP,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Update requests must have a google.protobuf.FieldMask update_mask (AIP-134)",
+			AutoFixable:     true,
+		},
+	}
+}
+
+// Check validates update masks
+func (r *AIPUpdateFieldMaskRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+	f := newAIPFile(node)
+	importsFieldMask := false
+	for _, imp := range node.Imports {
+		importsFieldMask = importsFieldMask || imp.Path == fieldMaskFile
+	}
+
+	for _, m := range f.methods {
+		request := f.message(m.request)
+		if m.verb != "Update" || request == nil {
+			continue
+		}
+		v := linter.Violation{
+			Rule:     r.Name(),
+			Severity: r.Severity(),
+			Category: r.Category(),
+		}
+
+		if field := fieldByName(request, "update_mask"); field != nil {
+			if typeIs(field.Type, fieldMaskType) && !field.Repeated {
+				continue
+			}
+			v.Message = "Field 'update_mask' of '" + request.Name + "' must be a singular " + fieldMaskType
+			v.Position = field.Position()
+			violations = append(violations, v)
+			continue
+		}
+
+		v.Message = "Update request '" + request.Name + "' must have a field '" + fieldMaskType + " update_mask'"
+		v.Position = request.Position()
+		var masks []*protobuf.FieldNode
+		for _, field := range request.Fields {
+			if typeIs(field.Type, fieldMaskType) && !field.Repeated {
+				masks = append(masks, field)
+			}
+		}
+		switch {
+		case len(masks) == 1:
+			// The mask exists under another name
+			v.Message = "Field mask '" + masks[0].Name + "' of '" + request.Name + "' should be named 'update_mask'"
+			v.Position = masks[0].Position()
+			v.SuggestedFix = linter.RenameFix(ctx, masks[0], masks[0].Name, "update_mask", "Rename to update_mask")
+		case len(masks) == 0 && importsFieldMask:
+			v.SuggestedFix = addFieldFix(ctx, request, fieldMaskType, "update_mask")
+		}
+		violations = append(violations, v)
+	}
+	return violations
+}
+
+// AutoFix adds or renames the update mask
+func (r *AIPUpdateFieldMaskRule) AutoFix(node *protobuf.RootNode, violation linter.Violation) (*linter.Fix, error) {
+	return violation.SuggestedFix, nil
+}
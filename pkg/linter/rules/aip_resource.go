@@ -0,0 +1,345 @@
+package rules
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+const (
+	resourceOption = "(google.api.resource)"
+	httpOption     = "(google.api.http)"
+)
+
+var (
+	// resourceTypePattern matches resource types such as
+	// library.googleapis.com/Book (AIP-123)
+	resourceTypePattern = regexp.MustCompile(`^[a-z0-9-]+(\.[a-z0-9-]+)+/([A-Z][a-zA-Z0-9]*)$`)
+	collectionIDPattern = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+	patternVarPattern   = regexp.MustCompile(`^\{([a-z][a-z0-9_]*)\}$`)
+
+	// httpVarPattern matches path template variables such as {name=shelves/*}
+	httpVarPattern = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+	httpVerbs      = []string{"get", "put", "post", "delete", "patch", "custom"}
+)
+
+// optionValue returns the typed value of a custom option, or nil
+func optionValue(options []*protobuf.OptionNode, name string) map[string]interface{} {
+	for _, opt := range options {
+		if opt.Custom && opt.Name == name {
+			if value, ok := opt.TypedValue.(map[string]interface{}); ok {
+				return value
+			}
+		}
+	}
+	return nil
+}
+
+// stringList returns a repeated string option field as a slice
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// AIPResourcePatternRule checks google.api.resource annotations: the type is
+// service/Kind after the message, and every pattern alternates collection
+// IDs and snake_case variables (AIP-122, AIP-123)
+type AIPResourcePatternRule struct {
+	BaseRule
+}
+
+// NewAIPResourcePatternRule creates a new resource pattern rule
+func NewAIPResourcePatternRule() *AIPResourcePatternRule {
+	return &AIPResourcePatternRule{
+		BaseRule: BaseRule{
+			RuleName:        "aip-resource-pattern",
+			RuleCategory:    linter.CategoryAIP,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Resources must have a valid google.api.resource type, name patterns and name field (AIP-122, AIP-123)",
+			AutoFixable:     true,
+		},
+	}
+}
+
+// Check validates resource annotations
+func (r *AIPResourcePatternRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+
+	forEachMessage(node, func(msg *protobuf.MessageNode, fullName string) {
+		resource := optionValue(msg.Options, resourceOption)
+		if resource == nil {
+			return
+		}
+		report := func(message string, pos protobuf.Position, fix *linter.Fix) {
+			violations = append(violations, linter.Violation{
+				Rule:         r.Name(),
+				Severity:     r.Severity(),
+				Category:     r.Category(),
+				Message:      message,
+				Position:     pos,
+				SuggestedFix: fix,
+			})
+		}
+
+		resourceType, _ := resource["type"].(string)
+		if match := resourceTypePattern.FindStringSubmatch(resourceType); match == nil {
+			report("Resource type '"+resourceType+"' of '"+msg.Name+"' should be {Service Name}/{Kind}, such as library.googleapis.com/"+msg.Name, msg.Position(), nil)
+		} else if match[2] != msg.Name {
+			report("Resource type '"+resourceType+"' should name its message: kind '"+match[2]+"' differs from '"+msg.Name+"'", msg.Position(), nil)
+		}
+
+		patterns := stringList(resource["pattern"])
+		if len(patterns) == 0 {
+			report("Resource '"+msg.Name+"' must declare at least one pattern", msg.Position(), nil)
+		}
+		for _, pattern := range patterns {
+			if problem := checkResourcePattern(pattern); problem != "" {
+				report("Resource pattern '"+pattern+"' of '"+msg.Name+"' "+problem, msg.Position(), nil)
+			}
+		}
+
+		nameField, _ := resource["name_field"].(string)
+		if nameField == "" {
+			nameField = "name"
+		}
+		field := fieldByName(msg, nameField)
+		switch {
+		case field == nil:
+			var fix *linter.Fix
+			if nameField == "name" {
+				fix = addFieldFix(ctx, msg, "string", "name")
+			}
+			report("Resource '"+msg.Name+"' must have a string field '"+nameField+"' holding its resource name", msg.Position(), fix)
+		case field.Type != "string" || field.Repeated:
+			report("Resource name field '"+nameField+"' of '"+msg.Name+"' must be a singular string", field.Position(), nil)
+		}
+	})
+	return violations
+}
+
+// checkResourcePattern describes what is wrong with a resource name pattern,
+// or returns "" when it is valid. Patterns alternate collection IDs and
+// variables, such as publishers/{publisher}/books/{book}; a singleton may
+// end in a collection ID, such as users/{user}/config.
+func checkResourcePattern(pattern string) string {
+	if pattern == "" || strings.HasPrefix(pattern, "/") || strings.HasSuffix(pattern, "/") {
+		return "must not be empty or start or end with '/'"
+	}
+	seen := make(map[string]bool)
+	for i, segment := range strings.Split(pattern, "/") {
+		if i%2 == 0 {
+			if strings.HasPrefix(segment, "{") {
+				return "must alternate collection IDs and variables; '" + segment + "' is not after a collection ID"
+			}
+			if !collectionIDPattern.MatchString(segment) {
+				return "has collection ID '" + segment + "' that is not lowerCamelCase"
+			}
+			continue
+		}
+		match := patternVarPattern.FindStringSubmatch(segment)
+		if match == nil {
+			if !strings.HasPrefix(segment, "{") {
+				return "must alternate collection IDs and variables; '" + segment + "' is not after a variable"
+			}
+			return "has variable '" + segment + "' that is not snake_case"
+		}
+		if seen[match[1]] {
+			return "repeats variable '" + match[1] + "'"
+		}
+		seen[match[1]] = true
+	}
+	return ""
+}
+
+// AutoFix adds the missing name field
+func (r *AIPResourcePatternRule) AutoFix(node *protobuf.RootNode, violation linter.Violation) (*linter.Fix, error) {
+	return violation.SuggestedFix, nil
+}
+
+// AIPHTTPBindingRule checks google.api.http annotations: paths bind request
+// fields that exist, and standard methods use the HTTP verb and body
+// AIP-127 and AIP-131 to AIP-135 give them
+type AIPHTTPBindingRule struct {
+	BaseRule
+}
+
+// NewAIPHTTPBindingRule creates a new HTTP binding rule
+func NewAIPHTTPBindingRule() *AIPHTTPBindingRule {
+	return &AIPHTTPBindingRule{
+		BaseRule: BaseRule{
+			RuleName:        "aip-http-binding",
+			RuleCategory:    linter.CategoryAIP,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "HTTP bindings must reference request fields and use the verb and body of their standard method (AIP-127)",
+			AutoFixable:     true,
+		},
+	}
+}
+
+// standardHTTP is the HTTP verb and body of each standard method. A body of
+// "resource" is the request field holding the resource.
+var standardHTTP = map[string]struct{ verb, body string }{
+	"Get":    {verb: "get"},
+	"List":   {verb: "get"},
+	"Create": {verb: "post", body: "resource"},
+	"Update": {verb: "patch", body: "resource"},
+	"Delete": {verb: "delete"},
+}
+
+// Check validates HTTP bindings
+func (r *AIPHTTPBindingRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+	f := newAIPFile(node)
+
+	for _, svc := range node.Services {
+		for _, rpc := range svc.RPCs {
+			binding := optionValue(rpc.Options, httpOption)
+			if binding == nil {
+				continue
+			}
+			report := func(message string, fix *linter.Fix) {
+				violations = append(violations, linter.Violation{
+					Rule:         r.Name(),
+					Severity:     r.Severity(),
+					Category:     r.Category(),
+					Message:      message,
+					Position:     rpc.Position(),
+					SuggestedFix: fix,
+				})
+			}
+
+			verb, path := "", ""
+			for _, v := range httpVerbs {
+				if value, ok := binding[v]; ok {
+					verb = v
+					path, _ = value.(string)
+					if custom, ok := value.(map[string]interface{}); ok {
+						path, _ = custom["path"].(string)
+					}
+					break
+				}
+			}
+			if verb == "" {
+				report("HTTP binding of RPC '"+rpc.Name+"' has no verb and path", nil)
+				continue
+			}
+
+			request := f.message(canonicalType(f.scope, rpc.InputType))
+			if !strings.HasPrefix(path, "/") {
+				report("HTTP path '"+path+"' of RPC '"+rpc.Name+"' must start with '/'", nil)
+			}
+			for _, match := range httpVarPattern.FindAllStringSubmatch(path, -1) {
+				if request != nil && !f.hasFieldPath(request, match[1]) {
+					report("HTTP path '"+path+"' of RPC '"+rpc.Name+"' binds '"+match[1]+"', which is not a field of '"+request.Name+"'", nil)
+				}
+			}
+
+			m, ok := parseStandardMethod(rpc)
+			if !ok {
+				continue
+			}
+			want := standardHTTP[m.verb]
+			if verb != want.verb {
+				var fix *linter.Fix
+				if verb != "custom" {
+					fix = replaceInOption(ctx, rpc, `\b`+verb+`(\s*:)`, want.verb+"$1", "Use HTTP "+strings.ToUpper(want.verb))
+				}
+				report(m.verb+" method '"+rpc.Name+"' should use HTTP "+strings.ToUpper(want.verb)+", not "+strings.ToUpper(verb), fix)
+			}
+			body, _ := binding["body"].(string)
+			wantBody := want.body
+			if wantBody == "resource" {
+				wantBody = m.resourceField()
+			}
+			switch {
+			case wantBody == "" && body != "":
+				report(m.verb+" method '"+rpc.Name+"' must not have an HTTP body", nil)
+			case wantBody != "" && body != wantBody:
+				var fix *linter.Fix
+				if body != "" {
+					fix = replaceInOption(ctx, rpc, `(\bbody\s*:\s*)"[^"]*"`, `${1}"`+wantBody+`"`, "Use body "+wantBody)
+				}
+				report(m.verb+" method '"+rpc.Name+"' should use the resource field '"+wantBody+"' as HTTP body, not '"+body+"'", fix)
+			}
+		}
+	}
+	return violations
+}
+
+// hasFieldPath reports whether a dotted field path, such as book.name,
+// names fields of msg. Paths through messages declared in other files are
+// assumed to exist.
+func (f *aipFile) hasFieldPath(msg *protobuf.MessageNode, path string) bool {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		field := fieldByName(msg, part)
+		if field == nil {
+			return false
+		}
+		if i == len(parts)-1 {
+			return true
+		}
+		next := f.message(canonicalType(f.scope, field.Type))
+		if next == nil {
+			return true
+		}
+		msg = next
+	}
+	return true
+}
+
+// replaceInOption builds a fix replacing the single match of expr in the
+// google.api.http option of rpc. It returns nil when the option source
+// cannot be found or the match is ambiguous, such as with
+// additional_bindings.
+func replaceInOption(ctx *linter.LintContext, rpc *protobuf.RPCNode, expr, replacement, description string) *linter.Fix {
+	if ctx == nil || ctx.Content == "" {
+		return nil
+	}
+	start, end := rpc.Position().Offset, rpc.End().Offset
+	if start < 0 || end <= start || end > len(ctx.Content) {
+		return nil
+	}
+	text := ctx.Content[start:end]
+	option := strings.Index(text, httpOption)
+	if option < 0 || strings.Contains(text, "additional_bindings") {
+		return nil
+	}
+	re := regexp.MustCompile(expr)
+	matches := re.FindAllStringSubmatchIndex(text[option:], -1)
+	if len(matches) != 1 {
+		return nil
+	}
+	from, to := start+option+matches[0][0], start+option+matches[0][1]
+	oldText := ctx.Content[from:to]
+	newText := string(re.ExpandString(nil, replacement, text[option:], matches[0]))
+	line := strings.Count(ctx.Content[:from], "\n") + 1
+	return &linter.Fix{
+		Description: description,
+		Changes: []linter.Change{{
+			FilePath: ctx.FilePath,
+			StartPos: protobuf.Position{Line: line, Offset: from},
+			EndPos:   protobuf.Position{Line: line + strings.Count(oldText, "\n"), Offset: to},
+			OldText:  oldText,
+			NewText:  newText,
+		}},
+	}
+}
+
+// AutoFix corrects the HTTP verb or body
+func (r *AIPHTTPBindingRule) AutoFix(node *protobuf.RootNode, violation linter.Violation) (*linter.Fix, error) {
+	return violation.SuggestedFix, nil
+}
@@ -0,0 +1,366 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+const aipLibraryProto = `syntax = "proto3";
+package library.v1;
+
+import "google/api/annotations.proto";
+import "google/api/resource.proto";
+import "google/protobuf/empty.proto";
+import "google/protobuf/field_mask.proto";
+
+message Book {
+  option (google.api.resource) = {
+    type: "library.googleapis.com/Book"
+    pattern: "shelves/{shelf}/books/{book}"
+  };
+  string name = 1;
+  string title = 2;
+}
+
+message GetBookRequest {
+  string name = 1;
+}
+
+message ListBooksRequest {
+  string parent = 1;
+  int32 page_size = 2;
+  string page_token = 3;
+}
+
+message ListBooksResponse {
+  repeated Book books = 1;
+  string next_page_token = 2;
+}
+
+message CreateBookRequest {
+  string parent = 1;
+  Book book = 2;
+}
+
+message UpdateBookRequest {
+  Book book = 1;
+  google.protobuf.FieldMask update_mask = 2;
+}
+
+message DeleteBookRequest {
+  string name = 1;
+}
+
+service LibraryService {
+  rpc GetBook(GetBookRequest) returns (Book) {
+    option (google.api.http) = { get: "/v1/{name=shelves/*/books/*}" };
+  }
+  rpc ListBooks(ListBooksRequest) returns (ListBooksResponse) {
+    option (google.api.http) = { get: "/v1/{parent=shelves/*}/books" };
+  }
+  rpc CreateBook(CreateBookRequest) returns (Book) {
+    option (google.api.http) = { post: "/v1/{parent=shelves/*}/books" body: "book" };
+  }
+  rpc UpdateBook(UpdateBookRequest) returns (Book) {
+    option (google.api.http) = { patch: "/v1/{book.name=shelves/*/books/*}" body: "book" };
+  }
+  rpc DeleteBook(DeleteBookRequest) returns (google.protobuf.Empty) {
+    option (google.api.http) = { delete: "/v1/{name=shelves/*/books/*}" };
+  }
+}
+`
+
+const aipViolationsProto = `syntax = "proto3";
+package library.v1;
+
+import "google/api/annotations.proto";
+import "google/api/resource.proto";
+import "google/protobuf/field_mask.proto";
+
+message Book {
+  option (google.api.resource) = {
+    type: "library/book"
+    pattern: "shelves/{shelf}/{book}"
+    pattern: "shelves/{Shelf}"
+  };
+  string title = 1;
+}
+
+message FetchBookRequest {
+  string id = 1;
+}
+
+message ListBooksRequest {
+  string parent = 1;
+  string page_size = 2;
+}
+
+message BooksPage {
+  repeated Book items = 1;
+}
+
+message UpdateBookRequest {
+  Book book = 1;
+  google.protobuf.FieldMask mask = 2;
+}
+
+message DeleteBookRequest {
+  string name = 1;
+}
+
+service LibraryService {
+  rpc GetBook(FetchBookRequest) returns (Book) {
+    option (google.api.http) = { post: "/v1/{name=shelves/*/books/*}" body: "*" };
+  }
+  rpc ListBooks(ListBooksRequest) returns (BooksPage) {
+    option (google.api.http) = { get: "/v1/{parent=shelves/*}/books" };
+  }
+  rpc UpdateBook(UpdateBookRequest) returns (Book) {
+    option (google.api.http) = { patch: "/v1/{book.id=shelves/*/books/*}" body: "*" };
+  }
+  rpc DeleteBook(DeleteBookRequest) returns (DeleteBookRequest) {
+    option (google.api.http) = { delete: "/v1/{name=shelves/*/books/*}" };
+  }
+}
+`
+
+func TestAIPRules_ConformingAPI(t *testing.T) {
+	for _, name := range []string{
+		"aip-standard-method-request",
+		"aip-standard-method-response",
+		"aip-pagination",
+		"aip-update-field-mask",
+		"aip-resource-pattern",
+		"aip-http-binding",
+	} {
+		violations := checkSource(t, findRule(t, name), "library/v1/library.proto", aipLibraryProto)
+		if len(violations) != 0 {
+			t.Errorf("%s: expected no violations, got %v", name, messages(violations))
+		}
+	}
+}
+
+func TestAIPStandardMethodRequestRule(t *testing.T) {
+	violations := checkSource(t, NewAIPStandardMethodRequestRule(), "library/v1/library.proto", aipViolationsProto)
+	if len(violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %d: %v", len(violations), messages(violations))
+	}
+	if !strings.Contains(violations[0].Message, "should be named 'GetBookRequest'") {
+		t.Errorf("Unexpected violation: %s", violations[0].Message)
+	}
+	fix := violations[0].SuggestedFix
+	if fix == nil || len(fix.Changes) != 2 || fix.Changes[0].NewText != "GetBookRequest" {
+		t.Errorf("Expected a rename of the message and its reference, got %+v", fix)
+	}
+	if !strings.Contains(violations[1].Message, "must have a field 'name' of type string") {
+		t.Errorf("Unexpected violation: %s", violations[1].Message)
+	}
+	if fix := violations[1].SuggestedFix; fix == nil || fix.Changes[0].NewText != "  string name = 2;\n" {
+		t.Errorf("Expected a fix adding the name field, got %+v", fix)
+	}
+}
+
+func TestAIPStandardMethodResponseRule(t *testing.T) {
+	violations := checkSource(t, NewAIPStandardMethodResponseRule(), "library/v1/library.proto", aipViolationsProto)
+	got := messages(violations)
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 violations, got %d: %v", len(got), got)
+	}
+	for i, want := range []string{
+		"response 'BooksPage' should be named 'ListBooksResponse'",
+		"repeated field 'books'",
+		"Delete method 'DeleteBook' should return google.protobuf.Empty",
+	} {
+		if !strings.Contains(got[i], want) {
+			t.Errorf("Violation %d = %q, want it to contain %q", i, got[i], want)
+		}
+	}
+	if fix := violations[1].SuggestedFix; fix == nil || fix.Changes[0].OldText != "items" || fix.Changes[0].NewText != "books" {
+		t.Errorf("Expected a fix renaming items to books, got %+v", fix)
+	}
+}
+
+func TestAIPPaginationRule(t *testing.T) {
+	violations := checkSource(t, NewAIPPaginationRule(), "library/v1/library.proto", aipViolationsProto)
+	got := messages(violations)
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 violations, got %d: %v", len(got), got)
+	}
+	if !strings.Contains(got[0], "'page_size' of 'ListBooksRequest' must be a singular int32") || violations[0].SuggestedFix != nil {
+		t.Errorf("Unexpected violation: %s", got[0])
+	}
+	if fix := violations[1].SuggestedFix; fix == nil || fix.Changes[0].NewText != "  string page_token = 3;\n" {
+		t.Errorf("Expected a fix adding page_token, got %+v", fix)
+	}
+	if fix := violations[2].SuggestedFix; fix == nil || fix.Changes[0].NewText != "  string next_page_token = 2;\n" {
+		t.Errorf("Expected a fix adding next_page_token, got %+v", fix)
+	}
+}
+
+func TestAIPPaginationRule_PageTokenOptsIn(t *testing.T) {
+	content := `syntax = "proto3";
+package acme.v1;
+
+message SearchRequest { string page_token = 1; }
+message SearchResponse {}
+
+service SearchService {
+  rpc Search(SearchRequest) returns (SearchResponse);
+}
+`
+	violations := checkSource(t, NewAIPPaginationRule(), "acme/v1/search.proto", content)
+	if len(violations) != 2 {
+		t.Fatalf("Expected page_size and next_page_token violations, got %v", messages(violations))
+	}
+	// The brace shares a line with the fields: insert before it
+	if fix := violations[0].SuggestedFix; fix == nil || fix.Changes[0].NewText != "int32 page_size = 2; " {
+		t.Errorf("Unexpected fix: %+v", fix)
+	}
+}
+
+func TestAIPUpdateFieldMaskRule(t *testing.T) {
+	violations := checkSource(t, NewAIPUpdateFieldMaskRule(), "library/v1/library.proto", aipViolationsProto)
+	if len(violations) != 1 {
+		t.Fatalf("Expected 1 violation, got %d: %v", len(violations), messages(violations))
+	}
+	if !strings.Contains(violations[0].Message, "'mask' of 'UpdateBookRequest' should be named 'update_mask'") {
+		t.Errorf("Unexpected violation: %s", violations[0].Message)
+	}
+	if fix := violations[0].SuggestedFix; fix == nil || fix.Changes[0].NewText != "update_mask" {
+		t.Errorf("Expected a rename to update_mask, got %+v", fix)
+	}
+
+	missing := strings.Replace(aipViolationsProto, "  google.protobuf.FieldMask mask = 2;\n", "", 1)
+	violations = checkSource(t, NewAIPUpdateFieldMaskRule(), "library/v1/library.proto", missing)
+	if len(violations) != 1 || violations[0].SuggestedFix == nil {
+		t.Fatalf("Expected 1 fixable violation, got %v", messages(violations))
+	}
+	if got := violations[0].SuggestedFix.Changes[0].NewText; got != "  google.protobuf.FieldMask update_mask = 2;\n" {
+		t.Errorf("Unexpected fix text %q", got)
+	}
+}
+
+func TestAIPResourcePatternRule(t *testing.T) {
+	violations := checkSource(t, NewAIPResourcePatternRule(), "library/v1/library.proto", aipViolationsProto)
+	got := messages(violations)
+	if len(got) != 4 {
+		t.Fatalf("Expected 4 violations, got %d: %v", len(got), got)
+	}
+	for i, want := range []string{
+		"type 'library/book'",
+		"'shelves/{shelf}/{book}' of 'Book' must alternate",
+		"'shelves/{Shelf}' of 'Book' has variable '{Shelf}' that is not snake_case",
+		"must have a string field 'name'",
+	} {
+		if !strings.Contains(got[i], want) {
+			t.Errorf("Violation %d = %q, want it to contain %q", i, got[i], want)
+		}
+	}
+	if fix := violations[3].SuggestedFix; fix == nil || fix.Changes[0].NewText != "  string name = 2;\n" {
+		t.Errorf("Expected a fix adding the name field, got %+v", fix)
+	}
+}
+
+func TestCheckResourcePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		valid   bool
+	}{
+		{"publishers/{publisher}/books/{book}", true},
+		{"users/{user}/config", true},
+		{"projects/{project}/dataSets/{data_set}", true},
+		{"/books/{book}", false},
+		{"books/{book}/", false},
+		{"{book}", false},
+		{"books/book", false},
+		{"Books/{book}", false},
+		{"shelves/{book}/books/{book}", false},
+	}
+	for _, tt := range tests {
+		if problem := checkResourcePattern(tt.pattern); (problem == "") != tt.valid {
+			t.Errorf("checkResourcePattern(%q) = %q, want valid=%v", tt.pattern, problem, tt.valid)
+		}
+	}
+}
+
+func TestAIPHTTPBindingRule(t *testing.T) {
+	violations := checkSource(t, NewAIPHTTPBindingRule(), "library/v1/library.proto", aipViolationsProto)
+	got := messages(violations)
+	if len(got) != 5 {
+		t.Fatalf("Expected 5 violations, got %d: %v", len(got), got)
+	}
+	for i, want := range []string{
+		"binds 'name', which is not a field of 'FetchBookRequest'",
+		"should use HTTP GET, not POST",
+		"must not have an HTTP body",
+		"binds 'book.id', which is not a field of 'UpdateBookRequest'",
+		"resource field 'book' as HTTP body, not '*'",
+	} {
+		if !strings.Contains(got[i], want) {
+			t.Errorf("Violation %d = %q, want it to contain %q", i, got[i], want)
+		}
+	}
+	if fix := violations[1].SuggestedFix; fix == nil || fix.Changes[0].OldText != "post:" || fix.Changes[0].NewText != "get:" {
+		t.Errorf("Expected a fix replacing the verb, got %+v", fix)
+	}
+
+	if fix := violations[4].SuggestedFix; fix == nil || fix.Changes[0].NewText != `body: "book"` {
+		t.Errorf("Expected a fix replacing the body, got %+v", fix)
+	}
+}
+
+func TestAIPRules_FixesApply(t *testing.T) {
+	content := `syntax = "proto3";
+package library.v1;
+
+message Book {
+  string name = 1;
+}
+
+message FetchBookRequest {
+  string id = 1;
+}
+
+message ListBooksRequest {
+  string parent = 1;
+}
+
+message BooksPage {
+  repeated Book items = 1;
+}
+
+service LibraryService {
+  rpc GetBook(FetchBookRequest) returns (Book);
+  rpc ListBooks(ListBooksRequest) returns (BooksPage);
+}
+`
+	config := linter.DefaultConfig()
+	config.Lint.Use = []string{"aip"}
+	engine := linter.NewLintEngine(config)
+	for _, rule := range DefaultRules() {
+		engine.Registry().Register(rule)
+	}
+
+	result, err := engine.Fix("library/v1/library.proto", content)
+	if err != nil {
+		t.Fatalf("Fix failed: %v", err)
+	}
+	if _, err := protobuf.ParseWithDescriptor("library/v1/library.proto", result.Content); err != nil {
+		t.Fatalf("Fixed source does not parse: %v\n%s", err, result.Content)
+	}
+	for _, want := range []string{
+		"message GetBookRequest {\n  string id = 1;\n  string name = 2;\n}",
+		"int32 page_size = 2;",
+		"string page_token = 3;",
+		"message ListBooksResponse {\n  repeated Book books = 1;\n  string next_page_token = 2;\n}",
+		"rpc GetBook(GetBookRequest) returns (Book);",
+		"rpc ListBooks(ListBooksRequest) returns (ListBooksResponse);",
+	} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("Fixed source lacks %q:\n%s", want, result.Content)
+		}
+	}
+}
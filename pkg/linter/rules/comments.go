@@ -0,0 +1,110 @@
+package rules
+
+import (
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+// commented is a declaration that may carry a comment
+type commented struct {
+	node     protobuf.Node
+	name     string
+	comments []*protobuf.CommentNode
+}
+
+// CommentRule checks that every declaration of one kind is documented
+type CommentRule struct {
+	BaseRule
+	kind    string
+	collect func(node *protobuf.RootNode) []commented
+}
+
+// NewMessageCommentRule creates a rule requiring comments on messages
+func NewMessageCommentRule() *CommentRule {
+	return newCommentRule("comment-message", "Message", func(node *protobuf.RootNode) []commented {
+		var decls []commented
+		forEachMessage(node, func(msg *protobuf.MessageNode, fullName string) {
+			decls = append(decls, commented{msg, msg.Name, msg.Comments})
+		})
+		return decls
+	})
+}
+
+// NewFieldCommentRule creates a rule requiring comments on fields
+func NewFieldCommentRule() *CommentRule {
+	return newCommentRule("comment-field", "Field", func(node *protobuf.RootNode) []commented {
+		var decls []commented
+		forEachMessage(node, func(msg *protobuf.MessageNode, fullName string) {
+			for _, field := range msg.Fields {
+				decls = append(decls, commented{field, field.Name, field.Comments})
+			}
+		})
+		return decls
+	})
+}
+
+// NewEnumCommentRule creates a rule requiring comments on enums
+func NewEnumCommentRule() *CommentRule {
+	return newCommentRule("comment-enum", "Enum", func(node *protobuf.RootNode) []commented {
+		var decls []commented
+		forEachEnum(node, func(enum *protobuf.EnumNode, fullName string) {
+			decls = append(decls, commented{enum, enum.Name, enum.Comments})
+		})
+		return decls
+	})
+}
+
+// NewServiceCommentRule creates a rule requiring comments on services
+func NewServiceCommentRule() *CommentRule {
+	return newCommentRule("comment-service", "Service", func(node *protobuf.RootNode) []commented {
+		var decls []commented
+		for _, svc := range node.Services {
+			decls = append(decls, commented{svc, svc.Name, svc.Comments})
+		}
+		return decls
+	})
+}
+
+// NewRPCCommentRule creates a rule requiring comments on RPCs
+func NewRPCCommentRule() *CommentRule {
+	return newCommentRule("comment-rpc", "RPC", func(node *protobuf.RootNode) []commented {
+		var decls []commented
+		for _, svc := range node.Services {
+			for _, rpc := range svc.RPCs {
+				decls = append(decls, commented{rpc, rpc.Name, rpc.Comments})
+			}
+		}
+		return decls
+	})
+}
+
+func newCommentRule(name, kind string, collect func(node *protobuf.RootNode) []commented) *CommentRule {
+	return &CommentRule{
+		BaseRule: BaseRule{
+			RuleName:        name,
+			RuleCategory:    linter.CategoryDocumentation,
+			RuleSeverity:    linter.SeverityWarning,
+			RuleDescription: kind + " declarations must have a comment",
+		},
+		kind:    kind,
+		collect: collect,
+	}
+}
+
+// Check reports declarations without a comment
+func (r *CommentRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+	for _, decl := range r.collect(node) {
+		if linter.HasDocComment(decl.comments) {
+			continue
+		}
+		violations = append(violations, linter.Violation{
+			Rule:     r.Name(),
+			Severity: r.Severity(),
+			Category: r.Category(),
+			Message:  r.kind + " '" + decl.name + "' should have a comment",
+			Position: decl.node.Position(),
+		})
+	}
+	return violations
+}
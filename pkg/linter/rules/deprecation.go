@@ -0,0 +1,163 @@
+package rules
+
+import (
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+// DeprecatedCommentRule checks that deprecated declarations say why they are
+// deprecated and what replaces them
+type DeprecatedCommentRule struct {
+	BaseRule
+}
+
+// NewDeprecatedCommentRule creates a new deprecated comment rule
+func NewDeprecatedCommentRule() *DeprecatedCommentRule {
+	return &DeprecatedCommentRule{
+		BaseRule: BaseRule{
+			RuleName:        "deprecated-comment",
+			RuleCategory:    linter.CategoryDocumentation,
+			RuleSeverity:    linter.SeverityWarning,
+			RuleDescription: "Deprecated declarations must have a comment naming their replacement",
+		},
+	}
+}
+
+// Check reports deprecated declarations without a comment
+func (r *DeprecatedCommentRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+	check := func(kind, name string, pos protobuf.Position, options []*protobuf.OptionNode, comments []*protobuf.CommentNode) {
+		if !isDeprecated(options) || linter.HasDocComment(comments) {
+			return
+		}
+		violations = append(violations, linter.Violation{
+			Rule:     r.Name(),
+			Severity: r.Severity(),
+			Category: r.Category(),
+			Message:  "Deprecated " + kind + " '" + name + "' should have a comment explaining what to use instead",
+			Position: pos,
+		})
+	}
+
+	forEachMessage(node, func(msg *protobuf.MessageNode, fullName string) {
+		check("message", msg.Name, msg.Position(), msg.Options, msg.Comments)
+		for _, field := range msg.Fields {
+			check("field", field.Name, field.Position(), field.Options, field.Comments)
+		}
+	})
+	forEachEnum(node, func(enum *protobuf.EnumNode, fullName string) {
+		check("enum", enum.Name, enum.Position(), enum.Options, enum.Comments)
+		for _, value := range enum.Values {
+			check("enum value", value.Name, value.Position(), value.Options, value.Comments)
+		}
+	})
+	for _, svc := range node.Services {
+		check("service", svc.Name, svc.Position(), svc.Options, svc.Comments)
+		for _, rpc := range svc.RPCs {
+			check("RPC", rpc.Name, rpc.Position(), rpc.Options, rpc.Comments)
+		}
+	}
+	return violations
+}
+
+// DeprecatedReferenceRule tracks uses of deprecated messages and enums by
+// declarations that are not deprecated themselves
+type DeprecatedReferenceRule struct {
+	BaseRule
+}
+
+// NewDeprecatedReferenceRule creates a new deprecated reference rule
+func NewDeprecatedReferenceRule() *DeprecatedReferenceRule {
+	return &DeprecatedReferenceRule{
+		BaseRule: BaseRule{
+			RuleName:        "deprecated-reference",
+			RuleCategory:    linter.CategoryStructure,
+			RuleSeverity:    linter.SeverityWarning,
+			RuleDescription: "Fields and RPCs should not use deprecated messages or enums",
+		},
+	}
+}
+
+// Check reports fields and RPCs that use deprecated types of the file
+func (r *DeprecatedReferenceRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	deprecated := make(map[string]bool)
+	forEachMessage(node, func(msg *protobuf.MessageNode, fullName string) {
+		if isDeprecated(msg.Options) {
+			deprecated[fullName] = true
+		}
+	})
+	forEachEnum(node, func(enum *protobuf.EnumNode, fullName string) {
+		if isDeprecated(enum.Options) {
+			deprecated[fullName] = true
+		}
+	})
+
+	violations := make([]linter.Violation, 0)
+	if len(deprecated) == 0 {
+		return violations
+	}
+	report := func(what, typeName string, pos protobuf.Position) {
+		violations = append(violations, linter.Violation{
+			Rule:     r.Name(),
+			Severity: r.Severity(),
+			Category: r.Category(),
+			Message:  what + " uses deprecated type '" + typeName + "'",
+			Position: pos,
+		})
+	}
+
+	forEachMessage(node, func(msg *protobuf.MessageNode, fullName string) {
+		if isDeprecated(msg.Options) {
+			return
+		}
+		for _, field := range msg.Fields {
+			if isDeprecated(field.Options) {
+				continue
+			}
+			if name, ok := resolveType(deprecated, fullName, field.Type); ok {
+				report("Field '"+field.Name+"'", name, field.Position())
+			}
+		}
+	})
+
+	scope := packageName(node)
+	for _, svc := range node.Services {
+		if isDeprecated(svc.Options) {
+			continue
+		}
+		for _, rpc := range svc.RPCs {
+			if isDeprecated(rpc.Options) {
+				continue
+			}
+			for _, typeName := range []string{rpc.InputType, rpc.OutputType} {
+				if name, ok := resolveType(deprecated, scope, typeName); ok {
+					report("RPC '"+rpc.Name+"'", name, rpc.Position())
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// resolveType looks a type name up in types the way protoc resolves names:
+// fully qualified first, then relative to each enclosing scope, innermost
+// first
+func resolveType(types map[string]bool, scope, typeName string) (string, bool) {
+	typeName = strings.TrimPrefix(typeName, ".")
+	if types[typeName] {
+		return typeName, true
+	}
+	for scope != "" {
+		if name := scope + "." + typeName; types[name] {
+			return name, true
+		}
+		i := strings.LastIndex(scope, ".")
+		if i < 0 {
+			break
+		}
+		scope = scope[:i]
+	}
+	return "", false
+}
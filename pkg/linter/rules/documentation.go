@@ -0,0 +1,50 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+// DocumentationCoverageRule checks the share of documented declarations of a
+// file against quality.documentation_coverage.min_coverage
+type DocumentationCoverageRule struct {
+	BaseRule
+}
+
+// NewDocumentationCoverageRule creates a new documentation coverage rule
+func NewDocumentationCoverageRule() *DocumentationCoverageRule {
+	return &DocumentationCoverageRule{
+		BaseRule: BaseRule{
+			RuleName:        "documentation-coverage",
+			RuleCategory:    linter.CategoryDocumentation,
+			RuleSeverity:    linter.SeverityWarning,
+			RuleDescription: "Files must document at least the configured share of their declarations",
+		},
+	}
+}
+
+// Check compares the documentation coverage with the configured minimum
+func (r *DocumentationCoverageRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	if ctx == nil || ctx.Config == nil {
+		return nil
+	}
+	min := ctx.Config.Quality.DocumentationCoverage.MinCoverage
+	if min <= 0 {
+		return nil
+	}
+
+	coverage := linter.MeasureDocCoverage(node)
+	if coverage.Percent() >= min {
+		return nil
+	}
+	return []linter.Violation{{
+		Rule:     r.Name(),
+		Severity: r.Severity(),
+		Category: r.Category(),
+		Message: fmt.Sprintf("Documentation coverage is %.1f%% (%d of %d declarations), below the minimum of %.1f%%",
+			coverage.Percent(), coverage.Documented, coverage.Total, min),
+		Position: filePosition(node),
+	}}
+}
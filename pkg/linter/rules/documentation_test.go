@@ -0,0 +1,152 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+const documentedProto = `syntax = "proto3";
+package acme.v1;
+
+// A user account.
+message User {
+  // Unique id.
+  string id = 1;
+  // Display name.
+  string name = 2;
+  string email = 3;
+}
+
+enum Role {
+  ROLE_UNSPECIFIED = 0;
+}
+
+// Manages users.
+service UserService {
+  rpc GetUser(User) returns (User);
+}
+`
+
+func TestCommentRules(t *testing.T) {
+	tests := []struct {
+		rule linter.Rule
+		want []string
+	}{
+		{NewMessageCommentRule(), nil},
+		{NewFieldCommentRule(), []string{"Field 'email' should have a comment"}},
+		{NewEnumCommentRule(), []string{"Enum 'Role' should have a comment"}},
+		{NewServiceCommentRule(), nil},
+		{NewRPCCommentRule(), []string{"RPC 'GetUser' should have a comment"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule.Name(), func(t *testing.T) {
+			got := messages(checkSource(t, tt.rule, "acme/v1/user.proto", documentedProto))
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDocumentationCoverageRule(t *testing.T) {
+	rule := NewDocumentationCoverageRule()
+	ast, err := protobuf.ParseWithDescriptor("acme/v1/user.proto", documentedProto)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	// 4 of 8 declarations are documented
+	tests := []struct {
+		minCoverage float64
+		want        int
+	}{
+		{0, 0},
+		{50, 0},
+		{80, 1},
+	}
+	for _, tt := range tests {
+		config := linter.DefaultConfig()
+		config.Quality.DocumentationCoverage.MinCoverage = tt.minCoverage
+		violations := rule.Check(ast, &linter.LintContext{FilePath: "acme/v1/user.proto", AST: ast, Config: config})
+		if len(violations) != tt.want {
+			t.Errorf("min %.0f%%: expected %d violations, got %v", tt.minCoverage, tt.want, messages(violations))
+		}
+		if len(violations) == 1 && !strings.Contains(violations[0].Message, "50.0% (4 of 8 declarations)") {
+			t.Errorf("Unexpected message: %s", violations[0].Message)
+		}
+	}
+
+	// Without a config there is no threshold
+	if violations := rule.Check(ast, &linter.LintContext{AST: ast}); len(violations) != 0 {
+		t.Errorf("Expected no violations without config, got %v", messages(violations))
+	}
+}
+
+const deprecatedProto = `syntax = "proto3";
+package acme.v1;
+
+// Use Account instead.
+message User {
+  option deprecated = true;
+  string id = 1;
+}
+
+message Account {
+  string id = 1;
+  User legacy = 2;
+  User old = 3 [deprecated = true];
+  Status status = 4;
+}
+
+enum Status {
+  option deprecated = true;
+  STATUS_UNSPECIFIED = 0;
+}
+
+service AccountService {
+  rpc GetAccount(User) returns (Account);
+  rpc GetUser(User) returns (User) {
+    option deprecated = true;
+  }
+}
+`
+
+func TestDeprecatedCommentRule(t *testing.T) {
+	got := messages(checkSource(t, NewDeprecatedCommentRule(), "acme/v1/user.proto", deprecatedProto))
+	want := []string{
+		"Deprecated field 'old' should have a comment explaining what to use instead",
+		"Deprecated enum 'Status' should have a comment explaining what to use instead",
+		"Deprecated RPC 'GetUser' should have a comment explaining what to use instead",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestDeprecatedReferenceRule(t *testing.T) {
+	got := messages(checkSource(t, NewDeprecatedReferenceRule(), "acme/v1/user.proto", deprecatedProto))
+	want := []string{
+		"Field 'legacy' uses deprecated type 'acme.v1.User'",
+		"Field 'status' uses deprecated type 'acme.v1.Status'",
+		"RPC 'GetAccount' uses deprecated type 'acme.v1.User'",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// Short type names resolve against enclosing scopes
+	ast := &protobuf.RootNode{
+		Package: &protobuf.PackageNode{Name: "acme"},
+		Messages: []*protobuf.MessageNode{
+			{Name: "Old", Options: []*protobuf.OptionNode{{Name: "deprecated", Value: "true"}}},
+			{Name: "New", Fields: []*protobuf.FieldNode{{Name: "old", Type: "Old", Number: 1}}},
+		},
+	}
+	if violations := NewDeprecatedReferenceRule().Check(ast, &linter.LintContext{AST: ast}); len(violations) != 1 {
+		t.Errorf("Expected 1 violation, got %v", messages(violations))
+	}
+}
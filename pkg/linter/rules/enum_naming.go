@@ -33,57 +33,46 @@ func (r *EnumNamingRule) Check(node *protobuf.RootNode, ctx *linter.LintContext)
 
 	// Check top-level enums
 	for _, enum := range node.Enums {
-		violations = append(violations, r.checkEnum(enum)...)
+		violations = append(violations, r.checkEnum(enum, ctx)...)
 	}
 
 	// Check enums in messages
 	for _, msg := range node.Messages {
-		violations = append(violations, r.checkMessage(msg)...)
+		violations = append(violations, r.checkMessage(msg, ctx)...)
 	}
 
 	return violations
 }
 
-func (r *EnumNamingRule) checkEnum(enum *protobuf.EnumNode) []linter.Violation {
+func (r *EnumNamingRule) checkEnum(enum *protobuf.EnumNode, ctx *linter.LintContext) []linter.Violation {
 	violations := make([]linter.Violation, 0)
 
 	if !isPascalCase(enum.Name) {
 		pos := enum.Position()
 		violations = append(violations, linter.Violation{
-			Rule:     r.Name(),
-			Severity: r.Severity(),
-			Category: r.Category(),
-			Message:  "Enum name '" + enum.Name + "' should be PascalCase",
-			Position: pos,
-			SuggestedFix: &linter.Fix{
-				Description: "Convert to PascalCase",
-				Changes: []linter.Change{
-					{
-						FilePath: "",
-						StartPos: pos,
-						EndPos:   pos,
-						OldText:  enum.Name,
-						NewText:  toPascalCase(enum.Name),
-					},
-				},
-			},
+			Rule:         r.Name(),
+			Severity:     r.Severity(),
+			Category:     r.Category(),
+			Message:      "Enum name '" + enum.Name + "' should be PascalCase",
+			Position:     pos,
+			SuggestedFix: linter.RenameFix(ctx, enum, enum.Name, toPascalCase(enum.Name), "Convert to PascalCase"),
 		})
 	}
 
 	return violations
 }
 
-func (r *EnumNamingRule) checkMessage(msg *protobuf.MessageNode) []linter.Violation {
+func (r *EnumNamingRule) checkMessage(msg *protobuf.MessageNode, ctx *linter.LintContext) []linter.Violation {
 	violations := make([]linter.Violation, 0)
 
 	// Check enums in message
 	for _, enum := range msg.Enums {
-		violations = append(violations, r.checkEnum(enum)...)
+		violations = append(violations, r.checkEnum(enum, ctx)...)
 	}
 
 	// Check nested messages
 	for _, nested := range msg.Nested {
-		violations = append(violations, r.checkMessage(nested)...)
+		violations = append(violations, r.checkMessage(nested, ctx)...)
 	}
 
 	return violations
@@ -118,41 +107,30 @@ func (r *EnumValueNamingRule) Check(node *protobuf.RootNode, ctx *linter.LintCon
 
 	// Check top-level enums
 	for _, enum := range node.Enums {
-		violations = append(violations, r.checkEnumValues(enum)...)
+		violations = append(violations, r.checkEnumValues(enum, ctx)...)
 	}
 
 	// Check enums in messages
 	for _, msg := range node.Messages {
-		violations = append(violations, r.checkMessageEnumValues(msg)...)
+		violations = append(violations, r.checkMessageEnumValues(msg, ctx)...)
 	}
 
 	return violations
 }
 
-func (r *EnumValueNamingRule) checkEnumValues(enum *protobuf.EnumNode) []linter.Violation {
+func (r *EnumValueNamingRule) checkEnumValues(enum *protobuf.EnumNode, ctx *linter.LintContext) []linter.Violation {
 	violations := make([]linter.Violation, 0)
 
 	for _, value := range enum.Values {
 		if !isUpperSnakeCase(value.Name) {
 			pos := value.Position()
 			violations = append(violations, linter.Violation{
-				Rule:     r.Name(),
-				Severity: r.Severity(),
-				Category: r.Category(),
-				Message:  "Enum value '" + value.Name + "' should be UPPER_SNAKE_CASE",
-				Position: pos,
-				SuggestedFix: &linter.Fix{
-					Description: "Convert to UPPER_SNAKE_CASE",
-					Changes: []linter.Change{
-						{
-							FilePath: "",
-							StartPos: pos,
-							EndPos:   pos,
-							OldText:  value.Name,
-							NewText:  toUpperSnakeCase(value.Name),
-						},
-					},
-				},
+				Rule:         r.Name(),
+				Severity:     r.Severity(),
+				Category:     r.Category(),
+				Message:      "Enum value '" + value.Name + "' should be UPPER_SNAKE_CASE",
+				Position:     pos,
+				SuggestedFix: linter.RenameFix(ctx, value, value.Name, toUpperSnakeCase(value.Name), "Convert to UPPER_SNAKE_CASE"),
 			})
 		}
 	}
@@ -160,17 +138,17 @@ func (r *EnumValueNamingRule) checkEnumValues(enum *protobuf.EnumNode) []linter.
 	return violations
 }
 
-func (r *EnumValueNamingRule) checkMessageEnumValues(msg *protobuf.MessageNode) []linter.Violation {
+func (r *EnumValueNamingRule) checkMessageEnumValues(msg *protobuf.MessageNode, ctx *linter.LintContext) []linter.Violation {
 	violations := make([]linter.Violation, 0)
 
 	// Check enums in message
 	for _, enum := range msg.Enums {
-		violations = append(violations, r.checkEnumValues(enum)...)
+		violations = append(violations, r.checkEnumValues(enum, ctx)...)
 	}
 
 	// Check nested messages
 	for _, nested := range msg.Nested {
-		violations = append(violations, r.checkMessageEnumValues(nested)...)
+		violations = append(violations, r.checkMessageEnumValues(nested, ctx)...)
 	}
 
 	return violations
@@ -0,0 +1,120 @@
+package rules
+
+import (
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+// EnumZeroValueSuffixRule checks that the zero value of an enum is named
+// ENUM_NAME_UNSPECIFIED
+type EnumZeroValueSuffixRule struct {
+	BaseRule
+}
+
+// NewEnumZeroValueSuffixRule creates a new enum zero value suffix rule
+func NewEnumZeroValueSuffixRule() *EnumZeroValueSuffixRule {
+	return &EnumZeroValueSuffixRule{
+		BaseRule: BaseRule{
+			RuleName:        "enum-zero-value-suffix",
+			RuleCategory:    linter.CategoryNaming,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Enum zero values must end in _UNSPECIFIED",
+			AutoFixable:     true,
+		},
+	}
+}
+
+// Check validates enum zero values
+func (r *EnumZeroValueSuffixRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+
+	forEachEnum(node, func(enum *protobuf.EnumNode, fullName string) {
+		for _, value := range enum.Values {
+			if value.Number != 0 || strings.HasSuffix(value.Name, "_UNSPECIFIED") {
+				continue
+			}
+			want := toUpperSnakeCase(enum.Name) + "_UNSPECIFIED"
+			v := linter.Violation{
+				Rule:     r.Name(),
+				Severity: r.Severity(),
+				Category: r.Category(),
+				Message:  "Enum zero value '" + value.Name + "' should be named '" + want + "'",
+				Position: value.Position(),
+			}
+			if !hasEnumValue(enum, want) {
+				v.SuggestedFix = linter.RenameFix(ctx, value, value.Name, want, "Rename to "+want)
+			}
+			violations = append(violations, v)
+		}
+	})
+
+	return violations
+}
+
+// AutoFix renames the zero value
+func (r *EnumZeroValueSuffixRule) AutoFix(node *protobuf.RootNode, violation linter.Violation) (*linter.Fix, error) {
+	return violation.SuggestedFix, nil
+}
+
+// EnumValuePrefixRule checks that enum values are prefixed with the enum
+// name, as enum values share the scope of their enum's parent
+type EnumValuePrefixRule struct {
+	BaseRule
+}
+
+// NewEnumValuePrefixRule creates a new enum value prefix rule
+func NewEnumValuePrefixRule() *EnumValuePrefixRule {
+	return &EnumValuePrefixRule{
+		BaseRule: BaseRule{
+			RuleName:        "enum-value-prefix",
+			RuleCategory:    linter.CategoryNaming,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Enum values must be prefixed with the UPPER_SNAKE_CASE enum name",
+			AutoFixable:     true,
+		},
+	}
+}
+
+// Check validates enum value prefixes
+func (r *EnumValuePrefixRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+
+	forEachEnum(node, func(enum *protobuf.EnumNode, fullName string) {
+		prefix := toUpperSnakeCase(enum.Name) + "_"
+		for _, value := range enum.Values {
+			if strings.HasPrefix(value.Name, prefix) {
+				continue
+			}
+			want := prefix + value.Name
+			v := linter.Violation{
+				Rule:     r.Name(),
+				Severity: r.Severity(),
+				Category: r.Category(),
+				Message:  "Enum value '" + value.Name + "' should be prefixed with '" + prefix + "'",
+				Position: value.Position(),
+			}
+			if isUpperSnakeCase(value.Name) && !hasEnumValue(enum, want) {
+				v.SuggestedFix = linter.RenameFix(ctx, value, value.Name, want, "Add prefix "+prefix)
+			}
+			violations = append(violations, v)
+		}
+	})
+
+	return violations
+}
+
+// AutoFix prefixes the enum value
+func (r *EnumValuePrefixRule) AutoFix(node *protobuf.RootNode, violation linter.Violation) (*linter.Fix, error) {
+	return violation.SuggestedFix, nil
+}
+
+func hasEnumValue(enum *protobuf.EnumNode, name string) bool {
+	for _, value := range enum.Values {
+		if value.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package rules
+
+import (
+	"testing"
+)
+
+const enumProto = `syntax = "proto3";
+package acme.v1;
+
+enum OrderState {
+  ORDER_STATE_UNKNOWN = 0;
+  ORDER_STATE_OPEN = 1;
+  CLOSED = 2;
+}
+
+enum Color {
+  COLOR_UNSPECIFIED = 0;
+  COLOR_RED = 1;
+}
+`
+
+func TestEnumZeroValueSuffixRule(t *testing.T) {
+	violations := checkSource(t, NewEnumZeroValueSuffixRule(), "acme/v1/order.proto", enumProto)
+	if len(violations) != 1 {
+		t.Fatalf("Expected 1 violation, got %d: %v", len(violations), messages(violations))
+	}
+	fix := violations[0].SuggestedFix
+	if fix == nil || fix.Changes[0].NewText != "ORDER_STATE_UNSPECIFIED" {
+		t.Errorf("Expected a rename to ORDER_STATE_UNSPECIFIED, got %+v", fix)
+	}
+}
+
+func TestEnumValuePrefixRule(t *testing.T) {
+	violations := checkSource(t, NewEnumValuePrefixRule(), "acme/v1/order.proto", enumProto)
+	if len(violations) != 1 {
+		t.Fatalf("Expected 1 violation, got %d: %v", len(violations), messages(violations))
+	}
+	fix := violations[0].SuggestedFix
+	if fix == nil || fix.Changes[0].NewText != "ORDER_STATE_CLOSED" {
+		t.Errorf("Expected a rename to ORDER_STATE_CLOSED, got %+v", fix)
+	}
+}
+
+func TestServiceSuffixRule(t *testing.T) {
+	content := "syntax = \"proto3\";\npackage acme.v1;\nmessage Empty {}\nservice Users {}\nservice OrderService {}\n"
+	violations := checkSource(t, NewServiceSuffixRule(), "acme/v1/svc.proto", content)
+	if len(violations) != 1 {
+		t.Fatalf("Expected 1 violation, got %d: %v", len(violations), messages(violations))
+	}
+	if fix := violations[0].SuggestedFix; fix == nil || fix.Changes[0].NewText != "UsersService" {
+		t.Errorf("Expected a rename to UsersService, got %+v", fix)
+	}
+}
@@ -33,13 +33,13 @@ func (r *FieldNamingRule) Check(node *protobuf.RootNode, ctx *linter.LintContext
 
 	// Check all messages
 	for _, msg := range node.Messages {
-		violations = append(violations, r.checkMessage(msg)...)
+		violations = append(violations, r.checkMessage(msg, ctx)...)
 	}
 
 	return violations
 }
 
-func (r *FieldNamingRule) checkMessage(msg *protobuf.MessageNode) []linter.Violation {
+func (r *FieldNamingRule) checkMessage(msg *protobuf.MessageNode, ctx *linter.LintContext) []linter.Violation {
 	violations := make([]linter.Violation, 0)
 
 	// Check fields
@@ -47,30 +47,19 @@ func (r *FieldNamingRule) checkMessage(msg *protobuf.MessageNode) []linter.Viola
 		if !isSnakeCase(field.Name) {
 			pos := field.Position()
 			violations = append(violations, linter.Violation{
-				Rule:     r.Name(),
-				Severity: r.Severity(),
-				Category: r.Category(),
-				Message:  "Field name '" + field.Name + "' should be snake_case",
-				Position: pos,
-				SuggestedFix: &linter.Fix{
-					Description: "Convert to snake_case",
-					Changes: []linter.Change{
-						{
-							FilePath: "",
-							StartPos: pos,
-							EndPos:   pos,
-							OldText:  field.Name,
-							NewText:  toSnakeCase(field.Name),
-						},
-					},
-				},
+				Rule:         r.Name(),
+				Severity:     r.Severity(),
+				Category:     r.Category(),
+				Message:      "Field name '" + field.Name + "' should be snake_case",
+				Position:     pos,
+				SuggestedFix: linter.RenameFix(ctx, field, field.Name, toSnakeCase(field.Name), "Convert to snake_case"),
 			})
 		}
 	}
 
 	// Check nested messages
 	for _, nested := range msg.Nested {
-		violations = append(violations, r.checkMessage(nested)...)
+		violations = append(violations, r.checkMessage(nested, ctx)...)
 	}
 
 	return violations
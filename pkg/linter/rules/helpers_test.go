@@ -0,0 +1,44 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+// checkSource parses content as filePath and runs rule over it
+func checkSource(t *testing.T, rule linter.Rule, filePath, content string) []linter.Violation {
+	t.Helper()
+	ast, err := protobuf.ParseWithDescriptor(filePath, content)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	ctx := &linter.LintContext{
+		FilePath: filePath,
+		AST:      ast,
+		Content:  content,
+		Config:   linter.DefaultConfig(),
+	}
+	return rule.Check(ast, ctx)
+}
+
+func messages(violations []linter.Violation) []string {
+	out := make([]string, 0, len(violations))
+	for _, v := range violations {
+		out = append(out, v.Message)
+	}
+	return out
+}
+
+// findRule returns the built-in rule with the given name
+func findRule(t *testing.T, name string) linter.Rule {
+	t.Helper()
+	for _, rule := range DefaultRules() {
+		if rule.Name() == name {
+			return rule
+		}
+	}
+	t.Fatalf("Rule %s not found", name)
+	return nil
+}
@@ -32,42 +32,31 @@ func (r *MessageNamingRule) Check(node *protobuf.RootNode, ctx *linter.LintConte
 
 	// Check top-level messages
 	for _, msg := range node.Messages {
-		violations = append(violations, r.checkMessage(msg)...)
+		violations = append(violations, r.checkMessage(msg, ctx)...)
 	}
 
 	return violations
 }
 
-func (r *MessageNamingRule) checkMessage(msg *protobuf.MessageNode) []linter.Violation {
+func (r *MessageNamingRule) checkMessage(msg *protobuf.MessageNode, ctx *linter.LintContext) []linter.Violation {
 	violations := make([]linter.Violation, 0)
 
 	// Check if name is PascalCase
 	if !isPascalCase(msg.Name) {
 		pos := msg.Position()
 		violations = append(violations, linter.Violation{
-			Rule:     r.Name(),
-			Severity: r.Severity(),
-			Category: r.Category(),
-			Message:  "Message name '" + msg.Name + "' should be PascalCase",
-			Position: pos,
-			SuggestedFix: &linter.Fix{
-				Description: "Convert to PascalCase",
-				Changes: []linter.Change{
-					{
-						FilePath: "",
-						StartPos: pos,
-						EndPos:   pos,
-						OldText:  msg.Name,
-						NewText:  toPascalCase(msg.Name),
-					},
-				},
-			},
+			Rule:         r.Name(),
+			Severity:     r.Severity(),
+			Category:     r.Category(),
+			Message:      "Message name '" + msg.Name + "' should be PascalCase",
+			Position:     pos,
+			SuggestedFix: linter.RenameFix(ctx, msg, msg.Name, toPascalCase(msg.Name), "Convert to PascalCase"),
 		})
 	}
 
 	// Check nested messages
 	for _, nested := range msg.Nested {
-		violations = append(violations, r.checkMessage(nested)...)
+		violations = append(violations, r.checkMessage(nested, ctx)...)
 	}
 
 	return violations
@@ -0,0 +1,298 @@
+package rules
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+// ImportNoCycleRule checks that the files of a module do not import each
+// other in a cycle, which protoc rejects
+type ImportNoCycleRule struct {
+	BaseRule
+}
+
+// NewImportNoCycleRule creates a new import cycle rule
+func NewImportNoCycleRule() *ImportNoCycleRule {
+	return &ImportNoCycleRule{
+		BaseRule: BaseRule{
+			RuleName:        "import-no-cycle",
+			RuleCategory:    linter.CategoryStructure,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Files must not import each other in a cycle",
+		},
+	}
+}
+
+// Check does nothing; the rule checks whole modules
+func (r *ImportNoCycleRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	return nil
+}
+
+// CheckModule reports every import that leads back to the importing file
+func (r *ImportNoCycleRule) CheckModule(module *linter.Module, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+	for _, file := range module.Paths() {
+		for _, imp := range module.Files[file].Imports {
+			if _, ok := module.Files[imp.Path]; !ok {
+				continue
+			}
+			cycle := importChain(module, imp.Path, file)
+			if cycle == nil {
+				continue
+			}
+			violations = append(violations, linter.Violation{
+				Rule:     r.Name(),
+				Severity: r.Severity(),
+				Category: r.Category(),
+				Message:  "Import of '" + imp.Path + "' forms a cycle: " + strings.Join(append([]string{file}, cycle...), " -> "),
+				Position: imp.Position(),
+				File:     file,
+			})
+		}
+	}
+	return violations
+}
+
+// importChain returns the shortest chain of imports from one module file to
+// another, both included, or nil when there is none
+func importChain(module *linter.Module, from, to string) []string {
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		file := queue[0]
+		queue = queue[1:]
+		if file == to {
+			var chain []string
+			for ; file != ""; file = previous[file] {
+				chain = append([]string{file}, chain...)
+			}
+			return chain
+		}
+		for _, imp := range module.Files[file].Imports {
+			if _, ok := module.Files[imp.Path]; !ok {
+				continue
+			}
+			if _, seen := previous[imp.Path]; !seen {
+				previous[imp.Path] = file
+				queue = append(queue, imp.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// ImportUsedRule checks that every import of a file is used by a type,
+// extension or option of the file. Imports of files outside the module are
+// only judged when they are well-known types or google.api annotations.
+type ImportUsedRule struct {
+	BaseRule
+}
+
+// NewImportUsedRule creates a new unused import rule
+func NewImportUsedRule() *ImportUsedRule {
+	return &ImportUsedRule{
+		BaseRule: BaseRule{
+			RuleName:        "import-used",
+			RuleCategory:    linter.CategoryStyle,
+			RuleSeverity:    linter.SeverityWarning,
+			RuleDescription: "Imports must be used",
+		},
+	}
+}
+
+// Check does nothing; the rule checks whole modules
+func (r *ImportUsedRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	return nil
+}
+
+// CheckModule reports the unused imports of every file
+func (r *ImportUsedRule) CheckModule(module *linter.Module, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+	for _, file := range module.Paths() {
+		for _, imp := range module.Symbols.UnusedImports(file) {
+			violations = append(violations, linter.Violation{
+				Rule:     r.Name(),
+				Severity: r.Severity(),
+				Category: r.Category(),
+				Message:  "Import '" + imp.Path + "' is not used",
+				Position: imp.Position(),
+				File:     file,
+			})
+		}
+	}
+	return violations
+}
+
+// PackageSameDirectoryRule checks that all files of a package are in one
+// directory
+type PackageSameDirectoryRule struct {
+	BaseRule
+}
+
+// NewPackageSameDirectoryRule creates a new package directory rule
+func NewPackageSameDirectoryRule() *PackageSameDirectoryRule {
+	return &PackageSameDirectoryRule{
+		BaseRule: BaseRule{
+			RuleName:        "package-same-directory",
+			RuleCategory:    linter.CategoryStructure,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "All files of a package must be in the same directory",
+		},
+	}
+}
+
+// Check does nothing; the rule checks whole modules
+func (r *PackageSameDirectoryRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	return nil
+}
+
+// CheckModule reports the files of packages split across directories
+func (r *PackageSameDirectoryRule) CheckModule(module *linter.Module, ctx *linter.LintContext) []linter.Violation {
+	dirs := make(map[string]map[string]bool)
+	for file, node := range module.Files {
+		pkg := packageName(node)
+		if pkg == "" {
+			continue
+		}
+		if dirs[pkg] == nil {
+			dirs[pkg] = make(map[string]bool)
+		}
+		dirs[pkg][path.Dir(file)] = true
+	}
+
+	violations := make([]linter.Violation, 0)
+	for _, file := range module.Paths() {
+		node := module.Files[file]
+		pkg := packageName(node)
+		if len(dirs[pkg]) < 2 {
+			continue
+		}
+		list := make([]string, 0, len(dirs[pkg]))
+		for dir := range dirs[pkg] {
+			list = append(list, dir)
+		}
+		sort.Strings(list)
+		violations = append(violations, linter.Violation{
+			Rule:     r.Name(),
+			Severity: r.Severity(),
+			Category: r.Category(),
+			Message:  "Package '" + pkg + "' is split across directories " + strings.Join(list, ", "),
+			Position: node.Package.Position(),
+			File:     file,
+		})
+	}
+	return violations
+}
+
+// SymbolUniqueRule checks that no name is declared by two files of a
+// module. Files that do not import each other compile on their own, so
+// protoc only reports the conflict when both are used together.
+type SymbolUniqueRule struct {
+	BaseRule
+}
+
+// NewSymbolUniqueRule creates a new unique symbol rule
+func NewSymbolUniqueRule() *SymbolUniqueRule {
+	return &SymbolUniqueRule{
+		BaseRule: BaseRule{
+			RuleName:        "symbol-unique",
+			RuleCategory:    linter.CategoryStructure,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Names must be declared by only one file of a module",
+		},
+	}
+}
+
+// Check does nothing; the rule checks whole modules
+func (r *SymbolUniqueRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	return nil
+}
+
+// CheckModule reports every declaration of a name after the first, in
+// file order
+func (r *SymbolUniqueRule) CheckModule(module *linter.Module, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+	for _, file := range module.Paths() {
+		for _, sym := range module.Symbols.Symbols(file) {
+			first := module.Symbols.Lookup(sym.FullName)[0]
+			if first.File == file || sym.Node == nil {
+				continue
+			}
+			violations = append(violations, linter.Violation{
+				Rule:     r.Name(),
+				Severity: r.Severity(),
+				Category: r.Category(),
+				Message:  "'" + sym.FullName + "' is already declared in " + first.File,
+				Position: sym.Node.Position(),
+				File:     file,
+			})
+		}
+	}
+	return violations
+}
+
+// TypeReferencedRule checks that every message and enum is used by a field,
+// RPC or extension of the module. Types meant for other modules or for
+// serialization on their own are legitimately unreferenced, so the rule is
+// opt-in.
+type TypeReferencedRule struct {
+	BaseRule
+}
+
+// NewTypeReferencedRule creates a new referenced type rule
+func NewTypeReferencedRule() *TypeReferencedRule {
+	return &TypeReferencedRule{
+		BaseRule: BaseRule{
+			RuleName:        "type-referenced",
+			RuleCategory:    linter.CategoryStructure,
+			RuleSeverity:    linter.SeverityInfo,
+			RuleDescription: "Messages and enums should be referenced within the module",
+		},
+	}
+}
+
+// Check does nothing; the rule checks whole modules
+func (r *TypeReferencedRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	return nil
+}
+
+// CheckModule reports the messages and enums no file refers to
+func (r *TypeReferencedRule) CheckModule(module *linter.Module, ctx *linter.LintContext) []linter.Violation {
+	referenced := make(map[string]bool)
+	for _, file := range module.Paths() {
+		for _, ref := range module.Symbols.References(file) {
+			referenced[ref.Name] = true
+		}
+	}
+
+	violations := make([]linter.Violation, 0)
+	for _, file := range module.Paths() {
+		for _, sym := range module.Symbols.Symbols(file) {
+			var kind string
+			switch sym.Kind {
+			case protobuf.SymbolMessage:
+				kind = "Message"
+			case protobuf.SymbolEnum:
+				kind = "Enum"
+			default:
+				continue
+			}
+			if referenced[sym.FullName] || sym.Node == nil {
+				continue
+			}
+			violations = append(violations, linter.Violation{
+				Rule:     r.Name(),
+				Severity: r.Severity(),
+				Category: r.Category(),
+				Message:  kind + " '" + sym.FullName + "' is not referenced in the module",
+				Position: sym.Node.Position(),
+				File:     file,
+			})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,135 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+// checkModule parses sources as one module and runs a module rule over it
+func checkModule(t *testing.T, name string, sources map[string]string) []linter.Violation {
+	t.Helper()
+	files, err := protobuf.ParseFiles(sources)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	rule, ok := findRule(t, name).(linter.ModuleRule)
+	if !ok {
+		t.Fatalf("Rule %s is not a module rule", name)
+	}
+	return rule.CheckModule(linter.NewModule(files), &linter.LintContext{Config: linter.DefaultConfig()})
+}
+
+func files(violations []linter.Violation) []string {
+	out := make([]string, 0, len(violations))
+	for _, v := range violations {
+		out = append(out, v.File)
+	}
+	return out
+}
+
+func TestImportNoCycleRule(t *testing.T) {
+	violations := checkModule(t, "import-no-cycle", map[string]string{
+		"a.proto": "syntax = \"proto3\";\npackage cycle;\nimport \"b.proto\";\n\nmessage A {\n  B b = 1;\n}\n",
+		"b.proto": "syntax = \"proto3\";\npackage cycle;\nimport \"c.proto\";\n\nmessage B {}\n",
+		"c.proto": "syntax = \"proto3\";\npackage cycle;\nimport \"a.proto\";\n\nmessage C {}\n",
+		"d.proto": "syntax = \"proto3\";\npackage cycle;\nimport \"a.proto\";\n\nmessage D {\n  A a = 1;\n}\n",
+	})
+
+	want := []string{
+		"Import of 'b.proto' forms a cycle: a.proto -> b.proto -> c.proto -> a.proto",
+		"Import of 'c.proto' forms a cycle: b.proto -> c.proto -> a.proto -> b.proto",
+		"Import of 'a.proto' forms a cycle: c.proto -> a.proto -> b.proto -> c.proto",
+	}
+	if got := messages(violations); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Violations = %v, want %v", got, want)
+	}
+	if got := files(violations); !reflect.DeepEqual(got, []string{"a.proto", "b.proto", "c.proto"}) {
+		t.Errorf("Files = %v", got)
+	}
+	if violations[0].Position.Line != 3 {
+		t.Errorf("Reported on line %d, want the import on line 3", violations[0].Position.Line)
+	}
+}
+
+func TestImportUsedRule(t *testing.T) {
+	violations := checkModule(t, "import-used", map[string]string{
+		"acme/v1/common.proto": "syntax = \"proto3\";\npackage acme.v1;\n\nmessage Money {}\n",
+		"acme/v1/types.proto":  "syntax = \"proto3\";\npackage acme.v1;\n\nmessage Unused {}\n",
+		"acme/v1/order.proto": "syntax = \"proto3\";\npackage acme.v1;\n\n" +
+			"import \"acme/v1/common.proto\";\nimport \"acme/v1/types.proto\";\nimport \"google/protobuf/timestamp.proto\";\n\n" +
+			"message Order {\n  Money total = 1;\n}\n",
+	})
+
+	want := []string{
+		"Import 'acme/v1/types.proto' is not used",
+		"Import 'google/protobuf/timestamp.proto' is not used",
+	}
+	if got := messages(violations); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Violations = %v, want %v", got, want)
+	}
+	if violations[0].File != "acme/v1/order.proto" || violations[0].Position.Line != 5 {
+		t.Errorf("Reported in %s line %d, want acme/v1/order.proto line 5", violations[0].File, violations[0].Position.Line)
+	}
+}
+
+func TestPackageSameDirectoryRule(t *testing.T) {
+	violations := checkModule(t, "package-same-directory", map[string]string{
+		"acme/v1/a.proto":  "syntax = \"proto3\";\npackage acme.v1;\n",
+		"acme/v1/b.proto":  "syntax = \"proto3\";\npackage acme.v1;\n",
+		"other/v1/c.proto": "syntax = \"proto3\";\npackage acme.v1;\n",
+		"other/v2/d.proto": "syntax = \"proto3\";\npackage other.v2;\n",
+	})
+
+	if got := files(violations); !reflect.DeepEqual(got, []string{"acme/v1/a.proto", "acme/v1/b.proto", "other/v1/c.proto"}) {
+		t.Fatalf("Files = %v", got)
+	}
+	if want := "Package 'acme.v1' is split across directories acme/v1, other/v1"; violations[0].Message != want {
+		t.Errorf("Message = %q, want %q", violations[0].Message, want)
+	}
+}
+
+func TestSymbolUniqueRule(t *testing.T) {
+	violations := checkModule(t, "symbol-unique", map[string]string{
+		"acme/v1/a.proto": "syntax = \"proto3\";\npackage acme.v1;\n\nmessage User {}\n\nenum Role {\n  ROLE_UNSPECIFIED = 0;\n}\n",
+		"acme/v1/b.proto": "syntax = \"proto3\";\npackage acme.v1;\n\nmessage User {}\n\nmessage Team {}\n",
+		"acme/v2/c.proto": "syntax = \"proto3\";\npackage acme.v2;\n\nmessage User {}\n",
+	})
+
+	want := []string{"'acme.v1.User' is already declared in acme/v1/a.proto"}
+	if got := messages(violations); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Violations = %v, want %v", got, want)
+	}
+	if violations[0].File != "acme/v1/b.proto" || violations[0].Position.Line != 4 {
+		t.Errorf("Reported in %s line %d, want acme/v1/b.proto line 4", violations[0].File, violations[0].Position.Line)
+	}
+}
+
+func TestTypeReferencedRule(t *testing.T) {
+	violations := checkModule(t, "type-referenced", map[string]string{
+		"acme/v1/common.proto": "syntax = \"proto3\";\npackage acme.v1;\n\n" +
+			"message Money {}\n\nmessage Orphan {}\n\nenum Currency {\n  CURRENCY_UNSPECIFIED = 0;\n}\n",
+		"acme/v1/service.proto": "syntax = \"proto3\";\npackage acme.v1;\n\nimport \"acme/v1/common.proto\";\n\n" +
+			"service Billing {\n  rpc Charge(ChargeRequest) returns (ChargeResponse);\n}\n\n" +
+			"message ChargeRequest {\n  Money amount = 1;\n}\n\nmessage ChargeResponse {}\n",
+	})
+
+	want := []string{
+		"Message 'acme.v1.Orphan' is not referenced in the module",
+		"Enum 'acme.v1.Currency' is not referenced in the module",
+	}
+	if got := messages(violations); !reflect.DeepEqual(got, want) {
+		t.Errorf("Violations = %v, want %v", got, want)
+	}
+}
+
+func TestModuleRules_Check(t *testing.T) {
+	// Module rules report nothing from the per-file pass
+	for _, name := range []string{"import-no-cycle", "import-used", "package-same-directory", "symbol-unique", "type-referenced"} {
+		if violations := checkSource(t, findRule(t, name), "a.proto", "syntax = \"proto3\";\nimport \"google/protobuf/empty.proto\";\n"); len(violations) != 0 {
+			t.Errorf("%s: Check reported %v", name, messages(violations))
+		}
+	}
+}
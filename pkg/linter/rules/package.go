@@ -0,0 +1,193 @@
+package rules
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+var (
+	packageComponent = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	packageVersion   = regexp.MustCompile(`^v\d+((alpha|beta)\d*)?$`)
+)
+
+// PackageDefinedRule checks that every file declares a package
+type PackageDefinedRule struct {
+	BaseRule
+}
+
+// NewPackageDefinedRule creates a new package defined rule
+func NewPackageDefinedRule() *PackageDefinedRule {
+	return &PackageDefinedRule{
+		BaseRule: BaseRule{
+			RuleName:        "package-defined",
+			RuleCategory:    linter.CategoryStructure,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Files must declare a package",
+		},
+	}
+}
+
+// Check validates that a package is declared
+func (r *PackageDefinedRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	if node.Package != nil && node.Package.Name != "" {
+		return nil
+	}
+	return []linter.Violation{{
+		Rule:     r.Name(),
+		Severity: r.Severity(),
+		Category: r.Category(),
+		Message:  "File does not declare a package",
+		Position: filePosition(node),
+	}}
+}
+
+// PackageNamingRule checks that package names are lower case
+type PackageNamingRule struct {
+	BaseRule
+}
+
+// NewPackageNamingRule creates a new package naming rule
+func NewPackageNamingRule() *PackageNamingRule {
+	return &PackageNamingRule{
+		BaseRule: BaseRule{
+			RuleName:        "package-naming",
+			RuleCategory:    linter.CategoryNaming,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Package names must be dot-separated lower_snake_case components",
+		},
+	}
+}
+
+// Check validates the package name
+func (r *PackageNamingRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	if node.Package == nil || node.Package.Name == "" {
+		return nil
+	}
+	for _, component := range strings.Split(node.Package.Name, ".") {
+		if !packageComponent.MatchString(component) {
+			return []linter.Violation{{
+				Rule:     r.Name(),
+				Severity: r.Severity(),
+				Category: r.Category(),
+				Message:  "Package name '" + node.Package.Name + "' should be lower case, like '" + strings.ToLower(node.Package.Name) + "'",
+				Position: node.Package.Position(),
+			}}
+		}
+	}
+	return nil
+}
+
+// PackageVersionSuffixRule checks that packages end in a version, such as
+// foo.v1 or foo.v2beta1, so breaking changes can ship as a new package
+type PackageVersionSuffixRule struct {
+	BaseRule
+}
+
+// NewPackageVersionSuffixRule creates a new package version suffix rule
+func NewPackageVersionSuffixRule() *PackageVersionSuffixRule {
+	return &PackageVersionSuffixRule{
+		BaseRule: BaseRule{
+			RuleName:        "package-version-suffix",
+			RuleCategory:    linter.CategoryNaming,
+			RuleSeverity:    linter.SeverityWarning,
+			RuleDescription: "Package names must end in a version such as v1, v1beta1 or v2alpha",
+		},
+	}
+}
+
+// Check validates the package version suffix
+func (r *PackageVersionSuffixRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	if node.Package == nil || node.Package.Name == "" {
+		return nil
+	}
+	components := strings.Split(node.Package.Name, ".")
+	if len(components) > 1 && packageVersion.MatchString(components[len(components)-1]) {
+		return nil
+	}
+	return []linter.Violation{{
+		Rule:     r.Name(),
+		Severity: r.Severity(),
+		Category: r.Category(),
+		Message:  "Package name '" + node.Package.Name + "' should end in a version, like '" + node.Package.Name + ".v1'",
+		Position: node.Package.Position(),
+	}}
+}
+
+// PackageDirectoryMatchRule checks that a file lives in the directory that
+// matches its package, foo/v1 for foo.v1. Files of one directory thereby
+// share a single package.
+type PackageDirectoryMatchRule struct {
+	BaseRule
+}
+
+// NewPackageDirectoryMatchRule creates a new package directory match rule
+func NewPackageDirectoryMatchRule() *PackageDirectoryMatchRule {
+	return &PackageDirectoryMatchRule{
+		BaseRule: BaseRule{
+			RuleName:        "package-directory-match",
+			RuleCategory:    linter.CategoryStructure,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Files must be in a directory matching their package, one package per directory",
+		},
+	}
+}
+
+// Check validates the directory of the file against its package
+func (r *PackageDirectoryMatchRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	if node.Package == nil || node.Package.Name == "" || ctx == nil || ctx.FilePath == "" {
+		return nil
+	}
+
+	want := strings.ReplaceAll(node.Package.Name, ".", "/")
+	dir := path.Clean(filepath.ToSlash(filepath.Dir(ctx.FilePath)))
+	if dir == want || strings.HasSuffix(dir, "/"+want) {
+		return nil
+	}
+	return []linter.Violation{{
+		Rule:     r.Name(),
+		Severity: r.Severity(),
+		Category: r.Category(),
+		Message:  "File of package '" + node.Package.Name + "' should be in a directory ending in '" + want + "'",
+		Position: node.Package.Position(),
+	}}
+}
+
+// FileNamingRule checks that file names are lower_snake_case.proto
+type FileNamingRule struct {
+	BaseRule
+}
+
+// NewFileNamingRule creates a new file naming rule
+func NewFileNamingRule() *FileNamingRule {
+	return &FileNamingRule{
+		BaseRule: BaseRule{
+			RuleName:        "file-naming",
+			RuleCategory:    linter.CategoryNaming,
+			RuleSeverity:    linter.SeverityWarning,
+			RuleDescription: "File names must use lower_snake_case.proto",
+		},
+	}
+}
+
+// Check validates the file name
+func (r *FileNamingRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	if ctx == nil || ctx.FilePath == "" {
+		return nil
+	}
+	base := strings.TrimSuffix(filepath.Base(ctx.FilePath), ".proto")
+	if isSnakeCase(base) {
+		return nil
+	}
+	return []linter.Violation{{
+		Rule:     r.Name(),
+		Severity: r.Severity(),
+		Category: r.Category(),
+		Message:  "File name '" + filepath.Base(ctx.FilePath) + "' should be lower_snake_case, like '" + toSnakeCase(base) + ".proto'",
+		Position: filePosition(node),
+	}}
+}
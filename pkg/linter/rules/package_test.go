@@ -0,0 +1,45 @@
+package rules
+
+import (
+	"testing"
+)
+
+func TestPackageRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		filePath string
+		pkg      string
+		want     int
+	}{
+		{"defined", "package-defined", "foo/v1/a.proto", "foo.v1", 0},
+		{"not defined", "package-defined", "a.proto", "", 1},
+		{"lower case", "package-naming", "a.proto", "acme.billing_api.v1", 0},
+		{"upper case", "package-naming", "a.proto", "Acme.Billing", 1},
+		{"version suffix", "package-version-suffix", "a.proto", "acme.billing.v1", 0},
+		{"beta version suffix", "package-version-suffix", "a.proto", "acme.billing.v2beta1", 0},
+		{"no version suffix", "package-version-suffix", "a.proto", "acme.billing", 1},
+		{"version only", "package-version-suffix", "a.proto", "v1", 1},
+		{"directory matches", "package-directory-match", "proto/acme/billing/v1/a.proto", "acme.billing.v1", 0},
+		{"relative directory matches", "package-directory-match", "acme/v1/a.proto", "acme.v1", 0},
+		{"directory differs", "package-directory-match", "proto/acme/a.proto", "acme.billing.v1", 1},
+		{"partial directory name", "package-directory-match", "proto/xacme/v1/a.proto", "acme.v1", 1},
+		{"file name", "file-naming", "acme/billing_service.proto", "acme", 0},
+		{"camel case file name", "file-naming", "acme/BillingService.proto", "acme", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "syntax = \"proto3\";\n"
+			if tt.pkg != "" {
+				content += "package " + tt.pkg + ";\n"
+			}
+			content += "message Foo {}\n"
+
+			violations := checkSource(t, findRule(t, tt.rule), tt.filePath, content)
+			if len(violations) != tt.want {
+				t.Errorf("Expected %d violations, got %d: %v", tt.want, len(violations), messages(violations))
+			}
+		})
+	}
+}
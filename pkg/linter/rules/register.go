@@ -3,7 +3,8 @@ package rules
 import "github.com/platinummonkey/spoke/pkg/linter"
 
 // DefaultRules returns all built-in lint rules
-// Caller should register these with their registry
+// Caller should register these with their registry; lint.use in the config
+// selects which of them run (see linter.Presets)
 func DefaultRules() []linter.Rule {
 	return []linter.Rule{
 		// Naming rules
@@ -12,12 +13,44 @@ func DefaultRules() []linter.Rule {
 		NewServiceNamingRule(),
 		NewEnumNamingRule(),
 		NewEnumValueNamingRule(),
+		NewRPCNamingRule(),
+		NewPackageNamingRule(),
+		NewPackageVersionSuffixRule(),
+		NewFileNamingRule(),
+		NewEnumZeroValueSuffixRule(),
+		NewEnumValuePrefixRule(),
+		NewServiceSuffixRule(),
+		NewRPCRequestStandardNameRule(),
+		NewRPCResponseStandardNameRule(),
 
-		// TODO: Add more built-in rules:
-		// - Package naming
-		// - Comment requirements
-		// - Documentation coverage
-		// - Deprecation tracking
-		// - Structure rules
+		// Structure rules
+		NewPackageDefinedRule(),
+		NewPackageDirectoryMatchRule(),
+		NewRPCRequestResponseUniqueRule(),
+		NewDeprecatedReferenceRule(),
+
+		// Module rules, run over all files of a module together
+		NewImportNoCycleRule(),
+		NewImportUsedRule(),
+		NewPackageSameDirectoryRule(),
+		NewSymbolUniqueRule(),
+		NewTypeReferencedRule(),
+
+		// Documentation rules
+		NewMessageCommentRule(),
+		NewFieldCommentRule(),
+		NewEnumCommentRule(),
+		NewServiceCommentRule(),
+		NewRPCCommentRule(),
+		NewDocumentationCoverageRule(),
+		NewDeprecatedCommentRule(),
+
+		// AIP rules, opt-in through the aip preset
+		NewAIPStandardMethodRequestRule(),
+		NewAIPStandardMethodResponseRule(),
+		NewAIPPaginationRule(),
+		NewAIPUpdateFieldMaskRule(),
+		NewAIPResourcePatternRule(),
+		NewAIPHTTPBindingRule(),
 	}
 }
@@ -0,0 +1,203 @@
+package rules
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+// RPCNamingRule checks that RPC names follow PascalCase
+type RPCNamingRule struct {
+	BaseRule
+}
+
+// NewRPCNamingRule creates a new RPC naming rule
+func NewRPCNamingRule() *RPCNamingRule {
+	return &RPCNamingRule{
+		BaseRule: BaseRule{
+			RuleName:        "rpc-naming",
+			RuleCategory:    linter.CategoryNaming,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "RPC names must use PascalCase",
+			AutoFixable:     true,
+		},
+	}
+}
+
+// Check validates RPC names
+func (r *RPCNamingRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+
+	for _, svc := range node.Services {
+		for _, rpc := range svc.RPCs {
+			if isPascalCase(rpc.Name) {
+				continue
+			}
+			violations = append(violations, linter.Violation{
+				Rule:         r.Name(),
+				Severity:     r.Severity(),
+				Category:     r.Category(),
+				Message:      "RPC name '" + rpc.Name + "' should be PascalCase",
+				Position:     rpc.Position(),
+				SuggestedFix: linter.RenameFix(ctx, rpc, rpc.Name, toPascalCase(rpc.Name), "Convert to PascalCase"),
+			})
+		}
+	}
+
+	return violations
+}
+
+// AutoFix converts RPC names to PascalCase
+func (r *RPCNamingRule) AutoFix(node *protobuf.RootNode, violation linter.Violation) (*linter.Fix, error) {
+	return violation.SuggestedFix, nil
+}
+
+// RPCRequestResponseUniqueRule checks that every message is the request or
+// response of at most one RPC, so each RPC can evolve its messages on its
+// own
+type RPCRequestResponseUniqueRule struct {
+	BaseRule
+}
+
+// NewRPCRequestResponseUniqueRule creates a new RPC request/response
+// uniqueness rule
+func NewRPCRequestResponseUniqueRule() *RPCRequestResponseUniqueRule {
+	return &RPCRequestResponseUniqueRule{
+		BaseRule: BaseRule{
+			RuleName:        "rpc-request-response-unique",
+			RuleCategory:    linter.CategoryStructure,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Messages must be used as the request or response of only one RPC",
+		},
+	}
+}
+
+// Check reports messages shared between RPCs or used as both request and
+// response
+func (r *RPCRequestResponseUniqueRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	type use struct {
+		rpc  *protobuf.RPCNode
+		name string // Service.Method
+	}
+	uses := make(map[string][]use)
+	var order []string
+
+	scope := packageName(node)
+	for _, svc := range node.Services {
+		for _, rpc := range svc.RPCs {
+			method := svc.Name + "." + rpc.Name
+			for _, typeName := range []string{rpc.InputType, rpc.OutputType} {
+				name := canonicalType(scope, typeName)
+				if name == "" {
+					continue
+				}
+				if _, seen := uses[name]; !seen {
+					order = append(order, name)
+				}
+				uses[name] = append(uses[name], use{rpc: rpc, name: method})
+			}
+		}
+	}
+
+	violations := make([]linter.Violation, 0)
+	for _, typeName := range order {
+		list := uses[typeName]
+		if len(list) < 2 {
+			continue
+		}
+		methods := make([]string, 0, len(list))
+		for _, u := range list {
+			methods = append(methods, u.name)
+		}
+		sort.Strings(methods)
+		// Report at every use after the first, naming all of them
+		for _, u := range list[1:] {
+			violations = append(violations, linter.Violation{
+				Rule:     r.Name(),
+				Severity: r.Severity(),
+				Category: r.Category(),
+				Message:  "Message '" + typeName + "' is used by more than one RPC or as both request and response: " + strings.Join(methods, ", "),
+				Position: u.rpc.Position(),
+			})
+		}
+	}
+	return violations
+}
+
+// RPCStandardNameRule checks that RPC requests or responses are named after
+// their method, MethodRequest or ServiceMethodRequest
+type RPCStandardNameRule struct {
+	BaseRule
+	suffix   string
+	typeName func(rpc *protobuf.RPCNode) string
+}
+
+// NewRPCRequestStandardNameRule creates a rule requiring request messages
+// named MethodRequest or ServiceMethodRequest
+func NewRPCRequestStandardNameRule() *RPCStandardNameRule {
+	return newRPCStandardNameRule("rpc-request-standard-name", "Request", func(rpc *protobuf.RPCNode) string {
+		return rpc.InputType
+	})
+}
+
+// NewRPCResponseStandardNameRule creates a rule requiring response messages
+// named MethodResponse or ServiceMethodResponse
+func NewRPCResponseStandardNameRule() *RPCStandardNameRule {
+	return newRPCStandardNameRule("rpc-response-standard-name", "Response", func(rpc *protobuf.RPCNode) string {
+		return rpc.OutputType
+	})
+}
+
+func newRPCStandardNameRule(name, suffix string, typeName func(rpc *protobuf.RPCNode) string) *RPCStandardNameRule {
+	return &RPCStandardNameRule{
+		BaseRule: BaseRule{
+			RuleName:        name,
+			RuleCategory:    linter.CategoryNaming,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "RPC " + strings.ToLower(suffix) + " messages must be named Method" + suffix + " or ServiceMethod" + suffix,
+		},
+		suffix:   suffix,
+		typeName: typeName,
+	}
+}
+
+// Check validates request or response message names
+func (r *RPCStandardNameRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+
+	for _, svc := range node.Services {
+		for _, rpc := range svc.RPCs {
+			typeName := r.typeName(rpc)
+			if typeName == "" {
+				continue
+			}
+			short := typeName[strings.LastIndex(typeName, ".")+1:]
+			want := rpc.Name + r.suffix
+			if short == want || short == svc.Name+want {
+				continue
+			}
+			violations = append(violations, linter.Violation{
+				Rule:     r.Name(),
+				Severity: r.Severity(),
+				Category: r.Category(),
+				Message:  "RPC '" + rpc.Name + "' " + strings.ToLower(r.suffix) + " '" + short + "' should be named '" + want + "' or '" + svc.Name + want + "'",
+				Position: rpc.Position(),
+			})
+		}
+	}
+	return violations
+}
+
+// canonicalType returns a fully qualified type name without a leading dot,
+// qualifying names written without a package in the file's package
+func canonicalType(scope, typeName string) string {
+	if typeName == "" {
+		return ""
+	}
+	if strings.HasPrefix(typeName, ".") || strings.Contains(typeName, ".") {
+		return strings.TrimPrefix(typeName, ".")
+	}
+	return qualify(scope, typeName)
+}
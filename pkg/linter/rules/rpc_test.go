@@ -0,0 +1,62 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+const rpcProto = `syntax = "proto3";
+package acme.v1;
+
+message GetUserRequest {}
+message GetUserResponse {}
+message User {}
+message ListUsersRequest {}
+
+service UserService {
+  rpc GetUser(GetUserRequest) returns (GetUserResponse);
+  rpc list_users(ListUsersRequest) returns (User);
+  rpc UpdateUser(User) returns (User);
+  rpc DeleteUser(UserServiceDeleteUserRequest) returns (GetUserResponse);
+}
+
+message UserServiceDeleteUserRequest {}
+`
+
+func TestRPCNamingRule(t *testing.T) {
+	violations := checkSource(t, NewRPCNamingRule(), "acme/v1/user.proto", rpcProto)
+	if len(violations) != 1 {
+		t.Fatalf("Expected 1 violation, got %d: %v", len(violations), messages(violations))
+	}
+	fix := violations[0].SuggestedFix
+	if fix == nil || len(fix.Changes) != 1 || fix.Changes[0].NewText != "ListUsers" {
+		t.Errorf("Expected a rename fix to ListUsers, got %+v", fix)
+	}
+}
+
+func TestRPCRequestResponseUniqueRule(t *testing.T) {
+	violations := checkSource(t, NewRPCRequestResponseUniqueRule(), "acme/v1/user.proto", rpcProto)
+
+	// User is used by ListUsers and twice by UpdateUser, GetUserResponse by
+	// GetUser and DeleteUser
+	if len(violations) != 3 {
+		t.Fatalf("Expected 3 violations, got %d: %v", len(violations), messages(violations))
+	}
+	for _, msg := range messages(violations) {
+		if !strings.Contains(msg, "acme.v1.User'") && !strings.Contains(msg, "acme.v1.GetUserResponse'") {
+			t.Errorf("Unexpected violation: %s", msg)
+		}
+	}
+}
+
+func TestRPCStandardNameRules(t *testing.T) {
+	requests := checkSource(t, NewRPCRequestStandardNameRule(), "acme/v1/user.proto", rpcProto)
+	if len(requests) != 2 || !strings.Contains(requests[1].Message, "'UpdateUserRequest'") {
+		t.Errorf("Expected list_users and UpdateUser request violations, got %v", messages(requests))
+	}
+
+	responses := checkSource(t, NewRPCResponseStandardNameRule(), "acme/v1/user.proto", rpcProto)
+	if len(responses) != 3 {
+		t.Errorf("Expected 3 response violations, got %v", messages(responses))
+	}
+}
@@ -1,6 +1,8 @@
 package rules
 
 import (
+	"strings"
+
 	"github.com/platinummonkey/spoke/pkg/api/protobuf"
 	"github.com/platinummonkey/spoke/pkg/linter"
 )
@@ -31,23 +33,12 @@ func (r *ServiceNamingRule) Check(node *protobuf.RootNode, ctx *linter.LintConte
 		if !isPascalCase(svc.Name) {
 			pos := svc.Position()
 			violations = append(violations, linter.Violation{
-				Rule:     r.Name(),
-				Severity: r.Severity(),
-				Category: r.Category(),
-				Message:  "Service name '" + svc.Name + "' should be PascalCase",
-				Position: pos,
-				SuggestedFix: &linter.Fix{
-					Description: "Convert to PascalCase",
-					Changes: []linter.Change{
-						{
-							FilePath: "",
-							StartPos: pos,
-							EndPos:   pos,
-							OldText:  svc.Name,
-							NewText:  toPascalCase(svc.Name),
-						},
-					},
-				},
+				Rule:         r.Name(),
+				Severity:     r.Severity(),
+				Category:     r.Category(),
+				Message:      "Service name '" + svc.Name + "' should be PascalCase",
+				Position:     pos,
+				SuggestedFix: linter.RenameFix(ctx, svc, svc.Name, toPascalCase(svc.Name), "Convert to PascalCase"),
 			})
 		}
 	}
@@ -59,3 +50,47 @@ func (r *ServiceNamingRule) Check(node *protobuf.RootNode, ctx *linter.LintConte
 func (r *ServiceNamingRule) AutoFix(node *protobuf.RootNode, violation linter.Violation) (*linter.Fix, error) {
 	return violation.SuggestedFix, nil
 }
+
+// ServiceSuffixRule checks that service names end in Service
+type ServiceSuffixRule struct {
+	BaseRule
+}
+
+// NewServiceSuffixRule creates a new service suffix rule
+func NewServiceSuffixRule() *ServiceSuffixRule {
+	return &ServiceSuffixRule{
+		BaseRule: BaseRule{
+			RuleName:        "service-suffix",
+			RuleCategory:    linter.CategoryNaming,
+			RuleSeverity:    linter.SeverityError,
+			RuleDescription: "Service names must end in Service",
+			AutoFixable:     true,
+		},
+	}
+}
+
+// Check validates service name suffixes
+func (r *ServiceSuffixRule) Check(node *protobuf.RootNode, ctx *linter.LintContext) []linter.Violation {
+	violations := make([]linter.Violation, 0)
+
+	for _, svc := range node.Services {
+		if strings.HasSuffix(svc.Name, "Service") {
+			continue
+		}
+		violations = append(violations, linter.Violation{
+			Rule:         r.Name(),
+			Severity:     r.Severity(),
+			Category:     r.Category(),
+			Message:      "Service name '" + svc.Name + "' should end in Service",
+			Position:     svc.Position(),
+			SuggestedFix: linter.RenameFix(ctx, svc, svc.Name, svc.Name+"Service", "Add suffix Service"),
+		})
+	}
+
+	return violations
+}
+
+// AutoFix adds the Service suffix
+func (r *ServiceSuffixRule) AutoFix(node *protobuf.RootNode, violation linter.Violation) (*linter.Fix, error) {
+	return violation.SuggestedFix, nil
+}
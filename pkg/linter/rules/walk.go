@@ -0,0 +1,68 @@
+package rules
+
+import (
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+)
+
+// forEachMessage calls fn for every message of a file, nested messages
+// included, with its fully qualified name
+func forEachMessage(node *protobuf.RootNode, fn func(msg *protobuf.MessageNode, fullName string)) {
+	var walk func(msgs []*protobuf.MessageNode, scope string)
+	walk = func(msgs []*protobuf.MessageNode, scope string) {
+		for _, msg := range msgs {
+			name := qualify(scope, msg.Name)
+			fn(msg, name)
+			walk(msg.Nested, name)
+		}
+	}
+	walk(node.Messages, packageName(node))
+}
+
+// forEachEnum calls fn for every enum of a file, enums nested in messages
+// included, with its fully qualified name
+func forEachEnum(node *protobuf.RootNode, fn func(enum *protobuf.EnumNode, fullName string)) {
+	scope := packageName(node)
+	for _, enum := range node.Enums {
+		fn(enum, qualify(scope, enum.Name))
+	}
+	forEachMessage(node, func(msg *protobuf.MessageNode, fullName string) {
+		for _, enum := range msg.Enums {
+			fn(enum, qualify(fullName, enum.Name))
+		}
+	})
+}
+
+func packageName(node *protobuf.RootNode) string {
+	if node.Package == nil {
+		return ""
+	}
+	return node.Package.Name
+}
+
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// filePosition is where violations about the file as a whole are reported
+func filePosition(node *protobuf.RootNode) protobuf.Position {
+	switch {
+	case node.Package != nil:
+		return node.Package.Position()
+	case node.Syntax != nil:
+		return node.Syntax.Position()
+	}
+	return protobuf.Position{Line: 1, Column: 1}
+}
+
+// isDeprecated reports whether options mark a declaration deprecated
+func isDeprecated(options []*protobuf.OptionNode) bool {
+	for _, opt := range options {
+		if opt.Name == "deprecated" && opt.Value == "true" {
+			return true
+		}
+	}
+	return false
+}
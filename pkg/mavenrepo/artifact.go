@@ -0,0 +1,248 @@
+package mavenrepo
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+	"golang.org/x/mod/semver"
+)
+
+// jarTime is the modification time of every jar entry, the earliest a zip
+// can hold, so jars of a version never change
+var jarTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// manifest is the META-INF/MANIFEST.MF of every jar
+const manifest = "Manifest-Version: 1.0\r\nCreated-By: Spoke\r\n\r\n"
+
+// sourceExtensions are the extensions of the files sources jars hold
+var sourceExtensions = map[string]bool{".java": true, ".kt": true}
+
+// artifactVersion is a version of a Spoke module served as a Maven version
+type artifactVersion struct {
+	mavenVersion string
+	version      *api.Version
+}
+
+// mavenVersion returns the Maven version of a Spoke version, dropping the
+// leading v, or "" when the version is no canonical semantic version or is
+// a snapshot, whose layout Maven resolves differently
+func mavenVersion(version string) string {
+	v := packages.SemVer(version)
+	if !semver.IsValid("v"+v) || semver.Canonical("v"+v) != "v"+v {
+		return ""
+	}
+	if strings.EqualFold(semver.Prerelease("v"+v), "-SNAPSHOT") {
+		return ""
+	}
+	return v
+}
+
+// resolve returns the Spoke module an artifactId is served from
+func (h *Handlers) resolve(artifactID string) (string, error) {
+	candidate := strings.TrimSuffix(artifactID, h.config.ArtifactSuffix)
+	name, ok, err := compiled.FindModule(h.storage, artifactID, candidate, h.config.ArtifactID)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: %s:%s", ErrArtifactNotFound, h.config.GroupID, artifactID)
+	}
+	return name, nil
+}
+
+// versions returns the versions of a module served as Maven versions, in
+// semantic version order
+func (h *Handlers) versions(name string) ([]artifactVersion, error) {
+	versions, err := h.storage.ListVersions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []artifactVersion
+	for _, v := range versions {
+		if mv := mavenVersion(v.Version); mv != "" {
+			result = append(result, artifactVersion{mavenVersion: mv, version: v})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return semver.Compare("v"+result[i].mavenVersion, "v"+result[j].mavenVersion) < 0
+	})
+	return result, nil
+}
+
+// lookup returns the version of a module served as a Maven version
+func (h *Handlers) lookup(name, mv string) (*api.Version, error) {
+	versions, err := h.versions(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.mavenVersion == mv {
+			return h.storage.GetVersion(name, v.version.Version)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s:%s", ErrVersionNotFound, name, mv)
+}
+
+// metadata returns the maven-metadata.xml of an artifact. Its release is
+// the highest release, or the highest pre-release when there are no
+// releases; latest is the highest version.
+func (h *Handlers) metadata(artifactID, name string) ([]byte, error) {
+	versions, err := h.versions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := Metadata{GroupID: h.config.GroupID, ArtifactID: artifactID}
+	var updated time.Time
+	for _, v := range versions {
+		doc.Versioning.Versions = append(doc.Versioning.Versions, v.mavenVersion)
+		doc.Versioning.Latest = v.mavenVersion
+		if semver.Prerelease("v"+v.mavenVersion) == "" {
+			doc.Versioning.Release = v.mavenVersion
+		}
+		if v.version.CreatedAt.After(updated) {
+			updated = v.version.CreatedAt
+		}
+	}
+	if doc.Versioning.Release == "" {
+		doc.Versioning.Release = doc.Versioning.Latest
+	}
+	if !updated.IsZero() {
+		doc.Versioning.LastUpdated = updated.UTC().Format("20060102150405")
+	}
+
+	content, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(content, '\n')...), nil
+}
+
+// packageFiles returns the files the maven package generator writes for a
+// version, by path: its pom.xml, naming the artifact and the artifacts of
+// the version's dependencies, and README.md
+func (h *Handlers) packageFiles(version *api.Version) (map[string][]byte, error) {
+	req := &packages.GenerateRequest{
+		ModuleName:  version.ModuleName,
+		Version:     mavenVersion(version.Version),
+		Language:    string(h.config.Language),
+		IncludeGRPC: h.config.IncludeGRPC,
+		Options:     map[string]string{packages.OptionPackageName: h.config.Coordinates(version.ModuleName)},
+	}
+	for _, dep := range version.Dependencies {
+		name, v, ok := strings.Cut(dep, "@")
+		if !ok {
+			continue
+		}
+		if mv := mavenVersion(v); mv != "" {
+			req.Dependencies = append(req.Dependencies, packages.Dependency{
+				Name:    h.config.Coordinates(name),
+				Version: mv,
+				Module:  name,
+			})
+		}
+	}
+
+	generated, err := h.generator.Generate(req)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte, len(generated))
+	for _, file := range generated {
+		files[file.Path] = file.Content
+	}
+	if _, ok := files["pom.xml"]; !ok {
+		return nil, fmt.Errorf("maven package generator wrote no pom.xml")
+	}
+	return files, nil
+}
+
+// jar returns the jar, or sources jar, of a version, compiling the version
+// first when it has not been compiled for the artifact
+func (h *Handlers) jar(ctx context.Context, artifactID string, version *api.Version, sources bool) ([]byte, error) {
+	pkgFiles, err := h.packageFiles(version)
+	if err != nil {
+		return nil, err
+	}
+	coordinates := h.config.GroupID + ":" + artifactID
+	files, err := h.source.Files(ctx, version, compiled.Target{
+		Language:    h.config.Language,
+		PackageName: coordinates,
+		IncludeGRPC: h.config.IncludeGRPC,
+		Options:     map[string]string{packages.OptionPackageName: coordinates},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	jar, err := buildJar(h.config.GroupID, artifactID, mavenVersion(version.Version), pkgFiles, files, sources)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s:%s", err, coordinates, mavenVersion(version.Version))
+	}
+	return jar, nil
+}
+
+// buildJar packs the compiled code of a version into a jar. Jars hold every
+// compiled file but the package generator's, whose pom.xml is packed under
+// META-INF/maven as Maven packs it; sources jars hold the Java and Kotlin
+// sources. Entries are written in path order after the manifest, with fixed
+// times, so the jar and its checksums never change.
+func buildJar(groupID, artifactID, mv string, pkgFiles map[string][]byte, files []api.File, sources bool) ([]byte, error) {
+	contents := make(map[string][]byte)
+	for _, file := range files {
+		name := path.Clean(strings.TrimPrefix(file.Path, "/"))
+		if _, ok := pkgFiles[name]; ok || name == "." || strings.HasPrefix(name, "../") {
+			continue
+		}
+		if sources && !sourceExtensions[path.Ext(name)] {
+			continue
+		}
+		contents[name] = []byte(file.Content)
+	}
+	if len(contents) == 0 {
+		return nil, ErrNoCode
+	}
+	if !sources {
+		metaDir := "META-INF/maven/" + groupID + "/" + artifactID + "/"
+		contents[metaDir+"pom.xml"] = pkgFiles["pom.xml"]
+		contents[metaDir+"pom.properties"] = []byte(fmt.Sprintf("groupId=%s\nartifactId=%s\nversion=%s\n", groupID, artifactID, mv))
+	}
+	delete(contents, "META-INF/MANIFEST.MF")
+
+	names := make([]string, 0, len(contents)+1)
+	for name := range contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	names = append([]string{"META-INF/MANIFEST.MF"}, names...)
+	contents["META-INF/MANIFEST.MF"] = []byte(manifest)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: jarTime}
+		header.SetMode(0644)
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(contents[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
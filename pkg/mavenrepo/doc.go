@@ -0,0 +1,41 @@
+// Package mavenrepo serves the Java or Kotlin code generated for modules in
+// the Maven 2 repository layout, so Maven and Gradle fetch it from Spoke
+// directly without a separate deploy step.
+//
+// # Coordinates
+//
+// Modules are served under a configured groupId, with an optional artifactId
+// suffix. With groupId com.ourorg.proto and suffix "-proto", module
+// "payments" is the artifact com.ourorg.proto:payments-proto, and its version
+// v1.4.0 is the Maven version 1.4.0. Versions that are not canonical semantic
+// versions, and snapshots, are not served.
+//
+// # Repository Layout
+//
+// Handlers registers the repository under /maven/, with the groupId as
+// directories:
+//
+//	GET /maven/com/ourorg/proto/payments-proto/maven-metadata.xml
+//	GET /maven/com/ourorg/proto/payments-proto/1.4.0/payments-proto-1.4.0.pom
+//	GET /maven/com/ourorg/proto/payments-proto/1.4.0/payments-proto-1.4.0.jar
+//	GET /maven/com/ourorg/proto/payments-proto/1.4.0/payments-proto-1.4.0-sources.jar
+//
+// Every file has .md5, .sha1, .sha256 and .sha512 checksums next to it.
+// maven-metadata.xml lists the versions; its release is the highest
+// release, or the highest pre-release when there are no releases.
+//
+// # Artifacts
+//
+// The pom is written by the maven package generator, naming the artifact
+// and depending on the exact versions of the artifacts of the version's
+// dependencies. Jars hold the files compiled for the version, except the
+// generator's, with the pom under META-INF/maven as Maven packs it. protoc
+// generates sources, so jars hold .class files only when the compiler image
+// compiles them; sources jars hold the .java and .kt files either way.
+//
+// When a version has no code compiled for its artifact, the first download
+// of a jar compiles it through the orchestrator, and the compilation is
+// stored with the version and served from then on. Jar entries are written
+// in path order with fixed times, so every download of a version, and its
+// checksums, are byte for byte the same.
+package mavenrepo
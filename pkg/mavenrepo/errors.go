@@ -0,0 +1,17 @@
+package mavenrepo
+
+import "errors"
+
+var (
+	// ErrArtifactNotFound is returned for paths outside the configured
+	// groupId or naming no Spoke module
+	ErrArtifactNotFound = errors.New("artifact not found")
+
+	// ErrVersionNotFound is returned for versions the module does not have,
+	// or that are not semantic versions
+	ErrVersionNotFound = errors.New("version not found")
+
+	// ErrNoCode is returned when compiling a version produced no code to
+	// package
+	ErrNoCode = errors.New("no generated code")
+)
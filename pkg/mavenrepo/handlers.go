@@ -0,0 +1,145 @@
+package mavenrepo
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages/maven"
+)
+
+// PathPrefix is where the repository is served; Maven and Gradle
+// repository URLs point at it
+const PathPrefix = "/maven/"
+
+// checksums maps the extensions of checksum files to their hash
+var checksums = map[string]func() hash.Hash{
+	".md5":    md5.New,
+	".sha1":   sha1.New,
+	".sha256": sha256.New,
+	".sha512": sha512.New,
+}
+
+// Handlers serves the Maven 2 repository layout
+type Handlers struct {
+	storage   api.Storage
+	source    *compiled.Source
+	generator *maven.Generator
+	config    *Config
+}
+
+// NewHandlers creates Maven repository handlers. Versions without code
+// compiled for their artifact are compiled on demand by compiler; with a
+// nil compiler only compiled versions can be downloaded.
+func NewHandlers(storage api.Storage, compiler orchestrator.Orchestrator, config *Config) *Handlers {
+	return &Handlers{
+		storage:   storage,
+		source:    compiled.NewSource(storage, compiler),
+		generator: maven.NewGenerator(),
+		config:    config,
+	}
+}
+
+// RegisterRoutes registers the repository routes
+func (h *Handlers) RegisterRoutes(router *mux.Router) {
+	router.PathPrefix(PathPrefix).HandlerFunc(h.serve).Methods("GET", "HEAD")
+}
+
+// serve handles GET and HEAD of /maven/{group}/{artifactId}/maven-metadata.xml
+// and /maven/{group}/{artifactId}/{version}/{file}, where {file} is the pom,
+// jar or sources jar of the version, and of the checksums of each
+func (h *Handlers) serve(w http.ResponseWriter, r *http.Request) {
+	rest, ok := strings.CutPrefix(r.URL.Path, PathPrefix+h.config.groupPath()+"/")
+	if !ok {
+		writeError(w, ErrArtifactNotFound)
+		return
+	}
+
+	var newHash func() hash.Hash
+	for ext, hashFunc := range checksums {
+		if base, ok := strings.CutSuffix(rest, ext); ok {
+			rest, newHash = base, hashFunc
+			break
+		}
+	}
+
+	content, contentType, err := h.file(r, strings.Split(rest, "/"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if newHash != nil {
+		sum := newHash()
+		sum.Write(content)
+		content, contentType = []byte(hex.EncodeToString(sum.Sum(nil))), "text/plain; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(content)
+}
+
+// file returns a repository file and its content type, given its path
+// below the groupId directory
+func (h *Handlers) file(r *http.Request, parts []string) ([]byte, string, error) {
+	switch {
+	case len(parts) == 2 && parts[1] == "maven-metadata.xml":
+		name, err := h.resolve(parts[0])
+		if err != nil {
+			return nil, "", err
+		}
+		content, err := h.metadata(parts[0], name)
+		return content, "text/xml; charset=utf-8", err
+
+	case len(parts) == 3:
+		artifactID, mv, file := parts[0], parts[1], parts[2]
+		name, err := h.resolve(artifactID)
+		if err != nil {
+			return nil, "", err
+		}
+		suffix, ok := strings.CutPrefix(file, artifactID+"-"+mv)
+		if !ok || (suffix != ".pom" && suffix != ".jar" && suffix != "-sources.jar") {
+			return nil, "", ErrArtifactNotFound
+		}
+		version, err := h.lookup(name, mv)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if suffix == ".pom" {
+			pkgFiles, err := h.packageFiles(version)
+			if err != nil {
+				return nil, "", err
+			}
+			return pkgFiles["pom.xml"], "text/xml; charset=utf-8", nil
+		}
+		content, err := h.jar(r.Context(), artifactID, version, suffix == "-sources.jar")
+		return content, "application/java-archive", err
+	}
+	return nil, "", ErrArtifactNotFound
+}
+
+// writeError writes an error as plain text. Missing artifacts and versions
+// are 404 Not Found, so Maven and Gradle move on to their other
+// repositories.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrArtifactNotFound), errors.Is(err, ErrVersionNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNoCode):
+		// The version has nothing to download, now or later
+		status = http.StatusGone
+	case errors.Is(err, compiled.ErrCompilerUnavailable):
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,322 @@
+package mavenrepo
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled/compiledtest"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generate generates a Java class per proto file, along with package
+// files of its own
+func generate(req *orchestrator.CompileRequest) *codegen.CompilationResult {
+	result := &codegen.CompilationResult{Language: req.Language, Success: true}
+	for _, file := range req.ProtoFiles {
+		name := strings.TrimSuffix(file.Path, ".proto")
+		result.GeneratedFiles = append(result.GeneratedFiles, codegen.GeneratedFile{
+			Path:    "com/ourorg/" + name + "/Proto.java",
+			Content: []byte("// Module " + req.ModuleName + " " + req.Version + "\n"),
+		})
+	}
+	result.PackageFiles = []codegen.GeneratedFile{
+		{Path: "pom.xml", Content: []byte("<project/>")},
+		{Path: "README.md", Content: []byte(time.Now().String())},
+	}
+	return result
+}
+
+func newCompiler() *compiledtest.Compiler {
+	return &compiledtest.Compiler{Generate: generate}
+}
+
+const artifactDir = "/maven/com/ourorg/proto/payments-proto/"
+
+func testConfig() *Config {
+	cfg := DefaultConfig("com.ourorg.proto")
+	cfg.ArtifactSuffix = "-proto"
+	return cfg
+}
+
+func newTestRouter(storage api.Storage, compiler orchestrator.Orchestrator) *mux.Router {
+	router := mux.NewRouter()
+	NewHandlers(storage, compiler, testConfig()).RegisterRoutes(router)
+	return router
+}
+
+func get(t *testing.T, router http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+// unzip returns the entry names and files of a jar
+func unzip(t *testing.T, jar []byte) ([]string, map[string]string) {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(jar), int64(len(jar)))
+	require.NoError(t, err)
+	var names []string
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		assert.True(t, f.Modified.Equal(jarTime), f.Name)
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		names = append(names, f.Name)
+		files[f.Name] = string(content)
+	}
+	return names, files
+}
+
+func TestConfig_Coordinates(t *testing.T) {
+	cfg := testConfig()
+	assert.Equal(t, "payments-proto", cfg.ArtifactID("payments"))
+	assert.Equal(t, "user-service-proto", cfg.ArtifactID("User_Service"))
+	assert.Equal(t, "com.ourorg.proto:acme-orders-proto", cfg.Coordinates("acme/orders"))
+	assert.Equal(t, "com/ourorg/proto", cfg.groupPath())
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, testConfig().Validate())
+
+	invalid := []func(*Config){
+		func(c *Config) { c.GroupID = "" },
+		func(c *Config) { c.GroupID = "com..ourorg" },
+		func(c *Config) { c.GroupID = "com/ourorg" },
+		func(c *Config) { c.ArtifactSuffix = "/proto" },
+		func(c *Config) { c.Language = api.LanguagePython },
+	}
+	for i, modify := range invalid {
+		cfg := testConfig()
+		modify(cfg)
+		assert.Error(t, cfg.Validate(), i)
+	}
+
+	cfg := testConfig()
+	cfg.Language = api.LanguageKotlin
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestMavenVersion(t *testing.T) {
+	tests := map[string]string{
+		"v1.2.3":          "1.2.3",
+		"1.2.3":           "1.2.3",
+		"v1.2.3-beta.1":   "1.2.3-beta.1",
+		"v1.2.3-SNAPSHOT": "",
+		"v1.2":            "",
+		"v1.2.3+build":    "",
+		"abc1234":         "",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, mavenVersion(input), input)
+	}
+}
+
+func TestHandlers_Metadata(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.1.0")
+	storage.Add("payments", "v1.0.0")
+	storage.Add("payments", "v2.0.0-beta.1")
+	storage.Add("payments", "abc1234")
+	storage.Add("drafts", "v0.1.0-alpha")
+	router := newTestRouter(storage, newCompiler())
+
+	w := get(t, router, artifactDir+"maven-metadata.xml")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>com.ourorg.proto</groupId>
+  <artifactId>payments-proto</artifactId>
+  <versioning>
+    <latest>2.0.0-beta.1</latest>
+    <release>1.1.0</release>
+    <versions>
+      <version>1.0.0</version>
+      <version>1.1.0</version>
+      <version>2.0.0-beta.1</version>
+    </versions>
+    <lastUpdated>20260301140000</lastUpdated>
+  </versioning>
+</metadata>
+`, w.Body.String())
+
+	w = get(t, router, "/maven/com/ourorg/proto/drafts-proto/maven-metadata.xml")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var doc Metadata
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "0.1.0-alpha", doc.Versioning.Release)
+}
+
+func TestHandlers_Pom(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("common", "v1.0.0")
+	storage.Add("payments", "v1.0.0", "common@v1.0.0", "scratch@abc1234")
+	router := newTestRouter(storage, newCompiler())
+
+	w := get(t, router, artifactDir+"1.0.0/payments-proto-1.0.0.pom")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	pom := w.Body.String()
+	assert.Contains(t, pom, "<groupId>com.ourorg.proto</groupId>\n    <artifactId>payments-proto</artifactId>\n    <version>1.0.0</version>")
+	assert.Contains(t, pom, "<groupId>com.ourorg.proto</groupId>\n            <artifactId>common-proto</artifactId>\n            <version>1.0.0</version>", "dependencies are the artifacts of dependency modules")
+	assert.NotContains(t, pom, "scratch")
+	assert.NotContains(t, pom, "io.grpc")
+}
+
+func TestHandlers_Jar(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("common", "v1.0.0")
+	storage.Add("payments", "v1.0.0", "common@v1.0.0")
+	compiler := newCompiler()
+	router := newTestRouter(storage, compiler)
+
+	w := get(t, router, artifactDir+"1.0.0/payments-proto-1.0.0.jar")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/java-archive", w.Header().Get("Content-Type"))
+	jar := w.Body.Bytes()
+
+	names, files := unzip(t, jar)
+	assert.Equal(t, []string{
+		"META-INF/MANIFEST.MF",
+		"META-INF/maven/com.ourorg.proto/payments-proto/pom.properties",
+		"META-INF/maven/com.ourorg.proto/payments-proto/pom.xml",
+		"com/ourorg/payments/Proto.java",
+	}, names, "the generator's files are left out, with the pom under META-INF")
+	assert.Equal(t, "groupId=com.ourorg.proto\nartifactId=payments-proto\nversion=1.0.0\n", files["META-INF/maven/com.ourorg.proto/payments-proto/pom.properties"])
+	assert.Contains(t, files["META-INF/maven/com.ourorg.proto/payments-proto/pom.xml"], "<artifactId>payments-proto</artifactId>")
+
+	// The compilation targets the artifact and is stored
+	require.Equal(t, 1, compiler.Calls())
+	req := compiler.Requests()[0]
+	assert.Equal(t, "java", req.Language)
+	assert.Equal(t, "com.ourorg.proto:payments-proto", req.Options[packages.OptionPackageName])
+	require.Len(t, req.Dependencies, 1)
+	assert.Equal(t, 1, storage.Updates())
+
+	// The sources jar and later downloads are served from the stored
+	// compilation, byte for byte the same
+	w = get(t, router, artifactDir+"1.0.0/payments-proto-1.0.0-sources.jar")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	names, _ = unzip(t, w.Body.Bytes())
+	assert.Equal(t, []string{"META-INF/MANIFEST.MF", "com/ourorg/payments/Proto.java"}, names)
+
+	w = get(t, router, artifactDir+"1.0.0/payments-proto-1.0.0.jar")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, jar, w.Body.Bytes())
+	assert.Equal(t, 1, compiler.Calls())
+}
+
+func TestHandlers_Checksums(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.0.0")
+	router := newTestRouter(storage, newCompiler())
+
+	for _, file := range []string{"maven-metadata.xml", "1.0.0/payments-proto-1.0.0.pom", "1.0.0/payments-proto-1.0.0.jar"} {
+		content := get(t, router, artifactDir+file).Body.Bytes()
+
+		w := get(t, router, artifactDir+file+".sha1")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		sha1sum := sha1.Sum(content)
+		assert.Equal(t, hex.EncodeToString(sha1sum[:]), w.Body.String(), file)
+
+		w = get(t, router, artifactDir+file+".md5")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		md5sum := md5.Sum(content)
+		assert.Equal(t, hex.EncodeToString(md5sum[:]), w.Body.String(), file)
+
+		for _, ext := range []string{".sha256", ".sha512"} {
+			w = get(t, router, artifactDir+file+ext)
+			assert.Equal(t, http.StatusOK, w.Code, file+ext)
+		}
+	}
+
+	// HEAD requests are answered too, as Gradle makes them
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("HEAD", artifactDir+"1.0.0/payments-proto-1.0.0.pom", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestHandlers_Jar_ConcurrentDownloadsCompileOnce(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.0.0")
+	compiler := newCompiler()
+	router := newTestRouter(storage, compiler)
+
+	var wg sync.WaitGroup
+	bodies := make([][]byte, 8)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", artifactDir+"1.0.0/payments-proto-1.0.0.jar", nil))
+			bodies[i] = w.Body.Bytes()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, body := range bodies[1:] {
+		assert.Equal(t, bodies[0], body)
+	}
+	assert.Equal(t, 1, compiler.Calls())
+	assert.Equal(t, 1, storage.Updates())
+}
+
+func TestHandlers_Errors(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.0.0")
+	storage.Add("empty", "v1.0.0").CompilationInfo = []api.CompilationInfo{{
+		Language:    api.LanguageJava,
+		PackageName: "com.ourorg.proto:empty-proto",
+		Files:       []api.File{{Path: "pom.xml", Content: "<project/>"}},
+	}}
+	jar := artifactDir + "1.0.0/payments-proto-1.0.0.jar"
+
+	tests := []struct {
+		name     string
+		compiler orchestrator.Orchestrator
+		path     string
+		status   int
+	}{
+		{"other group", newCompiler(), "/maven/com/acme/payments-proto/maven-metadata.xml", http.StatusNotFound},
+		{"unknown artifact", newCompiler(), "/maven/com/ourorg/proto/orders-proto/maven-metadata.xml", http.StatusNotFound},
+		{"missing suffix", newCompiler(), "/maven/com/ourorg/proto/payments/maven-metadata.xml", http.StatusNotFound},
+		{"unknown version", newCompiler(), artifactDir + "1.9.0/payments-proto-1.9.0.pom", http.StatusNotFound},
+		{"mismatched version", newCompiler(), artifactDir + "1.0.0/payments-proto-1.9.0.pom", http.StatusNotFound},
+		{"unknown file", newCompiler(), artifactDir + "1.0.0/payments-proto-1.0.0.war", http.StatusNotFound},
+		{"unknown checksum", newCompiler(), artifactDir + "1.0.0/payments-proto-1.0.0.pom.asc", http.StatusNotFound},
+		{"compile failure", &compiledtest.Compiler{Err: errors.New("protoc exploded")}, jar, http.StatusInternalServerError},
+		{"no compiler", nil, jar, http.StatusServiceUnavailable},
+		{"no compiler checksum", nil, jar + ".sha1", http.StatusServiceUnavailable},
+		{"no code", nil, "/maven/com/ourorg/proto/empty-proto/1.0.0/empty-proto-1.0.0.jar", http.StatusGone},
+		{"pom without compiler", nil, artifactDir + "1.0.0/payments-proto-1.0.0.pom", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(t, newTestRouter(storage, tt.compiler), tt.path)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+}
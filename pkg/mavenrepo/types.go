@@ -0,0 +1,95 @@
+package mavenrepo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+)
+
+// Config holds Maven repository configuration
+type Config struct {
+	// GroupID is the groupId artifacts are served under: module "payments"
+	// is the artifact GroupID:payments
+	GroupID string
+
+	// ArtifactSuffix is appended to artifactIds, such as "-proto" for
+	// payments-proto
+	ArtifactSuffix string
+
+	// Language is the language artifacts are compiled for, java or kotlin
+	Language api.Language
+
+	// IncludeGRPC generates gRPC service code along with the messages when
+	// compiling on demand
+	IncludeGRPC bool
+}
+
+// DefaultConfig returns the default configuration for a groupId
+func DefaultConfig(groupID string) *Config {
+	return &Config{
+		GroupID:  groupID,
+		Language: api.LanguageJava,
+	}
+}
+
+var (
+	// groupID matches dot-separated Java identifiers, as groupIds are
+	groupID = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+	// artifactSuffix matches the characters artifactIds are made of
+	artifactSuffix = regexp.MustCompile(`^[a-z0-9._-]*$`)
+)
+
+// Validate checks that the groupId is valid and the language can be
+// packaged for Maven
+func (c *Config) Validate() error {
+	if !groupID.MatchString(c.GroupID) {
+		return fmt.Errorf("invalid groupId %q", c.GroupID)
+	}
+	if !artifactSuffix.MatchString(c.ArtifactSuffix) {
+		return fmt.Errorf("invalid artifactId suffix %q", c.ArtifactSuffix)
+	}
+	switch c.Language {
+	case api.LanguageJava, api.LanguageKotlin:
+	default:
+		return fmt.Errorf("unsupported Maven artifact language %q: must be java or kotlin", c.Language)
+	}
+	return nil
+}
+
+// ArtifactID returns the artifactId a Spoke module is served as
+func (c *Config) ArtifactID(moduleName string) string {
+	name := strings.ToLower(moduleName)
+	name = strings.NewReplacer("_", "-", " ", "-", "/", "-").Replace(name)
+	return name + c.ArtifactSuffix
+}
+
+// Coordinates returns the groupId:artifactId a Spoke module is served as
+func (c *Config) Coordinates(moduleName string) string {
+	return c.GroupID + ":" + c.ArtifactID(moduleName)
+}
+
+// groupPath returns the repository directory of the groupId
+func (c *Config) groupPath() string {
+	return strings.ReplaceAll(c.GroupID, ".", "/")
+}
+
+// Metadata is the maven-metadata.xml served for an artifact, listing its
+// versions
+type Metadata struct {
+	XMLName    xml.Name   `xml:"metadata"`
+	GroupID    string     `xml:"groupId"`
+	ArtifactID string     `xml:"artifactId"`
+	Versioning Versioning `xml:"versioning"`
+}
+
+// Versioning lists the versions of an artifact
+type Versioning struct {
+	Latest      string   `xml:"latest,omitempty"`
+	Release     string   `xml:"release,omitempty"`
+	Versions    []string `xml:"versions>version"`
+	LastUpdated string   `xml:"lastUpdated,omitempty"` // yyyyMMddHHmmss, UTC
+}
@@ -0,0 +1,45 @@
+// Package npmregistry serves the TypeScript or JavaScript code generated for
+// modules as npm packages, so `npm install` fetches them from Spoke directly
+// without a separate publish step.
+//
+// # Package Names
+//
+// Modules are served under a configured scope and name suffix. With scope
+// "ourorg" and suffix "-proto", module "payments" is the package
+// @ourorg/payments-proto, and its version v1.4.0 is the npm version 1.4.0.
+// Versions that are not canonical semantic versions (commit hashes, v1.2,
+// builds with metadata) are not served.
+//
+// # Registry API
+//
+// Handlers registers the endpoints npm uses under /npm/:
+//
+//	GET /npm/{package}                    package document (packument)
+//	GET /npm/{package}/{version}          manifest of a version or dist-tag
+//	GET /npm/{package}/-/{name}-{v}.tgz   package tarball
+//
+// Consumers point the scope at it:
+//
+//	# .npmrc
+//	@ourorg:registry=https://spoke.example.com/npm/
+//
+// Spoke versions carry no tags, so dist-tags follow from the versions:
+// latest is the highest release, or the highest pre-release when there are
+// no releases, and each pre-release channel tags its highest pre-release,
+// such as beta for 2.0.0-beta.3.
+//
+// # Packages
+//
+// The package.json, tsconfig.json and README.md of each version are written
+// by the npm package generator, naming the package and depending on the
+// exact versions of the packages of the version's dependencies. Tarballs hold
+// them and the code compiled for the version. When a version has no code
+// compiled for its package, the first download compiles it through the
+// orchestrator, and the compilation is stored with the version and served
+// from then on.
+//
+// Tarball entries are written in path order with fixed times and modes, so
+// every download of a version is byte for byte the same and matches the
+// integrity recorded in lockfiles. Packuments carry the shasum and integrity
+// of versions once compiled.
+package npmregistry
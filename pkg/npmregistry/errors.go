@@ -0,0 +1,17 @@
+package npmregistry
+
+import "errors"
+
+var (
+	// ErrPackageNotFound is returned for package names naming no Spoke
+	// module
+	ErrPackageNotFound = errors.New("package not found")
+
+	// ErrVersionNotFound is returned for versions the module does not have,
+	// or that are not semantic versions
+	ErrVersionNotFound = errors.New("version not found")
+
+	// ErrNoCode is returned when compiling a version produced no code to
+	// package
+	ErrNoCode = errors.New("no generated code")
+)
@@ -0,0 +1,208 @@
+package npmregistry
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages/npm"
+)
+
+// PathPrefix is where the registry is served; npm registry settings point
+// at it
+const PathPrefix = "/npm/"
+
+// Handlers serves the npm registry API
+type Handlers struct {
+	storage   api.Storage
+	source    *compiled.Source
+	generator *npm.Generator
+	config    *Config
+}
+
+// NewHandlers creates npm registry handlers. Versions without code compiled
+// for their package are compiled on demand by compiler; with a nil compiler
+// only compiled versions can be downloaded.
+func NewHandlers(storage api.Storage, compiler orchestrator.Orchestrator, config *Config) *Handlers {
+	return &Handlers{
+		storage:   storage,
+		source:    compiled.NewSource(storage, compiler),
+		generator: npm.NewGenerator(),
+		config:    config,
+	}
+}
+
+// RegisterRoutes registers the registry routes
+func (h *Handlers) RegisterRoutes(router *mux.Router) {
+	router.PathPrefix(PathPrefix).HandlerFunc(h.serve).Methods("GET")
+}
+
+// serve handles GET /npm/{package}, /npm/{package}/{version-or-tag} and
+// /npm/{package}/-/{tarball}, where {package} may be scoped with its slash
+// escaped, as npm requests it
+func (h *Handlers) serve(w http.ResponseWriter, r *http.Request) {
+	rest, err := url.PathUnescape(strings.TrimPrefix(r.URL.Path, PathPrefix))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if packageName, file, ok := strings.Cut(rest, "/-/"); ok {
+		h.serveTarball(w, r, packageName, file)
+		return
+	}
+
+	packageName, version := splitName(rest)
+	if packageName == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if version == "" {
+		h.servePackument(w, r, packageName)
+		return
+	}
+	h.serveVersion(w, r, packageName, version)
+}
+
+// servePackument writes the package document of a package
+func (h *Handlers) servePackument(w http.ResponseWriter, r *http.Request, packageName string) {
+	name, err := h.resolve(packageName)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	doc, err := h.packument(h.baseURL(r), packageName, name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, doc)
+}
+
+// serveVersion writes the manifest of a version, named by its version or a
+// dist-tag
+func (h *Handlers) serveVersion(w http.ResponseWriter, r *http.Request, packageName, versionOrTag string) {
+	name, err := h.resolve(packageName)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	versions, err := h.versions(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	nv := versionOrTag
+	if tagged, ok := distTags(versions)[versionOrTag]; ok {
+		nv = tagged
+	}
+	for _, v := range versions {
+		if v.npmVersion == nv {
+			manifest, err := h.manifest(h.baseURL(r), packageName, v)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, manifest)
+			return
+		}
+	}
+	writeError(w, ErrVersionNotFound)
+}
+
+// serveTarball writes the tarball of a version, compiling it when needed
+func (h *Handlers) serveTarball(w http.ResponseWriter, r *http.Request, packageName, file string) {
+	name, err := h.resolve(packageName)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	prefix := strings.TrimSuffix(tarballName(packageName, ""), ".tgz")
+	nv, ok := strings.CutPrefix(file, prefix)
+	if !ok || !strings.HasSuffix(nv, ".tgz") {
+		writeError(w, ErrVersionNotFound)
+		return
+	}
+	version, err := h.lookup(name, strings.TrimSuffix(nv, ".tgz"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	tarball, err := h.tarball(r.Context(), packageName, version)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(tarball)
+}
+
+// baseURL returns the URL of the registry tarball URLs start with
+func (h *Handlers) baseURL(r *http.Request) string {
+	if h.config.BaseURL != "" {
+		return strings.TrimSuffix(h.config.BaseURL, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + strings.TrimSuffix(PathPrefix, "/")
+}
+
+// splitName splits a request path into a package name, scoped or not, and
+// the version or dist-tag following it
+func splitName(rest string) (string, string) {
+	parts := strings.SplitN(rest, "/", 3)
+	if strings.HasPrefix(rest, "@") {
+		if len(parts) < 2 || len(parts[0]) < 2 || parts[1] == "" {
+			return "", ""
+		}
+		if len(parts) == 3 {
+			return parts[0] + "/" + parts[1], parts[2]
+		}
+		return parts[0] + "/" + parts[1], ""
+	}
+	if parts[0] == "" || len(parts) == 3 {
+		return "", ""
+	}
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return parts[0], ""
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error as the JSON error document of npm registries,
+// which npm shows to the user. Missing packages and versions are 404 Not
+// Found.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrPackageNotFound), errors.Is(err, ErrVersionNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNoCode):
+		// The version has nothing to download, now or later
+		status = http.StatusGone
+	case errors.Is(err, compiled.ErrCompilerUnavailable):
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,407 @@
+package npmregistry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled/compiledtest"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generate generates one TypeScript file per proto file, along with
+// package files of its own
+func generate(req *orchestrator.CompileRequest) *codegen.CompilationResult {
+	result := &codegen.CompilationResult{Language: req.Language, Success: true}
+	for _, file := range req.ProtoFiles {
+		name := strings.TrimSuffix(file.Path, ".proto")
+		result.GeneratedFiles = append(result.GeneratedFiles, codegen.GeneratedFile{
+			Path:    name + ".ts",
+			Content: []byte("export const module = \"" + req.ModuleName + "@" + req.Version + "\";\n"),
+		})
+	}
+	result.PackageFiles = []codegen.GeneratedFile{
+		{Path: "package.json", Content: []byte(`{"name": "@spoke/` + req.ModuleName + `"}`)},
+		{Path: "README.md", Content: []byte(time.Now().String())},
+	}
+	return result
+}
+
+func newCompiler() *compiledtest.Compiler {
+	return &compiledtest.Compiler{Generate: generate}
+}
+
+func testConfig() *Config {
+	cfg := DefaultConfig("ourorg")
+	cfg.NameSuffix = "-proto"
+	return cfg
+}
+
+func newTestRouter(storage api.Storage, compiler orchestrator.Orchestrator) *mux.Router {
+	router := mux.NewRouter()
+	NewHandlers(storage, compiler, testConfig()).RegisterRoutes(router)
+	return router
+}
+
+func get(t *testing.T, router http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+func getPackument(t *testing.T, router http.Handler, path string) Packument {
+	t.Helper()
+	w := get(t, router, path)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var doc Packument
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	return doc
+}
+
+// untar returns the files of a tarball
+func untar(t *testing.T, tarball []byte) map[string]string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(tarball))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, tarballTime, header.ModTime.UTC())
+		assert.Equal(t, int64(0644), header.Mode)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[header.Name] = string(content)
+	}
+	return files
+}
+
+func TestConfig_PackageName(t *testing.T) {
+	cfg := testConfig()
+	assert.Equal(t, "@ourorg/payments-proto", cfg.PackageName("payments"))
+	assert.Equal(t, "@ourorg/user-service-proto", cfg.PackageName("User_Service"))
+	assert.Equal(t, "@ourorg/acme-orders-proto", cfg.PackageName("acme/orders"))
+	assert.Equal(t, "payments", DefaultConfig("").PackageName("payments"))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, testConfig().Validate())
+	assert.NoError(t, DefaultConfig("").Validate())
+
+	cfg := testConfig()
+	cfg.Language = api.LanguageJavaScript
+	cfg.BaseURL = "https://spoke.example.com/npm"
+	assert.NoError(t, cfg.Validate())
+
+	invalid := []func(*Config){
+		func(c *Config) { c.Scope = "OurOrg" },
+		func(c *Config) { c.Scope = "our org" },
+		func(c *Config) { c.NameSuffix = "/proto" },
+		func(c *Config) { c.Language = api.LanguageGo },
+		func(c *Config) { c.BaseURL = "spoke.example.com/npm" },
+	}
+	for i, modify := range invalid {
+		cfg := testConfig()
+		modify(cfg)
+		assert.Error(t, cfg.Validate(), i)
+	}
+}
+
+func TestNPMVersion(t *testing.T) {
+	tests := map[string]string{
+		"v1.2.3":        "1.2.3",
+		"1.2.3":         "1.2.3",
+		"v1.2.3-beta.1": "1.2.3-beta.1",
+		"v1.2":          "",
+		"v1.2.3+build":  "",
+		"abc1234":       "",
+		"":              "",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, npmVersion(input), input)
+	}
+}
+
+func TestDistTags(t *testing.T) {
+	versions := func(vs ...string) []packageVersion {
+		var result []packageVersion
+		for _, v := range vs {
+			result = append(result, packageVersion{npmVersion: v})
+		}
+		return result
+	}
+
+	assert.Equal(t, map[string]string{
+		"latest": "1.1.0",
+		"beta":   "2.0.0-beta.2",
+		"rc":     "1.1.0-rc.1",
+	}, distTags(versions("1.0.0", "1.1.0-rc.1", "1.1.0", "2.0.0-beta.1", "2.0.0-beta.2", "2.0.0-3")))
+
+	assert.Equal(t, map[string]string{
+		"latest": "0.2.0-alpha",
+		"alpha":  "0.2.0-alpha",
+	}, distTags(versions("0.1.0-alpha", "0.2.0-alpha")))
+
+	assert.Empty(t, distTags(nil))
+}
+
+func TestHandlers_Packument(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("common", "v1.0.0")
+	storage.Add("payments", "v1.0.0")
+	storage.Add("payments", "v1.1.0", "common@v1.0.0", "scratch@abc1234")
+	storage.Add("payments", "v2.0.0-beta.1")
+	storage.Add("payments", "abc1234")
+	router := newTestRouter(storage, newCompiler())
+
+	doc := getPackument(t, router, "/npm/@ourorg%2fpayments-proto")
+	assert.Equal(t, "@ourorg/payments-proto", doc.Name)
+	assert.Equal(t, map[string]string{"latest": "1.1.0", "beta": "2.0.0-beta.1"}, doc.DistTags)
+	assert.Len(t, doc.Versions, 3)
+	assert.Equal(t, "2026-03-01T13:00:00.000Z", doc.Time["1.1.0"])
+	assert.Equal(t, "2026-03-01T12:00:00.000Z", doc.Time["created"])
+	assert.Equal(t, "2026-03-01T14:00:00.000Z", doc.Time["modified"])
+
+	manifest := doc.Versions["1.1.0"]
+	assert.Equal(t, "@ourorg/payments-proto", manifest["name"])
+	assert.Equal(t, "1.1.0", manifest["version"])
+	deps := manifest["dependencies"].(map[string]interface{})
+	assert.Equal(t, "1.0.0", deps["@ourorg/common-proto"], "dependencies are the packages of dependency modules")
+	assert.Contains(t, deps, "google-protobuf")
+	assert.Len(t, deps, 2)
+
+	dist := manifest["dist"].(map[string]interface{})
+	assert.Equal(t, "http://example.com/npm/@ourorg/payments-proto/-/payments-proto-1.1.0.tgz", dist["tarball"])
+	assert.NotContains(t, dist, "integrity", "hashes are unknown before compiling")
+
+	// Unescaped scoped names and forwarded requests are served too
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/npm/@ourorg/payments-proto", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	dist = doc.Versions["1.0.0"]["dist"].(map[string]interface{})
+	assert.Equal(t, "https://example.com/npm/@ourorg/payments-proto/-/payments-proto-1.0.0.tgz", dist["tarball"])
+}
+
+func TestHandlers_Packument_BaseURLAndUnscoped(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.0.0")
+	cfg := DefaultConfig("")
+	cfg.BaseURL = "https://spoke.example.com/npm/"
+	router := mux.NewRouter()
+	NewHandlers(storage, newCompiler(), cfg).RegisterRoutes(router)
+
+	doc := getPackument(t, router, "/npm/payments")
+	dist := doc.Versions["1.0.0"]["dist"].(map[string]interface{})
+	assert.Equal(t, "https://spoke.example.com/npm/payments/-/payments-1.0.0.tgz", dist["tarball"])
+}
+
+func TestHandlers_Packument_ListedModules(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("Payments_API", "v1.0.0")
+	router := newTestRouter(storage, newCompiler())
+
+	doc := getPackument(t, router, "/npm/@ourorg%2fpayments-api-proto")
+	assert.Equal(t, "@ourorg/payments-api-proto", doc.Name)
+	assert.Contains(t, doc.Versions, "1.0.0")
+}
+
+func TestHandlers_Version(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.0.0")
+	storage.Add("payments", "v1.1.0-rc.1")
+	router := newTestRouter(storage, newCompiler())
+
+	for path, version := range map[string]string{
+		"/npm/@ourorg%2fpayments-proto/1.0.0":    "1.0.0",
+		"/npm/@ourorg%2fpayments-proto/latest":   "1.0.0",
+		"/npm/@ourorg/payments-proto/rc":         "1.1.0-rc.1",
+		"/npm/@ourorg/payments-proto/1.1.0-rc.1": "1.1.0-rc.1",
+	} {
+		w := get(t, router, path)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var manifest map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &manifest))
+		assert.Equal(t, version, manifest["version"], path)
+	}
+
+	w := get(t, router, "/npm/@ourorg/payments-proto/2.0.0")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandlers_Tarball(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("common", "v1.0.0")
+	storage.Add("payments", "v1.1.0", "common@v1.0.0")
+	compiler := newCompiler()
+	router := newTestRouter(storage, compiler)
+
+	w := get(t, router, "/npm/@ourorg/payments-proto/-/payments-proto-1.1.0.tgz")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	first := w.Body.Bytes()
+
+	files := untar(t, first)
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{
+		"package/README.md",
+		"package/package.json",
+		"package/payments.ts",
+		"package/tsconfig.json",
+	}, names)
+	assert.Contains(t, files["package/package.json"], `"name": "@ourorg/payments-proto"`, "the generator's package.json replaces the compiled one")
+	assert.Contains(t, files["package/README.md"], "npm install @ourorg/payments-proto")
+
+	// The compilation targets the package and is stored
+	require.Equal(t, 1, compiler.Calls())
+	req := compiler.Requests()[0]
+	assert.Equal(t, "typescript", req.Language)
+	assert.False(t, req.IncludeGRPC)
+	assert.Equal(t, "@ourorg/payments-proto", req.Options[packages.OptionPackageName])
+	require.Len(t, req.Dependencies, 1)
+	assert.Equal(t, "common", req.Dependencies[0].ModuleName)
+	assert.Equal(t, 1, storage.Updates())
+
+	// Later downloads serve the same bytes without compiling again, and the
+	// packument now carries their hashes
+	w = get(t, router, "/npm/@ourorg/payments-proto/-/payments-proto-1.1.0.tgz")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, first, w.Body.Bytes())
+	assert.Equal(t, 1, compiler.Calls())
+
+	shasum, integrity := hashes(first)
+	doc := getPackument(t, router, "/npm/@ourorg%2fpayments-proto")
+	dist := doc.Versions["1.1.0"]["dist"].(map[string]interface{})
+	assert.Equal(t, shasum, dist["shasum"])
+	assert.Equal(t, integrity, dist["integrity"])
+}
+
+func TestHandlers_Tarball_ConcurrentDownloadsCompileOnce(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.0.0")
+	compiler := newCompiler()
+	router := newTestRouter(storage, compiler)
+
+	var wg sync.WaitGroup
+	bodies := make([][]byte, 8)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/npm/@ourorg/payments-proto/-/payments-proto-1.0.0.tgz", nil))
+			bodies[i] = w.Body.Bytes()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, body := range bodies[1:] {
+		assert.Equal(t, bodies[0], body)
+	}
+	assert.Equal(t, 1, compiler.Calls())
+	assert.Equal(t, 1, storage.Updates())
+}
+
+func TestHandlers_Errors(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.0.0")
+	storage.Add("empty", "v1.0.0").CompilationInfo = []api.CompilationInfo{{
+		Language:    api.LanguageTypeScript,
+		PackageName: "@ourorg/empty-proto",
+		Files:       []api.File{{Path: "package.json", Content: "{}"}},
+	}}
+
+	tests := []struct {
+		name     string
+		compiler orchestrator.Orchestrator
+		path     string
+		status   int
+	}{
+		{"unknown package", newCompiler(), "/npm/@ourorg%2forders-proto", http.StatusNotFound},
+		{"other scope", newCompiler(), "/npm/@acme%2fpayments-proto", http.StatusNotFound},
+		{"missing suffix", newCompiler(), "/npm/@ourorg%2fpayments", http.StatusNotFound},
+		{"bare scope", newCompiler(), "/npm/@ourorg", http.StatusNotFound},
+		{"unknown version", newCompiler(), "/npm/@ourorg/payments-proto/-/payments-proto-1.9.0.tgz", http.StatusNotFound},
+		{"wrong tarball name", newCompiler(), "/npm/@ourorg/payments-proto/-/payments-1.0.0.tgz", http.StatusNotFound},
+		{"not a tarball", newCompiler(), "/npm/@ourorg/payments-proto/-/payments-proto-1.0.0.zip", http.StatusNotFound},
+		{"compile failure", &compiledtest.Compiler{Err: errors.New("protoc exploded")}, "/npm/@ourorg/payments-proto/-/payments-proto-1.0.0.tgz", http.StatusInternalServerError},
+		{"no compiler", nil, "/npm/@ourorg/payments-proto/-/payments-proto-1.0.0.tgz", http.StatusServiceUnavailable},
+		{"no code", nil, "/npm/@ourorg/empty-proto/-/empty-proto-1.0.0.tgz", http.StatusGone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(t, newTestRouter(storage, tt.compiler), tt.path)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			if tt.status != http.StatusNotFound || strings.Contains(tt.path, "ourorg/payments-proto") {
+				var body map[string]string
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.NotEmpty(t, body["error"])
+			}
+		})
+	}
+}
+
+// TestHandlers_NpmCacheAdd fetches a package through the registry with npm,
+// which resolves it from the packument and verifies the tarball
+func TestHandlers_NpmCacheAdd(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping npm test in short mode")
+	}
+	npmCmd, err := exec.LookPath("npm")
+	if err != nil {
+		t.Skip("npm not available")
+	}
+
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.0.0")
+	server := httptest.NewServer(newTestRouter(storage, newCompiler()))
+	defer server.Close()
+
+	cache := filepath.Join(t.TempDir(), "cache")
+	for i := 0; i < 2; i++ {
+		cmd := exec.Command(npmCmd, "cache", "add", "@ourorg/payments-proto@latest",
+			"--@ourorg:registry="+server.URL+"/npm/",
+			"--cache", cache,
+			"--prefer-online",
+		)
+		cmd.Dir = t.TempDir()
+		cmd.Env = append(os.Environ(), "npm_config_update_notifier=false")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	out, err := exec.Command(npmCmd, "cache", "ls", "--cache", cache).CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Contains(t, string(out), "payments-proto-1.0.0.tgz")
+}
@@ -0,0 +1,333 @@
+package npmregistry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+	"golang.org/x/mod/semver"
+)
+
+// tarballTime is the modification time of every tarball entry, the one npm
+// itself packs files with, so tarballs of a version never change
+var tarballTime = time.Date(1985, time.October, 26, 8, 15, 0, 0, time.UTC)
+
+// packageVersion is a version of a Spoke module served as an npm version
+type packageVersion struct {
+	npmVersion string
+	version    *api.Version
+}
+
+// npmVersion returns the npm version of a Spoke version, dropping the
+// leading v, or "" when the version is no canonical semantic version
+func npmVersion(version string) string {
+	v := packages.SemVer(version)
+	if !semver.IsValid("v"+v) || semver.Canonical("v"+v) != "v"+v {
+		return ""
+	}
+	return v
+}
+
+// resolve returns the Spoke module a package is served from
+func (h *Handlers) resolve(packageName string) (string, error) {
+	candidate := packageName
+	if h.config.Scope != "" {
+		candidate = strings.TrimPrefix(candidate, "@"+h.config.Scope+"/")
+	}
+	candidate = strings.TrimSuffix(candidate, h.config.NameSuffix)
+
+	name, ok, err := compiled.FindModule(h.storage, packageName, candidate, h.config.PackageName)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrPackageNotFound, packageName)
+	}
+	return name, nil
+}
+
+// versions returns the versions of a module served as npm versions, in
+// semantic version order
+func (h *Handlers) versions(name string) ([]packageVersion, error) {
+	versions, err := h.storage.ListVersions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []packageVersion
+	for _, v := range versions {
+		if nv := npmVersion(v.Version); nv != "" {
+			result = append(result, packageVersion{npmVersion: nv, version: v})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return semver.Compare("v"+result[i].npmVersion, "v"+result[j].npmVersion) < 0
+	})
+	return result, nil
+}
+
+// lookup returns the version of a module served as an npm version
+func (h *Handlers) lookup(name, nv string) (*api.Version, error) {
+	versions, err := h.versions(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.npmVersion == nv {
+			return h.storage.GetVersion(name, v.version.Version)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s@%s", ErrVersionNotFound, name, nv)
+}
+
+// distTags maps dist-tags to versions. Spoke versions carry no tags, so
+// they follow from the versions: latest is the highest release, or the
+// highest pre-release when there are no releases, and each pre-release
+// channel, the first identifier of pre-releases such as "beta" in
+// 2.0.0-beta.3, tags its highest pre-release.
+func distTags(versions []packageVersion) map[string]string {
+	tags := make(map[string]string)
+	for _, v := range versions {
+		prerelease := strings.TrimPrefix(semver.Prerelease("v"+v.npmVersion), "-")
+		if prerelease == "" {
+			tags["latest"] = v.npmVersion
+			continue
+		}
+		channel, _, _ := strings.Cut(prerelease, ".")
+		if channel != "latest" && strings.Trim(channel, "0123456789") != "" {
+			tags[channel] = v.npmVersion
+		}
+	}
+	if _, ok := tags["latest"]; !ok && len(versions) > 0 {
+		tags["latest"] = versions[len(versions)-1].npmVersion
+	}
+	return tags
+}
+
+// packageFiles returns the files the npm package generator writes for a
+// version: its package.json, naming the package and the packages of the
+// version's dependencies, tsconfig.json and README.md
+func (h *Handlers) packageFiles(packageName string, version *api.Version) ([]api.File, error) {
+	req := &packages.GenerateRequest{
+		ModuleName:  version.ModuleName,
+		Version:     npmVersion(version.Version),
+		Language:    string(h.config.Language),
+		IncludeGRPC: h.config.IncludeGRPC,
+		Options:     map[string]string{packages.OptionPackageName: packageName},
+	}
+	for _, dep := range version.Dependencies {
+		name, v, ok := strings.Cut(dep, "@")
+		if !ok {
+			continue
+		}
+		if nv := npmVersion(v); nv != "" {
+			req.Dependencies = append(req.Dependencies, packages.Dependency{
+				Name:    h.config.PackageName(name),
+				Version: nv,
+				Module:  name,
+			})
+		}
+	}
+
+	generated, err := h.generator.Generate(req)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]api.File, 0, len(generated))
+	for _, file := range generated {
+		files = append(files, api.File{Path: file.Path, Content: string(file.Content)})
+	}
+	return files, nil
+}
+
+// manifest returns the package.json of a version as served in the
+// packument, with its dist. The tarball's hashes are only known once the
+// version has been compiled for the package.
+func (h *Handlers) manifest(baseURL, packageName string, v packageVersion) (map[string]interface{}, error) {
+	pkgFiles, err := h.packageFiles(packageName, v.version)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest map[string]interface{}
+	for _, file := range pkgFiles {
+		if file.Path == "package.json" {
+			if err := json.Unmarshal([]byte(file.Content), &manifest); err != nil {
+				return nil, fmt.Errorf("invalid generated package.json: %w", err)
+			}
+		}
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("npm package generator wrote no package.json")
+	}
+
+	dist := Dist{Tarball: tarballURL(baseURL, packageName, v.npmVersion)}
+	if files, ok := compiled.Find(v.version, h.config.Language, packageName); ok {
+		tarball, err := buildTarball(pkgFiles, files)
+		if err == nil {
+			dist.Shasum, dist.Integrity = hashes(tarball)
+		}
+	}
+	manifest["dist"] = dist
+	return manifest, nil
+}
+
+// packument returns the package document of a module
+func (h *Handlers) packument(baseURL, packageName, name string) (*Packument, error) {
+	versions, err := h.versions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Packument{
+		Name:     packageName,
+		DistTags: distTags(versions),
+		Versions: make(map[string]map[string]interface{}, len(versions)),
+		Time:     make(map[string]string, len(versions)+2),
+	}
+	var created, modified time.Time
+	for _, v := range versions {
+		manifest, err := h.manifest(baseURL, packageName, v)
+		if err != nil {
+			return nil, err
+		}
+		doc.Versions[v.npmVersion] = manifest
+
+		t := v.version.CreatedAt.UTC()
+		doc.Time[v.npmVersion] = formatTime(t)
+		if created.IsZero() || t.Before(created) {
+			created = t
+		}
+		if t.After(modified) {
+			modified = t
+		}
+	}
+	if len(versions) > 0 {
+		doc.Time["created"] = formatTime(created)
+		doc.Time["modified"] = formatTime(modified)
+	}
+	return doc, nil
+}
+
+// tarball returns the package tarball of a version, compiling the version
+// first when it has not been compiled for the package
+func (h *Handlers) tarball(ctx context.Context, packageName string, version *api.Version) ([]byte, error) {
+	pkgFiles, err := h.packageFiles(packageName, version)
+	if err != nil {
+		return nil, err
+	}
+	files, err := h.source.Files(ctx, version, compiled.Target{
+		Language:    h.config.Language,
+		PackageName: packageName,
+		IncludeGRPC: h.config.IncludeGRPC,
+		Options:     map[string]string{packages.OptionPackageName: packageName},
+	})
+	if err != nil {
+		return nil, err
+	}
+	tarball, err := buildTarball(pkgFiles, files)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s@%s", err, packageName, npmVersion(version.Version))
+	}
+	return tarball, nil
+}
+
+// buildTarball packs the package files and compiled code of a version
+// under package/, as npm packs packages. The package files take the place
+// of compiled files at the same paths. Entries are written in path order
+// with fixed times and modes, so the tarball, and the integrity lockfiles
+// record for it, never change.
+func buildTarball(pkgFiles, files []api.File) ([]byte, error) {
+	contents := make(map[string]string)
+	for _, file := range files {
+		name := path.Clean(strings.TrimPrefix(file.Path, "/"))
+		if name == "." || strings.HasPrefix(name, "../") {
+			continue
+		}
+		contents[name] = file.Content
+	}
+	for _, file := range pkgFiles {
+		delete(contents, file.Path)
+	}
+	if len(contents) == 0 {
+		return nil, ErrNoCode
+	}
+	for _, file := range pkgFiles {
+		contents[file.Path] = file.Content
+	}
+
+	names := make([]string, 0, len(contents))
+	for name := range contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		content := contents[name]
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     "package/" + name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			ModTime:  tarballTime,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// hashes returns the hex SHA-1 shasum and the SHA-512 subresource integrity
+// of a tarball
+func hashes(tarball []byte) (string, string) {
+	sha1sum := sha1.Sum(tarball)
+	sha512sum := sha512.Sum512(tarball)
+	return hex.EncodeToString(sha1sum[:]), "sha512-" + base64.StdEncoding.EncodeToString(sha512sum[:])
+}
+
+// tarballURL returns the URL of the tarball of a version,
+// {base}/@scope/name/-/name-1.0.0.tgz as npm lays tarballs out
+func tarballURL(baseURL, packageName, nv string) string {
+	return baseURL + "/" + packageName + "/-/" + tarballName(packageName, nv)
+}
+
+// tarballName returns the file name of the tarball of a version
+func tarballName(packageName, nv string) string {
+	_, base, scoped := strings.Cut(packageName, "/")
+	if !scoped {
+		base = packageName
+	}
+	return base + "-" + nv + ".tgz"
+}
+
+// formatTime formats a time as npm does in packuments
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
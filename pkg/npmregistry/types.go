@@ -0,0 +1,96 @@
+package npmregistry
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+)
+
+// Config holds npm registry configuration
+type Config struct {
+	// Scope is the npm scope packages are served under, without the @:
+	// with scope "ourorg", module "payments" is the package
+	// @ourorg/payments. Packages are unscoped when empty.
+	Scope string
+
+	// NameSuffix is appended to package names, such as "-proto" for
+	// @ourorg/payments-proto
+	NameSuffix string
+
+	// Language is the language packages are compiled for, typescript or
+	// javascript
+	Language api.Language
+
+	// IncludeGRPC generates gRPC service code along with the messages when
+	// compiling on demand
+	IncludeGRPC bool
+
+	// BaseURL is the external URL of the registry that tarball URLs start
+	// with, such as https://spoke.example.com/npm. When empty it is derived
+	// from each request.
+	BaseURL string
+}
+
+// DefaultConfig returns the default configuration for a scope
+func DefaultConfig(scope string) *Config {
+	return &Config{
+		Scope:    scope,
+		Language: api.LanguageTypeScript,
+	}
+}
+
+// namePart matches the characters npm allows in scopes and package names
+var namePart = regexp.MustCompile(`^[a-z0-9~-][a-z0-9._~-]*$`)
+
+// Validate checks that packages get valid npm names and the language can
+// be packaged for npm
+func (c *Config) Validate() error {
+	if c.Scope != "" && !namePart.MatchString(c.Scope) {
+		return fmt.Errorf("invalid npm scope %q", c.Scope)
+	}
+	if c.NameSuffix != "" && !namePart.MatchString("x"+c.NameSuffix) {
+		return fmt.Errorf("invalid npm package name suffix %q", c.NameSuffix)
+	}
+	switch c.Language {
+	case api.LanguageTypeScript, api.LanguageJavaScript:
+	default:
+		return fmt.Errorf("unsupported npm package language %q: must be typescript or javascript", c.Language)
+	}
+	if c.BaseURL != "" {
+		u, err := url.Parse(c.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid npm registry base URL %q", c.BaseURL)
+		}
+	}
+	return nil
+}
+
+// PackageName returns the npm package name a Spoke module is served as
+func (c *Config) PackageName(moduleName string) string {
+	name := strings.ToLower(moduleName)
+	name = strings.NewReplacer("_", "-", " ", "-", "/", "-").Replace(name)
+	name += c.NameSuffix
+	if c.Scope != "" {
+		return "@" + c.Scope + "/" + name
+	}
+	return name
+}
+
+// Packument is the package document served for a package, listing its
+// versions with their manifests
+type Packument struct {
+	Name     string                            `json:"name"`
+	DistTags map[string]string                 `json:"dist-tags"`
+	Versions map[string]map[string]interface{} `json:"versions"` // package.json of each version, with its dist
+	Time     map[string]string                 `json:"time"`
+}
+
+// Dist describes the tarball of a version
+type Dist struct {
+	Tarball   string `json:"tarball"`
+	Shasum    string `json:"shasum,omitempty"`    // SHA-1 of the tarball, hex encoded
+	Integrity string `json:"integrity,omitempty"` // Subresource integrity of the tarball
+}
@@ -22,6 +22,14 @@
 //		BuildProtocCommand(req *CompileRequest) ([]string, error)
 //	}
 //
+// LinterPlugin: Ships lint rules, run out of process over a JSON protocol
+//
+//	type LinterPlugin interface {
+//		Plugin
+//		GetLinterSpec() *LinterSpec
+//		Lint(ctx context.Context, req *LintRequest) (*LintResponse, error)
+//	}
+//
 // ValidatorPlugin: Validates proto schemas
 //
 //	type ValidatorPlugin interface {
@@ -0,0 +1,199 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"gopkg.in/yaml.v3"
+)
+
+// LintProtocolVersion is the version of the protocol linter plugins speak
+const LintProtocolVersion = "1"
+
+// defaultLintTimeout bounds a linter plugin run when its spec sets none
+const defaultLintTimeout = 30 * time.Second
+
+// LinterPlugin extends Plugin for plugins that ship lint rules
+type LinterPlugin interface {
+	Plugin
+	GetLinterSpec() *LinterSpec
+	Lint(ctx context.Context, req *LintRequest) (*LintResponse, error)
+}
+
+// LinterSpec describes the rules of a linter plugin and how to run it
+type LinterSpec struct {
+	Executable string         `yaml:"executable" json:"executable"` // In the plugin directory, or looked up in PATH
+	Args       []string       `yaml:"args" json:"args"`
+	Timeout    time.Duration  `yaml:"timeout" json:"timeout"` // Defaults to 30s
+	Rules      []LintRuleSpec `yaml:"rules" json:"rules"`
+}
+
+// LintRuleSpec declares a rule of a linter plugin
+type LintRuleSpec struct {
+	Name        string `yaml:"name" json:"name"`
+	Category    string `yaml:"category" json:"category"` // Defaults to style
+	Severity    string `yaml:"severity" json:"severity"` // Defaults to warning
+	Description string `yaml:"description" json:"description"`
+}
+
+// LintRequest is written as JSON to the standard input of a linter plugin.
+// It holds the files of one module and the rules of the plugin to run.
+type LintRequest struct {
+	ProtocolVersion string     `json:"protocol_version"`
+	Rules           []string   `json:"rules"`
+	Files           []LintFile `json:"files"`
+}
+
+// LintFile is a file of the module being linted
+type LintFile struct {
+	Path    string             `json:"path"`    // Import path
+	Content string             `json:"content"` // Source text, when known
+	AST     *protobuf.RootNode `json:"ast"`
+}
+
+// LintResponse is read as JSON from the standard output of a linter plugin
+type LintResponse struct {
+	Violations []LintViolation `json:"violations"`
+}
+
+// LintViolation is a violation reported by a linter plugin. Lines and
+// columns are 1-based.
+type LintViolation struct {
+	Rule     string `json:"rule"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity,omitempty"` // Defaults to the rule's severity
+	Message  string `json:"message"`
+	NodePath string `json:"node_path,omitempty"` // Declaration the violation is about, such as acme.v1.User.name
+}
+
+// BasicLinterPlugin runs a linter plugin as a process: the request is written
+// to its standard input and the response read from its standard output. A
+// non-zero exit fails the run.
+type BasicLinterPlugin struct {
+	manifest   *Manifest
+	pluginDir  string
+	linterSpec *LinterSpec
+}
+
+// NewBasicLinterPlugin creates a new basic linter plugin
+func NewBasicLinterPlugin(manifest *Manifest, pluginDir string) *BasicLinterPlugin {
+	return &BasicLinterPlugin{
+		manifest:  manifest,
+		pluginDir: pluginDir,
+	}
+}
+
+// Manifest returns the plugin manifest
+func (p *BasicLinterPlugin) Manifest() *Manifest {
+	return p.manifest
+}
+
+// Load reads the linter spec from linter_spec.yaml in the plugin directory,
+// or from the linter_spec manifest metadata
+func (p *BasicLinterPlugin) Load() error {
+	var data []byte
+	specPath := filepath.Join(p.pluginDir, "linter_spec.yaml")
+	if _, err := os.Stat(specPath); err == nil {
+		data, err = os.ReadFile(specPath)
+		if err != nil {
+			return fmt.Errorf("failed to read linter spec file: %w", err)
+		}
+	} else if specData, ok := p.manifest.Metadata["linter_spec"]; ok {
+		data = []byte(specData)
+	} else {
+		return fmt.Errorf("linter spec not found: expected linter_spec.yaml or linter_spec metadata")
+	}
+
+	var spec LinterSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("failed to parse linter spec: %w", err)
+	}
+	if spec.Executable == "" {
+		return fmt.Errorf("linter spec has no executable")
+	}
+	if len(spec.Rules) == 0 {
+		return fmt.Errorf("linter spec declares no rules")
+	}
+	for i, rule := range spec.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d of linter spec has no name", i)
+		}
+	}
+
+	p.linterSpec = &spec
+	return nil
+}
+
+// Unload cleans up plugin resources
+func (p *BasicLinterPlugin) Unload() error {
+	return nil
+}
+
+// GetLinterSpec returns the linter specification
+func (p *BasicLinterPlugin) GetLinterSpec() *LinterSpec {
+	return p.linterSpec
+}
+
+// Lint runs the plugin executable on a request
+func (p *BasicLinterPlugin) Lint(ctx context.Context, req *LintRequest) (*LintResponse, error) {
+	if p.linterSpec == nil {
+		return nil, fmt.Errorf("linter spec not loaded")
+	}
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lint request: %w", err)
+	}
+
+	timeout := p.linterSpec.Timeout
+	if timeout <= 0 {
+		timeout = defaultLintTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.executable(), p.linterSpec.Args...)
+	cmd.Dir = p.pluginDir
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("linter plugin %s failed: %w: %s", p.manifest.ID, err, msg)
+		}
+		return nil, fmt.Errorf("linter plugin %s failed: %w", p.manifest.ID, err)
+	}
+
+	var resp LintResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("linter plugin %s returned an invalid response: %w", p.manifest.ID, err)
+	}
+	return &resp, nil
+}
+
+// executable resolves the plugin executable: a file in the plugin directory
+// is preferred, otherwise the name is looked up in PATH
+func (p *BasicLinterPlugin) executable() string {
+	exe := p.linterSpec.Executable
+	if filepath.IsAbs(exe) {
+		return exe
+	}
+	local, err := filepath.Abs(filepath.Join(p.pluginDir, exe))
+	if err == nil {
+		if _, err := os.Stat(local); err == nil {
+			return local
+		}
+	}
+	return exe
+}
@@ -0,0 +1,166 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLinterPlugin creates a linter plugin whose executable saves the
+// request to request.json and prints response
+func writeLinterPlugin(t *testing.T, dir, response string) string {
+	t.Helper()
+	pluginDir := filepath.Join(dir, "acme-rules")
+	require.NoError(t, os.MkdirAll(pluginDir, 0755))
+
+	require.NoError(t, SaveManifest(&Manifest{
+		ID:         "acme-rules",
+		Name:       "Acme Rules",
+		Version:    "1.0.0",
+		APIVersion: "1.0.0",
+		Type:       PluginTypeLinter,
+	}, filepath.Join(pluginDir, "plugin.yaml")))
+
+	spec := `executable: lint.sh
+timeout: 5s
+rules:
+  - name: acme-field-prefix
+    category: naming
+    severity: error
+    description: Fields must not start with x_
+  - name: acme-message-suffix
+    description: Messages must not end in Data
+`
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "linter_spec.yaml"), []byte(spec), 0644))
+
+	script := "#!/bin/sh\ncat > request.json\ncat <<'EOF'\n" + response + "\nEOF\n"
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "lint.sh"), []byte(script), 0755))
+	return pluginDir
+}
+
+func TestBasicLinterPlugin_Load(t *testing.T) {
+	pluginDir := writeLinterPlugin(t, t.TempDir(), `{"violations": []}`)
+	manifest, err := LoadManifestFromDir(pluginDir)
+	require.NoError(t, err)
+
+	plugin := NewBasicLinterPlugin(manifest, pluginDir)
+	require.NoError(t, plugin.Load())
+
+	spec := plugin.GetLinterSpec()
+	require.NotNil(t, spec)
+	assert.Equal(t, "lint.sh", spec.Executable)
+	assert.Equal(t, 5*time.Second, spec.Timeout)
+	require.Len(t, spec.Rules, 2)
+	assert.Equal(t, "acme-field-prefix", spec.Rules[0].Name)
+	assert.Equal(t, "error", spec.Rules[0].Severity)
+}
+
+func TestBasicLinterPlugin_LoadFromMetadata(t *testing.T) {
+	plugin := NewBasicLinterPlugin(&Manifest{
+		ID: "meta",
+		Metadata: map[string]string{
+			"linter_spec": "executable: spoke-lint-meta\nrules:\n  - name: meta-rule\n",
+		},
+	}, t.TempDir())
+	require.NoError(t, plugin.Load())
+	assert.Equal(t, "spoke-lint-meta", plugin.GetLinterSpec().Executable)
+}
+
+func TestBasicLinterPlugin_LoadErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		wantErr  string
+	}{
+		{name: "missing spec", wantErr: "linter spec not found"},
+		{name: "no executable", metadata: map[string]string{"linter_spec": "rules:\n  - name: a\n"}, wantErr: "no executable"},
+		{name: "no rules", metadata: map[string]string{"linter_spec": "executable: lint\n"}, wantErr: "declares no rules"},
+		{name: "unnamed rule", metadata: map[string]string{"linter_spec": "executable: lint\nrules:\n  - description: x\n"}, wantErr: "has no name"},
+		{name: "invalid yaml", metadata: map[string]string{"linter_spec": "rules: ["}, wantErr: "failed to parse linter spec"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := NewBasicLinterPlugin(&Manifest{ID: "bad", Metadata: tt.metadata}, t.TempDir())
+			err := plugin.Load()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestBasicLinterPlugin_Lint(t *testing.T) {
+	pluginDir := writeLinterPlugin(t, t.TempDir(),
+		`{"violations": [{"rule": "acme-field-prefix", "file": "acme/v1/user.proto", "line": 5, "column": 3, "message": "Field x_name starts with x_"}]}`)
+	manifest, err := LoadManifestFromDir(pluginDir)
+	require.NoError(t, err)
+	plugin := NewBasicLinterPlugin(manifest, pluginDir)
+	require.NoError(t, plugin.Load())
+
+	ast, err := protobuf.ParseString("syntax = \"proto3\";\npackage acme.v1;\n\nmessage User {\n  string x_name = 1;\n}\n")
+	require.NoError(t, err)
+	resp, err := plugin.Lint(context.Background(), &LintRequest{
+		ProtocolVersion: LintProtocolVersion,
+		Rules:           []string{"acme-field-prefix"},
+		Files:           []LintFile{{Path: "acme/v1/user.proto", AST: ast}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Violations, 1)
+	assert.Equal(t, "acme-field-prefix", resp.Violations[0].Rule)
+	assert.Equal(t, 5, resp.Violations[0].Line)
+
+	// The plugin received the request on its standard input
+	data, err := os.ReadFile(filepath.Join(pluginDir, "request.json"))
+	require.NoError(t, err)
+	var req LintRequest
+	require.NoError(t, json.Unmarshal(data, &req))
+	assert.Equal(t, LintProtocolVersion, req.ProtocolVersion)
+	require.Len(t, req.Files, 1)
+	assert.Equal(t, "acme.v1", req.Files[0].AST.Package.Name)
+}
+
+func TestBasicLinterPlugin_LintErrors(t *testing.T) {
+	dir := t.TempDir()
+	plugin := NewBasicLinterPlugin(&Manifest{
+		ID:       "failing",
+		Metadata: map[string]string{"linter_spec": "executable: fail.sh\nrules:\n  - name: a\n"},
+	}, dir)
+	require.NoError(t, plugin.Load())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fail.sh"), []byte("#!/bin/sh\necho 'bad input' >&2\nexit 2\n"), 0755))
+	_, err := plugin.Lint(context.Background(), &LintRequest{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "linter plugin failing failed")
+	assert.Contains(t, err.Error(), "bad input")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fail.sh"), []byte("#!/bin/sh\necho 'not json'\n"), 0755))
+	_, err = plugin.Lint(context.Background(), &LintRequest{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid response")
+
+	_, err = NewBasicLinterPlugin(&Manifest{ID: "unloaded"}, dir).Lint(context.Background(), &LintRequest{})
+	assert.Error(t, err)
+}
+
+func TestDiscoverPlugins_LinterPlugin(t *testing.T) {
+	dir := t.TempDir()
+	writeLinterPlugin(t, dir, `{"violations": []}`)
+
+	loader := NewLoader([]string{dir}, logrus.New())
+	plugins, err := loader.DiscoverPlugins(context.Background())
+	require.NoError(t, err)
+	require.Len(t, plugins, 1)
+
+	linterPlugin, ok := plugins[0].(LinterPlugin)
+	require.True(t, ok)
+	assert.Equal(t, PluginTypeLinter, linterPlugin.Manifest().Type)
+	assert.Len(t, linterPlugin.GetLinterSpec().Rules, 2)
+}
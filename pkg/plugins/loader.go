@@ -150,11 +150,14 @@ func (l *Loader) loadPluginFromDir(ctx context.Context, pluginDir string) (Plugi
 	// Load plugin based on type
 	var plugin Plugin
 
-	if manifest.Type != PluginTypeLanguage {
+	switch manifest.Type {
+	case PluginTypeLanguage:
+		plugin, err = l.loadLanguagePlugin(ctx, pluginDir, manifest)
+	case PluginTypeLinter:
+		plugin = NewBasicLinterPlugin(manifest, pluginDir)
+	default:
 		return nil, fmt.Errorf("unsupported plugin type: %s", manifest.Type)
 	}
-
-	plugin, err = l.loadLanguagePlugin(ctx, pluginDir, manifest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load plugin: %w", err)
 	}
@@ -102,10 +102,10 @@ func ValidateManifest(manifest *Manifest) []ValidationError {
 	}
 
 	// Validate plugin type
-	if manifest.Type != PluginTypeLanguage {
+	if manifest.Type != PluginTypeLanguage && manifest.Type != PluginTypeLinter {
 		errors = append(errors, ValidationError{
 			Field:   "type",
-			Message: fmt.Sprintf("Invalid plugin type: %s (only 'language' is supported)", manifest.Type),
+			Message: fmt.Sprintf("Invalid plugin type: %s (expected 'language' or 'linter')", manifest.Type),
 		})
 	}
 
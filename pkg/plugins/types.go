@@ -32,6 +32,7 @@ type PluginType string
 
 const (
 	PluginTypeLanguage PluginType = "language"
+	PluginTypeLinter   PluginType = "linter"
 )
 
 // PluginInfo contains runtime information about a loaded plugin
@@ -0,0 +1,44 @@
+// Package pypiindex serves the Python code generated for modules as wheels
+// from a PEP 503 simple index, so `pip install` fetches them from Spoke
+// directly without a separate upload step.
+//
+// # Project Names
+//
+// Modules are served as projects named with a configured prefix and suffix.
+// With prefix "ourorg-" and suffix "-proto", module "payments" is the project
+// ourorg-payments-proto, normalized as PEP 503 specifies. Its version v1.4.0
+// is the Python version 1.4.0, and pre-releases map to their Python spelling:
+// v2.0.0-beta.1 is 2.0.0b1 and v2.0.0-rc.2 is 2.0.0rc2. Versions that are not
+// canonical semantic versions, or whose pre-releases have no Python spelling,
+// are not served.
+//
+// # Simple Repository API
+//
+// Handlers registers the index under /pypi/:
+//
+//	GET /pypi/simple/                     all projects
+//	GET /pypi/simple/{project}/           links to the wheels of a project
+//	GET /pypi/packages/{project}/{wheel}  wheel
+//
+// Consumers point pip at it:
+//
+//	pip install --extra-index-url https://spoke.example.com/pypi/simple/ \
+//	    ourorg-payments-proto==1.4.0
+//
+// # Wheels
+//
+// Each version is served as a pure Python wheel, tagged py3-none-any; the
+// generated code needs no build step, so no source distributions are served.
+// Wheels hold the .py and .pyi files compiled for the version at the import
+// paths protoc generated them for. Their metadata comes from the pip package
+// generator: the summary, the required Python version, and the requirements
+// on the protobuf runtime, gRPC when enabled, and the exact versions of the
+// projects of the version's dependencies.
+//
+// When a version has no Python code compiled for its project, the first
+// download compiles it through the orchestrator, and the compilation is
+// stored with the version and served from then on. Wheel entries are written
+// in path order with fixed times, so every download of a version is byte for
+// byte the same. Links of versions already compiled carry the SHA-256 of
+// their wheel.
+package pypiindex
@@ -0,0 +1,17 @@
+package pypiindex
+
+import "errors"
+
+var (
+	// ErrProjectNotFound is returned for project names naming no Spoke
+	// module
+	ErrProjectNotFound = errors.New("project not found")
+
+	// ErrVersionNotFound is returned for versions the module does not have,
+	// or that have no Python version
+	ErrVersionNotFound = errors.New("version not found")
+
+	// ErrNoCode is returned when compiling a version produced no Python
+	// code to package
+	ErrNoCode = errors.New("no generated Python code")
+)
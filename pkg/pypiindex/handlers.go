@@ -0,0 +1,230 @@
+package pypiindex
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages/pip"
+)
+
+// PathPrefix is where the index is served; pip's index URL is its simple/
+// directory
+const PathPrefix = "/pypi/"
+
+// rootTemplate renders the list of projects
+var rootTemplate = template.Must(template.New("root").Parse(`<!DOCTYPE html>
+<html>
+  <head>
+    <meta name="pypi:repository-version" content="1.0">
+    <title>Simple index</title>
+  </head>
+  <body>
+{{- range .}}
+    <a href="{{.}}/">{{.}}</a><br>
+{{- end}}
+  </body>
+</html>
+`))
+
+// projectTemplate renders the files of a project
+var projectTemplate = template.Must(template.New("project").Parse(`<!DOCTYPE html>
+<html>
+  <head>
+    <meta name="pypi:repository-version" content="1.0">
+    <title>Links for {{.Project}}</title>
+  </head>
+  <body>
+    <h1>Links for {{.Project}}</h1>
+{{- range .Files}}
+    <a href="{{.URL}}"{{if .RequiresPython}} data-requires-python="{{.RequiresPython}}"{{end}}>{{.Name}}</a><br>
+{{- end}}
+  </body>
+</html>
+`))
+
+// projectFile is a link on a project page
+type projectFile struct {
+	Name           string
+	URL            string
+	RequiresPython string
+}
+
+// Handlers serves the PEP 503 simple repository API
+type Handlers struct {
+	storage   api.Storage
+	source    *compiled.Source
+	generator *pip.Generator
+	config    *Config
+}
+
+// NewHandlers creates Python package index handlers. Versions without code
+// compiled for their project are compiled on demand by compiler; with a nil
+// compiler only compiled versions can be downloaded.
+func NewHandlers(storage api.Storage, compiler orchestrator.Orchestrator, config *Config) *Handlers {
+	return &Handlers{
+		storage:   storage,
+		source:    compiled.NewSource(storage, compiler),
+		generator: pip.NewGenerator(),
+		config:    config,
+	}
+}
+
+// RegisterRoutes registers the index routes
+func (h *Handlers) RegisterRoutes(router *mux.Router) {
+	router.PathPrefix(PathPrefix).HandlerFunc(h.serve).Methods("GET")
+}
+
+// serve handles GET /pypi/simple/, /pypi/simple/{project}/ and
+// /pypi/packages/{project}/{wheel}
+func (h *Handlers) serve(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, PathPrefix)
+
+	if rest == "simple/" {
+		h.serveRoot(w)
+		return
+	}
+	if project, ok := strings.CutPrefix(rest, "simple/"); ok && project != "" {
+		project, slash := strings.CutSuffix(project, "/")
+		if strings.Contains(project, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		// Project pages live at their normalized name, with a trailing
+		// slash, as PEP 503 asks
+		if normalized := normalize(project); normalized != project || !slash {
+			http.Redirect(w, r, PathPrefix+"simple/"+normalized+"/", http.StatusMovedPermanently)
+			return
+		}
+		h.serveProject(w, project)
+		return
+	}
+	if file, ok := strings.CutPrefix(rest, "packages/"); ok {
+		project, name, ok := strings.Cut(file, "/")
+		if ok && !strings.Contains(name, "/") {
+			h.serveWheel(w, r, project, name)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// serveRoot writes the list of projects, one per module
+func (h *Handlers) serveRoot(w http.ResponseWriter) {
+	modules, err := h.storage.ListModules()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	projects := make([]string, 0, len(modules))
+	for _, m := range modules {
+		projects = append(projects, h.config.ProjectName(m.Name))
+	}
+	sort.Strings(projects)
+	writeHTML(w, rootTemplate, projects)
+}
+
+// serveProject writes the links to the wheels of a project. Links of
+// versions already compiled carry the SHA-256 of the wheel.
+func (h *Handlers) serveProject(w http.ResponseWriter, project string) {
+	name, err := h.resolve(project)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	versions, err := h.versions(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	files := make([]projectFile, 0, len(versions))
+	for _, v := range versions {
+		rel, err := h.release(project, v.version)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		file := projectFile{
+			Name:           rel.wheelName(),
+			URL:            "../../packages/" + project + "/" + rel.wheelName(),
+			RequiresPython: rel.meta.RequiresPython,
+		}
+		if code, ok := compiled.Find(v.version, api.LanguagePython, project); ok {
+			if wheel, err := buildWheel(rel, code); err == nil {
+				file.URL += "#sha256=" + sha256Hex(wheel)
+			}
+		}
+		files = append(files, file)
+	}
+	writeHTML(w, projectTemplate, map[string]interface{}{"Project": project, "Files": files})
+}
+
+// serveWheel writes the wheel of a version, compiling it when needed
+func (h *Handlers) serveWheel(w http.ResponseWriter, r *http.Request, project, file string) {
+	name, err := h.resolve(project)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	pv, ok := strings.CutPrefix(file, distributionName(project)+"-")
+	if ok {
+		pv, ok = strings.CutSuffix(pv, "-"+wheelTag+".whl")
+	}
+	if !ok {
+		writeError(w, ErrVersionNotFound)
+		return
+	}
+	version, err := h.lookup(name, pv)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	rel, err := h.release(project, version)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	wheel, err := h.wheel(r.Context(), rel, version)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Write(wheel)
+}
+
+// writeHTML renders an index page
+func writeHTML(w http.ResponseWriter, tmpl *template.Template, data interface{}) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// writeError writes an error as plain text. Missing projects and versions
+// are 404 Not Found, so pip moves on to its other indexes.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrProjectNotFound), errors.Is(err, ErrVersionNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNoCode):
+		// The version has nothing to download, now or later
+		status = http.StatusGone
+	case errors.Is(err, compiled.ErrCompilerUnavailable):
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, err.Error(), status)
+}
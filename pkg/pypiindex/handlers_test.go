@@ -0,0 +1,353 @@
+package pypiindex
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled/compiledtest"
+	"github.com/platinummonkey/spoke/pkg/codegen/orchestrator"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generate generates a Python module and stub per proto file, along
+// with package files of its own
+func generate(req *orchestrator.CompileRequest) *codegen.CompilationResult {
+	result := &codegen.CompilationResult{Language: req.Language, Success: true}
+	for _, file := range req.ProtoFiles {
+		name := strings.TrimSuffix(file.Path, ".proto")
+		result.GeneratedFiles = append(result.GeneratedFiles,
+			codegen.GeneratedFile{Path: name + "_pb2.py", Content: []byte("MODULE = \"" + req.ModuleName + "@" + req.Version + "\"\n")},
+			codegen.GeneratedFile{Path: name + "_pb2.pyi", Content: []byte("MODULE: str\n")},
+		)
+	}
+	result.PackageFiles = []codegen.GeneratedFile{
+		{Path: "setup.py", Content: []byte("from setuptools import setup\n")},
+		{Path: "README.md", Content: []byte(time.Now().String())},
+	}
+	return result
+}
+
+func newCompiler() *compiledtest.Compiler {
+	return &compiledtest.Compiler{Generate: generate}
+}
+
+func testConfig() *Config {
+	cfg := DefaultConfig()
+	cfg.NamePrefix = "ourorg-"
+	cfg.NameSuffix = "-proto"
+	return cfg
+}
+
+func newTestRouter(storage api.Storage, compiler orchestrator.Orchestrator) *mux.Router {
+	router := mux.NewRouter()
+	NewHandlers(storage, compiler, testConfig()).RegisterRoutes(router)
+	return router
+}
+
+func get(t *testing.T, router http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+// unzip returns the files of a wheel
+func unzip(t *testing.T, wheel []byte) (map[string]string, []string) {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(wheel), int64(len(wheel)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	var names []string
+	for _, f := range zr.File {
+		assert.True(t, f.Modified.Equal(wheelTime), f.Name)
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[f.Name] = string(content)
+		names = append(names, f.Name)
+	}
+	return files, names
+}
+
+func TestConfig_ProjectName(t *testing.T) {
+	cfg := testConfig()
+	assert.Equal(t, "ourorg-payments-proto", cfg.ProjectName("payments"))
+	assert.Equal(t, "ourorg-user-service-proto", cfg.ProjectName("User_Service"))
+	assert.Equal(t, "ourorg-acme-orders-proto", cfg.ProjectName("acme/orders"))
+	assert.Equal(t, "payments-api", DefaultConfig().ProjectName("Payments.API"))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, testConfig().Validate())
+	assert.NoError(t, DefaultConfig().Validate())
+
+	cfg := testConfig()
+	cfg.NamePrefix = "our org "
+	assert.Error(t, cfg.Validate())
+	cfg = testConfig()
+	cfg.NameSuffix = "/proto"
+	assert.Error(t, cfg.Validate())
+}
+
+func TestPythonVersion(t *testing.T) {
+	tests := map[string]string{
+		"v1.2.3":          "1.2.3",
+		"1.2.3":           "1.2.3",
+		"v1.2.3-alpha":    "1.2.3a0",
+		"v1.2.3-beta.1":   "1.2.3b1",
+		"v1.2.3-rc.2":     "1.2.3rc2",
+		"v1.2.3-RC3":      "1.2.3rc3",
+		"v1.2.3-preview":  "1.2.3rc0",
+		"v1.2.3-nightly":  "",
+		"v1.2.3-beta.1.2": "",
+		"v1.2":            "",
+		"v1.2.3+build":    "",
+		"abc1234":         "",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, pythonVersion(input), input)
+	}
+}
+
+func TestParsePyproject(t *testing.T) {
+	files, err := NewHandlers(compiledtest.NewStorage(), nil, testConfig()).generator.Generate(&packages.GenerateRequest{
+		ModuleName:   "payments",
+		Version:      "1.0.0",
+		IncludeGRPC:  true,
+		Dependencies: []packages.Dependency{{Name: "ourorg-common-proto", Version: "1.0.0"}},
+	})
+	require.NoError(t, err)
+
+	var meta pyproject
+	for _, f := range files {
+		if f.Path == "pyproject.toml" {
+			meta = parsePyproject(string(f.Content))
+		}
+	}
+	assert.Equal(t, pyproject{
+		Description:    "Protocol Buffer generated code for payments",
+		RequiresPython: ">=3.8",
+		Dependencies: []string{
+			"protobuf==4.25.1",
+			"grpcio==1.60.0",
+			"grpcio-tools==1.60.0",
+			"ourorg-common-proto==1.0.0",
+		},
+	}, meta)
+}
+
+func TestHandlers_Root(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.0.0")
+	storage.Add("common", "v1.0.0")
+	router := newTestRouter(storage, newCompiler())
+
+	w := get(t, router, "/pypi/simple/")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, `<a href="ourorg-common-proto/">ourorg-common-proto</a>`)
+	assert.Contains(t, body, `<a href="ourorg-payments-proto/">ourorg-payments-proto</a>`)
+	assert.Less(t, strings.Index(body, "ourorg-common-proto"), strings.Index(body, "ourorg-payments-proto"))
+}
+
+func TestHandlers_Project(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.1.0")
+	storage.Add("payments", "v1.0.0")
+	storage.Add("payments", "v2.0.0-beta.1")
+	storage.Add("payments", "v2.0.0-nightly")
+	storage.Add("payments", "abc1234")
+	router := newTestRouter(storage, newCompiler())
+
+	w := get(t, router, "/pypi/simple/ourorg-payments-proto/")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	body := w.Body.String()
+	assert.Contains(t, body, `<meta name="pypi:repository-version" content="1.0">`)
+	assert.Contains(t, body, `<a href="../../packages/ourorg-payments-proto/ourorg_payments_proto-1.0.0-py3-none-any.whl" data-requires-python="&gt;=3.8">ourorg_payments_proto-1.0.0-py3-none-any.whl</a>`)
+	assert.Contains(t, body, "ourorg_payments_proto-2.0.0b1-py3-none-any.whl")
+	assert.Equal(t, 3, strings.Count(body, "<a "))
+	assert.Less(t, strings.Index(body, "-1.0.0-"), strings.Index(body, "-1.1.0-"))
+	assert.NotContains(t, body, "#sha256=", "hashes are unknown before compiling")
+
+	// Unnormalized names and missing slashes redirect to the project page
+	for _, path := range []string{"/pypi/simple/ourorg-payments-proto", "/pypi/simple/OurOrg_Payments.Proto/"} {
+		w = get(t, router, path)
+		assert.Equal(t, http.StatusMovedPermanently, w.Code, path)
+		assert.Equal(t, "/pypi/simple/ourorg-payments-proto/", w.Header().Get("Location"), path)
+	}
+}
+
+func TestHandlers_Wheel(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("common", "v1.0.0")
+	storage.Add("scratch", "abc1234")
+	v := storage.Add("payments", "v1.1.0", "common@v1.0.0", "scratch@abc1234")
+	v.Files = append(v.Files, api.File{Path: "acme/v1/refunds.proto", Content: `syntax = "proto3";`})
+	compiler := newCompiler()
+	router := newTestRouter(storage, compiler)
+
+	w := get(t, router, "/pypi/packages/ourorg-payments-proto/ourorg_payments_proto-1.1.0-py3-none-any.whl")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	first := w.Body.Bytes()
+
+	files, names := unzip(t, first)
+	assert.Equal(t, []string{
+		"acme/v1/refunds_pb2.py",
+		"acme/v1/refunds_pb2.pyi",
+		"payments_pb2.py",
+		"payments_pb2.pyi",
+		"ourorg_payments_proto-1.1.0.dist-info/METADATA",
+		"ourorg_payments_proto-1.1.0.dist-info/WHEEL",
+		"ourorg_payments_proto-1.1.0.dist-info/RECORD",
+	}, names, "only Python modules and stubs are packed, with the metadata last")
+
+	metadata := files["ourorg_payments_proto-1.1.0.dist-info/METADATA"]
+	assert.Contains(t, metadata, "Name: ourorg-payments-proto\nVersion: 1.1.0\n")
+	assert.Contains(t, metadata, "Requires-Python: >=3.8\n")
+	assert.Contains(t, metadata, "Requires-Dist: protobuf==4.25.1\n")
+	assert.Contains(t, metadata, "Requires-Dist: ourorg-common-proto==1.0.0\n", "dependencies are the projects of dependency modules")
+	assert.NotContains(t, metadata, "grpcio")
+	assert.Contains(t, metadata, "pip install ourorg-payments-proto")
+	assert.Contains(t, files["ourorg_payments_proto-1.1.0.dist-info/WHEEL"], "Tag: py3-none-any\n")
+	assert.Contains(t, files["ourorg_payments_proto-1.1.0.dist-info/RECORD"], "payments_pb2.py,sha256=")
+	assert.True(t, strings.HasSuffix(files["ourorg_payments_proto-1.1.0.dist-info/RECORD"], "ourorg_payments_proto-1.1.0.dist-info/RECORD,,\n"))
+
+	// The compilation targets the project and is stored
+	require.Equal(t, 1, compiler.Calls())
+	req := compiler.Requests()[0]
+	assert.Equal(t, "python", req.Language)
+	assert.Equal(t, "ourorg-payments-proto", req.Options[packages.OptionPackageName])
+	assert.Equal(t, 1, storage.Updates())
+
+	// Later downloads serve the same bytes without compiling again, and the
+	// project page now links them with their hash
+	w = get(t, router, "/pypi/packages/ourorg-payments-proto/ourorg_payments_proto-1.1.0-py3-none-any.whl")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, first, w.Body.Bytes())
+	assert.Equal(t, 1, compiler.Calls())
+
+	w = get(t, router, "/pypi/simple/ourorg-payments-proto/")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "ourorg_payments_proto-1.1.0-py3-none-any.whl#sha256="+sha256Hex(first))
+}
+
+func TestHandlers_Wheel_ConcurrentDownloadsCompileOnce(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.0.0")
+	compiler := newCompiler()
+	router := newTestRouter(storage, compiler)
+
+	var wg sync.WaitGroup
+	bodies := make([][]byte, 8)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/pypi/packages/ourorg-payments-proto/ourorg_payments_proto-1.0.0-py3-none-any.whl", nil))
+			bodies[i] = w.Body.Bytes()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, body := range bodies[1:] {
+		assert.Equal(t, bodies[0], body)
+	}
+	assert.Equal(t, 1, compiler.Calls())
+	assert.Equal(t, 1, storage.Updates())
+}
+
+func TestHandlers_Errors(t *testing.T) {
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.0.0")
+	storage.Add("empty", "v1.0.0").CompilationInfo = []api.CompilationInfo{{
+		Language:    api.LanguagePython,
+		PackageName: "ourorg-empty-proto",
+		Files:       []api.File{{Path: "README.md", Content: "nothing here"}},
+	}}
+	wheel := "/pypi/packages/ourorg-payments-proto/ourorg_payments_proto-1.0.0-py3-none-any.whl"
+
+	tests := []struct {
+		name     string
+		compiler orchestrator.Orchestrator
+		path     string
+		status   int
+	}{
+		{"unknown project", newCompiler(), "/pypi/simple/ourorg-orders-proto/", http.StatusNotFound},
+		{"missing affixes", newCompiler(), "/pypi/simple/payments/", http.StatusNotFound},
+		{"nested project path", newCompiler(), "/pypi/simple/ourorg-payments-proto/extra/", http.StatusNotFound},
+		{"unknown version", newCompiler(), "/pypi/packages/ourorg-payments-proto/ourorg_payments_proto-1.9.0-py3-none-any.whl", http.StatusNotFound},
+		{"wrong file name", newCompiler(), "/pypi/packages/ourorg-payments-proto/payments-1.0.0-py3-none-any.whl", http.StatusNotFound},
+		{"source distribution", newCompiler(), "/pypi/packages/ourorg-payments-proto/ourorg_payments_proto-1.0.0.tar.gz", http.StatusNotFound},
+		{"compile failure", &compiledtest.Compiler{Err: errors.New("protoc exploded")}, wheel, http.StatusInternalServerError},
+		{"no compiler", nil, wheel, http.StatusServiceUnavailable},
+		{"no python code", nil, "/pypi/packages/ourorg-empty-proto/ourorg_empty_proto-1.0.0-py3-none-any.whl", http.StatusGone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(t, newTestRouter(storage, tt.compiler), tt.path)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+// TestHandlers_PipInstall installs a project through the index with pip,
+// which checks the wheel's name, version, metadata and hash
+func TestHandlers_PipInstall(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping pip test in short mode")
+	}
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+	if err := exec.Command(python, "-m", "pip", "--version").Run(); err != nil {
+		t.Skip("pip not available")
+	}
+
+	storage := compiledtest.NewStorage()
+	storage.Add("payments", "v1.0.0")
+	server := httptest.NewServer(newTestRouter(storage, newCompiler()))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		target := t.TempDir()
+		cmd := exec.Command(python, "-m", "pip", "install",
+			"--no-deps",
+			"--no-cache-dir",
+			"--disable-pip-version-check",
+			"--index-url", server.URL+"/pypi/simple/",
+			"--target", target,
+			"ourorg-payments-proto==1.0.0",
+		)
+		cmd.Env = append(os.Environ(), "PIP_CONFIG_FILE="+os.DevNull)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+
+		content, err := os.ReadFile(filepath.Join(target, "payments_pb2.py"))
+		require.NoError(t, err)
+		assert.Equal(t, "MODULE = \"payments@v1.0.0\"\n", string(content))
+		_, err = os.Stat(filepath.Join(target, "ourorg_payments_proto-1.0.0.dist-info", "RECORD"))
+		assert.NoError(t, err)
+	}
+}
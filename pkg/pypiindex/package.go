@@ -0,0 +1,326 @@
+package pypiindex
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/platinummonkey/spoke/pkg/api"
+	"github.com/platinummonkey/spoke/pkg/codegen/compiled"
+	"github.com/platinummonkey/spoke/pkg/codegen/packages"
+	"golang.org/x/mod/semver"
+)
+
+// wheelTime is the modification time of every wheel entry, the earliest a
+// zip can hold, so wheels of a version never change
+var wheelTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// wheelTag is the compatibility tag of wheels: generated code is pure
+// Python 3
+const wheelTag = "py3-none-any"
+
+// projectVersion is a version of a Spoke module served as a Python version
+type projectVersion struct {
+	pythonVersion string
+	version       *api.Version
+}
+
+// prerelease matches the pre-releases that have a Python equivalent, such
+// as beta.2 or rc1
+var prerelease = regexp.MustCompile(`^(alpha|a|beta|b|rc|c|pre|preview)\.?([0-9]*)$`)
+
+// prereleaseLabels maps pre-release names to their Python spelling
+var prereleaseLabels = map[string]string{
+	"alpha": "a", "a": "a",
+	"beta": "b", "b": "b",
+	"rc": "rc", "c": "rc", "pre": "rc", "preview": "rc",
+}
+
+// pythonVersion returns the normalized Python version of a Spoke version,
+// 1.2.0b1 for v1.2.0-beta.1, or "" when the version is no canonical
+// semantic version or has a pre-release Python versions cannot express
+func pythonVersion(version string) string {
+	v := packages.SemVer(version)
+	if !semver.IsValid("v"+v) || semver.Canonical("v"+v) != "v"+v {
+		return ""
+	}
+	release, pre, ok := strings.Cut(v, "-")
+	if !ok {
+		return release
+	}
+	m := prerelease.FindStringSubmatch(strings.ToLower(pre))
+	if m == nil {
+		return ""
+	}
+	n := 0
+	if m[2] != "" {
+		var err error
+		if n, err = strconv.Atoi(m[2]); err != nil {
+			return ""
+		}
+	}
+	return release + prereleaseLabels[m[1]] + strconv.Itoa(n)
+}
+
+// resolve returns the Spoke module a project is served from
+func (h *Handlers) resolve(project string) (string, error) {
+	candidate := strings.TrimPrefix(project, normalize(h.config.NamePrefix))
+	candidate = strings.TrimSuffix(candidate, normalize(h.config.NameSuffix))
+
+	name, ok, err := compiled.FindModule(h.storage, project, candidate, h.config.ProjectName)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrProjectNotFound, project)
+	}
+	return name, nil
+}
+
+// versions returns the versions of a module served as Python versions, in
+// semantic version order
+func (h *Handlers) versions(name string) ([]projectVersion, error) {
+	versions, err := h.storage.ListVersions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []projectVersion
+	for _, v := range versions {
+		if pv := pythonVersion(v.Version); pv != "" {
+			result = append(result, projectVersion{pythonVersion: pv, version: v})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return semver.Compare("v"+packages.SemVer(result[i].version.Version), "v"+packages.SemVer(result[j].version.Version)) < 0
+	})
+	return result, nil
+}
+
+// lookup returns the version of a module served as a Python version
+func (h *Handlers) lookup(name, pv string) (*api.Version, error) {
+	versions, err := h.versions(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.pythonVersion == pv {
+			return h.storage.GetVersion(name, v.version.Version)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s==%s", ErrVersionNotFound, name, pv)
+}
+
+// pyproject is the project metadata the pip package generator writes to
+// pyproject.toml
+type pyproject struct {
+	Description    string
+	RequiresPython string
+	Dependencies   []string
+}
+
+// parsePyproject reads the metadata of the pyproject.toml written by the
+// pip package generator. It reads the keys the generator writes, one per
+// line, rather than TOML in general.
+func parsePyproject(content string) pyproject {
+	var p pyproject
+	inDependencies := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if inDependencies {
+			if line == "]" {
+				inDependencies = false
+			} else if dep := strings.Trim(strings.TrimSuffix(line, ","), `"`); dep != "" {
+				p.Dependencies = append(p.Dependencies, dep)
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, " = ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "description":
+			p.Description = strings.Trim(value, `"`)
+		case "requires-python":
+			p.RequiresPython = strings.Trim(value, `"`)
+		case "dependencies":
+			inDependencies = value == "["
+		}
+	}
+	return p
+}
+
+// release is a version of a project as the index serves it: its core
+// metadata, read from the pip package generator's files
+type release struct {
+	project       string
+	pythonVersion string
+	meta          pyproject
+	readme        string
+	packageFiles  map[string]bool // Paths of the generator's files
+}
+
+// release generates the package files of a version with the pip package
+// generator, naming the project and the projects of the version's
+// dependencies
+func (h *Handlers) release(project string, version *api.Version) (*release, error) {
+	pv := pythonVersion(version.Version)
+	req := &packages.GenerateRequest{
+		ModuleName:  version.ModuleName,
+		Version:     pv,
+		Language:    string(api.LanguagePython),
+		IncludeGRPC: h.config.IncludeGRPC,
+		Options:     map[string]string{packages.OptionPackageName: project},
+	}
+	for _, dep := range version.Dependencies {
+		name, v, ok := strings.Cut(dep, "@")
+		if !ok {
+			continue
+		}
+		if depVersion := pythonVersion(v); depVersion != "" {
+			req.Dependencies = append(req.Dependencies, packages.Dependency{
+				Name:    h.config.ProjectName(name),
+				Version: depVersion,
+				Module:  name,
+			})
+		}
+	}
+
+	generated, err := h.generator.Generate(req)
+	if err != nil {
+		return nil, err
+	}
+	r := &release{project: project, pythonVersion: pv, packageFiles: make(map[string]bool)}
+	for _, file := range generated {
+		r.packageFiles[file.Path] = true
+		switch file.Path {
+		case "pyproject.toml":
+			r.meta = parsePyproject(string(file.Content))
+		case "README.md":
+			r.readme = string(file.Content)
+		}
+	}
+	return r, nil
+}
+
+// wheelName returns the file name of the wheel of a release
+func (r *release) wheelName() string {
+	return distributionName(r.project) + "-" + r.pythonVersion + "-" + wheelTag + ".whl"
+}
+
+// distInfo returns the .dist-info directory of the wheel of a release
+func (r *release) distInfo() string {
+	return distributionName(r.project) + "-" + r.pythonVersion + ".dist-info"
+}
+
+// metadata returns the core metadata of a release
+func (r *release) metadata() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Metadata-Version: 2.1\nName: %s\nVersion: %s\n", r.project, r.pythonVersion)
+	if r.meta.Description != "" {
+		fmt.Fprintf(&buf, "Summary: %s\n", r.meta.Description)
+	}
+	if r.meta.RequiresPython != "" {
+		fmt.Fprintf(&buf, "Requires-Python: %s\n", r.meta.RequiresPython)
+	}
+	for _, dep := range r.meta.Dependencies {
+		fmt.Fprintf(&buf, "Requires-Dist: %s\n", dep)
+	}
+	if r.readme != "" {
+		fmt.Fprintf(&buf, "Description-Content-Type: text/markdown\n\n%s", r.readme)
+	}
+	return buf.Bytes()
+}
+
+// wheel returns the wheel of a version, compiling the version first when it
+// has not been compiled for the project
+func (h *Handlers) wheel(ctx context.Context, r *release, version *api.Version) ([]byte, error) {
+	files, err := h.source.Files(ctx, version, compiled.Target{
+		Language:    api.LanguagePython,
+		PackageName: r.project,
+		IncludeGRPC: h.config.IncludeGRPC,
+		Options:     map[string]string{packages.OptionPackageName: r.project},
+	})
+	if err != nil {
+		return nil, err
+	}
+	wheel, err := buildWheel(r, files)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s==%s", err, r.project, r.pythonVersion)
+	}
+	return wheel, nil
+}
+
+// buildWheel packs the Python modules and stubs of a version's compiled code
+// into a wheel, at the import paths protoc generated them for, along with
+// the wheel's metadata. The package generator's files, such as setup.py,
+// are left out: the wheel's metadata takes their place. Entries are written in path order with fixed times,
+// so the wheel, and the hash pip checks it against, never change.
+func buildWheel(r *release, files []api.File) ([]byte, error) {
+	contents := make(map[string][]byte)
+	for _, file := range files {
+		name := path.Clean(strings.TrimPrefix(file.Path, "/"))
+		if strings.HasPrefix(name, "../") || r.packageFiles[name] || (path.Ext(name) != ".py" && path.Ext(name) != ".pyi") {
+			continue
+		}
+		contents[name] = []byte(file.Content)
+	}
+	if len(contents) == 0 {
+		return nil, ErrNoCode
+	}
+	names := make([]string, 0, len(contents)+2)
+	for name := range contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	distInfo := r.distInfo()
+	contents[distInfo+"/METADATA"] = r.metadata()
+	contents[distInfo+"/WHEEL"] = []byte("Wheel-Version: 1.0\nGenerator: spoke\nRoot-Is-Purelib: true\nTag: " + wheelTag + "\n")
+	names = append(names, distInfo+"/METADATA", distInfo+"/WHEEL")
+
+	var record bytes.Buffer
+	for _, name := range names {
+		sum := sha256.Sum256(contents[name])
+		fmt.Fprintf(&record, "%s,sha256=%s,%d\n", name, base64.RawURLEncoding.EncodeToString(sum[:]), len(contents[name]))
+	}
+	fmt.Fprintf(&record, "%s/RECORD,,\n", distInfo)
+	contents[distInfo+"/RECORD"] = record.Bytes()
+	names = append(names, distInfo+"/RECORD")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: wheelTime}
+		header.SetMode(0644)
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(contents[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sha256Hex returns the hex SHA-256 of a file, as index links carry it
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
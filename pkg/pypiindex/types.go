@@ -0,0 +1,61 @@
+package pypiindex
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Config holds Python package index configuration
+type Config struct {
+	// NamePrefix and NameSuffix are added to module names to name projects:
+	// with prefix "ourorg-" and suffix "-proto", module "payments" is the
+	// project ourorg-payments-proto
+	NamePrefix string
+	NameSuffix string
+
+	// IncludeGRPC generates gRPC service code along with the messages when
+	// compiling on demand
+	IncludeGRPC bool
+}
+
+// DefaultConfig returns the default configuration, serving modules under
+// their own names
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+// nameAffix matches the characters Python allows in project names
+var nameAffix = regexp.MustCompile(`^[A-Za-z0-9._-]*$`)
+
+// Validate checks that projects get valid Python project names
+func (c *Config) Validate() error {
+	if !nameAffix.MatchString(c.NamePrefix) {
+		return fmt.Errorf("invalid project name prefix %q", c.NamePrefix)
+	}
+	if !nameAffix.MatchString(c.NameSuffix) {
+		return fmt.Errorf("invalid project name suffix %q", c.NameSuffix)
+	}
+	return nil
+}
+
+// ProjectName returns the normalized name of the project a Spoke module is
+// served as
+func (c *Config) ProjectName(moduleName string) string {
+	return normalize(c.NamePrefix + moduleName + c.NameSuffix)
+}
+
+// separators matches the runs of characters PEP 503 normalizes to "-",
+// along with the spaces and slashes module names may contain
+var separators = regexp.MustCompile(`[-_. /]+`)
+
+// normalize normalizes a project name as PEP 503 specifies
+func normalize(name string) string {
+	return separators.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// distributionName returns the name of a project as it appears in file
+// names
+func distributionName(project string) string {
+	return strings.ReplaceAll(project, "-", "_")
+}
@@ -0,0 +1,77 @@
+package reporting
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+type checkstyleResult struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// WriteCheckstyle writes a report as Checkstyle XML. Findings without a file
+// are grouped under the tool name.
+func WriteCheckstyle(w io.Writer, report *Report) error {
+	result := checkstyleResult{Version: "4.3"}
+
+	index := make(map[string]int)
+	addFile := func(name string) int {
+		if i, ok := index[name]; ok {
+			return i
+		}
+		index[name] = len(result.Files)
+		result.Files = append(result.Files, checkstyleFile{Name: name})
+		return index[name]
+	}
+
+	for _, file := range report.Files {
+		addFile(file)
+	}
+	for _, f := range report.Findings {
+		name := f.File
+		if name == "" {
+			name = report.Tool
+		}
+		i := addFile(name)
+		result.Files[i].Errors = append(result.Files[i].Errors, checkstyleError{
+			Line:     f.Line,
+			Column:   f.Column,
+			Severity: checkstyleSeverity(f.Level),
+			Message:  findingText(f),
+			Source:   report.Tool + "." + f.RuleID,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func checkstyleSeverity(level Level) string {
+	if level == LevelNote {
+		return "info"
+	}
+	return string(level)
+}
@@ -0,0 +1,39 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCheckstyle(t *testing.T) {
+	report := &Report{
+		Tool:  "spoke-compatibility",
+		Files: []string{"user.proto"},
+		Findings: []Finding{
+			{RuleID: "FIELD_REMOVED", Level: LevelError, Message: "Field 2 (email) was removed", File: "user.proto", Line: 6, Help: "Reserve it."},
+			{RuleID: "PACKAGE_CHANGED", Level: LevelNote, Message: "Package changed"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCheckstyle(&buf, report))
+
+	var result checkstyleResult
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &result))
+	require.Len(t, result.Files, 2)
+
+	assert.Equal(t, "user.proto", result.Files[0].Name)
+	require.Len(t, result.Files[0].Errors, 1)
+	e := result.Files[0].Errors[0]
+	assert.Equal(t, 6, e.Line)
+	assert.Equal(t, "error", e.Severity)
+	assert.Equal(t, "spoke-compatibility.FIELD_REMOVED", e.Source)
+	assert.Equal(t, "Field 2 (email) was removed Reserve it.", e.Message)
+
+	assert.Equal(t, "spoke-compatibility", result.Files[1].Name)
+	assert.Equal(t, "info", result.Files[1].Errors[0].Severity)
+}
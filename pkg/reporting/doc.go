@@ -0,0 +1,30 @@
+// Package reporting renders compatibility and lint results in the formats
+// CI systems and code scanning dashboards consume.
+//
+// # Overview
+//
+// Results are first normalized into a Report: a tool name, the rules that
+// fired and one Finding per violation, with the file and line resolved from
+// the protobuf AST positions. A Report is then written as:
+//
+//   - SARIF 2.1.0 (GitHub code scanning, GitLab, Azure DevOps)
+//   - JUnit XML (CI test reports, one test suite per file)
+//   - Checkstyle XML (Jenkins warnings, reviewdog)
+//   - GitHub Actions workflow commands (inline PR annotations)
+//
+// # Usage
+//
+//	result, _ := compatibility.CheckCompatibility(oldSchema, newSchema, mode)
+//	report := reporting.FromCompatibility(result, "proto/")
+//	reporting.Write(os.Stdout, reporting.FormatSARIF, report)
+//
+// Lint results are converted the same way:
+//
+//	report := reporting.FromLint(results, engine.Registry())
+//
+// # Related Packages
+//
+//   - pkg/compatibility: Breaking change detection
+//   - pkg/linter: Style and quality rules
+//   - pkg/cli: Exposes the formats through --format
+package reporting
@@ -0,0 +1,61 @@
+package reporting
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteGitHub writes a report as GitHub Actions workflow commands, which
+// the Actions runner turns into inline annotations on the pull request:
+//
+//	::error file=api/user.proto,line=12,title=FIELD_REMOVED::Field 2 (email) was removed
+func WriteGitHub(w io.Writer, report *Report) error {
+	for _, f := range report.Findings {
+		props := make([]string, 0, 4)
+		if f.File != "" {
+			props = append(props, "file="+escapeGitHubProperty(f.File))
+			if f.Line > 0 {
+				props = append(props, fmt.Sprintf("line=%d", f.Line))
+			}
+			if f.Column > 0 {
+				props = append(props, fmt.Sprintf("col=%d", f.Column))
+			}
+		}
+		props = append(props, "title="+escapeGitHubProperty(f.RuleID))
+
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n",
+			githubCommand(f.Level),
+			strings.Join(props, ","),
+			escapeGitHubData(findingText(f)),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func githubCommand(level Level) string {
+	switch level {
+	case LevelError:
+		return "error"
+	case LevelWarning:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// escapeGitHubData escapes a workflow command message
+func escapeGitHubData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeGitHubProperty escapes a workflow command property value
+func escapeGitHubProperty(s string) string {
+	s = escapeGitHubData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
@@ -0,0 +1,34 @@
+package reporting
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteGitHub(t *testing.T) {
+	report := &Report{
+		Findings: []Finding{
+			{RuleID: "FIELD_REMOVED", Level: LevelError, Message: "Field 2 (email) was removed", File: "api/user.proto", Line: 12},
+			{RuleID: "naming", Level: LevelWarning, Message: "100% bad,\nreally", File: "a:b.proto", Line: 3, Column: 5},
+			{RuleID: "PACKAGE_CHANGED", Level: LevelNote, Message: "Package changed"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteGitHub(&buf, report))
+
+	assert.Equal(t,
+		"::error file=api/user.proto,line=12,title=FIELD_REMOVED::Field 2 (email) was removed\n"+
+			"::warning file=a%3Ab.proto,line=3,col=5,title=naming::100%25 bad,%0Areally\n"+
+			"::notice title=PACKAGE_CHANGED::Package changed\n",
+		buf.String())
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, Write(&buf, Format("html"), &Report{}))
+	assert.NoError(t, Write(&buf, FormatGitHub, &Report{}))
+}
@@ -0,0 +1,128 @@
+package reporting
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes a report as JUnit XML. Each file becomes a test suite
+// with one test case per finding; errors and warnings are failures, notes
+// pass with the message as output. Files without findings get a single
+// passing test case so CI shows them as checked.
+func WriteJUnit(w io.Writer, report *Report) error {
+	suites := junitTestSuites{Name: report.Tool}
+
+	byFile := make(map[string][]Finding)
+	order := make([]string, 0, len(report.Files))
+	seen := make(map[string]bool)
+	for _, file := range report.Files {
+		if !seen[file] {
+			seen[file] = true
+			order = append(order, file)
+		}
+	}
+	for _, f := range report.Findings {
+		file := f.File
+		if file == "" {
+			file = report.Tool
+		}
+		if !seen[file] {
+			seen[file] = true
+			order = append(order, file)
+		}
+		byFile[file] = append(byFile[file], f)
+	}
+
+	for _, file := range order {
+		suite := junitTestSuite{Name: file}
+		findings := byFile[file]
+		if len(findings) == 0 {
+			suite.TestCases = append(suite.TestCases, junitTestCase{Name: report.Tool, ClassName: file})
+		}
+
+		for _, f := range findings {
+			tc := junitTestCase{
+				Name:      junitCaseName(f),
+				ClassName: file,
+			}
+			if f.Level == LevelNote {
+				tc.SystemOut = findingText(f)
+			} else {
+				tc.Failure = &junitFailure{
+					Message: f.Message,
+					Type:    string(f.Level),
+					Body:    junitFailureBody(f),
+				}
+				suite.Failures++
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+
+		suite.Tests = len(suite.TestCases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitCaseName(f Finding) string {
+	switch {
+	case f.Location != "":
+		return fmt.Sprintf("%s: %s", f.RuleID, f.Location)
+	case f.Line > 0:
+		return fmt.Sprintf("%s: line %d", f.RuleID, f.Line)
+	default:
+		return f.RuleID
+	}
+}
+
+func junitFailureBody(f Finding) string {
+	body := f.Message
+	if f.File != "" && f.Line > 0 {
+		body = fmt.Sprintf("%s:%d: %s", f.File, f.Line, f.Message)
+	}
+	if f.Help != "" {
+		body += "\n" + f.Help
+	}
+	return body
+}
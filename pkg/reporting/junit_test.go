@@ -0,0 +1,46 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	report := &Report{
+		Tool:  "spoke-lint",
+		Files: []string{"a.proto", "b.proto"},
+		Findings: []Finding{
+			{RuleID: "enum-zero-value", Level: LevelError, Message: "Enum must start at zero", File: "b.proto", Line: 4},
+			{RuleID: "comment-style", Level: LevelNote, Message: "Prefer // comments", File: "b.proto", Line: 9},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, report))
+	assert.True(t, strings.HasPrefix(buf.String(), xml.Header))
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	assert.Equal(t, 3, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	require.Len(t, suites.Suites, 2)
+
+	passing := suites.Suites[0]
+	assert.Equal(t, "a.proto", passing.Name)
+	require.Len(t, passing.TestCases, 1)
+	assert.Nil(t, passing.TestCases[0].Failure)
+
+	failing := suites.Suites[1]
+	assert.Equal(t, "b.proto", failing.Name)
+	assert.Equal(t, 1, failing.Failures)
+	require.NotNil(t, failing.TestCases[0].Failure)
+	assert.Equal(t, "error", failing.TestCases[0].Failure.Type)
+	assert.Contains(t, failing.TestCases[0].Failure.Body, "b.proto:4:")
+	assert.Nil(t, failing.TestCases[1].Failure)
+	assert.Equal(t, "Prefer // comments", failing.TestCases[1].SystemOut)
+}
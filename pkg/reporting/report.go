@@ -0,0 +1,217 @@
+package reporting
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/platinummonkey/spoke/pkg/compatibility"
+	"github.com/platinummonkey/spoke/pkg/linter"
+)
+
+// Format is an output format for reports
+type Format string
+
+const (
+	FormatSARIF      Format = "sarif"
+	FormatJUnit      Format = "junit"
+	FormatCheckstyle Format = "checkstyle"
+	FormatGitHub     Format = "github"
+)
+
+// Formats returns all supported formats
+func Formats() []Format {
+	return []Format{FormatSARIF, FormatJUnit, FormatCheckstyle, FormatGitHub}
+}
+
+// ParseFormat converts a string to a Format
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats() {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown report format: %s", s)
+}
+
+// Level is the severity of a finding, using SARIF's vocabulary
+type Level string
+
+const (
+	LevelError   Level = "error"
+	LevelWarning Level = "warning"
+	LevelNote    Level = "note"
+)
+
+// Finding is a single reported problem
+type Finding struct {
+	RuleID   string
+	Level    Level
+	Message  string
+	File     string // Path of the proto file, empty when not tied to a file
+	Line     int    // 1-based, 0 when unknown
+	Column   int    // 1-based, 0 when unknown
+	Location string // Fully qualified element, e.g. pkg.User.email
+	Help     string // How to resolve the finding
+}
+
+// Rule describes a rule referenced by findings
+type Rule struct {
+	ID          string
+	Description string
+}
+
+// Report is the format-independent form of a set of results
+type Report struct {
+	Tool     string
+	Rules    []Rule
+	Findings []Finding
+	Files    []string // Files that were checked, including those without findings
+}
+
+// Write renders a report in the given format
+func Write(w io.Writer, format Format, report *Report) error {
+	switch format {
+	case FormatSARIF:
+		return WriteSARIF(w, report)
+	case FormatJUnit:
+		return WriteJUnit(w, report)
+	case FormatCheckstyle:
+		return WriteCheckstyle(w, report)
+	case FormatGitHub:
+		return WriteGitHub(w, report)
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+// FromCompatibility converts a compatibility check into a report. File paths
+// recorded in the schema are relative to the schema root; baseDir is joined
+// in front of them so annotations resolve from the repository root.
+func FromCompatibility(result *compatibility.CheckResult, baseDir string) *Report {
+	report := &Report{Tool: "spoke-compatibility"}
+	seenRules := make(map[string]bool)
+	seenFiles := make(map[string]bool)
+
+	for _, v := range result.Violations {
+		file := v.File
+		if file != "" && baseDir != "" {
+			file = path.Join(strings.TrimSuffix(baseDir, "/"), file)
+		}
+
+		help := v.Suggestion
+		if help == "" {
+			help = compatibility.MigrationTip(v.Kind)
+		}
+
+		report.Findings = append(report.Findings, Finding{
+			RuleID:   v.Rule,
+			Level:    compatibilityLevel(v.Level),
+			Message:  v.Message,
+			File:     file,
+			Line:     v.Line,
+			Location: v.Location,
+			Help:     help,
+		})
+
+		if !seenRules[v.Rule] {
+			seenRules[v.Rule] = true
+			report.Rules = append(report.Rules, Rule{ID: v.Rule, Description: ruleDescription(v.Rule)})
+		}
+		if file != "" && !seenFiles[file] {
+			seenFiles[file] = true
+			report.Files = append(report.Files, file)
+		}
+	}
+
+	sort.Strings(report.Files)
+	return report
+}
+
+func compatibilityLevel(level compatibility.ViolationLevel) Level {
+	switch level {
+	case compatibility.ViolationLevelError:
+		return LevelError
+	case compatibility.ViolationLevelWarning:
+		return LevelWarning
+	default:
+		return LevelNote
+	}
+}
+
+// ruleDescription turns a rule ID such as FIELD_TYPE_CHANGED into a sentence
+func ruleDescription(id string) string {
+	words := strings.ToLower(strings.ReplaceAll(id, "_", " "))
+	if words == "" {
+		return ""
+	}
+	return strings.ToUpper(words[:1]) + words[1:]
+}
+
+// FromLint converts lint results into a report. The registry, when given,
+// supplies rule descriptions.
+func FromLint(results []linter.LintResult, registry *linter.RuleRegistry) *Report {
+	report := &Report{Tool: "spoke-lint"}
+	seenRules := make(map[string]bool)
+
+	sorted := make([]linter.LintResult, len(results))
+	copy(sorted, results)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].FilePath < sorted[j].FilePath })
+
+	for _, result := range sorted {
+		report.Files = append(report.Files, result.FilePath)
+
+		for _, v := range result.Violations {
+			finding := Finding{
+				RuleID:  v.Rule,
+				Level:   lintLevel(v.Severity),
+				Message: v.Message,
+				File:    result.FilePath,
+				Line:    v.Position.Line,
+				Column:  v.Position.Column,
+			}
+			if v.SuggestedFix != nil {
+				finding.Help = v.SuggestedFix.Description
+			}
+			report.Findings = append(report.Findings, finding)
+
+			if !seenRules[v.Rule] {
+				seenRules[v.Rule] = true
+				rule := Rule{ID: v.Rule}
+				if registry != nil {
+					if r, ok := registry.GetRule(v.Rule); ok {
+						rule.Description = r.Description()
+					}
+				}
+				report.Rules = append(report.Rules, rule)
+			}
+		}
+	}
+
+	sort.Slice(report.Rules, func(i, j int) bool { return report.Rules[i].ID < report.Rules[j].ID })
+	return report
+}
+
+func lintLevel(severity linter.Severity) Level {
+	switch severity {
+	case linter.SeverityError:
+		return LevelError
+	case linter.SeverityWarning:
+		return LevelWarning
+	default:
+		return LevelNote
+	}
+}
+
+// Count returns the number of findings at the given level
+func (r *Report) Count(level Level) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Level == level {
+			n++
+		}
+	}
+	return n
+}
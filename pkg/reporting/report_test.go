@@ -0,0 +1,113 @@
+package reporting
+
+import (
+	"testing"
+
+	"github.com/platinummonkey/spoke/pkg/api/protobuf"
+	"github.com/platinummonkey/spoke/pkg/compatibility"
+	"github.com/platinummonkey/spoke/pkg/linter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const oldUserProto = `syntax = "proto3";
+package users.v1;
+
+message User {
+  string id = 1;
+  string email = 2;
+  string name = 3;
+}
+`
+
+const newUserProto = `syntax = "proto3";
+package users.v1;
+
+message User {
+  string id = 1;
+  int64 name = 3;
+  string nickname = 4;
+}
+`
+
+func testCompatibilityReport(t *testing.T) *Report {
+	t.Helper()
+	oldSchema, err := compatibility.ParseSchemaFiles([]compatibility.SourceFile{{Path: "users/user.proto", Content: oldUserProto}})
+	require.NoError(t, err)
+	newSchema, err := compatibility.ParseSchemaFiles([]compatibility.SourceFile{{Path: "users/user.proto", Content: newUserProto}})
+	require.NoError(t, err)
+	result, err := compatibility.CheckCompatibility(oldSchema, newSchema, compatibility.CompatibilityModeBackward)
+	require.NoError(t, err)
+	return FromCompatibility(result, "proto/")
+}
+
+func TestFromCompatibility(t *testing.T) {
+	report := testCompatibilityReport(t)
+
+	assert.Equal(t, "spoke-compatibility", report.Tool)
+	assert.Equal(t, []string{"proto/users/user.proto"}, report.Files)
+
+	byRule := make(map[string]Finding)
+	for _, f := range report.Findings {
+		byRule[f.RuleID] = f
+	}
+
+	removed := byRule["FIELD_REMOVED"]
+	assert.Equal(t, LevelError, removed.Level)
+	assert.Equal(t, "proto/users/user.proto", removed.File)
+	assert.Equal(t, 6, removed.Line, "removed field points at its old declaration")
+	assert.Equal(t, "users.v1.User.email", removed.Location)
+	assert.NotEmpty(t, removed.Help)
+
+	typeChanged := byRule["FIELD_TYPE_CHANGED"]
+	assert.Equal(t, 6, typeChanged.Line, "changed field points at its new declaration")
+
+	added := byRule["FIELD_ADDED"]
+	assert.Equal(t, LevelNote, added.Level)
+	assert.Equal(t, 7, added.Line)
+
+	assert.Equal(t, 2, report.Count(LevelError))
+}
+
+func TestFromLint(t *testing.T) {
+	results := []linter.LintResult{
+		{
+			FilePath: "b.proto",
+			Violations: []linter.Violation{{
+				Rule:         "field-names-lower-snake-case",
+				Severity:     linter.SeverityError,
+				Message:      "Field name 'BadField' should be lower_snake_case",
+				Position:     protobuf.Position{Line: 5, Column: 3},
+				SuggestedFix: &linter.Fix{Description: "Rename to 'bad_field'"},
+			}},
+		},
+		{FilePath: "a.proto"},
+	}
+
+	report := FromLint(results, nil)
+	assert.Equal(t, "spoke-lint", report.Tool)
+	assert.Equal(t, []string{"a.proto", "b.proto"}, report.Files)
+	require.Len(t, report.Findings, 1)
+
+	f := report.Findings[0]
+	assert.Equal(t, LevelError, f.Level)
+	assert.Equal(t, 5, f.Line)
+	assert.Equal(t, 3, f.Column)
+	assert.Equal(t, "Rename to 'bad_field'", f.Help)
+	assert.Equal(t, []Rule{{ID: "field-names-lower-snake-case"}}, report.Rules)
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range Formats() {
+		got, err := ParseFormat(string(f))
+		require.NoError(t, err)
+		assert.Equal(t, f, got)
+	}
+
+	got, err := ParseFormat("SARIF")
+	require.NoError(t, err)
+	assert.Equal(t, FormatSARIF, got)
+
+	_, err = ParseFormat("html")
+	assert.Error(t, err)
+}
@@ -0,0 +1,52 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	report := testCompatibilityReport(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSARIF(&buf, report))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	assert.Equal(t, "spoke-compatibility", run.Tool.Driver.Name)
+	assert.Len(t, run.Results, len(report.Findings))
+
+	for _, result := range run.Results {
+		assert.Equal(t, result.RuleID, run.Tool.Driver.Rules[result.RuleIndex].ID)
+		require.Len(t, result.Locations, 1)
+		loc := result.Locations[0]
+		assert.Equal(t, "proto/users/user.proto", loc.PhysicalLocation.ArtifactLocation.URI)
+		require.NotNil(t, loc.PhysicalLocation.Region)
+		assert.Greater(t, loc.PhysicalLocation.Region.StartLine, 0)
+		assert.NotEmpty(t, loc.LogicalLocations[0].FullyQualifiedName)
+	}
+}
+
+func TestWriteSARIF_NoLocation(t *testing.T) {
+	report := &Report{
+		Tool:     "spoke-compatibility",
+		Rules:    []Rule{{ID: "PACKAGE_CHANGED"}},
+		Findings: []Finding{{RuleID: "PACKAGE_CHANGED", Level: LevelError, Message: "Package changed"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSARIF(&buf, report))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Empty(t, log.Runs[0].Results[0].Locations)
+	assert.Nil(t, log.Runs[0].Tool.Driver.Rules[0].ShortDescription)
+}
@@ -3,13 +3,16 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/platinummonkey/spoke/pkg/api"
 )
@@ -47,11 +50,22 @@ func (s *FileSystemStorage) CreateModule(module *api.Module) error {
 	return nil
 }
 
+// UpdateModule replaces the metadata of an existing module
+func (s *FileSystemStorage) UpdateModule(module *api.Module) error {
+	if _, err := s.GetModule(module.Name); err != nil {
+		return err
+	}
+	return s.CreateModule(module)
+}
+
 // GetModule implements Storage.GetModule
 func (s *FileSystemStorage) GetModule(name string) (*api.Module, error) {
 	moduleFile := filepath.Join(s.rootDir, name, "module.json")
 	data, err := os.ReadFile(moduleFile)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("module %s: %w", name, api.ErrNotFound)
+		}
 		return nil, fmt.Errorf("failed to read module file: %w", err)
 	}
 
@@ -272,6 +286,77 @@ func (s *FileSystemStorage) UpdateVersion(version *api.Version) error {
 	return nil
 }
 
+// PutCompilation implements api.CompilationUpdater. The version is locked
+// while it is updated, also against other processes sharing the directory.
+func (s *FileSystemStorage) PutCompilation(moduleName, version string, info api.CompilationInfo) error {
+	versionDir := filepath.Join(s.rootDir, moduleName, "versions", version)
+	unlock, err := lockDir(versionDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ver, err := s.GetVersion(moduleName, version)
+	if err != nil {
+		return err
+	}
+	ver.SetCompilation(info)
+	data, err := json.Marshal(ver)
+	if err != nil {
+		return fmt.Errorf("failed to marshal version: %w", err)
+	}
+
+	// Readers see the old or the new version, never part of one
+	tmp, err := os.CreateTemp(versionDir, "version-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write version file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write version file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write version file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write version file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(versionDir, "version.json")); err != nil {
+		return fmt.Errorf("failed to write version file: %w", err)
+	}
+	return nil
+}
+
+// lockDir takes the lock of a directory, held by creating a lock file in it.
+// Locks left by crashed processes are broken once they are stale.
+func lockDir(dir string) (unlock func(), err error) {
+	const (
+		staleAfter = 30 * time.Second
+		waitFor    = 10 * time.Second
+	)
+	lockFile := filepath.Join(dir, ".lock")
+	deadline := time.Now().Add(waitFor)
+	for {
+		f, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockFile) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to lock %s: %w", dir, err)
+		}
+		if info, err := os.Stat(lockFile); err == nil && time.Since(info.ModTime()) > staleAfter {
+			os.Remove(lockFile)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock on %s", dir)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 // Context-aware methods that implement the storage.Storage interface
 // These delegate to the existing non-context methods for backward compatibility
 
@@ -423,4 +508,5 @@ func (s *FileSystemStorage) HealthCheck(ctx context.Context) error {
 }
 
 // Verify that FileSystemStorage implements storage.Storage at compile time
-var _ Storage = (*FileSystemStorage)(nil) 
\ No newline at end of file
+var _ Storage = (*FileSystemStorage)(nil)
+var _ api.CompilationUpdater = (*FileSystemStorage)(nil) 
\ No newline at end of file
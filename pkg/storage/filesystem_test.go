@@ -2,10 +2,12 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -128,8 +130,8 @@ func TestFileSystemStorage_GetModule(t *testing.T) {
 		}
 
 		_, err = storage.GetModule("nonexistent")
-		if err == nil {
-			t.Error("Expected error for non-existent module")
+		if !errors.Is(err, api.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for non-existent module, got %v", err)
 		}
 	})
 }
@@ -481,6 +483,57 @@ func TestFileSystemStorage_UpdateVersion(t *testing.T) {
 	})
 }
 
+func TestFileSystemStorage_PutCompilation(t *testing.T) {
+	storage, err := NewFileSystemStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	if err := storage.CreateVersion(&api.Version{ModuleName: "users", Version: "v1.0.0"}); err != nil {
+		t.Fatalf("Failed to create version: %v", err)
+	}
+
+	// Compilations stored at the same time all survive
+	languages := []api.Language{"go", "python", "java", "rust"}
+	var wg sync.WaitGroup
+	for _, language := range languages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			info := api.CompilationInfo{Language: language, PackageName: "users", Version: "v1.0.0"}
+			if err := storage.PutCompilation("users", "v1.0.0", info); err != nil {
+				t.Errorf("Failed to store %s compilation: %v", language, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Storing a language again replaces its compilation
+	info := api.CompilationInfo{Language: "go", PackageName: "users", Version: "v1.0.0", Files: []api.File{{Path: "users.pb.go"}}}
+	if err := storage.PutCompilation("users", "v1.0.0", info); err != nil {
+		t.Fatalf("Failed to store compilation: %v", err)
+	}
+
+	version, err := storage.GetVersion("users", "v1.0.0")
+	if err != nil {
+		t.Fatalf("Failed to get version: %v", err)
+	}
+	if len(version.CompilationInfo) != len(languages) {
+		t.Fatalf("Expected %d compilations, got %d", len(languages), len(version.CompilationInfo))
+	}
+	for _, compiled := range version.CompilationInfo {
+		if compiled.Language == "go" && len(compiled.Files) != 1 {
+			t.Errorf("Expected the go compilation to be replaced, got %v", compiled.Files)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(storage.rootDir, "users", "versions", "v1.0.0", ".lock")); !os.IsNotExist(err) {
+		t.Error("Version lock should have been released")
+	}
+
+	if err := storage.PutCompilation("users", "v9.9.9", info); err == nil {
+		t.Error("Expected error storing the compilation of a missing version")
+	}
+}
+
 func TestFileSystemStorage_ContextMethods(t *testing.T) {
 	t.Run("CreateModuleContext delegates correctly", func(t *testing.T) {
 		tmpDir := t.TempDir()
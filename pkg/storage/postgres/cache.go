@@ -63,6 +63,18 @@ func (c *RedisCache) CreateModule(module *api.Module) error {
 	return nil
 }
 
+// UpdateModule updates a module and invalidates its cache entries
+func (c *RedisCache) UpdateModule(module *api.Module) error {
+	if err := c.storage.UpdateModule(module); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	c.redis.Del(ctx, "modules:list", fmt.Sprintf("module:%s", module.Name))
+
+	return nil
+}
+
 // GetModule gets a module with caching
 func (c *RedisCache) GetModule(name string) (*api.Module, error) {
 	ctx := context.Background()
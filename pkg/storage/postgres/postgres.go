@@ -124,16 +124,23 @@ func (s *PostgresStorage) CreateModuleContext(ctx context.Context, module *api.M
 	)
 	defer span.End()
 
+	metadata, err := encodeModuleMetadata(module)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to encode module metadata")
+		return err
+	}
+
 	query := `
 		INSERT INTO modules (name, description, metadata)
 		VALUES ($1, $2, $3)
-		RETURNING id, created_at, updated_at
+		RETURNING created_at, updated_at
 	`
 
-	err := s.db.QueryRowContext(ctx, query,
+	err = s.db.QueryRowContext(ctx, query,
 		module.Name,
 		module.Description,
-		"{}",
+		metadata,
 	).Scan(&module.CreatedAt, &module.UpdatedAt)
 
 	if err != nil {
@@ -173,27 +180,34 @@ func (s *PostgresStorage) GetModuleContext(ctx context.Context, name string) (*a
 	span.SetAttributes(attribute.Bool("cache.hit", false))
 
 	query := `
-		SELECT name, description, created_at, updated_at
+		SELECT name, description, metadata, created_at, updated_at
 		FROM modules
 		WHERE name = $1
 	`
 
 	var module api.Module
+	var metadata []byte
 	err := s.db.QueryRowContext(ctx, query, name).Scan(
 		&module.Name,
 		&module.Description,
+		&metadata,
 		&module.CreatedAt,
 		&module.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
 		span.SetStatus(codes.Error, "module not found")
-		return nil, fmt.Errorf("module not found: %s", name)
+		return nil, fmt.Errorf("module %s: %w", name, api.ErrNotFound)
 	} else if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to get module")
 		return nil, fmt.Errorf("failed to get module: %w", err)
 	}
+	if err := decodeModuleMetadata(metadata, &module); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to parse module metadata")
+		return nil, err
+	}
 
 	// Cache result
 	if s.redisClient != nil {
@@ -219,7 +233,7 @@ func (s *PostgresStorage) ListModulesPaginated(ctx context.Context, limit, offse
 
 	// Query page
 	query := `
-		SELECT name, description, created_at, updated_at
+		SELECT name, description, metadata, created_at, updated_at
 		FROM modules
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -234,16 +248,94 @@ func (s *PostgresStorage) ListModulesPaginated(ctx context.Context, limit, offse
 	var modules []*api.Module
 	for rows.Next() {
 		var m api.Module
-		err := rows.Scan(&m.Name, &m.Description, &m.CreatedAt, &m.UpdatedAt)
+		var metadata []byte
+		err := rows.Scan(&m.Name, &m.Description, &metadata, &m.CreatedAt, &m.UpdatedAt)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan module: %w", err)
 		}
+		if err := decodeModuleMetadata(metadata, &m); err != nil {
+			return nil, 0, err
+		}
 		modules = append(modules, &m)
 	}
 
 	return modules, total, nil
 }
 
+// UpdateModule changes the description and governance of a module
+func (s *PostgresStorage) UpdateModule(module *api.Module) error {
+	return s.UpdateModuleContext(context.Background(), module)
+}
+
+func (s *PostgresStorage) UpdateModuleContext(ctx context.Context, module *api.Module) error {
+	ctx, span := tracer.Start(ctx, "UpdateModule",
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", "UPDATE"),
+			attribute.String("db.table", "modules"),
+			attribute.String("module.name", module.Name),
+		),
+	)
+	defer span.End()
+
+	metadata, err := encodeModuleMetadata(module)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to encode module metadata")
+		return err
+	}
+
+	query := `
+		UPDATE modules
+		SET description = $2, metadata = $3, updated_at = NOW()
+		WHERE name = $1
+		RETURNING updated_at
+	`
+
+	err = s.db.QueryRowContext(ctx, query, module.Name, module.Description, metadata).Scan(&module.UpdatedAt)
+	if err == sql.ErrNoRows {
+		span.SetStatus(codes.Error, "module not found")
+		return fmt.Errorf("module not found: %s", module.Name)
+	} else if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to update module")
+		return fmt.Errorf("failed to update module: %w", err)
+	}
+
+	// Invalidate cache
+	if s.redisClient != nil {
+		s.redisClient.InvalidateModule(ctx, module.Name)
+	}
+
+	span.SetStatus(codes.Ok, "module updated successfully")
+	return nil
+}
+
+// moduleMetadata is what the metadata column of modules holds
+type moduleMetadata struct {
+	Governance *api.Governance `json:"governance,omitempty"`
+}
+
+func encodeModuleMetadata(module *api.Module) ([]byte, error) {
+	data, err := json.Marshal(moduleMetadata{Governance: module.Governance})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode module metadata: %w", err)
+	}
+	return data, nil
+}
+
+func decodeModuleMetadata(data []byte, module *api.Module) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var metadata moduleMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return fmt.Errorf("failed to parse module metadata: %w", err)
+	}
+	module.Governance = metadata.Governance
+	return nil
+}
+
 // Placeholder implementations for remaining methods
 
 func (s *PostgresStorage) CreateVersionContext(ctx context.Context, version *api.Version) error {
@@ -288,8 +380,8 @@ func (s *PostgresStorage) CreateVersionContext(ctx context.Context, version *api
 	// Insert version
 	var versionID int64
 	versionQuery := `
-		INSERT INTO versions (module_id, version, dependencies, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO versions (module_id, version, dependencies, quality, lint_report, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id
 	`
 
@@ -307,10 +399,32 @@ func (s *PostgresStorage) CreateVersionContext(ctx context.Context, version *api
 		}
 	}
 
+	// Quality is stored as JSON, NULL when the version was not scored
+	var qualityJSON []byte
+	if version.Quality != nil {
+		qualityJSON, err = json.Marshal(version.Quality)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to encode quality")
+			return fmt.Errorf("failed to encode quality: %w", err)
+		}
+	}
+	var lintJSON []byte
+	if version.Lint != nil {
+		lintJSON, err = json.Marshal(version.Lint)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to encode lint report")
+			return fmt.Errorf("failed to encode lint report: %w", err)
+		}
+	}
+
 	err = tx.QueryRowContext(ctx, versionQuery,
 		moduleID,
 		version.Version,
 		depsJSON,
+		qualityJSON,
+		lintJSON,
 		version.CreatedAt,
 		now,
 	).Scan(&versionID)
@@ -402,7 +516,7 @@ func (s *PostgresStorage) GetVersionContext(ctx context.Context, moduleName, ver
 
 	// Get version metadata
 	query := `
-		SELECT v.id, v.version, v.dependencies, v.created_at, v.updated_at
+		SELECT v.id, v.version, v.dependencies, v.quality, v.lint_report, v.compilation_info, v.created_at, v.updated_at
 		FROM versions v
 		JOIN modules m ON v.module_id = m.id
 		WHERE m.name = $1 AND v.version = $2
@@ -410,6 +524,7 @@ func (s *PostgresStorage) GetVersionContext(ctx context.Context, moduleName, ver
 
 	var versionID int64
 	var depsJSON string
+	var qualityJSON, lintJSON, compilationJSON []byte
 	var createdAt, updatedAt time.Time
 	var versionStr string
 
@@ -417,6 +532,9 @@ func (s *PostgresStorage) GetVersionContext(ctx context.Context, moduleName, ver
 		&versionID,
 		&versionStr,
 		&depsJSON,
+		&qualityJSON,
+		&lintJSON,
+		&compilationJSON,
 		&createdAt,
 		&updatedAt,
 	)
@@ -440,6 +558,33 @@ func (s *PostgresStorage) GetVersionContext(ctx context.Context, moduleName, ver
 		}
 	}
 
+	var quality *api.QualityScore
+	if len(qualityJSON) > 0 {
+		quality = &api.QualityScore{}
+		if err := json.Unmarshal(qualityJSON, quality); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to parse quality")
+			return nil, fmt.Errorf("failed to parse quality: %w", err)
+		}
+	}
+	var lint *api.LintReport
+	if len(lintJSON) > 0 {
+		lint = &api.LintReport{}
+		if err := json.Unmarshal(lintJSON, lint); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to parse lint report")
+			return nil, fmt.Errorf("failed to parse lint report: %w", err)
+		}
+	}
+	var compilations []api.CompilationInfo
+	if len(compilationJSON) > 0 {
+		if err := json.Unmarshal(compilationJSON, &compilations); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to parse compilation info")
+			return nil, fmt.Errorf("failed to parse compilation info: %w", err)
+		}
+	}
+
 	// Get file metadata
 	fileQuery := `
 		SELECT file_path, content_hash, object_key
@@ -494,11 +639,14 @@ func (s *PostgresStorage) GetVersionContext(ctx context.Context, moduleName, ver
 	}
 
 	result := &api.Version{
-		ModuleName:   moduleName,
-		Version:      versionStr,
-		Files:        files,
-		CreatedAt:    createdAt,
-		Dependencies: dependencies,
+		ModuleName:      moduleName,
+		Version:         versionStr,
+		Files:           files,
+		CreatedAt:       createdAt,
+		Dependencies:    dependencies,
+		Quality:         quality,
+		Lint:            lint,
+		CompilationInfo: compilations,
 	}
 
 	// Cache result
@@ -557,6 +705,69 @@ func (s *PostgresStorage) UpdateVersionContext(ctx context.Context, version *api
 	return fmt.Errorf("not implemented")
 }
 
+// PutCompilation implements api.CompilationUpdater
+func (s *PostgresStorage) PutCompilation(moduleName, version string, info api.CompilationInfo) error {
+	return s.PutCompilationContext(context.Background(), moduleName, version, info)
+}
+
+// PutCompilationContext replaces the compilation of a version with the same
+// language and package name as info, or appends info, in a single UPDATE so
+// compile jobs finishing at the same time for other languages are kept
+func (s *PostgresStorage) PutCompilationContext(ctx context.Context, moduleName, version string, info api.CompilationInfo) error {
+	ctx, span := tracer.Start(ctx, "PutCompilation",
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", "UPDATE"),
+			attribute.String("db.table", "versions"),
+			attribute.String("module.name", moduleName),
+			attribute.String("version", version),
+			attribute.String("language", string(info.Language)),
+		),
+	)
+	defer span.End()
+
+	infoJSON, err := json.Marshal(info)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to encode compilation info")
+		return fmt.Errorf("failed to encode compilation info: %w", err)
+	}
+
+	query := `
+		UPDATE versions v
+		SET compilation_info = COALESCE((
+				SELECT jsonb_agg(CASE WHEN c->>'language' = $3 AND c->>'package_name' = $4 THEN $5::jsonb ELSE c END ORDER BY i)
+				FROM jsonb_array_elements(v.compilation_info) WITH ORDINALITY AS e(c, i)
+			), '[]'::jsonb) || CASE
+				WHEN v.compilation_info @> jsonb_build_array(jsonb_build_object('language', $3::text, 'package_name', $4::text)) THEN '[]'::jsonb
+				ELSE jsonb_build_array($5::jsonb)
+			END,
+			updated_at = NOW()
+		FROM modules m
+		WHERE v.module_id = m.id AND m.name = $1 AND v.version = $2
+		RETURNING v.updated_at
+	`
+
+	var updatedAt time.Time
+	err = s.db.QueryRowContext(ctx, query, moduleName, version, string(info.Language), info.PackageName, infoJSON).Scan(&updatedAt)
+	if err == sql.ErrNoRows {
+		span.SetStatus(codes.Error, "version not found")
+		return fmt.Errorf("version not found: %s@%s", moduleName, version)
+	} else if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to update compilation info")
+		return fmt.Errorf("failed to update compilation info: %w", err)
+	}
+
+	// Invalidate cache
+	if s.redisClient != nil {
+		s.redisClient.InvalidateVersion(ctx, moduleName, version)
+	}
+
+	span.SetStatus(codes.Ok, "compilation info updated successfully")
+	return nil
+}
+
 func (s *PostgresStorage) GetFileContext(ctx context.Context, moduleName, version, path string) (*api.File, error) {
 	if s.s3Client == nil {
 		return nil, fmt.Errorf("s3 client not initialized")
@@ -708,3 +919,4 @@ func (s *PostgresStorage) Close() error {
 
 // Verify that PostgresStorage implements storage.Storage at compile time
 var _ storage.Storage = (*PostgresStorage)(nil)
+var _ api.CompilationUpdater = (*PostgresStorage)(nil)
@@ -2,9 +2,13 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/platinummonkey/spoke/pkg/api"
 )
 
@@ -243,6 +247,42 @@ func TestPostgresStorage_ConnectionConfig(t *testing.T) {
 	})
 }
 
+func TestPostgresStorage_PutCompilation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+	defer db.Close()
+	s := &PostgresStorage{db: db}
+
+	info := api.CompilationInfo{
+		Language:    api.LanguageGo,
+		PackageName: "github.com/example/user",
+		Version:     "v1.0.0",
+		Files:       []api.File{{Path: "user.pb.go", Content: "package user"}},
+	}
+	infoJSON, _ := json.Marshal(info)
+
+	mock.ExpectQuery(`UPDATE versions v\s+SET compilation_info = .*jsonb_array_elements\(v\.compilation_info\)`).
+		WithArgs("test.module", "v1.0.0", "go", "github.com/example/user", infoJSON).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+	if err := s.PutCompilation("test.module", "v1.0.0", info); err != nil {
+		t.Errorf("PutCompilation() error = %v", err)
+	}
+
+	mock.ExpectQuery(`UPDATE versions v`).
+		WithArgs("test.module", "v9.9.9", "go", "github.com/example/user", infoJSON).
+		WillReturnError(sql.ErrNoRows)
+	err = s.PutCompilation("test.module", "v9.9.9", info)
+	if err == nil || !strings.Contains(err.Error(), "version not found") {
+		t.Errorf("PutCompilation() of a missing version error = %v, want version not found", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
 // Note: Integration tests with actual PostgreSQL + S3 would use:
 // - testcontainers for PostgreSQL
 // - testcontainers for MinIO
@@ -60,9 +60,11 @@ func (n *Normalizer) Normalize(ast *protobuf.RootNode) (*protobuf.RootNode, erro
 		Package:         ast.Package,
 		Imports:         n.normalizeImports(ast.Imports),
 		Options:         ast.Options,
+		Features:        ast.Features,
 		Messages:        n.normalizeMessages(ast.Messages),
 		Enums:           n.normalizeEnums(ast.Enums),
 		Services:        n.normalizeServices(ast.Services),
+		Extensions:      ast.Extensions,
 		Comments:        ast.Comments,
 		SpokeDirectives: ast.SpokeDirectives,
 		Pos:             ast.Pos,
@@ -128,6 +130,11 @@ func (n *Normalizer) normalizeMessages(messages []*protobuf.MessageNode) []*prot
 			Nested:          n.normalizeMessages(msg.Nested),
 			Enums:           n.normalizeEnums(msg.Enums),
 			OneOfs:          n.normalizeOneOfs(msg.OneOfs),
+			Extensions:      msg.Extensions,
+			ReservedRanges:  msg.ReservedRanges,
+			ReservedNames:   msg.ReservedNames,
+			ExtensionRanges: msg.ExtensionRanges,
+			Features:        msg.Features,
 			Options:         msg.Options,
 			Comments:        msg.Comments,
 			SpokeDirectives: msg.SpokeDirectives,
@@ -173,6 +180,9 @@ func (n *Normalizer) normalizeEnums(enums []*protobuf.EnumNode) []*protobuf.Enum
 		normalized[i] = &protobuf.EnumNode{
 			Name:            enum.Name,
 			Values:          n.normalizeEnumValues(enum.Values),
+			ReservedRanges:  enum.ReservedRanges,
+			ReservedNames:   enum.ReservedNames,
+			Features:        enum.Features,
 			Options:         enum.Options,
 			Comments:        enum.Comments,
 			SpokeDirectives: enum.SpokeDirectives,
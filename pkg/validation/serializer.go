@@ -25,8 +25,16 @@ func NewSerializer(config *NormalizationConfig) *Serializer {
 func (s *Serializer) Serialize(ast *protobuf.RootNode) (string, error) {
 	var builder strings.Builder
 
-	// Syntax
-	if ast.Syntax != nil {
+	// Syntax or edition. Edition files keep the features they resolve to,
+	// written relative to the file's features.
+	var fileFeatures *protobuf.Features
+	if ast.Syntax.IsEditions() {
+		builder.WriteString(fmt.Sprintf("edition = \"%s\";\n\n", ast.Syntax.Edition))
+		fileFeatures = ast.Features
+		if fileFeatures == nil {
+			fileFeatures = protobuf.DefaultFeatures(protobuf.SyntaxEditions)
+		}
+	} else if ast.Syntax != nil {
 		builder.WriteString(fmt.Sprintf("syntax = \"%s\";\n\n", ast.Syntax.Value))
 	}
 
@@ -44,22 +52,32 @@ func (s *Serializer) Serialize(ast *protobuf.RootNode) (string, error) {
 	}
 
 	// Options
-	for _, opt := range ast.Options {
-		builder.WriteString(fmt.Sprintf("option %s = %s;\n", opt.Name, opt.Value))
+	fileFeatureOptions := featureOptions(fileFeatures, protobuf.DefaultFeatures(protobuf.SyntaxEditions), fileFeatureNames)
+	for _, opt := range fileFeatureOptions {
+		builder.WriteString(fmt.Sprintf("option %s;\n", opt))
+	}
+	for _, opt := range optionAssignments(ast.Options) {
+		builder.WriteString(fmt.Sprintf("option %s;\n", opt))
 	}
-	if len(ast.Options) > 0 {
+	if len(ast.Options) > 0 || len(fileFeatureOptions) > 0 {
 		builder.WriteString("\n")
 	}
 
 	// Messages
 	for _, msg := range ast.Messages {
-		s.writeMessage(&builder, msg, 0)
+		s.writeMessage(&builder, msg, 0, fileFeatures)
+		builder.WriteString("\n")
+	}
+
+	// Extensions
+	for _, ext := range ast.Extensions {
+		s.writeExtend(&builder, ext, 0, fileFeatures)
 		builder.WriteString("\n")
 	}
 
 	// Enums
 	for _, enum := range ast.Enums {
-		s.writeEnum(&builder, enum, 0)
+		s.writeEnum(&builder, enum, 0, fileFeatures)
 		builder.WriteString("\n")
 	}
 
@@ -93,82 +111,168 @@ func (s *Serializer) writeImport(builder *strings.Builder, imp *protobuf.ImportN
 	builder.WriteString(fmt.Sprintf("import %s\"%s\";\n", modifier, imp.Path))
 }
 
-func (s *Serializer) writeMessage(builder *strings.Builder, msg *protobuf.MessageNode, depth int) {
+func (s *Serializer) writeMessage(builder *strings.Builder, msg *protobuf.MessageNode, depth int, fileFeatures *protobuf.Features) {
 	indent := s.indentation(depth)
 
 	// Message declaration
 	builder.WriteString(fmt.Sprintf("%smessage %s {\n", indent, msg.Name))
 
+	// Reserved and extension ranges
+	s.writeReserved(builder, depth+1, msg.ReservedRanges, msg.ReservedNames)
+	if len(msg.ExtensionRanges) > 0 {
+		builder.WriteString(fmt.Sprintf("%s%sextensions %s;\n", indent, s.indent, joinRanges(msg.ExtensionRanges)))
+	}
+
 	// Nested enums
 	for _, enum := range msg.Enums {
-		s.writeEnum(builder, enum, depth+1)
+		s.writeEnum(builder, enum, depth+1, fileFeatures)
 	}
 
 	// Nested messages
 	for _, nested := range msg.Nested {
-		s.writeMessage(builder, nested, depth+1)
+		s.writeMessage(builder, nested, depth+1, fileFeatures)
 	}
 
 	// OneOfs
 	for _, oneof := range msg.OneOfs {
-		s.writeOneOf(builder, oneof, depth+1)
+		s.writeOneOf(builder, oneof, depth+1, fileFeatures)
 	}
 
 	// Fields
 	for _, field := range msg.Fields {
-		s.writeField(builder, field, depth+1)
+		s.writeField(builder, field, depth+1, fileFeatures)
+	}
+
+	// Nested extensions
+	for _, ext := range msg.Extensions {
+		s.writeExtend(builder, ext, depth+1, fileFeatures)
 	}
 
 	// Options
-	for _, opt := range msg.Options {
-		builder.WriteString(fmt.Sprintf("%s%soption %s = %s;\n", indent, s.indent, opt.Name, opt.Value))
+	for _, opt := range featureOptions(msg.Features, fileFeatures, []string{"json_format"}) {
+		builder.WriteString(fmt.Sprintf("%s%soption %s;\n", indent, s.indent, opt))
+	}
+	for _, opt := range optionAssignments(msg.Options) {
+		builder.WriteString(fmt.Sprintf("%s%soption %s;\n", indent, s.indent, opt))
 	}
 
 	builder.WriteString(fmt.Sprintf("%s}\n", indent))
 }
 
-func (s *Serializer) writeField(builder *strings.Builder, field *protobuf.FieldNode, depth int) {
+func (s *Serializer) writeField(builder *strings.Builder, field *protobuf.FieldNode, depth int, fileFeatures *protobuf.Features) {
 	indent := s.indentation(depth)
 
 	// Field label
 	label := ""
-	if field.Repeated {
+	switch {
+	case field.Repeated:
 		label = "repeated "
-	} else if field.Optional {
+	case fileFeatures != nil:
+		// Edition files express optional and required through field presence
+	case field.Optional:
 		label = "optional "
-	} else if field.Required {
+	case field.Required:
 		label = "required "
 	}
 
-	builder.WriteString(fmt.Sprintf("%s%s%s %s = %d;\n",
-		indent, label, field.Type, field.Name, field.Number))
+	options := ""
+	opts := append(featureOptions(field.Features, fileFeatures, fieldFeatureNames), optionAssignments(field.Options)...)
+	if len(opts) > 0 {
+		options = fmt.Sprintf(" [%s]", strings.Join(opts, ", "))
+	}
+
+	builder.WriteString(fmt.Sprintf("%s%s%s %s = %d%s;\n",
+		indent, label, field.Type, field.Name, field.Number, options))
 }
 
-func (s *Serializer) writeOneOf(builder *strings.Builder, oneof *protobuf.OneOfNode, depth int) {
+func (s *Serializer) writeExtend(builder *strings.Builder, ext *protobuf.ExtendNode, depth int, fileFeatures *protobuf.Features) {
+	indent := s.indentation(depth)
+
+	builder.WriteString(fmt.Sprintf("%sextend %s {\n", indent, ext.Extendee))
+	for _, field := range ext.Fields {
+		s.writeField(builder, field, depth+1, fileFeatures)
+	}
+	builder.WriteString(fmt.Sprintf("%s}\n", indent))
+}
+
+func (s *Serializer) writeReserved(builder *strings.Builder, depth int, ranges []protobuf.Range, names []string) {
+	indent := s.indentation(depth)
+
+	if len(ranges) > 0 {
+		builder.WriteString(fmt.Sprintf("%sreserved %s;\n", indent, joinRanges(ranges)))
+	}
+	if len(names) > 0 {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = fmt.Sprintf("%q", name)
+		}
+		builder.WriteString(fmt.Sprintf("%sreserved %s;\n", indent, strings.Join(quoted, ", ")))
+	}
+}
+
+func joinRanges(ranges []protobuf.Range) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// optionAssignments returns "name = value" for each option. Options parsed
+// with a TypedValue are rendered in source syntax, with one assignment per
+// value of repeated options; others are written with Value as given.
+func optionAssignments(options []*protobuf.OptionNode) []string {
+	var assignments []string
+	for _, opt := range options {
+		switch typed := opt.TypedValue.(type) {
+		case nil:
+			assignments = append(assignments, fmt.Sprintf("%s = %s", opt.Name, opt.Value))
+		case []interface{}:
+			for _, item := range typed {
+				assignments = append(assignments, fmt.Sprintf("%s = %s", opt.Name, protobuf.FormatOptionValue(item)))
+			}
+		default:
+			assignments = append(assignments, fmt.Sprintf("%s = %s", opt.Name, protobuf.FormatOptionValue(typed)))
+		}
+	}
+	return assignments
+}
+
+func (s *Serializer) writeOneOf(builder *strings.Builder, oneof *protobuf.OneOfNode, depth int, fileFeatures *protobuf.Features) {
 	indent := s.indentation(depth)
 
 	builder.WriteString(fmt.Sprintf("%soneof %s {\n", indent, oneof.Name))
 
 	for _, field := range oneof.Fields {
-		s.writeField(builder, field, depth+1)
+		s.writeField(builder, field, depth+1, fileFeatures)
 	}
 
 	builder.WriteString(fmt.Sprintf("%s}\n", indent))
 }
 
-func (s *Serializer) writeEnum(builder *strings.Builder, enum *protobuf.EnumNode, depth int) {
+func (s *Serializer) writeEnum(builder *strings.Builder, enum *protobuf.EnumNode, depth int, fileFeatures *protobuf.Features) {
 	indent := s.indentation(depth)
 
 	builder.WriteString(fmt.Sprintf("%senum %s {\n", indent, enum.Name))
 
+	for _, opt := range featureOptions(enum.Features, fileFeatures, []string{"enum_type", "json_format"}) {
+		builder.WriteString(fmt.Sprintf("%s%soption %s;\n", indent, s.indent, opt))
+	}
+
 	for _, value := range enum.Values {
-		builder.WriteString(fmt.Sprintf("%s%s%s = %d;\n",
-			indent, s.indent, value.Name, value.Number))
+		options := ""
+		if opts := optionAssignments(value.Options); len(opts) > 0 {
+			options = fmt.Sprintf(" [%s]", strings.Join(opts, ", "))
+		}
+		builder.WriteString(fmt.Sprintf("%s%s%s = %d%s;\n",
+			indent, s.indent, value.Name, value.Number, options))
 	}
 
+	s.writeReserved(builder, depth+1, enum.ReservedRanges, enum.ReservedNames)
+
 	// Options
-	for _, opt := range enum.Options {
-		builder.WriteString(fmt.Sprintf("%s%soption %s = %s;\n", indent, s.indent, opt.Name, opt.Value))
+	for _, opt := range optionAssignments(enum.Options) {
+		builder.WriteString(fmt.Sprintf("%s%soption %s;\n", indent, s.indent, opt))
 	}
 
 	builder.WriteString(fmt.Sprintf("%s}\n", indent))
@@ -182,8 +286,8 @@ func (s *Serializer) writeService(builder *strings.Builder, svc *protobuf.Servic
 	}
 
 	// Options
-	for _, opt := range svc.Options {
-		builder.WriteString(fmt.Sprintf("%soption %s = %s;\n", s.indent, opt.Name, opt.Value))
+	for _, opt := range optionAssignments(svc.Options) {
+		builder.WriteString(fmt.Sprintf("%soption %s;\n", s.indent, opt))
 	}
 
 	builder.WriteString("}\n")
@@ -204,6 +308,40 @@ func (s *Serializer) writeRPC(builder *strings.Builder, rpc *protobuf.RPCNode) {
 		s.indent, rpc.Name, inputType, outputType))
 }
 
+var (
+	fileFeatureNames  = []string{"field_presence", "enum_type", "repeated_field_encoding", "utf8_validation", "message_encoding", "json_format"}
+	fieldFeatureNames = []string{"field_presence", "repeated_field_encoding", "utf8_validation", "message_encoding"}
+)
+
+// featureOptions returns "features.<name> = <VALUE>" for each named feature
+// that differs from base. It returns nothing outside edition files, where
+// base is nil.
+func featureOptions(features, base *protobuf.Features, names []string) []string {
+	if features == nil || base == nil {
+		return nil
+	}
+
+	values := func(f *protobuf.Features) map[string]string {
+		return map[string]string{
+			"field_presence":          f.FieldPresence,
+			"enum_type":               f.EnumType,
+			"repeated_field_encoding": f.RepeatedFieldEncoding,
+			"utf8_validation":         f.UTF8Validation,
+			"message_encoding":        f.MessageEncoding,
+			"json_format":             f.JSONFormat,
+		}
+	}
+	own, inherited := values(features), values(base)
+
+	var options []string
+	for _, name := range names {
+		if own[name] != "" && own[name] != inherited[name] {
+			options = append(options, fmt.Sprintf("features.%s = %s", name, own[name]))
+		}
+	}
+	return options
+}
+
 func (s *Serializer) indentation(depth int) string {
 	if depth == 0 {
 		return ""
@@ -891,3 +891,153 @@ func TestSerializer_MultipleMessagesAndEnums(t *testing.T) {
 		}
 	}
 }
+
+func TestSerializer_EditionsRoundTrip(t *testing.T) {
+	content := `edition = "2023";
+
+package example.v1;
+
+option features.field_presence = IMPLICIT;
+
+message User {
+  string name = 1;
+  int32 age = 2 [features.field_presence = EXPLICIT];
+  repeated int32 scores = 3 [features.repeated_field_encoding = EXPANDED];
+  int32 id = 4 [features.field_presence = LEGACY_REQUIRED];
+}
+
+enum Status {
+  option features.enum_type = CLOSED;
+  STATUS_UNSPECIFIED = 0;
+}
+`
+	ast, err := protobuf.ParseWithDescriptor("user.proto", content)
+	if err != nil {
+		t.Fatalf("ParseWithDescriptor() error = %v", err)
+	}
+
+	normalized, err := NewNormalizer(DefaultNormalizationConfig()).Normalize(ast)
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	result, err := NewSerializer(DefaultNormalizationConfig()).Serialize(normalized)
+	if err != nil {
+		t.Fatalf("Serialize() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`edition = "2023";`,
+		"option features.field_presence = IMPLICIT;",
+		"string name = 1;",
+		"int32 age = 2 [features.field_presence = EXPLICIT];",
+		"repeated int32 scores = 3 [features.repeated_field_encoding = EXPANDED];",
+		"int32 id = 4 [features.field_presence = LEGACY_REQUIRED];",
+		"option features.enum_type = CLOSED;",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Serialize() missing %q in:\n%s", want, result)
+		}
+	}
+	for _, unwanted := range []string{"syntax =", "optional ", "required "} {
+		if strings.Contains(result, unwanted) {
+			t.Errorf("Serialize() should not contain %q in:\n%s", unwanted, result)
+		}
+	}
+
+	// The serialized file must compile to the same features
+	reparsed, err := protobuf.ParseWithDescriptor("user.proto", result)
+	if err != nil {
+		t.Fatalf("re-parse error = %v\n%s", err, result)
+	}
+	for i, field := range ast.Messages[0].Fields {
+		if *reparsed.Messages[0].Fields[i].Features != *field.Features {
+			t.Errorf("field %s features = %+v, want %+v", field.Name, reparsed.Messages[0].Fields[i].Features, field.Features)
+		}
+	}
+	if *reparsed.Enums[0].Features != *ast.Enums[0].Features {
+		t.Errorf("enum features = %+v, want %+v", reparsed.Enums[0].Features, ast.Enums[0].Features)
+	}
+}
+
+func TestSerializer_ExtensionsAndReservedRoundTrip(t *testing.T) {
+	content := `syntax = "proto2";
+
+package shop.v1;
+
+import "google/protobuf/descriptor.proto";
+
+enum Level {
+  LEVEL_LOW = 0;
+  LEVEL_HIGH = 1;
+}
+
+extend google.protobuf.FieldOptions {
+  optional bool sensitive = 50001;
+  repeated string tags = 50002;
+  optional Level level = 50003;
+}
+
+extend google.protobuf.FileOptions {
+  optional string team = 50004;
+}
+
+option (team) = "payments";
+
+message Order {
+  reserved 2, 10 to 20;
+  reserved "legacy";
+  extensions 100 to 199;
+
+  optional string id = 1 [deprecated = true, (sensitive) = true, (tags) = "a", (tags) = "b", (level) = LEVEL_HIGH];
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OLD = 1 [deprecated = true];
+  reserved 5 to 7;
+  reserved "STATUS_GONE";
+}
+`
+	ast, err := protobuf.ParseWithDescriptor("shop/order.proto", content)
+	if err != nil {
+		t.Fatalf("ParseWithDescriptor() error = %v", err)
+	}
+
+	normalized, err := NewNormalizer(DefaultNormalizationConfig()).Normalize(ast)
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	result, err := NewSerializer(DefaultNormalizationConfig()).Serialize(normalized)
+	if err != nil {
+		t.Fatalf("Serialize() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`option (shop.v1.team) = "payments";`,
+		"extend google.protobuf.FieldOptions {",
+		"reserved 2, 10 to 20;",
+		`reserved "legacy";`,
+		"extensions 100 to 199;",
+		`optional string id = 1 [deprecated = true, (shop.v1.sensitive) = true, (shop.v1.tags) = "a", (shop.v1.tags) = "b", (shop.v1.level) = LEVEL_HIGH];`,
+		"STATUS_OLD = 1 [deprecated = true];",
+		"reserved 5 to 7;",
+		`reserved "STATUS_GONE";`,
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Serialize() missing %q in:\n%s", want, result)
+		}
+	}
+
+	// The serialized file must compile to the same declarations
+	reparsed, err := protobuf.ParseWithDescriptor("shop/order.proto", result)
+	if err != nil {
+		t.Fatalf("re-parse error = %v\n%s", err, result)
+	}
+	order := reparsed.Messages[0]
+	if len(order.ReservedRanges) != 2 || len(order.ExtensionRanges) != 1 || len(order.Fields[0].Options) != 4 {
+		t.Errorf("re-parsed Order = %+v", order)
+	}
+	if len(reparsed.Extensions) != 2 {
+		t.Errorf("re-parsed extensions = %d, want 2", len(reparsed.Extensions))
+	}
+}
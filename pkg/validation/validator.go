@@ -74,6 +74,35 @@ func (s Severity) String() string {
 	return []string{"ERROR", "WARNING", "INFO"}[s]
 }
 
+// knownRules are the names of the rules the validator reports
+var knownRules = map[string]bool{
+	"DELIMITED_MESSAGE_ENCODING":  true,
+	"DUPLICATE_ENUM_VALUE_NUMBER": true,
+	"DUPLICATE_FIELD_NUMBER":      true,
+	"ENUM_NAME_CONVENTION":        true,
+	"ENUM_VALUE_NAME_CONVENTION":  true,
+	"FIELD_IN_EXTENSION_RANGE":    true,
+	"FIELD_NAME_CONVENTION":       true,
+	"INVALID_FIELD_NUMBER":        true,
+	"INVALID_PACKAGE_NAME":        true,
+	"LEGACY_REQUIRED_FIELD":       true,
+	"MESSAGE_NAME_CONVENTION":     true,
+	"MISSING_EDITION":             true,
+	"MISSING_ENUM_ZERO_VALUE":     true,
+	"RESERVED_FIELD_NUMBER":       true,
+	"RESERVED_NAME_USED":          true,
+	"RESERVED_NUMBER_USED":        true,
+	"RPC_NAME_CONVENTION":         true,
+	"SERVICE_NAME_CONVENTION":     true,
+	"UNSUPPORTED_EDITION":         true,
+	"UNUSED_IMPORT":               true,
+}
+
+// IsRule reports whether name is a rule the validator reports
+func IsRule(name string) bool {
+	return knownRules[name]
+}
+
 // ValidationResult contains validation errors
 type ValidationResult struct {
 	Errors   []*ValidationError
@@ -89,6 +118,11 @@ func (v *Validator) Validate(ast *protobuf.RootNode) *ValidationResult {
 		Valid:    true,
 	}
 
+	// Check the edition of edition files
+	if ast.Syntax.IsEditions() {
+		v.validateEdition(ast.Syntax, result)
+	}
+
 	// Check package naming
 	if ast.Package != nil {
 		v.validatePackageName(ast.Package, result)
@@ -96,7 +130,7 @@ func (v *Validator) Validate(ast *protobuf.RootNode) *ValidationResult {
 
 	// Validate messages
 	for _, msg := range ast.Messages {
-		v.validateMessage(msg, "", result)
+		v.validateMessage(msg, "", ast.Syntax, result)
 	}
 
 	// Validate enums
@@ -120,6 +154,17 @@ func (v *Validator) Validate(ast *protobuf.RootNode) *ValidationResult {
 	return result
 }
 
+func (v *Validator) validateEdition(syntax *protobuf.SyntaxNode, result *ValidationResult) {
+	if syntax.Edition == "" {
+		result.addError("edition", "MISSING_EDITION", "Edition syntax requires an edition (e.g., edition = \"2023\")")
+		return
+	}
+	if !protobuf.IsSupportedEdition(syntax.Edition) {
+		result.addError("edition", "UNSUPPORTED_EDITION",
+			fmt.Sprintf("Edition %q is not supported (supported: %s)", syntax.Edition, strings.Join(protobuf.SupportedEditions, ", ")))
+	}
+}
+
 func (v *Validator) validatePackageName(pkg *protobuf.PackageNode, result *ValidationResult) {
 	if !v.config.CheckNamingConventions {
 		return
@@ -132,7 +177,7 @@ func (v *Validator) validatePackageName(pkg *protobuf.PackageNode, result *Valid
 	}
 }
 
-func (v *Validator) validateMessage(msg *protobuf.MessageNode, parentName string, result *ValidationResult) {
+func (v *Validator) validateMessage(msg *protobuf.MessageNode, parentName string, syntax *protobuf.SyntaxNode, result *ValidationResult) {
 	fullName := msg.Name
 	if parentName != "" {
 		fullName = parentName + "." + msg.Name
@@ -150,6 +195,9 @@ func (v *Validator) validateMessage(msg *protobuf.MessageNode, parentName string
 	// Validate fields
 	for _, field := range msg.Fields {
 		v.validateField(field, fullName, fieldNumbers, result)
+		if syntax.IsEditions() {
+			v.validateFieldFeatures(field, fullName, result)
+		}
 	}
 
 	// Validate oneofs
@@ -159,9 +207,23 @@ func (v *Validator) validateMessage(msg *protobuf.MessageNode, parentName string
 		}
 	}
 
+	// Check fields against reservations and extension ranges
+	if v.config.CheckReservedFields {
+		for _, field := range msg.Fields {
+			location := fmt.Sprintf("%s.%s", fullName, field.Name)
+			v.validateReserved(location, field.Name, field.Number, msg.ReservedRanges, msg.ReservedNames, result)
+			for _, r := range msg.ExtensionRanges {
+				if r.Contains(field.Number) {
+					result.addError(location, "FIELD_IN_EXTENSION_RANGE",
+						fmt.Sprintf("Field number %d is inside extension range %s", field.Number, r))
+				}
+			}
+		}
+	}
+
 	// Validate nested messages
 	for _, nested := range msg.Nested {
-		v.validateMessage(nested, fullName, result)
+		v.validateMessage(nested, fullName, syntax, result)
 	}
 
 	// Validate nested enums
@@ -207,6 +269,21 @@ func (v *Validator) validateField(field *protobuf.FieldNode, parentName string,
 	}
 }
 
+// validateFieldFeatures flags legacy features in edition files, which exist
+// only to migrate proto2 schemas
+func (v *Validator) validateFieldFeatures(field *protobuf.FieldNode, parentName string, result *ValidationResult) {
+	location := fmt.Sprintf("%s.%s", parentName, field.Name)
+
+	if field.Required {
+		result.addWarning(location, "LEGACY_REQUIRED_FIELD",
+			fmt.Sprintf("Field %q uses LEGACY_REQUIRED presence; required fields cannot be removed safely", field.Name))
+	}
+	if field.Features != nil && field.Features.MessageEncoding == protobuf.MessageEncodingDelimited {
+		result.addWarning(location, "DELIMITED_MESSAGE_ENCODING",
+			fmt.Sprintf("Field %q uses DELIMITED message encoding, which is only meant for migrated groups", field.Name))
+	}
+}
+
 func (v *Validator) validateEnum(enum *protobuf.EnumNode, parentName string, result *ValidationResult) {
 	fullName := enum.Name
 	if parentName != "" {
@@ -219,8 +296,8 @@ func (v *Validator) validateEnum(enum *protobuf.EnumNode, parentName string, res
 			fmt.Sprintf("Enum name %q should be PascalCase", enum.Name))
 	}
 
-	// Check for zero value
-	if v.config.RequireEnumZeroValue {
+	// Check for zero value; closed enums default to their first value
+	if v.config.RequireEnumZeroValue && enum.IsOpenEnum() {
 		hasZero := false
 		for _, value := range enum.Values {
 			if value.Number == 0 {
@@ -230,7 +307,7 @@ func (v *Validator) validateEnum(enum *protobuf.EnumNode, parentName string, res
 		}
 		if !hasZero {
 			result.addError(fullName, "MISSING_ENUM_ZERO_VALUE",
-				"Enum must have a zero value (required in proto3)")
+				"Enum must have a zero value (required for open enums)")
 		}
 	}
 
@@ -247,6 +324,10 @@ func (v *Validator) validateEnum(enum *protobuf.EnumNode, parentName string, res
 				fmt.Sprintf("Enum value %q should be UPPER_SNAKE_CASE", value.Name))
 		}
 
+		if v.config.CheckReservedFields {
+			v.validateReserved(location, value.Name, value.Number, enum.ReservedRanges, enum.ReservedNames, result)
+		}
+
 		// Check for duplicate numbers
 		if existingValue, exists := valueNumbers[value.Number]; exists {
 			result.addError(location, "DUPLICATE_ENUM_VALUE_NUMBER",
@@ -257,6 +338,23 @@ func (v *Validator) validateEnum(enum *protobuf.EnumNode, parentName string, res
 	}
 }
 
+// validateReserved reports a field or enum value that uses a reserved number
+// or name. The parser rejects these, so this catches ASTs edited in code.
+func (v *Validator) validateReserved(location, name string, number int, ranges []protobuf.Range, names []string, result *ValidationResult) {
+	for _, r := range ranges {
+		if r.Contains(number) {
+			result.addError(location, "RESERVED_NUMBER_USED",
+				fmt.Sprintf("Number %d of %q is reserved (%s)", number, name, r))
+		}
+	}
+	for _, reserved := range names {
+		if reserved == name {
+			result.addError(location, "RESERVED_NAME_USED",
+				fmt.Sprintf("Name %q is reserved", name))
+		}
+	}
+}
+
 func (v *Validator) validateService(svc *protobuf.ServiceNode, result *ValidationResult) {
 	// Check service naming convention
 	if v.config.CheckNamingConventions && !isPascalCase(svc.Name) {
@@ -275,11 +373,16 @@ func (v *Validator) validateService(svc *protobuf.ServiceNode, result *Validatio
 	}
 }
 
+// detectUnusedImports warns about imports no type or option of the file
+// uses. Only imports whose declarations are known, the well-known types and
+// google.api annotations, can be judged from a single file.
 func (v *Validator) detectUnusedImports(ast *protobuf.RootNode, result *ValidationResult) {
-	// TODO: Implement unused import detection
-	// This requires tracking type usage throughout the file
-	_ = ast
-	_ = result
+	const path = "input.proto"
+	table := protobuf.NewSymbolTable(map[string]*protobuf.RootNode{path: ast})
+	for _, imp := range table.UnusedImports(path) {
+		result.addWarning(imp.Path, "UNUSED_IMPORT",
+			fmt.Sprintf("Import %q is not used", imp.Path))
+	}
 }
 
 func (r *ValidationResult) addError(location, rule, message string) {
@@ -359,3 +359,186 @@ func TestValidationResult_Summary(t *testing.T) {
 		t.Error("Result should not be valid with errors")
 	}
 }
+
+func TestValidator_Editions(t *testing.T) {
+	validator := NewValidator(DefaultValidationConfig())
+
+	tests := []struct {
+		name         string
+		syntax       *protobuf.SyntaxNode
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name:         "supported edition",
+			syntax:       &protobuf.SyntaxNode{Value: protobuf.SyntaxEditions, Edition: "2023"},
+			wantWarnings: []string{"LEGACY_REQUIRED_FIELD", "DELIMITED_MESSAGE_ENCODING"},
+		},
+		{
+			name:         "unsupported edition",
+			syntax:       &protobuf.SyntaxNode{Value: protobuf.SyntaxEditions, Edition: "2099"},
+			wantErrors:   []string{"UNSUPPORTED_EDITION"},
+			wantWarnings: []string{"LEGACY_REQUIRED_FIELD", "DELIMITED_MESSAGE_ENCODING"},
+		},
+		{
+			name:         "missing edition",
+			syntax:       &protobuf.SyntaxNode{Value: protobuf.SyntaxEditions},
+			wantErrors:   []string{"MISSING_EDITION"},
+			wantWarnings: []string{"LEGACY_REQUIRED_FIELD", "DELIMITED_MESSAGE_ENCODING"},
+		},
+		{
+			name:   "proto2 required fields are not flagged",
+			syntax: &protobuf.SyntaxNode{Value: protobuf.SyntaxProto2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast := &protobuf.RootNode{
+				Syntax: tt.syntax,
+				Messages: []*protobuf.MessageNode{
+					{
+						Name: "Order",
+						Fields: []*protobuf.FieldNode{
+							{Name: "id", Type: "int64", Number: 1, Required: true},
+							{
+								Name: "item", Type: "Item", Number: 2,
+								Features: &protobuf.Features{MessageEncoding: protobuf.MessageEncodingDelimited},
+							},
+						},
+					},
+				},
+			}
+
+			result := validator.Validate(ast)
+
+			if got := rules(result.Errors); !equalRules(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := rules(result.Warnings); !equalRules(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestValidator_ClosedEnumZeroValue(t *testing.T) {
+	validator := NewValidator(DefaultValidationConfig())
+
+	ast := &protobuf.RootNode{
+		Enums: []*protobuf.EnumNode{
+			{
+				Name:     "Status",
+				Features: &protobuf.Features{EnumType: protobuf.EnumTypeClosed},
+				Values:   []*protobuf.EnumValueNode{{Name: "STATUS_ACTIVE", Number: 1}},
+			},
+		},
+	}
+
+	result := validator.Validate(ast)
+	if len(result.Errors) > 0 {
+		t.Errorf("closed enums do not need a zero value, got %v", rules(result.Errors))
+	}
+}
+
+func rules(errs []*ValidationError) []string {
+	var names []string
+	for _, err := range errs {
+		names = append(names, err.Rule)
+	}
+	return names
+}
+
+func equalRules(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestValidator_Reserved(t *testing.T) {
+	validator := NewValidator(DefaultValidationConfig())
+
+	ast := &protobuf.RootNode{
+		Messages: []*protobuf.MessageNode{
+			{
+				Name: "Order",
+				Fields: []*protobuf.FieldNode{
+					{Name: "id", Number: 1},
+					{Name: "legacy", Number: 3},
+					{Name: "rank", Number: 100},
+				},
+				ReservedRanges:  []protobuf.Range{{Start: 2, End: 5}},
+				ReservedNames:   []string{"legacy"},
+				ExtensionRanges: []protobuf.Range{{Start: 100, End: 199}},
+			},
+		},
+		Enums: []*protobuf.EnumNode{
+			{
+				Name: "Status",
+				Values: []*protobuf.EnumValueNode{
+					{Name: "STATUS_UNSPECIFIED", Number: 0},
+					{Name: "STATUS_OLD", Number: 1},
+				},
+				ReservedRanges: []protobuf.Range{{Start: 1, End: 1}},
+			},
+		},
+	}
+
+	result := validator.Validate(ast)
+	want := []string{"RESERVED_NUMBER_USED", "RESERVED_NAME_USED", "FIELD_IN_EXTENSION_RANGE", "RESERVED_NUMBER_USED"}
+	if got := rules(result.Errors); !equalRules(got, want) {
+		t.Errorf("errors = %v, want %v", got, want)
+	}
+
+	config := DefaultValidationConfig()
+	config.CheckReservedFields = false
+	if result := NewValidator(config).Validate(ast); len(result.Errors) != 0 {
+		t.Errorf("errors with CheckReservedFields disabled = %v", rules(result.Errors))
+	}
+}
+
+func TestValidator_UnusedImports(t *testing.T) {
+	ast, err := protobuf.ParseString(`syntax = "proto3";
+package acme.v1;
+
+import "google/protobuf/timestamp.proto";
+import "google/protobuf/duration.proto";
+import "acme/v1/common.proto";
+
+message Order {
+  google.protobuf.Timestamp created = 1;
+}
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	result := NewValidator(DefaultValidationConfig()).Validate(ast)
+	if got := rules(result.Warnings); !equalRules(got, []string{"UNUSED_IMPORT"}) {
+		t.Fatalf("warnings = %v, want [UNUSED_IMPORT]", got)
+	}
+	if result.Warnings[0].Location != "google/protobuf/duration.proto" {
+		t.Errorf("location = %q, want the duration import", result.Warnings[0].Location)
+	}
+
+	config := DefaultValidationConfig()
+	config.DetectUnusedImports = false
+	if result := NewValidator(config).Validate(ast); len(result.Warnings) != 0 {
+		t.Errorf("warnings with DetectUnusedImports disabled = %v", rules(result.Warnings))
+	}
+}
+
+func TestIsRule(t *testing.T) {
+	if !IsRule("MESSAGE_NAME_CONVENTION") {
+		t.Error("Expected MESSAGE_NAME_CONVENTION to be a rule")
+	}
+	if IsRule("MESSAGE_NAME_CONVENTON") {
+		t.Error("Expected MESSAGE_NAME_CONVENTON not to be a rule")
+	}
+}